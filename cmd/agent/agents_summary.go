@@ -0,0 +1,145 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	cloud "github.com/calyptia/api/types"
+
+	"github.com/calyptia/cli/formatters"
+)
+
+const unknownSummaryKey = "unknown"
+
+// agentsSummary aggregate statistics used to plan fleet-wide upgrades.
+type agentsSummary struct {
+	Total      int            `json:"total" yaml:"total"`
+	ByVersion  []summaryCount `json:"byVersion" yaml:"byVersion"`
+	ByPlatform []summaryCount `json:"byPlatform" yaml:"byPlatform"`
+	ByFleet    []summaryCount `json:"byFleet" yaml:"byFleet"`
+	ByStatus   []summaryCount `json:"byStatus" yaml:"byStatus"`
+	Newest     *summaryAgent  `json:"newest,omitempty" yaml:"newest,omitempty"`
+	Oldest     *summaryAgent  `json:"oldest,omitempty" yaml:"oldest,omitempty"`
+}
+
+type summaryCount struct {
+	Key   string `json:"key" yaml:"key"`
+	Count int    `json:"count" yaml:"count"`
+}
+
+type summaryAgent struct {
+	Name      string    `json:"name" yaml:"name"`
+	Version   string    `json:"version" yaml:"version"`
+	CreatedAt time.Time `json:"createdAt" yaml:"createdAt"`
+}
+
+func summarizeAgents(agents []cloud.Agent, now time.Time) agentsSummary {
+	out := agentsSummary{Total: len(agents)}
+
+	versions := map[string]int{}
+	platforms := map[string]int{}
+	fleets := map[string]int{}
+	statuses := map[string]int{}
+	for _, a := range agents {
+		versions[orUnknown(a.Version)]++
+		platforms[agentPlatform(a)]++
+
+		fleet := "none"
+		if a.FleetID != nil && *a.FleetID != "" {
+			fleet = *a.FleetID
+		}
+		fleets[fleet]++
+
+		status := "active"
+		if a.LastMetricsAddedAt == nil || a.LastMetricsAddedAt.Before(now.Add(time.Minute*-5)) {
+			status = "inactive"
+		}
+		statuses[status]++
+
+		if out.Newest == nil || a.CreatedAt.After(out.Newest.CreatedAt) {
+			out.Newest = &summaryAgent{Name: a.Name, Version: a.Version, CreatedAt: a.CreatedAt}
+		}
+		if out.Oldest == nil || a.CreatedAt.Before(out.Oldest.CreatedAt) {
+			out.Oldest = &summaryAgent{Name: a.Name, Version: a.Version, CreatedAt: a.CreatedAt}
+		}
+	}
+
+	out.ByVersion = sortedCounts(versions)
+	out.ByPlatform = sortedCounts(platforms)
+	out.ByFleet = sortedCounts(fleets)
+	out.ByStatus = sortedCounts(statuses)
+
+	return out
+}
+
+// agentPlatform returns the os/arch pair reported in the agent metadata.
+func agentPlatform(agent cloud.Agent) string {
+	var metadata struct {
+		OS   string `json:"os"`
+		Arch string `json:"arch"`
+	}
+	if agent.Metadata != nil {
+		_ = json.Unmarshal(*agent.Metadata, &metadata)
+	}
+
+	if metadata.OS == "" && metadata.Arch == "" {
+		return unknownSummaryKey
+	}
+
+	return orUnknown(metadata.OS) + "/" + orUnknown(metadata.Arch)
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return unknownSummaryKey
+	}
+	return s
+}
+
+// sortedCounts from the most to the least common key.
+func sortedCounts(m map[string]int) []summaryCount {
+	out := make([]summaryCount, 0, len(m))
+	for k, v := range m {
+		out = append(out, summaryCount{Key: k, Count: v})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+func renderAgentsSummary(w io.Writer, summary agentsSummary) error {
+	fmt.Fprintf(w, "Agents: %d\n", summary.Total)
+	if summary.Total == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(w, "Newest: %s (%s, registered %s)\n", summary.Newest.Name, orUnknown(summary.Newest.Version), formatters.FmtTime(summary.Newest.CreatedAt))
+	fmt.Fprintf(w, "Oldest: %s (%s, registered %s)\n", summary.Oldest.Name, orUnknown(summary.Oldest.Version), formatters.FmtTime(summary.Oldest.CreatedAt))
+
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	sections := []struct {
+		title  string
+		counts []summaryCount
+	}{
+		{title: "STATUS", counts: summary.ByStatus},
+		{title: "VERSION", counts: summary.ByVersion},
+		{title: "OS/ARCH", counts: summary.ByPlatform},
+		{title: "FLEET-ID", counts: summary.ByFleet},
+	}
+	for _, sec := range sections {
+		fmt.Fprintln(tw)
+		fmt.Fprintf(tw, "%s\tCOUNT\tPERCENT\n", sec.title)
+		for _, c := range sec.counts {
+			fmt.Fprintf(tw, "%s\t%d\t%.1f%%\n", c.Key, c.Count, float64(c.Count)*100/float64(summary.Total))
+		}
+	}
+	return tw.Flush()
+}
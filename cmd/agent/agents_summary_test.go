@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+func Test_summarizeAgents(t *testing.T) {
+	now := time.Now()
+	ptr := func(v time.Time) *time.Time { return &v }
+	metadata := func(s string) *json.RawMessage {
+		m := json.RawMessage(s)
+		return &m
+	}
+	fleetID := "fleet-a"
+
+	agents := []cloud.Agent{
+		{Name: "a", Version: "2.1.0", FleetID: &fleetID, Metadata: metadata(`{"os":"linux","arch":"amd64"}`), LastMetricsAddedAt: ptr(now), CreatedAt: now.Add(-time.Hour)},
+		{Name: "b", Version: "2.1.0", Metadata: metadata(`{"os":"linux","arch":"arm64"}`), LastMetricsAddedAt: ptr(now.Add(-time.Hour)), CreatedAt: now.Add(-time.Hour * 48)},
+		{Name: "c", Version: "1.9.9", FleetID: &fleetID, Metadata: metadata(`{"os":"linux","arch":"amd64"}`), CreatedAt: now},
+		{Name: "d", CreatedAt: now.Add(-time.Minute)},
+	}
+
+	got := summarizeAgents(agents, now)
+
+	if got.Total != 4 {
+		t.Errorf("want total 4, got %d", got.Total)
+	}
+
+	want := map[string][]summaryCount{
+		"version":  {{Key: "2.1.0", Count: 2}, {Key: "1.9.9", Count: 1}, {Key: "unknown", Count: 1}},
+		"platform": {{Key: "linux/amd64", Count: 2}, {Key: "linux/arm64", Count: 1}, {Key: "unknown", Count: 1}},
+		"fleet":    {{Key: "fleet-a", Count: 2}, {Key: "none", Count: 2}},
+		"status":   {{Key: "inactive", Count: 3}, {Key: "active", Count: 1}},
+	}
+	for name, counts := range map[string][]summaryCount{
+		"version":  got.ByVersion,
+		"platform": got.ByPlatform,
+		"fleet":    got.ByFleet,
+		"status":   got.ByStatus,
+	} {
+		if !reflect.DeepEqual(want[name], counts) {
+			t.Errorf("%s: want %+v, got %+v", name, want[name], counts)
+		}
+	}
+
+	if got.Newest.Name != "c" || got.Oldest.Name != "b" {
+		t.Errorf("unexpected newest %q and oldest %q", got.Newest.Name, got.Oldest.Name)
+	}
+
+	var buf bytes.Buffer
+	if err := renderAgentsSummary(&buf, got); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "linux/amd64 2     50.0%") {
+		t.Errorf("unexpected summary:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,270 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	fluentbitconfig "github.com/calyptia/go-fluentbit-config/v2"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	cloud "github.com/calyptia/api/types"
+	"github.com/calyptia/cli/completer"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/formatters"
+)
+
+// defaultCheckWindow is how far back metrics are looked up for evidence
+// of records flowing.
+const defaultCheckWindow = 5 * time.Minute
+
+// tagCheck is the evidence of records with an expected tag
+// flowing through an agent.
+type tagCheck struct {
+	Tag string `json:"tag" yaml:"tag"`
+	// Inputs are the input instances generating records with the tag.
+	Inputs       []string `json:"inputs" yaml:"inputs"`
+	InputRecords float64  `json:"inputRecords" yaml:"inputRecords"`
+	// Outputs are the output instances matching the tag.
+	Outputs       []string `json:"outputs" yaml:"outputs"`
+	OutputRecords float64  `json:"outputRecords" yaml:"outputRecords"`
+	Pass          bool     `json:"pass" yaml:"pass"`
+	Reason        string   `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+func NewCmdCheckAgent(config *cfg.Config) *cobra.Command {
+	var expectTags []string
+	var window time.Duration
+	var environment string
+	completer := completer.Completer{Config: config}
+
+	cmd := &cobra.Command{
+		Use:   "agent AGENT",
+		Short: "Check records with the expected tags are flowing from an agent",
+		Long: "Check records with the expected tags are flowing from an agent.\n" +
+			"The agent config is used to find the inputs producing each tag and the outputs matching it, " +
+			"then their metrics over the last --window must show records going in and out. " +
+			"Useful right after installing an agent on a new host. Fails unless every tag passes.",
+		Example:           "  calyptia check agent my-host --expect-tag 'kube.*'",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completer.CompleteAgents,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			var environmentID string
+			if environment != "" {
+				var err error
+				environmentID, err = completer.LoadEnvironmentID(environment)
+				if err != nil {
+					return err
+				}
+			}
+
+			agentID, err := completer.LoadAgentID(args[0], environmentID)
+			if err != nil {
+				return err
+			}
+
+			agent, err := config.Cloud.Agent(ctx, agentID)
+			if err != nil {
+				return fmt.Errorf("could not fetch agent: %w", err)
+			}
+
+			conf, err := fluentbitconfig.ParseAs(agent.RawConfig, agentConfigFormat(agent.RawConfig))
+			if err != nil {
+				return fmt.Errorf("could not parse agent config: %w", err)
+			}
+
+			metrics, err := config.Cloud.AgentMetricsByPlugin(ctx, agentID, cloud.MetricsParams{Start: -window, Interval: window})
+			if err != nil {
+				return fmt.Errorf("could not fetch agent metrics: %w", err)
+			}
+
+			checks := checkAgentTags(conf, metrics, expectTags)
+
+			fs := cmd.Flags()
+			outputFormat := formatters.OutputFormatFromFlags(fs)
+			if fn, ok := formatters.ShouldApplyTemplating(outputFormat); ok {
+				err = fn(cmd.OutOrStdout(), formatters.TemplateFromFlags(fs), checks)
+			} else {
+				switch outputFormat {
+				case formatters.OutputFormatJSON:
+					err = json.NewEncoder(cmd.OutOrStdout()).Encode(checks)
+				case formatters.OutputFormatYAML:
+					err = yaml.NewEncoder(cmd.OutOrStdout()).Encode(checks)
+				default:
+					err = renderTagChecks(cmd.OutOrStdout(), checks)
+				}
+			}
+			if err != nil {
+				return err
+			}
+
+			var failed []string
+			for _, c := range checks {
+				if !c.Pass {
+					failed = append(failed, c.Tag)
+				}
+			}
+			if len(failed) != 0 {
+				return fmt.Errorf("no evidence of records flowing from agent %q in the last %s for tags: %s", agent.Name, window, strings.Join(failed, ", "))
+			}
+
+			return nil
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.StringSliceVar(&expectTags, "expect-tag", nil, "Tag, or tag pattern with * wildcards, expected to be flowing. Pass as many as you want")
+	fs.DurationVar(&window, "window", defaultCheckWindow, "How far back to look for metrics")
+	fs.StringVar(&environment, "environment", "", "Calyptia environment name")
+	formatters.BindFormatFlags(cmd)
+
+	_ = cmd.RegisterFlagCompletionFunc("environment", completer.CompleteEnvironments)
+	_ = cmd.MarkFlagRequired("expect-tag")
+
+	return cmd
+}
+
+// checkAgentTags looks, for each expected tag, for the inputs producing it
+// and the outputs matching it, and whether they processed any records.
+func checkAgentTags(conf fluentbitconfig.Config, metrics cloud.MetricsSummaryPlugin, expectTags []string) []tagCheck {
+	inputRecords := map[string]float64{}
+	for _, in := range metrics.Inputs {
+		inputRecords[in.Instance] += metricValue(in.Metrics.Records)
+	}
+
+	outputRecords := map[string]float64{}
+	for _, out := range metrics.Outputs {
+		outputRecords[out.Instance] += metricValue(out.Metrics.Records)
+	}
+
+	out := make([]tagCheck, 0, len(expectTags))
+	for _, tag := range expectTags {
+		c := tagCheck{Tag: tag}
+
+		for _, p := range conf.Pipeline.Inputs {
+			inputTag := pluginProperty(p, "tag")
+			if inputTag == "" {
+				inputTag = p.ID
+			}
+
+			if !tagMatch(tag, inputTag) && !tagMatch(inputTag, tag) {
+				continue
+			}
+
+			name := instanceName(p)
+			c.Inputs = append(c.Inputs, name)
+			c.InputRecords += inputRecords[name]
+		}
+
+		for _, p := range conf.Pipeline.Outputs {
+			match := pluginProperty(p, "match")
+			if match == "" || !tagMatch(match, tag) && !tagMatch(tag, match) {
+				continue
+			}
+
+			name := instanceName(p)
+			c.Outputs = append(c.Outputs, name)
+			c.OutputRecords += outputRecords[name]
+		}
+
+		switch {
+		case len(c.Inputs) == 0:
+			c.Reason = "no input produces the tag"
+		case c.InputRecords == 0:
+			c.Reason = "inputs did not ingest any records"
+		case len(c.Outputs) == 0:
+			c.Reason = "no output matches the tag"
+		case c.OutputRecords == 0:
+			c.Reason = "outputs did not send any records"
+		default:
+			c.Pass = true
+		}
+
+		out = append(out, c)
+	}
+	return out
+}
+
+func renderTagChecks(w io.Writer, checks []tagCheck) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "TAG\tINPUTS\tIN-RECORDS\tOUTPUTS\tOUT-RECORDS\tRESULT")
+	for _, c := range checks {
+		result := "pass"
+		if !c.Pass {
+			result = "fail: " + c.Reason
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%.0f\t%s\t%.0f\t%s\n", c.Tag, orDash(c.Inputs), c.InputRecords, orDash(c.Outputs), c.OutputRecords, result)
+	}
+	return tw.Flush()
+}
+
+// instanceName is the name fluent-bit reports the plugin metrics under,
+// its alias when set.
+func instanceName(p fluentbitconfig.Plugin) string {
+	if alias := pluginProperty(p, "alias"); alias != "" {
+		return alias
+	}
+	return p.ID
+}
+
+func pluginProperty(p fluentbitconfig.Plugin, key string) string {
+	v, ok := p.Properties.Get(key)
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprint(v))
+}
+
+// tagMatch reports whether the tag matches the pattern
+// with * wildcards as fluent-bit routes records.
+func tagMatch(pattern, tag string) bool {
+	head, rest, wildcard := strings.Cut(pattern, "*")
+	if !wildcard {
+		return pattern == tag
+	}
+
+	if !strings.HasPrefix(tag, head) {
+		return false
+	}
+
+	tag = tag[len(head):]
+	for i := 0; i <= len(tag); i++ {
+		if tagMatch(rest, tag[i:]) {
+			return true
+		}
+	}
+	return false
+}
+
+func agentConfigFormat(raw string) fluentbitconfig.Format {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case strings.HasPrefix(raw, "{"):
+		return fluentbitconfig.FormatJSON
+	case strings.HasPrefix(raw, "["), strings.HasPrefix(raw, "@"):
+		return fluentbitconfig.FormatClassic
+	default:
+		return fluentbitconfig.FormatYAML
+	}
+}
+
+func metricValue(f *float64) float64 {
+	if f == nil {
+		return 0
+	}
+	return *f
+}
+
+func orDash(ss []string) string {
+	if len(ss) == 0 {
+		return "-"
+	}
+	return strings.Join(ss, ",")
+}
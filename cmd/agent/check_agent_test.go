@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"testing"
+
+	fluentbitconfig "github.com/calyptia/go-fluentbit-config/v2"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+func Test_tagMatch(t *testing.T) {
+	tt := []struct {
+		pattern, tag string
+		want         bool
+	}{
+		{"kube.*", "kube.var.log.containers.app", true},
+		{"kube.*", "kube.*", true},
+		{"kube.*", "host.syslog", false},
+		{"*", "anything", true},
+		{"*.log", "app.log", true},
+		{"*.log", "app.txt", false},
+		{"cpu", "cpu", true},
+	}
+	for _, tc := range tt {
+		if got := tagMatch(tc.pattern, tc.tag); got != tc.want {
+			t.Errorf("tagMatch(%q, %q) = %v, want %v", tc.pattern, tc.tag, got, tc.want)
+		}
+	}
+}
+
+func Test_checkAgentTags(t *testing.T) {
+	conf, err := fluentbitconfig.ParseAs(`
+[INPUT]
+    Name tail
+    Tag  kube.*
+
+[INPUT]
+    Name  cpu
+    Alias host_cpu
+
+[OUTPUT]
+    Name  stdout
+    Match kube.*
+`, fluentbitconfig.FormatClassic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := func(v float64) *float64 { return &v }
+	metrics := cloud.MetricsSummaryPlugin{
+		Inputs: []cloud.MetricsInputPlugin{
+			{Instance: "tail.0", Metrics: cloud.MetricsInput{Records: f(10)}},
+			{Instance: "host_cpu", Metrics: cloud.MetricsInput{Records: f(5)}},
+		},
+		Outputs: []cloud.MetricsOutputPlugin{
+			{Instance: "stdout.0", Metrics: cloud.MetricsOutput{Records: f(10)}},
+		},
+	}
+
+	checks := checkAgentTags(conf, metrics, []string{"kube.*", "host_cpu", "syslog"})
+	if len(checks) != 3 {
+		t.Fatalf("expected 3 checks, got %d", len(checks))
+	}
+
+	if c := checks[0]; !c.Pass || c.InputRecords != 10 || c.OutputRecords != 10 {
+		t.Errorf("expected kube.* to pass, got %+v", c)
+	}
+
+	if c := checks[1]; c.Pass || c.Reason != "no input produces the tag" {
+		t.Errorf("expected host_cpu to fail as cpu inputs are tagged by their ID, got %+v", c)
+	}
+
+	if c := checks[2]; c.Pass {
+		t.Errorf("expected syslog to fail, got %+v", c)
+	}
+}
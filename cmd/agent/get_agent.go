@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -15,7 +16,10 @@ import (
 	"github.com/calyptia/cli/cmd/utils"
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/exitcode"
 	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/pagination"
+	"github.com/calyptia/cli/redact"
 )
 
 func NewCmdGetAgents(config *cfg.Config) *cobra.Command {
@@ -28,6 +32,8 @@ func NewCmdGetAgents(config *cfg.Config) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "agents",
 		Short: "Display latest agents from a project",
+		Long: "Display latest agents from a project.\n" +
+			"Use --output-format summary for an overview of the agents by status, version, OS/arch and fleet.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var environmentID string
 			if environment != "" {
@@ -39,7 +45,6 @@ func NewCmdGetAgents(config *cfg.Config) *cobra.Command {
 			}
 			var params cloud.AgentsParams
 
-			params.Last = &last
 			if environmentID != "" {
 				params.EnvironmentID = &environmentID
 			}
@@ -54,13 +59,24 @@ func NewCmdGetAgents(config *cfg.Config) *cobra.Command {
 				params.FleetID = &fleedID
 			}
 
-			aa, err := config.Cloud.Agents(config.Ctx, config.ProjectID, params)
+			items, next, err := pagination.List(config.Ctx, pagination.FromFlags(cmd), func(ctx context.Context, last uint, before *string) (pagination.Page[cloud.Agent], error) {
+				params.Last, params.Before = &last, before
+				aa, err := config.Cloud.Agents(ctx, config.ProjectID, params)
+				return pagination.Page[cloud.Agent]{Items: aa.Items, EndCursor: aa.EndCursor}, err
+			})
 			if err != nil {
 				return fmt.Errorf("could not fetch your agents: %w", err)
 			}
 
-			if strings.HasPrefix(outputFormat, "go-template") {
-				return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, aa.Items)
+			aa := cloud.Agents{Items: items, EndCursor: next}
+			defer pagination.PrintNext(cmd, next)
+
+			if err := exitcode.FailOnEmpty(cmd, len(aa.Items)); err != nil {
+				return err
+			}
+
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, aa.Items)
 			}
 
 			switch outputFormat {
@@ -78,10 +94,12 @@ func NewCmdGetAgents(config *cfg.Config) *cobra.Command {
 					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", a.Name, a.Type, a.EnvironmentName, utils.ZeroOfPtr(a.FleetID), a.Version, status, formatters.FmtTime(a.CreatedAt))
 				}
 				tw.Flush()
+			case "summary":
+				return renderAgentsSummary(cmd.OutOrStdout(), summarizeAgents(aa.Items, time.Now()))
 			case "json":
-				return json.NewEncoder(cmd.OutOrStdout()).Encode(aa.Items)
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(redact.FromFlags(cmd).Value(aa.Items))
 			case "yml", "yaml":
-				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(aa.Items)
+				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(redact.FromFlags(cmd).Value(aa.Items))
 			default:
 				return fmt.Errorf("unknown output format %q", outputFormat)
 			}
@@ -91,15 +109,22 @@ func NewCmdGetAgents(config *cfg.Config) *cobra.Command {
 
 	fs := cmd.Flags()
 	fs.UintVarP(&last, "last", "l", 0, "Last `N` agents. 0 means no limit")
+	pagination.BindFlags(cmd)
+	_ = fs.MarkDeprecated("last", "use --limit instead")
 	fs.BoolVar(&showIDs, "show-ids", false, "Include agent IDs in table output")
 	fs.StringVar(&environment, "environment", "", "Calyptia environment name")
 	fs.StringVar(&fleetKey, "fleet", "", "Filter agents from the following fleet only")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, summary, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 
 	_ = cmd.RegisterFlagCompletionFunc("environment", completer.CompleteEnvironments)
 	_ = cmd.RegisterFlagCompletionFunc("fleet", completer.CompleteFleets)
-	_ = cmd.RegisterFlagCompletionFunc("output-format", formatters.CompleteOutputFormat)
+	_ = cmd.RegisterFlagCompletionFunc("output-format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		formats, directive := formatters.CompleteOutputFormat(cmd, args, toComplete)
+		return append(formats, "summary"), directive
+	})
+
+	exitcode.BindFailOnEmptyFlag(cmd)
 
 	return cmd
 }
@@ -138,12 +163,12 @@ func NewCmdGetAgent(config *cfg.Config) *cobra.Command {
 			}
 
 			if onlyConfig {
-				fmt.Fprintln(cmd.OutOrStdout(), strings.TrimSpace(agent.RawConfig))
+				fmt.Fprintln(cmd.OutOrStdout(), strings.TrimSpace(redact.FromFlags(cmd).Config(agent.RawConfig)))
 				return nil
 			}
 
-			if strings.HasPrefix(outputFormat, "go-template") {
-				return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, agent)
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, agent)
 			}
 
 			switch outputFormat {
@@ -160,9 +185,9 @@ func NewCmdGetAgent(config *cfg.Config) *cobra.Command {
 				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", agent.Name, agent.Type, agent.EnvironmentName, utils.ZeroOfPtr(agent.FleetID), agent.Version, status, formatters.FmtTime(agent.CreatedAt))
 				tw.Flush()
 			case "json":
-				return json.NewEncoder(cmd.OutOrStdout()).Encode(agent)
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(redact.FromFlags(cmd).Value(agent))
 			case "yml", "yaml":
-				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(agent)
+				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(redact.FromFlags(cmd).Value(agent))
 			default:
 				return fmt.Errorf("unknown output format %q", outputFormat)
 			}
@@ -175,8 +200,8 @@ func NewCmdGetAgent(config *cfg.Config) *cobra.Command {
 	fs.BoolVar(&onlyConfig, "only-config", false, "Only show the agent configuration")
 	fs.BoolVar(&showIDs, "show-ids", false, "Include agent IDs in table output")
 	fs.StringVar(&environment, "environment", "", "Calyptia environment name")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 
 	_ = cmd.RegisterFlagCompletionFunc("environment", completer.CompleteEnvironments)
 	_ = cmd.RegisterFlagCompletionFunc("output-format", formatters.CompleteOutputFormat)
@@ -0,0 +1,320 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/localdata"
+)
+
+// KeyAliases is the local data key where user defined aliases are stored.
+const KeyAliases = "aliases"
+
+// commandAliases are short forms accepted on top of the normalized
+// forms generated by commandNameVariants. Short forms of the singular
+// or plural name also apply, ie: `get ci` lists core instances when
+// there is no `get core_instance` command.
+var commandAliases = map[string][]string{
+	"core_instance":     {"ci"},
+	"core_instances":    {"cis"},
+	"pipeline":          {"pl"},
+	"pipelines":         {"pls"},
+	"environment":       {"env"},
+	"environments":      {"envs"},
+	"config_section":    {"cs"},
+	"config_sections":   {"css"},
+	"resource_profile":  {"rp"},
+	"resource_profiles": {"rps"},
+}
+
+// resourceVerbs are the top level commands taking a resource
+// noun, ie: get pipelines. Only those nouns accept their
+// singular or plural form, not verbs like install or set.
+var resourceVerbs = map[string]bool{
+	"check":     true,
+	"create":    true,
+	"debug":     true,
+	"delete":    true,
+	"diff":      true,
+	"edit":      true,
+	"gc":        true,
+	"get":       true,
+	"install":   true,
+	"logs":      true,
+	"migrate":   true,
+	"pause":     true,
+	"render":    true,
+	"resume":    true,
+	"rollout":   true,
+	"top":       true,
+	"uninstall": true,
+	"update":    true,
+}
+
+// applyAliases walks the command tree adding the aliases from the alias table
+// and the normalized forms of each command name, ie: core_instance also
+// accepts coreinstance, core-instance and core_instances when there is no
+// sibling command already named like that.
+func applyAliases(cmd *cobra.Command) {
+	children := cmd.Commands()
+	nouns := cmd.HasParent() && !cmd.Parent().HasParent() && resourceVerbs[cmd.Name()]
+
+	names := map[string]bool{}
+	taken := map[string]bool{}
+	for _, c := range children {
+		names[c.Name()] = true
+		taken[c.Name()] = true
+		for _, a := range c.Aliases {
+			taken[a] = true
+		}
+	}
+
+	for _, c := range children {
+		name := c.Name()
+		variants := commandNameVariants(name, nouns)
+		candidates := append(variants, commandAliases[name]...)
+		for _, v := range variants {
+			if !names[v] {
+				candidates = append(candidates, commandAliases[v]...)
+			}
+		}
+		for _, a := range candidates {
+			if taken[a] {
+				continue
+			}
+			taken[a] = true
+			c.Aliases = append(c.Aliases, a)
+		}
+
+		applyAliases(c)
+	}
+}
+
+// commandNameVariants returns the alternative spellings of a command name:
+// without underscores, with hyphens, and for nouns its singular or plural form.
+func commandNameVariants(name string, noun bool) []string {
+	forms := []string{name}
+	switch {
+	case !noun:
+	case strings.HasSuffix(name, "ss"), strings.HasSuffix(name, "us"):
+		// not a plural, ie: status.
+	case strings.HasSuffix(name, "s"):
+		forms = append(forms, strings.TrimSuffix(name, "s"))
+	default:
+		forms = append(forms, name+"s")
+	}
+
+	var out []string
+	for _, f := range forms {
+		out = append(out, f)
+		if strings.Contains(f, "_") {
+			out = append(out, strings.ReplaceAll(f, "_", ""), strings.ReplaceAll(f, "_", "-"))
+		}
+	}
+	return out[1:]
+}
+
+// loadUserAliases reads the user defined aliases from local data.
+func loadUserAliases(config *cfg.Config) (map[string]string, error) {
+	data, err := config.LocalData.Get(KeyAliases)
+	if errors.Is(err, localdata.ErrNotFound) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve your stored aliases: %w", err)
+	}
+
+	aliases := map[string]string{}
+	if err := json.Unmarshal([]byte(data), &aliases); err != nil {
+		return nil, fmt.Errorf("could not parse your stored aliases, reset them with 'calyptia alias delete --all': %w", err)
+	}
+	return aliases, nil
+}
+
+func saveUserAliases(config *cfg.Config, aliases map[string]string) error {
+	if len(aliases) == 0 {
+		err := config.LocalData.Delete(KeyAliases)
+		if errors.Is(err, localdata.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	b, err := json.Marshal(aliases)
+	if err != nil {
+		return err
+	}
+	return config.LocalData.Save(KeyAliases, string(b))
+}
+
+// expandUserAlias replaces the first argument with its alias expansion, if any.
+// Built-in commands always take precedence over user defined aliases.
+func expandUserAlias(root *cobra.Command, args []string, aliases map[string]string) ([]string, bool) {
+	if len(args) == 0 {
+		return args, false
+	}
+
+	expansion, ok := aliases[args[0]]
+	if !ok || isBuiltinCommand(root, args[0]) {
+		return args, false
+	}
+
+	return append(splitArgs(expansion), args[1:]...), true
+}
+
+func isBuiltinCommand(root *cobra.Command, name string) bool {
+	for _, c := range root.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return true
+		}
+	}
+	return name == "help" || name == "completion"
+}
+
+// splitArgs splits s by whitespace, keeping single or double quoted
+// sections together.
+func splitArgs(s string) []string {
+	var out []string
+	var b strings.Builder
+	var quote rune
+	var inArg bool
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			b.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				out = append(out, b.String())
+				b.Reset()
+				inArg = false
+			}
+		default:
+			b.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		out = append(out, b.String())
+	}
+	return out
+}
+
+func newCmdAlias(config *cfg.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "alias",
+		Short: "Manage user defined command aliases",
+	}
+
+	cmd.AddCommand(
+		newCmdAliasSet(config),
+		newCmdAliasList(config),
+		newCmdAliasDelete(config),
+	)
+
+	return cmd
+}
+
+func newCmdAliasSet(config *cfg.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:     "set NAME EXPANSION",
+		Short:   "Set an alias that expands to the given command",
+		Example: `  calyptia alias set prod-pipelines "get pipelines --core-instance prod"`,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, expansion := args[0], args[1]
+			if strings.ContainsAny(name, " \t") || strings.HasPrefix(name, "-") {
+				return fmt.Errorf("invalid alias name %q", name)
+			}
+
+			if isBuiltinCommand(cmd.Root(), name) {
+				return fmt.Errorf("alias %q would shadow a built-in command", name)
+			}
+
+			if len(splitArgs(expansion)) == 0 {
+				return errors.New("alias expansion cannot be empty")
+			}
+
+			aliases, err := loadUserAliases(config)
+			if err != nil {
+				return err
+			}
+
+			aliases[name] = expansion
+			return saveUserAliases(config, aliases)
+		},
+	}
+}
+
+func newCmdAliasList(config *cfg.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List user defined aliases",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			aliases, err := loadUserAliases(config)
+			if err != nil {
+				return err
+			}
+
+			names := make([]string, 0, len(aliases))
+			for name := range aliases {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 1, ' ', 0)
+			fmt.Fprintln(tw, "ALIAS\tEXPANSION")
+			for _, name := range names {
+				fmt.Fprintf(tw, "%s\t%s\n", name, aliases[name])
+			}
+			return tw.Flush()
+		},
+	}
+}
+
+func newCmdAliasDelete(config *cfg.Config) *cobra.Command {
+	var all bool
+	cmd := &cobra.Command{
+		Use:   "delete NAME",
+		Short: "Delete a user defined alias",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if all {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if all {
+				// without parsing them, so unreadable aliases can be reset.
+				return saveUserAliases(config, nil)
+			}
+
+			aliases, err := loadUserAliases(config)
+			if err != nil {
+				return err
+			}
+
+			if _, ok := aliases[args[0]]; !ok {
+				return fmt.Errorf("alias %q not found", args[0])
+			}
+
+			delete(aliases, args[0])
+			return saveUserAliases(config, aliases)
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "Delete every alias")
+
+	return cmd
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/zalando/go-keyring"
+
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/localdata"
+)
+
+func Test_splitArgs(t *testing.T) {
+	tt := []struct {
+		in   string
+		want []string
+	}{
+		{in: ""},
+		{in: "   "},
+		{in: "get pipelines", want: []string{"get", "pipelines"}},
+		{in: " get\tpipelines\n--core-instance  prod ", want: []string{"get", "pipelines", "--core-instance", "prod"}},
+		{in: `get pipelines --selector "team=a b"`, want: []string{"get", "pipelines", "--selector", "team=a b"}},
+		{in: `create pipeline --name 'it''s' --metadata "a'b"`, want: []string{"create", "pipeline", "--name", "its", "--metadata", "a'b"}},
+		{in: `get pipelines --name ""`, want: []string{"get", "pipelines", "--name", ""}},
+	}
+	for _, tc := range tt {
+		if got := splitArgs(tc.in); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("splitArgs(%q): want %q, got %q", tc.in, tc.want, got)
+		}
+	}
+}
+
+func Test_expandUserAlias(t *testing.T) {
+	root := &cobra.Command{Use: "calyptia"}
+	root.AddCommand(&cobra.Command{Use: "get", Aliases: []string{"g"}})
+
+	aliases := map[string]string{
+		"prod": `get pipelines --core-instance "prod core"`,
+		"get":  "delete pipelines",
+		"g":    "delete pipelines",
+	}
+
+	tt := []struct {
+		name   string
+		args   []string
+		want   []string
+		wantOK bool
+	}{
+		{name: "no args"},
+		{name: "unknown", args: []string{"pipelines"}, want: []string{"pipelines"}},
+		{name: "alias", args: []string{"prod", "-o", "json"}, want: []string{"get", "pipelines", "--core-instance", "prod core", "-o", "json"}, wantOK: true},
+		{name: "builtin", args: []string{"get", "pipelines"}, want: []string{"get", "pipelines"}},
+		{name: "builtin alias", args: []string{"g", "pipelines"}, want: []string{"g", "pipelines"}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := expandUserAlias(root, tc.args, aliases)
+			if ok != tc.wantOK || !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want (%q, %v), got (%q, %v)", tc.want, tc.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+func Test_applyAliases(t *testing.T) {
+	leaf := func(use string) *cobra.Command { return &cobra.Command{Use: use} }
+
+	root := &cobra.Command{Use: "calyptia"}
+	get := &cobra.Command{Use: "get"}
+	get.AddCommand(leaf("core_instances"), leaf("pipelines"), leaf("pipeline"), leaf("status"))
+	alias := &cobra.Command{Use: "alias"}
+	alias.AddCommand(leaf("set"), leaf("list"))
+	operator := &cobra.Command{Use: "operator"}
+	operator.AddCommand(leaf("install"))
+	root.AddCommand(get, alias, operator, leaf("install"))
+
+	applyAliases(root)
+
+	aliasesOf := func(parent *cobra.Command, name string) []string {
+		for _, c := range parent.Commands() {
+			if c.Name() == name {
+				return c.Aliases
+			}
+		}
+		t.Fatalf("command %s not found", name)
+		return nil
+	}
+
+	tt := []struct {
+		parent *cobra.Command
+		name   string
+		want   []string
+	}{
+		{parent: get, name: "core_instances", want: []string{"coreinstances", "core-instances", "core_instance", "coreinstance", "core-instance", "cis", "ci"}},
+		// a sibling already takes the singular form.
+		{parent: get, name: "pipelines", want: []string{"pls"}},
+		{parent: get, name: "pipeline", want: []string{"pl"}},
+		{parent: get, name: "status"},
+		{parent: alias, name: "set"},
+		{parent: alias, name: "list"},
+		{parent: operator, name: "install"},
+		{parent: root, name: "install"},
+	}
+	for _, tc := range tt {
+		if got := aliasesOf(tc.parent, tc.name); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s %s: want aliases %q, got %q", tc.parent.Name(), tc.name, tc.want, got)
+		}
+	}
+}
+
+func Test_loadUserAliases(t *testing.T) {
+	keyring.MockInit()
+	config := &cfg.Config{LocalData: localdata.New("calyptia-test", t.TempDir())}
+
+	if err := config.LocalData.Save(KeyAliases, "{not json"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := loadUserAliases(config); err == nil {
+		t.Fatal("expected a corrupt alias store to fail")
+	}
+
+	cmd := newCmdAliasDelete(config)
+	cmd.SetArgs([]string{"--all"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	aliases, err := loadUserAliases(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(aliases) != 0 {
+		t.Errorf("expected no aliases, got %v", aliases)
+	}
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/calyptia/cli/cmd/agent"
+	cfg "github.com/calyptia/cli/config"
+)
+
+func newCmdCheck(config *cfg.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check resources work as expected",
+	}
+
+	cmd.AddCommand(
+		agent.NewCmdCheckAgent(config),
+	)
+
+	return cmd
+}
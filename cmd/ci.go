@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/calyptia/cli/cmd/ci"
+	cfg "github.com/calyptia/cli/config"
+)
+
+func newCmdCI(config *cfg.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ci",
+		Short: "Set up the CLI on continuous integration",
+	}
+
+	cmd.AddCommand(
+		ci.NewCmdCISetup(config),
+	)
+
+	return cmd
+}
@@ -0,0 +1,229 @@
+package ci
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	cloud "github.com/calyptia/api/types"
+	cnfg "github.com/calyptia/cli/cmd/config"
+	"github.com/calyptia/cli/cmd/version"
+	cfg "github.com/calyptia/cli/config"
+)
+
+const (
+	checkOK      = "ok"
+	checkWarning = "warning"
+	checkFailed  = "failed"
+	checkSkipped = "skipped"
+
+	installScriptURL = "https://raw.githubusercontent.com/calyptia/cli/main/install.sh"
+)
+
+type check struct {
+	Check  string `json:"check" yaml:"check"`
+	Status string `json:"status" yaml:"status"`
+	Detail string `json:"detail,omitempty" yaml:"detail,omitempty"`
+}
+
+// envVar is an environment variable the CLI needs on CI.
+type envVar struct {
+	Name        string
+	Value       string
+	Description string
+	Secret      bool
+}
+
+var githubActionsWorkflow = template.Must(template.New("workflow").Parse(`# .github/workflows/calyptia.yml
+name: calyptia
+
+on:
+  push:
+    branches: [main]
+  workflow_dispatch:
+
+jobs:
+  calyptia:
+    runs-on: ubuntu-latest
+    env:
+{{- range .}}
+      {{.Name}}: {{if .Secret}}${{"{{"}} secrets.{{.Name}} {{"}}"}}{{else}}{{.Value}}{{end}}
+{{- end}}
+    steps:
+      - uses: actions/checkout@v4
+      - name: Install calyptia CLI
+        run: curl -sSfL ` + installScriptURL + ` | sh
+      - name: Check calyptia setup
+        run: calyptia ci setup --no-save
+      - name: Lint pipeline configs
+        run: calyptia lint fluent-bit.conf
+`))
+
+func NewCmdCISetup(config *cfg.Config) *cobra.Command {
+	var githubActions, noSave bool
+
+	cmd := &cobra.Command{
+		Use:   "setup",
+		Short: "Validate a CI project token and configure the CLI for non-interactive use",
+		Long: "Validate the project token given with --token or CALYPTIA_CLOUD_TOKEN, store it along\n" +
+			"with the cloud URL so the following commands need no flags, print the environment\n" +
+			"variables the CLI needs, and run a connectivity self-test against Calyptia Cloud.\n" +
+			"Fails when any check fails, so it can be used as the first step of a pipeline.",
+		Example: "  CALYPTIA_CLOUD_TOKEN=... calyptia ci setup\n" +
+			"  calyptia ci setup --github-actions > .github/workflows/calyptia.yml",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			env := ciEnvVars(config.BaseURL)
+
+			if githubActions {
+				return githubActionsWorkflow.Execute(cmd.OutOrStdout(), env)
+			}
+
+			if config.ProjectToken == "" {
+				return fmt.Errorf("no project token, pass --token or set CALYPTIA_CLOUD_TOKEN")
+			}
+
+			checks := runSelfTest(cmd.Context(), config)
+			failed := false
+			for _, c := range checks {
+				if c.Status == checkFailed {
+					failed = true
+				}
+			}
+
+			if !failed && !noSave {
+				if err := config.LocalData.Save(cnfg.KeyToken, config.ProjectToken); err != nil {
+					return fmt.Errorf("could not store project token: %w", err)
+				}
+
+				if config.BaseURL != version.DefaultCloudURLStr {
+					if err := config.LocalData.Save(cnfg.KeyBaseURL, config.BaseURL); err != nil {
+						return fmt.Errorf("could not store cloud url: %w", err)
+					}
+				}
+
+				checks = append(checks, check{Check: "config", Status: checkOK, Detail: "token and cloud url stored"})
+			}
+
+			if err := renderChecks(cmd.OutOrStdout(), checks); err != nil {
+				return err
+			}
+
+			cmd.Println()
+			renderEnvVars(cmd.OutOrStdout(), env)
+
+			if failed {
+				return fmt.Errorf("ci self-test failed")
+			}
+
+			return nil
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.BoolVar(&githubActions, "github-actions", false, "Print a GitHub Actions workflow snippet using the CLI instead of running the setup")
+	fs.BoolVar(&noSave, "no-save", false, "Only run the self-test, without storing the token and cloud url")
+
+	return cmd
+}
+
+// ciEnvVars returns the environment variables the CLI needs on CI.
+func ciEnvVars(cloudURL string) []envVar {
+	out := []envVar{{
+		Name:        "CALYPTIA_CLOUD_TOKEN",
+		Description: "Project token, store it as a CI secret",
+		Secret:      true,
+	}}
+
+	if cloudURL != "" && cloudURL != version.DefaultCloudURLStr {
+		out = append(out, envVar{
+			Name:        "CALYPTIA_CLOUD_URL",
+			Value:       cloudURL,
+			Description: "Calyptia Cloud URL",
+		})
+	}
+
+	return out
+}
+
+// runSelfTest checks the project token against Cloud:
+// that it is valid, how much it is allowed to do, and that the API is reachable.
+func runSelfTest(ctx context.Context, config *cfg.Config) []check {
+	projectID, err := cnfg.DecodeToken([]byte(config.ProjectToken))
+	if err != nil {
+		return []check{{Check: "token", Status: checkFailed, Detail: err.Error()}}
+	}
+
+	start := time.Now()
+	project, err := config.Cloud.Project(ctx, projectID)
+	if err != nil {
+		return []check{
+			{Check: "token", Status: checkFailed, Detail: fmt.Sprintf("rejected by %s: %v", config.BaseURL, err)},
+			{Check: "scope", Status: checkSkipped},
+			{Check: "read access", Status: checkSkipped},
+		}
+	}
+
+	out := []check{
+		{Check: "token", Status: checkOK, Detail: fmt.Sprintf("valid for project %q", project.Name)},
+		{Check: "latency", Status: checkOK, Detail: time.Since(start).Round(time.Millisecond).String()},
+		tokenScopeCheck(ctx, config, projectID),
+	}
+
+	last := uint(1)
+	if _, err := config.Cloud.CoreInstances(ctx, projectID, cloud.CoreInstancesParams{Last: &last}); err != nil {
+		out = append(out, check{Check: "read access", Status: checkFailed, Detail: fmt.Sprintf("could not list core instances: %v", err)})
+	} else {
+		out = append(out, check{Check: "read access", Status: checkOK, Detail: "core instances listed"})
+	}
+
+	return out
+}
+
+// tokenScopeCheck warns when the token grants full access to the project.
+// Tokens scoped with permissions usually cannot list the project tokens,
+// which is reported as ok.
+func tokenScopeCheck(ctx context.Context, config *cfg.Config, projectID string) check {
+	tokens, err := config.Cloud.Tokens(ctx, projectID, cloud.TokensParams{})
+	if err != nil {
+		return check{Check: "scope", Status: checkOK, Detail: "restricted, cannot list project tokens"}
+	}
+
+	for _, t := range tokens.Items {
+		if t.Token != config.ProjectToken {
+			continue
+		}
+
+		if len(t.Permissions) == 0 {
+			return check{Check: "scope", Status: checkWarning, Detail: fmt.Sprintf("token %q has full project access, prefer a token scoped with permissions for CI", t.Name)}
+		}
+
+		return check{Check: "scope", Status: checkOK, Detail: fmt.Sprintf("token %q: %s", t.Name, strings.Join(t.Permissions, ", "))}
+	}
+
+	return check{Check: "scope", Status: checkSkipped, Detail: "token not found in the project tokens"}
+}
+
+func renderChecks(w io.Writer, checks []check) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tSTATUS\tDETAIL")
+	for _, c := range checks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Check, c.Status, c.Detail)
+	}
+	return tw.Flush()
+}
+
+func renderEnvVars(w io.Writer, env []envVar) {
+	fmt.Fprintln(w, "Required environment variables:")
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	for _, e := range env {
+		fmt.Fprintf(tw, "  %s\t%s\n", e.Name, e.Description)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,45 @@
+package ci
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/calyptia/cli/cmd/version"
+	cfg "github.com/calyptia/cli/config"
+)
+
+func Test_ciEnvVars(t *testing.T) {
+	if got := ciEnvVars(version.DefaultCloudURLStr); len(got) != 1 || got[0].Name != "CALYPTIA_CLOUD_TOKEN" || !got[0].Secret {
+		t.Errorf("expected only the token on the default cloud, got %+v", got)
+	}
+
+	got := ciEnvVars("https://cloud.example.org")
+	if len(got) != 2 || got[1].Name != "CALYPTIA_CLOUD_URL" || got[1].Value != "https://cloud.example.org" {
+		t.Errorf("expected the custom cloud url, got %+v", got)
+	}
+}
+
+func Test_githubActionsWorkflow(t *testing.T) {
+	var sb strings.Builder
+	if err := githubActionsWorkflow.Execute(&sb, ciEnvVars("https://cloud.example.org")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"CALYPTIA_CLOUD_TOKEN: ${{ secrets.CALYPTIA_CLOUD_TOKEN }}",
+		"CALYPTIA_CLOUD_URL: https://cloud.example.org",
+		"run: calyptia ci setup --no-save",
+	} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("expected workflow to contain %q, got:\n%s", want, sb.String())
+		}
+	}
+}
+
+func Test_runSelfTest_invalidToken(t *testing.T) {
+	got := runSelfTest(context.Background(), &cfg.Config{ProjectToken: "invalid"})
+	if len(got) != 1 || got[0].Status != checkFailed {
+		t.Errorf("expected a failed token check, got %+v", got)
+	}
+}
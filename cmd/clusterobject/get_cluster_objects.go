@@ -3,24 +3,38 @@ package clusterobject
 import (
 	"encoding/json"
 	"fmt"
-	"strings"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
 
 	cloud "github.com/calyptia/api/types"
 	"github.com/calyptia/cli/completer"
 	cnfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/confirm"
+	"github.com/calyptia/cli/exitcode"
 	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/k8s"
 )
 
+// VerifiedClusterObject is a cluster object along with its state
+// in the live cluster.
+type VerifiedClusterObject struct {
+	cloud.ClusterObject `yaml:",inline"`
+	State               k8s.ClusterObjectState `json:"state" yaml:"state"`
+}
+
 func NewCmdGetClusterObjects(config *cnfg.Config) *cobra.Command {
 	var coreInstanceKey string
 	var last uint
 	var outputFormat, goTemplate string
 	var environment string
 	var showIDs bool
+	var verify, pruneStale, confirmed bool
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	configOverrides := &clientcmd.ConfigOverrides{}
 	completer := completer.Completer{Config: config}
 
 	cmd := &cobra.Command{
@@ -48,33 +62,72 @@ func NewCmdGetClusterObjects(config *cnfg.Config) *cobra.Command {
 				return fmt.Errorf("could not fetch your cluster objects: %w", err)
 			}
 
-			if strings.HasPrefix(outputFormat, "go-template") {
-				return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, co.Items)
+			if err := exitcode.FailOnEmpty(cmd, len(co.Items)); err != nil {
+				return err
+			}
+
+			if !verify && !pruneStale {
+				return renderClusterObjects(cmd, co.Items, outputFormat, goTemplate, showIDs)
+			}
+
+			kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+			kubeClientConfig, err := kubeConfig.ClientConfig()
+			if err != nil {
+				return err
+			}
+
+			clientSet, err := kubernetes.NewForConfig(kubeClientConfig)
+			if err != nil {
+				return err
+			}
+
+			k8sClient := &k8s.Client{
+				Interface: clientSet,
+				Config:    kubeClientConfig,
+			}
+
+			verified := make([]VerifiedClusterObject, 0, len(co.Items))
+			var stale []cloud.ClusterObject
+			for _, c := range co.Items {
+				state, err := k8sClient.VerifyClusterObject(config.Ctx, c)
+				if err != nil {
+					return err
+				}
+
+				verified = append(verified, VerifiedClusterObject{ClusterObject: c, State: state})
+				if state == k8s.ClusterObjectStateStale {
+					stale = append(stale, c)
+				}
+			}
+
+			if err := renderVerifiedClusterObjects(cmd, verified, outputFormat, goTemplate, showIDs); err != nil {
+				return err
+			}
+
+			if !pruneStale || len(stale) == 0 {
+				return nil
+			}
+
+			if !confirmed {
+				cmd.Printf("Are you sure you want to deregister %d stale cluster objects? (y/N) ", len(stale))
+				confirmed, err := confirm.Read(cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+
+				if !confirmed {
+					cmd.Println("Aborted")
+					return nil
+				}
 			}
 
-			switch outputFormat {
-			case "table":
-				{
-					tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 1, ' ', 0)
-					if showIDs {
-						fmt.Fprintf(tw, "ID\t")
-					}
-					fmt.Fprintln(tw, "NAME\tKIND\tCREATED AT")
-					for _, c := range co.Items {
-						if showIDs {
-							fmt.Fprintf(tw, "%s\t", c.ID)
-						}
-						fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Name, string(c.Kind), formatters.FmtTime(c.CreatedAt))
-					}
-					tw.Flush()
+			for _, c := range stale {
+				if err := config.Cloud.DeleteClusterObject(config.Ctx, c.ID); err != nil {
+					return fmt.Errorf("could not deregister cluster object %q: %w", c.Name, err)
 				}
-			case "json":
-				return json.NewEncoder(cmd.OutOrStdout()).Encode(co.Items)
-			case "yml", "yaml":
-				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(co.Items)
-			default:
-				return fmt.Errorf("unknown output format %q", outputFormat)
 			}
+
+			cmd.PrintErrf("Deregistered %d stale cluster objects\n", len(stale))
 			return nil
 		},
 	}
@@ -83,12 +136,77 @@ func NewCmdGetClusterObjects(config *cnfg.Config) *cobra.Command {
 	fs.StringVar(&coreInstanceKey, "core-instance", "", "Core Instance to list cluster objects from")
 	fs.UintVarP(&last, "last", "l", 0, "Last `N` cluster objects. 0 means no limit")
 	fs.BoolVar(&showIDs, "show-ids", false, "Include status IDs in table output")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	fs.StringVar(&environment, "environment", "", "Calyptia environment name")
+	fs.BoolVar(&verify, "verify", false, "Check each cluster object against the live kubernetes cluster")
+	fs.BoolVar(&pruneStale, "prune-stale", false, "Deregister the cluster objects no longer present in the live kubernetes cluster. Implies --verify")
+	fs.BoolVarP(&confirmed, "yes", "y", false, "Confirm pruning of stale cluster objects")
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
+	clientcmd.BindOverrideFlags(configOverrides, fs, clientcmd.RecommendedConfigOverrideFlags("kube-"))
 
 	_ = cmd.MarkFlagRequired("core-instance")
 
 	_ = cmd.RegisterFlagCompletionFunc("core-instance", completer.CompleteCoreInstances)
+	_ = cmd.RegisterFlagCompletionFunc("environment", completer.CompleteEnvironments)
+	_ = cmd.RegisterFlagCompletionFunc("output-format", formatters.CompleteOutputFormat)
+
+	exitcode.BindFailOnEmptyFlag(cmd)
 
 	return cmd
 }
+
+func renderClusterObjects(cmd *cobra.Command, items []cloud.ClusterObject, outputFormat, goTemplate string, showIDs bool) error {
+	if formatters.IsTemplating(outputFormat) {
+		return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, items)
+	}
+
+	switch outputFormat {
+	case "table":
+		tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 1, ' ', 0)
+		if showIDs {
+			fmt.Fprintf(tw, "ID\t")
+		}
+		fmt.Fprintln(tw, "NAME\tKIND\tCREATED AT")
+		for _, c := range items {
+			if showIDs {
+				fmt.Fprintf(tw, "%s\t", c.ID)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Name, string(c.Kind), formatters.FmtTime(c.CreatedAt))
+		}
+		return tw.Flush()
+	case "json":
+		return json.NewEncoder(cmd.OutOrStdout()).Encode(items)
+	case "yml", "yaml":
+		return yaml.NewEncoder(cmd.OutOrStdout()).Encode(items)
+	default:
+		return fmt.Errorf("unknown output format %q", outputFormat)
+	}
+}
+
+func renderVerifiedClusterObjects(cmd *cobra.Command, items []VerifiedClusterObject, outputFormat, goTemplate string, showIDs bool) error {
+	if formatters.IsTemplating(outputFormat) {
+		return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, items)
+	}
+
+	switch outputFormat {
+	case "table":
+		tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 1, ' ', 0)
+		if showIDs {
+			fmt.Fprintf(tw, "ID\t")
+		}
+		fmt.Fprintln(tw, "NAME\tKIND\tSTATE\tCREATED AT")
+		for _, c := range items {
+			if showIDs {
+				fmt.Fprintf(tw, "%s\t", c.ID)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", c.Name, string(c.Kind), c.State, formatters.FmtTime(c.CreatedAt))
+		}
+		return tw.Flush()
+	case "json":
+		return json.NewEncoder(cmd.OutOrStdout()).Encode(items)
+	case "yml", "yaml":
+		return yaml.NewEncoder(cmd.OutOrStdout()).Encode(items)
+	default:
+		return fmt.Errorf("unknown output format %q", outputFormat)
+	}
+}
@@ -20,6 +20,8 @@ func newCmdConfig(config *cfg.Config) *cobra.Command {
 		cnfg.NewCmdConfigSetURL(config),
 		cnfg.NewCmdConfigCurrentURL(config),
 		cnfg.NewCmdConfigUnsetURL(config),
+		cnfg.NewCmdConfigSet(config),
+		cnfg.NewCmdConfigUnset(config),
 	)
 
 	return cmd
@@ -14,6 +14,7 @@ import (
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
 	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/state"
 )
 
 func NewCmdCreateConfigSection(config *cfg.Config) *cobra.Command {
@@ -42,8 +43,18 @@ func NewCmdCreateConfigSection(config *cfg.Config) *cobra.Command {
 				return fmt.Errorf("cloud: %w", err)
 			}
 
-			if strings.HasPrefix(outputFormat, "go-template") {
-				return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, created)
+			err = config.State.Track(cmd, state.Resource{
+				Kind:      state.KindConfigSection,
+				ID:        created.ID,
+				Name:      name,
+				ProjectID: config.ProjectID,
+			})
+			if err != nil {
+				return err
+			}
+
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, created)
 			}
 
 			switch outputFormat {
@@ -60,8 +71,8 @@ func NewCmdCreateConfigSection(config *cfg.Config) *cobra.Command {
 	fs.StringVar(&kind, "kind", "", "Plugin kind. Either input, filter or output")
 	fs.StringVar(&name, "name", "", "Plugin name. See\n[https://docs.fluentbit.io/manual/pipeline]")
 	fs.StringSliceVarP(&propsSlice, "prop", "p", nil, "Additional properties; follow the format -p foo=bar -p baz=qux")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 
 	_ = cmd.MarkFlagRequired("kind")
 	_ = cmd.MarkFlagRequired("name")
@@ -0,0 +1,234 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	cloud "github.com/calyptia/api/types"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/localdata"
+)
+
+// KeyProjectDefaults is the local data key where the defaults
+// of each project are stored, keyed by project ID.
+const KeyProjectDefaults = "project_defaults"
+
+// ProjectDefaults are the settings applied to new resources
+// of a project unless overridden by flags.
+type ProjectDefaults struct {
+	ResourceProfile string `json:"resourceProfile,omitempty"`
+}
+
+// EffectiveDefault is a default setting as seen by the commands,
+// along with where it comes from.
+type EffectiveDefault struct {
+	Setting string `json:"setting" yaml:"setting"`
+	Value   string `json:"value" yaml:"value"`
+	Source  string `json:"source" yaml:"source"`
+}
+
+func NewCmdConfigSet(config *cfg.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set",
+		Short: "Set project defaults",
+	}
+
+	cmd.AddCommand(
+		newCmdConfigSetDefaultResourceProfile(config),
+	)
+
+	return cmd
+}
+
+func NewCmdConfigUnset(config *cfg.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "unset",
+		Short: "Unset project defaults",
+	}
+
+	cmd.AddCommand(
+		newCmdConfigUnsetDefaultResourceProfile(config),
+	)
+
+	return cmd
+}
+
+func newCmdConfigSetDefaultResourceProfile(config *cfg.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "default-resource-profile RESOURCE_PROFILE",
+		Short: "Set the resource profile new pipelines of the current project get unless --resource-profile is given",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := strings.TrimSpace(args[0])
+			if name == "" {
+				return errors.New("resource profile name cannot be empty")
+			}
+
+			return updateProjectDefaults(config, func(d *ProjectDefaults) {
+				d.ResourceProfile = name
+			})
+		},
+	}
+}
+
+func newCmdConfigUnsetDefaultResourceProfile(config *cfg.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "default-resource-profile",
+		Short: "Unset the default resource profile of the current project",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return updateProjectDefaults(config, func(d *ProjectDefaults) {
+				d.ResourceProfile = ""
+			})
+		},
+	}
+}
+
+func NewCmdGetConfig(config *cfg.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Display the CLI configuration",
+	}
+
+	cmd.AddCommand(
+		newCmdGetConfigDefaults(config),
+	)
+
+	return cmd
+}
+
+func newCmdGetConfigDefaults(config *cfg.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "defaults",
+		Short: "Display the effective defaults applied to new resources of the current project",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out, err := EffectiveDefaults(config)
+			if err != nil {
+				return err
+			}
+
+			fs := cmd.Flags()
+			outputFormat := formatters.OutputFormatFromFlags(fs)
+			if fn, ok := formatters.ShouldApplyTemplating(outputFormat); ok {
+				return fn(cmd.OutOrStdout(), formatters.TemplateFromFlags(fs), out)
+			}
+
+			switch outputFormat {
+			case formatters.OutputFormatJSON:
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(out)
+			case formatters.OutputFormatYAML:
+				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(out)
+			default:
+				return renderEffectiveDefaults(cmd.OutOrStdout(), out)
+			}
+		},
+	}
+
+	formatters.BindFormatFlags(cmd)
+
+	return cmd
+}
+
+// EffectiveDefaults returns every default setting of the current project,
+// falling back to the built-in values.
+func EffectiveDefaults(config *cfg.Config) ([]EffectiveDefault, error) {
+	defaults, err := LoadProjectDefaults(config)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceProfile := EffectiveDefault{
+		Setting: "resource-profile",
+		Value:   cloud.DefaultResourceProfileName,
+		Source:  "built-in",
+	}
+	if defaults.ResourceProfile != "" {
+		resourceProfile.Value = defaults.ResourceProfile
+		resourceProfile.Source = "project"
+	}
+
+	return []EffectiveDefault{resourceProfile}, nil
+}
+
+// LoadProjectDefaults reads the defaults of the current project from local data.
+func LoadProjectDefaults(config *cfg.Config) (ProjectDefaults, error) {
+	all, err := loadAllProjectDefaults(config)
+	if err != nil {
+		return ProjectDefaults{}, err
+	}
+
+	return all[config.ProjectID], nil
+}
+
+func loadAllProjectDefaults(config *cfg.Config) (map[string]ProjectDefaults, error) {
+	data, err := config.LocalData.Get(KeyProjectDefaults)
+	if errors.Is(err, localdata.ErrNotFound) {
+		return map[string]ProjectDefaults{}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve your stored project defaults: %w", err)
+	}
+
+	all := map[string]ProjectDefaults{}
+	if err := json.Unmarshal([]byte(data), &all); err != nil {
+		return nil, fmt.Errorf("could not parse your stored project defaults: %w", err)
+	}
+
+	return all, nil
+}
+
+// updateProjectDefaults applies the given change to the defaults of the
+// current project. Projects left without defaults are removed.
+func updateProjectDefaults(config *cfg.Config, update func(*ProjectDefaults)) error {
+	if config.ProjectID == "" {
+		return errors.New("project defaults require a project token, set one with 'calyptia config set_token'")
+	}
+
+	all, err := loadAllProjectDefaults(config)
+	if err != nil {
+		return err
+	}
+
+	defaults := all[config.ProjectID]
+	update(&defaults)
+
+	if defaults == (ProjectDefaults{}) {
+		delete(all, config.ProjectID)
+	} else {
+		all[config.ProjectID] = defaults
+	}
+
+	if len(all) == 0 {
+		err := config.LocalData.Delete(KeyProjectDefaults)
+		if errors.Is(err, localdata.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	b, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+
+	return config.LocalData.Save(KeyProjectDefaults, string(b))
+}
+
+func renderEffectiveDefaults(w io.Writer, defaults []EffectiveDefault) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "SETTING\tVALUE\tSOURCE")
+	for _, d := range defaults {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", d.Setting, d.Value, d.Source)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/localdata"
+)
+
+func TestProjectDefaults(t *testing.T) {
+	keyring.MockInit()
+
+	data := localdata.New("project-defaults-test", t.TempDir())
+	projectA := &cfg.Config{ProjectID: "project-a", LocalData: data}
+	projectB := &cfg.Config{ProjectID: "project-b", LocalData: data}
+
+	err := updateProjectDefaults(projectA, func(d *ProjectDefaults) { d.ResourceProfile = "medium" })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadProjectDefaults(projectA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.ResourceProfile != "medium" {
+		t.Errorf("expected project-a resource profile %q, got %q", "medium", got.ResourceProfile)
+	}
+
+	got, err = LoadProjectDefaults(projectB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.ResourceProfile != "" {
+		t.Errorf("expected no project-b resource profile, got %q", got.ResourceProfile)
+	}
+
+	err = updateProjectDefaults(projectA, func(d *ProjectDefaults) { d.ResourceProfile = "" })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := data.Get(KeyProjectDefaults); !errors.Is(err, localdata.ErrNotFound) {
+		t.Errorf("expected project defaults to be deleted, got %v", err)
+	}
+
+	err = updateProjectDefaults(&cfg.Config{LocalData: data}, func(d *ProjectDefaults) { d.ResourceProfile = "medium" })
+	if err == nil {
+		t.Error("expected an error without a project")
+	}
+}
@@ -14,7 +14,9 @@ import (
 
 	"github.com/calyptia/api/types"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/exitcode"
 	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/redact"
 )
 
 func NewCmdGetConfigSections(config *cfg.Config) *cobra.Command {
@@ -42,17 +44,21 @@ func NewCmdGetConfigSections(config *cfg.Config) *cobra.Command {
 				return fmt.Errorf("cloud: %w", err)
 			}
 
-			if strings.HasPrefix(outputFormat, "go-template") {
-				return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, cc.Items)
+			if err := exitcode.FailOnEmpty(cmd, len(cc.Items)); err != nil {
+				return err
+			}
+
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, cc.Items)
 			}
 
 			switch outputFormat {
 			case "json":
-				return json.NewEncoder(cmd.OutOrStdout()).Encode(cc)
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(redact.FromFlags(cmd).Value(cc))
 			case "yml", "yaml":
-				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(cc)
+				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(redact.FromFlags(cmd).Value(cc))
 			default:
-				return renderConfigSectionsTable(cmd.OutOrStdout(), cc, showIDs)
+				return renderConfigSectionsTable(cmd.OutOrStdout(), cc, showIDs, redact.FromFlags(cmd))
 			}
 		},
 	}
@@ -61,15 +67,17 @@ func NewCmdGetConfigSections(config *cfg.Config) *cobra.Command {
 	fs.UintVarP(&last, "last", "l", 0, "Last `N` config sections. 0 means no limit")
 	fs.StringVar(&before, "before", "", "Only show config sections created before the given cursor")
 	fs.BoolVar(&showIDs, "show-ids", false, "Show config section IDs. Only applies when output format is table")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 
 	_ = cmd.RegisterFlagCompletionFunc("output-format", formatters.CompleteOutputFormat)
 
+	exitcode.BindFailOnEmptyFlag(cmd)
+
 	return cmd
 }
 
-func renderConfigSectionsTable(w io.Writer, cc types.ConfigSections, showIDs bool) error {
+func renderConfigSectionsTable(w io.Writer, cc types.ConfigSections, showIDs bool, r redact.Redactor) error {
 	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
 	if showIDs {
 		if _, err := fmt.Fprint(tw, "ID\t"); err != nil {
@@ -84,7 +92,7 @@ func renderConfigSectionsTable(w io.Writer, cc types.ConfigSections, showIDs boo
 				return err
 			}
 		}
-		props, err := pairsToLogfmt(cs.Properties, true)
+		props, err := pairsToLogfmt(cs.Properties, true, r)
 		if err != nil {
 			return err
 		}
@@ -110,7 +118,7 @@ func renderConfigSectionsTable(w io.Writer, cc types.ConfigSections, showIDs boo
 	return nil
 }
 
-func pairsToLogfmt(pp types.Pairs, skipName bool) (string, error) {
+func pairsToLogfmt(pp types.Pairs, skipName bool, r redact.Redactor) (string, error) {
 	var buff bytes.Buffer
 	enc := logfmt.NewEncoder(&buff)
 	for _, p := range pp {
@@ -118,7 +126,12 @@ func pairsToLogfmt(pp types.Pairs, skipName bool) (string, error) {
 			continue
 		}
 
-		err := enc.EncodeKeyval(p.Key, p.Value)
+		value := p.Value
+		if !r.ShowSecrets && redact.IsSecretKey(p.Key) {
+			value = redact.Placeholder
+		}
+
+		err := enc.EncodeKeyval(p.Key, value)
 		if err != nil {
 			return "", fmt.Errorf("encode property key-val: %w", err)
 		}
@@ -3,7 +3,6 @@ package config
 import (
 	"encoding/json"
 	"fmt"
-	"strings"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
@@ -51,8 +50,8 @@ func NewCmdUpdateConfigSection(config *cfg.Config) *cobra.Command {
 				return fmt.Errorf("cloud: %w", err)
 			}
 
-			if strings.HasPrefix(outputFormat, "go-template") {
-				return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, updated)
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, updated)
 			}
 
 			switch outputFormat {
@@ -68,8 +67,8 @@ func NewCmdUpdateConfigSection(config *cfg.Config) *cobra.Command {
 
 	fs := cmd.Flags()
 	fs.StringSliceVarP(&propsSlice, "prop", "p", nil, "Additional properties; follow the format -p foo=bar -p baz=qux")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 
 	_ = cmd.RegisterFlagCompletionFunc("prop", completer.CompletePluginProps)
 
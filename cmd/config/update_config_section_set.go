@@ -2,15 +2,21 @@ package config
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 
+	cloud "github.com/calyptia/api/types"
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/confirm"
+	fluentbitconfig "github.com/calyptia/go-fluentbit-config/v2"
 )
 
 func NewCmdUpdateConfigSectionSet(config *cfg.Config) *cobra.Command {
 	var configSectionKeys []string
+	var confirmed bool
 	completer := completer.Completer{Config: config}
 
 	cmd := &cobra.Command{
@@ -37,6 +43,48 @@ func NewCmdUpdateConfigSectionSet(config *cfg.Config) *cobra.Command {
 				configSectionIDs = append(configSectionIDs, id)
 			}
 
+			pipeline, err := config.Cloud.Pipeline(ctx, pipelineID, cloud.PipelineParams{})
+			if err != nil {
+				return fmt.Errorf("cloud: %w", err)
+			}
+
+			var pipelineConf fluentbitconfig.Config
+			if pipeline.Config.RawConfig != "" {
+				pipelineConf, err = fluentbitconfig.ParseAs(pipeline.Config.RawConfig, fluentbitconfig.Format(pipeline.Config.ConfigFormat))
+				if err != nil {
+					cmd.PrintErrf("Warning: could not parse pipeline config, validating config sections alone: %v\n", err)
+				}
+			}
+
+			var sections []cloud.ConfigSection
+			for _, id := range configSectionIDs {
+				cs, err := config.Cloud.ConfigSection(ctx, id)
+				if err != nil {
+					return fmt.Errorf("cloud: %w", err)
+				}
+
+				sections = append(sections, cs)
+			}
+
+			if warnings := validateConfigSectionSet(pipelineConf, sections); len(warnings) != 0 {
+				for _, w := range warnings {
+					cmd.PrintErrf("Warning: %s\n", w)
+				}
+
+				if !confirmed {
+					cmd.Print("Do you want to attach these config sections anyway? (y/N) ")
+					ok, err := confirm.Read(cmd.InOrStdin())
+					if err != nil {
+						return err
+					}
+
+					if !ok {
+						cmd.Println("Aborted")
+						return nil
+					}
+				}
+			}
+
 			err = config.Cloud.UpdateConfigSectionSet(ctx, pipelineID, configSectionIDs...)
 			if err != nil {
 				return fmt.Errorf("cloud: %w", err)
@@ -47,7 +95,10 @@ func NewCmdUpdateConfigSectionSet(config *cfg.Config) *cobra.Command {
 		},
 	}
 
+	isNonInteractive := os.Stdin == nil || !term.IsTerminal(int(os.Stdin.Fd()))
+
 	fs := cmd.Flags()
+	fs.BoolVarP(&confirmed, "yes", "y", isNonInteractive, "Attach config sections even if validation warnings are found")
 	fs.StringSliceVarP(&configSectionKeys, "config-section", "c", nil, "List of config sections.\nFormat is either: -c one -c two, or -c one,two.\nEither the plugin kind:name or the ID")
 
 	_ = cmd.RegisterFlagCompletionFunc("config-section", completer.CompleteConfigSections)
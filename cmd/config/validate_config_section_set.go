@@ -0,0 +1,145 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	cloud "github.com/calyptia/api/types"
+	fluentbitconfig "github.com/calyptia/go-fluentbit-config/v2"
+)
+
+// validateConfigSectionSet checks that attaching the given config sections
+// to a pipeline with the given config results in a coherent composition.
+// Only issues involving at least one of the config sections are reported,
+// as a list of human readable warnings.
+func validateConfigSectionSet(pipelineConf fluentbitconfig.Config, sections []cloud.ConfigSection) []string {
+	var warnings []string
+
+	seen := map[string]bool{}
+	for _, cs := range sections {
+		if seen[cs.ID] {
+			warnings = append(warnings, fmt.Sprintf("config section %q is listed more than once", cs.ID))
+		}
+		seen[cs.ID] = true
+	}
+
+	conf := pipelineConf
+	conf.Pipeline.Inputs = append(fluentbitconfig.Plugins{}, pipelineConf.Pipeline.Inputs...)
+	conf.Pipeline.Filters = append(fluentbitconfig.Plugins{}, pipelineConf.Pipeline.Filters...)
+	conf.Pipeline.Outputs = append(fluentbitconfig.Plugins{}, pipelineConf.Pipeline.Outputs...)
+
+	// plugins below these offsets come from the pipeline config itself.
+	offsets := map[string]int{
+		"input":  len(conf.Pipeline.Inputs),
+		"filter": len(conf.Pipeline.Filters),
+		"output": len(conf.Pipeline.Outputs),
+	}
+
+	for _, cs := range sections {
+		conf.AddSection(fluentbitconfig.SectionKind(cs.Kind), cs.Properties.AsProperties())
+	}
+
+	groups := []struct {
+		kind    string
+		plugins fluentbitconfig.Plugins
+	}{
+		{"input", conf.Pipeline.Inputs},
+		{"filter", conf.Pipeline.Filters},
+		{"output", conf.Pipeline.Outputs},
+	}
+
+	aliases := map[string]string{}
+	for _, g := range groups {
+		for i, p := range g.plugins {
+			fromSection := i >= offsets[g.kind]
+
+			if alias := pluginProp(p, "alias"); alias != "" {
+				if prev, ok := aliases[alias]; ok && fromSection {
+					warnings = append(warnings, fmt.Sprintf("%s %s alias %q clashes with %s", g.kind, p.ID, alias, prev))
+				}
+				aliases[alias] = g.kind + " " + p.ID
+			}
+
+			if !fromSection {
+				continue
+			}
+
+			for _, other := range g.plugins[:i] {
+				if strings.EqualFold(other.Name, p.Name) && reflect.DeepEqual(other.Properties, p.Properties) {
+					warnings = append(warnings, fmt.Sprintf("%s %s duplicates %s %s", g.kind, p.ID, g.kind, other.ID))
+				}
+			}
+		}
+	}
+
+	// without any input there is nothing to match against, which usually
+	// means the pipeline config could not be read.
+	tags := inputTags(conf.Pipeline.Inputs)
+	if len(tags) == 0 {
+		return warnings
+	}
+
+	for _, g := range groups[1:] {
+		for _, p := range g.plugins[offsets[g.kind]:] {
+			if match := pluginProp(p, "match"); match != "" && !matchesAnyTag(wildcardRegexp(match), tags) {
+				warnings = append(warnings, fmt.Sprintf("%s %s match %q does not match any input tag", g.kind, p.ID, match))
+			}
+
+			if match := pluginProp(p, "match_regex"); match != "" {
+				re, err := regexp.Compile(match)
+				if err != nil {
+					warnings = append(warnings, fmt.Sprintf("%s %s match_regex %q is invalid: %v", g.kind, p.ID, match, err))
+					continue
+				}
+				if !matchesAnyTag(re, tags) {
+					warnings = append(warnings, fmt.Sprintf("%s %s match_regex %q does not match any input tag", g.kind, p.ID, match))
+				}
+			}
+		}
+	}
+
+	return warnings
+}
+
+// inputTags returns the tag of each input.
+// Inputs without an explicit tag are tagged with their instance name.
+func inputTags(inputs fluentbitconfig.Plugins) []string {
+	tags := make([]string, 0, len(inputs))
+	for _, p := range inputs {
+		if tag := pluginProp(p, "tag"); tag != "" {
+			tags = append(tags, tag)
+			continue
+		}
+		tags = append(tags, p.ID)
+	}
+	return tags
+}
+
+func matchesAnyTag(re *regexp.Regexp, tags []string) bool {
+	for _, tag := range tags {
+		if re.MatchString(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// wildcardRegexp converts a fluent-bit match pattern
+// where `*` matches any sequence of characters into a regexp.
+func wildcardRegexp(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
+
+func pluginProp(p fluentbitconfig.Plugin, key string) string {
+	v, ok := p.Properties.Get(key)
+	if !ok || v == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", v)
+}
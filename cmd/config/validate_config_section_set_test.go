@@ -0,0 +1,87 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	cloud "github.com/calyptia/api/types"
+	fluentbitconfig "github.com/calyptia/go-fluentbit-config/v2"
+)
+
+func TestValidateConfigSectionSet(t *testing.T) {
+	pipelineConf, err := fluentbitconfig.ParseAs(`
+[INPUT]
+    Name  dummy
+    Tag   app.logs
+    Alias my_dummy
+
+[OUTPUT]
+    Name  stdout
+    Match *
+`, fluentbitconfig.FormatClassic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	section := func(id string, kind cloud.ConfigSectionKind, pairs ...string) cloud.ConfigSection {
+		var props cloud.Pairs
+		for i := 0; i < len(pairs); i += 2 {
+			props = append(props, cloud.Pair{Key: pairs[i], Value: pairs[i+1]})
+		}
+		return cloud.ConfigSection{ID: id, Kind: kind, Properties: props}
+	}
+
+	tt := []struct {
+		name     string
+		sections []cloud.ConfigSection
+		want     []string
+	}{
+		{
+			name: "coherent",
+			sections: []cloud.ConfigSection{
+				section("a", cloud.SectionKindFilter, "name", "grep", "match", "app.*"),
+				section("b", cloud.SectionKindOutput, "name", "http", "match_regex", "^app\\..+"),
+			},
+		},
+		{
+			name: "listed twice",
+			sections: []cloud.ConfigSection{
+				section("a", cloud.SectionKindOutput, "name", "http", "match", "*"),
+				section("a", cloud.SectionKindOutput, "name", "http", "match", "*"),
+			},
+			want: []string{"listed more than once", "output http.2 duplicates output http.1"},
+		},
+		{
+			name: "alias clash",
+			sections: []cloud.ConfigSection{
+				section("a", cloud.SectionKindInput, "name", "tail", "alias", "my_dummy"),
+			},
+			want: []string{`alias "my_dummy" clashes with input dummy.0`},
+		},
+		{
+			name: "dangling match",
+			sections: []cloud.ConfigSection{
+				section("a", cloud.SectionKindFilter, "name", "grep", "match", "kube.*"),
+				section("b", cloud.SectionKindOutput, "name", "http", "match_regex", "^kube"),
+			},
+			want: []string{
+				`filter grep.0 match "kube.*" does not match any input tag`,
+				`output http.1 match_regex "^kube" does not match any input tag`,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := validateConfigSectionSet(pipelineConf, tc.sections)
+			if len(got) != len(tc.want) {
+				t.Fatalf("expected %d warnings, got %d: %v", len(tc.want), len(got), got)
+			}
+			for i := range tc.want {
+				if !strings.Contains(got[i], tc.want[i]) {
+					t.Errorf("expected warning %q to contain %q", got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package coreinstance
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/pflag"
+	apiv1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/calyptia/cli/k8s"
+)
+
+// coreInstanceManifest describes the desired core instance so it can be
+// created from a reviewable file instead of a long list of flags.
+// Flags given along with the manifest take precedence over it.
+type coreInstanceManifest struct {
+	Name           string            `json:"name"`
+	Environment    string            `json:"environment"`
+	Version        string            `json:"version"`
+	Image          string            `json:"image"`
+	FluentBitImage string            `json:"fluentBitImage"`
+	Tags           []string          `json:"tags"`
+	Labels         map[string]string `json:"labels"`
+
+	HealthCheckPipeline manifestHealthCheckPipeline `json:"healthCheckPipeline"`
+	ClusterLogging      bool                        `json:"clusterLogging"`
+	OpenShift           bool                        `json:"openShift"`
+	ServiceAccount      string                      `json:"serviceAccount"`
+	Service             manifestService             `json:"service"`
+	Sync                manifestSync                `json:"sync"`
+
+	// Resources of the core instance container.
+	Resources apiv1.ResourceRequirements `json:"resources"`
+	// Env are extra environment variables for the core instance container.
+	Env map[string]string `json:"env"`
+}
+
+type manifestHealthCheckPipeline struct {
+	// Enabled defaults to true, same as without --no-health-check-pipeline.
+	Enabled     *bool  `json:"enabled"`
+	Port        string `json:"port"`
+	ServiceType string `json:"serviceType"`
+}
+
+type manifestService struct {
+	SkipCreation bool `json:"skipCreation"`
+}
+
+// manifestSync holds how the core instance syncs with Calyptia Cloud.
+type manifestSync struct {
+	CloudURL   string `json:"cloudURL"`
+	TLSVerify  *bool  `json:"tlsVerify"`
+	HTTPProxy  string `json:"httpProxy"`
+	HTTPSProxy string `json:"httpsProxy"`
+	NoProxy    string `json:"noProxy"`
+}
+
+// readCoreInstanceManifest reads a YAML or JSON core instance manifest,
+// rejecting unknown fields so typos do not go unnoticed.
+func readCoreInstanceManifest(name string) (coreInstanceManifest, error) {
+	var m coreInstanceManifest
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return m, fmt.Errorf("could not read core instance manifest: %w", err)
+	}
+
+	if err := yaml.UnmarshalStrict(b, &m); err != nil {
+		return m, fmt.Errorf("could not parse core instance manifest %s: %w", name, err)
+	}
+
+	return m, nil
+}
+
+// applyToFlags sets the flags not given explicitly from the manifest.
+// Every field must map onto an existing flag, even if empty, so removing
+// a flag cannot silently drop or break its manifest field.
+func (m coreInstanceManifest) applyToFlags(fs *pflag.FlagSet) error {
+	values := map[string]string{
+		"name":                               m.Name,
+		"environment":                        m.Environment,
+		"version":                            m.Version,
+		"image":                              m.Image,
+		"fluent-bit-image":                   m.FluentBitImage,
+		"health-check-pipeline-port-number":  m.HealthCheckPipeline.Port,
+		"health-check-pipeline-service-type": m.HealthCheckPipeline.ServiceType,
+		"service-account":                    m.ServiceAccount,
+		"core-cloud-url":                     m.Sync.CloudURL,
+	}
+	if m.HealthCheckPipeline.Enabled != nil {
+		values["no-health-check-pipeline"] = strconv.FormatBool(!*m.HealthCheckPipeline.Enabled)
+	}
+	if m.Sync.TLSVerify != nil {
+		values["no-tls-verify"] = strconv.FormatBool(!*m.Sync.TLSVerify)
+	}
+	if m.ClusterLogging {
+		values["enable-cluster-logging"] = "true"
+	}
+	if m.OpenShift {
+		values["enable-openshift"] = "true"
+	}
+	if m.Service.SkipCreation {
+		values["skip-service-creation"] = "true"
+	}
+
+	for name, value := range values {
+		if fs.Lookup(name) == nil {
+			return fmt.Errorf("core instance manifest field for unknown flag --%s", name)
+		}
+
+		if value == "" || fs.Changed(name) {
+			continue
+		}
+
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("invalid core instance manifest %s: %w", name, err)
+		}
+	}
+
+	slices := map[string][]string{
+		"tags":   m.Tags,
+		"labels": keyValuePairs(m.Labels),
+	}
+	for name, values := range slices {
+		f := fs.Lookup(name)
+		if f == nil {
+			return fmt.Errorf("core instance manifest field for unknown flag --%s", name)
+		}
+
+		sv, ok := f.Value.(pflag.SliceValue)
+		if !ok {
+			return fmt.Errorf("core instance manifest field for flag --%s that is not a list", name)
+		}
+
+		if len(values) == 0 || fs.Changed(name) {
+			continue
+		}
+
+		if err := sv.Replace(values); err != nil {
+			return fmt.Errorf("invalid core instance manifest %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// envVars returns the extra environment variables for the core instance
+// container, including the sync proxy settings.
+func (m coreInstanceManifest) envVars() (map[string]string, error) {
+	out := map[string]string{}
+	for name, value := range map[string]string{
+		"HTTP_PROXY":  m.Sync.HTTPProxy,
+		"HTTPS_PROXY": m.Sync.HTTPSProxy,
+		"NO_PROXY":    m.Sync.NoProxy,
+	} {
+		if value != "" {
+			out[name] = value
+		}
+	}
+
+	names := make([]string, 0, len(m.Env))
+	for name, value := range m.Env {
+		if _, ok := out[name]; ok {
+			return nil, fmt.Errorf("environment variable %q is already set by the sync settings of the core instance manifest", name)
+		}
+		out[name] = value
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	if err := k8s.ValidateCoreInstanceEnvVars(names...); err != nil {
+		return nil, err
+	}
+
+	if len(out) == 0 {
+		return nil, nil
+	}
+
+	return out, nil
+}
+
+func keyValuePairs(m map[string]string) []string {
+	out := make([]string, 0, len(m))
+	for k, v := range m {
+		out = append(out, k+"="+v)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,134 @@
+package coreinstance
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+
+	cfg "github.com/calyptia/cli/config"
+)
+
+const fullCoreInstanceManifest = `name: core-1
+environment: staging
+version: v1.0.0
+image: ghcr.io/calyptia/core:v1.0.0
+fluentBitImage: ghcr.io/calyptia/core/calyptia-fluent-bit:v1.0.0
+tags: [one, two]
+labels:
+  team: infra
+healthCheckPipeline:
+  enabled: false
+  port: "2021"
+  serviceType: NodePort
+clusterLogging: true
+openShift: true
+serviceAccount: core-sa
+service:
+  skipCreation: true
+sync:
+  cloudURL: https://cloud.example.com
+  tlsVerify: false
+  httpProxy: http://proxy:3128
+  httpsProxy: http://proxy:3129
+  noProxy: localhost
+resources:
+  limits:
+    memory: 512Mi
+env:
+  INTERVAL: 30s
+`
+
+// Test_coreInstanceManifest_applyToFlags applies a manifest with every
+// field populated to the real command flags, so removing or renaming a
+// flag the manifest maps onto fails here.
+func Test_coreInstanceManifest_applyToFlags(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "core.yaml")
+	if err := os.WriteFile(name, []byte(fullCoreInstanceManifest), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := readCoreInstanceManifest(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertPopulated(t, "manifest", reflect.ValueOf(m))
+
+	cmd := newCmdCreateCoreInstanceOnK8s(&cfg.Config{}, nil)
+	fs := cmd.Flags()
+	if err := fs.Set("environment", "production"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.applyToFlags(fs); err != nil {
+		t.Fatal(err)
+	}
+
+	for flag, want := range map[string]string{
+		"name":                               "core-1",
+		"environment":                        "production",
+		"version":                            "v1.0.0",
+		"image":                              "ghcr.io/calyptia/core:v1.0.0",
+		"fluent-bit-image":                   "ghcr.io/calyptia/core/calyptia-fluent-bit:v1.0.0",
+		"health-check-pipeline-port-number":  "2021",
+		"health-check-pipeline-service-type": "NodePort",
+		"service-account":                    "core-sa",
+		"core-cloud-url":                     "https://cloud.example.com",
+		"no-health-check-pipeline":           "true",
+		"no-tls-verify":                      "true",
+		"enable-cluster-logging":             "true",
+		"enable-openshift":                   "true",
+		"skip-service-creation":              "true",
+		"tags":                               "[one,two]",
+		"labels":                             "[team=infra]",
+	} {
+		if got := fs.Lookup(flag).Value.String(); got != want {
+			t.Errorf("--%s = %q, want %q", flag, got, want)
+		}
+	}
+
+	env, err := m.envVars()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := map[string]string{
+		"HTTP_PROXY":  "http://proxy:3128",
+		"HTTPS_PROXY": "http://proxy:3129",
+		"NO_PROXY":    "localhost",
+		"INTERVAL":    "30s",
+	}; !reflect.DeepEqual(env, want) {
+		t.Errorf("envVars() = %v, want %v", env, want)
+	}
+}
+
+func Test_coreInstanceManifest_applyToFlags_unknownFlag(t *testing.T) {
+	var m coreInstanceManifest
+	err := m.applyToFlags(pflag.NewFlagSet("test", pflag.ContinueOnError))
+	if err == nil || !strings.Contains(err.Error(), "unknown flag") {
+		t.Fatalf("expected unknown flag error, got %v", err)
+	}
+}
+
+// assertPopulated fails for any zero field of the manifest types so new
+// fields get added to fullCoreInstanceManifest.
+func assertPopulated(t *testing.T, path string, v reflect.Value) {
+	t.Helper()
+
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		fv := v.Field(i)
+		if fv.IsZero() {
+			t.Errorf("%s.%s is not set in the test manifest", path, f.Name)
+			continue
+		}
+
+		if fv.Kind() == reflect.Struct && strings.HasPrefix(fv.Type().Name(), "manifest") {
+			assertPopulated(t, path+"."+f.Name, fv)
+		}
+	}
+}
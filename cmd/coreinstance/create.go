@@ -1,18 +1,38 @@
 package coreinstance
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
+	"github.com/sethvargo/go-retry"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 
+	cloud "github.com/calyptia/api/types"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/idempotency"
+	"github.com/calyptia/cli/k8s"
+	"github.com/calyptia/cli/labels"
 )
 
 func NewCmdCreateCoreInstance(config *cfg.Config) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:               "core_instance",
-		Short:             "Setup a new core instance on either Kubernetes, Amazon EC2, or Google Compute Engine",
-		PersistentPreRunE: checkForProjectToken(config),
+		Use:   "core_instance",
+		Short: "Setup a new core instance on either Kubernetes, Amazon EC2, or Google Compute Engine",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// cobra only runs the closest persistent pre-run hook,
+			// so the root one applying the workspace defaults,
+			// --quiet and the feature gates has to be called here.
+			if root := cmd.Root(); root != cmd && root.PersistentPreRunE != nil {
+				if err := root.PersistentPreRunE(cmd, args); err != nil {
+					return err
+				}
+			}
+
+			return checkForProjectToken(config)(cmd, args)
+		},
 	}
 	cmd.AddCommand(newCmdCreateCoreInstanceOnK8s(config, nil))
 	cmd.AddCommand(newCmdCreateCoreInstanceOnAWS(config, nil, nil))
@@ -29,3 +49,129 @@ func checkForProjectToken(config *cfg.Config) func(cmd *cobra.Command, args []st
 		return nil
 	}
 }
+
+// conflictPolicy returns the kubernetes conflict policy to use
+// given the --force-recreate and --adopt flags.
+func conflictPolicy(forceRecreate, adopt bool) k8s.ConflictPolicy {
+	switch {
+	case forceRecreate:
+		return k8s.ConflictPolicyRecreate
+	case adopt:
+		return k8s.ConflictPolicyAdopt
+	default:
+		return k8s.ConflictPolicyFail
+	}
+}
+
+// bindNoRollbackFlag binds the --no-rollback flag shared by the commands
+// creating the kubernetes objects of a core instance, see k8s.CoreInstancePlan.
+func bindNoRollbackFlag(fs *pflag.FlagSet, p *bool) {
+	fs.BoolVar(p, "no-rollback", false, "Keep the kubernetes objects already created when a later one fails, instead of deleting them.")
+}
+
+// printLeftBehind lists the kubernetes objects a failed creation left in the cluster.
+func printLeftBehind(cmd *cobra.Command, leftBehind k8s.Applied) {
+	if len(leftBehind) == 0 {
+		return
+	}
+
+	cmd.PrintErrln("Kubernetes objects left behind:")
+	for _, r := range leftBehind {
+		cmd.PrintErrf("  %s\n", r)
+	}
+}
+
+// quotaIssuesHandler prints the resource quota issues found before creating
+// a deployment as warnings, or fails with them when strict is set.
+func quotaIssuesHandler(cmd *cobra.Command, strict bool) func(string, []k8s.QuotaIssue) error {
+	return func(deployment string, issues []k8s.QuotaIssue) error {
+		for _, issue := range issues {
+			cmd.PrintErrf("WARNING: deployment %q: %s\n", deployment, issue)
+		}
+
+		if strict {
+			return fmt.Errorf("refusing to create deployment %q with %d resource quota issues", deployment, len(issues))
+		}
+		return nil
+	}
+}
+
+// precheckCoreInstanceObjects validates the names of the kubernetes objects
+// to create for the core instance and, unless dryRun, that none of them
+// collides with an existing one, so it fails before registering it at
+// calyptia cloud instead of midway. Autogenerated names cannot be checked.
+func precheckCoreInstanceObjects(ctx context.Context, client *k8s.Client, name, environment string, operator, withRBAC, dryRun bool) error {
+	if name == "" {
+		return nil
+	}
+
+	if environment == "" {
+		environment = "default"
+	}
+
+	objects := client.CoreInstanceObjects(name, environment, operator, withRBAC)
+	if err := k8s.ValidateObjectNames(objects); err != nil {
+		return err
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	err := client.CheckCollisions(ctx, objects)
+	var collisionErr *k8s.CollisionError
+	if errors.As(err, &collisionErr) {
+		if collisionErr.Unmanaged {
+			return fmt.Errorf("%w\nDelete them or use another core instance name", err)
+		}
+		return fmt.Errorf("%w\nPass --adopt or --force-recreate to reuse them, or use another core instance name", err)
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not check for existing kubernetes objects: %w", err)
+	}
+
+	return nil
+}
+
+// withLabels appends the given key=value labels to the core instance tags.
+func withLabels(tags, labelPairs []string) ([]string, error) {
+	ll, err := labels.Parse(labelPairs)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(tags, ll...), nil
+}
+
+// retryableK8sErr marks transient kubernetes API errors as retryable.
+func retryableK8sErr(err error) error {
+	if apiErrors.IsServerTimeout(err) || apiErrors.IsTimeout(err) ||
+		apiErrors.IsTooManyRequests(err) || apiErrors.IsServiceUnavailable(err) {
+		return retry.RetryableError(err)
+	}
+	return err
+}
+
+// findIdempotentCoreInstance returns the core instance previously
+// created with the given idempotency key, if any.
+func findIdempotentCoreInstance(ctx context.Context, config *cfg.Config, key string) (*cloud.CoreInstance, error) {
+	if key == "" {
+		return nil, nil
+	}
+
+	tag := idempotency.Tag(key)
+	cc, err := config.Cloud.CoreInstances(ctx, config.ProjectID, cloud.CoreInstancesParams{
+		TagsQuery: &tag,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not look up core instances by idempotency key: %w", err)
+	}
+
+	for _, c := range cc.Items {
+		if idempotency.HasKey(c.Tags, key) {
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
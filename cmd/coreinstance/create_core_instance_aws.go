@@ -92,6 +92,7 @@ func (c *DefaultCoreInstancePoller) Ready(ctx context.Context, environment, name
 func newCmdCreateCoreInstanceOnAWS(config *cfg.Config, client awsclient.Client, poller CoreInstancePoller) *cobra.Command {
 	var (
 		tags                   []string
+		labelPairs             []string
 		noHealthCheckPipeline  bool
 		noElasticIPv4Address   bool
 		noTLSVerify            bool
@@ -125,7 +126,7 @@ func newCmdCreateCoreInstanceOnAWS(config *cfg.Config, client awsclient.Client,
 				err         error
 			)
 
-			ctx := context.Background()
+			ctx := cmd.Context()
 
 			if client == nil {
 				client, err = awsclient.New(ctx, coreInstanceName, region, credentials, profileFile, profileName, debug)
@@ -174,6 +175,11 @@ func newCmdCreateCoreInstanceOnAWS(config *cfg.Config, client awsclient.Client,
 				params.UserData.CoreInstanceEnvironment = environment
 			}
 
+			tags, err := withLabels(tags, labelPairs)
+			if err != nil {
+				return err
+			}
+
 			if tags != nil {
 				params.UserData.CoreInstanceTags = strings.Join(tags, ",")
 			}
@@ -224,6 +230,7 @@ func newCmdCreateCoreInstanceOnAWS(config *cfg.Config, client awsclient.Client,
 
 	fs.StringVar(&environment, "environment", "default", "Calyptia environment name")
 	fs.StringSliceVar(&tags, "tags", nil, "Tags to apply to the core instance.")
+	fs.StringSliceVar(&labelPairs, "labels", nil, "Labels to apply to the core instance in the form of key=value. Core instances can be filtered by them with get core_instances --selector")
 	fs.StringVar(&credentials, "credentials", "", "Path to the AWS credentials file. If not specified the default credential loader will be used.")
 	fs.StringVar(&profileFile, "profile-file", "", "Path to the AWS profile file. If not specified the default credential loader will be used.")
 	fs.StringVar(&profileName, "profile", "", "Name of the AWS profile to use, if not specified, the default profileFile will be used.")
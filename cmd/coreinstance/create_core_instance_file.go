@@ -2,11 +2,13 @@ package coreinstance
 
 import (
 	"encoding/json"
+	"fmt"
 	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
+	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/calyptia/api/types"
 	"github.com/calyptia/cli/completer"
@@ -20,12 +22,20 @@ func NewCmdCreateCoreInstanceFile(config *cfg.Config) *cobra.Command {
 	var instanceKey string
 	var file string
 	var encrypted bool
+	var fromConfigMap string
+	source := newK8sSource()
 
 	cmd := &cobra.Command{
 		Use:   "core_instance_file", // create
 		Short: "Create core instance files",
-		Long:  "Create a file within a core instance",
+		Long: "Create a file within a core instance.\n" +
+			"With --from-k8s-configmap a file is created from each entry of an existing ConfigMap " +
+			"and named after its key without the extension.",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if fromConfigMap != "" {
+				return createCoreInstanceFilesFromConfigMap(cmd, config, source, instanceKey, fromConfigMap, encrypted)
+			}
+
 			name := filepath.Base(file)
 			name = strings.TrimSuffix(name, filepath.Ext(name))
 			contents, err := cfg.ReadFile(file)
@@ -71,12 +81,75 @@ func NewCmdCreateCoreInstanceFile(config *cfg.Config) *cobra.Command {
 	fs.StringVar(&instanceKey, "core-instance", "", "Core instance ID or name")
 	fs.StringVar(&file, "file", "", "File path. You will be able to reference the file from a fluentbit config using its base name without the extension. Ex: `some_dir/my_file.txt` will be referenced as `{{files.my_file}}`")
 	fs.BoolVar(&encrypted, "encrypted", false, "Encrypt the file contents")
+	fs.StringVar(&fromConfigMap, "from-k8s-configmap", "", "Create a file from each entry of the given kubernetes ConfigMap, as NAMESPACE/NAME")
 	formatters.BindFormatFlags(cmd)
+	clientcmd.BindOverrideFlags(source.configOverrides, fs, clientcmd.RecommendedConfigOverrideFlags("kube-"))
 
 	_ = cmd.RegisterFlagCompletionFunc("core-instance", loader.CompleteCoreInstances)
 
 	_ = cmd.MarkFlagRequired("core-instance")
-	_ = cmd.MarkFlagRequired("file")
+	cmd.MarkFlagsMutuallyExclusive("file", "from-k8s-configmap")
+	cmd.MarkFlagsOneRequired("file", "from-k8s-configmap")
 
 	return cmd
 }
+
+func createCoreInstanceFilesFromConfigMap(cmd *cobra.Command, config *cfg.Config, source *k8sSource, instanceKey, ref string, encrypted bool) error {
+	loader := completer.Completer{Config: config}
+	ctx := cmd.Context()
+
+	data, err := source.configMapData(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	if len(data) == 0 {
+		return fmt.Errorf("configmap %q has no entries", ref)
+	}
+
+	keys := sortedKeys(data)
+	seen := map[string]string{}
+	for _, key := range keys {
+		name := strings.TrimSuffix(key, filepath.Ext(key))
+		if other, ok := seen[name]; ok {
+			return fmt.Errorf("configmap entries %q and %q would both be named %q", other, key, name)
+		}
+		seen[name] = key
+	}
+
+	instanceID, err := loader.LoadCoreInstanceID(instanceKey, "")
+	if err != nil {
+		return err
+	}
+
+	var out []createdEntry
+	for _, key := range keys {
+		name := strings.TrimSuffix(key, filepath.Ext(key))
+		created, err := config.Cloud.CreateCoreInstanceFile(ctx, types.CreateCoreInstanceFile{
+			CoreInstanceID: instanceID,
+			Name:           name,
+			Contents:       data[key],
+			Encrypted:      encrypted,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create core instance file %q: %w", name, err)
+		}
+
+		out = append(out, createdEntry{Name: name, Created: created})
+	}
+
+	fs := cmd.Flags()
+	outputFormat := formatters.OutputFormatFromFlags(fs)
+	if fn, ok := formatters.ShouldApplyTemplating(outputFormat); ok {
+		return fn(cmd.OutOrStdout(), formatters.TemplateFromFlags(fs), out)
+	}
+
+	switch outputFormat {
+	case formatters.OutputFormatJSON:
+		return json.NewEncoder(cmd.OutOrStdout()).Encode(out)
+	case formatters.OutputFormatYAML:
+		return yaml.NewEncoder(cmd.OutOrStdout()).Encode(out)
+	default:
+		return renderCreatedEntries(cmd.OutOrStdout(), out)
+	}
+}
@@ -3,13 +3,18 @@ package coreinstance
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"strings"
+	"time"
 
 	"github.com/itchyny/json2yaml"
 	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp" // register GCP auth provider
 	"k8s.io/client-go/tools/clientcmd"
@@ -19,7 +24,13 @@ import (
 	"github.com/calyptia/cli/cmd/version"
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/idempotency"
 	"github.com/calyptia/cli/k8s"
+	"github.com/calyptia/cli/kustomize"
+	"github.com/calyptia/cli/labels"
+	"github.com/calyptia/cli/progress"
+	"github.com/calyptia/cli/redact"
+	"github.com/calyptia/cli/state"
 )
 
 func newCmdCreateCoreInstanceOnK8s(config *cfg.Config, testClientSet kubernetes.Interface) *cobra.Command {
@@ -37,7 +48,23 @@ func newCmdCreateCoreInstanceOnK8s(config *cfg.Config, testClientSet kubernetes.
 	var skipServiceCreation bool
 	var environment string
 	var tags []string
+	var labelPairs []string
 	var dryRun bool
+	var forceRecreate, adopt, strict, noRollback bool
+	var serviceAccountName string
+	var workloadIdentity k8s.WorkloadIdentity
+	var resources k8s.ResourceOptions
+	var schedulingFlags k8s.SchedulingFlags
+	var imagePullSecrets []string
+	var registryCreds string
+	var saveManifestsDir string
+	var output, outputDir string
+	var overlays []string
+	var fromFile string
+	var createNamespace bool
+	var waitReady, verbose bool
+	var waitTimeout time.Duration
+	var waitFor []string
 
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 	configOverrides := &clientcmd.ConfigOverrides{}
@@ -47,12 +74,82 @@ func newCmdCreateCoreInstanceOnK8s(config *cfg.Config, testClientSet kubernetes.
 		Use:     "kubernetes",
 		Aliases: []string{"kube", "k8s"},
 		Short:   "Setup a new core instance on Kubernetes",
+		Example: "  calyptia create core_instance kubernetes --from-file core-instance.yaml\n" +
+			"  calyptia create core_instance kubernetes --from-file core-instance.yaml --name other --environment staging",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := context.Background()
+			ctx := cmd.Context()
+
+			var manifest coreInstanceManifest
+			if fromFile != "" {
+				var err error
+				manifest, err = readCoreInstanceManifest(fromFile)
+				if err != nil {
+					return err
+				}
+
+				if err := manifest.applyToFlags(cmd.Flags()); err != nil {
+					return err
+				}
+			}
+
+			coreEnv, err := manifest.envVars()
+			if err != nil {
+				return err
+			}
+
+			// flags take precedence over the resources from the manifest.
+			if err := resources.Apply(&manifest.Resources); err != nil {
+				return err
+			}
+
+			tracker, err := progress.FromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			stages, err := parseWaitStages(waitFor, waitTimeout)
+			if err != nil {
+				return err
+			}
+
+			if err := workloadIdentity.Validate(); err != nil {
+				return err
+			}
+
+			if output != "" && output != outputKustomize {
+				return fmt.Errorf("invalid output format %q, options: %s", output, outputKustomize)
+			}
+
+			if output == outputKustomize {
+				if outputDir == "" {
+					return errors.New("--output-dir is required with --output kustomize")
+				}
+
+				// kustomize output only generates the kubernetes objects.
+				dryRun = true
+			}
+
+			tags, err := withLabels(tags, labelPairs)
+			if err != nil {
+				return err
+			}
+
+			scheduling, err := schedulingFlags.Scheduling()
+			if err != nil {
+				return err
+			}
+
+			var registryCredentials *k8s.RegistryCredentials
+			if registryCreds != "" {
+				creds, err := k8s.ParseRegistryCredentials(registryCreds)
+				if err != nil {
+					return err
+				}
+				registryCredentials = &creds
+			}
 
 			var environmentID string
 			if environment != "" {
-				var err error
 				environmentID, err = completer.LoadEnvironmentID(environment)
 				if err != nil {
 					return err
@@ -72,20 +169,37 @@ func newCmdCreateCoreInstanceOnK8s(config *cfg.Config, testClientSet kubernetes.
 				coreInstanceParams.Image = &coreFluentBitDockerImage
 			}
 
-			created, err := config.Cloud.CreateCoreInstance(ctx, coreInstanceParams)
+			idempotencyKey, err := idempotency.KeyFromFlags(cmd, coreInstanceParams)
 			if err != nil {
-				return fmt.Errorf("could not create core instance at calyptia cloud: %w", err)
+				return err
 			}
 
-			if configOverrides.Context.Namespace == "" {
-				configOverrides.Context.Namespace = apiv1.NamespaceDefault
+			existing, err := findIdempotentCoreInstance(ctx, config, idempotencyKey)
+			if err != nil {
+				return err
+			}
+
+			if existing != nil {
+				cmd.Printf("Core instance %q was already created with idempotency key %q, nothing to do\n", existing.Name, idempotencyKey)
+				return nil
+			}
+
+			if idempotencyKey != "" {
+				coreInstanceParams.Tags = labels.Merge(coreInstanceParams.Tags, []string{idempotency.Tag(idempotencyKey)})
+			}
+
+			var created cloud.CreatedCoreInstance
+
+			kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+			namespace, err := k8s.ResolveNamespace(kubeConfig)
+			if err != nil {
+				return err
 			}
 
 			var clientSet kubernetes.Interface
 			if testClientSet != nil {
 				clientSet = testClientSet
 			} else {
-				kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
 				kubeClientConfig, err := kubeConfig.ClientConfig()
 				if err != nil {
 					return err
@@ -99,10 +213,18 @@ func newCmdCreateCoreInstanceOnK8s(config *cfg.Config, testClientSet kubernetes.
 			}
 
 			k8sClient := &k8s.Client{
-				Interface:    clientSet,
-				Namespace:    configOverrides.Context.Namespace,
-				ProjectToken: config.ProjectToken,
-				CloudBaseURL: config.BaseURL,
+				Interface:        clientSet,
+				Namespace:        namespace,
+				ProjectToken:     config.ProjectToken,
+				CloudBaseURL:     config.BaseURL,
+				ConflictPolicy:   conflictPolicy(forceRecreate, adopt),
+				WorkloadIdentity: workloadIdentity,
+				Scheduling:       scheduling,
+				ImagePullSecrets: imagePullSecrets,
+				CoreResources:    manifest.Resources,
+				CoreEnv:          coreEnv,
+				OnQuotaIssues:    quotaIssuesHandler(cmd, strict),
+				OnPodFailure:     tracker.PodFailureReporter(waitCoreInstanceStep),
 				LabelsFunc: func() map[string]string {
 					return map[string]string{
 						k8s.LabelVersion:      version.Version,
@@ -115,31 +237,34 @@ func newCmdCreateCoreInstanceOnK8s(config *cfg.Config, testClientSet kubernetes.
 				},
 			}
 
-			if err := k8sClient.EnsureOwnNamespace(ctx); err != nil {
-				return fmt.Errorf("could not ensure kubernetes namespace exists: %w", err)
-			}
-
-			secret, err := k8sClient.CreateSecret(ctx, created, dryRun)
-			if err != nil {
-				return fmt.Errorf("could not create kubernetes secret from private key: %w", err)
+			// Fail before registering the core instance when it could not be deployed.
+			if !createNamespace {
+				if err := k8sClient.EnsureOwnNamespace(ctx, false); err != nil {
+					return err
+				}
 			}
 
-			var clusterRoleOpts k8s.ClusterRoleOpt
-
-			clusterRoleOpts.EnableOpenShift = enableOpenShift
-			clusterRole, err := k8sClient.CreateClusterRole(ctx, created, dryRun, clusterRoleOpts)
-			if err != nil {
-				return fmt.Errorf("could not create kubernetes cluster role: %w", err)
+			if err := precheckCoreInstanceObjects(ctx, k8sClient, coreInstanceName, environment, false, serviceAccountName == "", dryRun); err != nil {
+				return err
 			}
 
-			serviceAccount, err := k8sClient.CreateServiceAccount(ctx, created, dryRun)
+			err = tracker.Run(ctx, "register core instance", func(ctx context.Context) error {
+				created, err = config.Cloud.CreateCoreInstance(idempotency.ContextWithKey(ctx, idempotencyKey), coreInstanceParams)
+				return err
+			})
 			if err != nil {
-				return fmt.Errorf("could not create kubernetes service account: %w", err)
+				_ = tracker.Summary()
+				return fmt.Errorf("could not create core instance at calyptia cloud: %w", err)
 			}
 
-			binding, err := k8sClient.CreateClusterRoleBinding(ctx, created, clusterRole, serviceAccount, dryRun)
+			err = config.State.Track(cmd, state.Resource{
+				Kind:      state.KindCoreInstance,
+				ID:        created.ID,
+				Name:      created.Name,
+				ProjectID: config.ProjectID,
+			})
 			if err != nil {
-				return fmt.Errorf("could not create kubernetes cluster role binding: %w", err)
+				return err
 			}
 
 			if coreDockerImage == "" {
@@ -154,38 +279,189 @@ func newCmdCreateCoreInstanceOnK8s(config *cfg.Config, testClientSet kubernetes.
 				coreCloudURL = config.BaseURL
 			}
 
-			deploy, err := k8sClient.CreateDeployment(ctx, coreDockerImage, created, coreCloudURL,
-				serviceAccount, !noTLSVerify, skipServiceCreation, dryRun)
+			var (
+				secret         *apiv1.Secret
+				registrySecret *apiv1.Secret
+				clusterRole    *rbacv1.ClusterRole
+				serviceAccount *apiv1.ServiceAccount
+				binding        *rbacv1.ClusterRoleBinding
+				deploy         *appsv1.Deployment
+			)
+
+			steps := []k8s.CoreInstanceStep{
+				{Name: "ensure namespace", ErrMsg: "could not ensure kubernetes namespace exists", Apply: func(ctx context.Context) (metav1.Object, error) {
+					return nil, k8sClient.EnsureOwnNamespace(ctx, createNamespace)
+				}},
+				{Name: "create secret", ErrMsg: "could not create kubernetes secret from private key", Apply: func(ctx context.Context) (_ metav1.Object, err error) {
+					secret, err = k8sClient.CreateSecret(ctx, created, dryRun)
+					return secret, err
+				}},
+			}
+
+			if registryCredentials != nil {
+				steps = append(steps, k8s.CoreInstanceStep{Name: "create registry secret", ErrMsg: "could not create kubernetes image pull secret", Apply: func(ctx context.Context) (_ metav1.Object, err error) {
+					registrySecret, err = k8sClient.CreateRegistrySecret(ctx, created, *registryCredentials, dryRun)
+					if err == nil {
+						k8sClient.ImagePullSecrets = append(k8sClient.ImagePullSecrets, registrySecret.Name)
+					}
+					return registrySecret, err
+				}})
+			}
+
+			if serviceAccountName != "" {
+				steps = append(steps, k8s.CoreInstanceStep{Name: "validate service account", ErrMsg: "could not use kubernetes service account", Apply: func(ctx context.Context) (_ metav1.Object, err error) {
+					// an existing service account is not ours to roll back.
+					serviceAccount, err = k8sClient.ValidateServiceAccount(ctx, serviceAccountName, false, k8s.CoreInstanceRequiredPermissions)
+					return nil, err
+				}})
+			} else {
+				steps = append(steps, []k8s.CoreInstanceStep{
+					{Name: "create cluster role", ErrMsg: "could not create kubernetes cluster role", Apply: func(ctx context.Context) (_ metav1.Object, err error) {
+						clusterRole, err = k8sClient.CreateClusterRole(ctx, created, dryRun, k8s.ClusterRoleOpt{
+							EnableOpenShift: enableOpenShift,
+						})
+						return clusterRole, err
+					}},
+					{Name: "create service account", ErrMsg: "could not create kubernetes service account", Apply: func(ctx context.Context) (_ metav1.Object, err error) {
+						serviceAccount, err = k8sClient.CreateServiceAccount(ctx, created, dryRun)
+						return serviceAccount, err
+					}},
+					{Name: "create cluster role binding", ErrMsg: "could not create kubernetes cluster role binding", Apply: func(ctx context.Context) (_ metav1.Object, err error) {
+						binding, err = k8sClient.CreateClusterRoleBinding(ctx, created, clusterRole, serviceAccount, dryRun)
+						return binding, err
+					}},
+				}...)
+			}
+
+			steps = append(steps, k8s.CoreInstanceStep{Name: "create deployment", ErrMsg: "could not create kubernetes deployment", Apply: func(ctx context.Context) (_ metav1.Object, err error) {
+				deploy, err = k8sClient.CreateDeployment(ctx, coreDockerImage, created, coreCloudURL,
+					serviceAccount, !noTLSVerify, skipServiceCreation, dryRun)
+				return deploy, err
+			}})
+
+			leftBehind, err := k8sClient.ApplyCoreInstanceResources(ctx, k8s.CoreInstancePlan{
+				Steps:      steps,
+				DryRun:     dryRun,
+				NoRollback: noRollback,
+				Run: func(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+					return tracker.Run(ctx, name, func(ctx context.Context) error {
+						return retryableK8sErr(fn(ctx))
+					})
+				},
+			})
 			if err != nil {
-				return fmt.Errorf("could not create kubernetes deployment: %w", err)
+				_ = tracker.Summary()
+				printLeftBehind(cmd, leftBehind)
+				return err
+			}
+
+			if !dryRun {
+				if waitReady && len(stages) == 0 {
+					err := tracker.Run(ctx, waitCoreInstanceStep, func(ctx context.Context) error {
+						return k8sClient.WaitReady(ctx, deploy.Namespace, deploy.Name, verbose, waitTimeout)
+					})
+					if err != nil {
+						_ = tracker.Summary()
+						return err
+					}
+				}
+
+				if err := waitCoreInstanceStages(ctx, config, tracker, created.ID, stages); err != nil {
+					_ = tracker.Summary()
+					return err
+				}
+			}
+
+			if err := tracker.Summary(); err != nil {
+				return err
+			}
+
+			manifests := []k8sManifest{
+				{File: "01-secret.yaml", APIVersion: "v1", Kind: "Secret", Object: secret},
+			}
+			if registrySecret != nil {
+				manifests = append(manifests, k8sManifest{File: "01-registry-secret.yaml", APIVersion: "v1", Kind: "Secret", Object: registrySecret})
+			}
+			if serviceAccountName == "" {
+				manifests = append(manifests,
+					k8sManifest{File: "02-cluster-role.yaml", APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Object: clusterRole},
+					k8sManifest{File: "03-service-account.yaml", APIVersion: "v1", Kind: "ServiceAccount", Object: serviceAccount},
+					k8sManifest{File: "04-cluster-role-binding.yaml", APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding", Object: binding},
+				)
+			}
+			manifests = append(manifests, k8sManifest{File: "05-deployment.yaml", APIVersion: "apps/v1", Kind: "Deployment", Object: deploy})
+
+			if output == outputKustomize {
+				files, err := saveKustomization(outputDir, k8sClient.Namespace, manifests, overlays)
+				if err != nil {
+					return err
+				}
+
+				for _, f := range files {
+					cmd.PrintErrf("Saved %s\n", f)
+				}
+				cmd.PrintErrln("Warning: base/01-secret.yaml holds the core instance private key, encrypt it before committing it.")
+				if registrySecret != nil {
+					cmd.PrintErrln("Warning: base/01-registry-secret.yaml holds the registry credentials, encrypt it before committing it.")
+				}
+				return nil
+			}
+
+			if saveManifestsDir != "" {
+				files, err := saveManifests(saveManifestsDir, manifests)
+				if err != nil {
+					return err
+				}
+
+				for _, f := range files {
+					cmd.PrintErrf("Saved manifest %s\n", f)
+				}
+				cmd.PrintErrln("Warning: 01-secret.yaml holds the core instance private key, encrypt it before committing it.")
+				if registrySecret != nil {
+					cmd.PrintErrln("Warning: 01-registry-secret.yaml holds the registry credentials, encrypt it before committing it.")
+				}
 			}
 
 			if dryRun {
-				fmt.Println("---")
-				printK8sYaml(secret)
-				fmt.Println("---")
-				printK8sYaml(clusterRole)
-				fmt.Println("---")
-				printK8sYaml(serviceAccount)
-				fmt.Println("---")
-				printK8sYaml(binding)
-				fmt.Println("---")
-				printK8sYaml(deploy)
+				w := cmd.OutOrStdout()
+				r := redact.FromFlags(cmd)
+				objs := []any{secret}
+				if registrySecret != nil {
+					objs = append(objs, registrySecret)
+				}
+				if serviceAccountName == "" {
+					objs = append(objs, clusterRole, serviceAccount, binding)
+				}
+				objs = append(objs, deploy)
+
+				for _, obj := range objs {
+					fmt.Fprintln(w, "---")
+					if err := printK8sYaml(w, r.Value(obj)); err != nil {
+						return err
+					}
+				}
 				return nil
 			}
 
 			fmt.Fprintf(cmd.OutOrStdout(), "secret=%q\n", secret.Name)
-			fmt.Fprintf(cmd.OutOrStdout(), "cluster_role=%q\n", clusterRole.Name)
+			if clusterRole != nil {
+				fmt.Fprintf(cmd.OutOrStdout(), "cluster_role=%q\n", clusterRole.Name)
+			}
 			fmt.Fprintf(cmd.OutOrStdout(), "service_account=%q\n", serviceAccount.Name)
-			fmt.Fprintf(cmd.OutOrStdout(), "cluster_role_binding=%q\n", binding.Name)
+			if binding != nil {
+				fmt.Fprintf(cmd.OutOrStdout(), "cluster_role_binding=%q\n", binding.Name)
+			}
 			fmt.Fprintf(cmd.OutOrStdout(), "deployment=%q\n", deploy.Name)
 			return nil
 		},
 	}
 
+	idempotency.BindFlags(cmd)
+
 	fs := cmd.Flags()
 	fs.StringVar(&coreInstanceVersion, "version", "", "Core instance version")
 	fs.StringVar(&coreInstanceName, "name", "", "Core instance name (autogenerated if empty)")
+	fs.StringVar(&fromFile, "from-file", "", "YAML or JSON manifest describing the core instance: name, environment, resources, env vars, sync settings, tags, etc. Flags given along with it take precedence")
 	fs.StringVar(&coreDockerImage, "image", "", "Calyptia core docker image to use (fully composed docker image).")
 	fs.StringVar(&coreFluentBitDockerImage, "fluent-bit-image", "", "Calyptia core fluent-bit image to use.")
 	fs.StringVar(&coreCloudURL, "core-cloud-url", "", "Override the cloud URL for the core instance")
@@ -199,11 +475,39 @@ func newCmdCreateCoreInstanceOnK8s(config *cfg.Config, testClientSet kubernetes.
 	fs.BoolVar(&noTLSVerify, "no-tls-verify", false, "Disable TLS verification when connecting to Calyptia Cloud API.")
 	fs.BoolVar(&skipServiceCreation, "skip-service-creation", false, "Skip the creation of kubernetes services for any pipeline under this core instance.")
 	fs.BoolVar(&dryRun, "dry-run", false, "Passing this value will skip creation of any Kubernetes resources and it will return resources as YAML manifest")
+	fs.BoolVar(&forceRecreate, "force-recreate", false, "Delete and recreate kubernetes resources managed by calyptia that already exist.")
+	fs.BoolVar(&adopt, "adopt", false, "Update kubernetes resources managed by calyptia that already exist into the desired state.")
+	bindNoRollbackFlag(fs, &noRollback)
+	fs.BoolVar(&waitReady, "wait", false, "Wait for the core instance deployment to be ready before returning")
+	fs.StringSliceVar(&waitFor, "wait-for", nil, fmt.Sprintf("Wait for the given stages instead of the deployment to be ready, each within its own timeout in the form of STAGE=TIMEOUT or --timeout otherwise.\nOptions: %s, ie: --wait-for registered,connected=2m,pipelines-ready=5m", strings.Join(waitStages, ", ")))
+	fs.DurationVar(&waitTimeout, "timeout", time.Second*30, "Wait timeout")
+	fs.BoolVar(&verbose, "verbose", false, "Stream the reason the core instance pods are not ready while waiting")
+	fs.BoolVar(&strict, "strict", false, "Fail instead of warning when the namespace resource quotas or limit ranges would reject or mutate the deployment pods.")
+
+	fs.StringVar(&workloadIdentity.AWSRoleARN, "aws-role-arn", "", "AWS IAM role ARN to annotate the generated service account with (IRSA).")
+	fs.StringVar(&workloadIdentity.GCPServiceAccount, "gcp-service-account", "", "GCP IAM service account email to annotate the generated service account with (GKE Workload Identity).")
+	k8s.BindResourceFlags(fs, &resources, "the core instance container")
+	k8s.BindSchedulingFlags(fs, &schedulingFlags)
+	fs.StringArrayVar(&imagePullSecrets, "image-pull-secret", nil, "Existing kubernetes secret to pull the core instance image with from a private registry. Can be given multiple times")
+	fs.StringVar(&registryCreds, "registry-creds", "", "Create a docker registry secret to pull the core instance image with, in the form of user:pass@registry")
+	fs.StringVar(&serviceAccountName, "service-account", "", "Use an existing kubernetes service account instead of creating one along with its cluster role and binding.")
+	fs.StringVar(&saveManifestsDir, "save-manifests", "", "Directory to write the created kubernetes objects into as manifests that can be re-applied later")
+	fs.StringVarP(&output, "output", "o", "", fmt.Sprintf("Generate the kubernetes objects instead of creating them, options: %s", outputKustomize))
+	fs.StringVar(&outputDir, "output-dir", "", "Directory to generate the kustomize base and overlays into")
+	fs.StringSliceVar(&overlays, "overlays", kustomize.DefaultOverlays, "Environments to generate a kustomize overlay for")
+	progress.BindFlag(cmd)
 
 	fs.StringVar(&environment, "environment", "", "Calyptia environment name")
 	fs.StringSliceVar(&tags, "tags", nil, "Tags to apply to the core instance")
+	fs.StringSliceVar(&labelPairs, "labels", nil, "Labels to apply to the core instance in the form of key=value. Core instances can be filtered by them with get core_instances --selector")
 
 	clientcmd.BindOverrideFlags(configOverrides, fs, clientcmd.RecommendedConfigOverrideFlags("kube-"))
+	utils.BindNamespaceCreateFlag(fs, &createNamespace)
+
+	cmd.MarkFlagsMutuallyExclusive("force-recreate", "adopt")
+	cmd.MarkFlagsMutuallyExclusive("output", "save-manifests")
+	cmd.MarkFlagsMutuallyExclusive("service-account", "aws-role-arn")
+	cmd.MarkFlagsMutuallyExclusive("service-account", "gcp-service-account")
 
 	_ = cmd.RegisterFlagCompletionFunc("environment", completer.CompleteEnvironments)
 	_ = cmd.RegisterFlagCompletionFunc("version", completer.CompleteCoreContainerVersion)
@@ -211,14 +515,18 @@ func newCmdCreateCoreInstanceOnK8s(config *cfg.Config, testClientSet kubernetes.
 	return cmd
 }
 
-// GetK8sYaml strips empty properties which would typically be marshalled with yaml.Marshal
-func printK8sYaml(req interface{}) {
-	out, _ := json.Marshal(req)
-	input := strings.NewReader(string(out))
+// printK8sYaml strips empty properties which would typically be marshalled with yaml.Marshal
+func printK8sYaml(w io.Writer, req interface{}) error {
+	out, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
 	var output strings.Builder
-	if err := json2yaml.Convert(&output, input); err != nil {
-		log.Println("failed to convert JSON to YAML:", err)
-		return
+	if err := json2yaml.Convert(&output, strings.NewReader(string(out))); err != nil {
+		return fmt.Errorf("could not convert JSON to YAML: %w", err)
 	}
-	fmt.Println(output.String())
+
+	_, err = fmt.Fprintln(w, output.String())
+	return err
 }
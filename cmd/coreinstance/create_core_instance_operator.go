@@ -9,8 +9,10 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/runtime"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp" // register GCP auth provider
 	restclient "k8s.io/client-go/rest"
@@ -21,9 +23,17 @@ import (
 	"github.com/calyptia/cli/cmd/version"
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/idempotency"
 	"github.com/calyptia/cli/k8s"
+	"github.com/calyptia/cli/labels"
+	"github.com/calyptia/cli/progress"
+	"github.com/calyptia/cli/state"
 )
 
+// waitCoreInstanceStep is the progress step waiting for the core instance
+// sync deployment to be ready.
+const waitCoreInstanceStep = "wait for core instance"
+
 func newCmdCreateCoreInstanceOperator(config *cfg.Config, testClientSet kubernetes.Interface) *cobra.Command {
 	var (
 		coreInstanceName               string
@@ -38,12 +48,22 @@ func newCmdCreateCoreInstanceOperator(config *cfg.Config, testClientSet kubernet
 		skipServiceCreation            bool
 		environment                    string
 		tags                           []string
+		labelPairs                     []string
 		dryRun                         bool
 		waitReady                      bool
+		verbose                        bool
 		waitTimeout                    time.Duration
 		noTLSVerify                    bool
 		metricsPort                    string
 		httpProxy, httpsProxy          string
+		forceRecreate, adopt, strict   bool
+		noRollback                     bool
+		serviceAccountName             string
+		workloadIdentity               k8s.WorkloadIdentity
+		resources                      k8s.ResourceOptions
+		schedulingFlags                k8s.SchedulingFlags
+		imagePullSecrets               []string
+		createNamespace                bool
 	)
 
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
@@ -55,30 +75,43 @@ func newCmdCreateCoreInstanceOperator(config *cfg.Config, testClientSet kubernet
 		Aliases: []string{"opr"},
 		Short:   "Setup a new core operator instance",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := context.Background()
-			if configOverrides.Context.Namespace == "" {
-				namespace, err := k8s.GetCurrentContextNamespace()
-				if err != nil {
-					if errors.Is(err, k8s.ErrNoContext) {
-						cmd.Printf("No context is currently set. Using default namespace.\n")
-					} else {
-						return err
-					}
-				}
-				if namespace != "" {
-					configOverrides.Context.Namespace = namespace
-				} else {
-					configOverrides.Context.Namespace = apiv1.NamespaceDefault
-				}
+			ctx := cmd.Context()
+			if err := workloadIdentity.Validate(); err != nil {
+				return err
+			}
+
+			tracker, err := progress.FromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			tags, err := withLabels(tags, labelPairs)
+			if err != nil {
+				return err
+			}
+
+			scheduling, err := schedulingFlags.Scheduling()
+			if err != nil {
+				return err
+			}
 
+			syncResources, err := resources.Requirements()
+			if err != nil {
+				return err
+			}
+
+			kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+			namespace, err := k8s.ResolveNamespace(kubeConfig)
+			if err != nil {
+				return err
 			}
+
 			var clientSet kubernetes.Interface
 			var kubeClientConfig *restclient.Config
 			if testClientSet != nil {
 				clientSet = testClientSet
 			} else {
 				var err error
-				kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
 				kubeClientConfig, err = kubeConfig.ClientConfig()
 				if err != nil {
 					return err
@@ -96,14 +129,22 @@ func newCmdCreateCoreInstanceOperator(config *cfg.Config, testClientSet kubernet
 			}
 
 			k8sClient := &k8s.Client{
-				Interface:    clientSet,
-				Namespace:    configOverrides.Context.Namespace,
-				ProjectToken: config.ProjectToken,
-				CloudBaseURL: coreCloudURL,
-				Config:       kubeClientConfig,
+				Interface:         clientSet,
+				Namespace:         namespace,
+				ProjectToken:      config.ProjectToken,
+				CloudBaseURL:      coreCloudURL,
+				Config:            kubeClientConfig,
+				ConflictPolicy:    conflictPolicy(forceRecreate, adopt),
+				WorkloadIdentity:  workloadIdentity,
+				Scheduling:        scheduling,
+				ImagePullSecrets:  imagePullSecrets,
+				SyncResources:     syncResources,
+				OnQuotaIssues:     quotaIssuesHandler(cmd, strict),
+				OnRolloutProgress: tracker.RolloutReporter(waitCoreInstanceStep),
+				OnPodFailure:      tracker.PodFailureReporter(waitCoreInstanceStep),
 			}
 
-			if err := k8sClient.EnsureOwnNamespace(ctx); err != nil {
+			if err := k8sClient.EnsureOwnNamespace(ctx, createNamespace); err != nil {
 				return fmt.Errorf("could not ensure kubernetes namespace exists: %w", err)
 			}
 
@@ -124,7 +165,7 @@ func newCmdCreateCoreInstanceOperator(config *cfg.Config, testClientSet kubernet
 				return err
 			}
 
-			fmt.Printf("Found calyptia core operator installed, version: %s...\n", operatorVersion)
+			cmd.PrintErrf("Found calyptia core operator installed, version: %s...\n", operatorVersion)
 			metadata, err := getCoreInstanceMetadata(k8sClient)
 			if err != nil {
 				return err
@@ -175,86 +216,58 @@ func newCmdCreateCoreInstanceOperator(config *cfg.Config, testClientSet kubernet
 				coreInstanceParams.Image = &coreFluentBitDockerImage
 			}
 
-			created, err := config.Cloud.CreateCoreInstance(ctx, coreInstanceParams)
+			idempotencyKey, err := idempotency.KeyFromFlags(cmd, coreInstanceParams)
 			if err != nil {
-				return fmt.Errorf("could not create core instance at calyptia cloud: %w", err)
-			}
-
-			labelsFunc := func() map[string]string {
-				return map[string]string{
-					k8s.LabelVersion:      version.Version,
-					k8s.LabelPartOf:       "calyptia",
-					k8s.LabelComponent:    "operator",
-					k8s.LabelManagedBy:    "calyptia-cli",
-					k8s.LabelCreatedBy:    "calyptia-cli",
-					k8s.LabelProjectID:    config.ProjectID,
-					k8s.LabelAggregatorID: created.ID,
-					k8s.LabelInstance:     coreInstanceName,
-				}
+				return err
 			}
 
-			k8sClient.LabelsFunc = labelsFunc
-
-			var resourcesCreated []k8s.ResourceRollBack
-			secret, err := k8sClient.CreateSecretOperatorRSAKey(ctx, created, dryRun)
-			if err != nil {
-				fmt.Printf("An error occurred while creating the core operator instance. %s Rolling back created resources.\n", err)
-				resources, err := k8sClient.DeleteResources(ctx, resourcesCreated)
-				if err != nil {
-					return fmt.Errorf("could not delete resources: %w", err)
-				}
-				fmt.Printf("Rollback successful. Deleted %d resources.\n", len(resources))
-			}
-			err = addToRollBack(err, secret.Name, secret, &resourcesCreated)
+			existing, err := findIdempotentCoreInstance(ctx, config, idempotencyKey)
 			if err != nil {
 				return err
 			}
 
-			var clusterRoleOpts k8s.ClusterRoleOpt
-			clusterRole, err := k8sClient.CreateClusterRole(ctx, created, dryRun, clusterRoleOpts)
-			if err != nil {
-				fmt.Printf("An error occurred while creating the core operator instance. %s Rolling back created resources.\n", err)
-				resources, err := k8sClient.DeleteResources(ctx, resourcesCreated)
-				if err != nil {
-					return fmt.Errorf("could not delete resources: %w", err)
-				}
-				fmt.Printf("Rollback successful. Deleted %d resources.\n", len(resources))
+			if existing != nil {
+				cmd.Printf("Core instance %q was already created with idempotency key %q, nothing to do\n", existing.Name, idempotencyKey)
+				return nil
 			}
 
-			err = addToRollBack(err, clusterRole.Name, clusterRole, &resourcesCreated)
-			if err != nil {
+			if idempotencyKey != "" {
+				coreInstanceParams.Tags = labels.Merge(coreInstanceParams.Tags, []string{idempotency.Tag(idempotencyKey)})
+			}
+
+			if err := precheckCoreInstanceObjects(ctx, k8sClient, coreInstanceName, environment, true, serviceAccountName == "", dryRun); err != nil {
 				return err
 			}
 
-			serviceAccount, err := k8sClient.CreateServiceAccount(ctx, created, dryRun)
+			created, err := config.Cloud.CreateCoreInstance(idempotency.ContextWithKey(ctx, idempotencyKey), coreInstanceParams)
 			if err != nil {
-				fmt.Printf("An error occurred while creating the core operator instance. %s Rolling back created resources.\n", err)
-				resources, err := k8sClient.DeleteResources(ctx, resourcesCreated)
-				if err != nil {
-					return fmt.Errorf("could not delete resources: %w", err)
-				}
-				fmt.Printf("Rollback successful. Deleted %d resources.\n", len(resources))
+				return fmt.Errorf("could not create core instance at calyptia cloud: %w", err)
 			}
 
-			err = addToRollBack(err, serviceAccount.Name, serviceAccount, &resourcesCreated)
+			err = config.State.Track(cmd, state.Resource{
+				Kind:      state.KindCoreInstance,
+				ID:        created.ID,
+				Name:      created.Name,
+				ProjectID: config.ProjectID,
+			})
 			if err != nil {
 				return err
 			}
 
-			binding, err := k8sClient.CreateClusterRoleBinding(ctx, created, clusterRole, serviceAccount, dryRun)
-			if err != nil {
-				fmt.Printf("An error occurred while creating the core operator instance. %s Rolling back created resources.\n", err)
-				resources, err := k8sClient.DeleteResources(ctx, resourcesCreated)
-				if err != nil {
-					return fmt.Errorf("could not delete resources: %w", err)
+			labelsFunc := func() map[string]string {
+				return map[string]string{
+					k8s.LabelVersion:      version.Version,
+					k8s.LabelPartOf:       "calyptia",
+					k8s.LabelComponent:    "operator",
+					k8s.LabelManagedBy:    "calyptia-cli",
+					k8s.LabelCreatedBy:    "calyptia-cli",
+					k8s.LabelProjectID:    config.ProjectID,
+					k8s.LabelAggregatorID: created.ID,
+					k8s.LabelInstance:     coreInstanceName,
 				}
-				fmt.Printf("Rollback successful. Deleted %d resources.\n", len(resources))
 			}
 
-			err = addToRollBack(err, serviceAccount.Name, binding, &resourcesCreated)
-			if err != nil {
-				return err
-			}
+			k8sClient.LabelsFunc = labelsFunc
 
 			if coreDockerToCloudImage == "" {
 				coreDockerToCloudImageTag := utils.DefaultCoreOperatorToCloudDockerImageTag
@@ -272,45 +285,89 @@ func newCmdCreateCoreInstanceOperator(config *cfg.Config, testClientSet kubernet
 				coreDockerFromCloudImage = fmt.Sprintf("%s:%s", utils.DefaultCoreOperatorFromCloudDockerImage, coreDockerFromCloudImageTag)
 			}
 
-			syncDeployment, err := k8sClient.DeployCoreOperatorSync(ctx, coreCloudURL, coreDockerFromCloudImage, coreDockerToCloudImage, metricsPort, !noTLSVerify, httpProxy, httpsProxy, created, serviceAccount.Name)
+			var (
+				secret         *apiv1.Secret
+				clusterRole    *rbacv1.ClusterRole
+				serviceAccount *apiv1.ServiceAccount
+				binding        *rbacv1.ClusterRoleBinding
+				syncDeployment *appsv1.Deployment
+			)
+
+			steps := []k8s.CoreInstanceStep{
+				{Name: "create secret", ErrMsg: "could not create kubernetes secret", Apply: func(ctx context.Context) (_ metav1.Object, err error) {
+					secret, err = k8sClient.CreateSecretOperatorRSAKey(ctx, created, dryRun)
+					return secret, err
+				}},
+			}
+
+			if serviceAccountName != "" {
+				steps = append(steps, k8s.CoreInstanceStep{Name: "validate service account", ErrMsg: "could not use kubernetes service account", Apply: func(ctx context.Context) (_ metav1.Object, err error) {
+					// an existing service account is not ours to roll back.
+					serviceAccount, err = k8sClient.ValidateServiceAccount(ctx, serviceAccountName, false, k8s.CoreInstanceRequiredPermissions)
+					return nil, err
+				}})
+			} else {
+				steps = append(steps, []k8s.CoreInstanceStep{
+					{Name: "create cluster role", ErrMsg: "could not create kubernetes cluster role", Apply: func(ctx context.Context) (_ metav1.Object, err error) {
+						clusterRole, err = k8sClient.CreateClusterRole(ctx, created, dryRun, k8s.ClusterRoleOpt{})
+						return clusterRole, err
+					}},
+					{Name: "create service account", ErrMsg: "could not create kubernetes service account", Apply: func(ctx context.Context) (_ metav1.Object, err error) {
+						serviceAccount, err = k8sClient.CreateServiceAccount(ctx, created, dryRun)
+						return serviceAccount, err
+					}},
+					{Name: "create cluster role binding", ErrMsg: "could not create kubernetes cluster role binding", Apply: func(ctx context.Context) (_ metav1.Object, err error) {
+						binding, err = k8sClient.CreateClusterRoleBinding(ctx, created, clusterRole, serviceAccount, dryRun)
+						return binding, err
+					}},
+				}...)
+			}
+
+			steps = append(steps, k8s.CoreInstanceStep{Name: "create deployment", ErrMsg: "could not create kubernetes deployment", Apply: func(ctx context.Context) (_ metav1.Object, err error) {
+				syncDeployment, err = k8sClient.DeployCoreOperatorSync(ctx, coreCloudURL, coreDockerFromCloudImage, coreDockerToCloudImage, metricsPort, !noTLSVerify, httpProxy, httpsProxy, created, serviceAccount.Name)
+				return syncDeployment, err
+			}})
+
+			leftBehind, err := k8sClient.ApplyCoreInstanceResources(ctx, k8s.CoreInstancePlan{
+				Steps:      steps,
+				DryRun:     dryRun,
+				NoRollback: noRollback,
+			})
 			if err != nil {
-				fmt.Printf("An error occurred while creating the core operator instance. %s Rolling back created resources.\n", err)
-				resources, err := k8sClient.DeleteResources(ctx, resourcesCreated)
-				if err != nil {
-					return fmt.Errorf("could not delete resources: %w", err)
-				}
-				fmt.Printf("Rollback successful. Deleted %d resources.\n", len(resources))
+				cmd.PrintErrf("An error occurred while creating the core operator instance.\n")
+				printLeftBehind(cmd, leftBehind)
+				return err
 			}
 
 			if waitReady {
-				start := time.Now()
-				fmt.Printf("Waiting for core instance to be ready...\n")
-				err := k8sClient.WaitReady(ctx, syncDeployment.Namespace, syncDeployment.Name, false, waitTimeout)
+				err := tracker.Run(ctx, waitCoreInstanceStep, func(ctx context.Context) error {
+					return k8sClient.WaitReady(ctx, syncDeployment.Namespace, syncDeployment.Name, verbose, waitTimeout)
+				})
 				if err != nil {
 					return err
 				}
-				fmt.Printf("Core instance is ready. Took %s\n", time.Since(start))
 			}
 
-			err = addToRollBack(err, serviceAccount.Name, syncDeployment, &resourcesCreated)
-			if err != nil {
-				return err
-			}
+			cmd.PrintErrf("Core instance created successfully\n")
+			cmd.PrintErrf("Deployed images=(sync-to-cloud: %s, sync-from-cloud: %s)\n", coreDockerToCloudImage, coreDockerFromCloudImage)
+			cmd.PrintErrf("Resources created:\n")
 
-			fmt.Printf("Core instance created successfully\n")
-			fmt.Printf("Deployed images=(sync-to-cloud: %s, sync-from-cloud: %s)\n", coreDockerToCloudImage, coreDockerFromCloudImage)
-			fmt.Printf("Resources created:\n")
-
-			fmt.Printf("Deployment=%s\n", syncDeployment.Name)
-			fmt.Printf("Secret=%s\n", secret.Name)
-			fmt.Printf("ClusterRole=%s\n", clusterRole.Name)
-			fmt.Printf("ClusterRoleBinding=%s\n", binding.Name)
-			fmt.Printf("ServiceAccount=%s\n", serviceAccount.Name)
+			fmt.Fprintf(cmd.OutOrStdout(), "Deployment=%s\n", syncDeployment.Name)
+			fmt.Fprintf(cmd.OutOrStdout(), "Secret=%s\n", secret.Name)
+			if clusterRole != nil {
+				fmt.Fprintf(cmd.OutOrStdout(), "ClusterRole=%s\n", clusterRole.Name)
+			}
+			if binding != nil {
+				fmt.Fprintf(cmd.OutOrStdout(), "ClusterRoleBinding=%s\n", binding.Name)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "ServiceAccount=%s\n", serviceAccount.Name)
 
 			return nil
 		},
 	}
 
+	idempotency.BindFlags(cmd)
+
 	fs := cmd.Flags()
 	fs.StringVar(&coreInstanceName, "name", "", "Core instance name (autogenerated if empty)")
 	fs.StringVar(&coreFluentBitDockerImage, "fluent-bit-image", "", "Calyptia core fluent-bit image to use.")
@@ -330,21 +387,39 @@ func newCmdCreateCoreInstanceOperator(config *cfg.Config, testClientSet kubernet
 
 	fs.BoolVar(&waitReady, "wait", false, "Wait for the core instance to be ready before returning")
 	fs.DurationVar(&waitTimeout, "timeout", time.Second*30, "Wait timeout")
+	fs.BoolVar(&verbose, "verbose", false, "Stream the reason the core instance pods are not ready while waiting")
+	progress.BindFlag(cmd)
 	fs.BoolVar(&noHealthCheckPipeline, "no-health-check-pipeline", false, "Disable health check pipeline creation alongside the core instance")
 	fs.StringVar(&healthCheckPipelinePort, "health-check-pipeline-port-number", "", "Port number to expose the health-check pipeline")
 	fs.StringVar(&healthCheckPipelineServiceType, "health-check-pipeline-service-type", "", fmt.Sprintf("Service type to use for health-check pipeline, options: %s", AllValidPortKinds()))
 	fs.BoolVar(&enableClusterLogging, "enable-cluster-logging", false, "Enable cluster logging pipeline creation.")
 	fs.BoolVar(&skipServiceCreation, "skip-service-creation", false, "Skip the creation of kubernetes services for any pipeline under this core instance.")
 	fs.BoolVar(&dryRun, "dry-run", false, "Passing this value will skip creation of any Kubernetes resources and it will return resources as YAML manifest")
+	fs.BoolVar(&forceRecreate, "force-recreate", false, "Delete and recreate kubernetes resources managed by calyptia that already exist.")
+	fs.BoolVar(&adopt, "adopt", false, "Update kubernetes resources managed by calyptia that already exist into the desired state.")
+	bindNoRollbackFlag(fs, &noRollback)
+	fs.BoolVar(&strict, "strict", false, "Fail instead of warning when the namespace resource quotas or limit ranges would reject or mutate the deployment pods.")
 	fs.BoolVar(&noTLSVerify, "no-tls-verify", false, "Disable TLS verification when connecting to Calyptia Cloud API.")
 	fs.StringVar(&metricsPort, "metrics-port", "15334", "Port for metrics endpoint.")
 	fs.StringVar(&environment, "environment", "", "Calyptia environment name")
+	fs.StringVar(&workloadIdentity.AWSRoleARN, "aws-role-arn", "", "AWS IAM role ARN to annotate the generated service account with (IRSA).")
+	fs.StringVar(&workloadIdentity.GCPServiceAccount, "gcp-service-account", "", "GCP IAM service account email to annotate the generated service account with (GKE Workload Identity).")
+	k8s.BindResourceFlags(fs, &resources, "each sync container")
+	k8s.BindSchedulingFlags(fs, &schedulingFlags)
+	fs.StringArrayVar(&imagePullSecrets, "image-pull-secret", nil, "Existing kubernetes secret to pull the sync images with from a private registry. Can be given multiple times")
+	fs.StringVar(&serviceAccountName, "service-account", "", "Use an existing kubernetes service account instead of creating one along with its cluster role and binding.")
 	fs.StringVar(&httpProxy, "http-proxy", "", "http proxy to use on this core instance")
 	fs.StringVar(&httpsProxy, "https-proxy", "", "http proxy to use on this core instance")
 
 	fs.StringSliceVar(&tags, "tags", nil, "Tags to apply to the core instance")
+	fs.StringSliceVar(&labelPairs, "labels", nil, "Labels to apply to the core instance in the form of key=value. Core instances can be filtered by them with get core_instances --selector")
 
 	clientcmd.BindOverrideFlags(configOverrides, fs, clientcmd.RecommendedConfigOverrideFlags("kube-"))
+	utils.BindNamespaceCreateFlag(fs, &createNamespace)
+
+	cmd.MarkFlagsMutuallyExclusive("force-recreate", "adopt")
+	cmd.MarkFlagsMutuallyExclusive("service-account", "aws-role-arn")
+	cmd.MarkFlagsMutuallyExclusive("service-account", "gcp-service-account")
 
 	_ = cmd.RegisterFlagCompletionFunc("environment", completer.CompleteEnvironments)
 	_ = cmd.RegisterFlagCompletionFunc("version", completer.CompleteCoreContainerVersion)
@@ -352,33 +427,6 @@ func newCmdCreateCoreInstanceOperator(config *cfg.Config, testClientSet kubernet
 	return cmd
 }
 
-func addToRollBack(err error, name string, obj runtime.Object, resourcesCreated *[]k8s.ResourceRollBack) error {
-	if err != nil {
-		return err
-	}
-
-	r, err := extractRollBack(name, obj)
-	if err != nil {
-		return err
-	}
-
-	*resourcesCreated = append(*resourcesCreated, r)
-
-	return nil
-}
-
-func extractRollBack(name string, obj runtime.Object) (k8s.ResourceRollBack, error) {
-	resource, err := k8s.ExtractGroupVersionResource(obj)
-	if err != nil {
-		return k8s.ResourceRollBack{}, err
-	}
-	back := k8s.ResourceRollBack{
-		Name: name,
-		GVR:  resource,
-	}
-	return back, err
-}
-
 func getCoreInstanceMetadata(k8s *k8s.Client) (cloud.CoreInstanceMetadata, error) {
 	var metadata cloud.CoreInstanceMetadata
 
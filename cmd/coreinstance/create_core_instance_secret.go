@@ -2,11 +2,14 @@ package coreinstance
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 	"gopkg.in/yaml.v2"
+	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/calyptia/api/types"
 	"github.com/calyptia/cli/completer"
@@ -20,14 +23,26 @@ func NewCmdCreateCoreInstanceSecret(config *cfg.Config) *cobra.Command {
 	var instanceKey string
 	var key string
 	var value string
+	var fromSecret string
+	source := newK8sSource()
 
 	cmd := &cobra.Command{
 		Use:   "core_instance_secret", // create
 		Short: "Create core instance secrets",
-		Long:  "Create a secret within a core instance",
+		Long: "Create a secret within a core instance.\n" +
+			"With --from-k8s-secret a secret is created from each entry of an existing kubernetes Secret, " +
+			"or only from the one given by --key.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			fs := cmd.Flags()
 
+			if fromSecret != "" {
+				return createCoreInstanceSecretsFromSecret(cmd, config, source, instanceKey, fromSecret, key)
+			}
+
+			if key == "" {
+				return errors.New("either --key or --from-k8s-secret is required")
+			}
+
 			if !fs.Changed("value") {
 				cmd.Print("Enter secret value: ")
 				var err error
@@ -74,16 +89,74 @@ func NewCmdCreateCoreInstanceSecret(config *cfg.Config) *cobra.Command {
 	fs.StringVar(&instanceKey, "core-instance", "", "Core instance ID or name")
 	fs.StringVar(&key, "key", "", "Secret key")
 	fs.StringVar(&value, "value", "", "Secret value")
+	fs.StringVar(&fromSecret, "from-k8s-secret", "", "Create a secret from each entry of the given kubernetes Secret, as NAMESPACE/NAME")
 	formatters.BindFormatFlags(cmd)
+	clientcmd.BindOverrideFlags(source.configOverrides, fs, clientcmd.RecommendedConfigOverrideFlags("kube-"))
 
 	_ = cmd.RegisterFlagCompletionFunc("core-instance", loader.CompleteCoreInstances)
 
 	_ = cmd.MarkFlagRequired("core-instance")
-	_ = cmd.MarkFlagRequired("key")
+	cmd.MarkFlagsMutuallyExclusive("value", "from-k8s-secret")
 
 	return cmd
 }
 
+func createCoreInstanceSecretsFromSecret(cmd *cobra.Command, config *cfg.Config, source *k8sSource, instanceKey, ref, onlyKey string) error {
+	loader := completer.Completer{Config: config}
+	ctx := cmd.Context()
+
+	data, err := source.secretData(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	if onlyKey != "" {
+		v, ok := data[onlyKey]
+		if !ok {
+			return fmt.Errorf("secret %q has no key %q", ref, onlyKey)
+		}
+		data = map[string][]byte{onlyKey: v}
+	}
+
+	if len(data) == 0 {
+		return fmt.Errorf("secret %q has no entries", ref)
+	}
+
+	instanceID, err := loader.LoadCoreInstanceID(instanceKey, "")
+	if err != nil {
+		return err
+	}
+
+	var out []createdEntry
+	for _, k := range sortedKeys(data) {
+		created, err := config.Cloud.CreateCoreInstanceSecret(ctx, types.CreateCoreInstanceSecret{
+			CoreInstanceID: instanceID,
+			Key:            k,
+			Value:          data[k],
+		})
+		if err != nil {
+			return fmt.Errorf("could not create core instance secret %q: %w", k, err)
+		}
+
+		out = append(out, createdEntry{Name: k, Created: created})
+	}
+
+	fs := cmd.Flags()
+	outputFormat := formatters.OutputFormatFromFlags(fs)
+	if fn, ok := formatters.ShouldApplyTemplating(outputFormat); ok {
+		return fn(cmd.OutOrStdout(), formatters.TemplateFromFlags(fs), out)
+	}
+
+	switch outputFormat {
+	case formatters.OutputFormatJSON:
+		return json.NewEncoder(cmd.OutOrStdout()).Encode(out)
+	case formatters.OutputFormatYAML:
+		return yaml.NewEncoder(cmd.OutOrStdout()).Encode(out)
+	default:
+		return renderCreatedEntries(cmd.OutOrStdout(), out)
+	}
+}
+
 func readPassword() (string, error) {
 	b, err := term.ReadPassword(int(os.Stdin.Fd()))
 	if err != nil {
@@ -0,0 +1,50 @@
+package coreinstance
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	cfg "github.com/calyptia/cli/config"
+)
+
+func TestNewCmdCreateCoreInstance_rootHook(t *testing.T) {
+	run := func(config *cfg.Config) (bool, error) {
+		var rootCalled bool
+		root := &cobra.Command{
+			Use: "calyptia",
+			PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+				rootCalled = true
+				return nil
+			},
+		}
+
+		create := NewCmdCreateCoreInstance(config)
+		create.AddCommand(&cobra.Command{
+			Use:  "noop",
+			RunE: func(cmd *cobra.Command, args []string) error { return nil },
+		})
+		root.AddCommand(create)
+		root.SetArgs([]string{"core_instance", "noop"})
+
+		err := root.Execute()
+		return rootCalled, err
+	}
+
+	called, err := run(&cfg.Config{ProjectToken: "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !called {
+		t.Error("expected the root persistent pre-run hook to be called")
+	}
+
+	called, err = run(&cfg.Config{})
+	if err == nil || !strings.Contains(err.Error(), "project token is required") {
+		t.Errorf("expected project token error, got %v", err)
+	}
+	if !called {
+		t.Error("expected the root persistent pre-run hook to be called before checking the token")
+	}
+}
@@ -0,0 +1,279 @@
+package coreinstance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	cloudclient "github.com/calyptia/api/client"
+	cnfg "github.com/calyptia/cli/cmd/config"
+	"github.com/calyptia/cli/completer"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/k8s"
+)
+
+const (
+	defaultDebugImage   = "curlimages/curl:8.4.0"
+	defaultDebugTimeout = time.Minute * 2
+
+	diagnosticOK      = "ok"
+	diagnosticFailed  = "failed"
+	diagnosticSkipped = "skipped"
+)
+
+// debugScript checks DNS resolution and the TLS handshake against the
+// Cloud URL from within the cluster, honoring the core instance proxy settings.
+const debugScript = `if nslookup "$CLOUD_HOST" >/dev/null 2>&1; then
+  echo "dns: ok $CLOUD_HOST resolved"
+else
+  echo "dns: failed could not resolve $CLOUD_HOST"
+fi
+if out=$(curl -sS -o /dev/null --connect-timeout 10 ${INSECURE:+-k} -w "handshake completed, http status %{http_code}" "$CLOUD_URL" 2>&1); then
+  echo "tls: ok $out"
+else
+  echo "tls: failed $out"
+fi
+`
+
+type diagnostic struct {
+	Check  string `json:"check" yaml:"check"`
+	Status string `json:"status" yaml:"status"`
+	Detail string `json:"detail,omitempty" yaml:"detail,omitempty"`
+}
+
+func NewCmdDebugCoreInstance(config *cfg.Config) *cobra.Command {
+	var environment, image string
+	var skipPod bool
+	var timeout time.Duration
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	configOverrides := &clientcmd.ConfigOverrides{}
+	completer := completer.Completer{Config: config}
+
+	cmd := &cobra.Command{
+		Use:   "core_instance CORE_INSTANCE",
+		Short: "Troubleshoot the connection between a core instance and Calyptia Cloud",
+		Long: "Print the proxy settings in effect for the core instance, check its token\n" +
+			"is valid and run a short-lived pod next to it to test the DNS resolution\n" +
+			"and TLS handshake to the Cloud URL.",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completer.CompleteCoreInstances,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			var environmentID string
+			if environment != "" {
+				var err error
+				environmentID, err = completer.LoadEnvironmentID(environment)
+				if err != nil {
+					return err
+				}
+			}
+
+			coreInstanceID, err := completer.LoadCoreInstanceID(args[0], environmentID)
+			if err != nil {
+				return err
+			}
+
+			kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+			namespace, err := k8s.ResolveNamespace(kubeConfig)
+			if err != nil {
+				return err
+			}
+			kubeClientConfig, err := kubeConfig.ClientConfig()
+			if err != nil {
+				return err
+			}
+
+			clientSet, err := kubernetes.NewForConfig(kubeClientConfig)
+			if err != nil {
+				return err
+			}
+
+			k8sClient := &k8s.Client{
+				Interface: clientSet,
+				Namespace: namespace,
+				Config:    kubeClientConfig,
+			}
+
+			cloudURL, token := config.BaseURL, config.ProjectToken
+			var diagnostics []diagnostic
+			var proxyEnv []apiv1.EnvVar
+
+			sync, err := k8sClient.FindSyncDeployment(ctx, fmt.Sprintf("%s=%s", k8s.LabelAggregatorID, coreInstanceID))
+			if err != nil {
+				diagnostics = append(diagnostics, diagnostic{Check: "sync deployment", Status: diagnosticFailed, Detail: err.Error()})
+			} else {
+				diagnostics = append(diagnostics, diagnostic{Check: "sync deployment", Status: diagnosticOK, Detail: sync.Namespace + "/" + sync.Name})
+				if v := sync.Env["CLOUD_URL"]; v != "" {
+					cloudURL = v
+				}
+				if v := sync.Env["TOKEN"]; v != "" {
+					token = v
+				}
+				if v := sync.Env["NO_TLS_VERIFY"]; v == "true" {
+					proxyEnv = append(proxyEnv, apiv1.EnvVar{Name: "INSECURE", Value: v})
+				}
+			}
+
+			for _, name := range k8s.ProxyEnvVars {
+				value := sync.Env[name]
+				detail := "not set"
+				if value != "" {
+					detail = value
+					proxyEnv = append(proxyEnv, apiv1.EnvVar{Name: name, Value: value})
+				}
+				diagnostics = append(diagnostics, diagnostic{Check: "proxy " + name, Status: diagnosticOK, Detail: detail})
+			}
+
+			diagnostics = append(diagnostics, checkCoreInstanceToken(ctx, cloudURL, token))
+
+			if skipPod {
+				diagnostics = append(diagnostics,
+					diagnostic{Check: "dns", Status: diagnosticSkipped},
+					diagnostic{Check: "tls", Status: diagnosticSkipped},
+				)
+			} else {
+				diagnostics = append(diagnostics, runConnectivityPod(ctx, k8sClient, args[0], image, cloudURL, proxyEnv, timeout)...)
+			}
+
+			fs := cmd.Flags()
+			outputFormat := formatters.OutputFormatFromFlags(fs)
+			if fn, ok := formatters.ShouldApplyTemplating(outputFormat); ok {
+				if err := fn(cmd.OutOrStdout(), formatters.TemplateFromFlags(fs), diagnostics); err != nil {
+					return err
+				}
+			} else {
+				switch outputFormat {
+				case formatters.OutputFormatJSON:
+					if err := json.NewEncoder(cmd.OutOrStdout()).Encode(diagnostics); err != nil {
+						return err
+					}
+				case formatters.OutputFormatYAML:
+					if err := yaml.NewEncoder(cmd.OutOrStdout()).Encode(diagnostics); err != nil {
+						return err
+					}
+				default:
+					if err := renderDiagnostics(cmd.OutOrStdout(), diagnostics); err != nil {
+						return err
+					}
+				}
+			}
+
+			var failed int
+			for _, d := range diagnostics {
+				if d.Status == diagnosticFailed {
+					failed++
+				}
+			}
+
+			if failed != 0 {
+				return fmt.Errorf("%d of %d connectivity checks failed", failed, len(diagnostics))
+			}
+
+			return nil
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.StringVar(&environment, "environment", "", "Calyptia environment name")
+	fs.StringVar(&image, "image", defaultDebugImage, "Image of the diagnostic pod. It must include nslookup and curl")
+	fs.BoolVar(&skipPod, "skip-pod", false, "Skip the DNS and TLS checks that require running a diagnostic pod")
+	fs.DurationVar(&timeout, "timeout", defaultDebugTimeout, "Time to wait for the diagnostic pod to complete")
+	formatters.BindFormatFlags(cmd)
+
+	_ = cmd.RegisterFlagCompletionFunc("environment", completer.CompleteEnvironments)
+	clientcmd.BindOverrideFlags(configOverrides, fs, clientcmd.RecommendedConfigOverrideFlags("kube-"))
+
+	return cmd
+}
+
+// checkCoreInstanceToken decodes the project token used by the core instance
+// and verifies it against the Cloud API.
+func checkCoreInstanceToken(ctx context.Context, cloudURL, token string) diagnostic {
+	if token == "" {
+		return diagnostic{Check: "token", Status: diagnosticFailed, Detail: "no project token found"}
+	}
+
+	projectID, err := cnfg.DecodeToken([]byte(token))
+	if err != nil {
+		return diagnostic{Check: "token", Status: diagnosticFailed, Detail: err.Error()}
+	}
+
+	client := &cloudclient.Client{BaseURL: cloudURL, Client: http.DefaultClient}
+	client.SetProjectToken(token)
+	project, err := client.Project(ctx, projectID)
+	if err != nil {
+		return diagnostic{Check: "token", Status: diagnosticFailed, Detail: fmt.Sprintf("rejected by %s: %v", cloudURL, err)}
+	}
+
+	return diagnostic{Check: "token", Status: diagnosticOK, Detail: fmt.Sprintf("valid for project %q", project.Name)}
+}
+
+// runConnectivityPod runs the debug script within a diagnostic pod
+// next to the core instance and returns the DNS and TLS checks.
+func runConnectivityPod(ctx context.Context, k8sClient *k8s.Client, coreInstanceName, image, cloudURL string, env []apiv1.EnvVar, timeout time.Duration) []diagnostic {
+	u, err := url.Parse(cloudURL)
+	if err != nil {
+		return []diagnostic{{Check: "cloud url", Status: diagnosticFailed, Detail: err.Error()}}
+	}
+
+	env = append(env,
+		apiv1.EnvVar{Name: "CLOUD_URL", Value: cloudURL},
+		apiv1.EnvVar{Name: "CLOUD_HOST", Value: u.Hostname()},
+	)
+
+	logs, err := k8sClient.RunDiagnosticPod(ctx, k8s.DiagnosticPod{
+		Name:    k8s.FormatResourceName(coreInstanceName, "debug"),
+		Image:   image,
+		Command: []string{"sh", "-c", debugScript},
+		Env:     env,
+	}, timeout)
+	if err != nil && logs == "" {
+		return []diagnostic{{Check: "diagnostic pod", Status: diagnosticFailed, Detail: err.Error()}}
+	}
+
+	return parseDiagnosticLogs(logs)
+}
+
+// parseDiagnosticLogs parses the "check: status detail" lines
+// printed by the debug script.
+func parseDiagnosticLogs(logs string) []diagnostic {
+	var out []diagnostic
+	for _, line := range strings.Split(logs, "\n") {
+		check, rest, ok := strings.Cut(strings.TrimSpace(line), ": ")
+		if !ok || (check != "dns" && check != "tls") {
+			continue
+		}
+
+		status, detail, _ := strings.Cut(rest, " ")
+		out = append(out, diagnostic{Check: check, Status: status, Detail: detail})
+	}
+
+	if len(out) == 0 {
+		out = append(out, diagnostic{Check: "diagnostic pod", Status: diagnosticFailed, Detail: "no checks reported"})
+	}
+
+	return out
+}
+
+func renderDiagnostics(w io.Writer, diagnostics []diagnostic) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tSTATUS\tDETAIL")
+	for _, d := range diagnostics {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", d.Check, d.Status, d.Detail)
+	}
+	return tw.Flush()
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
 	"github.com/calyptia/cli/confirm"
+	"github.com/calyptia/cli/protection"
 )
 
 func NewCmdDeleteCoreInstance(config *cfg.Config, testClientSet kubernetes.Interface) *cobra.Command {
@@ -45,6 +46,8 @@ func NewCmdDeleteCoreInstances(config *cfg.Config) *cobra.Command {
 				return fmt.Errorf("could not prefetch core instances to delete: %w", err)
 			}
 
+			aa.Items = withoutProtectedCoreInstances(cmd, aa.Items)
+
 			if len(aa.Items) == 0 {
 				cmd.Println("No core instances to delete")
 				return nil
@@ -83,6 +86,32 @@ func NewCmdDeleteCoreInstances(config *cfg.Config) *cobra.Command {
 
 	fs := cmd.Flags()
 	fs.BoolVarP(&confirmed, "yes", "y", isNonInteractive, "Confirm deletion")
+	protection.BindOverrideFlag(cmd)
 
 	return cmd
 }
+
+// checkCoreInstanceProtection fails if the core instance is protected
+// from deletion and --override-protection was not given.
+func checkCoreInstanceProtection(cmd *cobra.Command, config *cfg.Config, coreInstanceID string) error {
+	coreInstance, err := config.Cloud.CoreInstance(cmd.Context(), coreInstanceID)
+	if err != nil {
+		return fmt.Errorf("could not fetch core instance: %w", err)
+	}
+
+	return protection.Check(cmd, "core instance", coreInstance.Name, protection.IsProtected(coreInstance.Tags))
+}
+
+// withoutProtectedCoreInstances leaves the protected core instances out
+// of a bulk deletion, unless --override-protection was given.
+func withoutProtectedCoreInstances(cmd *cobra.Command, aa []types.CoreInstance) []types.CoreInstance {
+	var out []types.CoreInstance
+	for _, a := range aa {
+		if protection.Check(cmd, "core instance", a.Name, protection.IsProtected(a.Tags)) != nil {
+			cmd.PrintErrf("Skipping protected core instance %q\n", a.Name)
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
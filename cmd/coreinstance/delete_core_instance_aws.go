@@ -1,7 +1,6 @@
 package coreinstance
 
 import (
-	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -13,6 +12,7 @@ import (
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
 	"github.com/calyptia/cli/confirm"
+	"github.com/calyptia/cli/protection"
 )
 
 func NewCmdDeleteCoreInstanceOnAWS(config *cfg.Config, client awsclient.Client) *cobra.Command {
@@ -53,9 +53,15 @@ func NewCmdDeleteCoreInstanceOnAWS(config *cfg.Config, client awsclient.Client)
 				return fmt.Errorf("could not load core instance ID: %w", err)
 			}
 
+			if coreInstanceID != "" {
+				if err := checkCoreInstanceProtection(cmd, config, coreInstanceID); err != nil {
+					return err
+				}
+			}
+
 			// TODO: Make sure to delete core instance from Cloud even if we cannot connect to AWS.
 
-			ctx := context.Background()
+			ctx := cmd.Context()
 			if client == nil {
 				client, err = awsclient.New(ctx, coreInstanceName, region, credentials, profileFile, profileName, false)
 				if err != nil {
@@ -130,6 +136,7 @@ func NewCmdDeleteCoreInstanceOnAWS(config *cfg.Config, client awsclient.Client)
 	fs.StringVar(&environment, "environment", "default", "Calyptia environment name")
 	fs.BoolVar(&skipError, "skip-error", false, "Skip errors during delete process")
 	fs.BoolVarP(&confirmDelete, "yes", "y", isNonInteractive, "Confirm deletion")
+	protection.BindOverrideFlag(cmd)
 	fs.BoolVar(&debug, "debug", false, "Enable debug logging")
 
 	_ = cmd.RegisterFlagCompletionFunc("environment", completer.CompleteEnvironments)
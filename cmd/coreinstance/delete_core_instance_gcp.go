@@ -50,7 +50,7 @@ func NewCmdDeleteCoreInstanceOnGCP(config *cfg.Config, client gcp.Client) *cobra
 				return fmt.Errorf("could not get operation: %w", err)
 			}
 
-			cmd.Printf("[*] Waiting for delete operation...")
+			cmd.PrintErr("[*] Waiting for delete operation...")
 
 			rateLimit := rateLimiter.Every(1 * time.Minute / rateLimit)
 			limiter := rateLimiter.NewLimiter(rateLimit, burstNumber)
@@ -65,7 +65,7 @@ func NewCmdDeleteCoreInstanceOnGCP(config *cfg.Config, client gcp.Client) *cobra
 				}
 
 				if operation.Status == OperationConcluded {
-					cmd.Println("done.")
+					cmd.PrintErrln("done.")
 					break
 				}
 			}
@@ -7,7 +7,6 @@ import (
 
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
-	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
@@ -16,6 +15,7 @@ import (
 	cfg "github.com/calyptia/cli/config"
 	"github.com/calyptia/cli/confirm"
 	"github.com/calyptia/cli/k8s"
+	"github.com/calyptia/cli/protection"
 )
 
 const (
@@ -37,7 +37,7 @@ func NewCmdDeleteCoreInstanceK8s(config *cfg.Config, testClientSet kubernetes.In
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: completer.CompleteCoreInstances,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := context.Background()
+			ctx := cmd.Context()
 			var environmentID string
 			if environment != "" {
 				var err error
@@ -53,6 +53,10 @@ func NewCmdDeleteCoreInstanceK8s(config *cfg.Config, testClientSet kubernetes.In
 				return err
 			}
 
+			if err := checkCoreInstanceProtection(cmd, config, coreInstanceID); err != nil {
+				return err
+			}
+
 			if !confirmed {
 				cmd.Printf("Are you sure you want to delete core instance with id %q and all of its associated kubernetes resources? (y/N) ", coreInstanceID)
 				confirmed, err := confirm.Read(cmd.InOrStdin())
@@ -78,15 +82,16 @@ func NewCmdDeleteCoreInstanceK8s(config *cfg.Config, testClientSet kubernetes.In
 
 			cmd.Printf("Successfully deleted core instance with id %q\n", agg.ID)
 
-			if configOverrides.Context.Namespace == "" {
-				configOverrides.Context.Namespace = apiv1.NamespaceDefault
+			kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+			namespace, err := k8s.ResolveNamespace(kubeConfig)
+			if err != nil {
+				return err
 			}
 
 			var clientset kubernetes.Interface
 			if testClientSet != nil {
 				clientset = testClientSet
 			} else {
-				kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
 				kubeClientConfig, err := kubeConfig.ClientConfig()
 				if err != nil {
 					return err
@@ -100,7 +105,7 @@ func NewCmdDeleteCoreInstanceK8s(config *cfg.Config, testClientSet kubernetes.In
 
 			k8sClient := &k8s.Client{
 				Interface:    clientset,
-				Namespace:    configOverrides.Context.Namespace,
+				Namespace:    namespace,
 				ProjectToken: config.ProjectToken,
 				CloudBaseURL: config.BaseURL,
 			}
@@ -223,6 +228,7 @@ func NewCmdDeleteCoreInstanceK8s(config *cfg.Config, testClientSet kubernetes.In
 	fs := cmd.Flags()
 	fs.BoolVar(&skipError, "skip-error", false, "Skip errors during delete process")
 	fs.BoolVarP(&confirmed, "yes", "y", isNonInteractive, "Confirm deletion")
+	protection.BindOverrideFlag(cmd)
 	fs.StringVar(&environment, "environment", "", "Calyptia environment name")
 
 	clientcmd.BindOverrideFlags(configOverrides, fs, clientcmd.RecommendedConfigOverrideFlags("kube-"))
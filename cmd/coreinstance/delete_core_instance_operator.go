@@ -1,13 +1,10 @@
 package coreinstance
 
 import (
-	"errors"
-	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
-	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -15,6 +12,7 @@ import (
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
 	"github.com/calyptia/cli/k8s"
+	"github.com/calyptia/cli/protection"
 )
 
 func NewCmdDeleteCoreInstanceOperator(config *cfg.Config, testClientSet kubernetes.Interface) *cobra.Command {
@@ -54,27 +52,16 @@ func NewCmdDeleteCoreInstanceOperator(config *cfg.Config, testClientSet kubernet
 				return err
 			}
 
-			err = config.Cloud.DeleteCoreInstance(ctx, coreInstance.ID)
-			if err != nil {
+			if err := protection.Check(cmd, "core instance", coreInstance.Name, protection.IsProtected(coreInstance.Tags)); err != nil {
 				return err
 			}
 
-			// delete the k8s resources
-			if configOverrides.Context.Namespace == "" {
-				namespace, err := k8s.GetCurrentContextNamespace()
-				if err != nil {
-					if errors.Is(err, k8s.ErrNoContext) {
-						cmd.Printf("No context is currently set. Using default namespace.\n")
-					} else {
-						return err
-					}
-				}
-				if namespace != "" {
-					configOverrides.Context.Namespace = namespace
-				} else {
-					configOverrides.Context.Namespace = apiv1.NamespaceDefault
-				}
-
+			// connect to the cluster first so a kubeconfig or TLS error
+			// does not leave the k8s resources behind.
+			kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+			namespace, err := k8s.ResolveNamespace(kubeConfig)
+			if err != nil {
+				return err
 			}
 			var clientSet kubernetes.Interface
 			var kubeClientConfig *restclient.Config
@@ -82,7 +69,6 @@ func NewCmdDeleteCoreInstanceOperator(config *cfg.Config, testClientSet kubernet
 				clientSet = testClientSet
 			} else {
 				var err error
-				kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
 				kubeClientConfig, err = kubeConfig.ClientConfig()
 				if err != nil {
 					return err
@@ -96,16 +82,18 @@ func NewCmdDeleteCoreInstanceOperator(config *cfg.Config, testClientSet kubernet
 			}
 			k8sClient := &k8s.Client{
 				Interface:    clientSet,
-				Namespace:    configOverrides.Context.Namespace,
+				Namespace:    namespace,
 				ProjectToken: config.ProjectToken,
 				CloudBaseURL: config.BaseURL,
 				Config:       kubeClientConfig,
 			}
 
-			if err := k8sClient.EnsureOwnNamespace(ctx); err != nil {
-				return fmt.Errorf("could not ensure kubernetes namespace exists: %w", err)
+			err = config.Cloud.DeleteCoreInstance(ctx, coreInstance.ID)
+			if err != nil {
+				return err
 			}
 
+			// delete the k8s resources
 			err = k8sClient.DeleteCoreInstance(ctx, coreInstance.Name, coreInstance.EnvironmentName, wait)
 			if err != nil {
 				return err
@@ -117,7 +105,10 @@ func NewCmdDeleteCoreInstanceOperator(config *cfg.Config, testClientSet kubernet
 	isNonInteractive := os.Stdin == nil || !term.IsTerminal(int(os.Stdin.Fd()))
 	fs := cmd.Flags()
 	fs.BoolVarP(&confirmed, "yes", "y", isNonInteractive, "Confirm deletion")
+	protection.BindOverrideFlag(cmd)
 	fs.StringVar(&environment, "environment", "", "Calyptia environment name")
 	fs.BoolVar(&wait, "wait", false, "Wait for the core instance to be deleted")
+
+	clientcmd.BindOverrideFlags(configOverrides, fs, clientcmd.RecommendedConfigOverrideFlags("kube-"))
 	return cmd
 }
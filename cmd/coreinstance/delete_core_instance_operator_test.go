@@ -0,0 +1,98 @@
+package coreinstance
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/calyptia/api/client"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	cfg "github.com/calyptia/cli/config"
+)
+
+func TestNewCmdDeleteCoreInstanceOperator(t *testing.T) {
+	kubeconfig := clientcmdapi.NewConfig()
+	kubeconfig.Clusters["cluster"] = &clientcmdapi.Cluster{Server: "https://127.0.0.1:6443"}
+	kubeconfig.Contexts["cluster"] = &clientcmdapi.Context{Cluster: "cluster", Namespace: "calyptia"}
+	kubeconfig.CurrentContext = "cluster"
+	kubeconfigPath := filepath.Join(t.TempDir(), "config")
+	if err := clientcmd.WriteToFile(*kubeconfig, kubeconfigPath); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(clientcmd.RecommendedConfigPathEnvVar, kubeconfigPath)
+
+	var deleted bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/projects/project-1/core_instances":
+			_, _ = w.Write([]byte(`{"items":[{"id":"core-1","name":"my-core"}]}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/aggregators/core-1":
+			_, _ = w.Write([]byte(`{"id":"core-1","name":"my-core"}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/aggregators/core-1":
+			deleted = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"not found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	cloud := client.New()
+	cloud.BaseURL = srv.URL
+	config := &cfg.Config{Ctx: context.Background(), Cloud: cloud, ProjectID: "project-1"}
+
+	t.Run("tls flags", func(t *testing.T) {
+		cmd := NewCmdDeleteCoreInstanceOperator(config, nil)
+		for _, name := range []string{"kube-insecure-skip-tls-verify", "kube-certificate-authority", "kube-tls-server-name"} {
+			if cmd.Flags().Lookup(name) == nil {
+				t.Errorf("expected flag --%s", name)
+			}
+		}
+	})
+
+	t.Run("cluster error keeps the core instance", func(t *testing.T) {
+		deleted = false
+		cmd := NewCmdDeleteCoreInstanceOperator(config, nil)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs([]string{"my-core", "--yes", "--kube-certificate-authority", filepath.Join(t.TempDir(), "missing.crt")})
+		if err := cmd.ExecuteContext(context.Background()); err == nil {
+			t.Fatal("expected the cluster connection to fail")
+		}
+
+		if deleted {
+			t.Error("expected the core instance not to be deleted from the cloud")
+		}
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		deleted = false
+		clientSet := fake.NewSimpleClientset(
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "my-core-sync", Namespace: "calyptia"}},
+		)
+		cmd := NewCmdDeleteCoreInstanceOperator(config, clientSet)
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetArgs([]string{"my-core", "--yes"})
+		if err := cmd.ExecuteContext(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if !deleted {
+			t.Error("expected the core instance to be deleted from the cloud")
+		}
+
+		if out.String() != "Core instance my-core deleted\n" {
+			t.Errorf("unexpected output %q", out.String())
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package coreinstance
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -8,11 +9,17 @@ import (
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
+	"k8s.io/client-go/tools/clientcmd"
 
 	cloud "github.com/calyptia/api/types"
+	"github.com/calyptia/cli/cmd/kubecontexts"
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/exitcode"
 	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/labels"
+	"github.com/calyptia/cli/pagination"
+	"github.com/calyptia/cli/redact"
 )
 
 func NewCmdGetCoreInstances(config *cfg.Config) *cobra.Command {
@@ -20,14 +27,26 @@ func NewCmdGetCoreInstances(config *cfg.Config) *cobra.Command {
 	var showIDs bool
 	var showMetadata bool
 	var environment string
+	var selector string
 	var outputFormat, goTemplate string
+	var multiContext kubecontexts.Flags
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	configOverrides := &clientcmd.ConfigOverrides{}
 	completer := completer.Completer{Config: config}
 
 	cmd := &cobra.Command{
 		Use:     "core_instances",
 		Aliases: []string{"instances", "core_instances"},
 		Short:   "Display latest core instances from a project",
+		Long: "Display latest core instances from a project.\n\n" +
+			"With --all-contexts, or a comma separated list of --kube-context, the clusters\n" +
+			"are checked concurrently for the core instances deployed in them and their status.",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			contexts, err := multiContext.Contexts(loadingRules, configOverrides)
+			if err != nil {
+				return err
+			}
+
 			var environmentID string
 			if environment != "" {
 				var err error
@@ -36,20 +55,41 @@ func NewCmdGetCoreInstances(config *cfg.Config) *cobra.Command {
 					return err
 				}
 			}
+			sel, err := labels.ParseSelector(selector)
+			if err != nil {
+				return err
+			}
+
 			var params cloud.CoreInstancesParams
 
-			params.Last = &last
 			if environmentID != "" {
 				params.EnvironmentID = &environmentID
 			}
 
-			aa, err := config.Cloud.CoreInstances(config.Ctx, config.ProjectID, params)
+			items, next, err := pagination.List(config.Ctx, pagination.FromFlags(cmd), func(ctx context.Context, last uint, before *string) (pagination.Page[cloud.CoreInstance], error) {
+				params.Last, params.Before = &last, before
+				aa, err := config.Cloud.CoreInstances(ctx, config.ProjectID, params)
+				return pagination.Page[cloud.CoreInstance]{Items: aa.Items, EndCursor: aa.EndCursor}, err
+			})
 			if err != nil {
 				return fmt.Errorf("could not fetch your core instances: %w", err)
 			}
 
-			if strings.HasPrefix(outputFormat, "go-template") {
-				return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, aa.Items)
+			aa := cloud.CoreInstances{Items: items, EndCursor: next}
+			defer pagination.PrintNext(cmd, next)
+
+			aa.Items = filterCoreInstances(aa.Items, sel)
+
+			if err := exitcode.FailOnEmpty(cmd, len(aa.Items)); err != nil {
+				return err
+			}
+
+			if contexts != nil {
+				return runGetCoreInstancesOnContexts(cmd, loadingRules, configOverrides, contexts, aa.Items, outputFormat, goTemplate)
+			}
+
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, aa.Items)
 			}
 
 			switch outputFormat {
@@ -81,9 +121,9 @@ func NewCmdGetCoreInstances(config *cfg.Config) *cobra.Command {
 				}
 				tw.Flush()
 			case "json":
-				return json.NewEncoder(cmd.OutOrStdout()).Encode(aa.Items)
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(redact.FromFlags(cmd).Value(aa.Items))
 			case "yml", "yaml":
-				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(aa.Items)
+				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(redact.FromFlags(cmd).Value(aa.Items))
 			default:
 				return fmt.Errorf("unknown output format %q", outputFormat)
 			}
@@ -93,14 +133,35 @@ func NewCmdGetCoreInstances(config *cfg.Config) *cobra.Command {
 
 	fs := cmd.Flags()
 	fs.UintVarP(&last, "last", "l", 0, "Last `N` core instances. 0 means no limit")
+	pagination.BindFlags(cmd)
+	_ = fs.MarkDeprecated("last", "use --limit instead")
 	fs.BoolVar(&showIDs, "show-ids", false, "Include core instance IDs in table output")
 	fs.BoolVar(&showMetadata, "show-metadata", false, "Include core instance metadata in table output")
 	fs.StringVar(&environment, "environment", "", "Calyptia environment name.")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	fs.StringVar(&selector, "selector", "", "Label selector to filter core instances on. Supports key=value, key!=value, key and !key separated by commas")
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 
 	_ = cmd.RegisterFlagCompletionFunc("environment", completer.CompleteEnvironments)
 	_ = cmd.RegisterFlagCompletionFunc("output-format", formatters.CompleteOutputFormat)
 
+	exitcode.BindFailOnEmptyFlag(cmd)
+	kubecontexts.BindFlags(cmd, &multiContext)
+	clientcmd.BindOverrideFlags(configOverrides, fs, clientcmd.RecommendedConfigOverrideFlags("kube-"))
+
 	return cmd
 }
+
+func filterCoreInstances(aa []cloud.CoreInstance, sel labels.Selector) []cloud.CoreInstance {
+	if len(sel) == 0 {
+		return aa
+	}
+
+	var out []cloud.CoreInstance
+	for _, a := range aa {
+		if sel.Matches(a.Tags) {
+			out = append(out, a)
+		}
+	}
+	return out
+}
@@ -0,0 +1,132 @@
+package coreinstance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+	"k8s.io/client-go/tools/clientcmd"
+
+	cloud "github.com/calyptia/api/types"
+	"github.com/calyptia/cli/cmd/kubecontexts"
+	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/k8s"
+)
+
+// clusterCoreInstance is a core instance deployed in a cluster
+// along with its status on Cloud.
+type clusterCoreInstance struct {
+	Name          string                   `json:"name" yaml:"name"`
+	Namespace     string                   `json:"namespace" yaml:"namespace"`
+	Deployment    string                   `json:"deployment" yaml:"deployment"`
+	Status        cloud.CoreInstanceStatus `json:"status" yaml:"status"`
+	Replicas      int32                    `json:"replicas" yaml:"replicas"`
+	ReadyReplicas int32                    `json:"readyReplicas" yaml:"readyReplicas"`
+}
+
+// clusterCoreInstances are the core instances found in a single cluster.
+type clusterCoreInstances struct {
+	Context       string                `json:"context" yaml:"context"`
+	CoreInstances []clusterCoreInstance `json:"coreInstances,omitempty" yaml:"coreInstances,omitempty"`
+	Error         string                `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// matchCoreInstances pairs the deployments found in a cluster with the
+// given Cloud core instances. Deployments of other core instances are skipped.
+func matchCoreInstances(deployments []k8s.CoreInstanceDeployment, aa []cloud.CoreInstance) []clusterCoreInstance {
+	byID := make(map[string]cloud.CoreInstance, len(aa))
+	for _, a := range aa {
+		byID[a.ID] = a
+	}
+
+	var out []clusterCoreInstance
+	for _, d := range deployments {
+		a, ok := byID[d.CoreInstanceID]
+		if !ok {
+			continue
+		}
+
+		out = append(out, clusterCoreInstance{
+			Name:          a.Name,
+			Namespace:     d.Namespace,
+			Deployment:    d.Name,
+			Status:        a.Status,
+			Replicas:      d.Replicas,
+			ReadyReplicas: d.ReadyReplicas,
+		})
+	}
+	return out
+}
+
+func clusterCoreInstancesResult(cc []clusterCoreInstance) string {
+	if len(cc) == 0 {
+		return "no core instances"
+	}
+
+	out := make([]string, len(cc))
+	for i, c := range cc {
+		out[i] = fmt.Sprintf("%s %s %d/%d ready", c.Name, c.Status, c.ReadyReplicas, c.Replicas)
+	}
+	return strings.Join(out, ", ")
+}
+
+func runGetCoreInstancesOnContexts(cmd *cobra.Command, loadingRules *clientcmd.ClientConfigLoadingRules, configOverrides *clientcmd.ConfigOverrides, contexts []string, aa []cloud.CoreInstance, outputFormat, goTemplate string) error {
+	clusters := make([]clusterCoreInstances, len(contexts))
+
+	var mu sync.Mutex
+	results := kubecontexts.Run(cmd.Context(), contexts, func(ctx context.Context, kubeContext string) (string, error) {
+		k, _, err := kubecontexts.ClientForContext(loadingRules, configOverrides, kubeContext)
+		if err != nil {
+			return "", err
+		}
+
+		deployments, err := k.CoreInstanceDeployments(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		cc := matchCoreInstances(deployments, aa)
+		mu.Lock()
+		for i := range contexts {
+			if contexts[i] == kubeContext {
+				clusters[i].CoreInstances = cc
+			}
+		}
+		mu.Unlock()
+
+		return clusterCoreInstancesResult(cc), nil
+	})
+
+	for i, r := range results {
+		clusters[i].Context = r.Context
+		clusters[i].Error = r.Error
+	}
+
+	if formatters.IsTemplating(outputFormat) {
+		if err := formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, clusters); err != nil {
+			return err
+		}
+		return kubecontexts.ResultsErr(results)
+	}
+
+	switch outputFormat {
+	case "table":
+		return kubecontexts.RenderResults(cmd.OutOrStdout(), results)
+	case "json":
+		if err := json.NewEncoder(cmd.OutOrStdout()).Encode(clusters); err != nil {
+			return err
+		}
+	case "yml", "yaml":
+		if err := yaml.NewEncoder(cmd.OutOrStdout()).Encode(clusters); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown output format %q", outputFormat)
+	}
+
+	return kubecontexts.ResultsErr(results)
+}
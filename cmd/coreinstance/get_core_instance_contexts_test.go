@@ -0,0 +1,41 @@
+package coreinstance
+
+import (
+	"reflect"
+	"testing"
+
+	cloud "github.com/calyptia/api/types"
+	"github.com/calyptia/cli/k8s"
+)
+
+func Test_matchCoreInstances(t *testing.T) {
+	deployments := []k8s.CoreInstanceDeployment{
+		{CoreInstanceID: "core-1", Name: "prod-fluent-bit", Namespace: "calyptia", Replicas: 2, ReadyReplicas: 1},
+		{CoreInstanceID: "core-other-project", Name: "other", Namespace: "default", Replicas: 1, ReadyReplicas: 1},
+	}
+	aa := []cloud.CoreInstance{
+		{ID: "core-1", Name: "prod", Status: cloud.CoreInstanceStatusRunning},
+		{ID: "core-2", Name: "staging", Status: cloud.CoreInstanceStatusRunning},
+	}
+
+	got := matchCoreInstances(deployments, aa)
+	want := []clusterCoreInstance{{
+		Name:          "prod",
+		Namespace:     "calyptia",
+		Deployment:    "prod-fluent-bit",
+		Status:        cloud.CoreInstanceStatusRunning,
+		Replicas:      2,
+		ReadyReplicas: 1,
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %+v, got %+v", want, got)
+	}
+
+	if got, want := clusterCoreInstancesResult(got), "prod running 1/2 ready"; got != want {
+		t.Errorf("want result %q, got %q", want, got)
+	}
+
+	if got, want := clusterCoreInstancesResult(nil), "no core instances"; got != want {
+		t.Errorf("want result %q, got %q", want, got)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/calyptia/api/types"
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/exitcode"
 	"github.com/calyptia/cli/formatters"
 )
 
@@ -57,6 +58,10 @@ func NewCmdGetCoreInstanceFiles(config *cfg.Config) *cobra.Command {
 				return err
 			}
 
+			if err := exitcode.FailOnEmpty(cmd, len(out.Items)); err != nil {
+				return err
+			}
+
 			outputFormat := formatters.OutputFormatFromFlags(fs)
 			if fn, ok := formatters.ShouldApplyTemplating(outputFormat); ok {
 				return fn(cmd.OutOrStdout(), formatters.TemplateFromFlags(fs), out)
@@ -83,6 +88,8 @@ func NewCmdGetCoreInstanceFiles(config *cfg.Config) *cobra.Command {
 
 	_ = cmd.MarkFlagRequired("core-instance")
 
+	exitcode.BindFailOnEmptyFlag(cmd)
+
 	return cmd
 }
 
@@ -12,7 +12,9 @@ import (
 	"github.com/calyptia/api/types"
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/exitcode"
 	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/redact"
 )
 
 func NewCmdGetCoreInstanceSecrets(config *cfg.Config) *cobra.Command {
@@ -57,6 +59,10 @@ func NewCmdGetCoreInstanceSecrets(config *cfg.Config) *cobra.Command {
 				return err
 			}
 
+			if err := exitcode.FailOnEmpty(cmd, len(out.Items)); err != nil {
+				return err
+			}
+
 			outputFormat := formatters.OutputFormatFromFlags(fs)
 			if fn, ok := formatters.ShouldApplyTemplating(outputFormat); ok {
 				return fn(cmd.OutOrStdout(), formatters.TemplateFromFlags(fs), out)
@@ -64,9 +70,9 @@ func NewCmdGetCoreInstanceSecrets(config *cfg.Config) *cobra.Command {
 
 			switch outputFormat {
 			case formatters.OutputFormatJSON:
-				return json.NewEncoder(cmd.OutOrStdout()).Encode(out)
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(redact.FromFlags(cmd).Secrets(out))
 			case formatters.OutputFormatYAML:
-				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(out)
+				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(redact.FromFlags(cmd).Secrets(out))
 			default:
 				return renderCoreInstanceSecrets(cmd.OutOrStdout(), instanceID, in.Before != nil, out)
 			}
@@ -83,6 +89,8 @@ func NewCmdGetCoreInstanceSecrets(config *cfg.Config) *cobra.Command {
 
 	_ = cmd.MarkFlagRequired("core-instance")
 
+	exitcode.BindFailOnEmptyFlag(cmd)
+
 	return cmd
 }
 
@@ -0,0 +1,109 @@
+package coreinstance
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/calyptia/api/types"
+	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/k8s"
+)
+
+// k8sSource reads the entries of an in-cluster ConfigMap or Secret
+// to seed core instance files and secrets from.
+type k8sSource struct {
+	loadingRules    *clientcmd.ClientConfigLoadingRules
+	configOverrides *clientcmd.ConfigOverrides
+}
+
+func newK8sSource() *k8sSource {
+	return &k8sSource{
+		loadingRules:    clientcmd.NewDefaultClientConfigLoadingRules(),
+		configOverrides: &clientcmd.ConfigOverrides{},
+	}
+}
+
+func (s *k8sSource) client() (*k8s.Client, string, error) {
+	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(s.loadingRules, s.configOverrides)
+	kubeClientConfig, err := kubeConfig.ClientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+
+	namespace, err := k8s.ResolveNamespace(kubeConfig)
+	if err != nil {
+		return nil, "", err
+	}
+
+	clientSet, err := kubernetes.NewForConfig(kubeClientConfig)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &k8s.Client{
+		Interface: clientSet,
+		Namespace: namespace,
+		Config:    kubeClientConfig,
+	}, namespace, nil
+}
+
+// configMapData returns the entries of the NAMESPACE/NAME ConfigMap.
+func (s *k8sSource) configMapData(ctx context.Context, ref string) (map[string][]byte, error) {
+	client, namespace, err := s.client()
+	if err != nil {
+		return nil, err
+	}
+
+	objRef, err := k8s.ParseObjectRef(ref, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.ConfigMapData(ctx, objRef)
+}
+
+// secretData returns the entries of the NAMESPACE/NAME Secret.
+func (s *k8sSource) secretData(ctx context.Context, ref string) (map[string][]byte, error) {
+	client, namespace, err := s.client()
+	if err != nil {
+		return nil, err
+	}
+
+	objRef, err := k8s.ParseObjectRef(ref, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.SecretData(ctx, objRef)
+}
+
+func sortedKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// createdEntry is a core instance file or secret created from a
+// ConfigMap or Secret entry.
+type createdEntry struct {
+	Name          string `json:"name" yaml:"name"`
+	types.Created `yaml:",inline"`
+}
+
+func renderCreatedEntries(w io.Writer, entries []createdEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tID\tCREATED-AT")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", e.Name, e.ID, formatters.FmtTimestamp(e.CreatedAt))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,178 @@
+package coreinstance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/itchyny/json2yaml"
+
+	"github.com/calyptia/cli/kustomize"
+)
+
+const outputKustomize = "kustomize"
+
+// k8sManifest is a kubernetes object to be saved as a manifest file.
+type k8sManifest struct {
+	File       string
+	APIVersion string
+	Kind       string
+	Object     any
+}
+
+// serverPopulatedMetadata are the metadata fields set by the kubernetes
+// API server that must not be part of a declarative manifest.
+var serverPopulatedMetadata = []string{
+	"uid",
+	"resourceVersion",
+	"generation",
+	"creationTimestamp",
+	"managedFields",
+	"selfLink",
+}
+
+// serverPopulatedDefaults are the fields defaulted by the kubernetes API server
+// along with their default value. They are only stripped when left untouched.
+var serverPopulatedDefaults = map[string]any{
+	"spec.revisionHistoryLimit":                        float64(10),
+	"spec.progressDeadlineSeconds":                     float64(600),
+	"spec.template.spec.dnsPolicy":                     "ClusterFirst",
+	"spec.template.spec.restartPolicy":                 "Always",
+	"spec.template.spec.schedulerName":                 "default-scheduler",
+	"spec.template.spec.terminationGracePeriodSeconds": float64(30),
+}
+
+// serverPopulatedContainerDefaults are the container fields defaulted
+// by the kubernetes API server along with their default value.
+var serverPopulatedContainerDefaults = map[string]any{
+	"terminationMessagePath":   "/dev/termination-log",
+	"terminationMessagePolicy": "File",
+}
+
+// saveManifests writes the given objects into dir, one file per object,
+// as they were applied but without the fields populated by the server.
+func saveManifests(dir string, manifests []k8sManifest) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("could not create manifests directory: %w", err)
+	}
+
+	var files []string
+	for _, m := range manifests {
+		out, err := manifestYAML(m)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate %s manifest: %w", strings.ToLower(m.Kind), err)
+		}
+
+		// manifests can hold secrets, so keep them private to the user.
+		name := filepath.Join(dir, m.File)
+		if err := os.WriteFile(name, []byte(out), 0o600); err != nil {
+			return nil, fmt.Errorf("could not write manifest: %w", err)
+		}
+
+		files = append(files, name)
+	}
+	return files, nil
+}
+
+// saveKustomization writes the given objects as a kustomize base
+// along with an overlay per environment into dir.
+func saveKustomization(dir, namespace string, manifests []k8sManifest, overlays []string) ([]string, error) {
+	resources := make([]kustomize.Resource, 0, len(manifests))
+	for _, m := range manifests {
+		out, err := manifestYAML(m)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate %s manifest: %w", strings.ToLower(m.Kind), err)
+		}
+
+		resources = append(resources, kustomize.Resource{File: m.File, Content: out})
+	}
+
+	files, err := kustomize.Write(dir, namespace, resources, overlays)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate kustomization: %w", err)
+	}
+	return files, nil
+}
+
+func manifestYAML(m k8sManifest) (string, error) {
+	b, err := json.Marshal(m.Object)
+	if err != nil {
+		return "", err
+	}
+
+	var obj map[string]any
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return "", err
+	}
+
+	stripServerPopulatedFields(obj)
+	obj["apiVersion"] = m.APIVersion
+	obj["kind"] = m.Kind
+
+	b, err = json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	if err := json2yaml.Convert(&out, strings.NewReader(string(b))); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func stripServerPopulatedFields(obj map[string]any) {
+	delete(obj, "status")
+
+	if metadata, ok := obj["metadata"].(map[string]any); ok {
+		for _, k := range serverPopulatedMetadata {
+			delete(metadata, k)
+		}
+
+		if annotations, ok := metadata["annotations"].(map[string]any); ok {
+			delete(annotations, "deployment.kubernetes.io/revision")
+			if len(annotations) == 0 {
+				delete(metadata, "annotations")
+			}
+		}
+	}
+
+	for path, def := range serverPopulatedDefaults {
+		deleteIfDefault(obj, strings.Split(path, "."), def)
+	}
+
+	containers, _ := lookupPath(obj, []string{"spec", "template", "spec", "containers"}).([]any)
+	for _, c := range containers {
+		if c, ok := c.(map[string]any); ok {
+			for k, def := range serverPopulatedContainerDefaults {
+				deleteIfDefault(c, []string{k}, def)
+			}
+		}
+	}
+}
+
+func lookupPath(obj map[string]any, path []string) any {
+	var v any = obj
+	for _, p := range path {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[p]
+	}
+	return v
+}
+
+func deleteIfDefault(obj map[string]any, path []string, def any) {
+	parent, ok := lookupPath(obj, path[:len(path)-1]).(map[string]any)
+	if !ok {
+		return
+	}
+
+	key := path[len(path)-1]
+	if v, ok := parent[key]; ok && v == def {
+		delete(parent, key)
+	}
+}
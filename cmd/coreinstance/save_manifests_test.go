@@ -0,0 +1,120 @@
+package coreinstance
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_stripServerPopulatedFields(t *testing.T) {
+	obj := map[string]any{
+		"metadata": map[string]any{
+			"name":              "core",
+			"uid":               "8a3c",
+			"resourceVersion":   "42",
+			"generation":        float64(3),
+			"creationTimestamp": "2023-11-09T13:48:25Z",
+			"managedFields":     []any{},
+			"annotations": map[string]any{
+				"deployment.kubernetes.io/revision": "3",
+			},
+			"labels": map[string]any{"app": "core"},
+		},
+		"spec": map[string]any{
+			"replicas":                float64(1),
+			"revisionHistoryLimit":    float64(10),
+			"progressDeadlineSeconds": float64(300),
+			"template": map[string]any{
+				"spec": map[string]any{
+					"dnsPolicy":     "ClusterFirst",
+					"restartPolicy": "Always",
+					"containers": []any{
+						map[string]any{
+							"name":                     "core",
+							"terminationMessagePath":   "/dev/termination-log",
+							"terminationMessagePolicy": "FallbackToLogsOnError",
+						},
+					},
+				},
+			},
+		},
+		"status": map[string]any{"readyReplicas": float64(1)},
+	}
+
+	stripServerPopulatedFields(obj)
+
+	want := map[string]any{
+		"metadata": map[string]any{
+			"name":   "core",
+			"labels": map[string]any{"app": "core"},
+		},
+		"spec": map[string]any{
+			"replicas": float64(1),
+			// customized values are kept.
+			"progressDeadlineSeconds": float64(300),
+			"template": map[string]any{
+				"spec": map[string]any{
+					"containers": []any{
+						map[string]any{
+							"name":                     "core",
+							"terminationMessagePolicy": "FallbackToLogsOnError",
+						},
+					},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(obj, want) {
+		t.Errorf("want %+v, got %+v", want, obj)
+	}
+}
+
+func Test_saveManifests(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "core",
+			Namespace:       "calyptia",
+			ResourceVersion: "42",
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "core", Image: "ghcr.io/calyptia/core"}},
+				},
+			},
+		},
+	}
+
+	dir := filepath.Join(t.TempDir(), "manifests")
+	files, err := saveManifests(dir, []k8sManifest{{File: "deployment.yaml", APIVersion: "apps/v1", Kind: "Deployment", Object: deployment}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 1 || files[0] != filepath.Join(dir, "deployment.yaml") {
+		t.Fatalf("unexpected files %v", files)
+	}
+
+	b, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := string(b)
+	for _, s := range []string{"apiVersion: apps/v1", "kind: Deployment", "name: core", "image: ghcr.io/calyptia/core"} {
+		if !strings.Contains(got, s) {
+			t.Errorf("expected manifest to contain %q, got:\n%s", s, got)
+		}
+	}
+	for _, s := range []string{"resourceVersion", "status:"} {
+		if strings.Contains(got, s) {
+			t.Errorf("expected manifest not to contain %q, got:\n%s", s, got)
+		}
+	}
+}
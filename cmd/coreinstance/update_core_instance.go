@@ -1,9 +1,13 @@
 package coreinstance
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/spf13/cobra"
 
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/labels"
 )
 
 func NewCmdUpdateCoreInstance(config *cfg.Config) *cobra.Command {
@@ -17,3 +21,20 @@ func NewCmdUpdateCoreInstance(config *cfg.Config) *cobra.Command {
 	cmd.AddCommand(NewCmdUpdateCoreInstanceOnGCP(config))
 	return cmd
 }
+
+// mergeLabels sets the given key=value labels on top of the current
+// core instance tags, replacing existing labels with the same key.
+func mergeLabels(ctx context.Context, config *cfg.Config, coreInstanceID string, labelPairs []string) (*[]string, error) {
+	ll, err := labels.Parse(labelPairs)
+	if err != nil {
+		return nil, err
+	}
+
+	coreInstance, err := config.Cloud.CoreInstance(ctx, coreInstanceID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch core instance: %w", err)
+	}
+
+	tags := labels.Merge(coreInstance.Tags, ll)
+	return &tags, nil
+}
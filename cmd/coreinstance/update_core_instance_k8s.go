@@ -1,13 +1,13 @@
 package coreinstance
 
 import (
-	"context"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
-	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 
 	cloud "github.com/calyptia/api/types"
@@ -15,15 +15,21 @@ import (
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
 	"github.com/calyptia/cli/k8s"
+	"github.com/calyptia/cli/protection"
 )
 
 func NewCmdUpdateCoreInstanceK8s(config *cfg.Config, testClientSet kubernetes.Interface) *cobra.Command {
 	var newVersion, newName, environment string
+	var labelPairs []string
+	var setEnv, unsetEnv []string
 	var (
 		disableClusterLogging bool
 		enableClusterLogging  bool
 		noTLSVerify           bool
 		skipServiceCreation   bool
+		reconcileRBAC         bool
+		enableOpenShift       bool
+		createNamespace       bool
 	)
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 	configOverrides := &clientcmd.ConfigOverrides{}
@@ -36,7 +42,7 @@ func NewCmdUpdateCoreInstanceK8s(config *cfg.Config, testClientSet kubernetes.In
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: completer.CompleteCoreInstances,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := context.Background()
+			ctx := cmd.Context()
 			coreInstanceKey := args[0]
 
 			var environmentID string
@@ -52,6 +58,11 @@ func NewCmdUpdateCoreInstanceK8s(config *cfg.Config, testClientSet kubernetes.In
 				return err
 			}
 
+			envChange, err := parseEnvVarsChange(setEnv, unsetEnv)
+			if err != nil {
+				return err
+			}
+
 			if coreInstanceKey == newName {
 				return fmt.Errorf("cannot update core instance with the same name")
 			}
@@ -77,9 +88,20 @@ func NewCmdUpdateCoreInstanceK8s(config *cfg.Config, testClientSet kubernetes.In
 				opts.SkipServiceCreation = &skipServiceCreation
 			}
 
-			err = config.Cloud.UpdateCoreInstance(config.Ctx, coreInstanceID, opts)
+			protect, err := protection.FromFlags(cmd)
 			if err != nil {
-				return fmt.Errorf("could not update core instance at calyptia cloud: %w", err)
+				return err
+			}
+
+			if protect != nil {
+				labelPairs = append(labelPairs, protection.Tag(*protect))
+			}
+
+			if len(labelPairs) != 0 {
+				opts.Tags, err = mergeLabels(config.Ctx, config, coreInstanceID, labelPairs)
+				if err != nil {
+					return err
+				}
 			}
 
 			agg, err := config.Cloud.CoreInstance(ctx, coreInstanceID)
@@ -87,17 +109,31 @@ func NewCmdUpdateCoreInstanceK8s(config *cfg.Config, testClientSet kubernetes.In
 				return err
 			}
 
-			if configOverrides.Context.Namespace == "" {
-				configOverrides.Context.Namespace = apiv1.NamespaceDefault
+			// the core container keeps the name the core instance was deployed with.
+			coreContainer := agg.Name
+
+			err = config.Cloud.UpdateCoreInstance(config.Ctx, coreInstanceID, opts)
+			if err != nil {
+				return fmt.Errorf("could not update core instance at calyptia cloud: %w", err)
 			}
 
-			if newVersion != "" {
+			if opts.Name != nil {
+				agg.Name = *opts.Name
+			}
+
+			kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+			namespace, err := k8s.ResolveNamespace(kubeConfig)
+			if err != nil {
+				return err
+			}
+
+			if newVersion != "" || reconcileRBAC || len(setEnv) != 0 || len(unsetEnv) != 0 || enableOpenShift {
 				var clientSet kubernetes.Interface
+				var kubeClientConfig *restclient.Config
 				if testClientSet != nil {
 					clientSet = testClientSet
 				} else {
-					kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
-					kubeClientConfig, err := kubeConfig.ClientConfig()
+					kubeClientConfig, err = kubeConfig.ClientConfig()
 					if err != nil {
 						return err
 					}
@@ -111,27 +147,54 @@ func NewCmdUpdateCoreInstanceK8s(config *cfg.Config, testClientSet kubernetes.In
 
 				k8sClient := &k8s.Client{
 					Interface:    clientSet,
-					Namespace:    configOverrides.Context.Namespace,
+					Namespace:    namespace,
 					ProjectToken: config.ProjectToken,
 					CloudBaseURL: config.BaseURL,
+					Config:       kubeClientConfig,
 				}
 				label := fmt.Sprintf("%s=%s,!%s", k8s.LabelAggregatorID, agg.ID, k8s.LabelPipelineID)
 
-				coreDockerImage := fmt.Sprintf("%s:%s", utils.DefaultCoreDockerImage, newVersion)
+				if newVersion != "" {
+					coreDockerImage := fmt.Sprintf("%s:%s", utils.DefaultCoreDockerImage, newVersion)
+
+					if err := k8sClient.EnsureOwnNamespace(ctx, createNamespace); err != nil {
+						return fmt.Errorf("could not ensure kubernetes namespace exists: %w", err)
+					}
+
+					if err := k8sClient.UpdateDeploymentByLabel(ctx, label, coreDockerImage, strconv.FormatBool(!noTLSVerify)); err != nil {
+						return fmt.Errorf("could not update kubernetes deployment: %w", err)
+					}
 
-				if err := k8sClient.EnsureOwnNamespace(ctx); err != nil {
-					return fmt.Errorf("could not ensure kubernetes namespace exists: %w", err)
+					cmd.Printf("calyptia-core instance version updated to version %s\n", newVersion)
 				}
 
-				if err := k8sClient.UpdateDeploymentByLabel(ctx, label, coreDockerImage, strconv.FormatBool(!noTLSVerify)); err != nil {
-					return fmt.Errorf("could not update kubernetes deployment: %w", err)
+				// drift is reported on version updates, and patched
+				// with --reconcile-rbac whether updating or not.
+				if newVersion != "" || reconcileRBAC {
+					if err := reconcileClusterRoles(cmd, k8sClient, agg.ID, reconcileRBAC); err != nil {
+						return err
+					}
 				}
 
-				if err != nil {
-					return err
+				if len(setEnv) != 0 || len(unsetEnv) != 0 {
+					updated, err := k8sClient.UpdateEnvVarsByLabel(ctx, label, coreContainer, envChange)
+					if err != nil {
+						return fmt.Errorf("could not update kubernetes deployment environment variables: %w", err)
+					}
+
+					for _, name := range updated {
+						cmd.Printf("deployment %q environment variables updated, rolling out\n", name)
+					}
+					if len(updated) == 0 {
+						cmd.Println("environment variables already up to date")
+					}
 				}
-				cmd.Printf("calyptia-core instance version updated to version %s\n", newVersion)
 
+				if enableOpenShift {
+					if err := enableCoreInstanceOpenShift(cmd, k8sClient, agg); err != nil {
+						return err
+					}
+				}
 			}
 
 			cmd.Printf("calyptia-core instance successfully updated\n")
@@ -146,9 +209,114 @@ func NewCmdUpdateCoreInstanceK8s(config *cfg.Config, testClientSet kubernetes.In
 	fs.BoolVar(&disableClusterLogging, "disable-cluster-logging", false, "Disable cluster logging functionality")
 	fs.BoolVar(&noTLSVerify, "no-tls-verify", false, "Disable TLS verification when connecting to Calyptia Cloud API.")
 	fs.BoolVar(&skipServiceCreation, "skip-service-creation", false, "Skip the creation of kubernetes services for any pipeline under this core instance.")
+	fs.BoolVar(&enableOpenShift, "enable-openshift", false, "Add the OpenShift security context constraints rules to the existing cluster role and apply the security context constraints for the core instance service accounts")
+	fs.BoolVar(&reconcileRBAC, "reconcile-rbac", false, "Add the cluster role rules required by the new version that are missing from the existing cluster role")
+	fs.StringSliceVar(&labelPairs, "labels", nil, "Labels to set on the core instance in the form of key=value. Existing labels with the same key get replaced")
+	protection.BindFlags(cmd)
+	fs.StringArrayVar(&setEnv, "set-env", nil, "Environment variable to set on the core instance container in the form of KEY=VALUE. Deployments get rolled out")
+	fs.StringSliceVar(&unsetEnv, "unset-env", nil, "Environment variable to remove from the core instance container. Deployments get rolled out")
 
 	_ = cmd.RegisterFlagCompletionFunc("environment", completer.CompleteEnvironments)
 	_ = cmd.RegisterFlagCompletionFunc("version", completer.CompleteCoreContainerVersion)
 	clientcmd.BindOverrideFlags(configOverrides, fs, clientcmd.RecommendedConfigOverrideFlags("kube-"))
+	utils.BindNamespaceCreateFlag(fs, &createNamespace)
 	return cmd
 }
+
+// reconcileClusterRoles shows the rules the core instance cluster roles are
+// missing compared to the ones generated by this version of the CLI,
+// and adds them when patch is set.
+func reconcileClusterRoles(cmd *cobra.Command, k8sClient *k8s.Client, coreInstanceID string, patch bool) error {
+	drifts, err := k8sClient.CoreInstanceClusterRoleDrift(cmd.Context(), coreInstanceID)
+	if err != nil {
+		return err
+	}
+
+	for _, drift := range drifts {
+		cmd.Printf("cluster role %q is missing rules:\n", drift.ClusterRole.Name)
+		for _, rule := range drift.Missing {
+			cmd.Printf("+ %s\n", k8s.FormatPolicyRule(rule))
+		}
+
+		if !patch {
+			continue
+		}
+
+		if err := k8sClient.PatchClusterRoleDrift(cmd.Context(), drift); err != nil {
+			return err
+		}
+		cmd.Printf("cluster role %q patched\n", drift.ClusterRole.Name)
+	}
+
+	if len(drifts) != 0 && !patch {
+		cmd.PrintErrln("Warning: some features may not work until the cluster role is updated. Run again with --reconcile-rbac to add the missing rules.")
+	}
+
+	return nil
+}
+
+// enableCoreInstanceOpenShift patches the core instance cluster roles with
+// the security.openshift.io rules and applies the security context
+// constraints for the service accounts its deployments run as.
+func enableCoreInstanceOpenShift(cmd *cobra.Command, k8sClient *k8s.Client, agg cloud.CoreInstance) error {
+	ctx := cmd.Context()
+
+	patched, err := k8sClient.EnableOpenShiftClusterRoles(ctx, agg.ID)
+	if err != nil {
+		return fmt.Errorf("could not update kubernetes cluster role: %w", err)
+	}
+
+	for _, drift := range patched {
+		cmd.Printf("cluster role %q patched with OpenShift rules\n", drift.ClusterRole.Name)
+	}
+	if len(patched) == 0 {
+		cmd.Println("cluster role already allows OpenShift security context constraints")
+	}
+
+	users, err := k8sClient.CoreInstanceServiceAccounts(ctx, agg.ID)
+	if err != nil {
+		return err
+	}
+
+	name := k8s.FormatResourceName(agg.Name, agg.EnvironmentName, "scc")
+	if err := k8sClient.ApplyOpenShiftSCC(ctx, name, users); err != nil {
+		return fmt.Errorf("could not apply security context constraints: %w", err)
+	}
+
+	cmd.Printf("security context constraints %q applied for %s\n", name, strings.Join(users, ", "))
+	return nil
+}
+
+// parseEnvVarsChange parses the --set-env KEY=VALUE pairs and --unset-env
+// names, validating them against the known core instance variables.
+func parseEnvVarsChange(setEnv, unsetEnv []string) (k8s.EnvVarsChange, error) {
+	change := k8s.EnvVarsChange{Unset: unsetEnv}
+	for _, pair := range setEnv {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return change, fmt.Errorf("invalid environment variable %q, expected KEY=VALUE", pair)
+		}
+
+		if change.Set == nil {
+			change.Set = map[string]string{}
+		}
+		change.Set[name] = value
+	}
+
+	for _, name := range unsetEnv {
+		if _, ok := change.Set[name]; ok {
+			return change, fmt.Errorf("environment variable %q cannot be both set and unset", name)
+		}
+	}
+
+	names := append([]string{}, unsetEnv...)
+	for name := range change.Set {
+		names = append(names, name)
+	}
+
+	if err := k8s.ValidateCoreInstanceEnvVars(names...); err != nil {
+		return change, err
+	}
+
+	return change, nil
+}
@@ -0,0 +1,69 @@
+package coreinstance
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/calyptia/api/client"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/k8s"
+)
+
+func TestNewCmdUpdateCoreInstanceK8s_reconcileRBAC(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/projects/project-1/core_instances":
+			_, _ = w.Write([]byte(`{"items":[{"id":"core-1","name":"my-core"}]}`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/v1/aggregators/core-1":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/aggregators/core-1":
+			_, _ = w.Write([]byte(`{"id":"core-1","name":"my-core"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"not found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	cloud := client.New()
+	cloud.BaseURL = srv.URL
+	config := &cfg.Config{Ctx: context.Background(), Cloud: cloud, ProjectID: "project-1"}
+
+	clientSet := fake.NewSimpleClientset(&rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "my-core-cluster-role",
+			Labels: map[string]string{k8s.LabelAggregatorID: "core-1"},
+		},
+	})
+
+	// without --version, the cluster role used to be left as it was.
+	cmd := NewCmdUpdateCoreInstanceK8s(config, clientSet)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{"my-core", "--reconcile-rbac"})
+	if err := cmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out.String(), `cluster role "my-core-cluster-role" patched`) {
+		t.Errorf("expected cluster role to be reported as patched, got %q", out.String())
+	}
+
+	role, err := clientSet.RbacV1().ClusterRoles().Get(context.Background(), "my-core-cluster-role", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if missing := k8s.MissingPolicyRules(role.Rules, k8s.ClusterRoleRules()); len(missing) != 0 {
+		t.Errorf("expected cluster role to have every rule, missing %v", missing)
+	}
+}
@@ -1,26 +1,27 @@
 package coreinstance
 
 import (
-	"context"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
-	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 
 	cloud "github.com/calyptia/api/types"
+	"github.com/calyptia/cli/cmd/utils"
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
 	"github.com/calyptia/cli/k8s"
+	"github.com/calyptia/cli/protection"
 	"github.com/calyptia/core-images-index/go-index"
 )
 
 func NewCmdUpdateCoreInstanceOperator(config *cfg.Config, testClientSet kubernetes.Interface) *cobra.Command {
 	var newVersion, newName, environment string
+	var labelPairs []string
 	var (
 		disableClusterLogging bool
 		enableClusterLogging  bool
@@ -28,6 +29,7 @@ func NewCmdUpdateCoreInstanceOperator(config *cfg.Config, testClientSet kubernet
 		skipServiceCreation   bool
 		verbose               bool
 		waitTimeout           time.Duration
+		createNamespace       bool
 	)
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 	configOverrides := &clientcmd.ConfigOverrides{}
@@ -59,7 +61,7 @@ func NewCmdUpdateCoreInstanceOperator(config *cfg.Config, testClientSet kubernet
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := context.Background()
+			ctx := cmd.Context()
 			coreInstanceKey := args[0]
 
 			var environmentID string
@@ -100,13 +102,31 @@ func NewCmdUpdateCoreInstanceOperator(config *cfg.Config, testClientSet kubernet
 				opts.SkipServiceCreation = &skipServiceCreation
 			}
 
+			protect, err := protection.FromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			if protect != nil {
+				labelPairs = append(labelPairs, protection.Tag(*protect))
+			}
+
+			if len(labelPairs) != 0 {
+				opts.Tags, err = mergeLabels(config.Ctx, config, coreInstanceID, labelPairs)
+				if err != nil {
+					return err
+				}
+			}
+
 			err = config.Cloud.UpdateCoreInstance(config.Ctx, coreInstanceID, opts)
 			if err != nil {
 				return fmt.Errorf("could not update core instance at calyptia cloud: %w", err)
 			}
 
-			if configOverrides.Context.Namespace == "" {
-				configOverrides.Context.Namespace = apiv1.NamespaceDefault
+			kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+			namespace, err := k8s.ResolveNamespace(kubeConfig)
+			if err != nil {
+				return err
 			}
 
 			if newVersion != "" {
@@ -114,7 +134,6 @@ func NewCmdUpdateCoreInstanceOperator(config *cfg.Config, testClientSet kubernet
 				if testClientSet != nil {
 					clientSet = testClientSet
 				} else {
-					kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
 					kubeClientConfig, err := kubeConfig.ClientConfig()
 					if err != nil {
 						return err
@@ -129,15 +148,19 @@ func NewCmdUpdateCoreInstanceOperator(config *cfg.Config, testClientSet kubernet
 
 				k8sClient := &k8s.Client{
 					Interface:    clientSet,
-					Namespace:    configOverrides.Context.Namespace,
+					Namespace:    namespace,
 					ProjectToken: config.ProjectToken,
 					CloudBaseURL: config.BaseURL,
 				}
-				
-				if err := k8sClient.EnsureOwnNamespace(ctx); err != nil {
+
+				if err := k8sClient.EnsureOwnNamespace(ctx, createNamespace); err != nil {
 					return fmt.Errorf("could not ensure kubernetes namespace exists: %w", err)
 				}
 
+				k8sClient.OnPodFailure = func(deployment, pod, reason string) {
+					cmd.PrintErrf("deployment %q: pod %s: %s\n", deployment, pod, reason)
+				}
+
 				label := fmt.Sprintf("%s=%s", k8s.LabelInstance, coreInstanceKey)
 				cmd.Printf("Waiting for core-instance to update...\n")
 				if err := k8sClient.UpdateSyncDeploymentByLabel(ctx, label, newVersion, strconv.FormatBool(!noTLSVerify), verbose, waitTimeout); err != nil {
@@ -167,9 +190,12 @@ func NewCmdUpdateCoreInstanceOperator(config *cfg.Config, testClientSet kubernet
 	fs.BoolVar(&verbose, "verbose", false, "Print verbose command output")
 	fs.DurationVar(&waitTimeout, "timeout", time.Second*30, "Wait timeout")
 	fs.BoolVar(&skipServiceCreation, "skip-service-creation", false, "Skip the creation of kubernetes services for any pipeline under this core instance.")
+	fs.StringSliceVar(&labelPairs, "labels", nil, "Labels to set on the core instance in the form of key=value. Existing labels with the same key get replaced")
+	protection.BindFlags(cmd)
 
 	_ = cmd.RegisterFlagCompletionFunc("environment", completer.CompleteEnvironments)
 	_ = cmd.RegisterFlagCompletionFunc("version", completer.CompleteCoreOperatorVersion)
 	clientcmd.BindOverrideFlags(configOverrides, fs, clientcmd.RecommendedConfigOverrideFlags("kube-"))
+	utils.BindNamespaceCreateFlag(fs, &createNamespace)
 	return cmd
 }
@@ -0,0 +1,143 @@
+package coreinstance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	cloud "github.com/calyptia/api/types"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/exitcode"
+	"github.com/calyptia/cli/progress"
+)
+
+const (
+	// waitStageRegistered waits for the core instance to be visible on Calyptia Cloud.
+	waitStageRegistered = "registered"
+	// waitStageConnected waits for the first heartbeat of the core instance.
+	waitStageConnected = "connected"
+	// waitStagePipelinesReady waits for the pipelines the core instance
+	// was created with, ie: health check, to be started.
+	waitStagePipelinesReady = "pipelines-ready"
+
+	waitStagePollInterval = 3 * time.Second
+)
+
+// waitStages in the order they happen.
+var waitStages = []string{waitStageRegistered, waitStageConnected, waitStagePipelinesReady}
+
+// waitStage is a stage to wait for after creating a core instance.
+type waitStage struct {
+	Name    string
+	Timeout time.Duration
+}
+
+// parseWaitStages parses the --wait-for values, in the form of STAGE or
+// STAGE=TIMEOUT, into the stages to wait for in the order they happen.
+func parseWaitStages(values []string, defaultTimeout time.Duration) ([]waitStage, error) {
+	timeouts := map[string]time.Duration{}
+	for _, v := range values {
+		name, timeout, hasTimeout := strings.Cut(v, "=")
+		if !isWaitStage(name) {
+			return nil, fmt.Errorf("invalid wait stage %q, options: %s", name, strings.Join(waitStages, ", "))
+		}
+
+		d := defaultTimeout
+		if hasTimeout {
+			var err error
+			d, err = time.ParseDuration(timeout)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid timeout %q for wait stage %q", timeout, name)
+			}
+		}
+		timeouts[name] = d
+	}
+
+	var out []waitStage
+	for _, name := range waitStages {
+		if d, ok := timeouts[name]; ok {
+			out = append(out, waitStage{Name: name, Timeout: d})
+		}
+	}
+	return out, nil
+}
+
+func isWaitStage(name string) bool {
+	for _, s := range waitStages {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// waitCoreInstanceStages waits for each stage in order, each one within its own timeout.
+func waitCoreInstanceStages(ctx context.Context, config *cfg.Config, tracker *progress.Tracker, coreInstanceID string, stages []waitStage) error {
+	for _, stage := range stages {
+		stage := stage
+		step := "wait for core instance " + stage.Name
+		err := tracker.Run(ctx, step, func(ctx context.Context) error {
+			err := wait.PollUntilContextTimeout(ctx, waitStagePollInterval, stage.Timeout, true, func(ctx context.Context) (bool, error) {
+				return checkWaitStage(ctx, config, tracker, step, coreInstanceID, stage.Name)
+			})
+			if wait.Interrupted(err) && !errors.Is(err, context.Canceled) {
+				return exitcode.WithCode(exitcode.Timeout, fmt.Errorf("core instance not %s within %s", stage.Name, stage.Timeout))
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkWaitStage(ctx context.Context, config *cfg.Config, tracker *progress.Tracker, step, coreInstanceID, stage string) (bool, error) {
+	switch stage {
+	case waitStageRegistered:
+		_, err := config.Cloud.CoreInstance(ctx, coreInstanceID)
+		if exitcode.FromError(err) == exitcode.NotFound {
+			return false, nil
+		}
+		return err == nil, err
+	case waitStageConnected:
+		coreInstance, err := config.Cloud.CoreInstance(ctx, coreInstanceID)
+		if err != nil {
+			return false, err
+		}
+		tracker.Report(step, fmt.Sprintf("core instance status %s", coreInstance.Status), 0, 0)
+		return coreInstance.Status == cloud.CoreInstanceStatusRunning, nil
+	case waitStagePipelinesReady:
+		pipelines, err := config.Cloud.Pipelines(ctx, cloud.PipelinesParams{CoreInstanceID: &coreInstanceID})
+		if err != nil {
+			return false, err
+		}
+
+		started, err := startedPipelines(pipelines.Items)
+		if err != nil {
+			return false, err
+		}
+		tracker.Report(step, "pipelines started", started, len(pipelines.Items))
+		return started == len(pipelines.Items), nil
+	default:
+		return false, fmt.Errorf("unknown wait stage %q", stage)
+	}
+}
+
+// startedPipelines counts the started pipelines, failing on the first failed one.
+func startedPipelines(pipelines []cloud.Pipeline) (int, error) {
+	var started int
+	for _, p := range pipelines {
+		switch p.Status.Status {
+		case cloud.PipelineStatusFailed:
+			return started, fmt.Errorf("pipeline %q failed", p.Name)
+		case cloud.PipelineStatusStarted:
+			started++
+		}
+	}
+	return started, nil
+}
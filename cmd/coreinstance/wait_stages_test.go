@@ -0,0 +1,108 @@
+package coreinstance
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/calyptia/api/client"
+	cloud "github.com/calyptia/api/types"
+
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/progress"
+)
+
+func Test_parseWaitStages(t *testing.T) {
+	got, err := parseWaitStages([]string{"pipelines-ready=1m", "registered"}, time.Minute*5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []waitStage{
+		{Name: waitStageRegistered, Timeout: time.Minute * 5},
+		{Name: waitStagePipelinesReady, Timeout: time.Minute},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+
+	for _, v := range []string{"ready", "connected=soon", "connected=-1s"} {
+		if _, err := parseWaitStages([]string{v}, time.Minute); err == nil {
+			t.Errorf("expected error for %q", v)
+		}
+	}
+}
+
+func Test_startedPipelines(t *testing.T) {
+	pipeline := func(name string, status cloud.PipelineStatusKind) cloud.Pipeline {
+		return cloud.Pipeline{Name: name, Status: cloud.PipelineStatus{Status: status}}
+	}
+
+	started, err := startedPipelines([]cloud.Pipeline{
+		pipeline("a", cloud.PipelineStatusStarted),
+		pipeline("b", cloud.PipelineStatusNew),
+	})
+	if err != nil || started != 1 {
+		t.Errorf("want 1 started pipeline, got %d, %v", started, err)
+	}
+
+	if _, err := startedPipelines([]cloud.Pipeline{pipeline("a", cloud.PipelineStatusFailed)}); err == nil {
+		t.Error("expected a failed pipeline to fail")
+	}
+}
+
+func Test_checkWaitStage(t *testing.T) {
+	var coreInstance, pipelines string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/aggregators/core-1":
+			if coreInstance == "" {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = io.WriteString(w, `{"error":"not found"}`)
+				return
+			}
+			_, _ = io.WriteString(w, coreInstance)
+		case "/v1/core_instances/core-1/pipelines":
+			_, _ = io.WriteString(w, pipelines)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"error":"not found"}`)
+		}
+	}))
+	defer srv.Close()
+
+	cloudClient := client.New()
+	cloudClient.BaseURL = srv.URL
+	config := &cfg.Config{Cloud: cloudClient}
+	tracker := progress.New(io.Discard, progress.ModeQuiet)
+
+	check := func(stage string, want bool) {
+		t.Helper()
+		got, err := checkWaitStage(context.Background(), config, tracker, "wait", "core-1", stage)
+		if err != nil {
+			t.Fatalf("%s: %v", stage, err)
+		}
+		if got != want {
+			t.Errorf("%s: want done %v, got %v", stage, want, got)
+		}
+	}
+
+	check(waitStageRegistered, false)
+
+	coreInstance = `{"id":"core-1","status":"waiting"}`
+	check(waitStageRegistered, true)
+	check(waitStageConnected, false)
+
+	coreInstance = `{"id":"core-1","status":"running"}`
+	check(waitStageConnected, true)
+
+	pipelines = `{"items":[{"id":"p-1","status":{"status":"STARTED"}},{"id":"p-2","status":{"status":"NEW"}}]}`
+	check(waitStagePipelinesReady, false)
+
+	pipelines = `{"items":[{"id":"p-1","status":{"status":"STARTED"}}]}`
+	check(waitStagePipelinesReady, true)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/calyptia/cli/cmd/resourceprofile"
 	"github.com/calyptia/cli/cmd/tracesession"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/features"
 )
 
 func newCmdCreate(config *cfg.Config) *cobra.Command {
@@ -29,13 +30,20 @@ func newCmdCreate(config *cfg.Config) *cobra.Command {
 		pipeline.NewCmdCreatePipeline(config),
 		resourceprofile.NewCmdCreateResourceProfile(config),
 		pipeline.NewCmdCreatePipelineFile(config),
+		pipeline.NewCmdCreatePipelineSecret(config),
 		environment.NewCmdCreateEnvironment(config),
 		tracesession.NewCmdCreateTraceSession(config),
 		cnfg.NewCmdCreateConfigSection(config),
+	)
+
+	cmd.AddCommand(features.Require(features.IngestChecks,
 		ingestcheck.NewCmdCreateIngestCheck(config),
+	)...)
+
+	cmd.AddCommand(features.Require(features.Fleets,
 		fleet.NewCmdCreateFleet(config),
 		fleet.NewCmdCreateFleetFile(config),
-	)
+	)...)
 
 	return cmd
 }
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/calyptia/cli/cmd/coreinstance"
+	cfg "github.com/calyptia/cli/config"
+)
+
+func newCmdDebug(config *cfg.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "debug",
+		Short: "Troubleshoot core instances",
+	}
+
+	cmd.AddCommand(
+		coreinstance.NewCmdDebugCoreInstance(config),
+	)
+
+	return cmd
+}
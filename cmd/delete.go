@@ -13,6 +13,7 @@ import (
 	"github.com/calyptia/cli/cmd/pipeline"
 	"github.com/calyptia/cli/cmd/tracesession"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/features"
 )
 
 func newCmdDelete(config *cfg.Config) *cobra.Command {
@@ -24,8 +25,6 @@ func newCmdDelete(config *cfg.Config) *cobra.Command {
 	cmd.AddCommand(
 		agent.NewCmdDeleteAgents(config),
 		agent.NewCmdDeleteAgent(config),
-		fleet.NewCmdDeleteFleet(config),
-		fleet.NewCmdDeleteFleetFile(config),
 		pipeline.NewCmdDeletePipeline(config),
 		pipeline.NewCmdDeletePipelines(config),
 		endpoint.NewCmdDeleteEndpoint(config),
@@ -38,8 +37,16 @@ func newCmdDelete(config *cfg.Config) *cobra.Command {
 		environment.NewCmdDeleteEnvironment(config),
 		tracesession.NewCmdDeleteTraceSession(config),
 		cnfg.NewCmdDeleteConfigSection(config),
-		ingestcheck.NewCmdDeleteIngestCheck(config),
 	)
 
+	cmd.AddCommand(features.Require(features.IngestChecks,
+		ingestcheck.NewCmdDeleteIngestCheck(config),
+	)...)
+
+	cmd.AddCommand(features.Require(features.Fleets,
+		fleet.NewCmdDeleteFleet(config),
+		fleet.NewCmdDeleteFleetFile(config),
+	)...)
+
 	return cmd
 }
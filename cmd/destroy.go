@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	cloud "github.com/calyptia/api/types"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/confirm"
+	"github.com/calyptia/cli/exitcode"
+	"github.com/calyptia/cli/protection"
+	"github.com/calyptia/cli/state"
+)
+
+func newCmdDestroy(config *cfg.Config) *cobra.Command {
+	var fromState, dryRun, confirmed bool
+
+	cmd := &cobra.Command{
+		Use:   "destroy",
+		Short: "Delete every resource recorded in the local state file",
+		Long: "Delete the resources of the current project recorded in the local state file,\n" +
+			"newest first so trace sessions and pipelines go before their core instances.\n" +
+			"Kubernetes objects of deleted core instances are left for `calyptia gc kubernetes`.",
+		Example: "  export " + state.EnvFile + "=demo.state\n" +
+			"  calyptia create core_instance kubernetes --name demo\n" +
+			"  calyptia create pipeline --core-instance demo --name demo --config-file demo.conf\n" +
+			"  calyptia destroy --from-state",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !fromState {
+				return errors.New("--from-state is required, it is the only source of resources to destroy for now")
+			}
+
+			ctx := cmd.Context()
+			recorded, err := config.State.Load()
+			if err != nil {
+				return err
+			}
+
+			resources := state.OfProject(recorded, config.ProjectID)
+			if len(resources) == 0 {
+				cmd.Println("No resources recorded in state")
+				return nil
+			}
+
+			// newest first.
+			for i, j := 0, len(resources)-1; i < j; i, j = i+1, j-1 {
+				resources[i], resources[j] = resources[j], resources[i]
+			}
+
+			if err := renderStateResources(cmd.OutOrStdout(), resources); err != nil {
+				return err
+			}
+
+			if dryRun {
+				return nil
+			}
+
+			if !confirmed {
+				cmd.Printf("Delete %d resources? (y/N) ", len(resources))
+				ok, err := confirm.Read(cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+
+				if !ok {
+					cmd.Println("Aborted")
+					return nil
+				}
+			}
+
+			var deleted []state.Resource
+			var failed int
+			var deletedCoreInstances bool
+			for _, r := range resources {
+				err := destroyStateResource(ctx, cmd, config, r)
+				var protectedErr *protection.ProtectedError
+				if errors.As(err, &protectedErr) {
+					cmd.PrintErrf("Skipping protected %s %q\n", r.Kind, r.Name)
+					continue
+				}
+
+				if err != nil && exitcode.FromError(err) != exitcode.NotFound {
+					cmd.PrintErrf("could not delete %s %q: %v\n", r.Kind, r.ID, err)
+					failed++
+					continue
+				}
+
+				if r.Kind == state.KindCoreInstance {
+					deletedCoreInstances = true
+				}
+
+				deleted = append(deleted, r)
+			}
+
+			if err := config.State.Save(state.Forget(recorded, deleted...)); err != nil {
+				return err
+			}
+
+			cmd.Printf("Deleted %d resources\n", len(deleted))
+
+			if deletedCoreInstances {
+				cmd.PrintErrln("Run `calyptia gc kubernetes` to delete the kubernetes objects of the deleted core instances")
+			}
+
+			if failed != 0 {
+				return fmt.Errorf("could not delete %d resources", failed)
+			}
+
+			return nil
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.BoolVar(&fromState, "from-state", false, "Delete the resources recorded in the local state file")
+	fs.BoolVar(&dryRun, "dry-run", false, "Only list the resources to delete")
+	fs.BoolVarP(&confirmed, "yes", "y", false, "Confirm the deletion")
+	protection.BindOverrideFlag(cmd)
+
+	return cmd
+}
+
+// destroyStateResource deletes the given recorded resource from Cloud.
+func destroyStateResource(ctx context.Context, cmd *cobra.Command, config *cfg.Config, r state.Resource) error {
+	switch r.Kind {
+	case state.KindPipeline:
+		pip, err := config.Cloud.Pipeline(ctx, r.ID, cloud.PipelineParams{})
+		if err != nil {
+			return err
+		}
+
+		if err := protection.Check(cmd, "pipeline", pip.Name, protection.IsPipelineProtected(pip)); err != nil {
+			return err
+		}
+
+		return config.Cloud.DeletePipeline(ctx, r.ID)
+	case state.KindTraceSession:
+		// trace sessions cannot be deleted, only the active one terminated.
+		ts, err := config.Cloud.TraceSession(ctx, r.ID)
+		if err != nil || !ts.Active() {
+			return err
+		}
+
+		_, err = config.Cloud.TerminateActiveTraceSession(ctx, r.ParentID)
+		return err
+	case state.KindIngestCheck:
+		return config.Cloud.DeleteIngestCheck(ctx, r.ID)
+	case state.KindConfigSection:
+		return config.Cloud.DeleteConfigSection(ctx, r.ID)
+	case state.KindCoreInstance:
+		return config.Cloud.DeleteCoreInstance(ctx, r.ID)
+	case state.KindEnvironment:
+		return config.Cloud.DeleteEnvironment(ctx, r.ID)
+	default:
+		return fmt.Errorf("unknown kind %q", r.Kind)
+	}
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/calyptia/cli/cmd/pipeline"
+	cfg "github.com/calyptia/cli/config"
+)
+
+func newCmdDiff(config *cfg.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Compare two resources",
+	}
+
+	cmd.AddCommand(
+		pipeline.NewCmdDiffPipelines(config),
+	)
+
+	return cmd
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/calyptia/cli/cmd/pipeline"
+	cfg "github.com/calyptia/cli/config"
+)
+
+func newCmdEdit(config *cfg.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Edit resources in your editor",
+	}
+
+	cmd.AddCommand(
+		pipeline.NewCmdEditPipeline(config),
+	)
+
+	return cmd
+}
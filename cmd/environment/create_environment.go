@@ -1,12 +1,15 @@
 package environment
 
 import (
-	"context"
+	"encoding/json"
 
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
 
 	"github.com/calyptia/api/types"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/state"
 )
 
 func NewCmdCreateEnvironment(c *cfg.Config) *cobra.Command {
@@ -16,15 +19,43 @@ func NewCmdCreateEnvironment(c *cfg.Config) *cobra.Command {
 		Short: "Create an environment",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			name := args[0]
-			ctx := context.Background()
+			ctx := cmd.Context()
+
 			environment := types.CreateEnvironment{Name: name}
 			createEnvironment, err := c.Cloud.CreateEnvironment(ctx, c.ProjectID, environment)
 			if err != nil {
 				return err
 			}
-			cmd.Printf("Created environment ID: %s Name: %s\n", createEnvironment.ID, name)
-			return nil
+
+			err = c.State.Track(cmd, state.Resource{
+				Kind:      state.KindEnvironment,
+				ID:        createEnvironment.ID,
+				Name:      name,
+				ProjectID: c.ProjectID,
+			})
+			if err != nil {
+				return err
+			}
+
+			fs := cmd.Flags()
+			outputFormat := formatters.OutputFormatFromFlags(fs)
+			if fn, ok := formatters.ShouldApplyTemplating(outputFormat); ok {
+				return fn(cmd.OutOrStdout(), formatters.TemplateFromFlags(fs), createEnvironment)
+			}
+
+			switch outputFormat {
+			case formatters.OutputFormatJSON:
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(createEnvironment)
+			case formatters.OutputFormatYAML:
+				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(createEnvironment)
+			default:
+				cmd.Printf("Created environment ID: %s Name: %s\n", createEnvironment.ID, name)
+				return nil
+			}
 		},
 	}
+
+	formatters.BindFormatFlags(cmd)
+
 	return cmd
 }
@@ -0,0 +1,52 @@
+package environment
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/calyptia/api/client"
+
+	cfg "github.com/calyptia/cli/config"
+)
+
+func TestNewCmdCreateEnvironment_outputFormat(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/projects/project-1/environments" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"error":"not found"}`)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{"id":"env-1","createdAt":"2023-11-09T13:48:25Z"}`)
+	}))
+	defer srv.Close()
+
+	cloud := client.New()
+	cloud.BaseURL = srv.URL
+	config := &cfg.Config{Ctx: context.Background(), Cloud: cloud, ProjectID: "project-1"}
+
+	tt := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"staging"}, want: "Created environment ID: env-1 Name: staging\n"},
+		{args: []string{"staging", "-o", "go-template", "--template", "{{.ID}}"}, want: "env-1\n"},
+	}
+	for _, tc := range tt {
+		var out bytes.Buffer
+		cmd := NewCmdCreateEnvironment(config)
+		cmd.SetOut(&out)
+		cmd.SetArgs(tc.args)
+		if err := cmd.ExecuteContext(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if out.String() != tc.want {
+			t.Errorf("%v: want %q, got %q", tc.args, tc.want, out.String())
+		}
+	}
+}
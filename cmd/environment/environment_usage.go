@@ -0,0 +1,163 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	cloud "github.com/calyptia/api/types"
+	"github.com/calyptia/cli/cmd/utils"
+	cfg "github.com/calyptia/cli/config"
+)
+
+// defaultUsageWindow is the period the throughput of an environment is averaged over.
+const defaultUsageWindow = time.Hour
+
+// environmentUsage is what an environment contains, used to review it
+// before cleanup or chargeback.
+type environmentUsage struct {
+	ID            string  `json:"id" yaml:"id"`
+	Name          string  `json:"name" yaml:"name"`
+	CoreInstances int     `json:"coreInstances" yaml:"coreInstances"`
+	Pipelines     int     `json:"pipelines" yaml:"pipelines"`
+	Agents        int     `json:"agents" yaml:"agents"`
+	Files         int     `json:"files" yaml:"files"`
+	FilesSize     int     `json:"filesSize" yaml:"filesSize"`
+	Secrets       int     `json:"secrets" yaml:"secrets"`
+	SecretsSize   int     `json:"secretsSize" yaml:"secretsSize"`
+	InputBytes    float64 `json:"inputBytes" yaml:"inputBytes"`
+	OutputBytes   float64 `json:"outputBytes" yaml:"outputBytes"`
+	InputRecords  float64 `json:"inputRecords" yaml:"inputRecords"`
+	OutputRecords float64 `json:"outputRecords" yaml:"outputRecords"`
+	// Window is the period the throughput was summed over.
+	Window time.Duration `json:"window" yaml:"window"`
+}
+
+// addPipelines counts the pipelines along with their attached files and secrets.
+func (u *environmentUsage) addPipelines(pipelines []cloud.Pipeline) {
+	u.Pipelines += len(pipelines)
+	for _, p := range pipelines {
+		u.Files += len(p.Files)
+		for _, f := range p.Files {
+			u.FilesSize += len(f.Contents)
+		}
+
+		u.Secrets += len(p.Secrets)
+		for _, s := range p.Secrets {
+			u.SecretsSize += len(s.Value)
+		}
+	}
+}
+
+// addMetrics sums the throughput of a core instance.
+func (u *environmentUsage) addMetrics(m cloud.MetricsSummary) {
+	value := func(f *float64) float64 {
+		if f == nil {
+			return 0
+		}
+		return *f
+	}
+
+	u.InputBytes += value(m.Input.Bytes)
+	u.InputRecords += value(m.Input.Records)
+	u.OutputBytes += value(m.Output.Bytes)
+	u.OutputRecords += value(m.Output.Records)
+}
+
+// fetchEnvironmentUsage walks every core instance of the environment
+// with its pipelines, and every agent enrolled on it.
+func fetchEnvironmentUsage(ctx context.Context, config *cfg.Config, env cloud.Environment, window time.Duration) (environmentUsage, error) {
+	out := environmentUsage{ID: env.ID, Name: env.Name, Window: window}
+
+	instancesParams := cloud.CoreInstancesParams{Last: cfg.Ptr(uint(100)), EnvironmentID: &env.ID}
+	for {
+		cc, err := config.Cloud.CoreInstances(ctx, config.ProjectID, instancesParams)
+		if err != nil {
+			return out, fmt.Errorf("could not fetch core instances of environment %q: %w", env.Name, err)
+		}
+
+		for _, c := range cc.Items {
+			out.CoreInstances++
+
+			pipelinesParams := cloud.PipelinesParams{
+				CoreInstanceID: &c.ID,
+				Last:           cfg.Ptr(uint(100)),
+				IncludeObjects: &cloud.PipelineObjectsParams{Files: true, Secrets: true},
+			}
+			for {
+				pp, err := config.Cloud.Pipelines(ctx, pipelinesParams)
+				if err != nil {
+					return out, fmt.Errorf("could not fetch pipelines of core instance %q: %w", c.Name, err)
+				}
+
+				out.addPipelines(pp.Items)
+
+				if pp.EndCursor == nil || len(pp.Items) == 0 {
+					break
+				}
+				pipelinesParams.Before = pp.EndCursor
+			}
+
+			m, err := config.Cloud.CoreInstanceMetrics(ctx, c.ID, cloud.MetricsParams{Start: -window, Interval: window})
+			if err != nil {
+				return out, fmt.Errorf("could not fetch metrics of core instance %q: %w", c.Name, err)
+			}
+
+			out.addMetrics(m)
+		}
+
+		if cc.EndCursor == nil || len(cc.Items) == 0 {
+			break
+		}
+		instancesParams.Before = cc.EndCursor
+	}
+
+	agentsParams := cloud.AgentsParams{Last: cfg.Ptr(uint(100)), EnvironmentID: &env.ID}
+	for {
+		aa, err := config.Cloud.Agents(ctx, config.ProjectID, agentsParams)
+		if err != nil {
+			return out, fmt.Errorf("could not fetch agents of environment %q: %w", env.Name, err)
+		}
+
+		out.Agents += len(aa.Items)
+
+		if aa.EndCursor == nil || len(aa.Items) == 0 {
+			break
+		}
+		agentsParams.Before = aa.EndCursor
+	}
+
+	return out, nil
+}
+
+func renderEnvironmentsUsage(w io.Writer, usages []environmentUsage, showIDs bool) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	if showIDs {
+		fmt.Fprint(tw, "ID\t")
+	}
+	fmt.Fprintln(tw, "NAME\tCORE-INSTANCES\tPIPELINES\tAGENTS\tFILES\tSECRETS\tIN/S\tOUT/S")
+	for _, u := range usages {
+		if showIDs {
+			fmt.Fprintf(tw, "%s\t", u.ID)
+		}
+
+		files := fmt.Sprintf("%d (%s)", u.Files, bytesCell(float64(u.FilesSize)))
+		secrets := fmt.Sprintf("%d (%s)", u.Secrets, bytesCell(float64(u.SecretsSize)))
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\t%s\t%s\t%s\n", u.Name, u.CoreInstances, u.Pipelines, u.Agents, files, secrets,
+			bytesCell(perSecond(u.InputBytes, u.Window)), bytesCell(perSecond(u.OutputBytes, u.Window)))
+	}
+	return tw.Flush()
+}
+
+func bytesCell(v float64) string {
+	return utils.ByteCell{Value: &v}.String()
+}
+
+func perSecond(v float64, window time.Duration) float64 {
+	if window <= 0 {
+		return 0
+	}
+	return v / window.Seconds()
+}
@@ -0,0 +1,50 @@
+package environment
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+func Test_environmentUsage(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+
+	u := environmentUsage{Name: "production", CoreInstances: 1, Agents: 2, Window: time.Minute}
+	u.addPipelines([]cloud.Pipeline{
+		{
+			Files:   []cloud.PipelineFile{{Contents: make([]byte, 1024)}, {Contents: make([]byte, 1024)}},
+			Secrets: []cloud.PipelineSecret{{Value: []byte("secret")}},
+		},
+		{},
+	})
+	u.addMetrics(cloud.MetricsSummary{
+		Input:  cloud.MetricsInput{Bytes: f(6144), Records: f(60)},
+		Output: cloud.MetricsOutput{Bytes: f(3072)},
+	})
+	u.addMetrics(cloud.MetricsSummary{Input: cloud.MetricsInput{Bytes: f(6144)}})
+
+	if u.Pipelines != 2 || u.Files != 2 || u.FilesSize != 2048 || u.Secrets != 1 || u.SecretsSize != 6 {
+		t.Fatalf("unexpected pipelines usage: %+v", u)
+	}
+
+	if u.InputBytes != 12288 || u.InputRecords != 60 || u.OutputBytes != 3072 {
+		t.Fatalf("unexpected throughput: %+v", u)
+	}
+
+	var buf bytes.Buffer
+	if err := renderEnvironmentsUsage(&buf, []environmentUsage{u}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected header and one row, got %q", buf.String())
+	}
+
+	if got := strings.Fields(lines[1]); strings.Join(got, " ") != "production 1 2 2 2 (2k) 1 (6) 205 51" {
+		t.Errorf("unexpected row %q", lines[1])
+	}
+}
@@ -1,17 +1,18 @@
 package environment
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
-	"strings"
+	"io"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
 
 	cloud "github.com/calyptia/api/types"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/exitcode"
 	"github.com/calyptia/cli/formatters"
 )
 
@@ -19,19 +20,42 @@ func NewCmdGetEnvironment(c *cfg.Config) *cobra.Command {
 	var last uint
 	var outputFormat, goTemplate string
 	var showIDs bool
+	var usage bool
+	var usageWindow time.Duration
 
 	cmd := &cobra.Command{
 		Use:   "environment",
 		Short: "Get environments",
+		Long: "Get environments.\n" +
+			"With --usage each environment is reported along with the core instances, pipelines and agents " +
+			"it contains, the attached pipeline files and secrets with their size, " +
+			"and its throughput averaged over --usage-window.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := context.Background()
+			ctx := cmd.Context()
 			ee, err := c.Cloud.Environments(ctx, c.ProjectID, cloud.EnvironmentsParams{Last: &last})
 			if err != nil {
 				return err
 			}
 
-			if strings.HasPrefix(outputFormat, "go-template") {
-				return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, ee.Items)
+			if err := exitcode.FailOnEmpty(cmd, len(ee.Items)); err != nil {
+				return err
+			}
+
+			if usage {
+				usages := make([]environmentUsage, 0, len(ee.Items))
+				for _, env := range ee.Items {
+					u, err := fetchEnvironmentUsage(ctx, c, env, usageWindow)
+					if err != nil {
+						return err
+					}
+					usages = append(usages, u)
+				}
+
+				return renderUsage(cmd.OutOrStdout(), outputFormat, goTemplate, usages, showIDs)
+			}
+
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, ee.Items)
 			}
 
 			switch outputFormat {
@@ -65,10 +89,31 @@ func NewCmdGetEnvironment(c *cfg.Config) *cobra.Command {
 	fs := cmd.Flags()
 	fs.UintVarP(&last, "last", "l", 0, "Last `N` members. 0 means no limit")
 	fs.BoolVar(&showIDs, "show-ids", false, "Include member IDs in table output")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	fs.BoolVar(&usage, "usage", false, "Report what each environment contains: core instances, pipelines, agents, attached files and secrets, and recent throughput")
+	fs.DurationVar(&usageWindow, "usage-window", defaultUsageWindow, "Period to average the throughput over with --usage")
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 
 	_ = cmd.RegisterFlagCompletionFunc("output-format", formatters.CompleteOutputFormat)
 
+	exitcode.BindFailOnEmptyFlag(cmd)
+
 	return cmd
 }
+
+func renderUsage(w io.Writer, outputFormat, goTemplate string, usages []environmentUsage, showIDs bool) error {
+	if formatters.IsTemplating(outputFormat) {
+		return formatters.ApplyTemplate(w, outputFormat, goTemplate, usages)
+	}
+
+	switch outputFormat {
+	case "table":
+		return renderEnvironmentsUsage(w, usages, showIDs)
+	case "json":
+		return json.NewEncoder(w).Encode(usages)
+	case "yml", "yaml":
+		return yaml.NewEncoder(w).Encode(usages)
+	default:
+		return fmt.Errorf("unknown output format %q", outputFormat)
+	}
+}
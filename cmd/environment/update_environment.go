@@ -1,7 +1,6 @@
 package environment
 
 import (
-	"context"
 	"fmt"
 
 	"github.com/spf13/cobra"
@@ -21,7 +20,7 @@ func NewCmdUpdateEnvironment(c *cfg.Config) *cobra.Command {
 			if name == newName {
 				return fmt.Errorf("environment name unchanged")
 			}
-			ctx := context.Background()
+			ctx := cmd.Context()
 			environments, err := c.Cloud.Environments(ctx, c.ProjectID, types.EnvironmentsParams{Name: &name})
 			if err != nil {
 				return err
@@ -2,11 +2,11 @@ package fleet
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strings"
 	"text/tabwriter"
-	"time"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
@@ -27,6 +27,9 @@ func NewCmdCreateFleet(config *cfg.Config) *cobra.Command {
 	var in types.CreateFleet
 	var configFile, configFormat string
 	var outputFormat, goTemplate string
+	var interactive bool
+	var renderTemplate bool
+	var strictCompat bool
 
 	cmd := &cobra.Command{
 		Use:   "fleet",
@@ -35,8 +38,22 @@ func NewCmdCreateFleet(config *cfg.Config) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 
+			if interactive {
+				confirmed, err := runFleetWizard(cmd, config, &in, &configFile)
+				if err != nil {
+					return err
+				}
+
+				if !confirmed {
+					cmd.Println("Aborted")
+					return nil
+				}
+			} else if in.Name == "" {
+				return errors.New("required flag(s) \"name\" not set")
+			}
+
 			var err error
-			in.RawConfig, err = readConfig(configFile)
+			in.RawConfig, err = readConfig(configFile, renderTemplate)
 			if err != nil {
 				return err
 			}
@@ -44,20 +61,36 @@ func NewCmdCreateFleet(config *cfg.Config) *cobra.Command {
 			in.ConfigFormat = getFormat(configFile, configFormat)
 			in.ProjectID = config.ProjectID
 
+			if !in.SkipConfigValidation {
+				features, err := configFeatures(in.RawConfig, in.ConfigFormat)
+				if err != nil {
+					return err
+				}
+
+				issues, err := checkFleetCompat(features, in.MinFluentBitVersion, nil)
+				if err != nil {
+					return err
+				}
+
+				if err := reportFleetCompat(cmd, issues, strictCompat); err != nil {
+					return err
+				}
+			}
+
 			created, err := config.Cloud.CreateFleet(ctx, in)
 			if err != nil {
 				return err
 			}
 
-			if strings.HasPrefix(outputFormat, "go-template") {
-				return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, created)
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, created)
 			}
 
 			switch outputFormat {
 			case "table":
 				tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 1, ' ', 0)
 				fmt.Fprintln(tw, "ID\tCREATED-AT")
-				fmt.Fprintf(tw, "%s\t%s\n", created.ID, created.CreatedAt.Format(time.RFC822))
+				fmt.Fprintf(tw, "%s\t%s\n", created.ID, formatters.FmtTimestamp(created.CreatedAt))
 				tw.Flush()
 			case "json":
 				return json.NewEncoder(cmd.OutOrStdout()).Encode(created)
@@ -74,13 +107,14 @@ func NewCmdCreateFleet(config *cfg.Config) *cobra.Command {
 	fs.StringVar(&in.Name, "name", "", "Name")
 	fs.StringVar(&in.MinFluentBitVersion, "min-fluent-bit-version", "", "Optional minimum fluent-bit version that agents must satisfy to join this fleet")
 	fs.StringVar(&configFile, "config-file", "fluent-bit.yaml", "Fluent-bit config file")
+	fs.BoolVar(&renderTemplate, "render-template", false, "Render the config file as a go template with sprig functions before sending it, ie: {{ env \"HOST\" | default \"localhost\" }}")
 	fs.StringVar(&configFormat, "config-format", "", "Optional fluent-bit config format (classic, yaml, json)")
 	fs.StringSliceVar(&in.Tags, "tags", nil, "Optional tags for this fleet")
+	fs.BoolVarP(&interactive, "interactive", "i", false, "Define the fleet match criteria interactively and preview the agents that would join before creating it")
 	fs.BoolVar(&in.SkipConfigValidation, "skip-config-validation", false, "Option to skip fluent-bit config validation (not recommended)")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
-
-	_ = cmd.MarkFlagRequired("name")
+	fs.BoolVar(&strictCompat, "strict-compat", false, "Fail instead of warning when the config uses features the fleet min fluent-bit version does not support")
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 
 	_ = cmd.RegisterFlagCompletionFunc("config-format", completeConfigFormat)
 	_ = cmd.RegisterFlagCompletionFunc("output-format", formatters.CompleteOutputFormat)
@@ -88,11 +122,15 @@ func NewCmdCreateFleet(config *cfg.Config) *cobra.Command {
 	return cmd
 }
 
-func readConfig(filename string) (string, error) {
+func readConfig(filename string, renderTemplate bool) (string, error) {
 	out, err := cfg.ReadFile(filename)
 	if err != nil {
 		return "", err
 	}
+
+	if renderTemplate {
+		return cfg.RenderTemplate(filename, string(out))
+	}
 	return string(out), nil
 }
 
@@ -0,0 +1,210 @@
+package fleet
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	semver "github.com/hashicorp/go-version"
+	"github.com/spf13/cobra"
+
+	"github.com/calyptia/api/types"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/confirm"
+)
+
+// previewSampleSize is the max number of matching agents listed in the preview.
+const previewSampleSize = 10
+
+// fleetCriteria are the conditions an agent must satisfy to join a fleet.
+// OS is not stored on the fleet, it only narrows down the preview.
+type fleetCriteria struct {
+	Tags                []string
+	MinFluentBitVersion string
+	OS                  string
+}
+
+// runFleetWizard prompts for the fleet details and match criteria, previews
+// the existing agents that would join the fleet and asks for confirmation.
+func runFleetWizard(cmd *cobra.Command, config *cfg.Config, in *types.CreateFleet, configFile *string) (bool, error) {
+	r := bufio.NewReader(cmd.InOrStdin())
+	var err error
+
+	if in.Name, err = prompt(cmd, r, "Fleet name", in.Name); err != nil {
+		return false, err
+	}
+	if in.Name == "" {
+		return false, fmt.Errorf("fleet name cannot be empty")
+	}
+
+	if *configFile, err = prompt(cmd, r, "Fluent-bit config file", *configFile); err != nil {
+		return false, err
+	}
+
+	tags, err := prompt(cmd, r, "Agent tags to match (comma separated)", strings.Join(in.Tags, ","))
+	if err != nil {
+		return false, err
+	}
+	in.Tags = splitTags(tags)
+
+	if in.MinFluentBitVersion, err = prompt(cmd, r, "Minimum fluent-bit version", in.MinFluentBitVersion); err != nil {
+		return false, err
+	}
+	if in.MinFluentBitVersion != "" {
+		if _, err := semver.NewVersion(in.MinFluentBitVersion); err != nil {
+			return false, fmt.Errorf("invalid fluent-bit version %q: %w", in.MinFluentBitVersion, err)
+		}
+	}
+
+	operatingSystem, err := prompt(cmd, r, "Agent operating system to preview (optional)", "")
+	if err != nil {
+		return false, err
+	}
+
+	criteria := fleetCriteria{
+		Tags:                in.Tags,
+		MinFluentBitVersion: in.MinFluentBitVersion,
+		OS:                  operatingSystem,
+	}
+
+	agents, err := matchingAgents(cmd.Context(), config, criteria)
+	if err != nil {
+		return false, err
+	}
+
+	if err := renderAgentsPreview(cmd.OutOrStdout(), agents); err != nil {
+		return false, err
+	}
+
+	cmd.Printf("Create fleet %q? (y/N) ", in.Name)
+	return confirm.Read(r)
+}
+
+func prompt(cmd *cobra.Command, r *bufio.Reader, label, defaultValue string) (string, error) {
+	if defaultValue != "" {
+		cmd.Printf("%s [%s]: ", label, defaultValue)
+	} else {
+		cmd.Printf("%s: ", label)
+	}
+
+	answer, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("could not read answer: %w", err)
+	}
+
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return defaultValue, nil
+	}
+	return answer, nil
+}
+
+func splitTags(s string) []string {
+	var out []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// matchingAgents goes through all the agents in the project
+// and returns those satisfying the given criteria.
+func matchingAgents(ctx context.Context, config *cfg.Config, criteria fleetCriteria) ([]types.Agent, error) {
+	var out []types.Agent
+	params := types.AgentsParams{Last: cfg.Ptr(uint(100))}
+	for {
+		aa, err := config.Cloud.Agents(ctx, config.ProjectID, params)
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch your agents: %w", err)
+		}
+
+		for _, a := range aa.Items {
+			if agentMatches(a, criteria) {
+				out = append(out, a)
+			}
+		}
+
+		if aa.EndCursor == nil || len(aa.Items) == 0 {
+			return out, nil
+		}
+		params.Before = aa.EndCursor
+	}
+}
+
+func agentMatches(agent types.Agent, criteria fleetCriteria) bool {
+	for _, tag := range criteria.Tags {
+		if !containsTag(agent.Tags, tag) {
+			return false
+		}
+	}
+
+	if criteria.MinFluentBitVersion != "" {
+		minVersion, err := semver.NewVersion(criteria.MinFluentBitVersion)
+		if err != nil {
+			return false
+		}
+
+		v, err := semver.NewVersion(strings.TrimPrefix(agent.Version, "v"))
+		if err != nil || v.LessThan(minVersion) {
+			return false
+		}
+	}
+
+	if criteria.OS != "" && !strings.EqualFold(agentOS(agent), criteria.OS) {
+		return false
+	}
+
+	return true
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// agentOS returns the operating system reported in the agent metadata, if any.
+func agentOS(agent types.Agent) string {
+	if agent.Metadata == nil {
+		return ""
+	}
+
+	var metadata struct {
+		OS string `json:"os"`
+	}
+	_ = json.Unmarshal(*agent.Metadata, &metadata)
+	return metadata.OS
+}
+
+func renderAgentsPreview(w io.Writer, agents []types.Agent) error {
+	fmt.Fprintf(w, "%d existing agents would join this fleet.\n", len(agents))
+	if len(agents) == 0 {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tVERSION\tENVIRONMENT\tTAGS")
+	for i, a := range agents {
+		if i == previewSampleSize {
+			break
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", a.Name, a.Version, a.EnvironmentName, strings.Join(a.Tags, ","))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(agents) > previewSampleSize {
+		fmt.Fprintf(w, "... and %d more.\n", len(agents)-previewSampleSize)
+	}
+	return nil
+}
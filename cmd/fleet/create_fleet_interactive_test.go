@@ -0,0 +1,38 @@
+package fleet
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/calyptia/api/types"
+)
+
+func Test_agentMatches(t *testing.T) {
+	metadata := json.RawMessage(`{"os":"linux"}`)
+	agent := types.Agent{
+		Version:  "v2.1.8",
+		Tags:     []string{"prod", "eu"},
+		Metadata: &metadata,
+	}
+
+	tests := []struct {
+		name     string
+		criteria fleetCriteria
+		want     bool
+	}{
+		{name: "empty", want: true},
+		{name: "tags", criteria: fleetCriteria{Tags: []string{"prod"}}, want: true},
+		{name: "missing tag", criteria: fleetCriteria{Tags: []string{"prod", "us"}}, want: false},
+		{name: "version", criteria: fleetCriteria{MinFluentBitVersion: "2.1.0"}, want: true},
+		{name: "old version", criteria: fleetCriteria{MinFluentBitVersion: "2.2"}, want: false},
+		{name: "os", criteria: fleetCriteria{OS: "Linux"}, want: true},
+		{name: "other os", criteria: fleetCriteria{OS: "windows"}, want: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := agentMatches(agent, tc.criteria); got != tc.want {
+				t.Errorf("agentMatches() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
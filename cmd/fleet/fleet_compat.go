@@ -0,0 +1,190 @@
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	fluentbitconfig "github.com/calyptia/go-fluentbit-config/v2"
+	semver "github.com/hashicorp/go-version"
+	"github.com/spf13/cobra"
+
+	"github.com/calyptia/api/types"
+	cfg "github.com/calyptia/cli/config"
+)
+
+// configFeature is a part of a fluent-bit config that older agents
+// cannot parse, along with the first fluent-bit version supporting it.
+type configFeature struct {
+	Name       string
+	MinVersion *semver.Version
+}
+
+// pluginMinVersions holds the first fluent-bit version shipping each plugin
+// that is not available on every version agents may run.
+var pluginMinVersions = map[string]map[string]string{
+	"input": {
+		"elasticsearch":     "2.0.0",
+		"event_type":        "2.0.0",
+		"kubernetes_events": "2.1.0",
+		"opentelemetry":     "2.0.0",
+		"prometheus_scrape": "1.9.0",
+		"splunk":            "2.0.0",
+		"udp":               "2.0.0",
+	},
+	"filter": {
+		"log_to_metrics": "2.0.0",
+		"nightfall":      "1.9.0",
+		"wasm":           "2.1.0",
+	},
+	"output": {
+		"azure_kusto":             "1.9.0",
+		"opentelemetry":           "1.9.0",
+		"prometheus_remote_write": "1.9.0",
+	},
+}
+
+var (
+	yamlConfigMinVersion = semver.Must(semver.NewVersion("2.0.0"))
+	processorsMinVersion = semver.Must(semver.NewVersion("2.1.2"))
+)
+
+// configFeatures parses the fleet config and returns the features it uses
+// that require a minimum fluent-bit version, sorted by that version.
+func configFeatures(rawConfig string, format types.ConfigFormat) ([]configFeature, error) {
+	conf, err := fluentbitconfig.ParseAs(rawConfig, fluentbitconfig.Format(format))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse fleet config: %w", err)
+	}
+
+	var out []configFeature
+	if f := strings.ToLower(string(format)); f == "yaml" || f == "yml" {
+		out = append(out, configFeature{Name: "yaml config format", MinVersion: yamlConfigMinVersion})
+	}
+
+	seen := map[string]bool{}
+	add := func(kind string, plugins fluentbitconfig.Plugins) {
+		for _, p := range plugins {
+			if _, ok := p.Properties.Get("processors"); ok && !seen["processors"] {
+				seen["processors"] = true
+				out = append(out, configFeature{Name: "processors", MinVersion: processorsMinVersion})
+			}
+
+			name := strings.ToLower(p.Name)
+			v, ok := pluginMinVersions[kind][name]
+			if !ok || seen[kind+" "+name] {
+				continue
+			}
+
+			seen[kind+" "+name] = true
+			out = append(out, configFeature{Name: kind + " " + name, MinVersion: semver.Must(semver.NewVersion(v))})
+		}
+	}
+	add("input", conf.Pipeline.Inputs)
+	add("filter", conf.Pipeline.Filters)
+	add("output", conf.Pipeline.Outputs)
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].MinVersion.GreaterThan(out[j].MinVersion)
+	})
+	return out, nil
+}
+
+// compatIssue is a config feature not supported by either the fleet
+// minimum fluent-bit version, or the version of an enrolled agent.
+type compatIssue struct {
+	Feature    configFeature
+	Agent      string
+	Version    string
+	Constraint bool
+}
+
+func (i compatIssue) String() string {
+	if i.Constraint {
+		if i.Version == "" {
+			return fmt.Sprintf("%s requires fluent-bit >= %s, but the fleet accepts agents of any version", i.Feature.Name, i.Feature.MinVersion)
+		}
+		return fmt.Sprintf("%s requires fluent-bit >= %s, but the fleet accepts agents from %s", i.Feature.Name, i.Feature.MinVersion, i.Version)
+	}
+	return fmt.Sprintf("%s requires fluent-bit >= %s, but agent %q runs %s", i.Feature.Name, i.Feature.MinVersion, i.Agent, i.Version)
+}
+
+// checkFleetCompat compares the features used by the config against
+// the fleet minimum fluent-bit version and the enrolled agents versions.
+// Agents with a version that cannot be parsed are not checked.
+func checkFleetCompat(features []configFeature, minFluentBitVersion string, agents []types.Agent) ([]compatIssue, error) {
+	if len(features) == 0 {
+		return nil, nil
+	}
+
+	var issues []compatIssue
+	var minVersion *semver.Version
+	if minFluentBitVersion != "" {
+		var err error
+		minVersion, err = semver.NewVersion(strings.TrimPrefix(minFluentBitVersion, "v"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid min fluent-bit version %q: %w", minFluentBitVersion, err)
+		}
+	}
+
+	// features are sorted by version, so the first unsupported one is enough.
+	for _, f := range features {
+		if minVersion == nil || minVersion.LessThan(f.MinVersion) {
+			issues = append(issues, compatIssue{Feature: f, Version: minFluentBitVersion, Constraint: true})
+			break
+		}
+	}
+
+	for _, a := range agents {
+		v, err := semver.NewVersion(strings.TrimPrefix(a.Version, "v"))
+		if err != nil {
+			continue
+		}
+
+		for _, f := range features {
+			if v.LessThan(f.MinVersion) {
+				issues = append(issues, compatIssue{Feature: f, Agent: a.Name, Version: a.Version})
+				break
+			}
+		}
+	}
+
+	return issues, nil
+}
+
+// reportFleetCompat prints the compatibility issues as warnings,
+// or fails when strict is set.
+func reportFleetCompat(cmd *cobra.Command, issues []compatIssue, strict bool) error {
+	if len(issues) == 0 {
+		return nil
+	}
+
+	if strict {
+		return fmt.Errorf("fleet config is not compatible with its agents: %s (%d issues), use --skip-config-validation to proceed anyway", issues[0], len(issues))
+	}
+
+	for _, i := range issues {
+		cmd.PrintErrf("Warning: %s\n", i)
+	}
+	return nil
+}
+
+// fleetAgents returns all the agents enrolled in the fleet.
+func fleetAgents(ctx context.Context, config *cfg.Config, fleetID string) ([]types.Agent, error) {
+	var out []types.Agent
+	params := types.AgentsParams{Last: cfg.Ptr(uint(100)), FleetID: &fleetID}
+	for {
+		aa, err := config.Cloud.Agents(ctx, config.ProjectID, params)
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch fleet agents: %w", err)
+		}
+
+		out = append(out, aa.Items...)
+
+		if aa.EndCursor == nil || len(aa.Items) == 0 {
+			return out, nil
+		}
+		params.Before = aa.EndCursor
+	}
+}
@@ -0,0 +1,88 @@
+package fleet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/calyptia/api/types"
+)
+
+func Test_configFeatures(t *testing.T) {
+	t.Run("classic", func(t *testing.T) {
+		raw := "[INPUT]\n    Name cpu\n[INPUT]\n    Name opentelemetry\n[OUTPUT]\n    Name prometheus_remote_write\n    Match *\n"
+		got, err := configFeatures(raw, types.ConfigFormatINI)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(got) != 2 || got[0].Name != "input opentelemetry" || got[1].Name != "output prometheus_remote_write" {
+			t.Errorf("unexpected features %+v", got)
+		}
+	})
+
+	t.Run("yaml processors", func(t *testing.T) {
+		raw := "pipeline:\n  inputs:\n    - name: dummy\n      processors:\n        logs:\n          - name: content_modifier\n"
+		got, err := configFeatures(raw, types.ConfigFormatYAML)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(got) != 2 || got[0].Name != "processors" || got[1].Name != "yaml config format" {
+			t.Errorf("unexpected features %+v", got)
+		}
+	})
+}
+
+func Test_checkFleetCompat(t *testing.T) {
+	features, err := configFeatures("[INPUT]\n    Name kubernetes_events\n[FILTER]\n    Name nightfall\n", types.ConfigFormatINI)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("supported", func(t *testing.T) {
+		issues, err := checkFleetCompat(features, "v2.1.0", []types.Agent{{Name: "a", Version: "v2.2.0"}, {Name: "b", Version: "dev"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(issues) != 0 {
+			t.Errorf("expected no issues, got %v", issues)
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		issues, err := checkFleetCompat(features, "1.9.0", []types.Agent{{Name: "old", Version: "v1.8.15"}, {Name: "new", Version: "2.1.10"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(issues) != 2 {
+			t.Fatalf("expected 2 issues, got %v", issues)
+		}
+
+		if !issues[0].Constraint || !strings.Contains(issues[0].String(), "input kubernetes_events requires fluent-bit >= 2.1.0") {
+			t.Errorf("unexpected constraint issue %q", issues[0])
+		}
+
+		if issues[1].Agent != "old" || !strings.Contains(issues[1].String(), `agent "old" runs v1.8.15`) {
+			t.Errorf("unexpected agent issue %q", issues[1])
+		}
+	})
+
+	t.Run("any version", func(t *testing.T) {
+		issues, err := checkFleetCompat(features, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(issues) != 1 || !strings.Contains(issues[0].String(), "accepts agents of any version") {
+			t.Errorf("unexpected issues %v", issues)
+		}
+	})
+
+	t.Run("invalid constraint", func(t *testing.T) {
+		if _, err := checkFleetCompat(features, "latest", nil); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
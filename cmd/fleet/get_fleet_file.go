@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"strings"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
@@ -13,6 +12,8 @@ import (
 	cloud "github.com/calyptia/api/types"
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/download"
+	"github.com/calyptia/cli/exitcode"
 	"github.com/calyptia/cli/formatters"
 )
 
@@ -39,8 +40,12 @@ func NewCmdGetFleetFiles(config *cfg.Config) *cobra.Command {
 				return fmt.Errorf("could not fetch your fleet files: %w", err)
 			}
 
-			if strings.HasPrefix(outputFormat, "go-template") {
-				return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, ff.Items)
+			if err := exitcode.FailOnEmpty(cmd, len(ff.Items)); err != nil {
+				return err
+			}
+
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, ff.Items)
 			}
 
 			switch outputFormat {
@@ -61,14 +66,16 @@ func NewCmdGetFleetFiles(config *cfg.Config) *cobra.Command {
 	fs.StringVar(&fleetKey, "fleet", "", "Parent fleet ID or name")
 	fs.UintVarP(&last, "last", "l", 0, "Last `N` fleet files. 0 means no limit")
 	fs.BoolVar(&showIDs, "show-ids", false, "Include status IDs in table output")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 
 	_ = cmd.RegisterFlagCompletionFunc("output-format", formatters.CompleteOutputFormat)
 	_ = cmd.RegisterFlagCompletionFunc("fleet", completer.CompleteFleets)
 
 	_ = cmd.MarkFlagRequired("fleet") // TODO: use default fleet key from config cmd.
 
+	exitcode.BindFailOnEmptyFlag(cmd)
+
 	return cmd
 }
 
@@ -107,13 +114,22 @@ func NewCmdGetFleetFile(config *cfg.Config) *cobra.Command {
 				return fmt.Errorf("could not find fleet file %q", name)
 			}
 
+			checksum, err := download.ChecksumFromFlags(cmd.Flags(), file.Name)
+			if err != nil {
+				return err
+			}
+
+			if err := download.Verify(file.Name, file.Contents, checksum); err != nil {
+				return err
+			}
+
 			if onlyContents {
 				cmd.Print(string(file.Contents))
 				return nil
 			}
 
-			if strings.HasPrefix(outputFormat, "go-template") {
-				return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, file)
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, file)
 			}
 
 			switch outputFormat {
@@ -144,8 +160,9 @@ func NewCmdGetFleetFile(config *cfg.Config) *cobra.Command {
 	fs.StringVar(&name, "name", "", "File name")
 	fs.BoolVar(&showIDs, "show-ids", false, "Include status IDs in table output")
 	fs.BoolVar(&onlyContents, "only-contents", false, "Only print file contents")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	download.BindChecksumFlags(cmd)
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 
 	_ = cmd.RegisterFlagCompletionFunc("fleet", completer.CompleteFleets)
 	_ = cmd.RegisterFlagCompletionFunc("output-format", formatters.CompleteOutputFormat)
@@ -0,0 +1,379 @@
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hexops/gotextdiff"
+	"github.com/hexops/gotextdiff/myers"
+	"github.com/hexops/gotextdiff/span"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/calyptia/api/types"
+	"github.com/calyptia/cli/completer"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/confirm"
+	"github.com/calyptia/cli/labels"
+	"github.com/calyptia/cli/progress"
+)
+
+// agentsMigration is the plan to enroll standalone agents into a fleet.
+type agentsMigration struct {
+	// Config is the fleet config the agents will share.
+	Config string
+	// Deviations holds one entry per agent, in the agents order.
+	Deviations []agentDeviation
+}
+
+// agentDeviation is how an agent config differs from the fleet config.
+type agentDeviation struct {
+	Agent   types.Agent
+	Added   int
+	Removed int
+	Diff    string
+}
+
+func (d agentDeviation) matches() bool {
+	return d.Added == 0 && d.Removed == 0
+}
+
+// matching returns the number of agents whose config is the fleet config.
+func (m agentsMigration) matching() int {
+	var n int
+	for _, d := range m.Deviations {
+		if d.matches() {
+			n++
+		}
+	}
+	return n
+}
+
+func NewCmdMigrateAgents(config *cfg.Config) *cobra.Command {
+	var fromStandalone bool
+	var fleetName, selector, environment, configFormat string
+	var batchSize uint
+	var batchInterval time.Duration
+	var dryRun, showDiff, confirmed bool
+	completer := completer.Completer{Config: config}
+
+	isNonInteractive := os.Stdin == nil || !term.IsTerminal(int(os.Stdin.Fd()))
+
+	cmd := &cobra.Command{
+		Use:   "agents",
+		Short: "Move standalone agents into a fleet",
+		Long: "Move the standalone agents matching the selector into a fleet.\n" +
+			"The fleet config is generated from the most common config among the agents, " +
+			"and a report of how each agent config deviates from it is printed before enrolling them. " +
+			"If the fleet already exists, its config is used instead.\n" +
+			"Agents are enrolled in batches; the migration stops after a batch with failures.",
+		Example: "  calyptia migrate agents --from-standalone --to-fleet edge --selector env=prod --dry-run --show-diff",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !fromStandalone {
+				return fmt.Errorf("only migrations from standalone agents are supported, pass --from-standalone")
+			}
+
+			if batchSize == 0 {
+				return fmt.Errorf("--batch-size must be greater than zero")
+			}
+
+			sel, err := labels.ParseSelector(selector)
+			if err != nil {
+				return err
+			}
+
+			tracker, err := progress.FromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			var environmentID string
+			if environment != "" {
+				environmentID, err = completer.LoadEnvironmentID(environment)
+				if err != nil {
+					return err
+				}
+			}
+
+			ctx := cmd.Context()
+			agents, err := standaloneAgents(ctx, config, environmentID, sel)
+			if err != nil {
+				return err
+			}
+
+			if len(agents) == 0 {
+				return fmt.Errorf("no standalone agents match the selector")
+			}
+
+			ff, err := config.Cloud.Fleets(ctx, types.FleetsParams{
+				ProjectID: config.ProjectID,
+				Name:      &fleetName,
+				Last:      cfg.Ptr(uint(1)),
+			})
+			if err != nil {
+				return fmt.Errorf("could not fetch fleet %q: %w", fleetName, err)
+			}
+
+			var fleet *types.Fleet
+			var baseConfig string
+			if len(ff.Items) != 0 {
+				fleet = &ff.Items[0]
+				baseConfig = fleet.RawConfig
+				cmd.PrintErrf("Fleet %q already exists, comparing agents against its config.\n", fleetName)
+			}
+
+			plan := planAgentsMigration(agents, baseConfig)
+			if err := renderAgentsMigration(cmd.OutOrStdout(), plan, showDiff); err != nil {
+				return err
+			}
+
+			if dryRun {
+				return nil
+			}
+
+			if !confirmed {
+				cmd.Printf("Enroll %d agents into fleet %q? (y/N) ", len(agents), fleetName)
+				ok, err := confirm.Read(cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+
+				if !ok {
+					cmd.Println("Aborted")
+					return nil
+				}
+			}
+
+			if fleet == nil {
+				format := types.ConfigFormat(configFormat)
+				if configFormat == "" {
+					format = inferConfigFormat(plan.Config)
+				}
+
+				created, err := config.Cloud.CreateFleet(ctx, types.CreateFleet{
+					ProjectID:    config.ProjectID,
+					Name:         fleetName,
+					RawConfig:    plan.Config,
+					ConfigFormat: format,
+				})
+				if err != nil {
+					return fmt.Errorf("could not create fleet %q: %w", fleetName, err)
+				}
+
+				cmd.PrintErrf("Created fleet %q (%s)\n", fleetName, created.ID)
+				fleet = &types.Fleet{ID: created.ID, Name: fleetName}
+			}
+
+			return enrollAgents(ctx, config, tracker, fleet.ID, agents, int(batchSize), batchInterval)
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.BoolVar(&fromStandalone, "from-standalone", false, "Migrate agents that do not belong to any fleet")
+	fs.StringVar(&fleetName, "to-fleet", "", "Name of the fleet to enroll the agents into. It is created if it does not exist")
+	fs.StringVar(&selector, "selector", "", "Label selector to filter agents on. Supports key=value, key!=value, key and !key separated by commas")
+	fs.StringVar(&environment, "environment", "", "Calyptia environment name")
+	fs.StringVar(&configFormat, "config-format", "", "Optional fluent-bit config format of the new fleet (classic, yaml, json). Detected from the config by default")
+	fs.UintVar(&batchSize, "batch-size", 10, "Number of agents enrolled at once")
+	fs.DurationVar(&batchInterval, "batch-interval", 0, "Time to wait between batches, ie: 30s")
+	fs.BoolVar(&dryRun, "dry-run", false, "Only print the generated fleet config report without creating the fleet or enrolling agents")
+	fs.BoolVar(&showDiff, "show-diff", false, "Print the unified diff of each deviating agent config against the fleet config")
+	fs.BoolVarP(&confirmed, "yes", "y", isNonInteractive, "Confirm the migration")
+	progress.BindFlag(cmd)
+
+	_ = cmd.RegisterFlagCompletionFunc("environment", completer.CompleteEnvironments)
+	_ = cmd.RegisterFlagCompletionFunc("config-format", completeConfigFormat)
+
+	_ = cmd.MarkFlagRequired("from-standalone")
+	_ = cmd.MarkFlagRequired("to-fleet")
+
+	return cmd
+}
+
+// standaloneAgents goes through all the agents in the project and returns
+// those not attached to a fleet whose tags match the selector.
+func standaloneAgents(ctx context.Context, config *cfg.Config, environmentID string, sel labels.Selector) ([]types.Agent, error) {
+	var out []types.Agent
+	params := types.AgentsParams{Last: cfg.Ptr(uint(100))}
+	if environmentID != "" {
+		params.EnvironmentID = &environmentID
+	}
+
+	for {
+		aa, err := config.Cloud.Agents(ctx, config.ProjectID, params)
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch your agents: %w", err)
+		}
+
+		for _, a := range aa.Items {
+			if (a.FleetID == nil || *a.FleetID == "") && sel.Matches(a.Tags) {
+				out = append(out, a)
+			}
+		}
+
+		if aa.EndCursor == nil || len(aa.Items) == 0 {
+			return out, nil
+		}
+		params.Before = aa.EndCursor
+	}
+}
+
+// planAgentsMigration compares each agent config against the base config.
+// When no base config is given, the most common config among the agents is
+// used; ties are broken by the first agent, in order, holding that config.
+func planAgentsMigration(agents []types.Agent, baseConfig string) agentsMigration {
+	configs := make([]string, len(agents))
+	for i, a := range agents {
+		configs[i] = normalizeConfig(a.RawConfig)
+	}
+
+	plan := agentsMigration{Config: normalizeConfig(baseConfig)}
+	if baseConfig == "" {
+		plan.Config = mostCommonConfig(configs)
+	}
+
+	for i, a := range agents {
+		d := agentDeviation{Agent: a}
+		if configs[i] != plan.Config {
+			edits := myers.ComputeEdits(span.URIFromPath("fleet"), plan.Config, configs[i])
+			unified := gotextdiff.ToUnified("fleet", a.Name, plan.Config, edits)
+			for _, hunk := range unified.Hunks {
+				for _, line := range hunk.Lines {
+					switch line.Kind {
+					case gotextdiff.Insert:
+						d.Added++
+					case gotextdiff.Delete:
+						d.Removed++
+					}
+				}
+			}
+			d.Diff = fmt.Sprint(unified)
+		}
+		plan.Deviations = append(plan.Deviations, d)
+	}
+
+	return plan
+}
+
+func mostCommonConfig(configs []string) string {
+	counts := map[string]int{}
+	for _, c := range configs {
+		counts[c]++
+	}
+
+	var out string
+	for _, c := range configs {
+		if counts[c] > counts[out] {
+			out = c
+		}
+	}
+	return out
+}
+
+// normalizeConfig drops trailing whitespace and line endings differences
+// so they do not count as deviations.
+func normalizeConfig(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+
+	s = strings.Trim(strings.Join(lines, "\n"), "\n")
+	if s == "" {
+		return ""
+	}
+	return s + "\n"
+}
+
+// inferConfigFormat guesses the fluent-bit config format from its contents.
+func inferConfigFormat(s string) types.ConfigFormat {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(s, "{"):
+		return types.ConfigFormatJSON
+	case strings.HasPrefix(s, "["), strings.HasPrefix(s, "@"):
+		return types.ConfigFormatINI
+	default:
+		return types.ConfigFormatYAML
+	}
+}
+
+func renderAgentsMigration(w io.Writer, plan agentsMigration, showDiff bool) error {
+	fmt.Fprintf(w, "%d of %d agents already run the fleet config.\n", plan.matching(), len(plan.Deviations))
+
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "AGENT\tVERSION\tSTATUS\tDEVIATION")
+	for _, d := range plan.Deviations {
+		status, deviation := "matches", "-"
+		if !d.matches() {
+			status, deviation = "deviates", fmt.Sprintf("+%d/-%d lines", d.Added, d.Removed)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", d.Agent.Name, d.Agent.Version, status, deviation)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if !showDiff {
+		return nil
+	}
+
+	for _, d := range plan.Deviations {
+		if d.Diff != "" {
+			fmt.Fprintf(w, "\n%s", d.Diff)
+		}
+	}
+	return nil
+}
+
+// enrollAgents moves the agents into the fleet in batches, waiting the given
+// interval between them. Each batch is reported as a progress step.
+// It stops after the first batch with failures.
+func enrollAgents(ctx context.Context, config *cfg.Config, tracker *progress.Tracker, fleetID string, agents []types.Agent, batchSize int, interval time.Duration) error {
+	batches := (len(agents) + batchSize - 1) / batchSize
+	var enrolled int
+
+	for b := 0; b < batches; b++ {
+		if b != 0 && interval > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(interval):
+			}
+		}
+
+		step := fmt.Sprintf("enroll batch %d/%d", b+1, batches)
+		end := min((b+1)*batchSize, len(agents))
+		var failed []string
+		err := tracker.Run(ctx, step, func(ctx context.Context) error {
+			for _, a := range agents[b*batchSize : end] {
+				err := config.Cloud.UpdateAgent(ctx, a.ID, types.UpdateAgent{FleetID: &fleetID})
+				if err != nil {
+					tracker.Report(step, fmt.Sprintf("could not enroll agent %q: %v", a.Name, err), enrolled, len(agents))
+					failed = append(failed, a.Name)
+					continue
+				}
+
+				enrolled++
+				tracker.Report(step, fmt.Sprintf("enrolled agent %q", a.Name), enrolled, len(agents))
+			}
+
+			if len(failed) != 0 {
+				sort.Strings(failed)
+				return fmt.Errorf("could not enroll %s", strings.Join(failed, ", "))
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("stopped after batch %d/%d, %d of %d agents enrolled: %w", b+1, batches, enrolled, len(agents), err)
+		}
+	}
+
+	return nil
+}
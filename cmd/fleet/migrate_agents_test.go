@@ -0,0 +1,42 @@
+package fleet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/calyptia/api/types"
+)
+
+func Test_planAgentsMigration(t *testing.T) {
+	common := "[INPUT]\n    Name cpu\n\n[OUTPUT]\n    Name stdout\n"
+	agents := []types.Agent{
+		{Name: "a", RawConfig: "[INPUT]\n    Name mem\n"},
+		{Name: "b", RawConfig: common},
+		{Name: "c", RawConfig: strings.ReplaceAll(common, "\n", "  \r\n")},
+		{Name: "d", RawConfig: common + "    Match *\n"},
+	}
+
+	plan := planAgentsMigration(agents, "")
+	if plan.Config != common {
+		t.Fatalf("expected most common config, got %q", plan.Config)
+	}
+
+	if got := plan.matching(); got != 2 {
+		t.Errorf("expected 2 matching agents, got %d", got)
+	}
+
+	if d := plan.Deviations[3]; d.Added != 1 || d.Removed != 0 || !strings.Contains(d.Diff, "+    Match *") {
+		t.Errorf("unexpected deviation of agent d: %+v", d)
+	}
+
+	plan = planAgentsMigration(agents, agents[0].RawConfig)
+	if got := plan.matching(); got != 1 || !plan.Deviations[0].matches() {
+		t.Errorf("expected only agent a to match the base config, got %d", got)
+	}
+}
+
+func Test_mostCommonConfig(t *testing.T) {
+	if got := mostCommonConfig([]string{"a", "b", "b", "a"}); got != "a" {
+		t.Errorf("expected ties to be broken by the first config, got %q", got)
+	}
+}
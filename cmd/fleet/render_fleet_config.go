@@ -0,0 +1,270 @@
+package fleet
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	cloud "github.com/calyptia/api/types"
+	"github.com/calyptia/cli/completer"
+	cfg "github.com/calyptia/cli/config"
+)
+
+// maxIncludeDepth guards against include cycles.
+const maxIncludeDepth = 16
+
+func NewCmdRenderFleetConfig(config *cfg.Config) *cobra.Command {
+	var check bool
+	completer := completer.Completer{Config: config}
+
+	cmd := &cobra.Command{
+		Use:   "fleet_config FLEET",
+		Short: "Print a fleet config with its includes resolved",
+		Long: "Print the fleet config with every @INCLUDE directive, or includes entry on\n" +
+			"YAML configs, replaced by the contents of the matching fleet file.\n" +
+			"Includes are matched against the fleet file names by their base name.\n" +
+			"Missing includes are left as is and reported, use --check to fail on them.",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completer.CompleteFleets,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			fleetID, err := completer.LoadFleetID(args[0])
+			if err != nil {
+				return err
+			}
+
+			fleet, err := config.Cloud.Fleet(ctx, cloud.FleetParams{FleetID: fleetID})
+			if err != nil {
+				return fmt.Errorf("could not fetch your fleet: %w", err)
+			}
+
+			ff, err := config.Cloud.FleetFiles(ctx, fleetID, cloud.FleetFilesParams{})
+			if err != nil {
+				return fmt.Errorf("could not fetch your fleet files: %w", err)
+			}
+
+			rendered, missing, err := renderFleetConfig(fleet.RawConfig, fleet.ConfigFormat, ff.Items)
+			if err != nil {
+				return err
+			}
+
+			for _, name := range missing {
+				cmd.PrintErrf("WARNING: no fleet file matches include %q\n", name)
+			}
+
+			if check && len(missing) != 0 {
+				return fmt.Errorf("%d includes could not be resolved", len(missing))
+			}
+
+			cmd.Print(rendered)
+			return nil
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.BoolVar(&check, "check", false, "Fail if any include does not match a fleet file")
+
+	return cmd
+}
+
+// renderFleetConfig resolves the includes of the given config against the
+// fleet files. It returns the flattened config and the includes that did
+// not match any file.
+func renderFleetConfig(raw string, format cloud.ConfigFormat, files []cloud.FleetFile) (string, []string, error) {
+	r := &includeResolver{files: files}
+
+	var out string
+	var err error
+	switch format {
+	case cloud.ConfigFormatYAML, cloud.ConfigFormatJSON:
+		out, err = r.renderStructured(raw, format)
+	default:
+		out, err = r.renderClassic(raw, 0)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	return out, cfg.UniqueSlice(r.missing), nil
+}
+
+type includeResolver struct {
+	files   []cloud.FleetFile
+	missing []string
+}
+
+// match returns the fleet files matching the given include path, sorted
+// by name. The base name of the path may be a glob pattern as accepted by
+// Fluent Bit, ie: @INCLUDE inputs/*.conf.
+func (r *includeResolver) match(include string) ([]cloud.FleetFile, error) {
+	pattern := path.Base(strings.TrimSpace(include))
+
+	var out []cloud.FleetFile
+	for _, f := range r.files {
+		ok, err := path.Match(pattern, f.Name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include %q: %w", include, err)
+		}
+
+		if ok {
+			out = append(out, f)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+
+	if len(out) == 0 {
+		r.missing = append(r.missing, include)
+	}
+
+	return out, nil
+}
+
+// renderClassic replaces every @INCLUDE line with the rendered
+// contents of the matching fleet files.
+func (r *includeResolver) renderClassic(raw string, depth int) (string, error) {
+	if depth > maxIncludeDepth {
+		return "", fmt.Errorf("includes nested more than %d levels deep, there might be a cycle", maxIncludeDepth)
+	}
+
+	var sb strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(raw))
+	for scanner.Scan() {
+		line := scanner.Text()
+		directive, include, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok || !strings.EqualFold(directive, "@INCLUDE") {
+			sb.WriteString(line + "\n")
+			continue
+		}
+
+		files, err := r.match(include)
+		if err != nil {
+			return "", err
+		}
+
+		if len(files) == 0 {
+			sb.WriteString(line + "\n")
+			continue
+		}
+
+		for _, f := range files {
+			contents, err := r.renderClassic(string(f.Contents), depth+1)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", f.Name, err)
+			}
+
+			sb.WriteString(contents)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
+
+// renderStructured merges the files listed under includes into the config.
+// Lists, like the pipeline inputs, are appended and maps are merged with the
+// including config taking precedence.
+func (r *includeResolver) renderStructured(raw string, format cloud.ConfigFormat) (string, error) {
+	doc, err := r.resolveStructured(raw, 0)
+	if err != nil {
+		return "", err
+	}
+
+	if format == cloud.ConfigFormatJSON {
+		b, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return "", err
+		}
+
+		return string(b) + "\n", nil
+	}
+
+	b, err := yaml.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+func (r *includeResolver) resolveStructured(raw string, depth int) (map[string]any, error) {
+	if depth > maxIncludeDepth {
+		return nil, fmt.Errorf("includes nested more than %d levels deep, there might be a cycle", maxIncludeDepth)
+	}
+
+	doc := map[string]any{}
+	if err := yaml.Unmarshal([]byte(raw), &doc); err != nil {
+		return nil, fmt.Errorf("could not parse config: %w", err)
+	}
+
+	includes, _ := doc["includes"].([]any)
+	delete(doc, "includes")
+
+	var unresolved []any
+	for _, v := range includes {
+		include, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid include %v", v)
+		}
+
+		files, err := r.match(include)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(files) == 0 {
+			unresolved = append(unresolved, include)
+			continue
+		}
+
+		for _, f := range files {
+			included, err := r.resolveStructured(string(f.Contents), depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Name, err)
+			}
+
+			mergeConfig(doc, included)
+		}
+	}
+
+	if len(unresolved) != 0 {
+		doc["includes"] = unresolved
+	}
+
+	return doc, nil
+}
+
+// mergeConfig merges src into dst. Lists are appended, maps merged
+// recursively and any other value already in dst is kept.
+func mergeConfig(dst, src map[string]any) {
+	for k, sv := range src {
+		dv, ok := dst[k]
+		if !ok {
+			dst[k] = sv
+			continue
+		}
+
+		switch dv := dv.(type) {
+		case []any:
+			if sv, ok := sv.([]any); ok {
+				dst[k] = append(dv, sv...)
+			}
+		case map[string]any:
+			if sv, ok := sv.(map[string]any); ok {
+				mergeConfig(dv, sv)
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package fleet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/calyptia/api/types"
+)
+
+func Test_renderFleetConfig(t *testing.T) {
+	files := []types.FleetFile{
+		{Name: "inputs.conf", Contents: []byte("[INPUT]\n    Name cpu\n@INCLUDE tail.conf\n")},
+		{Name: "tail.conf", Contents: []byte("[INPUT]\n    Name tail\n")},
+		{Name: "outputs.yaml", Contents: []byte("pipeline:\n  outputs:\n    - name: stdout\n      match: '*'\n")},
+	}
+
+	t.Run("classic", func(t *testing.T) {
+		raw := "[SERVICE]\n    Flush 1\n@INCLUDE inputs.conf\n@include missing.conf\n"
+		got, missing, err := renderFleetConfig(raw, types.ConfigFormatINI, files)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := "[SERVICE]\n    Flush 1\n[INPUT]\n    Name cpu\n[INPUT]\n    Name tail\n@include missing.conf\n"
+		if got != want {
+			t.Errorf("got:\n%s\nwant:\n%s", got, want)
+		}
+
+		if len(missing) != 1 || missing[0] != "missing.conf" {
+			t.Errorf("unexpected missing includes %v", missing)
+		}
+	})
+
+	t.Run("glob", func(t *testing.T) {
+		got, missing, err := renderFleetConfig("@INCLUDE conf.d/*.conf\n", types.ConfigFormatINI, files)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Count(got, "Name tail") != 2 || len(missing) != 0 {
+			t.Errorf("unexpected rendered config %q, missing %v", got, missing)
+		}
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		raw := "includes:\n  - outputs.yaml\n  - missing.yaml\npipeline:\n  inputs:\n    - name: dummy\n"
+		got, missing, err := renderFleetConfig(raw, types.ConfigFormatYAML, files)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, s := range []string{"name: dummy", "name: stdout", "- missing.yaml"} {
+			if !strings.Contains(got, s) {
+				t.Errorf("expected %q within:\n%s", s, got)
+			}
+		}
+
+		if strings.Contains(got, "outputs.yaml") {
+			t.Errorf("expected resolved include to be removed:\n%s", got)
+		}
+
+		if len(missing) != 1 || missing[0] != "missing.yaml" {
+			t.Errorf("unexpected missing includes %v", missing)
+		}
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		cyclic := []types.FleetFile{{Name: "a.conf", Contents: []byte("@INCLUDE a.conf\n")}}
+		if _, _, err := renderFleetConfig("@INCLUDE a.conf\n", types.ConfigFormatINI, cyclic); err == nil {
+			t.Error("expected an include cycle error")
+		}
+	})
+}
@@ -3,9 +3,7 @@ package fleet
 import (
 	"encoding/json"
 	"fmt"
-	"strings"
 	"text/tabwriter"
-	"time"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
@@ -14,11 +12,14 @@ import (
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
 	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/operation"
 )
 
 func NewCmdUpdateFleet(config *cfg.Config) *cobra.Command {
 	var in types.UpdateFleet
 	var configFile, configFormat string
+	var renderTemplate bool
+	var strictCompat bool
 	var outputFormat, goTemplate string
 	completer := completer.Completer{Config: config}
 
@@ -29,6 +30,10 @@ func NewCmdUpdateFleet(config *cfg.Config) *cobra.Command {
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: completer.CompleteFleets,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if detached, err := operation.Detach(cmd); err != nil || detached {
+				return err
+			}
+
 			var err error
 
 			ctx := cmd.Context()
@@ -40,7 +45,7 @@ func NewCmdUpdateFleet(config *cfg.Config) *cobra.Command {
 			}
 			in.ID = fleetID
 
-			cfg, err := readConfig(configFile)
+			cfg, err := readConfig(configFile, renderTemplate)
 			if err != nil {
 				return err
 			}
@@ -48,20 +53,48 @@ func NewCmdUpdateFleet(config *cfg.Config) *cobra.Command {
 			format := getFormat(configFile, configFormat)
 			in.ConfigFormat = &format
 
+			if !in.SkipConfigValidation {
+				features, err := configFeatures(cfg, format)
+				if err != nil {
+					return err
+				}
+
+				if len(features) != 0 {
+					fleet, err := config.Cloud.Fleet(ctx, types.FleetParams{FleetID: fleetID})
+					if err != nil {
+						return fmt.Errorf("could not fetch fleet: %w", err)
+					}
+
+					agents, err := fleetAgents(ctx, config, fleetID)
+					if err != nil {
+						return err
+					}
+
+					issues, err := checkFleetCompat(features, fleet.MinFluentBitVersion, agents)
+					if err != nil {
+						return err
+					}
+
+					if err := reportFleetCompat(cmd, issues, strictCompat); err != nil {
+						return err
+					}
+				}
+			}
+
 			updated, err := config.Cloud.UpdateFleet(ctx, in)
 			if err != nil {
 				return err
 			}
 
-			if strings.HasPrefix(outputFormat, "go-template") {
-				return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, updated)
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, updated)
 			}
 
 			switch outputFormat {
 			case "table":
 				tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 1, ' ', 0)
 				fmt.Fprintln(tw, "ID\tUPDATED-AT")
-				fmt.Fprintf(tw, "%s\t%s\n", "0", updated.UpdatedAt.Format(time.RFC822))
+				fmt.Fprintf(tw, "%s\t%s\n", "0", formatters.FmtTimestamp(updated.UpdatedAt))
 				tw.Flush()
 			case "json":
 				return json.NewEncoder(cmd.OutOrStdout()).Encode(updated)
@@ -74,12 +107,16 @@ func NewCmdUpdateFleet(config *cfg.Config) *cobra.Command {
 		},
 	}
 
+	operation.BindFlag(cmd)
+
 	fs := cmd.Flags()
 	fs.StringVar(&configFile, "config-file", "fluent-bit.yaml", "Fluent-bit config file")
+	fs.BoolVar(&renderTemplate, "render-template", false, "Render the config file as a go template with sprig functions before sending it, ie: {{ env \"HOST\" | default \"localhost\" }}")
 	fs.StringVar(&configFormat, "config-format", "", "Optional fluent-bit config format (classic, yaml, json)")
 	fs.BoolVar(&in.SkipConfigValidation, "skip-config-validation", false, "Option to skip fluent-bit config validation (not recommended)")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	fs.BoolVar(&strictCompat, "strict-compat", false, "Fail instead of warning when the config uses features the fleet min fluent-bit version or its enrolled agents do not support")
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 
 	_ = cmd.MarkFlagRequired("name")
 
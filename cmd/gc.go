@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	cloud "github.com/calyptia/api/types"
+	"github.com/calyptia/cli/completer"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/confirm"
+	"github.com/calyptia/cli/exitcode"
+	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/protection"
+	"github.com/calyptia/cli/ttl"
+)
+
+func newCmdGC(config *cfg.Config) *cobra.Command {
+	var dryRun, confirmed, localOnly bool
+	completer := completer.Completer{Config: config}
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Delete expired temporary resources",
+		Long: "Delete the resources created with --ttl that already expired.\n" +
+			"Temporary resources are recorded locally when created, and pipelines\n" +
+			"also carry their expiry as the " + ttl.Label + " label so the ones created\n" +
+			"from other machines are collected too, unless --local-only is given.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			now := time.Now()
+
+			recorded, err := ttl.Load(config.LocalData)
+			if err != nil {
+				return err
+			}
+
+			expired := ttl.Expired(recorded, config.ProjectID, now)
+
+			if !localOnly {
+				pipelines, err := completer.FetchAllPipelines()
+				if err != nil {
+					return fmt.Errorf("could not fetch your pipelines: %w", err)
+				}
+
+				for _, p := range pipelines {
+					expiresAt, ok := ttl.ExpiresAt(p.Tags)
+					if !ok || expiresAt.After(now) {
+						continue
+					}
+
+					r := ttl.Resource{
+						Kind:      ttl.KindPipeline,
+						ID:        p.ID,
+						Name:      p.Name,
+						ProjectID: config.ProjectID,
+						ExpiresAt: expiresAt,
+					}
+					expired = append(ttl.Forget(expired, r), r)
+				}
+			}
+
+			if len(expired) == 0 {
+				cmd.Println("No expired temporary resources")
+				return nil
+			}
+
+			renderTemporaryResources(cmd.OutOrStdout(), expired)
+
+			if dryRun {
+				return nil
+			}
+
+			if !confirmed {
+				cmd.Printf("Delete %d expired resources? (y/N) ", len(expired))
+				ok, err := confirm.Read(cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+
+				if !ok {
+					cmd.Println("Aborted")
+					return nil
+				}
+			}
+
+			var deleted []ttl.Resource
+			var failed int
+			for _, r := range expired {
+				var err error
+				switch r.Kind {
+				case ttl.KindPipeline:
+					var pip cloud.Pipeline
+					pip, err = config.Cloud.Pipeline(ctx, r.ID, cloud.PipelineParams{})
+					if err == nil {
+						if protectedErr := protection.Check(cmd, "pipeline", pip.Name, protection.IsPipelineProtected(pip)); protectedErr != nil {
+							cmd.PrintErrf("Skipping protected pipeline %q\n", pip.Name)
+							continue
+						}
+
+						err = config.Cloud.DeletePipeline(ctx, r.ID)
+					}
+				case ttl.KindTraceSession:
+					// trace sessions cannot be deleted, only the active one terminated.
+					ts, getErr := config.Cloud.TraceSession(ctx, r.ID)
+					err = getErr
+					if err == nil && ts.Active() {
+						_, err = config.Cloud.TerminateActiveTraceSession(ctx, r.ParentID)
+					}
+				default:
+					err = fmt.Errorf("unknown kind %q", r.Kind)
+				}
+
+				if err != nil && exitcode.FromError(err) != exitcode.NotFound {
+					cmd.PrintErrf("could not delete %s %q: %v\n", r.Kind, r.ID, err)
+					failed++
+					continue
+				}
+
+				deleted = append(deleted, r)
+			}
+
+			if err := ttl.Save(config.LocalData, ttl.Forget(recorded, deleted...)); err != nil {
+				return err
+			}
+
+			cmd.Printf("Deleted %d expired resources\n", len(deleted))
+
+			if failed != 0 {
+				return fmt.Errorf("could not delete %d expired resources", failed)
+			}
+
+			return nil
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.BoolVar(&dryRun, "dry-run", false, "Only list the expired resources")
+	fs.BoolVarP(&confirmed, "yes", "y", false, "Confirm the deletion")
+	fs.BoolVar(&localOnly, "local-only", false, "Only collect the resources recorded on this machine")
+	protection.BindOverrideFlag(cmd)
+
+	cmd.AddCommand(newCmdGCKubernetes(config))
+	cmd.AddCommand(newCmdGCTraceSessions(config))
+
+	return cmd
+}
+
+func renderTemporaryResources(w io.Writer, resources []ttl.Resource) {
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tID\tNAME\tEXPIRED")
+	for _, r := range resources {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Kind, r.ID, r.Name, formatters.FmtTime(r.ExpiresAt))
+	}
+	tw.Flush()
+}
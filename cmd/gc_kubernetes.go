@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	cloud "github.com/calyptia/api/types"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/confirm"
+	"github.com/calyptia/cli/exitcode"
+	"github.com/calyptia/cli/k8s"
+)
+
+// orphanedObject is a managed kubernetes object whose owning
+// Cloud core instance or pipeline no longer exists.
+type orphanedObject struct {
+	k8s.ManagedObject
+	Reason string
+}
+
+func newCmdGCKubernetes(config *cfg.Config) *cobra.Command {
+	var dryRun, confirmed, includeUnlabeled bool
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	configOverrides := &clientcmd.ConfigOverrides{}
+
+	cmd := &cobra.Command{
+		Use:     "kubernetes",
+		Aliases: []string{"kube", "k8s"},
+		Short:   "Delete kubernetes objects left behind by deleted core instances and pipelines",
+		Long: "Delete the kubernetes objects labeled with a core instance ID whose core instance,\n" +
+			"or pipeline, no longer exists in Calyptia Cloud, ie: leaked by older CLI versions.\n" +
+			"Only objects labeled with the current project are considered. Objects without project label,\n" +
+			"ie: created by older CLI versions, may belong to another project and are only considered with --include-unlabeled.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+			kubeClientConfig, err := kubeConfig.ClientConfig()
+			if err != nil {
+				return err
+			}
+
+			clientSet, err := kubernetes.NewForConfig(kubeClientConfig)
+			if err != nil {
+				return err
+			}
+
+			k8sClient := &k8s.Client{
+				Interface: clientSet,
+				Config:    kubeClientConfig,
+			}
+
+			managed, err := k8sClient.ListManagedObjects(ctx)
+			if err != nil {
+				return err
+			}
+
+			orphans, err := findOrphanedObjects(ctx, config, managed, includeUnlabeled)
+			if err != nil {
+				return err
+			}
+
+			if len(orphans) == 0 {
+				cmd.Println("No orphaned kubernetes objects")
+				return nil
+			}
+
+			renderOrphanedObjects(cmd.OutOrStdout(), orphans)
+
+			if dryRun {
+				return nil
+			}
+
+			if !confirmed {
+				cmd.Printf("Delete %d orphaned kubernetes objects? (y/N) ", len(orphans))
+				ok, err := confirm.Read(cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+
+				if !ok {
+					cmd.Println("Aborted")
+					return nil
+				}
+			}
+
+			var deleted, failed int
+			for _, o := range orphans {
+				if err := k8sClient.DeleteObject(ctx, o.NamedObject); err != nil {
+					cmd.PrintErrf("could not delete %s: %v\n", o.NamedObject, err)
+					failed++
+					continue
+				}
+				deleted++
+			}
+
+			cmd.Printf("Deleted %d orphaned kubernetes objects\n", deleted)
+
+			if failed != 0 {
+				return fmt.Errorf("could not delete %d orphaned kubernetes objects", failed)
+			}
+
+			return nil
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.BoolVar(&dryRun, "dry-run", false, "Only list the orphaned kubernetes objects")
+	fs.BoolVarP(&confirmed, "yes", "y", false, "Confirm the deletion")
+	fs.BoolVar(&includeUnlabeled, "include-unlabeled", false, "Also consider the objects without project label. Only use it when the cluster runs core instances of the current project alone, since those of other projects are not found with its token and would be deleted")
+	clientcmd.BindOverrideFlags(configOverrides, fs, clientcmd.RecommendedConfigOverrideFlags("kube-"))
+
+	return cmd
+}
+
+// findOrphanedObjects looks up the owners of the given objects in Cloud,
+// once per owner, and returns the objects whose owner is gone.
+// Objects without project label are skipped unless includeUnlabeled is set,
+// as their owner may belong to another project.
+func findOrphanedObjects(ctx context.Context, config *cfg.Config, objects []k8s.ManagedObject, includeUnlabeled bool) ([]orphanedObject, error) {
+	coreInstances := map[string]bool{}
+	pipelines := map[string]bool{}
+
+	exists := func(cache map[string]bool, id string, fetch func() error) (bool, error) {
+		if ok, found := cache[id]; found {
+			return ok, nil
+		}
+
+		err := fetch()
+		if err != nil && exitcode.FromError(err) != exitcode.NotFound {
+			return false, err
+		}
+
+		cache[id] = err == nil
+		return cache[id], nil
+	}
+
+	var out []orphanedObject
+	for _, obj := range objects {
+		if obj.ProjectID != config.ProjectID && (obj.ProjectID != "" || !includeUnlabeled) {
+			continue
+		}
+
+		ok, err := exists(coreInstances, obj.CoreInstanceID, func() error {
+			_, err := config.Cloud.CoreInstance(ctx, obj.CoreInstanceID)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch core instance %q: %w", obj.CoreInstanceID, err)
+		}
+
+		if !ok {
+			out = append(out, orphanedObject{ManagedObject: obj, Reason: "core instance " + obj.CoreInstanceID + " deleted"})
+			continue
+		}
+
+		if obj.PipelineID == "" {
+			continue
+		}
+
+		ok, err = exists(pipelines, obj.PipelineID, func() error {
+			_, err := config.Cloud.Pipeline(ctx, obj.PipelineID, cloud.PipelineParams{})
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch pipeline %q: %w", obj.PipelineID, err)
+		}
+
+		if !ok {
+			out = append(out, orphanedObject{ManagedObject: obj, Reason: "pipeline " + obj.PipelineID + " deleted"})
+		}
+	}
+
+	return out, nil
+}
+
+func renderOrphanedObjects(w io.Writer, objects []orphanedObject) {
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tNAMESPACE\tNAME\tREASON")
+	for _, o := range objects {
+		namespace := o.Namespace
+		if namespace == "" {
+			namespace = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", o.Kind, namespace, o.Name, o.Reason)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/calyptia/api/client"
+
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/k8s"
+)
+
+func TestFindOrphanedObjects(t *testing.T) {
+	// only core-1 and its pipeline-1 exist in Cloud.
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/v1/aggregators/core-1":
+			_, _ = w.Write([]byte(`{"id":"core-1"}`))
+		case "/v1/aggregator_pipelines/pipeline-1":
+			_, _ = w.Write([]byte(`{"id":"pipeline-1"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"not found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	cloud := client.New()
+	cloud.BaseURL = srv.URL
+	config := &cfg.Config{Cloud: cloud, ProjectID: "project-1"}
+
+	object := func(name, projectID, coreInstanceID, pipelineID string) k8s.ManagedObject {
+		return k8s.ManagedObject{
+			NamedObject:    k8s.NamedObject{Kind: "Deployment", Namespace: "default", Name: name},
+			ProjectID:      projectID,
+			CoreInstanceID: coreInstanceID,
+			PipelineID:     pipelineID,
+		}
+	}
+
+	objects := []k8s.ManagedObject{
+		object("live-core", "project-1", "core-1", ""),
+		object("live-pipeline", "project-1", "core-1", "pipeline-1"),
+		object("deleted-pipeline", "project-1", "core-1", "pipeline-2"),
+		object("deleted-core", "project-1", "core-2", ""),
+		object("deleted-core-pipeline", "project-1", "core-2", "pipeline-3"),
+		// the core instance of another project is not found with the
+		// current project token, but it must not be deleted.
+		object("other-project", "project-2", "core-3", ""),
+		object("unlabeled", "", "core-4", ""),
+	}
+
+	names := func(orphans []orphanedObject) string {
+		var out []string
+		for _, o := range orphans {
+			out = append(out, o.Name+"="+o.Reason)
+		}
+		sort.Strings(out)
+		return strings.Join(out, ",")
+	}
+
+	t.Run("default", func(t *testing.T) {
+		orphans, err := findOrphanedObjects(context.Background(), config, objects, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := "deleted-core-pipeline=core instance core-2 deleted," +
+			"deleted-core=core instance core-2 deleted," +
+			"deleted-pipeline=pipeline pipeline-2 deleted"
+		if got := names(orphans); got != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	})
+
+	t.Run("include unlabeled", func(t *testing.T) {
+		requests = 0
+		orphans, err := findOrphanedObjects(context.Background(), config, objects, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := names(orphans); !strings.Contains(got, "unlabeled=core instance core-4 deleted") || strings.Contains(got, "other-project") {
+			t.Errorf("unexpected orphans %s", got)
+		}
+
+		// core-1, core-2, core-4, pipeline-1 and pipeline-2, each looked up once.
+		if requests != 5 {
+			t.Errorf("expected 5 Cloud requests, got %d", requests)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	cloud "github.com/calyptia/api/types"
+	"github.com/calyptia/cli/completer"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/confirm"
+	"github.com/calyptia/cli/exitcode"
+	"github.com/calyptia/cli/formatters"
+)
+
+// leakedTraceSession is an active trace session along with its pipeline name.
+type leakedTraceSession struct {
+	cloud.TraceSession
+	PipelineName string
+}
+
+func newCmdGCTraceSessions(config *cfg.Config) *cobra.Command {
+	var dryRun, confirmed bool
+	var olderThan time.Duration
+	completer := completer.Completer{Config: config}
+
+	cmd := &cobra.Command{
+		Use:     "trace_sessions",
+		Aliases: []string{"trace_session"},
+		Short:   "Terminate active trace sessions older than a TTL",
+		Long: "Terminate the active trace sessions started longer than --older-than ago,\n" +
+			"ie: left behind by an interrupted command or created with a long lifespan.\n" +
+			"Trace sessions cannot be deleted, only the active one of each pipeline terminated.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			now := time.Now()
+
+			pipelines, err := completer.FetchAllPipelines()
+			if err != nil {
+				return fmt.Errorf("could not fetch your pipelines: %w", err)
+			}
+
+			var leaked []leakedTraceSession
+			for _, p := range pipelines {
+				ts, err := config.Cloud.ActiveTraceSession(ctx, p.ID)
+				if exitcode.FromError(err) == exitcode.NotFound {
+					continue
+				}
+
+				if err != nil {
+					return fmt.Errorf("could not fetch active trace session of pipeline %q: %w", p.Name, err)
+				}
+
+				if ts.Active() && now.Sub(ts.CreatedAt) >= olderThan {
+					// terminate by the pipeline being checked, the session
+					// does not always carry its pipeline ID.
+					ts.PipelineID = p.ID
+					leaked = append(leaked, leakedTraceSession{TraceSession: ts, PipelineName: p.Name})
+				}
+			}
+
+			if len(leaked) == 0 {
+				cmd.Println("No leaked trace sessions")
+				return nil
+			}
+
+			renderLeakedTraceSessions(cmd.OutOrStdout(), leaked)
+
+			if dryRun {
+				return nil
+			}
+
+			if !confirmed {
+				cmd.Printf("Terminate %d trace sessions? (y/N) ", len(leaked))
+				ok, err := confirm.Read(cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+
+				if !ok {
+					cmd.Println("Aborted")
+					return nil
+				}
+			}
+
+			var terminated, failed int
+			for _, ts := range leaked {
+				_, err := config.Cloud.TerminateActiveTraceSession(ctx, ts.PipelineID)
+				if err != nil && exitcode.FromError(err) != exitcode.NotFound {
+					cmd.PrintErrf("could not terminate trace session %q: %v\n", ts.ID, err)
+					failed++
+					continue
+				}
+				terminated++
+			}
+
+			cmd.Printf("Terminated %d trace sessions\n", terminated)
+
+			if failed != 0 {
+				return fmt.Errorf("could not terminate %d trace sessions", failed)
+			}
+
+			return nil
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.DurationVar(&olderThan, "older-than", time.Hour, "Only terminate the trace sessions started at least this long ago")
+	fs.BoolVar(&dryRun, "dry-run", false, "Only list the leaked trace sessions")
+	fs.BoolVarP(&confirmed, "yes", "y", false, "Confirm the termination")
+
+	return cmd
+}
+
+func renderLeakedTraceSessions(w io.Writer, sessions []leakedTraceSession) {
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "ID\tPIPELINE\tPLUGINS\tLIFESPAN\tAGE")
+	for _, ts := range sessions {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", ts.ID, ts.PipelineName, len(ts.Plugins), formatters.FmtDuration(time.Duration(ts.Lifespan)), formatters.FmtTime(ts.CreatedAt))
+	}
+	tw.Flush()
+}
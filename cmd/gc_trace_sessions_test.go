@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/calyptia/api/client"
+
+	cfg "github.com/calyptia/cli/config"
+)
+
+func TestNewCmdGCTraceSessions(t *testing.T) {
+	now := time.Now().UTC()
+	session := func(id string, createdAt time.Time) string {
+		return fmt.Sprintf(`{"id":%q,"lifespan":"24h0m0s","createdAt":%q}`, id, createdAt.Format(time.RFC3339))
+	}
+
+	var mu sync.Mutex
+	var terminated []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/projects/project-1/core_instances":
+			_, _ = w.Write([]byte(`{"items":[{"id":"core-1"}]}`))
+		case r.URL.Path == "/v1/core_instances/core-1/pipelines":
+			_, _ = w.Write([]byte(`{"items":[{"id":"old","name":"old"},{"id":"new","name":"new"},{"id":"none","name":"none"}]}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/pipelines/old/trace_session":
+			_, _ = w.Write([]byte(session("session-old", now.Add(-2*time.Hour))))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/pipelines/new/trace_session":
+			_, _ = w.Write([]byte(session("session-new", now.Add(-time.Minute))))
+		case r.Method == http.MethodDelete && strings.HasSuffix(r.URL.Path, "/trace_session"):
+			mu.Lock()
+			terminated = append(terminated, r.URL.Path)
+			mu.Unlock()
+			_, _ = w.Write([]byte(`{"id":"terminated"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"not found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	cloud := client.New()
+	cloud.BaseURL = srv.URL
+	config := &cfg.Config{Ctx: context.Background(), Cloud: cloud, ProjectID: "project-1"}
+
+	run := func(args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		cmd := newCmdGCTraceSessions(config)
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		cmd.SetArgs(args)
+		if err := cmd.ExecuteContext(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+
+	out := run("--dry-run")
+	if !strings.Contains(out, "session-old") || strings.Contains(out, "session-new") {
+		t.Errorf("expected only the old session to be listed, got:\n%s", out)
+	}
+	if len(terminated) != 0 {
+		t.Fatalf("expected no terminations on dry run, got %v", terminated)
+	}
+
+	out = run("--yes")
+	if !strings.Contains(out, "Terminated 1 trace sessions") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if len(terminated) != 1 || terminated[0] != "/v1/pipelines/old/trace_session" {
+		t.Errorf("expected the old session to be terminated, got %v", terminated)
+	}
+}
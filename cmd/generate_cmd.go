@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/calyptia/cli/cmd/operator"
+)
+
+func newCmdGenerate() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate calyptia related files",
+	}
+
+	cmd.AddCommand(
+		operator.NewCmdGenerateKubeconfig(),
+	)
+
+	return cmd
+}
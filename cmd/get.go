@@ -12,11 +12,14 @@ import (
 	"github.com/calyptia/cli/cmd/fleet"
 	"github.com/calyptia/cli/cmd/ingestcheck"
 	"github.com/calyptia/cli/cmd/members"
+	"github.com/calyptia/cli/cmd/operation"
+	"github.com/calyptia/cli/cmd/operator"
 	"github.com/calyptia/cli/cmd/pipeline"
 	"github.com/calyptia/cli/cmd/resourceprofile"
 	"github.com/calyptia/cli/cmd/tracerecord"
 	"github.com/calyptia/cli/cmd/tracesession"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/features"
 )
 
 func newCmdGet(config *cfg.Config) *cobra.Command {
@@ -37,6 +40,8 @@ func newCmdGet(config *cfg.Config) *cobra.Command {
 		endpoint.NewCmdGetEndpoints(config),
 		pipeline.NewCmdGetPipelineConfigHistory(config),
 		pipeline.NewCmdGetPipelineStatusHistory(config),
+		pipeline.NewCmdGetPipelineMetrics(config),
+		pipeline.NewCmdGetPipelineBuffers(config),
 		pipeline.NewCmdGetPipelineSecrets(config),
 		pipeline.NewCmdGetPipelineFiles(config),
 		pipeline.NewCmdGetPipelineFile(config),
@@ -48,14 +53,25 @@ func newCmdGet(config *cfg.Config) *cobra.Command {
 		tracesession.NewCmdGetTraceSession(config),
 		tracerecord.NewCmdGetTraceRecords(config),
 		cnfg.NewCmdGetConfigSections(config),
+		cnfg.NewCmdGetConfig(config),
+		operator.NewCmdGetOperatorStatus(),
+		operator.NewCmdGetCRDs(),
+		operator.NewCmdGetOperatorReleases(),
+		operation.NewCmdGetOperation(),
+	)
+
+	cmd.AddCommand(features.Require(features.IngestChecks,
 		ingestcheck.NewCmdGetIngestChecks(config),
 		ingestcheck.NewCmdGetIngestCheck(config),
 		ingestcheck.NewCmdGetIngestCheckLogs(config),
+	)...)
+
+	cmd.AddCommand(features.Require(features.Fleets,
 		fleet.NewCmdGetFleets(config),
 		fleet.NewCmdGetFleet(config),
 		fleet.NewCmdGetFleetFiles(config),
 		fleet.NewCmdGetFleetFile(config),
-	)
+	)...)
 
 	return cmd
 }
@@ -1,14 +1,18 @@
 package ingestcheck
 
 import (
-	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
 
 	"github.com/calyptia/api/types"
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/state"
 )
 
 func NewCmdCreateIngestCheck(config *cfg.Config) *cobra.Command {
@@ -18,20 +22,72 @@ func NewCmdCreateIngestCheck(config *cfg.Config) *cobra.Command {
 		status          string
 		environment     string
 		collectLogs     bool
+		fromTemplate    string
+		host            string
+		port            uint
 	)
 	completer := completer.Completer{Config: config}
 
 	cmd := &cobra.Command{
 		Use:   "ingest_check CORE_INSTANCE",
 		Short: "Create an ingest check",
-		Args:  cobra.ExactArgs(1),
+		Long: "Create an ingest check running the given output config section.\n" +
+			"With --from-template the config section gets created to send sample records\n" +
+			"to a pipeline source at --host, so a new pipeline can be verified in one command.\n\n" +
+			"Templates:\n" + describeIngestCheckTemplates(),
+		Example: "  calyptia create ingest_check my-core --from-template syslog --host my-pipeline.default.svc",
+		Args:    cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			coreInstance := args[0]
-			ctx := context.Background()
+			ctx := cmd.Context()
 
 			params := types.CreateIngestCheck{
 				CollectLogs: collectLogs,
 			}
+
+			if fromTemplate != "" {
+				if configSectionID != "" {
+					return fmt.Errorf("--config-section-id cannot be used with --from-template")
+				}
+
+				if host == "" {
+					return fmt.Errorf("--host is required with --from-template")
+				}
+
+				tmpl, err := lookupIngestCheckTemplate(fromTemplate)
+				if err != nil {
+					return err
+				}
+
+				if port == 0 {
+					port = tmpl.Port
+				}
+
+				if !cmd.Flags().Changed("retries") {
+					retries = tmpl.Retries
+				}
+
+				created, err := config.Cloud.CreateConfigSection(ctx, config.ProjectID, types.CreateConfigSection{
+					Kind:       types.SectionKindOutput,
+					Properties: tmpl.Properties(host, port),
+				})
+				if err != nil {
+					return fmt.Errorf("could not create %s template config section: %w", fromTemplate, err)
+				}
+
+				err = config.State.Track(cmd, state.Resource{
+					Kind:      state.KindConfigSection,
+					ID:        created.ID,
+					Name:      fromTemplate,
+					ProjectID: config.ProjectID,
+				})
+				if err != nil {
+					return err
+				}
+
+				configSectionID = created.ID
+			}
+
 			if configSectionID == "" {
 				return fmt.Errorf("invalid config section id")
 			}
@@ -62,8 +118,32 @@ func NewCmdCreateIngestCheck(config *cfg.Config) *cobra.Command {
 			if err != nil {
 				return err
 			}
-			cmd.Println(check.ID)
-			return nil
+
+			err = config.State.Track(cmd, state.Resource{
+				Kind:      state.KindIngestCheck,
+				ID:        check.ID,
+				ProjectID: config.ProjectID,
+				ParentID:  coreInstanceID,
+			})
+			if err != nil {
+				return err
+			}
+
+			fs := cmd.Flags()
+			outputFormat := formatters.OutputFormatFromFlags(fs)
+			if fn, ok := formatters.ShouldApplyTemplating(outputFormat); ok {
+				return fn(cmd.OutOrStdout(), formatters.TemplateFromFlags(fs), check)
+			}
+
+			switch outputFormat {
+			case formatters.OutputFormatJSON:
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(check)
+			case formatters.OutputFormatYAML:
+				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(check)
+			default:
+				cmd.Println(check.ID)
+				return nil
+			}
 		},
 	}
 	flags := cmd.Flags()
@@ -72,5 +152,15 @@ func NewCmdCreateIngestCheck(config *cfg.Config) *cobra.Command {
 	flags.StringVar(&status, "status", "", "status")
 	flags.BoolVar(&collectLogs, "collect-logs", false, "Collect logs from the kubernetes pods once the job is finished")
 	flags.StringVar(&environment, "environment", "default", "calyptia environment name")
+	flags.StringVar(&fromTemplate, "from-template", "", fmt.Sprintf("Create the config section from a template sending sample records to a pipeline source, options: %s", strings.Join(ingestCheckTemplateNames(), ", ")))
+	flags.StringVar(&host, "host", "", "Host of the pipeline to send the sample records to, required with --from-template")
+	flags.UintVar(&port, "port", 0, "Port of the pipeline source. Defaults to the template one")
+	formatters.BindFormatFlags(cmd)
+
+	cmd.MarkFlagsMutuallyExclusive("from-template", "config-section-id")
+
+	_ = cmd.RegisterFlagCompletionFunc("from-template", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return ingestCheckTemplateNames(), cobra.ShellCompDirectiveNoFileComp
+	})
 	return cmd
 }
@@ -1,11 +1,12 @@
 package ingestcheck
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"strings"
+	"io"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
@@ -13,7 +14,9 @@ import (
 	"github.com/calyptia/api/types"
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/exitcode"
 	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/notify"
 )
 
 func NewCmdGetIngestCheck(c *cfg.Config) *cobra.Command {
@@ -27,14 +30,14 @@ func NewCmdGetIngestCheck(c *cfg.Config) *cobra.Command {
 		Short: "Get a specific ingest check",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := context.Background()
+			ctx := cmd.Context()
 			id := args[0]
 			check, err := c.Cloud.IngestCheck(ctx, id)
 			if err != nil {
 				return err
 			}
-			if strings.HasPrefix(outputFormat, "go-template") {
-				return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, check)
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, check)
 			}
 			switch outputFormat {
 			case "table":
@@ -68,8 +71,8 @@ func NewCmdGetIngestCheck(c *cfg.Config) *cobra.Command {
 	}
 	fs := cmd.Flags()
 	fs.BoolVar(&showIDs, "show-ids", false, "Include member IDs in table output")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 	_ = cmd.RegisterFlagCompletionFunc("output-format", formatters.CompleteOutputFormat)
 	return cmd
 }
@@ -81,16 +84,39 @@ func NewCmdGetIngestChecks(c *cfg.Config) *cobra.Command {
 		last         uint
 		goTemplate   string
 		environment  string
+		coreInstance string
+		status       string
+		watch        bool
+		interval     time.Duration
 	)
 	completer := completer.Completer{Config: c}
 
 	cmd := &cobra.Command{
-		Use:   "ingest_checks CORE_INSTANCE",
+		Use:   "ingest_checks [CORE_INSTANCE]",
 		Short: "Get a list of ingest checks",
-		Args:  cobra.ExactArgs(1),
+		Long: "Get a list of ingest checks from a core instance along with the pass rate\n" +
+			"of the finished ones. With --watch, new checks and status changes are printed as they happen,\n" +
+			"and --notify posts a message whenever the latest finished check fails, and once it passes again.",
+		Example:           "  calyptia get ingest_checks my-core --watch --notify slack://hooks.slack.com/services/T/B/X",
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completer.CompleteCoreInstances,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := context.Background()
-			id := args[0]
+			ctx := cmd.Context()
+			if len(args) == 1 {
+				if coreInstance != "" && coreInstance != args[0] {
+					return fmt.Errorf("core instance given as both argument %q and --core-instance %q", args[0], coreInstance)
+				}
+				coreInstance = args[0]
+			}
+
+			if coreInstance == "" {
+				return errors.New("core instance is required, either as argument or with --core-instance")
+			}
+
+			if status != "" && !validCheckStatus(status) {
+				return fmt.Errorf("invalid status %q, allowed: %v", status, types.AllValidCheckStatuses)
+			}
+
 			var environmentID string
 			if environment != "" {
 				var err error
@@ -99,39 +125,47 @@ func NewCmdGetIngestChecks(c *cfg.Config) *cobra.Command {
 					return err
 				}
 			}
-			aggregatorID, err := completer.LoadCoreInstanceID(id, environmentID)
+			aggregatorID, err := completer.LoadCoreInstanceID(coreInstance, environmentID)
 			if err != nil {
 				return err
 			}
+
+			if watch {
+				notifier, err := notify.FromFlags(cmd)
+				if err != nil {
+					return err
+				}
+
+				return watchIngestChecks(cmd, c, notifier, coreInstance, aggregatorID, last, status, interval, outputFormat, goTemplate, showIDs)
+			}
+
 			check, err := c.Cloud.IngestChecks(ctx, aggregatorID, types.IngestChecksParams{Last: &last})
 			if err != nil {
 				return err
 			}
 
-			if strings.HasPrefix(outputFormat, "go-template") {
-				return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, check.Items)
+			rate := computePassRate(check.Items)
+			check.Items = filterByStatus(check.Items, status)
+
+			if err := exitcode.FailOnEmpty(cmd, len(check.Items)); err != nil {
+				return err
+			}
+
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, check.Items)
 			}
 			switch outputFormat {
 			case "table":
 				tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 3, 1, ' ', 0)
-				if showIDs {
-					fmt.Fprintf(tw, "ID\t")
-				}
-				fmt.Fprint(tw, "STATUS\tRETRIES\t")
-				fmt.Fprintln(tw, "AGE")
-				for _, m := range check.Items {
-					if showIDs {
-						fmt.Fprintf(tw, "%s\t", m.ID)
-					}
-
-					fmt.Fprintf(tw, "%s\t", m.Status)
-					fmt.Fprintf(tw, "%d\t", m.Retries)
-					fmt.Fprintln(tw, formatters.FmtTime(m.CreatedAt))
-				}
+				renderIngestChecksHeader(tw, showIDs)
+				renderIngestChecksRows(tw, check.Items, showIDs)
 				err := tw.Flush()
 				if err != nil {
 					return err
 				}
+
+				fmt.Fprintln(cmd.OutOrStdout())
+				fmt.Fprintln(cmd.OutOrStdout(), rate)
 			case "json":
 				return json.NewEncoder(cmd.OutOrStdout()).Encode(check.Items)
 			case "yml", "yaml":
@@ -146,9 +180,122 @@ func NewCmdGetIngestChecks(c *cfg.Config) *cobra.Command {
 	fs := cmd.Flags()
 	fs.UintVarP(&last, "last", "l", 0, "Last `N` members. 0 means no limit")
 	fs.BoolVar(&showIDs, "show-ids", false, "Include member IDs in table output")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 	fs.StringVar(&environment, "environment", "default", "Environment name")
+	fs.StringVar(&coreInstance, "core-instance", "", "Core instance ID or name to list the ingest checks from")
+	fs.StringVar(&status, "status", "", fmt.Sprintf("Filter ingest checks by status, allowed: %v", types.AllValidCheckStatuses))
+	fs.BoolVarP(&watch, "watch", "w", false, "Watch for new ingest checks and status changes")
+	fs.DurationVar(&interval, "interval", time.Second*5, "Polling interval when watching")
+	notify.BindFlag(cmd)
 	_ = cmd.RegisterFlagCompletionFunc("output-format", formatters.CompleteOutputFormat)
+	_ = cmd.RegisterFlagCompletionFunc("environment", completer.CompleteEnvironments)
+	_ = cmd.RegisterFlagCompletionFunc("core-instance", completer.CompleteCoreInstances)
+	_ = cmd.RegisterFlagCompletionFunc("status", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		out := make([]string, 0, len(types.AllValidCheckStatuses))
+		for _, s := range types.AllValidCheckStatuses {
+			out = append(out, string(s))
+		}
+		return out, cobra.ShellCompDirectiveNoFileComp
+	})
+	exitcode.BindFailOnEmptyFlag(cmd)
+
 	return cmd
 }
+
+func renderIngestChecksHeader(w io.Writer, showIDs bool) {
+	if showIDs {
+		fmt.Fprintf(w, "ID\t")
+	}
+	fmt.Fprint(w, "STATUS\tRETRIES\t")
+	fmt.Fprintln(w, "AGE")
+}
+
+func renderIngestChecksRows(w io.Writer, checks []types.IngestCheck, showIDs bool) {
+	for _, m := range checks {
+		if showIDs {
+			fmt.Fprintf(w, "%s\t", m.ID)
+		}
+
+		fmt.Fprintf(w, "%s\t", m.Status)
+		fmt.Fprintf(w, "%d\t", m.Retries)
+		fmt.Fprintln(w, formatters.FmtTime(m.CreatedAt))
+	}
+}
+
+// watchIngestChecks polls the core instance ingest checks printing the new
+// ones and those whose status changed, until the context is canceled.
+// In table output the pass rate is reported whenever it changes.
+// The latest finished check is observed by the notifier.
+func watchIngestChecks(cmd *cobra.Command, c *cfg.Config, notifier *notify.Notifier, coreInstance, coreInstanceID string, last uint, status string, interval time.Duration, outputFormat, goTemplate string, showIDs bool) error {
+	ctx := cmd.Context()
+	out := cmd.OutOrStdout()
+	seen := map[string]types.IngestCheck{}
+	var lastRate passRate
+
+	tw := tabwriter.NewWriter(out, 0, 3, 1, ' ', 0)
+	if outputFormat == "table" {
+		renderIngestChecksHeader(tw, showIDs)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		check, err := c.Cloud.IngestChecks(ctx, coreInstanceID, types.IngestChecksParams{Last: &last})
+		if err != nil && ctx.Err() == nil {
+			return err
+		}
+
+		if err == nil {
+			changed := filterByStatus(changedChecks(check.Items, seen), status)
+			if latest, ok := latestFinishedCheck(check.Items); ok {
+				msg := fmt.Sprintf("latest ingest check %s %s after %d retries", latest.ID, latest.Status, latest.Retries)
+				if err := notifier.Observe(ctx, "ingest checks of core instance "+coreInstance, latest.Status == types.CheckStatusFailed, msg); err != nil {
+					cmd.PrintErrf("Warning: %v\n", err)
+				}
+			}
+
+			switch {
+			case formatters.IsTemplating(outputFormat):
+				if len(changed) != 0 {
+					if err := formatters.ApplyTemplate(out, outputFormat, goTemplate, changed); err != nil {
+						return err
+					}
+				}
+			case outputFormat == "table":
+				renderIngestChecksRows(tw, changed, showIDs)
+				if err := tw.Flush(); err != nil {
+					return err
+				}
+
+				if rate := computePassRate(check.Items); rate != lastRate {
+					fmt.Fprintln(out, rate)
+					lastRate = rate
+				}
+			case outputFormat == "json":
+				enc := json.NewEncoder(out)
+				for _, m := range changed {
+					if err := enc.Encode(m); err != nil {
+						return err
+					}
+				}
+			case outputFormat == "yml" || outputFormat == "yaml":
+				for _, m := range changed {
+					fmt.Fprintln(out, "---")
+					if err := yaml.NewEncoder(out).Encode(m); err != nil {
+						return err
+					}
+				}
+			default:
+				return fmt.Errorf("unknown output format %q", outputFormat)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package ingestcheck
 
 import (
-	"context"
 	"fmt"
 
 	cfg "github.com/calyptia/cli/config"
@@ -14,13 +13,13 @@ func NewCmdGetIngestCheckLogs(c *cfg.Config) *cobra.Command {
 		Short: "Get a specific ingest check logs",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := context.Background()
+			ctx := cmd.Context()
 			id := args[0]
 			check, err := c.Cloud.IngestCheck(ctx, id)
 			if err != nil {
 				return err
 			}
-			fmt.Println(string(check.Logs))
+			fmt.Fprintln(cmd.OutOrStdout(), string(check.Logs))
 			return nil
 		},
 	}
@@ -0,0 +1,95 @@
+package ingestcheck
+
+import (
+	"fmt"
+
+	"github.com/calyptia/api/types"
+)
+
+// passRate of the ingest checks that have finished running.
+type passRate struct {
+	OK      int
+	Failed  int
+	Pending int
+}
+
+func computePassRate(checks []types.IngestCheck) passRate {
+	var out passRate
+	for _, c := range checks {
+		switch c.Status {
+		case types.CheckStatusOK:
+			out.OK++
+		case types.CheckStatusFailed:
+			out.Failed++
+		default:
+			out.Pending++
+		}
+	}
+	return out
+}
+
+func (r passRate) String() string {
+	finished := r.OK + r.Failed
+	if finished == 0 {
+		return fmt.Sprintf("Pass rate: n/a (%d pending)", r.Pending)
+	}
+
+	return fmt.Sprintf("Pass rate: %.1f%% (%d ok, %d failed, %d pending)", float64(r.OK)*100/float64(finished), r.OK, r.Failed, r.Pending)
+}
+
+func validCheckStatus(status string) bool {
+	for _, s := range types.AllValidCheckStatuses {
+		if string(s) == status {
+			return true
+		}
+	}
+	return false
+}
+
+func filterByStatus(checks []types.IngestCheck, status string) []types.IngestCheck {
+	if status == "" {
+		return checks
+	}
+
+	var out []types.IngestCheck
+	for _, c := range checks {
+		if string(c.Status) == status {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// changedChecks returns the checks that are new or whose status or retries
+// changed since the last time they were seen, updating seen along the way.
+// Checks are returned from the oldest to the newest.
+func changedChecks(checks []types.IngestCheck, seen map[string]types.IngestCheck) []types.IngestCheck {
+	var out []types.IngestCheck
+	for i := len(checks) - 1; i >= 0; i-- {
+		c := checks[i]
+		prev, ok := seen[c.ID]
+		if ok && prev.Status == c.Status && prev.Retries == c.Retries {
+			continue
+		}
+
+		seen[c.ID] = c
+		out = append(out, c)
+	}
+	return out
+}
+
+// latestFinishedCheck returns the newest check that either passed or failed.
+func latestFinishedCheck(checks []types.IngestCheck) (types.IngestCheck, bool) {
+	var out types.IngestCheck
+	var found bool
+	for _, c := range checks {
+		if c.Status != types.CheckStatusOK && c.Status != types.CheckStatusFailed {
+			continue
+		}
+
+		if !found || c.CreatedAt.After(out.CreatedAt) {
+			out, found = c, true
+		}
+	}
+	return out, found
+}
@@ -0,0 +1,109 @@
+package ingestcheck
+
+import (
+	"testing"
+	"time"
+
+	"github.com/calyptia/api/types"
+)
+
+func TestComputePassRate(t *testing.T) {
+	tt := []struct {
+		name   string
+		checks []types.IngestCheck
+		want   string
+	}{
+		{
+			name: "empty",
+			want: "Pass rate: n/a (0 pending)",
+		},
+		{
+			name: "pending only",
+			checks: []types.IngestCheck{
+				{Status: types.CheckStatusNew},
+				{Status: types.CheckStatusRunning},
+			},
+			want: "Pass rate: n/a (2 pending)",
+		},
+		{
+			name: "mixed",
+			checks: []types.IngestCheck{
+				{Status: types.CheckStatusOK},
+				{Status: types.CheckStatusOK},
+				{Status: types.CheckStatusOK},
+				{Status: types.CheckStatusFailed},
+				{Status: types.CheckStatusRunning},
+			},
+			want: "Pass rate: 75.0% (3 ok, 1 failed, 1 pending)",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := computePassRate(tc.checks).String(); got != tc.want {
+				t.Errorf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestChangedChecks(t *testing.T) {
+	seen := map[string]types.IngestCheck{}
+
+	// checks are listed from the newest to the oldest.
+	got := changedChecks([]types.IngestCheck{
+		{ID: "2", Status: types.CheckStatusRunning},
+		{ID: "1", Status: types.CheckStatusOK},
+	}, seen)
+	if len(got) != 2 || got[0].ID != "1" || got[1].ID != "2" {
+		t.Fatalf("unexpected changed checks %+v", got)
+	}
+
+	got = changedChecks([]types.IngestCheck{
+		{ID: "3", Status: types.CheckStatusNew},
+		{ID: "2", Status: types.CheckStatusFailed},
+		{ID: "1", Status: types.CheckStatusOK},
+	}, seen)
+	if len(got) != 2 || got[0].ID != "2" || got[0].Status != types.CheckStatusFailed || got[1].ID != "3" {
+		t.Fatalf("unexpected changed checks %+v", got)
+	}
+
+	got = changedChecks([]types.IngestCheck{
+		{ID: "3", Status: types.CheckStatusNew},
+	}, seen)
+	if len(got) != 0 {
+		t.Fatalf("expected no changes, got %+v", got)
+	}
+}
+
+func TestFilterByStatus(t *testing.T) {
+	checks := []types.IngestCheck{
+		{ID: "1", Status: types.CheckStatusOK},
+		{ID: "2", Status: types.CheckStatusFailed},
+	}
+
+	if got := filterByStatus(checks, ""); len(got) != 2 {
+		t.Errorf("expected all checks, got %+v", got)
+	}
+
+	if got := filterByStatus(checks, "failed"); len(got) != 1 || got[0].ID != "2" {
+		t.Errorf("unexpected filtered checks %+v", got)
+	}
+}
+
+func TestLatestFinishedCheck(t *testing.T) {
+	now := time.Now()
+	checks := []types.IngestCheck{
+		{ID: "running", Status: types.CheckStatusRunning, CreatedAt: now},
+		{ID: "failed", Status: types.CheckStatusFailed, CreatedAt: now.Add(-time.Minute)},
+		{ID: "ok", Status: types.CheckStatusOK, CreatedAt: now.Add(-time.Hour)},
+	}
+
+	got, ok := latestFinishedCheck(checks)
+	if !ok || got.ID != "failed" {
+		t.Errorf("want failed check, got %+v", got)
+	}
+
+	if _, ok := latestFinishedCheck(checks[:1]); ok {
+		t.Error("expected no finished check")
+	}
+}
@@ -0,0 +1,111 @@
+package ingestcheck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/calyptia/api/types"
+)
+
+// ingestCheckTemplate is an output config section sending sample records
+// to a pipeline source, so checking a newly created pipeline does not
+// require crafting the config section by hand.
+type ingestCheckTemplate struct {
+	Description string
+	// Port the pipeline source listens on by default.
+	Port uint
+	// Retries before the check is considered failed.
+	Retries    uint
+	Properties func(host string, port uint) types.Pairs
+}
+
+var ingestCheckTemplates = map[string]ingestCheckTemplate{
+	"syslog": {
+		Description: "RFC 5424 messages over TCP to a syslog input",
+		Port:        5140,
+		Retries:     3,
+		Properties: func(host string, port uint) types.Pairs {
+			return types.Pairs{
+				{Key: "name", Value: "syslog"},
+				{Key: "host", Value: host},
+				{Key: "port", Value: port},
+				{Key: "mode", Value: "tcp"},
+				{Key: "syslog_format", Value: "rfc5424"},
+				{Key: "syslog_hostname_key", Value: "hostname"},
+				{Key: "syslog_appname_key", Value: "appname"},
+				{Key: "syslog_message_key", Value: "message"},
+			}
+		},
+	},
+	"http": {
+		Description: "JSON records to an http input",
+		Port:        9880,
+		Retries:     3,
+		Properties: func(host string, port uint) types.Pairs {
+			return types.Pairs{
+				{Key: "name", Value: "http"},
+				{Key: "host", Value: host},
+				{Key: "port", Value: port},
+				{Key: "uri", Value: "/"},
+				{Key: "format", Value: "json"},
+				{Key: "json_date_key", Value: "timestamp"},
+			}
+		},
+	},
+	"otlp": {
+		Description: "OpenTelemetry logs over HTTP to an opentelemetry input",
+		Port:        4318,
+		Retries:     3,
+		Properties: func(host string, port uint) types.Pairs {
+			return types.Pairs{
+				{Key: "name", Value: "opentelemetry"},
+				{Key: "host", Value: host},
+				{Key: "port", Value: port},
+				{Key: "logs_uri", Value: "/v1/logs"},
+				{Key: "log_response_payload", Value: true},
+			}
+		},
+	},
+	"kubernetes-logs": {
+		Description: "Container log records tagged as kube.* to a forward input",
+		Port:        24224,
+		Retries:     3,
+		Properties: func(host string, port uint) types.Pairs {
+			return types.Pairs{
+				{Key: "name", Value: "forward"},
+				{Key: "host", Value: host},
+				{Key: "port", Value: port},
+				{Key: "tag", Value: "kube.var.log.containers.ingest-check_default_ingest-check"},
+			}
+		},
+	},
+}
+
+// lookupIngestCheckTemplate returns the template with the given name.
+func lookupIngestCheckTemplate(name string) (ingestCheckTemplate, error) {
+	t, ok := ingestCheckTemplates[name]
+	if !ok {
+		return t, fmt.Errorf("unknown ingest check template %q, options: %s", name, strings.Join(ingestCheckTemplateNames(), ", "))
+	}
+	return t, nil
+}
+
+func ingestCheckTemplateNames() []string {
+	out := make([]string, 0, len(ingestCheckTemplates))
+	for name := range ingestCheckTemplates {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// describeIngestCheckTemplates lists the templates for the command help.
+func describeIngestCheckTemplates() string {
+	var sb strings.Builder
+	for _, name := range ingestCheckTemplateNames() {
+		t := ingestCheckTemplates[name]
+		fmt.Fprintf(&sb, "  %-16s %s, port %d by default\n", name, t.Description, t.Port)
+	}
+	return sb.String()
+}
@@ -0,0 +1,27 @@
+package ingestcheck
+
+import "testing"
+
+func Test_ingestCheckTemplates(t *testing.T) {
+	for _, name := range []string{"syslog", "http", "otlp", "kubernetes-logs"} {
+		tmpl, err := lookupIngestCheckTemplate(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		props := tmpl.Properties("pipeline.default.svc", tmpl.Port).AsProperties()
+		if v, ok := props.Get("name"); !ok || v == "" {
+			t.Errorf("%s: expected plugin name, got %v", name, v)
+		}
+		if v, _ := props.Get("host"); v != "pipeline.default.svc" {
+			t.Errorf("%s: expected host to be set, got %v", name, v)
+		}
+		if v, _ := props.Get("port"); v != tmpl.Port {
+			t.Errorf("%s: expected port %d, got %v", name, tmpl.Port, v)
+		}
+	}
+
+	if _, err := lookupIngestCheckTemplate("kafka"); err == nil {
+		t.Error("expected unknown template to fail")
+	}
+}
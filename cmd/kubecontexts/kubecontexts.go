@@ -0,0 +1,178 @@
+// Package kubecontexts runs commands against several kubeconfig
+// contexts concurrently, printing a result per cluster.
+package kubecontexts
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/calyptia/cli/k8s"
+)
+
+// maxConcurrentClusters bounds the number of clusters operated on at once.
+const maxConcurrentClusters = 10
+
+// Flags select the kubeconfig contexts to operate on
+// when targeting more than one cluster.
+type Flags struct {
+	AllContexts     bool
+	ContextSelector string
+}
+
+// BindFlags adds the --all-contexts and --context-selector flags.
+func BindFlags(cmd *cobra.Command, f *Flags) {
+	fs := cmd.Flags()
+	fs.BoolVar(&f.AllContexts, "all-contexts", false, "Run against every context in the kubeconfig file concurrently. Also --kube-context accepts a comma separated list of contexts")
+	fs.StringVar(&f.ContextSelector, "context-selector", "", "Glob pattern to filter the contexts used with --all-contexts, ie: prod-*")
+}
+
+// Contexts returns the kubeconfig contexts to operate on, or nil
+// when a single cluster is targeted.
+func (f Flags) Contexts(loadingRules *clientcmd.ClientConfigLoadingRules, configOverrides *clientcmd.ConfigOverrides) ([]string, error) {
+	if !f.AllContexts {
+		if f.ContextSelector != "" {
+			return nil, fmt.Errorf("--context-selector requires --all-contexts")
+		}
+
+		if !strings.Contains(configOverrides.CurrentContext, ",") {
+			return nil, nil
+		}
+
+		var out []string
+		for _, c := range strings.Split(configOverrides.CurrentContext, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				out = append(out, c)
+			}
+		}
+		return out, nil
+	}
+
+	rawConfig, err := loadingRules.Load()
+	if err != nil {
+		return nil, fmt.Errorf("could not load kubeconfig: %w", err)
+	}
+
+	var out []string
+	for name := range rawConfig.Contexts {
+		if f.ContextSelector != "" {
+			ok, err := path.Match(f.ContextSelector, name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid context selector %q: %w", f.ContextSelector, err)
+			}
+			if !ok {
+				continue
+			}
+		}
+		out = append(out, name)
+	}
+
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no kubeconfig contexts matched")
+	}
+
+	sort.Strings(out)
+	return out, nil
+}
+
+// ClientForContext returns a client for the given kubeconfig context
+// along with the namespace to use within it.
+func ClientForContext(loadingRules *clientcmd.ClientConfigLoadingRules, configOverrides *clientcmd.ConfigOverrides, kubeContext string) (*k8s.Client, string, error) {
+	overrides := *configOverrides
+	overrides.CurrentContext = kubeContext
+
+	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &overrides)
+	kubeClientConfig, err := kubeConfig.ClientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+
+	namespace, err := k8s.ResolveNamespace(kubeConfig)
+	if err != nil {
+		return nil, "", err
+	}
+
+	clientSet, err := kubernetes.NewForConfig(kubeClientConfig)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &k8s.Client{
+		Interface: clientSet,
+		Namespace: namespace,
+		Config:    kubeClientConfig,
+	}, namespace, nil
+}
+
+// Result is the outcome of running an operation against a single cluster.
+type Result struct {
+	Context string `json:"context" yaml:"context"`
+	Result  string `json:"result,omitempty" yaml:"result,omitempty"`
+	Error   string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Run runs fn against each context concurrently. Results are
+// returned in the same order as the given contexts.
+func Run(ctx context.Context, contexts []string, fn func(ctx context.Context, kubeContext string) (string, error)) []Result {
+	results := make([]Result, len(contexts))
+	sem := make(chan struct{}, maxConcurrentClusters)
+
+	var wg sync.WaitGroup
+	for i, kubeContext := range contexts {
+		wg.Add(1)
+		go func(i int, kubeContext string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i].Context = kubeContext
+			res, err := fn(ctx, kubeContext)
+			if err != nil {
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Result = res
+		}(i, kubeContext)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// RenderResults prints the result of each cluster and returns
+// an error when any of them failed.
+func RenderResults(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "CONTEXT\tRESULT\tERROR")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Context, r.Result, r.Error)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	return ResultsErr(results)
+}
+
+// ResultsErr returns an error when the operation failed on any cluster.
+func ResultsErr(results []Result) error {
+	var failed int
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+	if failed != 0 {
+		return fmt.Errorf("failed on %d of %d clusters", failed, len(results))
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package kubecontexts
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestFlags_Contexts(t *testing.T) {
+	kubeconfig := clientcmdapi.NewConfig()
+	for _, name := range []string{"prod-eu", "prod-us", "staging"} {
+		kubeconfig.Contexts[name] = clientcmdapi.NewContext()
+	}
+
+	path := t.TempDir() + "/config"
+	if err := clientcmd.WriteToFile(*kubeconfig, path); err != nil {
+		t.Fatal(err)
+	}
+	loadingRules := &clientcmd.ClientConfigLoadingRules{ExplicitPath: path}
+
+	tt := []struct {
+		name     string
+		flags    Flags
+		override string
+		want     []string
+	}{
+		{name: "single", override: "staging"},
+		{name: "list", override: "prod-eu, staging", want: []string{"prod-eu", "staging"}},
+		{name: "all", flags: Flags{AllContexts: true}, want: []string{"prod-eu", "prod-us", "staging"}},
+		{name: "selector", flags: Flags{AllContexts: true, ContextSelector: "prod-*"}, want: []string{"prod-eu", "prod-us"}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.flags.Contexts(loadingRules, &clientcmd.ConfigOverrides{CurrentContext: tc.override})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	results := Run(context.TODO(), []string{"a", "b"}, func(ctx context.Context, kubeContext string) (string, error) {
+		if kubeContext == "b" {
+			return "", errors.New("unreachable")
+		}
+		return "ok", nil
+	})
+
+	want := []Result{
+		{Context: "a", Result: "ok"},
+		{Context: "b", Error: "unreachable"},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Fatalf("want %+v, got %+v", want, results)
+	}
+
+	if err := ResultsErr(results); err == nil {
+		t.Fatal("expected error")
+	}
+}
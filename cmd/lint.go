@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/calyptia/cli/cmd/pipeline"
+)
+
+func newCmdLint() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Check configuration files against best practices",
+	}
+
+	cmd.AddCommand(
+		pipeline.NewCmdLintPipelineConfig(),
+	)
+
+	return cmd
+}
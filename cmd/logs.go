@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/calyptia/cli/cmd/pipeline"
+	cfg "github.com/calyptia/cli/config"
+)
+
+func newCmdLogs(config *cfg.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "logs",
+		Short: "Print the logs of pipelines",
+	}
+
+	cmd.AddCommand(
+		pipeline.NewCmdLogsPipeline(config),
+	)
+
+	return cmd
+}
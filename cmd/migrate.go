@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/calyptia/cli/cmd/fleet"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/features"
+)
+
+func newCmdMigrate(config *cfg.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Migrate resources between deployment models",
+	}
+
+	cmd.AddCommand(features.Require(features.Fleets,
+		fleet.NewCmdMigrateAgents(config),
+	)...)
+
+	return cmd
+}
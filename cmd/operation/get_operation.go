@@ -0,0 +1,131 @@
+package operation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/notify"
+	"github.com/calyptia/cli/operation"
+)
+
+const (
+	waitInterval       = time.Second
+	defaultWaitTimeout = 30 * time.Minute
+)
+
+func NewCmdGetOperation() *cobra.Command {
+	var wait, showLogs bool
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "operation ID",
+		Short: "Get an operation submitted with --async",
+		Long: "Get the status of an operation submitted with --async.\n" +
+			"With --wait it blocks until the operation finishes and fails if the operation did, " +
+			"so CI steps can submit several operations and wait for them later.\n" +
+			"With --notify a message is posted when the waited operation fails.",
+		Example: "  id=$(calyptia create pipeline --core-instance my-core --config-file big.yaml --async)\n" +
+			"  calyptia get operation $id --wait --timeout 10m --logs --notify webhook://example.com/alerts",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id := args[0]
+
+			store, err := operation.DefaultStore()
+			if err != nil {
+				return err
+			}
+
+			notifier, err := notify.FromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			op, err := store.Get(id)
+			if err != nil {
+				return err
+			}
+
+			if wait && !op.Done() {
+				ctx := cmd.Context()
+				if timeout > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, timeout)
+					defer cancel()
+				}
+
+				op, err = store.Wait(ctx, id, waitInterval)
+				if err != nil {
+					return err
+				}
+			}
+
+			if showLogs {
+				if err := copyLogs(cmd.ErrOrStderr(), op.LogFile); err != nil {
+					return err
+				}
+			}
+
+			if err := renderOperation(cmd, op); err != nil {
+				return err
+			}
+
+			if wait && op.Status == operation.StatusFailed {
+				if err := notifier.Observe(cmd.Context(), "operation "+op.ID+" ("+op.Command+")", true, op.Error); err != nil {
+					cmd.PrintErrf("Warning: %v\n", err)
+				}
+				return fmt.Errorf("operation %s failed: %s", op.ID, op.Error)
+			}
+
+			return nil
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.BoolVar(&wait, "wait", false, "Wait for the operation to finish, failing if it did")
+	fs.DurationVar(&timeout, "timeout", defaultWaitTimeout, "Maximum time to wait for the operation. 0 means no limit")
+	fs.BoolVar(&showLogs, "logs", false, "Print the operation output to stderr")
+	notify.BindFlag(cmd)
+	formatters.BindFormatFlags(cmd)
+
+	return cmd
+}
+
+func renderOperation(cmd *cobra.Command, op operation.Operation) error {
+	fs := cmd.Flags()
+	outputFormat := formatters.OutputFormatFromFlags(fs)
+	if fn, ok := formatters.ShouldApplyTemplating(outputFormat); ok {
+		return fn(cmd.OutOrStdout(), formatters.TemplateFromFlags(fs), op)
+	}
+
+	switch outputFormat {
+	case formatters.OutputFormatJSON:
+		return json.NewEncoder(cmd.OutOrStdout()).Encode(op)
+	case formatters.OutputFormatYAML:
+		return yaml.NewEncoder(cmd.OutOrStdout()).Encode(op)
+	default:
+		tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 1, ' ', 0)
+		fmt.Fprintln(tw, "ID\tSTATUS\tCOMMAND\tAGE\tERROR")
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", op.ID, op.Status, op.Command, formatters.FmtTime(op.CreatedAt), op.Error)
+		return tw.Flush()
+	}
+}
+
+func copyLogs(w io.Writer, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("could not open operation logs: %w", err)
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
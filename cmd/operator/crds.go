@@ -0,0 +1,224 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/calyptia/cli/cmd/utils"
+	"github.com/calyptia/cli/confirm"
+	"github.com/calyptia/cli/download"
+	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/interrupt"
+	"github.com/calyptia/cli/k8s"
+)
+
+func NewCmdGetCRDs() *cobra.Command {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	configOverrides := &clientcmd.ConfigOverrides{}
+
+	cmd := &cobra.Command{
+		Use:   "crds",
+		Short: "Display the core operator custom resource definitions installed in the cluster",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			k, err := newCRDsClient(loadingRules, configOverrides)
+			if err != nil {
+				return err
+			}
+
+			crds, err := k.CRDs(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("could not fetch core operator crds: %w", err)
+			}
+
+			fs := cmd.Flags()
+			outputFormat := formatters.OutputFormatFromFlags(fs)
+			if fn, ok := formatters.ShouldApplyTemplating(outputFormat); ok {
+				return fn(cmd.OutOrStdout(), formatters.TemplateFromFlags(fs), crds)
+			}
+
+			switch outputFormat {
+			case formatters.OutputFormatJSON:
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(crds)
+			case formatters.OutputFormatYAML:
+				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(crds)
+			default:
+				return renderCRDs(cmd.OutOrStdout(), crds)
+			}
+		},
+	}
+
+	fs := cmd.Flags()
+	formatters.BindFormatFlags(cmd)
+	clientcmd.BindOverrideFlags(configOverrides, fs, clientcmd.RecommendedConfigOverrideFlags("kube-"))
+
+	return cmd
+}
+
+func NewCmdUpdateCRDs() *cobra.Command {
+	var manifestPath string
+	var dryRun, force, confirmed bool
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	configOverrides := &clientcmd.ConfigOverrides{}
+
+	cmd := &cobra.Command{
+		Use:   "crds [CRD...]",
+		Short: "Upgrade the core operator custom resource definitions",
+		Long: "Upgrade the core operator custom resource definitions independently of the\n" +
+			"full operator manifest. Only the given CRDs are upgraded, or all of them if none given.\n" +
+			"Upgrades that would drop a stored or served version are refused unless --force is given.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			checksum, err := download.ChecksumFromFlags(cmd.Flags(), manifestPath)
+			if err != nil {
+				return err
+			}
+
+			manifest, err := crdsManifest(cmd.Context(), manifestPath, checksum)
+			if err != nil {
+				return err
+			}
+
+			k, err := newCRDsClient(loadingRules, configOverrides)
+			if err != nil {
+				return err
+			}
+
+			ctx := cmd.Context()
+			upgrades, err := k.CheckCRDUpgrades(ctx, manifest, args...)
+			if err != nil {
+				return fmt.Errorf("could not check core operator crds: %w", err)
+			}
+
+			renderCRDUpgrades(cmd.OutOrStdout(), upgrades)
+
+			var unsafe int
+			for _, u := range upgrades {
+				if !u.Safe() {
+					unsafe++
+				}
+			}
+
+			if unsafe != 0 && !force {
+				return fmt.Errorf("%d crd upgrades are not conversion-safe, pass --force to apply them anyway", unsafe)
+			}
+
+			if dryRun {
+				return nil
+			}
+
+			if !confirmed {
+				cmd.Printf("Upgrade %d crds? (y/N) ", len(upgrades))
+				ok, err := confirm.Read(cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+
+				if !ok {
+					cmd.Println("Aborted")
+					return nil
+				}
+			}
+
+			done := interrupt.Step("upgrade core operator crds")
+			if err := k.UpgradeCRDs(ctx, manifest, upgrades, force); err != nil {
+				return fmt.Errorf("could not upgrade core operator crds: %w", err)
+			}
+			done()
+
+			cmd.Printf("Upgraded %d crds\n", len(upgrades))
+			return nil
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.StringVar(&manifestPath, "manifest", "", "Core operator manifest path or URL to take the CRDs from. Interrupted downloads resume on the next run. Defaults to the one bundled for core operator "+utils.DefaultCoreOperatorDockerImageTag)
+	fs.BoolVar(&dryRun, "dry-run", false, "Only run the conversion-safety checks")
+	fs.BoolVar(&force, "force", false, "Apply CRD upgrades that are not conversion-safe")
+	fs.BoolVarP(&confirmed, "yes", "y", false, "Confirm the upgrade")
+	download.BindChecksumFlags(cmd)
+	clientcmd.BindOverrideFlags(configOverrides, fs, clientcmd.RecommendedConfigOverrideFlags("kube-"))
+
+	return cmd
+}
+
+func newCRDsClient(loadingRules *clientcmd.ClientConfigLoadingRules, configOverrides *clientcmd.ConfigOverrides) (*k8s.Client, error) {
+	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+	kubeClientConfig, err := kubeConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	clientSet, err := kubernetes.NewForConfig(kubeClientConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &k8s.Client{
+		Interface: clientSet,
+		Config:    kubeClientConfig,
+	}, nil
+}
+
+// crdsManifest returns the manifest from the given local path or URL,
+// or the bundled one if none given.
+func crdsManifest(ctx context.Context, location, checksum string) (string, error) {
+	if location != "" {
+		b, err := download.Fetch(ctx, location, download.Options{SHA256: checksum})
+		if err != nil {
+			return "", fmt.Errorf("could not read manifest: %w", err)
+		}
+		return string(b), nil
+	}
+
+	b, err := f.ReadFile(manifestFile)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func renderCRDs(w io.Writer, crds []k8s.CRD) error {
+	if len(crds) == 0 {
+		fmt.Fprintln(w, "No core operator crds installed.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tKIND\tSTORAGE\tSERVED\tSTORED\tESTABLISHED\tAGE")
+	for _, c := range crds {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%v\t%s\n", c.Name, c.Kind, c.StorageVersion, strings.Join(c.ServedVersions, ","), strings.Join(c.StoredVersions, ","), c.Established, formatters.FmtTime(c.CreatedAt))
+	}
+	return tw.Flush()
+}
+
+func renderCRDUpgrades(w io.Writer, upgrades []k8s.CRDUpgrade) {
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tCURRENT\tDESIRED\tSAFE")
+	for _, u := range upgrades {
+		current := "not installed"
+		if u.Current != nil {
+			current = strings.Join(u.Current.ServedVersions, ",") + " (storage " + u.Current.StorageVersion + ")"
+		}
+		desired := strings.Join(u.Desired.ServedVersions, ",") + " (storage " + u.Desired.StorageVersion + ")"
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%v\n", u.Name, current, desired, u.Safe())
+	}
+	tw.Flush()
+
+	for _, u := range upgrades {
+		for _, p := range u.Problems {
+			fmt.Fprintf(w, "ERROR: %s: %s\n", u.Name, p)
+		}
+		for _, warn := range u.Warnings {
+			fmt.Fprintf(w, "WARNING: %s: %s\n", u.Name, warn)
+		}
+	}
+}
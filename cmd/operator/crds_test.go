@@ -0,0 +1,22 @@
+package operator
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCRDsManifest(t *testing.T) {
+	manifest, err := crdsManifest(context.Background(), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(manifest, "kind: CustomResourceDefinition") {
+		t.Error("expected the bundled manifest to contain the core operator crds")
+	}
+
+	if _, err := crdsManifest(context.Background(), "testdata/does-not-exist.yaml", ""); err == nil {
+		t.Error("expected missing manifest file to fail")
+	}
+}
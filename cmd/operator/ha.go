@@ -0,0 +1,139 @@
+package operator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	managerContainerName = "manager"
+	leaderElectFlag      = "--leader-elect"
+)
+
+// leaderElectionRBAC grants the manager access to the leases used for
+// leader election. It is only required when running more than one replica.
+const leaderElectionRBAC = `apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  labels:
+    calyptia.core: core-operator
+  name: calyptia-core-leader-election-role
+  namespace: calyptia-core
+rules:
+- apiGroups:
+  - coordination.k8s.io
+  resources:
+  - leases
+  verbs:
+  - get
+  - list
+  - watch
+  - create
+  - update
+  - patch
+  - delete
+- apiGroups:
+  - ""
+  resources:
+  - events
+  verbs:
+  - create
+  - patch
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  labels:
+    calyptia.core: core-operator
+  name: calyptia-core-leader-election-rolebinding
+  namespace: calyptia-core
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: calyptia-core-leader-election-role
+subjects:
+- kind: ServiceAccount
+  name: calyptia-core-controller-manager
+  namespace: calyptia-core
+`
+
+// enableHA patches the manager deployment found in the manifest to run the
+// given number of replicas with leader election enabled, spread across nodes.
+// The leader election RBAC is inserted right before the deployment so it
+// remains the last document of the manifest.
+func enableHA(file string, replicas int) (string, error) {
+	if replicas < 2 {
+		return "", fmt.Errorf("high availability requires at least 2 replicas, got %d", replicas)
+	}
+
+	docs := strings.Split(file, "---\n")
+	for i, doc := range docs {
+		var meta metav1.TypeMeta
+		if err := yaml.Unmarshal([]byte(doc), &meta); err != nil {
+			return "", err
+		}
+		if meta.Kind != "Deployment" {
+			continue
+		}
+
+		var deployment appsv1.Deployment
+		if err := yaml.Unmarshal([]byte(doc), &deployment); err != nil {
+			return "", err
+		}
+
+		if err := patchDeploymentHA(&deployment, int32(replicas)); err != nil {
+			return "", err
+		}
+
+		patched, err := yaml.Marshal(deployment)
+		if err != nil {
+			return "", err
+		}
+
+		out := append([]string{}, docs[:i]...)
+		out = append(out, leaderElectionRBAC, string(patched))
+		out = append(out, docs[i+1:]...)
+		return strings.Join(out, "---\n"), nil
+	}
+
+	return "", errors.New("could not find deployment in manifest")
+}
+
+func patchDeploymentHA(deployment *appsv1.Deployment, replicas int32) error {
+	deployment.Spec.Replicas = &replicas
+
+	podSpec := &deployment.Spec.Template.Spec
+	if podSpec.Affinity == nil {
+		podSpec.Affinity = &apiv1.Affinity{}
+	}
+	podSpec.Affinity.PodAntiAffinity = &apiv1.PodAntiAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []apiv1.WeightedPodAffinityTerm{{
+			Weight: 100,
+			PodAffinityTerm: apiv1.PodAffinityTerm{
+				LabelSelector: deployment.Spec.Selector,
+				TopologyKey:   apiv1.LabelHostname,
+			},
+		}},
+	}
+
+	for i, container := range podSpec.Containers {
+		if container.Name != managerContainerName {
+			continue
+		}
+		for _, arg := range container.Args {
+			if arg == leaderElectFlag {
+				return nil
+			}
+		}
+		podSpec.Containers[i].Args = append(podSpec.Containers[i].Args, leaderElectFlag)
+		return nil
+	}
+
+	return fmt.Errorf("could not find %q container in deployment", managerContainerName)
+}
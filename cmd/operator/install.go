@@ -18,21 +18,31 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/calyptia/cli/cmd/utils"
+	"github.com/calyptia/cli/interrupt"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/pflag"
-	apiv1 "k8s.io/api/core/v1"
-	"k8s.io/cli-runtime/pkg/genericclioptions"
-	"k8s.io/component-base/logs"
-	kubectl "k8s.io/kubectl/pkg/cmd"
 
+	"github.com/calyptia/cli/cmd/kubecontexts"
 	"github.com/calyptia/cli/k8s"
+	"github.com/calyptia/cli/kustomize"
+	"github.com/calyptia/cli/progress"
 )
 
 //go:embed manifest.yaml
 var f embed.FS
 
-const manifestFile = "manifest.yaml"
+const (
+	manifestFile          = "manifest.yaml"
+	managerServiceAccount = "calyptia-core-controller-manager"
+	managerDeploymentName = "calyptia-core-controller-manager"
+	outputKustomize       = "kustomize"
+
+	dryRunClient = "client"
+	dryRunServer = "server"
+
+	applyManifestStep = "apply operator manifest"
+	waitManagerStep   = "wait for core operator manager"
+)
 
 func NewCmdInstall() *cobra.Command {
 	var (
@@ -41,9 +51,25 @@ func NewCmdInstall() *cobra.Command {
 		isNonInteractive    bool
 		waitReady           bool
 		waitTimeout         time.Duration
+		verbose             bool
 		confirmed           bool
+		ha                  bool
+		haReplicas          int
+		serviceAccount      string
+		dryRun              string
+		outputFile          string
+		output              string
+		outputDir           string
+		overlays            []string
+		profile             string
+		resources           k8s.ResourceOptions
+		imagePullSecrets    []string
+		registryCreds       string
+		namespaceCreate     bool
 	)
 
+	var multiContext kubecontexts.Flags
+
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 	configOverrides := &clientcmd.ConfigOverrides{}
 
@@ -52,27 +78,100 @@ func NewCmdInstall() *cobra.Command {
 		Aliases: []string{"opr"},
 		Short:   "Setup a new core operator instance",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			var namespace string
-
-			kubeNamespaceFlag := cmd.Flag("kube-namespace")
-			if kubeNamespaceFlag != nil {
-				namespace = kubeNamespaceFlag.Value.String()
+			if !ha {
+				haReplicas = 0
 			}
-
-			if namespace == "" {
-				namespace = apiv1.NamespaceDefault
+			if err := validProfile(profile); err != nil {
+				return err
 			}
-
-			n, err := k8s.GetCurrentContextNamespace()
+			tracker, err := progress.FromFlags(cmd)
 			if err != nil {
-				if errors.Is(err, k8s.ErrNoContext) {
-					cmd.Printf("No context is currently set. Using default namespace.\n")
-				} else {
+				return err
+			}
+			if ha && profile == profileEdge {
+				return errors.New("--profile edge runs a single replica and cannot be combined with --ha")
+			}
+			opts := manifestOptions{
+				haReplicas:       haReplicas,
+				serviceAccount:   serviceAccount,
+				profile:          profile,
+				resources:        resources,
+				imagePullSecrets: imagePullSecrets,
+			}
+			if registryCreds != "" {
+				creds, err := k8s.ParseRegistryCredentials(registryCreds)
+				if err != nil {
 					return err
 				}
+				opts.registryCreds = &creds
+			}
+
+			contexts, err := multiContext.Contexts(loadingRules, configOverrides)
+			if err != nil {
+				return err
 			}
-			if n != "" {
-				namespace = n
+
+			if dryRun != "" && dryRun != dryRunClient && dryRun != dryRunServer {
+				return fmt.Errorf("invalid dry run mode %q, options: %s, %s", dryRun, dryRunClient, dryRunServer)
+			}
+
+			if outputFile != "" && dryRun == "" {
+				return errors.New("--output-file requires --dry-run")
+			}
+
+			if output != "" && output != outputKustomize {
+				return fmt.Errorf("invalid output format %q, options: %s", output, outputKustomize)
+			}
+
+			if output == outputKustomize && outputDir == "" {
+				return errors.New("--output-dir is required with --output kustomize")
+			}
+
+			if contexts != nil {
+				if dryRun != "" || output != "" {
+					return errors.New("--dry-run is not supported when targeting multiple clusters")
+				}
+				if !confirmed {
+					return errors.New("--yes is required when targeting multiple clusters")
+				}
+
+				results := kubecontexts.Run(cmd.Context(), contexts, func(ctx context.Context, kubeContext string) (string, error) {
+					k, namespace, err := kubecontexts.ClientForContext(loadingRules, configOverrides, kubeContext)
+					if err != nil {
+						return "", err
+					}
+
+					if serviceAccount != "" {
+						if _, err := k.ValidateServiceAccount(ctx, serviceAccount, true, k8s.OperatorRequiredPermissions); err != nil {
+							return "", fmt.Errorf("could not use kubernetes service account: %w", err)
+						}
+					}
+
+					createNamespace, err := namespaceToCreate(ctx, k, namespace, namespaceCreate)
+					if err != nil {
+						return "", err
+					}
+
+					manifest, err := installManifest(ctx, k, namespace, coreDockerImage, coreInstanceVersion, createNamespace, opts)
+					if err != nil {
+						return "", err
+					}
+
+					if waitReady {
+						deployment, err := extractDeployment(manifest)
+						if err != nil {
+							return "", err
+						}
+
+						if err := k.WaitReady(ctx, namespace, deployment, verbose, waitTimeout); err != nil {
+							return "", err
+						}
+						return "installed and ready", nil
+					}
+
+					return "installed", nil
+				})
+				return kubecontexts.RenderResults(cmd.OutOrStdout(), results)
 			}
 
 			kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
@@ -81,6 +180,11 @@ func NewCmdInstall() *cobra.Command {
 				return err
 			}
 
+			namespace, err := k8s.ResolveNamespace(kubeConfig)
+			if err != nil {
+				return err
+			}
+
 			clientSet, err := kubernetes.NewForConfig(kubeClientConfig)
 			if err != nil {
 				return err
@@ -89,6 +193,64 @@ func NewCmdInstall() *cobra.Command {
 				Interface: clientSet,
 				Config:    kubeClientConfig,
 			}
+
+			if output == outputKustomize {
+				createNamespace, err := namespaceToCreate(cmd.Context(), k, namespace, namespaceCreate)
+				if err != nil {
+					return err
+				}
+
+				manifest, err := buildInstallManifest(coreDockerImage, coreInstanceVersion, namespace, createNamespace, opts)
+				if err != nil {
+					return err
+				}
+
+				files, err := kustomize.Write(outputDir, namespace, []kustomize.Resource{{File: manifestFile, Content: manifest}}, overlays)
+				if err != nil {
+					return fmt.Errorf("could not generate kustomization: %w", err)
+				}
+
+				for _, f := range files {
+					cmd.PrintErrf("Saved %s\n", f)
+				}
+				return nil
+			}
+
+			if dryRun != "" {
+				createNamespace, err := namespaceToCreate(cmd.Context(), k, namespace, namespaceCreate)
+				if err != nil {
+					return err
+				}
+
+				manifest, err := buildInstallManifest(coreDockerImage, coreInstanceVersion, namespace, createNamespace, opts)
+				if err != nil {
+					return err
+				}
+
+				if dryRun == dryRunServer {
+					manifest, err = k.DryRunManifest(cmd.Context(), manifest)
+					if err != nil {
+						return fmt.Errorf("server rejected the operator manifest: %w", err)
+					}
+				}
+
+				if ha {
+					cmd.PrintErrf("High availability requires extra RBAC: a role granting access to leases (coordination.k8s.io) and events in the %q namespace, bound to the manager service account.\n", namespace)
+				}
+
+				if outputFile != "" {
+					if err := os.WriteFile(outputFile, []byte(manifest), 0o644); err != nil {
+						return fmt.Errorf("could not write manifest: %w", err)
+					}
+
+					cmd.PrintErrf("Saved %s\n", outputFile)
+					return nil
+				}
+
+				fmt.Fprint(cmd.OutOrStdout(), manifest)
+				return nil
+			}
+
 			if !confirmed {
 				isInstalled, err := k.IsOperatorInstalled(cmd.Context())
 				if isInstalled {
@@ -114,28 +276,33 @@ func NewCmdInstall() *cobra.Command {
 				}
 			}
 
-			_, err = k.GetNamespace(context.Background(), namespace)
-			if err != nil && !k8serrors.IsNotFound(err) {
+			if serviceAccount != "" {
+				k.Namespace = namespace
+				_, err := k.ValidateServiceAccount(cmd.Context(), serviceAccount, true, k8s.OperatorRequiredPermissions)
+				if err != nil {
+					return fmt.Errorf("could not use kubernetes service account: %w", err)
+				}
+			}
+
+			createNamespace, err := namespaceToCreate(cmd.Context(), k, namespace, namespaceCreate)
+			if err != nil {
 				return err
 			}
 
-			manifest, err := installManifest(namespace, coreDockerImage, coreInstanceVersion, k8serrors.IsNotFound(err))
+			var manifest string
+			err = tracker.Run(cmd.Context(), applyManifestStep, func(ctx context.Context) error {
+				var err error
+				manifest, err = installManifest(ctx, k, namespace, coreDockerImage, coreInstanceVersion, createNamespace, opts)
+				return err
+			})
 			if err != nil {
 				return err
 			}
 
 			if waitReady {
-				deployment, err := extractDeployment(manifest)
-				if err != nil {
-					return err
-				}
-				start := time.Now()
-				fmt.Printf("Waiting for core operator manager to be ready...\n")
-				err = k.WaitReady(context.Background(), namespace, deployment, false, waitTimeout)
-				if err != nil {
+				if err := waitManager(cmd.Context(), tracker, k, namespace, manifest, verbose, waitTimeout); err != nil {
 					return err
 				}
-				fmt.Printf("Core operator manager is ready. Took %s\n", time.Since(start))
 			}
 
 			cmd.Printf("Core operator manager successfully installed.\n")
@@ -148,32 +315,87 @@ func NewCmdInstall() *cobra.Command {
 	fs.BoolVarP(&confirmed, "yes", "y", isNonInteractive, "Confirm install")
 	fs.BoolVar(&waitReady, "wait", false, "Wait for the core instance to be ready before returning")
 	fs.DurationVar(&waitTimeout, "timeout", time.Second*30, "Wait timeout")
+	fs.BoolVar(&verbose, "verbose", false, "Stream the reason the core operator manager pods are not ready while waiting")
 	fs.StringVar(&coreInstanceVersion, "version", "", "Core instance version")
 	fs.StringVar(&coreDockerImage, "image", utils.DefaultCoreOperatorDockerImage, "Calyptia core manager docker image to use (fully composed docker image).")
+	fs.BoolVar(&ha, "ha", false, "Run the core operator manager in high availability mode with leader election")
+	fs.IntVar(&haReplicas, "ha-replicas", 2, "Number of core operator manager replicas when running in high availability mode")
+	fs.StringVar(&profile, "profile", "", fmt.Sprintf("Tune the manifest for the target environment, options: %s. The edge profile runs a single replica with reduced resources and without metrics nor webhooks, for k3s/k0s edge clusters", strings.Join(installProfiles, ", ")))
+	k8s.BindResourceFlags(fs, &resources, "the core operator manager container")
+	fs.StringArrayVar(&imagePullSecrets, "image-pull-secret", nil, "Existing kubernetes secret to pull the core operator image with from a private registry. Can be given multiple times")
+	fs.StringVar(&registryCreds, "registry-creds", "", "Create a docker registry secret to pull the core operator image with, in the form of user:pass@registry")
+	fs.StringVar(&serviceAccount, "service-account", "", "Use an existing kubernetes service account for the core operator manager instead of creating one along with its cluster role bindings")
+	fs.StringVar(&dryRun, "dry-run", "", fmt.Sprintf("Print the fully rendered manifest instead of applying it, options: %s, %s. With %s the manifest is validated by the API server and printed with its defaults, without persisting anything", dryRunClient, dryRunServer, dryRunServer))
+	fs.Lookup("dry-run").NoOptDefVal = dryRunClient
+	fs.StringVar(&outputFile, "output-file", "", "Write the --dry-run manifest to the given file instead of stdout")
+	fs.StringVarP(&output, "output", "o", "", fmt.Sprintf("Generate the manifest instead of applying it, options: %s", outputKustomize))
+	fs.StringVar(&outputDir, "output-dir", "", "Directory to generate the kustomize base and overlays into")
+	fs.StringSliceVar(&overlays, "overlays", kustomize.DefaultOverlays, "Environments to generate a kustomize overlay for")
 	_ = cmd.Flags().MarkHidden("image")
+	progress.BindFlag(cmd)
+	kubecontexts.BindFlags(cmd, &multiContext)
 	clientcmd.BindOverrideFlags(configOverrides, fs, clientcmd.RecommendedConfigOverrideFlags("kube-"))
+	utils.BindNamespaceCreateFlag(fs, &namespaceCreate)
 
 	return cmd
 }
 
-// extractDeployment extracts the name of the deployment from the yaml file
-// provided. It assumes that the last yaml document is the deployment.
+// namespaceToCreate reports whether the namespace does not exist
+// and has to be created, failing when its creation is disabled.
+func namespaceToCreate(ctx context.Context, k *k8s.Client, namespace string, create bool) (bool, error) {
+	_, err := k.GetNamespace(ctx, namespace)
+	if err == nil {
+		return false, nil
+	}
+
+	if !k8serrors.IsNotFound(err) {
+		return false, err
+	}
+
+	if !create {
+		return false, fmt.Errorf("namespace %q does not exist", namespace)
+	}
+
+	return true, nil
+}
+
+// waitManager waits for the core operator manager deployment
+// of the applied manifest to be ready. When verbose, the reason
+// its pods are not ready is reported along the way.
+func waitManager(ctx context.Context, tracker *progress.Tracker, k *k8s.Client, namespace, manifest string, verbose bool, timeout time.Duration) error {
+	deployment, err := extractDeployment(manifest)
+	if err != nil {
+		return err
+	}
+
+	done := interrupt.Step(waitManagerStep)
+	k.OnRolloutProgress = tracker.RolloutReporter(waitManagerStep)
+	k.OnPodFailure = tracker.PodFailureReporter(waitManagerStep)
+	err = tracker.Run(ctx, waitManagerStep, func(ctx context.Context) error {
+		return k.WaitReady(ctx, namespace, deployment, verbose, timeout)
+	})
+	if err != nil {
+		return err
+	}
+
+	done()
+	return nil
+}
+
+// extractDeployment extracts the name of the deployment from the yaml
+// manifest provided. It assumes that the last yaml document is the deployment.
 // This is a temporary solution until we have a better way to do this.
 // Possibly we will strip it out when we change the way we install the
 // operator.
-func extractDeployment(yml string) (string, error) {
-	file, err := os.ReadFile(yml)
-	if err != nil {
-		return "", err
-	}
-	splitFile := strings.Split(string(file), "---\n")
+func extractDeployment(manifest string) (string, error) {
+	splitFile := strings.Split(manifest, "---\n")
 	deployment := splitFile[len(splitFile)-1]
 	var deploymentConfig struct {
 		Metadata struct {
 			Name string `yaml:"name"`
 		}
 	}
-	err = yaml.Unmarshal([]byte(deployment), &deploymentConfig)
+	err := yaml.Unmarshal([]byte(deployment), &deploymentConfig)
 	if err != nil {
 		return "", err
 	}
@@ -181,36 +403,66 @@ func extractDeployment(yml string) (string, error) {
 	return deployName, nil
 }
 
-func prepareInstallManifest(coreDockerImage, coreInstanceVersion, namespace string, createNamespace bool) (string, error) {
+// manifestOptions customizes the install manifest.
+type manifestOptions struct {
+	// haReplicas greater than zero enables high availability mode.
+	haReplicas int
+	// serviceAccount, when set, replaces the manager service account
+	// and its cluster role bindings.
+	serviceAccount string
+	// profile, when set, tunes the manifest for the target environment.
+	profile string
+	// resources, when set, override the manager container resources,
+	// including those set by the profile.
+	resources k8s.ResourceOptions
+	// imagePullSecrets, when set, are the secrets the manager pulls its image with.
+	imagePullSecrets []string
+	// registryCreds, when set, are added as a docker registry secret
+	// the manager pulls its image with.
+	registryCreds *k8s.RegistryCredentials
+}
+
+// buildInstallManifest returns the manifest to apply.
+func buildInstallManifest(coreDockerImage, coreInstanceVersion, namespace string, createNamespace bool, opts manifestOptions) (string, error) {
 	file, err := f.ReadFile(manifestFile)
 	if err != nil {
 		return "", err
 	}
 	fullFile := string(file)
-	solveNamespace := solveNamespaceCreation(createNamespace, fullFile, namespace)
-	withNamespace := injectNamespace(solveNamespace, namespace)
-
-	withImage, err := addImage(coreDockerImage, coreInstanceVersion, withNamespace)
-	if err != nil {
-		return "", err
+	if opts.profile != "" {
+		fullFile, err = applyProfile(fullFile, opts.profile)
+		if err != nil {
+			return "", err
+		}
 	}
-
-	dir, err := os.MkdirTemp("", "calyptia-operator")
-	if err != nil {
-		return "", err
+	if opts.haReplicas > 0 {
+		fullFile, err = enableHA(fullFile, opts.haReplicas)
+		if err != nil {
+			return "", err
+		}
 	}
-
-	temp, err := os.CreateTemp(dir, "operator_*.yaml")
-	if err != nil {
-		return "", err
+	if !opts.resources.IsZero() {
+		fullFile, err = setManagerResources(fullFile, opts.resources)
+		if err != nil {
+			return "", err
+		}
 	}
-
-	_, err = temp.WriteString(withImage)
-	if err != nil {
-		return "", err
+	if len(opts.imagePullSecrets) != 0 || opts.registryCreds != nil {
+		fullFile, err = useImagePullSecrets(fullFile, opts.imagePullSecrets, opts.registryCreds)
+		if err != nil {
+			return "", err
+		}
 	}
+	if opts.serviceAccount != "" {
+		fullFile, err = useServiceAccount(fullFile, opts.serviceAccount)
+		if err != nil {
+			return "", err
+		}
+	}
+	solveNamespace := solveNamespaceCreation(createNamespace, fullFile, namespace)
+	withNamespace := injectNamespace(solveNamespace, namespace)
 
-	return temp.Name(), err
+	return addImage(coreDockerImage, coreInstanceVersion, withNamespace)
 }
 
 func solveNamespaceCreation(createNamespace bool, fullFile string, namespace string) string {
@@ -238,73 +490,59 @@ func addImage(coreDockerImage, coreInstanceVersion, file string) (string, error)
 	return file, nil
 }
 
-func injectNamespace(s string, namespace string) string {
-	if _, err := strconv.Atoi(namespace); err == nil {
-		namespace = fmt.Sprintf(`"%s"`, namespace)
-	}
-	return strings.ReplaceAll(s, "namespace: calyptia-core", fmt.Sprintf("namespace: %s", namespace))
-}
-
-func newKubectlCmd() *cobra.Command {
-	_ = pflag.CommandLine.MarkHidden("log-flush-frequency")
-	_ = pflag.CommandLine.MarkHidden("version")
-
-	args := kubectl.KubectlOptions{
-		IOStreams: genericclioptions.IOStreams{
-			In:     os.Stdin,
-			Out:    os.Stdout,
-			ErrOut: os.Stderr,
-		},
-		Arguments: os.Args,
-	}
+// useServiceAccount removes the manager service account and its cluster role
+// bindings from the manifest, making the manager run as the given
+// pre-provisioned service account instead.
+func useServiceAccount(file, name string) (string, error) {
+	var found bool
+	var out []string
+	for _, doc := range strings.Split(file, "---\n") {
+		var meta struct {
+			Kind string `yaml:"kind"`
+		}
+		if err := yaml.Unmarshal([]byte(doc), &meta); err != nil {
+			return "", err
+		}
 
-	cmd := kubectl.NewKubectlCommand(args)
-
-	cmd.Aliases = []string{"kc"}
-	cmd.Hidden = true
-	// Get handle on the original kubectl prerun so we can call it later
-	originalPreRunE := cmd.PersistentPreRunE
-	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
-		// Call parents pre-run if exists, cobra does not do this automatically
-		// See: https://github.com/spf13/cobra/issues/216
-		if parent := cmd.Parent(); parent != nil {
-			if parent.PersistentPreRun != nil {
-				parent.PersistentPreRun(parent, args)
-			}
-			if parent.PersistentPreRunE != nil {
-				err := parent.PersistentPreRunE(parent, args)
-				if err != nil {
-					return err
-				}
-			}
+		switch meta.Kind {
+		case "ServiceAccount", "ClusterRoleBinding":
+			continue
+		case "RoleBinding":
+			doc = strings.ReplaceAll(doc, "name: "+managerServiceAccount+"\n", "name: "+name+"\n")
+		case "Deployment":
+			found = strings.Contains(doc, "serviceAccountName: "+managerServiceAccount)
+			doc = strings.ReplaceAll(doc, "serviceAccountName: "+managerServiceAccount, "serviceAccountName: "+name)
 		}
-		return originalPreRunE(cmd, args)
+		out = append(out, doc)
 	}
 
-	originalRun := cmd.Run
-	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		originalRun(cmd, args)
-		return nil
+	if !found {
+		return "", errors.New("could not find service account in manifest")
 	}
 
-	logs.AddFlags(cmd.PersistentFlags())
-	return cmd
+	return strings.Join(out, "---\n"), nil
 }
 
-func installManifest(namespace, coreDockerImage, coreInstanceVersion string, createNamespace bool) (string, error) {
-	kctl := newKubectlCmd()
+func injectNamespace(s string, namespace string) string {
+	if _, err := strconv.Atoi(namespace); err == nil {
+		namespace = fmt.Sprintf(`"%s"`, namespace)
+	}
+	return strings.ReplaceAll(s, "namespace: calyptia-core", fmt.Sprintf("namespace: %s", namespace))
+}
 
-	manifest, err := prepareInstallManifest(coreDockerImage, coreInstanceVersion, namespace, createNamespace)
-	defer os.RemoveAll(manifest)
+// installManifest applies the operator manifest with the given client
+// straight from memory. The applied manifest is returned.
+func installManifest(ctx context.Context, k *k8s.Client, namespace, coreDockerImage, coreInstanceVersion string, createNamespace bool, opts manifestOptions) (string, error) {
+	manifest, err := buildInstallManifest(coreDockerImage, coreInstanceVersion, namespace, createNamespace, opts)
 	if err != nil {
 		return "", err
 	}
 
-	kctl.SetArgs([]string{"apply", "-f", manifest})
-	err = kctl.Execute()
-	if err != nil {
-		return "", err
+	done := interrupt.Step(applyManifestStep)
+	if err := k.ApplyManifest(ctx, manifest); err != nil {
+		return "", fmt.Errorf("could not apply operator manifest: %w", err)
 	}
+	done()
 
-	return manifest, err
+	return manifest, nil
 }
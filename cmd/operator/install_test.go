@@ -1,11 +1,16 @@
 package operator
 
 import (
+	"context"
 	"fmt"
-	"os"
+	"io"
 	"strings"
 	"testing"
 
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
 	"github.com/calyptia/cli/k8s"
 )
 
@@ -124,29 +129,247 @@ spec:
 `
 
 	t.Run("Successful manifest preparation", func(t *testing.T) {
-		// Mocking k8s.GetOperatorManifest
-		k8s.GetOperatorManifest = func(version string) ([]byte, error) {
-			return []byte(deploymentManifest), nil
-		}
-
 		// Test the prepareManifest function
-		resultFile, err := prepareInstallManifest(coreInstanceVersion, coreDockerImage, namespace, false)
+		result, err := buildInstallManifest(coreDockerImage, coreInstanceVersion, namespace, false, manifestOptions{})
 		// Verify the results
 		if err != nil {
 			t.Errorf("Expected no error, but got: %v", err)
 		}
 
-		actualFileContents, _ := os.ReadFile(resultFile)
-
-		result := string(actualFileContents)
 		if strings.Contains(result, fmt.Sprintf("image: %s:%s", coreDockerImage, coreInstanceVersion)) == false {
 			t.Errorf("Expected image: %s:%s, but got: %s", coreDockerImage, coreInstanceVersion, result)
 		}
 		if strings.Contains(result, fmt.Sprintf("namespace: %s", namespace)) == false {
 			t.Errorf("Expected namespace: %s, but got: %s", namespace, result)
 		}
+	})
+}
+
+func TestEnableHA(t *testing.T) {
+	file, err := f.ReadFile(manifestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Successful patch", func(t *testing.T) {
+		result, err := enableHA(string(file), 3)
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+
+		for _, expected := range []string{
+			"replicas: 3",
+			"- --leader-elect",
+			"podAntiAffinity:",
+			"topologyKey: kubernetes.io/hostname",
+			"- leases",
+		} {
+			if !strings.Contains(result, expected) {
+				t.Errorf("Expected manifest to contain %q", expected)
+			}
+		}
+
+		docs := strings.Split(result, "---\n")
+		if !strings.Contains(docs[len(docs)-1], "kind: Deployment") {
+			t.Error("Expected deployment to remain the last manifest document")
+		}
+	})
+
+	t.Run("Not enough replicas", func(t *testing.T) {
+		if _, err := enableHA(string(file), 1); err == nil {
+			t.Error("Expected an error, but got no error")
+		}
+	})
+
+	t.Run("No deployment found", func(t *testing.T) {
+		if _, err := enableHA("kind: Namespace\n", 2); err == nil {
+			t.Error("Expected an error, but got no error")
+		}
+	})
+}
+
+func TestApplyProfile(t *testing.T) {
+	file, err := f.ReadFile(manifestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Edge", func(t *testing.T) {
+		result, err := applyProfile(string(file), profileEdge)
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+
+		for _, expected := range []string{
+			"replicas: 1",
+			"- " + metricsDisabledArg,
+			"cpu: 5m",
+			"memory: 32Mi",
+		} {
+			if !strings.Contains(result, expected) {
+				t.Errorf("Expected manifest to contain %q", expected)
+			}
+		}
+
+		for _, unexpected := range []string{
+			"name: calyptia-core-controller-manager-metrics-service",
+			"name: calyptia-core-proxy-role",
+			"containerPort: 8443",
+		} {
+			if strings.Contains(result, unexpected) {
+				t.Errorf("Expected manifest not to contain %q", unexpected)
+			}
+		}
+
+		docs := strings.Split(result, "---\n")
+		if !strings.Contains(docs[len(docs)-1], "kind: Deployment") {
+			t.Error("Expected deployment to remain the last manifest document")
+		}
+	})
 
-		// Clean up the temporary file
-		os.Remove(resultFile)
+	t.Run("Unknown profile", func(t *testing.T) {
+		if _, err := applyProfile(string(file), "tiny"); err == nil {
+			t.Error("Expected an error, but got no error")
+		}
 	})
 }
+
+func TestSetManagerResources(t *testing.T) {
+	file, err := f.ReadFile(manifestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Successful patch", func(t *testing.T) {
+		result, err := setManagerResources(string(file), k8s.ResourceOptions{MemoryLimit: "256Mi", CPURequest: "50m"})
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+
+		for _, expected := range []string{
+			"memory: 256Mi",
+			"cpu: 50m",
+			"cpu: 500m",
+			"memory: 64Mi",
+		} {
+			if !strings.Contains(result, expected) {
+				t.Errorf("Expected manifest to contain %q", expected)
+			}
+		}
+	})
+
+	t.Run("Request exceeds limit", func(t *testing.T) {
+		if _, err := setManagerResources(string(file), k8s.ResourceOptions{MemoryRequest: "1Gi"}); err == nil {
+			t.Error("Expected an error, but got no error")
+		}
+	})
+}
+
+func TestUseImagePullSecrets(t *testing.T) {
+	file, err := f.ReadFile(manifestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	creds := &k8s.RegistryCredentials{Server: "registry.example.com", Username: "robot", Password: "pass"}
+	result, err := useImagePullSecrets(string(file), []string{"existing"}, creds)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	for _, expected := range []string{
+		"- name: existing",
+		"- name: " + registrySecretName,
+		"type: kubernetes.io/dockerconfigjson",
+	} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected manifest to contain %q", expected)
+		}
+	}
+
+	docs := strings.Split(result, "---\n")
+	if !strings.Contains(docs[len(docs)-1], "kind: Deployment") {
+		t.Error("Expected deployment to remain the last manifest document")
+	}
+}
+
+func TestUseServiceAccount(t *testing.T) {
+	file, err := f.ReadFile(manifestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withHA, err := enableHA(string(file), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := useServiceAccount(withHA, "my-sa")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	if !strings.Contains(result, "serviceAccountName: my-sa") {
+		t.Error("Expected deployment to use the provided service account")
+	}
+	if strings.Contains(result, "kind: ClusterRoleBinding") {
+		t.Error("Expected cluster role bindings to be removed")
+	}
+	if strings.Contains(result, "\nkind: ServiceAccount") {
+		t.Error("Expected service account to be removed")
+	}
+	if !strings.Contains(result, "kind: RoleBinding") || !strings.Contains(result, "name: my-sa\n") {
+		t.Error("Expected leader election role binding to reference the provided service account")
+	}
+}
+
+func TestInstallDryRunFlags(t *testing.T) {
+	tt := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"--dry-run=apply"}, wantErr: `invalid dry run mode "apply"`},
+		{args: []string{"--output-file", "manifest.yaml"}, wantErr: "--output-file requires --dry-run"},
+	}
+	for _, tc := range tt {
+		cmd := NewCmdInstall()
+		cmd.SetArgs(tc.args)
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+
+		err := cmd.Execute()
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("%v: want error %q, got %v", tc.args, tc.wantErr, err)
+		}
+	}
+}
+
+func TestNamespaceToCreate(t *testing.T) {
+	ctx := context.Background()
+	k := &k8s.Client{Interface: fake.NewSimpleClientset(&apiv1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "existing"}})}
+
+	tt := []struct {
+		name      string
+		namespace string
+		create    bool
+		want      bool
+		wantErr   bool
+	}{
+		{name: "existing", namespace: "existing", create: true},
+		{name: "existing without create", namespace: "existing"},
+		{name: "missing", namespace: "missing", create: true, want: true},
+		{name: "missing without create", namespace: "missing", wantErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := namespaceToCreate(ctx, k, tc.namespace, tc.create)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("want error %v, got %v", tc.wantErr, err)
+			}
+
+			if got != tc.want {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package operator
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/k8s"
+)
+
+const defaultKubeconfigDuration = time.Hour
+
+func NewCmdGenerateKubeconfig() *cobra.Command {
+	var serviceAccount string
+	var duration time.Duration
+	var outputFile string
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	configOverrides := &clientcmd.ConfigOverrides{}
+
+	cmd := &cobra.Command{
+		Use:   "kubeconfig",
+		Short: "Generate a short-lived kubeconfig for the core operator service account",
+		Long: "Generate a short-lived kubeconfig authenticating as a service account, the core operator one by default, " +
+			"using a token from the TokenRequest API.\n" +
+			"Useful to diagnose RBAC issues exactly as the operator sees them. The token cannot be revoked, keep --duration short.",
+		Example: "  calyptia generate kubeconfig --output-file operator.kubeconfig\n" +
+			"  KUBECONFIG=operator.kubeconfig kubectl auth can-i --list",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+			kubeClientConfig, err := kubeConfig.ClientConfig()
+			if err != nil {
+				return err
+			}
+
+			clientSet, err := kubernetes.NewForConfig(kubeClientConfig)
+			if err != nil {
+				return err
+			}
+
+			k := &k8s.Client{
+				Interface: clientSet,
+				Config:    kubeClientConfig,
+			}
+
+			var namespace string
+			if serviceAccount == "" {
+				namespace, serviceAccount, err = k.OperatorServiceAccount(ctx)
+				if err != nil {
+					return fmt.Errorf("could not find core operator service account: %w", err)
+				}
+			} else {
+				namespace, err = k8s.ResolveNamespace(kubeConfig)
+				if err != nil {
+					return err
+				}
+			}
+
+			generated, err := k.GenerateServiceAccountKubeconfig(ctx, namespace, serviceAccount, duration)
+			if err != nil {
+				return err
+			}
+
+			b, err := clientcmd.Write(*generated.Config)
+			if err != nil {
+				return fmt.Errorf("could not encode kubeconfig: %w", err)
+			}
+
+			if outputFile == "" {
+				cmd.PrintErrf("Kubeconfig for %s expires at %s\n", generated.User, formatters.FmtTimestamp(generated.ExpiresAt))
+				_, err = cmd.OutOrStdout().Write(b)
+				return err
+			}
+
+			if err := os.WriteFile(outputFile, b, 0o600); err != nil {
+				return fmt.Errorf("could not write kubeconfig: %w", err)
+			}
+
+			cmd.PrintErrf("Saved kubeconfig for %s to %s, it expires at %s\n", generated.User, outputFile, formatters.FmtTimestamp(generated.ExpiresAt))
+			return nil
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.StringVar(&serviceAccount, "service-account", "", "Service account to authenticate as, in the --kube-namespace namespace. Defaults to the core operator one")
+	fs.DurationVar(&duration, "duration", defaultKubeconfigDuration, fmt.Sprintf("How long the kubeconfig is valid for, at least %s", k8s.MinTokenDuration))
+	fs.StringVar(&outputFile, "output-file", "", "File to write the kubeconfig to instead of stdout")
+	clientcmd.BindOverrideFlags(configOverrides, fs, clientcmd.RecommendedConfigOverrideFlags("kube-"))
+
+	return cmd
+}
@@ -0,0 +1,148 @@
+package operator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+)
+
+// profileEdge tunes the manifest for constrained clusters such as k3s or k0s.
+const profileEdge = "edge"
+
+// installProfiles are the values accepted by --profile.
+var installProfiles = []string{profileEdge}
+
+// metricsDisabledArg turns off the manager metrics endpoint.
+const metricsDisabledArg = "--metrics-bind-address=0"
+
+// edgeOptionalObjects are the manifest objects serving the manager metrics,
+// not needed when they are disabled.
+var edgeOptionalObjects = map[string]bool{
+	"calyptia-core-metrics-reader":                     true,
+	"calyptia-core-proxy-role":                         true,
+	"calyptia-core-proxy-rolebinding":                  true,
+	"calyptia-core-controller-manager-metrics-service": true,
+}
+
+// edgeResources are the manager resources with the edge profile.
+var edgeResources = apiv1.ResourceRequirements{
+	Limits: apiv1.ResourceList{
+		apiv1.ResourceCPU:    resource.MustParse("200m"),
+		apiv1.ResourceMemory: resource.MustParse("96Mi"),
+	},
+	Requests: apiv1.ResourceList{
+		apiv1.ResourceCPU:    resource.MustParse("5m"),
+		apiv1.ResourceMemory: resource.MustParse("32Mi"),
+	},
+}
+
+func validProfile(profile string) error {
+	if profile == "" {
+		return nil
+	}
+
+	for _, p := range installProfiles {
+		if p == profile {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid profile %q, options: %s", profile, strings.Join(installProfiles, ", "))
+}
+
+// applyProfile patches the manifest for the given install profile.
+// The edge profile runs a single manager replica with reduced resources,
+// and drops the metrics endpoint and any webhook configuration along with
+// the objects supporting them.
+func applyProfile(file, profile string) (string, error) {
+	if err := validProfile(profile); err != nil {
+		return "", err
+	}
+
+	if profile != profileEdge {
+		return file, nil
+	}
+
+	var found bool
+	var out []string
+	for _, doc := range strings.Split(file, "---\n") {
+		var meta struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		}
+		if err := yaml.Unmarshal([]byte(doc), &meta); err != nil {
+			return "", err
+		}
+
+		switch {
+		case edgeOptionalObjects[meta.Metadata.Name],
+			meta.Kind == "ValidatingWebhookConfiguration",
+			meta.Kind == "MutatingWebhookConfiguration":
+			continue
+		case meta.Kind == "Deployment":
+			var deployment appsv1.Deployment
+			if err := yaml.Unmarshal([]byte(doc), &deployment); err != nil {
+				return "", err
+			}
+
+			if err := patchDeploymentEdge(&deployment); err != nil {
+				return "", err
+			}
+
+			patched, err := yaml.Marshal(deployment)
+			if err != nil {
+				return "", err
+			}
+
+			doc = string(patched)
+			found = true
+		}
+		out = append(out, doc)
+	}
+
+	if !found {
+		return "", errors.New("could not find deployment in manifest")
+	}
+
+	return strings.Join(out, "---\n"), nil
+}
+
+func patchDeploymentEdge(deployment *appsv1.Deployment) error {
+	replicas := int32(1)
+	deployment.Spec.Replicas = &replicas
+
+	podSpec := &deployment.Spec.Template.Spec
+	for i, container := range podSpec.Containers {
+		if container.Name != managerContainerName {
+			continue
+		}
+
+		c := &podSpec.Containers[i]
+		c.Resources = *edgeResources.DeepCopy()
+
+		var ports []apiv1.ContainerPort
+		for _, p := range c.Ports {
+			if p.Name != "https" {
+				ports = append(ports, p)
+			}
+		}
+		c.Ports = ports
+
+		for _, arg := range c.Args {
+			if arg == metricsDisabledArg {
+				return nil
+			}
+		}
+		c.Args = append(c.Args, metricsDisabledArg)
+		return nil
+	}
+
+	return fmt.Errorf("could not find %q container in deployment", managerContainerName)
+}
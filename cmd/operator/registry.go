@@ -0,0 +1,78 @@
+package operator
+
+import (
+	"errors"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/calyptia/cli/k8s"
+)
+
+// registrySecretName is the docker registry secret created with --registry-creds.
+const registrySecretName = "calyptia-core-registry-credentials"
+
+// useImagePullSecrets patches the manager deployment found in the manifest to
+// pull its image with the given secrets. With registry credentials, their
+// secret is inserted right before the deployment and used too, so the
+// deployment remains the last document of the manifest.
+func useImagePullSecrets(file string, names []string, creds *k8s.RegistryCredentials) (string, error) {
+	var secretDoc string
+	if creds != nil {
+		secret, err := creds.RegistrySecret(metav1.ObjectMeta{
+			Name:      registrySecretName,
+			Namespace: "calyptia-core",
+			Labels:    map[string]string{"calyptia.core": "core-operator"},
+		})
+		if err != nil {
+			return "", err
+		}
+
+		b, err := yaml.Marshal(secret)
+		if err != nil {
+			return "", err
+		}
+
+		secretDoc = string(b)
+		names = append(append([]string{}, names...), registrySecretName)
+	}
+
+	docs := strings.Split(file, "---\n")
+	for i, doc := range docs {
+		var meta metav1.TypeMeta
+		if err := yaml.Unmarshal([]byte(doc), &meta); err != nil {
+			return "", err
+		}
+		if meta.Kind != "Deployment" {
+			continue
+		}
+
+		var deployment appsv1.Deployment
+		if err := yaml.Unmarshal([]byte(doc), &deployment); err != nil {
+			return "", err
+		}
+
+		podSpec := &deployment.Spec.Template.Spec
+		for _, name := range names {
+			podSpec.ImagePullSecrets = append(podSpec.ImagePullSecrets, apiv1.LocalObjectReference{Name: name})
+		}
+
+		patched, err := yaml.Marshal(deployment)
+		if err != nil {
+			return "", err
+		}
+
+		out := append([]string{}, docs[:i]...)
+		if secretDoc != "" {
+			out = append(out, secretDoc)
+		}
+		out = append(out, string(patched))
+		out = append(out, docs[i+1:]...)
+		return strings.Join(out, "---\n"), nil
+	}
+
+	return "", errors.New("could not find deployment in manifest")
+}
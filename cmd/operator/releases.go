@@ -0,0 +1,83 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/calyptia/cli/cmd/utils"
+	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/k8s"
+)
+
+func NewCmdGetOperatorReleases() *cobra.Command {
+	var prerelease bool
+	var last uint
+
+	cmd := &cobra.Command{
+		Use:     "core_operator_releases",
+		Aliases: []string{"core-operator-releases", "operator_releases", "operator-releases"},
+		Short:   "List the available core operator versions",
+		Long: "List the published core operator versions, from the newest to the oldest, " +
+			"to choose the target of an install or update.\n" +
+			"The version bundled with this CLI, installed by default, is marked with an asterisk.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			releases, err := k8s.ListOperatorReleases(cmd.Context(), prerelease)
+			if err != nil {
+				return fmt.Errorf("could not fetch core operator releases: %w", err)
+			}
+
+			if last != 0 && uint(len(releases)) > last {
+				releases = releases[:last]
+			}
+
+			fs := cmd.Flags()
+			outputFormat := formatters.OutputFormatFromFlags(fs)
+			if fn, ok := formatters.ShouldApplyTemplating(outputFormat); ok {
+				return fn(cmd.OutOrStdout(), formatters.TemplateFromFlags(fs), releases)
+			}
+
+			switch outputFormat {
+			case formatters.OutputFormatJSON:
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(releases)
+			case formatters.OutputFormatYAML:
+				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(releases)
+			default:
+				return renderOperatorReleases(cmd.OutOrStdout(), releases)
+			}
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.BoolVar(&prerelease, "prerelease", false, "Include pre-release versions")
+	fs.UintVarP(&last, "last", "l", 0, "Last `N` releases. 0 means no limit")
+	formatters.BindFormatFlags(cmd)
+
+	return cmd
+}
+
+func renderOperatorReleases(w io.Writer, releases []k8s.OperatorRelease) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tRELEASED\tKUBERNETES\tRELEASE-NOTES")
+	for _, r := range releases {
+		version := r.Version
+		if r.Prerelease {
+			version += " (pre-release)"
+		}
+		if r.Version == utils.DefaultCoreOperatorDockerImageTag {
+			version += " *"
+		}
+
+		compatibility := r.Compatibility
+		if compatibility == "" {
+			compatibility = "-"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", version, formatters.FmtDate(r.ReleasedAt), compatibility, r.ReleaseNotesURL)
+	}
+	return tw.Flush()
+}
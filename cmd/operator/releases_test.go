@@ -0,0 +1,37 @@
+package operator
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/calyptia/cli/cmd/utils"
+	"github.com/calyptia/cli/k8s"
+)
+
+func TestRenderOperatorReleases(t *testing.T) {
+	releasedAt := time.Date(2024, 1, 15, 12, 0, 0, 0, time.Local)
+
+	var buf bytes.Buffer
+	err := renderOperatorReleases(&buf, []k8s.OperatorRelease{
+		{Version: "v9.0.0-rc1", Prerelease: true, ReleasedAt: releasedAt, ReleaseNotesURL: "https://example.org/rc"},
+		{Version: utils.DefaultCoreOperatorDockerImageTag, ReleasedAt: releasedAt, Compatibility: ">= 1.24", ReleaseNotesURL: "https://example.org/default"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and 2 rows, got %q", buf.String())
+	}
+
+	if !strings.Contains(lines[1], "v9.0.0-rc1 (pre-release)") || !strings.Contains(lines[1], "2024-01-15") || !strings.Contains(lines[1], " - ") {
+		t.Errorf("unexpected pre-release row %q", lines[1])
+	}
+
+	if !strings.Contains(lines[2], utils.DefaultCoreOperatorDockerImageTag+" *") || !strings.Contains(lines[2], ">= 1.24") {
+		t.Errorf("unexpected default release row %q", lines[2])
+	}
+}
@@ -0,0 +1,63 @@
+package operator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/calyptia/cli/k8s"
+)
+
+// setManagerResources patches the manager container of the deployment found
+// in the manifest with the given resources, on top of the ones it already has.
+func setManagerResources(file string, resources k8s.ResourceOptions) (string, error) {
+	docs := strings.Split(file, "---\n")
+	for i, doc := range docs {
+		var meta metav1.TypeMeta
+		if err := yaml.Unmarshal([]byte(doc), &meta); err != nil {
+			return "", err
+		}
+		if meta.Kind != "Deployment" {
+			continue
+		}
+
+		var deployment appsv1.Deployment
+		if err := yaml.Unmarshal([]byte(doc), &deployment); err != nil {
+			return "", err
+		}
+
+		if err := patchDeploymentResources(&deployment, resources); err != nil {
+			return "", err
+		}
+
+		patched, err := yaml.Marshal(deployment)
+		if err != nil {
+			return "", err
+		}
+
+		docs[i] = string(patched)
+		return strings.Join(docs, "---\n"), nil
+	}
+
+	return "", errors.New("could not find deployment in manifest")
+}
+
+func patchDeploymentResources(deployment *appsv1.Deployment, resources k8s.ResourceOptions) error {
+	podSpec := &deployment.Spec.Template.Spec
+	for i, container := range podSpec.Containers {
+		if container.Name != managerContainerName {
+			continue
+		}
+
+		if err := resources.Apply(&podSpec.Containers[i].Resources); err != nil {
+			return fmt.Errorf("could not set %q container resources: %w", managerContainerName, err)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("could not find %q container in deployment", managerContainerName)
+}
@@ -0,0 +1,177 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/calyptia/cli/cmd/kubecontexts"
+	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/k8s"
+)
+
+func NewCmdGetOperatorStatus() *cobra.Command {
+	var multiContext kubecontexts.Flags
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	configOverrides := &clientcmd.ConfigOverrides{}
+
+	cmd := &cobra.Command{
+		Use:     "operator_status",
+		Aliases: []string{"operator-status"},
+		Short:   "Display the core operator components installed in the cluster",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			contexts, err := multiContext.Contexts(loadingRules, configOverrides)
+			if err != nil {
+				return err
+			}
+
+			if contexts != nil {
+				return runOperatorStatusOnContexts(cmd, loadingRules, configOverrides, contexts)
+			}
+
+			kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+			kubeClientConfig, err := kubeConfig.ClientConfig()
+			if err != nil {
+				return err
+			}
+
+			clientSet, err := kubernetes.NewForConfig(kubeClientConfig)
+			if err != nil {
+				return err
+			}
+
+			k := &k8s.Client{
+				Interface: clientSet,
+				Config:    kubeClientConfig,
+			}
+
+			status, err := k.OperatorStatus(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("could not fetch core operator status: %w", err)
+			}
+
+			fs := cmd.Flags()
+			outputFormat := formatters.OutputFormatFromFlags(fs)
+			if fn, ok := formatters.ShouldApplyTemplating(outputFormat); ok {
+				return fn(cmd.OutOrStdout(), formatters.TemplateFromFlags(fs), status)
+			}
+
+			switch outputFormat {
+			case formatters.OutputFormatJSON:
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(status)
+			case formatters.OutputFormatYAML:
+				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(status)
+			default:
+				return renderOperatorStatus(cmd.OutOrStdout(), status)
+			}
+		},
+	}
+
+	fs := cmd.Flags()
+	formatters.BindFormatFlags(cmd)
+	kubecontexts.BindFlags(cmd, &multiContext)
+	clientcmd.BindOverrideFlags(configOverrides, fs, clientcmd.RecommendedConfigOverrideFlags("kube-"))
+
+	return cmd
+}
+
+func renderOperatorStatus(w io.Writer, status k8s.OperatorStatus) error {
+	if !status.Installed && len(status.Components) == 0 {
+		fmt.Fprintln(w, "Core operator is not installed.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "INSTALLED\tVERSION\tNAMESPACES")
+	fmt.Fprintf(tw, "%v\t%s\t%s\n", status.Installed, status.Version, strings.Join(status.Namespaces, ","))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "")
+	tw = tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tNAMESPACE\tNAME\tVERSION\tSTATE")
+	for _, c := range status.Components {
+		state := string(c.State)
+		if c.Reason != "" {
+			state = fmt.Sprintf("%s (%s)", state, c.Reason)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", c.Kind, c.Namespace, c.Name, c.Version, state)
+	}
+	return tw.Flush()
+}
+
+// clusterOperatorStatus is the core operator status of a single cluster.
+type clusterOperatorStatus struct {
+	Context string              `json:"context" yaml:"context"`
+	Status  *k8s.OperatorStatus `json:"status,omitempty" yaml:"status,omitempty"`
+	Error   string              `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+func runOperatorStatusOnContexts(cmd *cobra.Command, loadingRules *clientcmd.ClientConfigLoadingRules, configOverrides *clientcmd.ConfigOverrides, contexts []string) error {
+	statuses := make([]clusterOperatorStatus, len(contexts))
+	for i, c := range contexts {
+		statuses[i].Context = c
+	}
+
+	var mu sync.Mutex
+	results := kubecontexts.Run(cmd.Context(), contexts, func(ctx context.Context, kubeContext string) (string, error) {
+		k, _, err := kubecontexts.ClientForContext(loadingRules, configOverrides, kubeContext)
+		if err != nil {
+			return "", err
+		}
+
+		status, err := k.OperatorStatus(ctx)
+		if err != nil {
+			return "", fmt.Errorf("could not fetch core operator status: %w", err)
+		}
+
+		mu.Lock()
+		for i := range statuses {
+			if statuses[i].Context == kubeContext {
+				statuses[i].Status = &status
+			}
+		}
+		mu.Unlock()
+
+		if !status.Installed {
+			return "not installed", nil
+		}
+		return fmt.Sprintf("installed %s in %s", status.Version, strings.Join(status.Namespaces, ",")), nil
+	})
+
+	for i, r := range results {
+		statuses[i].Error = r.Error
+	}
+
+	fs := cmd.Flags()
+	outputFormat := formatters.OutputFormatFromFlags(fs)
+	if fn, ok := formatters.ShouldApplyTemplating(outputFormat); ok {
+		return fn(cmd.OutOrStdout(), formatters.TemplateFromFlags(fs), statuses)
+	}
+
+	switch outputFormat {
+	case formatters.OutputFormatJSON:
+		if err := json.NewEncoder(cmd.OutOrStdout()).Encode(statuses); err != nil {
+			return err
+		}
+		return kubecontexts.ResultsErr(results)
+	case formatters.OutputFormatYAML:
+		if err := yaml.NewEncoder(cmd.OutOrStdout()).Encode(statuses); err != nil {
+			return err
+		}
+		return kubecontexts.ResultsErr(results)
+	default:
+		return kubecontexts.RenderResults(cmd.OutOrStdout(), results)
+	}
+}
@@ -1,17 +1,26 @@
 package operator
 
 import (
-	"context"
-	"os"
+	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
 
+	"github.com/calyptia/cli/interrupt"
 	"github.com/calyptia/cli/k8s"
 )
 
 func NewCmdUninstall() *cobra.Command {
+	var (
+		dryRun               bool
+		cascadeCoreInstances bool
+		force                bool
+	)
 	// Create a new default kubectl command and retrieve its flags
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 	configOverrides := &clientcmd.ConfigOverrides{}
@@ -20,76 +29,173 @@ func NewCmdUninstall() *cobra.Command {
 		Use:     "operator",
 		Aliases: []string{"opr"},
 		Short:   "Uninstall operator components",
+		Long: "Uninstall the core operator by deleting the objects of its manifest.\n" +
+			"With --force, the operator objects are discovered in the cluster by label and name\n" +
+			"instead, and deleted whatever the state of the manager, ie: when its deployment\n" +
+			"is broken or was installed from another version of the manifest.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			kctl := newKubectlCmd()
-			namespace := cmd.Flag("kube-namespace").Value.String()
 			kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
 			kubeClientConfig, err := kubeConfig.ClientConfig()
 			if err != nil {
 				return err
 			}
 
+			namespace, err := k8s.ResolveNamespace(kubeConfig)
+			if err != nil {
+				return err
+			}
+
 			clientSet, err := kubernetes.NewForConfig(kubeClientConfig)
 			if err != nil {
 				return err
 			}
 			k := &k8s.Client{
 				Interface: clientSet,
+				Config:    kubeClientConfig,
 			}
 
-			version, err := k.CheckOperatorVersion(context.Background())
-			if err != nil {
-				return err
+			ctx := cmd.Context()
+			version, err := k.CheckOperatorVersion(ctx)
+			if err != nil && !force {
+				return fmt.Errorf("%w\nPass --force to uninstall the operator regardless of its manager", err)
 			}
 
-			yaml, err := prepareUninstallManifest(version, namespace)
+			dependents, err := k.OperatorDependents(ctx)
 			if err != nil {
 				return err
 			}
 
-			kctl.SetArgs([]string{"delete", "-f", yaml})
+			var manifest string
+			var objects []manifestObject
+			var discovered []*unstructured.Unstructured
+			if force {
+				discovered, err = k.OperatorObjects(ctx)
+				if err != nil {
+					return fmt.Errorf("could not discover operator objects: %w", err)
+				}
+
+				for _, o := range discovered {
+					objects = append(objects, manifestObject{Kind: o.GetKind(), Name: o.GetName(), Namespace: o.GetNamespace()})
+				}
+			} else {
+				manifest, err = uninstallManifest(namespace)
+				if err != nil {
+					return err
+				}
+
+				objects, err = manifestObjects(manifest)
+				if err != nil {
+					return err
+				}
+			}
+
+			if len(dependents) != 0 && !cascadeCoreInstances {
+				cmd.PrintErrf("WARNING: the following objects depend on the Calyptia Operator and would be orphaned:\n")
+				for _, d := range dependents {
+					cmd.PrintErrf("  %s\n", d)
+				}
+				cmd.PrintErrf("Pass --cascade-core-instances to delete them along with the operator.\n")
+				if !dryRun {
+					return fmt.Errorf("refusing to uninstall operator with %d dependent objects", len(dependents))
+				}
+			}
 
-			err = kctl.Execute()
-			if err != nil {
-				return err
+			if dryRun {
+				cmd.Printf("The following objects would be deleted:\n")
+				if cascadeCoreInstances {
+					for _, d := range dependents {
+						cmd.Printf("  %s\n", d)
+					}
+				}
+				for _, o := range objects {
+					cmd.Printf("  %s\n", o)
+				}
+				return nil
+			}
+
+			if cascadeCoreInstances && len(dependents) != 0 {
+				done := interrupt.Step("delete operator dependents")
+				if force {
+					// the manager may not be running to process the finalizers.
+					if err := k.RemoveOperatorFinalizers(ctx, dependents); err != nil {
+						return err
+					}
+				}
+				if err := k.DeleteOperatorDependents(ctx, dependents); err != nil {
+					return err
+				}
+				done()
+				cmd.Printf("Deleted %d objects depending on the Calyptia Operator.\n", len(dependents))
+			}
+
+			if force {
+				done := interrupt.Step("delete operator objects")
+				if err := k.DeleteManifestObjects(ctx, discovered); err != nil {
+					return fmt.Errorf("could not delete operator objects: %w", err)
+				}
+				done()
+
+				cmd.Printf("Deleted %d Calyptia Operator objects.\n", len(discovered))
+			} else {
+				done := interrupt.Step("delete operator manifest")
+				if err := k.DeleteManifest(ctx, manifest); err != nil {
+					return fmt.Errorf("could not delete operator %s manifest: %w", version, err)
+				}
+				done()
 			}
-			defer os.RemoveAll(yaml)
 
 			cmd.Printf("Calyptia Operator uninstalled successfully.\n")
 			return nil
 		},
 	}
 	fs := cmd.Flags()
+	fs.BoolVar(&dryRun, "dry-run", false, "List the objects that would be deleted without deleting them")
+	fs.BoolVar(&cascadeCoreInstances, "cascade-core-instances", false, "Also delete the core instances and custom resources managed by the operator. Core instances stay registered on Calyptia Cloud")
+	fs.BoolVar(&force, "force", false, "Discover the operator objects in the cluster and delete them even if the manager is missing or broken")
 	clientcmd.BindOverrideFlags(configOverrides, fs, clientcmd.RecommendedConfigOverrideFlags("kube-"))
 	return cmd
 }
 
-func prepareUninstallManifest(version string, namespace string) (string, error) {
+// uninstallManifest returns the operator manifest for the given namespace.
+func uninstallManifest(namespace string) (string, error) {
 	file, err := f.ReadFile(manifestFile)
 	if err != nil {
 		return "", err
 	}
 
-	fullFile := string(file)
+	solveNamespace := solveNamespaceCreation(false, string(file), namespace)
+	return injectNamespace(solveNamespace, namespace), nil
+}
 
-	solveNamespace := solveNamespaceCreation(false, fullFile, namespace)
-	withNamespace := injectNamespace(solveNamespace, namespace)
+// manifestObject is a single kubernetes object found in a manifest.
+type manifestObject struct {
+	Kind      string
+	Name      string
+	Namespace string
+}
 
-	dir, err := os.MkdirTemp("", "calyptia-operator")
-	if err != nil {
-		return "", err
+func (o manifestObject) String() string {
+	if o.Namespace == "" {
+		return fmt.Sprintf("%s: %s", o.Kind, o.Name)
 	}
+	return fmt.Sprintf("%s: %s/%s", o.Kind, o.Namespace, o.Name)
+}
 
-	sysFile, err := os.CreateTemp(dir, "operator_*.yaml")
-	if err != nil {
-		return "", err
-	}
-	defer sysFile.Close()
-
-	_, err = sysFile.WriteString(withNamespace)
-	if err != nil {
-		return "", err
+// manifestObjects lists the objects defined in the given multi-document manifest.
+func manifestObjects(manifest string) ([]manifestObject, error) {
+	var out []manifestObject
+	for _, doc := range strings.Split(manifest, "---\n") {
+		var obj struct {
+			metav1.TypeMeta   `json:",inline"`
+			metav1.ObjectMeta `json:"metadata"`
+		}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			return nil, fmt.Errorf("could not parse manifest: %w", err)
+		}
+		if obj.Kind == "" {
+			continue
+		}
+		out = append(out, manifestObject{Kind: obj.Kind, Name: obj.Name, Namespace: obj.Namespace})
 	}
-
-	return sysFile.Name(), nil
+	return out, nil
 }
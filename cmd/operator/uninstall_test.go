@@ -0,0 +1,37 @@
+package operator
+
+import (
+	"testing"
+)
+
+func TestManifestObjects(t *testing.T) {
+	manifest, err := uninstallManifest("test-ns")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	objects, err := manifestObjects(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var foundCRD, foundDeployment bool
+	for _, o := range objects {
+		switch {
+		case o.Kind == "CustomResourceDefinition" && o.Name == "pipelines.core.calyptia.com":
+			foundCRD = true
+		case o.Kind == "Deployment" && o.Name == "calyptia-core-controller-manager":
+			foundDeployment = true
+			if o.Namespace != "test-ns" {
+				t.Errorf("Expected deployment namespace to be %q, got %q", "test-ns", o.Namespace)
+			}
+		}
+	}
+
+	if !foundCRD {
+		t.Error("Expected pipelines CRD to be listed")
+	}
+	if !foundDeployment {
+		t.Error("Expected manager deployment to be listed")
+	}
+}
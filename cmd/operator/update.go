@@ -7,7 +7,11 @@ import (
 	"strings"
 	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 
@@ -16,9 +20,11 @@ import (
 
 	semver "github.com/hashicorp/go-version"
 	"github.com/spf13/cobra"
-	apiv1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
 
+	"github.com/calyptia/cli/cmd/kubecontexts"
 	"github.com/calyptia/cli/k8s"
+	"github.com/calyptia/cli/progress"
 )
 
 const (
@@ -31,8 +37,11 @@ func NewCmdUpdate() *cobra.Command {
 		waitReady           bool
 		waitTimeout         time.Duration
 		verbose             bool
+		namespaceCreate     bool
 	)
 
+	var multiContext kubecontexts.Flags
+
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 	configOverrides := &clientcmd.ConfigOverrides{}
 
@@ -40,6 +49,9 @@ func NewCmdUpdate() *cobra.Command {
 		Use:     "operator",
 		Aliases: []string{"opr"},
 		Short:   "Update core operator",
+		Long: "Update the core operator manager to the given version.\n" +
+			"Settings given at install time, ie: --ha, --service-account, --profile, the container resources\n" +
+			"and image pull secrets, are read back from the running manager and kept.",
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			if coreOperatorVersion == "" {
 				return nil
@@ -63,26 +75,56 @@ func NewCmdUpdate() *cobra.Command {
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			var namespace string
-			if configOverrides.Context.Namespace == "" {
-				configOverrides.Context.Namespace = apiv1.NamespaceDefault
+			if coreOperatorVersion == "" {
+				coreOperatorVersion = utils.DefaultCoreOperatorDockerImageTag
 			}
 
-			kubeNamespaceFlag := cmd.Flag("kube-namespace")
-			if kubeNamespaceFlag != nil {
-				namespace = kubeNamespaceFlag.Value.String()
+			tracker, err := progress.FromFlags(cmd)
+			if err != nil {
+				return err
 			}
 
-			n, err := k8s.GetCurrentContextNamespace()
+			contexts, err := multiContext.Contexts(loadingRules, configOverrides)
 			if err != nil {
-				if errors.Is(err, k8s.ErrNoContext) {
-					cmd.Printf("No context is currently set. Using default namespace.\n")
-				} else {
-					return err
-				}
+				return err
 			}
-			if n != "" {
-				namespace = n
+
+			if contexts != nil {
+				results := kubecontexts.Run(cmd.Context(), contexts, func(ctx context.Context, kubeContext string) (string, error) {
+					k, namespace, err := kubecontexts.ClientForContext(loadingRules, configOverrides, kubeContext)
+					if err != nil {
+						return "", err
+					}
+
+					createNamespace, err := namespaceToCreate(ctx, k, namespace, namespaceCreate)
+					if err != nil {
+						return "", err
+					}
+
+					opts, err := liveManifestOptions(ctx, k, namespace)
+					if err != nil {
+						return "", err
+					}
+
+					manifest, err := installManifest(ctx, k, namespace, utils.DefaultCoreOperatorDockerImage, coreOperatorVersion, createNamespace, opts)
+					if err != nil {
+						return "", err
+					}
+
+					if waitReady {
+						deployment, err := extractDeployment(manifest)
+						if err != nil {
+							return "", err
+						}
+
+						if err := k.WaitReady(ctx, namespace, deployment, verbose, waitTimeout); err != nil {
+							return "", err
+						}
+					}
+
+					return "updated to " + coreOperatorVersion, nil
+				})
+				return kubecontexts.RenderResults(cmd.OutOrStdout(), results)
 			}
 
 			kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
@@ -91,40 +133,44 @@ func NewCmdUpdate() *cobra.Command {
 				return err
 			}
 
+			namespace, err := k8s.ResolveNamespace(kubeConfig)
+			if err != nil {
+				return err
+			}
+
 			clientSet, err := kubernetes.NewForConfig(kubeClientConfig)
 			if err != nil {
 				return err
 			}
 			k := &k8s.Client{
 				Interface: clientSet,
-				Namespace: configOverrides.Context.Namespace,
+				Namespace: namespace,
+				Config:    kubeClientConfig,
 			}
-			_, err = k.GetNamespace(cmd.Context(), namespace)
-			if err != nil && !k8serrors.IsNotFound(err) {
+			createNamespace, err := namespaceToCreate(cmd.Context(), k, namespace, namespaceCreate)
+			if err != nil {
 				return err
 			}
 
-			if coreOperatorVersion == "" {
-				coreOperatorVersion = utils.DefaultCoreOperatorDockerImageTag
+			opts, err := liveManifestOptions(cmd.Context(), k, namespace)
+			if err != nil {
+				return err
 			}
 
-			manifest, err := installManifest(namespace, utils.DefaultCoreOperatorDockerImage, coreOperatorVersion, k8serrors.IsNotFound(err))
+			var manifest string
+			err = tracker.Run(cmd.Context(), applyManifestStep, func(ctx context.Context) error {
+				var err error
+				manifest, err = installManifest(ctx, k, namespace, utils.DefaultCoreOperatorDockerImage, coreOperatorVersion, createNamespace, opts)
+				return err
+			})
 			if err != nil {
 				return err
 			}
 
 			if waitReady {
-				deployment, err := extractDeployment(manifest)
-				if err != nil {
-					return err
-				}
-				start := time.Now()
-				fmt.Printf("Waiting for core operator manager to be updated...\n")
-				err = k.WaitReady(context.Background(), namespace, deployment, false, waitTimeout)
-				if err != nil {
+				if err := waitManager(cmd.Context(), tracker, k, namespace, manifest, verbose, waitTimeout); err != nil {
 					return err
 				}
-				fmt.Printf("Core operator manager is ready. Update took %s\n", time.Since(start))
 			}
 
 			cmd.Printf("Core operator manager successfully updated to version %s\n", coreOperatorVersion)
@@ -136,10 +182,119 @@ func NewCmdUpdate() *cobra.Command {
 
 	fs.BoolVar(&waitReady, "wait", false, "Wait for the core instance to be ready before returning")
 	fs.DurationVar(&waitTimeout, "timeout", defaultWaitTimeout, "Wait timeout")
+	progress.BindFlag(cmd)
 	fs.BoolVar(&verbose, "verbose", false, "Print verbose command output")
 	fs.StringVar(&coreOperatorVersion, "version", "", "Core instance version")
 	_ = cmd.Flags().MarkHidden("image")
+	kubecontexts.BindFlags(cmd, &multiContext)
 	clientcmd.BindOverrideFlags(configOverrides, fs, clientcmd.RecommendedConfigOverrideFlags("kube-"))
+	utils.BindNamespaceCreateFlag(fs, &namespaceCreate)
 
 	return cmd
 }
+
+// liveManifestOptions returns the manifest options the running core operator
+// manager was installed with, read back from its deployment, so updating it
+// keeps them instead of reverting to the stock manifest.
+// The registry credentials secret is kept by name, it is not re-created.
+func liveManifestOptions(ctx context.Context, k *k8s.Client, namespace string) (manifestOptions, error) {
+	var opts manifestOptions
+
+	d, err := k.AppsV1().Deployments(namespace).Get(ctx, managerDeploymentName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return opts, nil
+	}
+	if err != nil {
+		return opts, fmt.Errorf("could not get core operator manager deployment: %w", err)
+	}
+
+	podSpec := d.Spec.Template.Spec
+	if podSpec.ServiceAccountName != "" && podSpec.ServiceAccountName != managerServiceAccount {
+		opts.serviceAccount = podSpec.ServiceAccountName
+	}
+
+	for _, ref := range podSpec.ImagePullSecrets {
+		opts.imagePullSecrets = append(opts.imagePullSecrets, ref.Name)
+	}
+
+	for _, c := range podSpec.Containers {
+		if c.Name != managerContainerName {
+			continue
+		}
+
+		for _, arg := range c.Args {
+			switch arg {
+			case leaderElectFlag:
+				opts.haReplicas = 2
+			case metricsDisabledArg:
+				opts.profile = profileEdge
+			}
+		}
+
+		defaults, err := defaultManagerResources(opts.profile)
+		if err != nil {
+			return opts, err
+		}
+
+		// only pin resources changed at install time, so the
+		// defaults of the new manifest apply otherwise.
+		if !apiequality.Semantic.DeepEqual(c.Resources, defaults) {
+			opts.resources = resourceOptions(c.Resources)
+		}
+	}
+
+	if opts.haReplicas != 0 && d.Spec.Replicas != nil && *d.Spec.Replicas > 2 {
+		opts.haReplicas = int(*d.Spec.Replicas)
+	}
+
+	return opts, nil
+}
+
+// defaultManagerResources returns the manager container resources
+// of the stock manifest with the given profile.
+func defaultManagerResources(profile string) (apiv1.ResourceRequirements, error) {
+	if profile == profileEdge {
+		return edgeResources, nil
+	}
+
+	file, err := f.ReadFile(manifestFile)
+	if err != nil {
+		return apiv1.ResourceRequirements{}, err
+	}
+
+	for _, doc := range strings.Split(string(file), "---\n") {
+		if !strings.Contains(doc, "kind: Deployment") {
+			continue
+		}
+
+		var d appsv1.Deployment
+		if err := yaml.Unmarshal([]byte(doc), &d); err != nil {
+			return apiv1.ResourceRequirements{}, err
+		}
+
+		for _, c := range d.Spec.Template.Spec.Containers {
+			if c.Name == managerContainerName {
+				return c.Resources, nil
+			}
+		}
+	}
+
+	return apiv1.ResourceRequirements{}, errors.New("could not find deployment in manifest")
+}
+
+// resourceOptions returns the cpu and memory values set on the requirements.
+func resourceOptions(r apiv1.ResourceRequirements) k8s.ResourceOptions {
+	quantity := func(list apiv1.ResourceList, name apiv1.ResourceName) string {
+		if q, ok := list[name]; ok {
+			return q.String()
+		}
+		return ""
+	}
+
+	return k8s.ResourceOptions{
+		CPURequest:    quantity(r.Requests, apiv1.ResourceCPU),
+		CPULimit:      quantity(r.Limits, apiv1.ResourceCPU),
+		MemoryRequest: quantity(r.Requests, apiv1.ResourceMemory),
+		MemoryLimit:   quantity(r.Limits, apiv1.ResourceMemory),
+	}
+}
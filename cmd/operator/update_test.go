@@ -0,0 +1,75 @@
+package operator
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/yaml"
+
+	"github.com/calyptia/cli/k8s"
+)
+
+func TestLiveManifestOptions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("not installed", func(t *testing.T) {
+		k := &k8s.Client{Interface: fake.NewSimpleClientset()}
+		opts, err := liveManifestOptions(ctx, k, "calyptia-core")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(opts, manifestOptions{}) {
+			t.Errorf("expected no options, got %+v", opts)
+		}
+	})
+
+	tt := []struct {
+		name string
+		opts manifestOptions
+	}{
+		{name: "stock"},
+		{name: "ha", opts: manifestOptions{haReplicas: 3, serviceAccount: "my-sa"}},
+		{name: "edge", opts: manifestOptions{profile: profileEdge, imagePullSecrets: []string{"my-registry"}}},
+		{name: "resources", opts: manifestOptions{resources: k8s.ResourceOptions{CPURequest: "50m", MemoryLimit: "512Mi"}}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			manifest, err := buildInstallManifest("ghcr.io/calyptia/core-operator", "v1.0.0", "calyptia-core", false, tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var deployment appsv1.Deployment
+			for _, doc := range strings.Split(manifest, "---\n") {
+				if strings.Contains(doc, "kind: Deployment") {
+					if err := yaml.Unmarshal([]byte(doc), &deployment); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			k := &k8s.Client{Interface: fake.NewSimpleClientset(&deployment)}
+			got, err := liveManifestOptions(ctx, k, "calyptia-core")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// rebuilding the manifest with the live options
+			// must give back the installed one.
+			rebuilt, err := buildInstallManifest("ghcr.io/calyptia/core-operator", "v1.0.0", "calyptia-core", false, got)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if rebuilt != manifest {
+				t.Errorf("live options %+v do not rebuild the installed manifest", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/calyptia/cli/cmd/pipeline"
+	cfg "github.com/calyptia/cli/config"
+)
+
+func newCmdPause(config *cfg.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pause",
+		Short: "Pause resources without deleting them",
+	}
+
+	cmd.AddCommand(
+		pipeline.NewCmdPausePipeline(config),
+	)
+
+	return cmd
+}
+
+func newCmdResume(config *cfg.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resume",
+		Short: "Resume paused resources",
+	}
+
+	cmd.AddCommand(
+		pipeline.NewCmdResumePipeline(config),
+	)
+
+	return cmd
+}
@@ -0,0 +1,251 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/joho/godotenv"
+
+	cloud "github.com/calyptia/api/types"
+	cfg "github.com/calyptia/cli/config"
+)
+
+// pipelineFileNamePattern are the names a file can be referenced
+// with from the config as {{files.NAME}}.
+var pipelineFileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// pipelineAssets are the secrets and files staged for a bulk update.
+type pipelineAssets struct {
+	Secrets []cloud.CreatePipelineSecret
+	Files   []cloud.CreatePipelineFile
+}
+
+func (a pipelineAssets) empty() bool {
+	return len(a.Secrets) == 0 && len(a.Files) == 0
+}
+
+// pipelineAssetsClient is the subset of the cloud client
+// used to apply the staged assets.
+type pipelineAssetsClient interface {
+	PipelineSecrets(ctx context.Context, pipelineID string, params cloud.PipelineSecretsParams) (cloud.PipelineSecrets, error)
+	CreatePipelineSecret(ctx context.Context, pipelineID string, payload cloud.CreatePipelineSecret) (cloud.Created, error)
+	UpdatePipelineSecret(ctx context.Context, secretID string, payload cloud.UpdatePipelineSecret) error
+	DeletePipelineSecret(ctx context.Context, secretID string) error
+	PipelineFiles(ctx context.Context, pipelineID string, params cloud.PipelineFilesParams) (cloud.PipelineFiles, error)
+	CreatePipelineFile(ctx context.Context, pipelineID string, payload cloud.CreatePipelineFile) (cloud.Created, error)
+	UpdatePipelineFile(ctx context.Context, fileID string, payload cloud.UpdatePipelineFile) error
+	DeletePipelineFile(ctx context.Context, fileID string) error
+}
+
+// stagePipelineAssets reads the secrets from the env file and every regular
+// file from the directory, validating them before anything gets applied.
+func stagePipelineAssets(envFile, filesDir string, encryptFiles bool) (pipelineAssets, error) {
+	var out pipelineAssets
+
+	if envFile != "" {
+		b, err := cfg.ReadFile(envFile)
+		if err != nil {
+			return out, fmt.Errorf("could not read secrets env file: %w", err)
+		}
+
+		m, err := godotenv.Parse(bytes.NewReader(b))
+		if err != nil {
+			return out, fmt.Errorf("could not parse secrets env file %q: %w", envFile, err)
+		}
+
+		for k, v := range m {
+			out.Secrets = append(out.Secrets, cloud.CreatePipelineSecret{Key: k, Value: []byte(v)})
+		}
+
+		sort.Slice(out.Secrets, func(i, j int) bool {
+			return out.Secrets[i].Key < out.Secrets[j].Key
+		})
+	}
+
+	if filesDir != "" {
+		entries, err := os.ReadDir(filesDir)
+		if err != nil {
+			return out, fmt.Errorf("could not read files directory: %w", err)
+		}
+
+		seen := map[string]string{}
+		for _, entry := range entries {
+			if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+
+			path := filepath.Join(filesDir, entry.Name())
+			name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+			if !pipelineFileNamePattern.MatchString(name) {
+				return out, fmt.Errorf("invalid file name %q, only alphanumeric characters, dashes and underscores are allowed", entry.Name())
+			}
+
+			if other, ok := seen[name]; ok {
+				return out, fmt.Errorf("files %q and %q would both be named %q", other, entry.Name(), name)
+			}
+			seen[name] = entry.Name()
+
+			contents, err := cfg.ReadFile(path)
+			if err != nil {
+				return out, fmt.Errorf("could not read file %q: %w", path, err)
+			}
+
+			if isWASMFile(path, "") {
+				if _, err := parseWASM(contents); err != nil {
+					return out, fmt.Errorf("invalid wasm file %q: %w", path, err)
+				}
+			}
+
+			out.Files = append(out.Files, cloud.CreatePipelineFile{
+				Name:      name,
+				Contents:  contents,
+				Encrypted: encryptFiles,
+			})
+		}
+	}
+
+	return out, nil
+}
+
+// updatePayload returns the assets in the form accepted
+// by a regular, non atomic, pipeline update.
+func (a pipelineAssets) updatePayload() ([]cloud.UpdatePipelineSecret, []cloud.UpdatePipelineFile) {
+	var secrets []cloud.UpdatePipelineSecret
+	for _, s := range a.Secrets {
+		s := s
+		secrets = append(secrets, cloud.UpdatePipelineSecret{Key: &s.Key, Value: &s.Value})
+	}
+
+	var files []cloud.UpdatePipelineFile
+	for _, f := range a.Files {
+		f := f
+		files = append(files, cloud.UpdatePipelineFile{Name: &f.Name, Contents: &f.Contents, Encrypted: &f.Encrypted})
+	}
+
+	return secrets, files
+}
+
+// assetsTransaction applies staged assets one by one recording how to
+// undo each change, so a failure leaves the pipeline as it was.
+type assetsTransaction struct {
+	client     pipelineAssetsClient
+	pipelineID string
+	undo       []func(ctx context.Context) error
+}
+
+// apply creates or updates every staged secret and file. On failure the
+// changes already applied are rolled back before returning the error.
+func (tx *assetsTransaction) apply(ctx context.Context, assets pipelineAssets) error {
+	secrets, err := tx.client.PipelineSecrets(ctx, tx.pipelineID, cloud.PipelineSecretsParams{})
+	if err != nil {
+		return fmt.Errorf("could not fetch pipeline secrets: %w", err)
+	}
+
+	files, err := tx.client.PipelineFiles(ctx, tx.pipelineID, cloud.PipelineFilesParams{})
+	if err != nil {
+		return fmt.Errorf("could not fetch pipeline files: %w", err)
+	}
+
+	existingSecrets := map[string]cloud.PipelineSecret{}
+	for _, s := range secrets.Items {
+		existingSecrets[s.Key] = s
+	}
+
+	existingFiles := map[string]cloud.PipelineFile{}
+	for _, f := range files.Items {
+		existingFiles[f.Name] = f
+	}
+
+	for _, s := range assets.Secrets {
+		if err := tx.applySecret(ctx, s, existingSecrets); err != nil {
+			return tx.fail(ctx, fmt.Errorf("could not apply secret %q: %w", s.Key, err))
+		}
+	}
+
+	for _, f := range assets.Files {
+		if err := tx.applyFile(ctx, f, existingFiles); err != nil {
+			return tx.fail(ctx, fmt.Errorf("could not apply file %q: %w", f.Name, err))
+		}
+	}
+
+	return nil
+}
+
+func (tx *assetsTransaction) applySecret(ctx context.Context, s cloud.CreatePipelineSecret, existing map[string]cloud.PipelineSecret) error {
+	if prev, ok := existing[s.Key]; ok {
+		if err := tx.client.UpdatePipelineSecret(ctx, prev.ID, cloud.UpdatePipelineSecret{Value: &s.Value}); err != nil {
+			return err
+		}
+
+		tx.undo = append(tx.undo, func(ctx context.Context) error {
+			return tx.client.UpdatePipelineSecret(ctx, prev.ID, cloud.UpdatePipelineSecret{Value: &prev.Value})
+		})
+		return nil
+	}
+
+	created, err := tx.client.CreatePipelineSecret(ctx, tx.pipelineID, s)
+	if err != nil {
+		return err
+	}
+
+	tx.undo = append(tx.undo, func(ctx context.Context) error {
+		return tx.client.DeletePipelineSecret(ctx, created.ID)
+	})
+	return nil
+}
+
+func (tx *assetsTransaction) applyFile(ctx context.Context, f cloud.CreatePipelineFile, existing map[string]cloud.PipelineFile) error {
+	if prev, ok := existing[f.Name]; ok {
+		if err := tx.client.UpdatePipelineFile(ctx, prev.ID, cloud.UpdatePipelineFile{Contents: &f.Contents, Encrypted: &f.Encrypted}); err != nil {
+			return err
+		}
+
+		tx.undo = append(tx.undo, func(ctx context.Context) error {
+			return tx.client.UpdatePipelineFile(ctx, prev.ID, cloud.UpdatePipelineFile{Contents: &prev.Contents, Encrypted: &prev.Encrypted})
+		})
+		return nil
+	}
+
+	created, err := tx.client.CreatePipelineFile(ctx, tx.pipelineID, f)
+	if err != nil {
+		return err
+	}
+
+	tx.undo = append(tx.undo, func(ctx context.Context) error {
+		return tx.client.DeletePipelineFile(ctx, created.ID)
+	})
+	return nil
+}
+
+// fail rolls back and returns the original error
+// along with any error found while rolling back.
+func (tx *assetsTransaction) fail(ctx context.Context, err error) error {
+	if len(tx.undo) == 0 {
+		return err
+	}
+
+	if rollbackErr := tx.rollback(ctx); rollbackErr != nil {
+		return fmt.Errorf("%w; rollback failed, the pipeline might be left half-updated: %v", err, rollbackErr)
+	}
+
+	return fmt.Errorf("%w; all changes were rolled back", err)
+}
+
+// rollback undoes the applied changes in reverse order.
+func (tx *assetsTransaction) rollback(ctx context.Context) error {
+	var errs []error
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		if err := tx.undo[i](ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	tx.undo = nil
+	return errors.Join(errs...)
+}
@@ -0,0 +1,159 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+func TestStagePipelineAssets(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "secrets.env")
+	filesDir := filepath.Join(dir, "files")
+
+	if err := os.WriteFile(envFile, []byte("B=2\nA=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Mkdir(filesDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, contents := range map[string]string{"parsers.conf": "[PARSER]", ".hidden": "x"} {
+		if err := os.WriteFile(filepath.Join(filesDir, name), []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := stagePipelineAssets(envFile, filesDir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.Secrets) != 2 || got.Secrets[0].Key != "A" || string(got.Secrets[1].Value) != "2" {
+		t.Errorf("unexpected secrets %+v", got.Secrets)
+	}
+
+	if len(got.Files) != 1 || got.Files[0].Name != "parsers" || !got.Files[0].Encrypted {
+		t.Errorf("unexpected files %+v", got.Files)
+	}
+
+	if err := os.WriteFile(filepath.Join(filesDir, "parsers.yaml"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := stagePipelineAssets("", filesDir, false); err == nil {
+		t.Error("expected files with the same name to fail")
+	}
+}
+
+type fakeAssetsClient struct {
+	secrets map[string]cloud.PipelineSecret
+	files   map[string]cloud.PipelineFile
+	failOn  string
+}
+
+func (c *fakeAssetsClient) PipelineSecrets(context.Context, string, cloud.PipelineSecretsParams) (cloud.PipelineSecrets, error) {
+	var out cloud.PipelineSecrets
+	for _, s := range c.secrets {
+		out.Items = append(out.Items, s)
+	}
+	return out, nil
+}
+
+func (c *fakeAssetsClient) CreatePipelineSecret(_ context.Context, _ string, in cloud.CreatePipelineSecret) (cloud.Created, error) {
+	id := "secret-" + in.Key
+	c.secrets[id] = cloud.PipelineSecret{ID: id, Key: in.Key, Value: in.Value}
+	return cloud.Created{ID: id}, nil
+}
+
+func (c *fakeAssetsClient) UpdatePipelineSecret(_ context.Context, id string, in cloud.UpdatePipelineSecret) error {
+	s := c.secrets[id]
+	s.Value = *in.Value
+	c.secrets[id] = s
+	return nil
+}
+
+func (c *fakeAssetsClient) DeletePipelineSecret(_ context.Context, id string) error {
+	delete(c.secrets, id)
+	return nil
+}
+
+func (c *fakeAssetsClient) PipelineFiles(context.Context, string, cloud.PipelineFilesParams) (cloud.PipelineFiles, error) {
+	var out cloud.PipelineFiles
+	for _, f := range c.files {
+		out.Items = append(out.Items, f)
+	}
+	return out, nil
+}
+
+func (c *fakeAssetsClient) CreatePipelineFile(_ context.Context, _ string, in cloud.CreatePipelineFile) (cloud.Created, error) {
+	if in.Name == c.failOn {
+		return cloud.Created{}, errors.New("upload failed")
+	}
+
+	id := "file-" + in.Name
+	c.files[id] = cloud.PipelineFile{ID: id, Name: in.Name, Contents: in.Contents}
+	return cloud.Created{ID: id}, nil
+}
+
+func (c *fakeAssetsClient) UpdatePipelineFile(_ context.Context, id string, in cloud.UpdatePipelineFile) error {
+	f := c.files[id]
+	f.Contents = *in.Contents
+	c.files[id] = f
+	return nil
+}
+
+func (c *fakeAssetsClient) DeletePipelineFile(_ context.Context, id string) error {
+	delete(c.files, id)
+	return nil
+}
+
+func TestAssetsTransaction(t *testing.T) {
+	newClient := func(failOn string) *fakeAssetsClient {
+		return &fakeAssetsClient{
+			secrets: map[string]cloud.PipelineSecret{"secret-A": {ID: "secret-A", Key: "A", Value: []byte("old")}},
+			files:   map[string]cloud.PipelineFile{"file-parsers": {ID: "file-parsers", Name: "parsers", Contents: []byte("old")}},
+			failOn:  failOn,
+		}
+	}
+
+	assets := pipelineAssets{
+		Secrets: []cloud.CreatePipelineSecret{{Key: "A", Value: []byte("new")}, {Key: "B", Value: []byte("new")}},
+		Files:   []cloud.CreatePipelineFile{{Name: "parsers", Contents: []byte("new")}, {Name: "lua", Contents: []byte("new")}},
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		client := newClient("")
+		tx := &assetsTransaction{client: client, pipelineID: "pipeline"}
+		if err := tx.apply(context.Background(), assets); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(client.secrets) != 2 || string(client.secrets["secret-A"].Value) != "new" || len(client.files) != 2 {
+			t.Errorf("unexpected state secrets=%+v files=%+v", client.secrets, client.files)
+		}
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		client := newClient("lua")
+		tx := &assetsTransaction{client: client, pipelineID: "pipeline"}
+		err := tx.apply(context.Background(), assets)
+		if err == nil || !strings.Contains(err.Error(), "rolled back") {
+			t.Fatalf("expected rolled back error, got %v", err)
+		}
+
+		if len(client.secrets) != 1 || string(client.secrets["secret-A"].Value) != "old" {
+			t.Errorf("expected secrets to be restored, got %+v", client.secrets)
+		}
+
+		if len(client.files) != 1 || string(client.files["file-parsers"].Contents) != "old" {
+			t.Errorf("expected files to be restored, got %+v", client.files)
+		}
+	})
+}
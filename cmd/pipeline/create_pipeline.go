@@ -2,6 +2,7 @@ package pipeline
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,7 +10,9 @@ import (
 	"path/filepath"
 	"strings"
 	"text/tabwriter"
+	"time"
 
+	cnfg "github.com/calyptia/cli/cmd/config"
 	"github.com/calyptia/cli/cmd/coreinstance"
 
 	"github.com/joho/godotenv"
@@ -20,6 +23,12 @@ import (
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
 	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/idempotency"
+	"github.com/calyptia/cli/labels"
+	"github.com/calyptia/cli/operation"
+	"github.com/calyptia/cli/redact"
+	"github.com/calyptia/cli/state"
+	"github.com/calyptia/cli/ttl"
 )
 
 func NewCmdCreatePipeline(config *cfg.Config) *cobra.Command {
@@ -27,6 +36,7 @@ func NewCmdCreatePipeline(config *cfg.Config) *cobra.Command {
 	var name string
 	var replicasCount uint
 	var configFile string
+	var renderTemplate bool
 	var secretsFile string
 	var secretsFormat string
 	var files []string
@@ -38,6 +48,8 @@ func NewCmdCreatePipeline(config *cfg.Config) *cobra.Command {
 	var outputFormat, goTemplate string
 	var metadataPairs []string
 	var metadataFile string
+	var variablePairs []string
+	var labelPairs []string
 	var environment string
 	var providedConfigFormat string
 	var deploymentStrategy string
@@ -56,9 +68,25 @@ func NewCmdCreatePipeline(config *cfg.Config) *cobra.Command {
 			if err != nil {
 				return err
 			}
+
+			if renderTemplate {
+				rendered, err := cfg.RenderTemplate(configFile, string(rawConfig))
+				if err != nil {
+					return err
+				}
+				rawConfig = []byte(rendered)
+			}
+
+			if redact.HasRedactedValues(string(rawConfig)) {
+				return fmt.Errorf("config file %q has %s values, get the config with --show-secrets", configFile, redact.Placeholder)
+			}
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if detached, err := operation.Detach(cmd); err != nil || detached {
+				return err
+			}
+
 			// TODO: support `@INCLUDE`. See https://docs.fluentbit.io/manual/administration/configuring-fluent-bit/configuration-file#config_include_file-1
 			secrets, err := parseCreatePipelineSecret(secretsFile, secretsFormat)
 			if err != nil {
@@ -81,7 +109,25 @@ func NewCmdCreatePipeline(config *cfg.Config) *cobra.Command {
 				}
 			}
 
+			vars, removed, err := parseVariables(variablePairs)
+			if err != nil {
+				return err
+			}
+
+			if len(removed) != 0 {
+				return fmt.Errorf("cannot remove variables %s from a new pipeline", strings.Join(removed, ", "))
+			}
+
+			secrets = append(secrets, vars...)
+
+			tags, err := labels.Parse(labelPairs)
+			if err != nil {
+				return err
+			}
+
 			var addFilesPayload []cloud.CreatePipelineFile
+			wasmPaths := map[string]string{}
+			wasmModules := map[string]wasmModule{}
 			for _, f := range files {
 				if f == "" {
 					continue
@@ -95,6 +141,16 @@ func NewCmdCreatePipeline(config *cfg.Config) *cobra.Command {
 					return fmt.Errorf("coult not read file %q: %w", f, err)
 				}
 
+				if isWASMFile(f, "") {
+					mod, err := parseWASM(contents)
+					if err != nil {
+						return fmt.Errorf("invalid wasm file %q: %w", f, err)
+					}
+
+					wasmPaths[filepath.Base(f)] = name
+					wasmModules[name] = mod
+				}
+
 				addFilesPayload = append(addFilesPayload, cloud.CreatePipelineFile{
 					Name:      name,
 					Contents:  contents,
@@ -102,6 +158,13 @@ func NewCmdCreatePipeline(config *cfg.Config) *cobra.Command {
 				})
 			}
 
+			if len(wasmModules) != 0 {
+				rawConfig = []byte(injectWASMPaths(string(rawConfig), wasmPaths))
+				if err := checkWASMFunctions(string(rawConfig), wasmModules); err != nil {
+					return err
+				}
+			}
+
 			var environmentID string
 			if environment != "" {
 				var err error
@@ -130,6 +193,17 @@ func NewCmdCreatePipeline(config *cfg.Config) *cobra.Command {
 				format = cloud.ConfigFormatINI
 			}
 
+			if !cmd.Flags().Changed("resource-profile") {
+				defaults, err := cnfg.LoadProjectDefaults(config)
+				if err != nil {
+					return err
+				}
+
+				if defaults.ResourceProfile != "" {
+					resourceProfileName = defaults.ResourceProfile
+				}
+			}
+
 			strategy := cloud.DefaultDeploymentStrategy
 			if deploymentStrategy == "" {
 				if hotReload {
@@ -153,6 +227,7 @@ func NewCmdCreatePipeline(config *cfg.Config) *cobra.Command {
 				ResourceProfileName:       resourceProfileName,
 				Files:                     addFilesPayload,
 				Metadata:                  metadata,
+				Tags:                      tags,
 				DeploymentStrategy:        strategy,
 			}
 
@@ -167,7 +242,51 @@ func NewCmdCreatePipeline(config *cfg.Config) *cobra.Command {
 				in.Image = &image
 			}
 
-			a, err := config.Cloud.CreatePipeline(config.Ctx, coreInstanceID, in)
+			idempotencyKey, err := idempotency.KeyFromFlags(cmd, coreInstanceID, in)
+			if err != nil {
+				return err
+			}
+
+			expiresAt, err := ttl.FromFlags(cmd, time.Now())
+			if err != nil {
+				return err
+			}
+
+			ctx := config.Ctx
+			var a cloud.CreatedPipeline
+			existing, err := findIdempotentPipeline(ctx, config, coreInstanceID, idempotencyKey)
+			if err != nil {
+				return err
+			}
+
+			if existing != nil {
+				cmd.PrintErrf("Pipeline %q was already created with idempotency key %q\n", existing.Name, idempotencyKey)
+				a = cloud.CreatedPipeline{
+					ID:                           existing.ID,
+					Name:                         existing.Name,
+					Kind:                         existing.Kind,
+					Config:                       existing.Config,
+					DeploymentStrategy:           existing.DeploymentStrategy,
+					Secrets:                      existing.Secrets,
+					Files:                        existing.Files,
+					Status:                       existing.Status,
+					ResourceProfile:              existing.ResourceProfile,
+					ReplicasCount:                existing.ReplicasCount,
+					WaitForChecksBeforeDeploying: existing.WaitForChecksBeforeDeploying,
+					CreatedAt:                    existing.CreatedAt,
+				}
+			} else {
+				if idempotencyKey != "" {
+					in.Tags = labels.Merge(in.Tags, []string{idempotency.Tag(idempotencyKey)})
+					ctx = idempotency.ContextWithKey(ctx, idempotencyKey)
+				}
+
+				if !expiresAt.IsZero() {
+					in.Tags = labels.Merge(in.Tags, []string{ttl.Tag(expiresAt)})
+				}
+
+				a, err = config.Cloud.CreatePipeline(ctx, coreInstanceID, in)
+			}
 			if err != nil {
 				if e, ok := err.(*cloud.Error); ok && e.Detail != nil {
 					return fmt.Errorf("could not create pipeline: %s: %s", err, *e.Detail)
@@ -176,8 +295,34 @@ func NewCmdCreatePipeline(config *cfg.Config) *cobra.Command {
 				return fmt.Errorf("could not create pipeline: %w", err)
 			}
 
-			if strings.HasPrefix(outputFormat, "go-template") {
-				return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, a)
+			if existing == nil && !expiresAt.IsZero() {
+				err := ttl.Track(config.LocalData, ttl.Resource{
+					Kind:      ttl.KindPipeline,
+					ID:        a.ID,
+					Name:      a.Name,
+					ProjectID: config.ProjectID,
+					ExpiresAt: expiresAt,
+				})
+				if err != nil {
+					return fmt.Errorf("could not record pipeline ttl: %w", err)
+				}
+			}
+
+			if existing == nil {
+				err := config.State.Track(cmd, state.Resource{
+					Kind:      state.KindPipeline,
+					ID:        a.ID,
+					Name:      a.Name,
+					ProjectID: config.ProjectID,
+					ParentID:  coreInstanceID,
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, a)
 			}
 
 			switch outputFormat {
@@ -197,15 +342,20 @@ func NewCmdCreatePipeline(config *cfg.Config) *cobra.Command {
 		},
 	}
 
+	idempotency.BindFlags(cmd)
+	ttl.BindFlag(cmd)
+	operation.BindFlag(cmd)
+
 	fs := cmd.Flags()
 	fs.StringVar(&coreInstanceKey, "core-instance", "", "Parent core-instance ID or name")
 	fs.StringVar(&name, "name", "", "Pipeline name; leave it empty to generate a random name")
 	fs.UintVar(&replicasCount, "replicas", 1, "Pipeline replica size")
 	fs.StringVar(&configFile, "config-file", "fluent-bit.conf", "Fluent Bit config file used by pipeline")
+	fs.BoolVar(&renderTemplate, "render-template", false, "Render the config file as a go template with sprig functions before sending it, ie: {{ env \"HOST\" | default \"localhost\" }}")
 	fs.StringVar(&providedConfigFormat, "config-format", "", "Default configuration format to use (yaml, ini(deprecated))")
 	fs.StringVar(&secretsFile, "secrets-file", "", "Optional file where secrets are defined. You can store key values and reference them inside your config like so:\n{{ secrets.foo }}")
 	fs.StringVar(&secretsFormat, "secrets-format", "auto", "Secrets file format. Allowed: auto, env, json, yaml. Auto tries to detect it from file extension")
-	fs.StringArrayVar(&files, "file", nil, "Optional file. You can reference this file contents from your config like so:\n{{ files.myfile }}\nPass as many as you want; bear in mind the file name can only contain alphanumeric characters.")
+	fs.StringArrayVar(&files, "file", nil, "Optional file. You can reference this file contents from your config like so:\n{{ files.myfile }}\nPass as many as you want; bear in mind the file name can only contain alphanumeric characters.\nWASM filter modules (.wasm) are validated, and wasm_path properties pointing to them are replaced by their {{ files.NAME }} reference.")
 	fs.BoolVar(&encryptFiles, "encrypt-files", false, "Encrypt file contents")
 	fs.StringVar(&deploymentStrategy, "deployment-strategy", "", "The deployment strategy to use when deploying this pipeline in cluster (hotReload or recreate (default)).")
 	fs.BoolVar(&hotReload, "hot-reload", false, "Use the hotReload deployment strategy when deploying the pipeline to the cluster, (mutually exclusive with deployment-strategy)")
@@ -213,12 +363,14 @@ func NewCmdCreatePipeline(config *cfg.Config) *cobra.Command {
 	fs.BoolVar(&autoCreatePortsFromConfig, "auto-create-ports", true, "Automatically create pipeline ports from config")
 	fs.StringVar(&portsServiceType, "service-type", "", fmt.Sprintf("Service type to use for all ports that are auto-created on this pipeline, options are: %s", coreinstance.AllValidPortKinds()))
 	fs.BoolVar(&skipConfigValidation, "skip-config-validation", false, "Opt-in to skip config validation (Use with caution as this option might be removed soon)")
-	fs.StringVar(&resourceProfileName, "resource-profile", cloud.DefaultResourceProfileName, "Resource profile name")
+	fs.StringVar(&resourceProfileName, "resource-profile", cloud.DefaultResourceProfileName, "Resource profile name. Defaults to the project default resource profile if set with 'calyptia config set default-resource-profile'")
 	fs.StringSliceVar(&metadataPairs, "metadata", nil, "Metadata to attach to the pipeline in the form of key:value. You could instead use a file with the --metadata-file option")
 	fs.StringVar(&metadataFile, "metadata-file", "", "Metadata JSON file to attach to the pipeline intead of passing multiple --metadata flags")
+	fs.StringArrayVar(&variablePairs, "var", nil, "Variable to store as a pipeline secret in the form of KEY=VALUE.\nReference it from the config as {{ secrets.KEY }}. Pass as many as you want")
+	fs.StringSliceVar(&labelPairs, "labels", nil, "Labels to attach to the pipeline in the form of key=value. Pipelines can be filtered by them with get pipelines --selector")
 	fs.StringVar(&environment, "environment", "", "Calyptia environment name")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 
 	_ = cmd.RegisterFlagCompletionFunc("environment", completer.CompleteEnvironments)
 	_ = cmd.RegisterFlagCompletionFunc("core-instance", completer.CompleteCoreInstances)
@@ -360,3 +512,27 @@ func isValidDeploymentStrategy(s string) bool {
 	}
 	return false
 }
+
+// findIdempotentPipeline returns the pipeline previously created
+// within the core instance with the given idempotency key, if any.
+func findIdempotentPipeline(ctx context.Context, config *cfg.Config, coreInstanceID, key string) (*cloud.Pipeline, error) {
+	if key == "" {
+		return nil, nil
+	}
+
+	tag := idempotency.Tag(key)
+	pp, err := config.Cloud.Pipelines(ctx, cloud.PipelinesParams{
+		CoreInstanceID: &coreInstanceID,
+		TagsQuery:      &tag,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not look up pipelines by idempotency key: %w", err)
+	}
+
+	for _, p := range pp.Items {
+		if idempotency.HasKey(p.Tags, key) {
+			return &p, nil
+		}
+	}
+	return nil, nil
+}
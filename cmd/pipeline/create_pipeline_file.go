@@ -20,6 +20,7 @@ func NewCmdCreatePipelineFile(config *cfg.Config) *cobra.Command {
 	var pipelineKey string
 	var file string
 	var encrypt bool
+	var fileType string
 	var outputFormat, goTemplate string
 	completer := completer.Completer{Config: config}
 
@@ -30,11 +31,21 @@ func NewCmdCreatePipelineFile(config *cfg.Config) *cobra.Command {
 			name := filepath.Base(file)
 			name = strings.TrimSuffix(name, filepath.Ext(name))
 
+			if fileType != "" && fileType != pipelineFileTypeWASM {
+				return fmt.Errorf("invalid file type %q, options: %s", fileType, pipelineFileTypeWASM)
+			}
+
 			contents, err := cfg.ReadFile(file)
 			if err != nil {
 				return err
 			}
 
+			if isWASMFile(file, fileType) {
+				if _, err := parseWASM(contents); err != nil {
+					return fmt.Errorf("invalid wasm file %q: %w", file, err)
+				}
+			}
+
 			pipelineID, err := completer.LoadPipelineID(pipelineKey)
 			if err != nil {
 				return err
@@ -49,8 +60,8 @@ func NewCmdCreatePipelineFile(config *cfg.Config) *cobra.Command {
 				return err
 			}
 
-			if strings.HasPrefix(outputFormat, "go-template") {
-				return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, out)
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, out)
 			}
 
 			switch outputFormat {
@@ -75,14 +86,18 @@ func NewCmdCreatePipelineFile(config *cfg.Config) *cobra.Command {
 	fs.StringVar(&pipelineKey, "pipeline", "", "Pipeline ID or name")
 	fs.StringVar(&file, "file", "", "File path. You will be able to reference the file from a fluentbit config using its base name without the extension. Ex: `some_dir/my_file.txt` will be referenced as `{{files.my_file}}`")
 	fs.BoolVar(&encrypt, "encrypt", false, "Encrypt file contents")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	fs.StringVar(&fileType, "type", "", fmt.Sprintf("File type, options: %s. WASM filter modules are validated to be wasm32 binaries under %d MiB; reference them from the wasm filter like so:\nwasm_path {{files.my_filter}}\nFiles with the .wasm extension are validated too", pipelineFileTypeWASM, maxWASMSize>>20))
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 
 	_ = cmd.MarkFlagRequired("pipeline")
 	_ = cmd.MarkFlagRequired("file")
 
 	_ = cmd.RegisterFlagCompletionFunc("pipeline", completer.CompletePipelines)
 	_ = cmd.RegisterFlagCompletionFunc("output-format", formatters.CompleteOutputFormat)
+	_ = cmd.RegisterFlagCompletionFunc("type", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return []string{pipelineFileTypeWASM}, cobra.ShellCompDirectiveNoFileComp
+	})
 
 	return cmd
 }
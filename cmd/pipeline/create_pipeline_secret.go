@@ -0,0 +1,159 @@
+package pipeline
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	cloud "github.com/calyptia/api/types"
+	"github.com/calyptia/cli/completer"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/formatters"
+)
+
+// secretCharsets are the characters a generated secret value can be made of.
+var secretCharsets = map[string]string{
+	"alnum":   "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789",
+	"alpha":   "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+	"numeric": "0123456789",
+	"hex":     "0123456789abcdef",
+	"base64":  "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_",
+	"ascii":   "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789!#$%&()*+,-./:;<=>?@[]^_{|}~",
+}
+
+func NewCmdCreatePipelineSecret(config *cfg.Config) *cobra.Command {
+	var pipelineKey string
+	var generate uint
+	var charset, outFile string
+	completer := completer.Completer{Config: config}
+
+	cmd := &cobra.Command{
+		Use:   "pipeline_secret NAME [VALUE]",
+		Short: "Create a pipeline secret",
+		Long: "Create a pipeline secret with the given value.\n" +
+			"With --generate a cryptographically random value is generated locally instead, " +
+			"to bootstrap tokens shared between pipelines and their producers. " +
+			"The generated value is printed only once, or written to --out-file.",
+		Example: "  calyptia create pipeline_secret HTTP_TOKEN --pipeline ingest --generate 32 --charset alnum --out-file token.txt",
+		Args:    cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key := args[0]
+			generated := generate != 0
+
+			var value string
+			switch {
+			case generated && len(args) == 2:
+				return errors.New("cannot use VALUE along with --generate")
+			case generated:
+				var err error
+				value, err = generateSecret(int(generate), charset)
+				if err != nil {
+					return err
+				}
+			case len(args) == 2:
+				value = args[1]
+			default:
+				return errors.New("either VALUE or --generate is required")
+			}
+
+			if outFile != "" && !generated {
+				return errors.New("--out-file can only be used along with --generate")
+			}
+
+			pipelineID, err := completer.LoadPipelineID(pipelineKey)
+			if err != nil {
+				return err
+			}
+
+			created, err := config.Cloud.CreatePipelineSecret(cmd.Context(), pipelineID, cloud.CreatePipelineSecret{
+				Key:   key,
+				Value: []byte(value),
+			})
+			if err != nil {
+				return fmt.Errorf("could not create pipeline secret: %w", err)
+			}
+
+			if generated {
+				if outFile == "" {
+					cmd.PrintErrf("Created pipeline secret %q (%s). Its generated value is shown only once:\n", key, created.ID)
+					fmt.Fprintln(cmd.OutOrStdout(), value)
+					return nil
+				}
+
+				if err := os.WriteFile(outFile, []byte(value), 0o600); err != nil {
+					return fmt.Errorf("pipeline secret %q created but could not write its value to %q: %w", key, outFile, err)
+				}
+				cmd.PrintErrf("Wrote the generated value of pipeline secret %q to %s\n", key, outFile)
+			}
+
+			fs := cmd.Flags()
+			outputFormat := formatters.OutputFormatFromFlags(fs)
+			if fn, ok := formatters.ShouldApplyTemplating(outputFormat); ok {
+				return fn(cmd.OutOrStdout(), formatters.TemplateFromFlags(fs), created)
+			}
+
+			switch outputFormat {
+			case formatters.OutputFormatJSON:
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(created)
+			case formatters.OutputFormatYAML:
+				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(created)
+			default:
+				return formatters.RenderCreated(cmd.OutOrStdout(), created)
+			}
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.StringVar(&pipelineKey, "pipeline", "", "Parent pipeline ID or name")
+	fs.UintVar(&generate, "generate", 0, "Generate a random value of `LENGTH` characters instead of passing VALUE")
+	fs.StringVar(&charset, "charset", "alnum", "Characters of the generated value. Allowed: "+strings.Join(secretCharsetNames(), ", "))
+	fs.StringVar(&outFile, "out-file", "", "Write the generated value to this file, readable only by the current user, instead of printing it")
+	formatters.BindFormatFlags(cmd)
+
+	_ = cmd.RegisterFlagCompletionFunc("pipeline", completer.CompletePipelines)
+	_ = cmd.RegisterFlagCompletionFunc("charset", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return secretCharsetNames(), cobra.ShellCompDirectiveNoFileComp
+	})
+
+	_ = cmd.MarkFlagRequired("pipeline")
+
+	return cmd
+}
+
+// generateSecret returns a random value of the given length
+// picked uniformly from the named charset using crypto/rand.
+func generateSecret(length int, charsetName string) (string, error) {
+	charset, ok := secretCharsets[charsetName]
+	if !ok {
+		return "", fmt.Errorf("unknown charset %q, allowed: %s", charsetName, strings.Join(secretCharsetNames(), ", "))
+	}
+
+	size := big.NewInt(int64(len(charset)))
+	out := make([]byte, length)
+	for i := range out {
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", fmt.Errorf("could not generate secret value: %w", err)
+		}
+		out[i] = charset[n.Int64()]
+	}
+
+	return string(out), nil
+}
+
+func secretCharsetNames() []string {
+	out := make([]string, 0, len(secretCharsets))
+	for name := range secretCharsets {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
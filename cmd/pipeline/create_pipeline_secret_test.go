@@ -0,0 +1,35 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_generateSecret(t *testing.T) {
+	for name, charset := range secretCharsets {
+		got, err := generateSecret(64, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(got) != 64 {
+			t.Errorf("%s: expected 64 characters, got %d", name, len(got))
+		}
+
+		for _, r := range got {
+			if !strings.ContainsRune(charset, r) {
+				t.Errorf("%s: unexpected character %q in %q", name, r, got)
+			}
+		}
+	}
+
+	a, _ := generateSecret(32, "alnum")
+	b, _ := generateSecret(32, "alnum")
+	if a == b {
+		t.Error("expected different values on each generation")
+	}
+
+	if _, err := generateSecret(32, "emoji"); err == nil {
+		t.Error("expected unknown charset error")
+	}
+}
@@ -12,10 +12,13 @@ import (
 	cmpltr "github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
 	"github.com/calyptia/cli/confirm"
+	"github.com/calyptia/cli/protection"
 )
 
 func NewCmdDeletePipeline(config *cfg.Config) *cobra.Command {
 	var confirmed bool
+	var coreInstanceKey string
+	var retention pipelineRetention
 	completer := cmpltr.Completer{Config: config}
 
 	cmd := &cobra.Command{
@@ -24,7 +27,25 @@ func NewCmdDeletePipeline(config *cfg.Config) *cobra.Command {
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: completer.CompletePipelines,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if retention.keepsAny() && coreInstanceKey == "" {
+				return fmt.Errorf("--keep-files and --keep-secrets require the parent --core-instance to keep them at")
+			}
+
 			pipelineKey := args[0]
+			pipelineID, err := completer.LoadPipelineID(pipelineKey)
+			if err != nil {
+				return err
+			}
+
+			pip, err := config.Cloud.Pipeline(config.Ctx, pipelineID, types.PipelineParams{})
+			if err != nil {
+				return fmt.Errorf("could not fetch pipeline: %w", err)
+			}
+
+			if err := protection.Check(cmd, "pipeline", pip.Name, protection.IsPipelineProtected(pip)); err != nil {
+				return err
+			}
+
 			if !confirmed {
 				cmd.Printf("Are you sure you want to delete %q? (y/N) ", pipelineKey)
 				var answer string
@@ -43,9 +64,21 @@ func NewCmdDeletePipeline(config *cfg.Config) *cobra.Command {
 				}
 			}
 
-			pipelineID, err := completer.LoadPipelineID(pipelineKey)
-			if err != nil {
-				return err
+			if retention.enabled() {
+				var coreInstanceID string
+				if coreInstanceKey != "" {
+					coreInstanceID, err = completer.LoadCoreInstanceID(coreInstanceKey, "")
+					if err != nil {
+						return err
+					}
+				}
+
+				retained, err := retainPipelineAssets(config.Ctx, config.Cloud, coreInstanceID, pip, retention)
+				if err != nil {
+					return fmt.Errorf("could not retain pipeline files and secrets, pipeline not deleted: %w", err)
+				}
+
+				printRetainedAssets(cmd, pip.Name, retained)
 			}
 
 			err = config.Cloud.DeletePipeline(config.Ctx, pipelineID)
@@ -59,6 +92,11 @@ func NewCmdDeletePipeline(config *cfg.Config) *cobra.Command {
 
 	fs := cmd.Flags()
 	fs.BoolVarP(&confirmed, "yes", "y", false, "Confirm deletion")
+	fs.StringVar(&coreInstanceKey, "core-instance", "", "Parent core-instance ID or name, to keep the pipeline files and secrets at")
+	bindPipelineRetentionFlags(fs, &retention)
+	protection.BindOverrideFlag(cmd)
+
+	_ = cmd.RegisterFlagCompletionFunc("core-instance", completer.CompleteCoreInstances)
 
 	return cmd
 }
@@ -67,6 +105,7 @@ func NewCmdDeletePipelines(config *cfg.Config) *cobra.Command {
 	var confirmed bool
 	var coreInstanceKey string
 	var environmentKey string
+	var retention pipelineRetention
 	completer := cmpltr.Completer{Config: config}
 
 	cmd := &cobra.Command{
@@ -96,6 +135,8 @@ func NewCmdDeletePipelines(config *cfg.Config) *cobra.Command {
 				return fmt.Errorf("could not prefetch pipelines to delete: %w", err)
 			}
 
+			pp.Items = withoutProtectedPipelines(cmd, pp.Items)
+
 			if len(pp.Items) == 0 {
 				cmd.Println("No pipelines to delete")
 				return nil
@@ -114,6 +155,17 @@ func NewCmdDeletePipelines(config *cfg.Config) *cobra.Command {
 				}
 			}
 
+			if retention.enabled() {
+				for _, p := range pp.Items {
+					retained, err := retainPipelineAssets(ctx, config.Cloud, coreInstanceID, p, retention)
+					if err != nil {
+						return fmt.Errorf("could not retain files and secrets of pipeline %q, pipelines not deleted: %w", p.Name, err)
+					}
+
+					printRetainedAssets(cmd, p.Name, retained)
+				}
+			}
+
 			pipelineIDs := make([]string, len(pp.Items))
 			for i, p := range pp.Items {
 				pipelineIDs[i] = p.ID
@@ -136,6 +188,8 @@ func NewCmdDeletePipelines(config *cfg.Config) *cobra.Command {
 	fs.BoolVarP(&confirmed, "yes", "y", isNonInteractive, "Confirm installation if previous installation found")
 	fs.StringVar(&coreInstanceKey, "core-instance", "", "Parent core-instance ID or name")
 	fs.StringVar(&environmentKey, "environment", "", "Calyptia environment ID or name")
+	bindPipelineRetentionFlags(fs, &retention)
+	protection.BindOverrideFlag(cmd)
 
 	_ = cmd.RegisterFlagCompletionFunc("core-instance", completer.CompleteCoreInstances)
 	_ = cmd.RegisterFlagCompletionFunc("environment", completer.CompleteEnvironments)
@@ -144,3 +198,17 @@ func NewCmdDeletePipelines(config *cfg.Config) *cobra.Command {
 
 	return cmd
 }
+
+// withoutProtectedPipelines leaves the protected pipelines out of a bulk
+// deletion, unless --override-protection was given.
+func withoutProtectedPipelines(cmd *cobra.Command, pp []types.Pipeline) []types.Pipeline {
+	var out []types.Pipeline
+	for _, p := range pp {
+		if protection.Check(cmd, "pipeline", p.Name, protection.IsPipelineProtected(p)) != nil {
+			cmd.PrintErrf("Skipping protected pipeline %q\n", p.Name)
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
@@ -0,0 +1,216 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+// pipelineRetention are the options to keep the files and secrets
+// of the pipelines about to be deleted.
+type pipelineRetention struct {
+	// KeepFiles copies the pipeline files to the parent core instance.
+	KeepFiles bool
+	// KeepSecrets copies the pipeline secrets to the parent core instance.
+	KeepSecrets bool
+	// ExportDir is the directory to export the files and secrets to,
+	// in the layout accepted by update pipeline --files-from-dir and --secrets-from-env-file.
+	ExportDir string
+}
+
+func bindPipelineRetentionFlags(fs *pflag.FlagSet, r *pipelineRetention) {
+	fs.BoolVar(&r.KeepFiles, "keep-files", false, "Keep the pipeline files as core instance files")
+	fs.BoolVar(&r.KeepSecrets, "keep-secrets", false, "Keep the pipeline secrets as core instance secrets")
+	fs.StringVar(&r.ExportDir, "export-before-delete", "", "Export the pipeline files and secrets to the given directory before deleting, one sub-directory per pipeline")
+}
+
+func (r pipelineRetention) keepsAny() bool {
+	return r.KeepFiles || r.KeepSecrets
+}
+
+func (r pipelineRetention) enabled() bool {
+	return r.keepsAny() || r.ExportDir != ""
+}
+
+// pipelineRetentionClient is the subset of the cloud client
+// used to retain the files and secrets of a pipeline.
+type pipelineRetentionClient interface {
+	PipelineSecrets(ctx context.Context, pipelineID string, params cloud.PipelineSecretsParams) (cloud.PipelineSecrets, error)
+	PipelineFiles(ctx context.Context, pipelineID string, params cloud.PipelineFilesParams) (cloud.PipelineFiles, error)
+	AllCoreInstanceSecrets(ctx context.Context, coreInstanceID string) ([]cloud.CoreInstanceSecret, error)
+	AllCoreInstanceFiles(ctx context.Context, coreInstanceID string) ([]cloud.CoreInstanceFile, error)
+	CreateCoreInstanceSecret(ctx context.Context, in cloud.CreateCoreInstanceSecret) (cloud.Created, error)
+	CreateCoreInstanceFile(ctx context.Context, in cloud.CreateCoreInstanceFile) (cloud.Created, error)
+}
+
+// retainedAssets counts the files and secrets retained from a pipeline.
+type retainedAssets struct {
+	Exported, KeptFiles, KeptSecrets int
+}
+
+// retainPipelineAssets exports and keeps the files and secrets of the given
+// pipeline, as requested, before it gets deleted. Files and secrets already
+// kept with the same contents, ie: shared by many pipelines, are skipped,
+// while different contents under the same name fail so nothing gets lost.
+func retainPipelineAssets(ctx context.Context, client pipelineRetentionClient, coreInstanceID string, pip cloud.Pipeline, opts pipelineRetention) (retainedAssets, error) {
+	var out retainedAssets
+
+	secrets, err := client.PipelineSecrets(ctx, pip.ID, cloud.PipelineSecretsParams{})
+	if err != nil {
+		return out, fmt.Errorf("could not fetch pipeline secrets: %w", err)
+	}
+
+	files, err := client.PipelineFiles(ctx, pip.ID, cloud.PipelineFilesParams{})
+	if err != nil {
+		return out, fmt.Errorf("could not fetch pipeline files: %w", err)
+	}
+
+	if opts.ExportDir != "" {
+		out.Exported, err = exportPipelineAssets(filepath.Join(opts.ExportDir, pip.Name), secrets.Items, files.Items)
+		if err != nil {
+			return out, err
+		}
+	}
+
+	if opts.KeepSecrets {
+		existing, err := client.AllCoreInstanceSecrets(ctx, coreInstanceID)
+		if err != nil {
+			return out, fmt.Errorf("could not fetch core instance secrets: %w", err)
+		}
+
+		kept := map[string][]byte{}
+		for _, s := range existing {
+			kept[s.Key] = s.Value
+		}
+
+		for _, s := range secrets.Items {
+			if value, ok := kept[s.Key]; ok {
+				if !bytes.Equal(value, s.Value) {
+					return out, fmt.Errorf("could not keep secret %q: core instance already has a different secret with the same key", s.Key)
+				}
+				continue
+			}
+
+			_, err := client.CreateCoreInstanceSecret(ctx, cloud.CreateCoreInstanceSecret{
+				CoreInstanceID: coreInstanceID,
+				Key:            s.Key,
+				Value:          s.Value,
+			})
+			if err != nil {
+				return out, fmt.Errorf("could not keep secret %q: %w", s.Key, err)
+			}
+
+			kept[s.Key] = s.Value
+			out.KeptSecrets++
+		}
+	}
+
+	if opts.KeepFiles {
+		existing, err := client.AllCoreInstanceFiles(ctx, coreInstanceID)
+		if err != nil {
+			return out, fmt.Errorf("could not fetch core instance files: %w", err)
+		}
+
+		kept := map[string][]byte{}
+		for _, f := range existing {
+			kept[f.Name] = f.Contents
+		}
+
+		for _, f := range files.Items {
+			if contents, ok := kept[f.Name]; ok {
+				if !bytes.Equal(contents, f.Contents) {
+					return out, fmt.Errorf("could not keep file %q: core instance already has a different file with the same name", f.Name)
+				}
+				continue
+			}
+
+			_, err := client.CreateCoreInstanceFile(ctx, cloud.CreateCoreInstanceFile{
+				CoreInstanceID: coreInstanceID,
+				Name:           f.Name,
+				Contents:       f.Contents,
+				Encrypted:      f.Encrypted,
+			})
+			if err != nil {
+				return out, fmt.Errorf("could not keep file %q: %w", f.Name, err)
+			}
+
+			kept[f.Name] = f.Contents
+			out.KeptFiles++
+		}
+	}
+
+	return out, nil
+}
+
+// exportPipelineAssets writes the secrets to dir/secrets.env
+// and every file to dir/files/NAME. It returns how many were written.
+func exportPipelineAssets(dir string, secrets []cloud.PipelineSecret, files []cloud.PipelineFile) (int, error) {
+	if len(secrets) == 0 && len(files) == 0 {
+		return 0, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return 0, fmt.Errorf("could not create export directory: %w", err)
+	}
+
+	var n int
+	if len(secrets) != 0 {
+		if err := os.WriteFile(filepath.Join(dir, "secrets.env"), marshalSecretsEnv(secrets), 0o600); err != nil {
+			return n, fmt.Errorf("could not export secrets: %w", err)
+		}
+		n += len(secrets)
+	}
+
+	if len(files) != 0 {
+		filesDir := filepath.Join(dir, "files")
+		if err := os.MkdirAll(filesDir, 0o700); err != nil {
+			return n, fmt.Errorf("could not create export directory: %w", err)
+		}
+
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].Name < files[j].Name
+		})
+
+		for _, f := range files {
+			if err := os.WriteFile(filepath.Join(filesDir, filepath.Base(f.Name)), f.Contents, 0o600); err != nil {
+				return n, fmt.Errorf("could not export file %q: %w", f.Name, err)
+			}
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// envValueReplacer escapes the characters godotenv interprets within double quotes.
+var envValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`, `"`, `\"`, `!`, `\!`, `$`, `\$`, "`", "\\`")
+
+// marshalSecretsEnv encodes the secrets as a .env file, with every value
+// double quoted so it parses back as is, unlike godotenv.Marshal which
+// drops the leading zeros of numeric looking values.
+func marshalSecretsEnv(secrets []cloud.PipelineSecret) []byte {
+	lines := make([]string, 0, len(secrets))
+	for _, s := range secrets {
+		lines = append(lines, fmt.Sprintf(`%s="%s"`, s.Key, envValueReplacer.Replace(string(s.Value))))
+	}
+	sort.Strings(lines)
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+func printRetainedAssets(cmd *cobra.Command, pipelineName string, r retainedAssets) {
+	if r.Exported != 0 {
+		cmd.Printf("Exported %d files and secrets from pipeline %q\n", r.Exported, pipelineName)
+	}
+	if r.KeptFiles != 0 || r.KeptSecrets != 0 {
+		cmd.Printf("Kept %d files and %d secrets from pipeline %q on the core instance\n", r.KeptFiles, r.KeptSecrets, pipelineName)
+	}
+}
@@ -0,0 +1,119 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/joho/godotenv"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+type fakeRetentionClient struct {
+	pipelineSecrets []cloud.PipelineSecret
+	pipelineFiles   []cloud.PipelineFile
+	secrets         []cloud.CoreInstanceSecret
+	files           []cloud.CoreInstanceFile
+}
+
+func (c *fakeRetentionClient) PipelineSecrets(context.Context, string, cloud.PipelineSecretsParams) (cloud.PipelineSecrets, error) {
+	return cloud.PipelineSecrets{Items: c.pipelineSecrets}, nil
+}
+
+func (c *fakeRetentionClient) PipelineFiles(context.Context, string, cloud.PipelineFilesParams) (cloud.PipelineFiles, error) {
+	return cloud.PipelineFiles{Items: c.pipelineFiles}, nil
+}
+
+func (c *fakeRetentionClient) AllCoreInstanceSecrets(context.Context, string) ([]cloud.CoreInstanceSecret, error) {
+	return c.secrets, nil
+}
+
+func (c *fakeRetentionClient) AllCoreInstanceFiles(context.Context, string) ([]cloud.CoreInstanceFile, error) {
+	return c.files, nil
+}
+
+func (c *fakeRetentionClient) CreateCoreInstanceSecret(_ context.Context, in cloud.CreateCoreInstanceSecret) (cloud.Created, error) {
+	c.secrets = append(c.secrets, cloud.CoreInstanceSecret{CoreInstanceID: in.CoreInstanceID, Key: in.Key, Value: in.Value})
+	return cloud.Created{ID: "secret-" + in.Key}, nil
+}
+
+func (c *fakeRetentionClient) CreateCoreInstanceFile(_ context.Context, in cloud.CreateCoreInstanceFile) (cloud.Created, error) {
+	c.files = append(c.files, cloud.CoreInstanceFile{CoreInstanceID: in.CoreInstanceID, Name: in.Name, Contents: in.Contents, Encrypted: in.Encrypted})
+	return cloud.Created{ID: "file-" + in.Name}, nil
+}
+
+func TestRetainPipelineAssets(t *testing.T) {
+	ctx := context.Background()
+	pip := cloud.Pipeline{ID: "pipeline", Name: "logs"}
+	newClient := func() *fakeRetentionClient {
+		return &fakeRetentionClient{
+			pipelineSecrets: []cloud.PipelineSecret{{Key: "TOKEN", Value: []byte("0123 $HOME \"x\"\n")}, {Key: "SHARED", Value: []byte("same")}},
+			pipelineFiles:   []cloud.PipelineFile{{Name: "geoip", Contents: []byte("db"), Encrypted: true}},
+			secrets:         []cloud.CoreInstanceSecret{{Key: "SHARED", Value: []byte("same")}},
+		}
+	}
+
+	t.Run("keep", func(t *testing.T) {
+		client := newClient()
+		got, err := retainPipelineAssets(ctx, client, "core-instance", pip, pipelineRetention{KeepFiles: true, KeepSecrets: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got.KeptSecrets != 1 || got.KeptFiles != 1 {
+			t.Errorf("unexpected retained %+v", got)
+		}
+
+		if len(client.files) != 1 || !client.files[0].Encrypted || client.files[0].CoreInstanceID != "core-instance" {
+			t.Errorf("unexpected core instance files %+v", client.files)
+		}
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		client := newClient()
+		client.files = []cloud.CoreInstanceFile{{Name: "geoip", Contents: []byte("other")}}
+		_, err := retainPipelineAssets(ctx, client, "core-instance", pip, pipelineRetention{KeepFiles: true})
+		if err == nil || !strings.Contains(err.Error(), `could not keep file "geoip"`) {
+			t.Errorf("expected conflict error, got %v", err)
+		}
+	})
+
+	t.Run("export", func(t *testing.T) {
+		dir := t.TempDir()
+		got, err := retainPipelineAssets(ctx, newClient(), "", pip, pipelineRetention{ExportDir: dir})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got.Exported != 3 {
+			t.Errorf("expected 3 exported, got %d", got.Exported)
+		}
+
+		b, err := os.ReadFile(filepath.Join(dir, "logs", "secrets.env"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		env, err := godotenv.Parse(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if env["TOKEN"] != "0123 $HOME \"x\"\n" || env["SHARED"] != "same" {
+			t.Errorf("secrets did not round trip, got %q", env)
+		}
+
+		contents, err := os.ReadFile(filepath.Join(dir, "logs", "files", "geoip"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(contents) != "db" {
+			t.Errorf("unexpected exported file contents %q", contents)
+		}
+	})
+}
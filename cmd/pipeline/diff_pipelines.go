@@ -0,0 +1,285 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/hexops/gotextdiff"
+	"github.com/hexops/gotextdiff/myers"
+	"github.com/hexops/gotextdiff/span"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	cloud "github.com/calyptia/api/types"
+	"github.com/calyptia/cli/completer"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/formatters"
+)
+
+// pipelineSnapshot holds the parts of a pipeline that get compared.
+type pipelineSnapshot struct {
+	Name               string
+	Config             string
+	ConfigFormat       cloud.ConfigFormat
+	ResourceProfile    string
+	ReplicasCount      uint
+	Image              string
+	DeploymentStrategy cloud.DeploymentStrategy
+	Files              []cloud.PipelineFile
+	Secrets            []cloud.PipelineSecret
+	Ports              []cloud.PipelinePort
+}
+
+// pipelineDifference is a single setting, file, secret or port
+// that is not the same on both pipelines.
+type pipelineDifference struct {
+	Section string `json:"section" yaml:"section"`
+	Name    string `json:"name" yaml:"name"`
+	A       string `json:"a" yaml:"a"`
+	B       string `json:"b" yaml:"b"`
+}
+
+type pipelinesDiff struct {
+	A           string               `json:"a" yaml:"a"`
+	B           string               `json:"b" yaml:"b"`
+	Differences []pipelineDifference `json:"differences" yaml:"differences"`
+	ConfigDiff  string               `json:"configDiff,omitempty" yaml:"configDiff,omitempty"`
+}
+
+func NewCmdDiffPipelines(config *cfg.Config) *cobra.Command {
+	completer := completer.Completer{Config: config}
+
+	cmd := &cobra.Command{
+		Use:   "pipelines PIPELINE_A PIPELINE_B",
+		Short: "Compare two pipelines",
+		Long: "Compare the config, attached files, secret keys, ports and settings like the\n" +
+			"resource profile of two pipelines, possibly from different environments.\n" +
+			"Secret values are never compared nor printed.",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completer.CompletePipelines,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			a, err := fetchPipelineSnapshot(ctx, config, &completer, args[0], nil)
+			if err != nil {
+				return err
+			}
+
+			// fetch the second config in the same format so they compare line by line.
+			b, err := fetchPipelineSnapshot(ctx, config, &completer, args[1], &a.ConfigFormat)
+			if err != nil {
+				return err
+			}
+
+			diff := diffPipelines(a, b)
+
+			fs := cmd.Flags()
+			outputFormat := formatters.OutputFormatFromFlags(fs)
+			if fn, ok := formatters.ShouldApplyTemplating(outputFormat); ok {
+				return fn(cmd.OutOrStdout(), formatters.TemplateFromFlags(fs), diff)
+			}
+
+			switch outputFormat {
+			case formatters.OutputFormatJSON:
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(diff)
+			case formatters.OutputFormatYAML:
+				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(diff)
+			default:
+				return renderPipelinesDiff(cmd.OutOrStdout(), diff)
+			}
+		},
+	}
+
+	formatters.BindFormatFlags(cmd)
+
+	return cmd
+}
+
+func fetchPipelineSnapshot(ctx context.Context, config *cfg.Config, completer *completer.Completer, key string, format *cloud.ConfigFormat) (pipelineSnapshot, error) {
+	pipelineID, err := completer.LoadPipelineID(key)
+	if err != nil {
+		return pipelineSnapshot{}, err
+	}
+
+	pip, err := config.Cloud.Pipeline(ctx, pipelineID, cloud.PipelineParams{ConfigFormat: format})
+	if err != nil {
+		return pipelineSnapshot{}, fmt.Errorf("could not fetch pipeline %q: %w", key, err)
+	}
+
+	files, err := config.Cloud.PipelineFiles(ctx, pipelineID, cloud.PipelineFilesParams{})
+	if err != nil {
+		return pipelineSnapshot{}, fmt.Errorf("could not fetch pipeline %q files: %w", key, err)
+	}
+
+	secrets, err := config.Cloud.PipelineSecrets(ctx, pipelineID, cloud.PipelineSecretsParams{})
+	if err != nil {
+		return pipelineSnapshot{}, fmt.Errorf("could not fetch pipeline %q secrets: %w", key, err)
+	}
+
+	ports, err := config.Cloud.PipelinePorts(ctx, pipelineID, cloud.PipelinePortsParams{})
+	if err != nil {
+		return pipelineSnapshot{}, fmt.Errorf("could not fetch pipeline %q ports: %w", key, err)
+	}
+
+	out := pipelineSnapshot{
+		Name:               pip.Name,
+		Config:             pip.Config.RawConfig,
+		ConfigFormat:       pip.Config.ConfigFormat,
+		ResourceProfile:    pip.ResourceProfile.Name,
+		ReplicasCount:      pip.ReplicasCount,
+		DeploymentStrategy: pip.DeploymentStrategy,
+		Files:              files.Items,
+		Secrets:            secrets.Items,
+		Ports:              ports.Items,
+	}
+	if pip.Image != nil {
+		out.Image = *pip.Image
+	}
+
+	return out, nil
+}
+
+// diffPipelines compares both pipelines. Differences are sorted by
+// section and name, and the config diff is in unified format.
+func diffPipelines(a, b pipelineSnapshot) pipelinesDiff {
+	out := pipelinesDiff{A: a.Name, B: b.Name}
+
+	add := func(section, name, va, vb string) {
+		if va != vb {
+			out.Differences = append(out.Differences, pipelineDifference{Section: section, Name: name, A: va, B: vb})
+		}
+	}
+
+	add("settings", "config format", string(a.ConfigFormat), string(b.ConfigFormat))
+	add("settings", "deployment strategy", string(a.DeploymentStrategy), string(b.DeploymentStrategy))
+	add("settings", "image", a.Image, b.Image)
+	add("settings", "replicas", strconv.FormatUint(uint64(a.ReplicasCount), 10), strconv.FormatUint(uint64(b.ReplicasCount), 10))
+	add("settings", "resource profile", a.ResourceProfile, b.ResourceProfile)
+
+	filesA, filesB := map[string]cloud.PipelineFile{}, map[string]cloud.PipelineFile{}
+	for _, f := range a.Files {
+		filesA[f.Name] = f
+	}
+	for _, f := range b.Files {
+		filesB[f.Name] = f
+	}
+	for _, name := range unionKeys(filesA, filesB) {
+		fa, inA := filesA[name]
+		fb, inB := filesB[name]
+		switch {
+		case !inA || !inB:
+			add("files", name, presence(inA), presence(inB))
+		case fa.Encrypted || fb.Encrypted:
+			add("files", name, fileEncryption(fa), fileEncryption(fb))
+		case !bytes.Equal(fa.Contents, fb.Contents):
+			add("files", name, fmt.Sprintf("%d bytes", len(fa.Contents)), fmt.Sprintf("%d bytes, contents differ", len(fb.Contents)))
+		}
+	}
+
+	secretsA, secretsB := map[string]struct{}{}, map[string]struct{}{}
+	for _, s := range a.Secrets {
+		secretsA[s.Key] = struct{}{}
+	}
+	for _, s := range b.Secrets {
+		secretsB[s.Key] = struct{}{}
+	}
+	for _, key := range unionKeys(secretsA, secretsB) {
+		_, inA := secretsA[key]
+		_, inB := secretsB[key]
+		add("secrets", key, presence(inA), presence(inB))
+	}
+
+	portsA, portsB := map[string]string{}, map[string]string{}
+	for _, p := range a.Ports {
+		portsA[pipelinePortKey(p)] = pipelinePortValue(p)
+	}
+	for _, p := range b.Ports {
+		portsB[pipelinePortKey(p)] = pipelinePortValue(p)
+	}
+	for _, key := range unionKeys(portsA, portsB) {
+		add("ports", key, portsA[key], portsB[key])
+	}
+
+	if a.Config != b.Config {
+		edits := myers.ComputeEdits(span.URIFromPath(a.Name), a.Config, b.Config)
+		out.ConfigDiff = fmt.Sprint(gotextdiff.ToUnified(a.Name, b.Name, a.Config, edits))
+	}
+
+	return out
+}
+
+func renderPipelinesDiff(w io.Writer, diff pipelinesDiff) error {
+	if len(diff.Differences) == 0 && diff.ConfigDiff == "" {
+		fmt.Fprintf(w, "Pipelines %q and %q are the same\n", diff.A, diff.B)
+		return nil
+	}
+
+	if len(diff.Differences) != 0 {
+		tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+		fmt.Fprintf(tw, "SECTION\tNAME\t%s\t%s\n", diff.A, diff.B)
+		for _, d := range diff.Differences {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", d.Section, d.Name, orDash(d.A), orDash(d.B))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if diff.ConfigDiff != "" {
+		if len(diff.Differences) != 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprint(w, diff.ConfigDiff)
+	}
+
+	return nil
+}
+
+func unionKeys[V any](a, b map[string]V) []string {
+	var out []string
+	for k := range a {
+		out = append(out, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			out = append(out, k)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func presence(ok bool) string {
+	if ok {
+		return "present"
+	}
+	return "missing"
+}
+
+func fileEncryption(f cloud.PipelineFile) string {
+	if f.Encrypted {
+		return "encrypted, not compared"
+	}
+	return fmt.Sprintf("%d bytes", len(f.Contents))
+}
+
+func pipelinePortKey(p cloud.PipelinePort) string {
+	return fmt.Sprintf("%s/%d", p.Protocol, p.FrontendPort)
+}
+
+func pipelinePortValue(p cloud.PipelinePort) string {
+	return fmt.Sprintf("backend %d, %s", p.BackendPort, p.Kind)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
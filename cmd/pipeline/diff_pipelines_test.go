@@ -0,0 +1,59 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+func Test_diffPipelines(t *testing.T) {
+	a := pipelineSnapshot{
+		Name:            "staging",
+		Config:          "[INPUT]\n    Name dummy\n",
+		ConfigFormat:    cloud.ConfigFormatINI,
+		ResourceProfile: "high-performance-guaranteed-delivery",
+		ReplicasCount:   1,
+		Files:           []cloud.PipelineFile{{Name: "parsers", Contents: []byte("a")}, {Name: "lua", Contents: []byte("x")}},
+		Secrets:         []cloud.PipelineSecret{{Key: "token", Value: []byte("a")}},
+		Ports:           []cloud.PipelinePort{{Protocol: "tcp", FrontendPort: 24224, BackendPort: 24224, Kind: "LoadBalancer"}},
+	}
+
+	t.Run("same", func(t *testing.T) {
+		got := diffPipelines(a, a)
+		if len(got.Differences) != 0 || got.ConfigDiff != "" {
+			t.Errorf("expected no differences, got %+v", got)
+		}
+	})
+
+	b := a
+	b.Name = "prod"
+	b.Config = "[INPUT]\n    Name tail\n"
+	b.ResourceProfile = "best-effort-low-resource"
+	b.Files = []cloud.PipelineFile{{Name: "parsers", Contents: []byte("b")}}
+	b.Secrets = []cloud.PipelineSecret{{Key: "token", Value: []byte("b")}, {Key: "password"}}
+	b.Ports = []cloud.PipelinePort{{Protocol: "tcp", FrontendPort: 24224, BackendPort: 24224, Kind: "ClusterIP"}}
+
+	got := diffPipelines(a, b)
+	want := []pipelineDifference{
+		{Section: "settings", Name: "resource profile", A: "high-performance-guaranteed-delivery", B: "best-effort-low-resource"},
+		{Section: "files", Name: "lua", A: "present", B: "missing"},
+		{Section: "files", Name: "parsers", A: "1 bytes", B: "1 bytes, contents differ"},
+		{Section: "secrets", Name: "password", A: "missing", B: "present"},
+		{Section: "ports", Name: "tcp/24224", A: "backend 24224, LoadBalancer", B: "backend 24224, ClusterIP"},
+	}
+
+	if len(got.Differences) != len(want) {
+		t.Fatalf("expected %d differences, got %+v", len(want), got.Differences)
+	}
+
+	for i, d := range want {
+		if got.Differences[i] != d {
+			t.Errorf("difference %d: want %+v, got %+v", i, d, got.Differences[i])
+		}
+	}
+
+	if !strings.Contains(got.ConfigDiff, "-    Name dummy") || !strings.Contains(got.ConfigDiff, "+    Name tail") {
+		t.Errorf("unexpected config diff:\n%s", got.ConfigDiff)
+	}
+}
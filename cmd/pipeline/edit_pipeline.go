@@ -0,0 +1,226 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	fluentbitconfig "github.com/calyptia/go-fluentbit-config/v2"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hexops/gotextdiff"
+	"github.com/hexops/gotextdiff/myers"
+	"github.com/hexops/gotextdiff/span"
+	"github.com/spf13/cobra"
+
+	cloud "github.com/calyptia/api/types"
+	"github.com/calyptia/cli/completer"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/confirm"
+)
+
+const defaultEditor = "vi"
+
+func NewCmdEditPipeline(config *cfg.Config) *cobra.Command {
+	var confirmed bool
+	var skipConfigValidation bool
+	completer := completer.Completer{Config: config}
+
+	cmd := &cobra.Command{
+		Use:   "pipeline PIPELINE",
+		Short: "Edit a pipeline config in your editor",
+		Long: "Open the current pipeline config in $VISUAL or $EDITOR (vi by default),\n" +
+			"validate it once saved, show the diff and push it on confirmation.\n" +
+			"Exiting the editor without changes cancels the edit.",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completer.CompletePipelines,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pipelineID, err := completer.LoadPipelineID(args[0])
+			if err != nil {
+				return err
+			}
+
+			pip, err := config.Cloud.Pipeline(config.Ctx, pipelineID, cloud.PipelineParams{})
+			if err != nil {
+				return fmt.Errorf("could not fetch pipeline: %w", err)
+			}
+
+			format := pip.Config.ConfigFormat
+			if format == "" {
+				format = cloud.ConfigFormatINI
+			}
+
+			current := pip.Config.RawConfig
+			edited := current
+			for {
+				edited, err = editConfig(cmd, edited, format)
+				if err != nil {
+					return err
+				}
+
+				if edited == current {
+					cmd.Println("Edit cancelled, no changes made.")
+					return nil
+				}
+
+				err = validateEditedConfig(edited, format)
+				if err == nil {
+					break
+				}
+
+				cmd.PrintErrf("Invalid config: %v\n", err)
+				cmd.Print("Reopen the editor to fix it? (y/N) ")
+				reopen, err := confirm.Read(cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+
+				if !reopen {
+					return errors.New("edit aborted, the pipeline config was left unchanged")
+				}
+			}
+
+			fmt.Fprint(cmd.OutOrStdout(), colorizeDiff(configDiff(pip.Name, current, edited)))
+
+			if !confirmed {
+				cmd.Printf("Push the new config to %q? (y/N) ", pip.Name)
+				ok, err := confirm.Read(cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+
+				if !ok {
+					cmd.Println("Aborted")
+					return nil
+				}
+			}
+
+			_, err = config.Cloud.UpdatePipeline(config.Ctx, pipelineID, cloud.UpdatePipeline{
+				RawConfig:            &edited,
+				ConfigFormat:         &format,
+				SkipConfigValidation: skipConfigValidation,
+			})
+			if err != nil {
+				return fmt.Errorf("could not update pipeline: %w", err)
+			}
+
+			cmd.Printf("Pipeline %q config updated\n", pip.Name)
+			return nil
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.BoolVarP(&confirmed, "yes", "y", false, "Push the edited config without asking for confirmation")
+	fs.BoolVar(&skipConfigValidation, "skip-config-validation", false, "Opt-in to skip config validation (Use with caution as this option might be removed soon)")
+
+	return cmd
+}
+
+// editConfig opens the given config in the user editor
+// and returns its contents once the editor exits.
+func editConfig(cmd *cobra.Command, rawConfig string, format cloud.ConfigFormat) (string, error) {
+	f, err := os.CreateTemp("", "calyptia-pipeline-*"+configFileExt(format))
+	if err != nil {
+		return "", fmt.Errorf("could not create temporary config file: %w", err)
+	}
+
+	defer os.Remove(f.Name())
+
+	if _, err := io.WriteString(f, rawConfig); err != nil {
+		f.Close()
+		return "", fmt.Errorf("could not write temporary config file: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("could not write temporary config file: %w", err)
+	}
+
+	editor := editorCommand(os.Getenv)
+	c := exec.CommandContext(cmd.Context(), editor[0], append(editor[1:], f.Name())...)
+	c.Stdin = cmd.InOrStdin()
+	c.Stdout = cmd.OutOrStdout()
+	c.Stderr = cmd.ErrOrStderr()
+	if err := c.Run(); err != nil {
+		return "", fmt.Errorf("could not run editor %q: %w", strings.Join(editor, " "), err)
+	}
+
+	b, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", fmt.Errorf("could not read edited config file: %w", err)
+	}
+
+	return string(b), nil
+}
+
+// editorCommand returns the user editor from $VISUAL or $EDITOR,
+// which may include arguments, like "code --wait".
+func editorCommand(getenv func(string) string) []string {
+	for _, key := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(getenv(key)); len(fields) != 0 {
+			return fields
+		}
+	}
+	return []string{defaultEditor}
+}
+
+func configFileExt(format cloud.ConfigFormat) string {
+	switch format {
+	case cloud.ConfigFormatYAML:
+		return ".yaml"
+	case cloud.ConfigFormatJSON:
+		return ".json"
+	default:
+		return ".conf"
+	}
+}
+
+func validateEditedConfig(rawConfig string, format cloud.ConfigFormat) error {
+	if strings.TrimSpace(rawConfig) == "" {
+		return errors.New("config is empty")
+	}
+
+	_, err := fluentbitconfig.ParseAs(rawConfig, fluentbitconfig.Format(format))
+	return err
+}
+
+// configDiff returns the unified diff between the current and edited configs.
+func configDiff(name, current, edited string) string {
+	return unifiedDiff(name+" (current)", name+" (edited)", current, edited)
+}
+
+func unifiedDiff(fromLabel, toLabel, from, to string) string {
+	edits := myers.ComputeEdits(span.URIFromPath(fromLabel), from, to)
+	return fmt.Sprint(gotextdiff.ToUnified(fromLabel, toLabel, from, edits))
+}
+
+var (
+	diffAddedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("2")).TabWidth(lipgloss.NoTabConversion)
+	diffRemovedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).TabWidth(lipgloss.NoTabConversion)
+	diffHunkStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("6")).TabWidth(lipgloss.NoTabConversion)
+)
+
+// colorizeDiff colors the added, removed and hunk header lines of
+// a unified diff. Colors are dropped when the output is not a terminal.
+func colorizeDiff(diff string) string {
+	lines := strings.SplitAfter(diff, "\n")
+	for i, line := range lines {
+		text := strings.TrimSuffix(line, "\n")
+		var style *lipgloss.Style
+		switch {
+		case strings.HasPrefix(text, "+++"), strings.HasPrefix(text, "---"):
+			continue
+		case strings.HasPrefix(text, "+"):
+			style = &diffAddedStyle
+		case strings.HasPrefix(text, "-"):
+			style = &diffRemovedStyle
+		case strings.HasPrefix(text, "@@"):
+			style = &diffHunkStyle
+		default:
+			continue
+		}
+		lines[i] = style.Render(text) + strings.TrimPrefix(line, text)
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,66 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+func TestEditorCommand(t *testing.T) {
+	tt := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{name: "default", want: "vi"},
+		{name: "editor", env: map[string]string{"EDITOR": "nano"}, want: "nano"},
+		{name: "visual first", env: map[string]string{"VISUAL": "code --wait", "EDITOR": "nano"}, want: "code --wait"},
+		{name: "blank visual", env: map[string]string{"VISUAL": " ", "EDITOR": "nano"}, want: "nano"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := editorCommand(func(key string) string { return tc.env[key] })
+			if strings.Join(got, " ") != tc.want {
+				t.Errorf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateEditedConfig(t *testing.T) {
+	if err := validateEditedConfig("[INPUT]\n    Name dummy\n", cloud.ConfigFormatINI); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	if err := validateEditedConfig("  \n", cloud.ConfigFormatINI); err == nil {
+		t.Error("expected empty config to fail")
+	}
+
+	if err := validateEditedConfig("pipeline: [", cloud.ConfigFormatYAML); err == nil {
+		t.Error("expected invalid yaml config to fail")
+	}
+}
+
+func TestConfigDiff(t *testing.T) {
+	got := configDiff("test", "[INPUT]\n    Name dummy\n", "[INPUT]\n    Name cpu\n")
+	for _, want := range []string{"--- test (current)", "+++ test (edited)", "-    Name dummy", "+    Name cpu"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected diff to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestColorizeDiff(t *testing.T) {
+	diff := configDiff("test", "[INPUT]\n\tName dummy\n", "[INPUT]\n\tName cpu\n")
+	got := colorizeDiff(diff)
+	for _, want := range []string{"--- test (current)\n", "+++ test (edited)\n", "-\tName dummy", "+\tName cpu"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected colorized diff to contain %q, got:\n%s", want, got)
+		}
+	}
+
+	if strings.Count(got, "\n") != strings.Count(diff, "\n") {
+		t.Errorf("expected colorized diff to keep its lines, got:\n%s", got)
+	}
+}
@@ -1,6 +1,7 @@
 package pipeline
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -13,7 +14,11 @@ import (
 	cloud "github.com/calyptia/api/types"
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/exitcode"
 	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/labels"
+	"github.com/calyptia/cli/pagination"
+	"github.com/calyptia/cli/redact"
 )
 
 func NewCmdGetPipelines(config *cfg.Config) *cobra.Command {
@@ -23,6 +28,7 @@ func NewCmdGetPipelines(config *cfg.Config) *cobra.Command {
 	var showIDs bool
 	var environment string
 	var renderWithConfigSections bool
+	var selector string
 
 	completer := completer.Completer{Config: config}
 
@@ -48,18 +54,37 @@ func NewCmdGetPipelines(config *cfg.Config) *cobra.Command {
 					return fmt.Errorf("not a valid config format: %s", configFormat)
 				}
 			}
-			pp, err := config.Cloud.Pipelines(config.Ctx, cloud.PipelinesParams{
-				Last:                     &last,
-				RenderWithConfigSections: renderWithConfigSections,
-				CoreInstanceID:           &coreInstanceID,
-				ConfigFormat:             (*cloud.ConfigFormat)(&configFormat),
+
+			sel, err := labels.ParseSelector(selector)
+			if err != nil {
+				return err
+			}
+
+			items, next, err := pagination.List(config.Ctx, pagination.FromFlags(cmd), func(ctx context.Context, last uint, before *string) (pagination.Page[cloud.Pipeline], error) {
+				pp, err := config.Cloud.Pipelines(ctx, cloud.PipelinesParams{
+					Last:                     &last,
+					Before:                   before,
+					RenderWithConfigSections: renderWithConfigSections,
+					CoreInstanceID:           &coreInstanceID,
+					ConfigFormat:             (*cloud.ConfigFormat)(&configFormat),
+				})
+				return pagination.Page[cloud.Pipeline]{Items: pp.Items, EndCursor: pp.EndCursor}, err
 			})
 			if err != nil {
 				return fmt.Errorf("could not fetch your pipelines: %w", err)
 			}
 
-			if strings.HasPrefix(outputFormat, "go-template") {
-				return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, pp.Items)
+			pp := cloud.Pipelines{Items: items, EndCursor: next}
+			defer pagination.PrintNext(cmd, next)
+
+			pp.Items = filterPipelines(pp.Items, sel)
+
+			if err := exitcode.FailOnEmpty(cmd, len(pp.Items)); err != nil {
+				return err
+			}
+
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, pp.Items)
 			}
 
 			switch outputFormat {
@@ -77,9 +102,9 @@ func NewCmdGetPipelines(config *cfg.Config) *cobra.Command {
 				}
 				tw.Flush()
 			case "json":
-				return json.NewEncoder(cmd.OutOrStdout()).Encode(pp.Items)
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(redact.FromFlags(cmd).Value(pp.Items))
 			case "yml", "yaml":
-				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(pp.Items)
+				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(redact.FromFlags(cmd).Value(pp.Items))
 			default:
 				return fmt.Errorf("unknown output format %q", outputFormat)
 			}
@@ -90,11 +115,14 @@ func NewCmdGetPipelines(config *cfg.Config) *cobra.Command {
 	fs := cmd.Flags()
 	fs.StringVar(&coreInstanceKey, "core-instance", "", "Parent core-instance ID or name")
 	fs.UintVarP(&last, "last", "l", 0, "Last `N` pipelines. 0 means no limit")
+	pagination.BindFlags(cmd)
+	_ = fs.MarkDeprecated("last", "use --limit instead")
 	fs.BoolVar(&showIDs, "show-ids", false, "Include pipeline IDs in table output")
 	fs.StringVar(&environment, "environment", "", "Calyptia environment name")
 	fs.BoolVar(&renderWithConfigSections, "render-with-config-sections", false, "Render the pipeline config with the attached config sections; if any")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	fs.StringVar(&selector, "selector", "", "Label selector to filter pipelines on. Supports key=value, key!=value, key and !key separated by commas")
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 	fs.StringVar(&configFormat, "config-format", string(cloud.ConfigFormatYAML), "Format to get the configuration file from the API (yaml/json/ini).")
 
 	_ = cmd.RegisterFlagCompletionFunc("environment", completer.CompleteEnvironments)
@@ -103,9 +131,25 @@ func NewCmdGetPipelines(config *cfg.Config) *cobra.Command {
 
 	_ = cmd.MarkFlagRequired("core-instance") // TODO: use default core instance ID from config cmd.
 
+	exitcode.BindFailOnEmptyFlag(cmd)
+
 	return cmd
 }
 
+func filterPipelines(pp []cloud.Pipeline, sel labels.Selector) []cloud.Pipeline {
+	if len(sel) == 0 {
+		return pp
+	}
+
+	var out []cloud.Pipeline
+	for _, p := range pp {
+		if sel.Matches(p.Tags) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
 func NewCmdGetPipeline(config *cfg.Config) *cobra.Command {
 	var onlyConfig bool
 	var lastEndpoints, lastConfigHistory, lastSecrets uint
@@ -205,12 +249,12 @@ func NewCmdGetPipeline(config *cfg.Config) *cobra.Command {
 			}
 
 			if onlyConfig {
-				fmt.Println(strings.TrimSpace(pip.Config.RawConfig))
+				fmt.Fprintln(cmd.OutOrStdout(), strings.TrimSpace(redact.FromFlags(cmd).Config(pip.Config.RawConfig)))
 				return nil
 			}
 
-			if strings.HasPrefix(outputFormat, "go-template") {
-				return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, pip)
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, pip)
 			}
 
 			switch outputFormat {
@@ -240,9 +284,9 @@ func NewCmdGetPipeline(config *cfg.Config) *cobra.Command {
 					renderPipelineSecrets(cmd.OutOrStdout(), secrets, showIDs)
 				}
 			case "json":
-				return json.NewEncoder(cmd.OutOrStdout()).Encode(pip)
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(redact.FromFlags(cmd).Value(pip))
 			case "yml", "yaml":
-				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(pip)
+				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(redact.FromFlags(cmd).Value(pip))
 			default:
 				return fmt.Errorf("unknown output format %q", outputFormat)
 			}
@@ -259,8 +303,8 @@ func NewCmdGetPipeline(config *cfg.Config) *cobra.Command {
 	fs.UintVar(&lastConfigHistory, "last-config-history", 0, "Last `N` pipeline config history if included. 0 means no limit")
 	fs.UintVar(&lastSecrets, "last-secrets", 0, "Last `N` pipeline secrets if included. 0 means no limit")
 	fs.BoolVar(&renderWithConfigSections, "render-with-config-sections", false, "Render the pipeline config with the attached config sections; if any")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 	fs.StringVar(&configFormat, "config-format", string(cloud.ConfigFormatYAML), "Format to get the configuration file from the API (yaml/json/ini).")
 	fs.BoolVar(&showIDs, "show-ids", false, "Include IDs in table output")
 
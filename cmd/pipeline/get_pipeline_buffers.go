@@ -0,0 +1,242 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"code.cloudfoundry.org/bytefmt"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	cloud "github.com/calyptia/api/types"
+	"github.com/calyptia/cli/completer"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/metric"
+)
+
+var bufferOverThresholdStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true)
+
+// PipelineBuffer holds the latest buffering metrics of a pipeline plugin.
+// Metrics not reported by the plugin fluent-bit version are left nil.
+type PipelineBuffer struct {
+	Plugin string `json:"plugin" yaml:"plugin"`
+	Kind   string `json:"kind" yaml:"kind"`
+	// Chunks is the total number of chunks, ChunksUp those loaded in memory
+	// and ChunksDown those only in the filesystem buffer.
+	Chunks     *float64 `json:"chunks" yaml:"chunks"`
+	ChunksUp   *float64 `json:"chunksUp" yaml:"chunksUp"`
+	ChunksDown *float64 `json:"chunksDown" yaml:"chunksDown"`
+	// ChunksBusy are the chunks being flushed to the outputs.
+	ChunksBusy  *float64 `json:"chunksBusy" yaml:"chunksBusy"`
+	BusyBytes   *float64 `json:"busyBytes" yaml:"busyBytes"`
+	MemoryBytes *float64 `json:"memoryBytes" yaml:"memoryBytes"`
+	// AvailableCapacity is the percentage of chunks an output can still take.
+	AvailableCapacity *float64 `json:"availableCapacity" yaml:"availableCapacity"`
+	// Backpressure is set when an input hit its memory buffer limit and got paused.
+	Backpressure  bool `json:"backpressure" yaml:"backpressure"`
+	OverThreshold bool `json:"overThreshold" yaml:"overThreshold"`
+}
+
+func NewCmdGetPipelineBuffers(config *cfg.Config) *cobra.Command {
+	var timeRange time.Duration
+	var threshold uint
+	var outputFormat, goTemplate string
+	completer := completer.Completer{Config: config}
+
+	cmd := &cobra.Command{
+		Use:   "pipeline_buffers PIPELINE",
+		Short: "Display the buffer usage and backpressure of a pipeline plugins",
+		Long: "Display the latest chunk counts, memory and filesystem buffer usage, and\n" +
+			"backpressure of each input and output of a pipeline, from its metrics.\n" +
+			"Plugins with backpressure, or whose busy chunks or used output capacity\n" +
+			"reach --threshold percent, are highlighted: the usual cause of delayed logs.",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completer.CompletePipelines,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if threshold > 100 {
+				return fmt.Errorf("threshold must be a percentage between 0 and 100, got %d", threshold)
+			}
+
+			pipelineID, err := completer.LoadPipelineID(args[0])
+			if err != nil {
+				return err
+			}
+
+			m, err := config.Cloud.PipelineMetricsV1(cmd.Context(), pipelineID, cloud.MetricsParams{
+				Start:    -timeRange,
+				Interval: time.Minute,
+			})
+			if err != nil {
+				return fmt.Errorf("could not fetch your pipeline metrics: %w", err)
+			}
+
+			buffers := pipelineBuffers(m, float64(threshold))
+
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, buffers)
+			}
+
+			switch outputFormat {
+			case "table":
+				if len(buffers) == 0 {
+					cmd.PrintErrln("No buffer metrics reported by the pipeline")
+					return nil
+				}
+				return renderPipelineBuffers(cmd.OutOrStdout(), buffers)
+			case "json":
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(buffers)
+			case "yml", "yaml":
+				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(buffers)
+			default:
+				return fmt.Errorf("unknown output format %q", outputFormat)
+			}
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.DurationVar(&timeRange, "range", time.Minute*15, "Time range to look for the latest metrics in, counting back from now")
+	fs.UintVar(&threshold, "threshold", 80, "Percentage of busy chunks, or used output capacity, over which a plugin is highlighted")
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
+
+	_ = cmd.RegisterFlagCompletionFunc("output-format", formatters.CompleteOutputFormat)
+
+	return cmd
+}
+
+// pipelineBuffers collects the buffering metrics of each plugin, inputs first.
+// Metric names are matched by suffix, as fluent-bit reports them either as
+// fields of the plugin kind measurement, ie: fluentbit_input storage_chunks_up,
+// or as measurements of their own, ie: fluentbit_input_storage chunks_up.
+func pipelineBuffers(m cloud.AgentMetrics, threshold float64) []PipelineBuffer {
+	var out []PipelineBuffer
+	for _, kind := range []string{"input", "output"} {
+		byPlugin := map[string]*PipelineBuffer{}
+		var names []string
+
+		for _, measurementName := range metric.MeasurementNames(m.Measurements) {
+			if !strings.HasPrefix(measurementName, "fluentbit_"+kind) {
+				continue
+			}
+
+			measurement := m.Measurements[measurementName]
+			for _, pluginName := range metric.MetricPluginNames(measurement.Plugins) {
+				// skip internal metrics.
+				if strings.HasPrefix(pluginName, "fluentbit_metrics.") || strings.HasPrefix(pluginName, "calyptia.") {
+					continue
+				}
+
+				for metricName, points := range measurement.Plugins[pluginName].Metrics {
+					v := lastMetricValue(points)
+					if v == nil {
+						continue
+					}
+
+					b, ok := byPlugin[pluginName]
+					if !ok {
+						b = &PipelineBuffer{Plugin: pluginName, Kind: kind}
+						byPlugin[pluginName] = b
+						names = append(names, pluginName)
+					}
+
+					b.apply(measurementName+"_"+metricName, v)
+				}
+			}
+		}
+
+		for _, name := range names {
+			b := byPlugin[name]
+			if b.hasBufferMetrics() {
+				b.OverThreshold = b.overThreshold(threshold)
+				out = append(out, *b)
+			}
+		}
+	}
+	return out
+}
+
+func (b *PipelineBuffer) apply(name string, v *float64) {
+	switch {
+	case strings.HasSuffix(name, "chunks_busy_bytes"):
+		b.BusyBytes = v
+	case strings.HasSuffix(name, "chunks_busy"):
+		b.ChunksBusy = v
+	case strings.HasSuffix(name, "chunks_up"):
+		b.ChunksUp = v
+	case strings.HasSuffix(name, "chunks_down"):
+		b.ChunksDown = v
+	case strings.HasSuffix(name, "storage_chunks"):
+		b.Chunks = v
+	case strings.HasSuffix(name, "storage_memory_bytes"):
+		b.MemoryBytes = v
+	case strings.HasSuffix(name, "chunk_available_capacity_percent"):
+		b.AvailableCapacity = v
+	case strings.HasSuffix(name, "storage_overlimit"), strings.HasSuffix(name, "ingestion_paused"):
+		b.Backpressure = b.Backpressure || *v > 0
+	}
+}
+
+func (b PipelineBuffer) hasBufferMetrics() bool {
+	return b.Chunks != nil || b.ChunksUp != nil || b.ChunksDown != nil || b.ChunksBusy != nil ||
+		b.MemoryBytes != nil || b.AvailableCapacity != nil || b.Backpressure
+}
+
+func (b PipelineBuffer) overThreshold(threshold float64) bool {
+	if b.Backpressure {
+		return true
+	}
+
+	if b.Chunks != nil && b.ChunksBusy != nil && *b.Chunks > 0 && *b.ChunksBusy / *b.Chunks * 100 >= threshold {
+		return true
+	}
+
+	return b.AvailableCapacity != nil && 100-*b.AvailableCapacity >= threshold
+}
+
+// lastMetricValue returns the latest reported value of a gauge.
+func lastMetricValue(points []cloud.MetricFields) *float64 {
+	for i := len(points) - 1; i >= 0; i-- {
+		if points[i].Value != nil {
+			return points[i].Value
+		}
+	}
+	return nil
+}
+
+func renderPipelineBuffers(w io.Writer, buffers []PipelineBuffer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "PLUGIN\tKIND\tCHUNKS\tMEM-CHUNKS\tFS-CHUNKS\tBUSY-CHUNKS\tMEMORY\tCAPACITY\tBACKPRESSURE\tSTATUS")
+	for _, b := range buffers {
+		status := "ok"
+		if b.OverThreshold {
+			status = bufferOverThresholdStyle.Render("over threshold")
+		}
+
+		capacity := "-"
+		if b.AvailableCapacity != nil {
+			capacity = fmt.Sprintf("%.0f%%", *b.AvailableCapacity)
+		}
+
+		memory := "-"
+		if b.MemoryBytes != nil {
+			memory = bytefmt.ByteSize(uint64(math.Round(*b.MemoryBytes)))
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%t\t%s\n", b.Plugin, b.Kind, fmtBufferCount(b.Chunks), fmtBufferCount(b.ChunksUp), fmtBufferCount(b.ChunksDown), fmtBufferCount(b.ChunksBusy), memory, capacity, b.Backpressure, status)
+	}
+	return tw.Flush()
+}
+
+func fmtBufferCount(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f", *v)
+}
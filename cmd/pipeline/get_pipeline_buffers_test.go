@@ -0,0 +1,64 @@
+package pipeline
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+func Test_pipelineBuffers(t *testing.T) {
+	now := time.Now()
+	points := func(plugin, field string, values ...float64) []cloud.MetricFields {
+		var out []cloud.MetricFields
+		for i, v := range values {
+			v := v
+			out = append(out, cloud.MetricFields{Time: now.Add(time.Duration(i) * time.Minute), Value: &v, Field: field, Plugin: plugin})
+		}
+		return out
+	}
+
+	var m cloud.AgentMetrics
+	var inputs []cloud.MetricFields
+	inputs = append(inputs, points("tail.0", "records", 10, 20)...)
+	inputs = append(inputs, points("tail.0", "storage_chunks", 10, 10)...)
+	inputs = append(inputs, points("tail.0", "storage_chunks_busy", 2, 9)...)
+	inputs = append(inputs, points("tail.0", "storage_chunks_down", 1, 4)...)
+	inputs = append(inputs, points("tail.0", "storage_memory_bytes", 1024, 2048)...)
+	inputs = append(inputs, points("dummy.0", "storage_chunks", 1, 1)...)
+	inputs = append(inputs, points("dummy.0", "storage_overlimit", 0, 0)...)
+	inputs = append(inputs, points("cpu.0", "records", 1, 2)...)
+	inputs = append(inputs, points("fluentbit_metrics.0", "storage_chunks", 1, 1)...)
+	m.AddMeasurementMetrics("fluentbit_input", inputs, nil)
+	m.AddMeasurementMetrics("fluentbit_input_storage", points("dummy.0", "overlimit", 0, 1), nil)
+	m.AddMeasurementMetrics("fluentbit_output", points("http.0", "chunk_available_capacity_percent", 90, 50), nil)
+
+	got := pipelineBuffers(m, 80)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 plugins with buffer metrics, got %+v", got)
+	}
+
+	dummy, tail, http := got[0], got[1], got[2]
+	if dummy.Plugin != "dummy.0" || !dummy.Backpressure || !dummy.OverThreshold {
+		t.Errorf("expected dummy.0 with backpressure, got %+v", dummy)
+	}
+
+	if tail.Plugin != "tail.0" || *tail.ChunksBusy != 9 || *tail.ChunksDown != 4 || *tail.MemoryBytes != 2048 || !tail.OverThreshold {
+		t.Errorf("expected tail.0 latest values over threshold, got %+v", tail)
+	}
+
+	if http.Kind != "output" || *http.AvailableCapacity != 50 || http.OverThreshold {
+		t.Errorf("expected http.0 output under threshold, got %+v", http)
+	}
+
+	var buf bytes.Buffer
+	if err := renderPipelineBuffers(&buf, got); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "over threshold") || !strings.Contains(buf.String(), "50%") {
+		t.Errorf("unexpected table:\n%s", buf.String())
+	}
+}
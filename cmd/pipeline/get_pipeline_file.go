@@ -4,8 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
@@ -13,12 +13,15 @@ import (
 	cloud "github.com/calyptia/api/types"
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/download"
+	"github.com/calyptia/cli/exitcode"
 	"github.com/calyptia/cli/formatters"
 )
 
 func NewCmdGetPipelineFiles(config *cfg.Config) *cobra.Command {
 	var pipelineKey string
 	var last uint
+	var modifiedSince time.Duration
 	var outputFormat, goTemplate string
 	var showIDs bool
 	completer := completer.Completer{Config: config}
@@ -39,8 +42,14 @@ func NewCmdGetPipelineFiles(config *cfg.Config) *cobra.Command {
 				return fmt.Errorf("could not fetch your pipeline files: %w", err)
 			}
 
-			if strings.HasPrefix(outputFormat, "go-template") {
-				return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, ff.Items)
+			ff.Items = filterModifiedSince(ff.Items, modifiedSince, time.Now(), pipelineFileModifiedAt)
+
+			if err := exitcode.FailOnEmpty(cmd, len(ff.Items)); err != nil {
+				return err
+			}
+
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, ff.Items)
 			}
 
 			switch outputFormat {
@@ -60,15 +69,18 @@ func NewCmdGetPipelineFiles(config *cfg.Config) *cobra.Command {
 	fs := cmd.Flags()
 	fs.StringVar(&pipelineKey, "pipeline", "", "Parent pipeline ID or name")
 	fs.UintVarP(&last, "last", "l", 0, "Last `N` pipeline files. 0 means no limit")
+	fs.DurationVar(&modifiedSince, "modified-since", 0, "Only list pipeline files created or updated within the given duration, like 24h. 0 means no filter")
 	fs.BoolVar(&showIDs, "show-ids", false, "Include status IDs in table output")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 
 	_ = cmd.RegisterFlagCompletionFunc("output-format", formatters.CompleteOutputFormat)
 	_ = cmd.RegisterFlagCompletionFunc("pipeline", completer.CompletePipelines)
 
 	_ = cmd.MarkFlagRequired("pipeline") // TODO: use default pipeline key from config cmd.
 
+	exitcode.BindFailOnEmptyFlag(cmd)
+
 	return cmd
 }
 
@@ -107,6 +119,15 @@ func NewCmdGetPipelineFile(config *cfg.Config) *cobra.Command {
 				return fmt.Errorf("could not find pipeline file %q", name)
 			}
 
+			checksum, err := download.ChecksumFromFlags(cmd.Flags(), file.Name)
+			if err != nil {
+				return err
+			}
+
+			if err := download.Verify(file.Name, file.Contents, checksum); err != nil {
+				return err
+			}
+
 			if onlyContents {
 				if !file.Encrypted {
 					cmd.Print(string(file.Contents))
@@ -116,8 +137,8 @@ func NewCmdGetPipelineFile(config *cfg.Config) *cobra.Command {
 				return nil
 			}
 
-			if strings.HasPrefix(outputFormat, "go-template") {
-				return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, file)
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, file)
 			}
 
 			switch outputFormat {
@@ -126,11 +147,11 @@ func NewCmdGetPipelineFile(config *cfg.Config) *cobra.Command {
 				if showIDs {
 					fmt.Fprint(tw, "ID\t")
 				}
-				fmt.Fprintln(tw, "NAME\tENCRYPTED\tAGE")
+				fmt.Fprintln(tw, "NAME\tENCRYPTED\tAGE\tUPDATED")
 				if showIDs {
 					fmt.Fprintf(tw, "%s\t", file.ID)
 				}
-				fmt.Fprintf(tw, "%s\t%v\t%s\n", file.Name, file.Encrypted, formatters.FmtTime(file.CreatedAt))
+				fmt.Fprintf(tw, "%s\t%v\t%s\t%s\n", file.Name, file.Encrypted, formatters.FmtTime(file.CreatedAt), formatters.FmtTime(pipelineFileModifiedAt(file)))
 				tw.Flush()
 			case "json":
 				return json.NewEncoder(cmd.OutOrStdout()).Encode(file)
@@ -148,8 +169,9 @@ func NewCmdGetPipelineFile(config *cfg.Config) *cobra.Command {
 	fs.StringVar(&name, "name", "", "File name")
 	fs.BoolVar(&showIDs, "show-ids", false, "Include status IDs in table output")
 	fs.BoolVar(&onlyContents, "only-contents", false, "Only print file contents")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	download.BindChecksumFlags(cmd)
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 
 	_ = cmd.RegisterFlagCompletionFunc("pipeline", completer.CompletePipelines)
 	_ = cmd.RegisterFlagCompletionFunc("output-format", formatters.CompleteOutputFormat)
@@ -165,12 +187,12 @@ func renderPipelineFiles(w io.Writer, ff []cloud.PipelineFile, showIDs bool) {
 	if showIDs {
 		fmt.Fprint(tw, "ID\t")
 	}
-	fmt.Fprintln(tw, "NAME\tENCRYPTED\tAGE")
+	fmt.Fprintln(tw, "NAME\tENCRYPTED\tAGE\tUPDATED")
 	for _, f := range ff {
 		if showIDs {
 			fmt.Fprintf(tw, "%s\t", f.ID)
 		}
-		fmt.Fprintf(tw, "%s\t%v\t%s\n", f.Name, f.Encrypted, formatters.FmtTime(f.CreatedAt))
+		fmt.Fprintf(tw, "%s\t%v\t%s\t%s\n", f.Name, f.Encrypted, formatters.FmtTime(f.CreatedAt), formatters.FmtTime(pipelineFileModifiedAt(f)))
 	}
 	tw.Flush()
 }
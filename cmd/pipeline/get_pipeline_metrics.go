@@ -0,0 +1,172 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	cloud "github.com/calyptia/api/types"
+	"github.com/calyptia/cli/completer"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/metric"
+)
+
+// PipelineMetricSeries is a single metric over the queried time range.
+type PipelineMetricSeries struct {
+	Name   string                 `json:"name" yaml:"name"`
+	Points []cloud.MetricOverTime `json:"points" yaml:"points"`
+}
+
+func NewCmdGetPipelineMetrics(config *cfg.Config) *cobra.Command {
+	var pipelineKey string
+	var timeRange, step time.Duration
+	var outputFormat, goTemplate string
+	completer := completer.Completer{Config: config}
+
+	cmd := &cobra.Command{
+		Use:   "pipeline_metrics",
+		Short: "Display historical metrics from a pipeline",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if timeRange <= 0 || step <= 0 {
+				return fmt.Errorf("range and step must be positive durations")
+			}
+
+			if step > timeRange {
+				return fmt.Errorf("step %s cannot be greater than range %s", step, timeRange)
+			}
+
+			pipelineID, err := completer.LoadPipelineID(pipelineKey)
+			if err != nil {
+				return err
+			}
+
+			m, err := config.Cloud.PipelineOverTimeMetrics(config.Ctx, pipelineID, cloud.MetricsParams{
+				Start:    -timeRange,
+				Interval: step,
+			})
+			if err != nil {
+				return fmt.Errorf("could not fetch your pipeline metrics: %w", err)
+			}
+
+			series := pipelineMetricSeries(m)
+
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, series)
+			}
+
+			switch outputFormat {
+			case "table":
+				return renderPipelineMetricsTable(cmd.OutOrStdout(), series)
+			case "sparkline":
+				return renderPipelineMetricsSparklines(cmd.OutOrStdout(), series)
+			case "json":
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(series)
+			case "yml", "yaml":
+				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(series)
+			default:
+				return fmt.Errorf("unknown output format %q", outputFormat)
+			}
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.StringVar(&pipelineKey, "pipeline", "", "Parent pipeline ID or name")
+	fs.DurationVar(&timeRange, "range", time.Hour, "Time range to query metrics for, counting back from now")
+	fs.DurationVar(&step, "step", time.Minute, "Interval between each metric point")
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, sparkline, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
+
+	_ = cmd.RegisterFlagCompletionFunc("output-format", formatters.CompleteOutputFormat)
+	_ = cmd.RegisterFlagCompletionFunc("pipeline", completer.CompletePipelines)
+
+	_ = cmd.MarkFlagRequired("pipeline") // TODO: use default pipeline key from config cmd.
+
+	return cmd
+}
+
+func pipelineMetricSeries(m cloud.MetricsOverTime) []PipelineMetricSeries {
+	return []PipelineMetricSeries{
+		{Name: "input_bytes", Points: m.Input.Bytes},
+		{Name: "input_records", Points: m.Input.Records},
+		{Name: "output_bytes", Points: m.Output.Bytes},
+		{Name: "output_records", Points: m.Output.Records},
+		{Name: "output_errors", Points: m.Output.Errors},
+		{Name: "output_retries", Points: m.Output.Retries},
+	}
+}
+
+func renderPipelineMetricsTable(w io.Writer, series []PipelineMetricSeries) error {
+	// Points of each series are not guaranteed to share timestamps,
+	// so rows are keyed by time.
+	var times []time.Time
+	values := map[time.Time]map[string]*float64{}
+	for _, s := range series {
+		for _, p := range s.Points {
+			if _, ok := values[p.Time]; !ok {
+				values[p.Time] = map[string]*float64{}
+				times = append(times, p.Time)
+			}
+			values[p.Time][s.Name] = p.Value
+		}
+	}
+
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprint(tw, "TIME")
+	for _, s := range series {
+		fmt.Fprintf(tw, "\t%s", strings.ToUpper(strings.ReplaceAll(s.Name, "_", "-")))
+	}
+	fmt.Fprintln(tw)
+	for _, t := range times {
+		fmt.Fprint(tw, formatters.FmtTimestamp(t))
+		for _, s := range series {
+			fmt.Fprintf(tw, "\t%s", fmtMetricValue(values[t][s.Name]))
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+func renderPipelineMetricsSparklines(w io.Writer, series []PipelineMetricSeries) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "METRIC\tMIN\tMAX\tLAST\tTREND")
+	for _, s := range series {
+		lo, hi, last := metricStats(s.Points)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", s.Name, fmtMetricValue(lo), fmtMetricValue(hi), fmtMetricValue(last), metric.Sparkline(s.Points))
+	}
+	return tw.Flush()
+}
+
+func metricStats(points []cloud.MetricOverTime) (lo, hi, last *float64) {
+	for _, p := range points {
+		if p.Value == nil {
+			continue
+		}
+		if lo == nil || *p.Value < *lo {
+			lo = p.Value
+		}
+		if hi == nil || *p.Value > *hi {
+			hi = p.Value
+		}
+		last = p.Value
+	}
+	return lo, hi, last
+}
+
+func fmtMetricValue(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", *v)
+}
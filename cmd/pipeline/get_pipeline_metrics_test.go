@@ -0,0 +1,57 @@
+package pipeline
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+func Test_renderPipelineMetricsTable(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	t0 := time.Date(2023, 11, 9, 13, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Minute)
+
+	series := []PipelineMetricSeries{
+		{Name: "input_bytes", Points: []cloud.MetricOverTime{{Time: t1, Value: f(20)}, {Time: t0, Value: f(10)}}},
+		// points of each series do not always share timestamps.
+		{Name: "output_errors", Points: []cloud.MetricOverTime{{Time: t1, Value: f(1)}}},
+	}
+
+	var buf bytes.Buffer
+	if err := renderPipelineMetricsTable(&buf, series); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected a header and 2 rows, got:\n%s", buf.String())
+	}
+
+	if got := strings.Fields(lines[0]); strings.Join(got, " ") != "TIME INPUT-BYTES OUTPUT-ERRORS" {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+
+	if got := strings.Fields(lines[1]); got[len(got)-2] != "10.00" || got[len(got)-1] != "-" {
+		t.Errorf("expected the oldest row first with a missing value, got %q", lines[1])
+	}
+
+	if got := strings.Fields(lines[2]); got[len(got)-2] != "20.00" || got[len(got)-1] != "1.00" {
+		t.Errorf("unexpected row %q", lines[2])
+	}
+}
+
+func Test_metricStats(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	lo, hi, last := metricStats([]cloud.MetricOverTime{{Value: f(3)}, {Value: f(1)}, {Value: f(5)}, {Value: f(2)}, {}})
+	if *lo != 1 || *hi != 5 || *last != 2 {
+		t.Errorf("want 1, 5, 2, got %v, %v, %v", *lo, *hi, *last)
+	}
+
+	lo, hi, last = metricStats([]cloud.MetricOverTime{{}})
+	if lo != nil || hi != nil || last != nil {
+		t.Error("want no stats without values")
+	}
+}
@@ -4,8 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
@@ -13,12 +13,15 @@ import (
 	cloud "github.com/calyptia/api/types"
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/exitcode"
 	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/redact"
 )
 
 func NewCmdGetPipelineSecrets(config *cfg.Config) *cobra.Command {
 	var pipelineKey string
 	var last uint
+	var modifiedSince time.Duration
 	var outputFormat, goTemplate string
 	var showIDs bool
 	completer := completer.Completer{Config: config}
@@ -39,17 +42,23 @@ func NewCmdGetPipelineSecrets(config *cfg.Config) *cobra.Command {
 				return fmt.Errorf("could not fetch your pipeline secrets: %w", err)
 			}
 
-			if strings.HasPrefix(outputFormat, "go-template") {
-				return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, ss.Items)
+			ss.Items = filterModifiedSince(ss.Items, modifiedSince, time.Now(), pipelineSecretModifiedAt)
+
+			if err := exitcode.FailOnEmpty(cmd, len(ss.Items)); err != nil {
+				return err
+			}
+
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, ss.Items)
 			}
 
 			switch outputFormat {
 			case "table":
 				renderPipelineSecrets(cmd.OutOrStdout(), ss.Items, showIDs)
 			case "json":
-				return json.NewEncoder(cmd.OutOrStdout()).Encode(ss.Items)
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(redact.FromFlags(cmd).Secrets(ss.Items))
 			case "yml", "yaml":
-				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(ss.Items)
+				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(redact.FromFlags(cmd).Secrets(ss.Items))
 			default:
 				return fmt.Errorf("unknown output format %q", outputFormat)
 			}
@@ -60,15 +69,18 @@ func NewCmdGetPipelineSecrets(config *cfg.Config) *cobra.Command {
 	fs := cmd.Flags()
 	fs.StringVar(&pipelineKey, "pipeline", "", "Parent pipeline ID or name")
 	fs.UintVarP(&last, "last", "l", 0, "Last `N` pipeline secrets. 0 means no limit")
+	fs.DurationVar(&modifiedSince, "modified-since", 0, "Only list pipeline secrets created or updated within the given duration, like 24h. 0 means no filter")
 	fs.BoolVar(&showIDs, "show-ids", false, "Include status IDs in table output")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 
 	_ = cmd.RegisterFlagCompletionFunc("output-format", formatters.CompleteOutputFormat)
 	_ = cmd.RegisterFlagCompletionFunc("pipeline", completer.CompletePipelines)
 
 	_ = cmd.MarkFlagRequired("pipeline") // TODO: use default pipeline key from config cmd.
 
+	exitcode.BindFailOnEmptyFlag(cmd)
+
 	return cmd
 }
 
@@ -77,12 +89,12 @@ func renderPipelineSecrets(w io.Writer, ss []cloud.PipelineSecret, showIDs bool)
 	if showIDs {
 		fmt.Fprint(tw, "ID\t")
 	}
-	fmt.Fprintln(tw, "KEY\tAGE")
+	fmt.Fprintln(tw, "KEY\tAGE\tUPDATED")
 	for _, s := range ss {
 		if showIDs {
 			fmt.Fprintf(tw, "%s\t", s.ID)
 		}
-		fmt.Fprintf(tw, "%s\t%s\n", s.Key, formatters.FmtTime(s.CreatedAt))
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Key, formatters.FmtTime(s.CreatedAt), formatters.FmtTime(pipelineSecretModifiedAt(s)))
 	}
 	tw.Flush()
 }
@@ -3,8 +3,8 @@ package pipeline
 import (
 	"encoding/json"
 	"fmt"
-	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
@@ -12,6 +12,7 @@ import (
 	cloud "github.com/calyptia/api/types"
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/exitcode"
 	"github.com/calyptia/cli/formatters"
 )
 
@@ -20,11 +21,16 @@ func NewCmdGetPipelineStatusHistory(config *cfg.Config) *cobra.Command {
 	var last uint
 	var outputFormat, goTemplate string
 	var showIDs bool
+	var timeline bool
+	var flappingThreshold uint
 	completer := completer.Completer{Config: config}
 
 	cmd := &cobra.Command{
 		Use:   "pipeline_status_history",
 		Short: "Display latest status history from a pipeline",
+		Long: "Display latest status history from a pipeline.\n" +
+			"Use --timeline to coalesce consecutive entries of the same status into periods with their durations, " +
+			"and flag the pipeline as flapping when it restarts too often within an hour.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			pipelineID, err := completer.LoadPipelineID(pipelineKey)
 			if err != nil {
@@ -38,8 +44,30 @@ func NewCmdGetPipelineStatusHistory(config *cfg.Config) *cobra.Command {
 				return fmt.Errorf("could not fetch your pipeline status history: %w", err)
 			}
 
-			if strings.HasPrefix(outputFormat, "go-template") {
-				return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, ss.Items)
+			if err := exitcode.FailOnEmpty(cmd, len(ss.Items)); err != nil {
+				return err
+			}
+
+			if timeline {
+				t := buildStatusTimeline(ss.Items, int(flappingThreshold), time.Now())
+				if formatters.IsTemplating(outputFormat) {
+					return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, t)
+				}
+
+				switch outputFormat {
+				case "table":
+					return renderStatusTimeline(cmd.OutOrStdout(), t, int(flappingThreshold))
+				case "json":
+					return json.NewEncoder(cmd.OutOrStdout()).Encode(t)
+				case "yml", "yaml":
+					return yaml.NewEncoder(cmd.OutOrStdout()).Encode(t)
+				default:
+					return fmt.Errorf("unknown output format %q", outputFormat)
+				}
+			}
+
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, ss.Items)
 			}
 
 			switch outputFormat {
@@ -71,13 +99,17 @@ func NewCmdGetPipelineStatusHistory(config *cfg.Config) *cobra.Command {
 	fs.StringVar(&pipelineKey, "pipeline", "", "Parent pipeline ID or name")
 	fs.UintVarP(&last, "last", "l", 0, "Last `N` pipeline status history entries. 0 means no limit")
 	fs.BoolVar(&showIDs, "show-ids", false, "Include status IDs in table output")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	fs.BoolVar(&timeline, "timeline", false, "Render the history as a timeline of periods in each status with their durations")
+	fs.UintVar(&flappingThreshold, "flapping-threshold", defaultFlappingThreshold, "Restarts within an hour from which the timeline flags the pipeline as flapping. 0 disables it")
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 
 	_ = cmd.RegisterFlagCompletionFunc("output-format", formatters.CompleteOutputFormat)
 	_ = cmd.RegisterFlagCompletionFunc("pipeline", completer.CompletePipelines)
 
 	_ = cmd.MarkFlagRequired("pipeline") // TODO: use default pipeline key from config cmd.
 
+	exitcode.BindFailOnEmptyFlag(cmd)
+
 	return cmd
 }
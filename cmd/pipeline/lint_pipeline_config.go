@@ -0,0 +1,182 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	fluentbitconfig "github.com/calyptia/go-fluentbit-config/v2"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/calyptia/cli/cmd/version"
+	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/lint"
+)
+
+func NewCmdLintPipelineConfig() *cobra.Command {
+	var rulePacks []string
+	var providedConfigFormat string
+	var outputFormat, goTemplate string
+	var strict, fix bool
+	var targetVersion string
+
+	cmd := &cobra.Command{
+		Use:   "pipeline-config FILE",
+		Args:  cobra.ExactArgs(1),
+		Short: "Check a pipeline configuration file against best practices",
+		Long: "Check a pipeline configuration file against the built-in best-practice rules\n" +
+			"(mem_buf_limit set, retry_limit configured, no plain text credentials, no tag collisions,\n" +
+			"no options deprecated as of --target-version) and any rule packs given with --rules.\n" +
+			"With --fix, renamed options are rewritten in place and a migration report is printed,\n" +
+			"deprecations without a safe replacement are left to migrate by hand.\n" +
+			"Exits with a non-zero code when an error is found, or any finding with --strict.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configFile := args[0]
+			rawConfig, err := readFile(configFile)
+			if err != nil {
+				return fmt.Errorf("could not read config file: %w", err)
+			}
+
+			format := providedConfigFormat
+			if format == "" {
+				inferred, err := InferConfigFormat(configFile)
+				if err != nil {
+					return err
+				}
+				format = string(inferred)
+			}
+
+			deprecationRule, err := lint.DeprecationRule(targetVersion)
+			if err != nil {
+				return err
+			}
+
+			if fix {
+				fixed, migrations, err := lint.Migrate(string(rawConfig), fluentbitconfig.Format(format), targetVersion)
+				if err != nil {
+					return err
+				}
+
+				if fixed != string(rawConfig) {
+					info, err := os.Stat(configFile)
+					if err != nil {
+						return fmt.Errorf("could not stat config file: %w", err)
+					}
+
+					if err := os.WriteFile(configFile, []byte(fixed), info.Mode().Perm()); err != nil {
+						return fmt.Errorf("could not write fixed config file: %w", err)
+					}
+
+					rawConfig = []byte(fixed)
+				}
+
+				renderMigrations(cmd.ErrOrStderr(), configFile, migrations)
+			}
+
+			rules := []lint.Rule{deprecationRule}
+			for _, pack := range rulePacks {
+				rr, err := lint.LoadRulePack(pack)
+				if err != nil {
+					return err
+				}
+				rules = append(rules, rr...)
+			}
+
+			findings, err := lint.Lint(string(rawConfig), fluentbitconfig.Format(format), rules...)
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case formatters.IsTemplating(outputFormat):
+				if err := formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, findings); err != nil {
+					return err
+				}
+			case outputFormat == "table":
+				if err := renderLintFindings(cmd.OutOrStdout(), configFile, findings); err != nil {
+					return err
+				}
+			case outputFormat == "json":
+				if err := json.NewEncoder(cmd.OutOrStdout()).Encode(findings); err != nil {
+					return fmt.Errorf("could not json encode your lint findings: %w", err)
+				}
+			case outputFormat == "yml", outputFormat == "yaml":
+				if err := yaml.NewEncoder(cmd.OutOrStdout()).Encode(findings); err != nil {
+					return fmt.Errorf("could not yaml encode your lint findings: %w", err)
+				}
+			case outputFormat == "sarif":
+				if err := lint.RenderSARIF(cmd.OutOrStdout(), version.Version, configFile, rules, findings); err != nil {
+					return fmt.Errorf("could not sarif encode your lint findings: %w", err)
+				}
+			default:
+				return fmt.Errorf("unknown output format %q", outputFormat)
+			}
+
+			if lint.HasErrors(findings) || (strict && len(findings) != 0) {
+				return fmt.Errorf("found %d problems in %s", len(findings), configFile)
+			}
+
+			return nil
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.StringArrayVar(&rulePacks, "rules", nil, "Rule pack YAML file with additional rules. Can be given multiple times")
+	fs.StringVar(&providedConfigFormat, "config-format", "", "Configuration format (yaml, json, ini). Inferred from the file extension by default")
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, sarif, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
+	fs.BoolVar(&strict, "strict", false, "Exit with a non-zero code on warnings and notes too")
+	fs.BoolVar(&fix, "fix", false, "Rewrite the options renamed as of --target-version in place, and print a migration report")
+	fs.StringVar(&targetVersion, "target-version", "", "Fluent-bit version the configuration is migrated to, ie: the one of the core instance being upgraded to. Defaults to the latest")
+
+	_ = cmd.MarkFlagFilename("rules", "yaml", "yml")
+	_ = cmd.RegisterFlagCompletionFunc("output-format", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return []string{"table", "json", "yaml", "sarif", "go-template", "go-template-file", "custom-columns", "custom-columns-file"}, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return cmd
+}
+
+func renderLintFindings(w io.Writer, configFile string, findings []lint.Finding) error {
+	if len(findings) == 0 {
+		_, err := fmt.Fprintf(w, "%s: no problems found\n", configFile)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "LOCATION\tSEVERITY\tRULE\tMESSAGE")
+	for _, f := range findings {
+		location := configFile
+		if f.Line != 0 {
+			location = fmt.Sprintf("%s:%d", configFile, f.Line)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", location, f.Severity, f.RuleID, f.Message)
+	}
+	return tw.Flush()
+}
+
+func renderMigrations(w io.Writer, configFile string, migrations []lint.Migration) {
+	if len(migrations) == 0 {
+		fmt.Fprintf(w, "%s: no deprecated options found\n", configFile)
+		return
+	}
+
+	var fixed int
+	for _, m := range migrations {
+		status := "needs manual migration"
+		if m.Fixed {
+			status = "fixed"
+			fixed++
+		}
+
+		msg := m.Deprecation.String()
+		if m.Note != "" && !m.Fixed {
+			msg += ": " + m.Note
+		}
+		fmt.Fprintf(w, "%s:%d: %s: %s\n", configFile, m.Line, status, msg)
+	}
+	fmt.Fprintf(w, "%s: fixed %d of %d deprecations\n", configFile, fixed, len(migrations))
+}
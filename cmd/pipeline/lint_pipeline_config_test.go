@@ -0,0 +1,28 @@
+package pipeline
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewCmdLintPipelineConfig_goTemplate(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "pipeline.conf")
+	config := "[INPUT]\n    Name dummy\n    Mem_Buf_Limit 5MB\n[OUTPUT]\n    Name stdout\n    Match *\n"
+	if err := os.WriteFile(configFile, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	cmd := NewCmdLintPipelineConfig()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{configFile, "-o", "go-template", "--template", "{{range .}}{{.RuleID}}:{{.Section}} {{end}}"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "retry-limit:output:stdout:stdout.0 \n"; out.String() != want {
+		t.Errorf("want %q, got %q", want, out.String())
+	}
+}
@@ -0,0 +1,139 @@
+package pipeline
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/calyptia/cli/completer"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/k8s"
+)
+
+var logMatchStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true)
+
+func NewCmdLogsPipeline(config *cfg.Config) *cobra.Command {
+	var opts k8s.LogsOptions
+	var container, grep string
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	configOverrides := &clientcmd.ConfigOverrides{}
+	completer := completer.Completer{Config: config}
+
+	cmd := &cobra.Command{
+		Use:   "pipeline PIPELINE",
+		Short: "Print the logs of a pipeline pods",
+		Long: "Print the logs of every pod and container of a pipeline, searching all namespaces\n" +
+			"unless --kube-namespace is given. Lines are prefixed by their pod and container\n" +
+			"when there is more than one. --grep filters lines on the client side and\n" +
+			"highlights the matches.",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completer.CompletePipelines,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			var filter *regexp.Regexp
+			if grep != "" {
+				var err error
+				filter, err = regexp.Compile(grep)
+				if err != nil {
+					return fmt.Errorf("invalid --grep pattern: %w", err)
+				}
+			}
+
+			pipelineID, err := completer.LoadPipelineID(args[0])
+			if err != nil {
+				return err
+			}
+
+			kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+			kubeClientConfig, err := kubeConfig.ClientConfig()
+			if err != nil {
+				return err
+			}
+
+			clientSet, err := kubernetes.NewForConfig(kubeClientConfig)
+			if err != nil {
+				return err
+			}
+
+			k8sClient := &k8s.Client{
+				Interface: clientSet,
+				Namespace: configOverrides.Context.Namespace,
+				Config:    kubeClientConfig,
+			}
+
+			sources, err := k8sClient.PipelineLogSources(ctx, pipelineID, container)
+			if err != nil {
+				return err
+			}
+
+			var mu sync.Mutex
+			g, gctx := errgroup.WithContext(ctx)
+			for _, source := range sources {
+				source := source
+				var prefix string
+				if len(sources) > 1 {
+					prefix = "[" + source.String() + "] "
+				}
+
+				g.Go(func() error {
+					stream, err := k8sClient.StreamLogs(gctx, source, opts)
+					if err != nil {
+						return err
+					}
+
+					defer stream.Close()
+
+					return copyLogLines(cmd.OutOrStdout(), &mu, stream, prefix, filter)
+				})
+			}
+
+			return g.Wait()
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.BoolVarP(&opts.Follow, "follow", "f", false, "Keep streaming new logs")
+	fs.Int64Var(&opts.Tail, "tail", -1, "Number of recent lines to show from each container. -1 shows all of them")
+	fs.BoolVar(&opts.Previous, "previous", false, "Print the logs of the previous terminated containers, useful with crash-looping pipelines")
+	fs.StringVar(&container, "container", "", "Only print the logs of the given container")
+	fs.StringVar(&grep, "grep", "", "Only print the lines matching the given regular expression, highlighting the matches")
+	clientcmd.BindOverrideFlags(configOverrides, fs, clientcmd.RecommendedConfigOverrideFlags("kube-"))
+
+	return cmd
+}
+
+// copyLogLines writes the lines read from r matching the filter, if any,
+// prefixed and with the matches highlighted. Writes are serialized with mu
+// so lines from concurrent streams do not get mixed.
+func copyLogLines(w io.Writer, mu *sync.Mutex, r io.Reader, prefix string, filter *regexp.Regexp) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if filter != nil {
+			if !filter.MatchString(line) {
+				continue
+			}
+			line = filter.ReplaceAllStringFunc(line, func(match string) string {
+				return logMatchStyle.Render(match)
+			})
+		}
+
+		mu.Lock()
+		_, err := fmt.Fprintln(w, prefix+line)
+		mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
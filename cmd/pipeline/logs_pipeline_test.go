@@ -0,0 +1,33 @@
+package pipeline
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCopyLogLines(t *testing.T) {
+	logs := "[info] started\n[error] could not flush chunk\n[info] flushed\n"
+
+	var buf bytes.Buffer
+	var mu sync.Mutex
+	if err := copyLogLines(&buf, &mu, strings.NewReader(logs), "[pod/fluent-bit] ", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Count(buf.String(), "[pod/fluent-bit] "); got != 3 {
+		t.Errorf("expected 3 prefixed lines, got %d:\n%s", got, buf.String())
+	}
+
+	buf.Reset()
+	if err := copyLogLines(&buf, &mu, strings.NewReader(logs), "", regexp.MustCompile(`error|flush`)); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "could not") || !strings.Contains(lines[1], "ed") {
+		t.Errorf("unexpected filtered lines %q", lines)
+	}
+}
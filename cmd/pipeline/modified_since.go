@@ -0,0 +1,41 @@
+package pipeline
+
+import (
+	"time"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+// modifiedAt returns the last time an attached artifact changed,
+// falling back to its creation time if it was never updated.
+func modifiedAt(createdAt, updatedAt time.Time) time.Time {
+	if updatedAt.After(createdAt) {
+		return updatedAt
+	}
+	return createdAt
+}
+
+// filterModifiedSince keeps the items modified within the given duration
+// counting back from now. A zero duration keeps them all.
+func filterModifiedSince[T any](items []T, since time.Duration, now time.Time, modified func(T) time.Time) []T {
+	if since <= 0 {
+		return items
+	}
+
+	cutoff := now.Add(-since)
+	var out []T
+	for _, item := range items {
+		if !modified(item).Before(cutoff) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func pipelineSecretModifiedAt(s cloud.PipelineSecret) time.Time {
+	return modifiedAt(s.CreatedAt, s.UpdatedAt)
+}
+
+func pipelineFileModifiedAt(f cloud.PipelineFile) time.Time {
+	return modifiedAt(f.CreatedAt, f.UpdatedAt)
+}
@@ -0,0 +1,31 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+func TestFilterModifiedSince(t *testing.T) {
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	secrets := []cloud.PipelineSecret{
+		{Key: "old", CreatedAt: now.Add(-time.Hour * 72), UpdatedAt: now.Add(-time.Hour * 72)},
+		{Key: "updated", CreatedAt: now.Add(-time.Hour * 72), UpdatedAt: now.Add(-time.Hour)},
+		{Key: "new", CreatedAt: now.Add(-time.Minute)},
+	}
+
+	if got := filterModifiedSince(secrets, 0, now, pipelineSecretModifiedAt); len(got) != 3 {
+		t.Errorf("expected all secrets, got %+v", got)
+	}
+
+	got := filterModifiedSince(secrets, time.Hour*24, now, pipelineSecretModifiedAt)
+	if len(got) != 2 || got[0].Key != "updated" || got[1].Key != "new" {
+		t.Errorf("unexpected filtered secrets %+v", got)
+	}
+
+	files := []cloud.PipelineFile{{Name: "old", CreatedAt: now.Add(-time.Hour * 2)}}
+	if got := filterModifiedSince(files, time.Hour, now, pipelineFileModifiedAt); len(got) != 0 {
+		t.Errorf("expected no files, got %+v", got)
+	}
+}
@@ -0,0 +1,193 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	cloud "github.com/calyptia/api/types"
+	"github.com/calyptia/cli/completer"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/progress"
+)
+
+const defaultScaleWaitTimeout = time.Minute * 2
+
+func NewCmdPausePipeline(config *cfg.Config) *cobra.Command {
+	var waitScaled bool
+	var waitTimeout time.Duration
+	completer := completer.Completer{Config: config}
+
+	cmd := &cobra.Command{
+		Use:               "pipeline PIPELINE",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completer.CompletePipelines,
+		Short:             "Pause a pipeline by scaling it to zero replicas",
+		Long:              "Pause a pipeline by scaling it to zero replicas.\nThe pipeline configuration is kept and the previous replicas count gets restored with resume pipeline.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pipelineID, err := completer.LoadPipelineID(args[0])
+			if err != nil {
+				return err
+			}
+
+			pip, err := config.Cloud.Pipeline(config.Ctx, pipelineID, cloud.PipelineParams{})
+			if err != nil {
+				return fmt.Errorf("could not fetch pipeline: %w", err)
+			}
+
+			if pip.ReplicasCount == 0 {
+				cmd.Printf("Pipeline %q is already paused\n", pip.Name)
+				return nil
+			}
+
+			tracker, err := progress.FromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := scalePipeline(cmd.Context(), config, tracker, pipelineID, 0, waitScaled, waitTimeout); err != nil {
+				return err
+			}
+
+			cmd.Printf("Pipeline %q paused, it had %d replicas\n", pip.Name, pip.ReplicasCount)
+			return nil
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.BoolVar(&waitScaled, "wait", false, "Wait for the pipeline to be scaled down before returning")
+	fs.DurationVar(&waitTimeout, "timeout", defaultScaleWaitTimeout, "Wait timeout")
+	progress.BindFlag(cmd)
+
+	return cmd
+}
+
+func NewCmdResumePipeline(config *cfg.Config) *cobra.Command {
+	var replicas uint
+	var waitScaled bool
+	var waitTimeout time.Duration
+	completer := completer.Completer{Config: config}
+
+	cmd := &cobra.Command{
+		Use:               "pipeline PIPELINE",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completer.CompletePipelines,
+		Short:             "Resume a paused pipeline restoring its previous replicas count",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pipelineID, err := completer.LoadPipelineID(args[0])
+			if err != nil {
+				return err
+			}
+
+			pip, err := config.Cloud.Pipeline(config.Ctx, pipelineID, cloud.PipelineParams{})
+			if err != nil {
+				return fmt.Errorf("could not fetch pipeline: %w", err)
+			}
+
+			if pip.ReplicasCount != 0 && !cmd.Flags().Changed("replicas") {
+				cmd.Printf("Pipeline %q is not paused, it has %d replicas\n", pip.Name, pip.ReplicasCount)
+				return nil
+			}
+
+			if !cmd.Flags().Changed("replicas") {
+				replicas = resumeReplicas(pip)
+			}
+
+			if replicas == 0 {
+				return errors.New("cannot resume a pipeline with zero replicas")
+			}
+
+			tracker, err := progress.FromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := scalePipeline(cmd.Context(), config, tracker, pipelineID, replicas, waitScaled, waitTimeout); err != nil {
+				return err
+			}
+
+			cmd.Printf("Pipeline %q resumed with %d replicas\n", pip.Name, replicas)
+			return nil
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.UintVar(&replicas, "replicas", 1, "Replicas count to resume the pipeline with. Defaults to the replicas count before pausing")
+	fs.BoolVar(&waitScaled, "wait", false, "Wait for the pipeline to be scaled up before returning")
+	fs.DurationVar(&waitTimeout, "timeout", defaultScaleWaitTimeout, "Wait timeout")
+	progress.BindFlag(cmd)
+
+	return cmd
+}
+
+// resumeReplicas returns the replicas count a paused pipeline had before pausing.
+func resumeReplicas(pip cloud.Pipeline) uint {
+	if pip.ReplicasCountPrev != 0 {
+		return pip.ReplicasCountPrev
+	}
+	return 1
+}
+
+// scalePipeline updates the pipeline replicas count and optionally waits
+// for the pipeline to finish scaling, see scaleDone.
+func scalePipeline(ctx context.Context, config *cfg.Config, tracker *progress.Tracker, pipelineID string, replicas uint, waitScaled bool, waitTimeout time.Duration) error {
+	start := time.Now()
+	_, err := config.Cloud.UpdatePipeline(ctx, pipelineID, cloud.UpdatePipeline{
+		ReplicasCount: &replicas,
+	})
+	if err != nil {
+		return fmt.Errorf("could not scale pipeline: %w", err)
+	}
+
+	if !waitScaled {
+		return nil
+	}
+
+	const step = "wait for pipeline to be scaled"
+	var lastStatus cloud.PipelineStatusKind
+	err = tracker.Run(ctx, step, func(ctx context.Context) error {
+		return wait.PollUntilContextTimeout(ctx, 3*time.Second, waitTimeout, true, func(ctx context.Context) (bool, error) {
+			pip, err := config.Cloud.Pipeline(ctx, pipelineID, cloud.PipelineParams{})
+			if err != nil {
+				return false, err
+			}
+
+			if !pip.Status.CreatedAt.Before(start) && pip.Status.Status != lastStatus {
+				lastStatus = pip.Status.Status
+				tracker.Report(step, fmt.Sprintf("pipeline status %s", lastStatus), 0, 0)
+			}
+
+			return scaleDone(pip, replicas, start)
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("could not wait for pipeline to be scaled: %w", err)
+	}
+
+	return nil
+}
+
+// scaleDone reports whether the pipeline finished scaling to the given
+// replicas count. A paused pipeline has no replicas left to start, so
+// scaling down finishes once the pipeline itself has zero replicas.
+// Scaling up finishes once the pipeline is started again, only taking into
+// account statuses reported after start.
+func scaleDone(pip cloud.Pipeline, replicas uint, start time.Time) (bool, error) {
+	if replicas == 0 {
+		return pip.ReplicasCount == 0, nil
+	}
+
+	if pip.Status.CreatedAt.Before(start) {
+		return false, nil
+	}
+
+	if pip.Status.Status == cloud.PipelineStatusFailed {
+		return false, errors.New("pipeline failed while scaling")
+	}
+
+	return pip.Status.Status == cloud.PipelineStatusStarted, nil
+}
@@ -0,0 +1,96 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/calyptia/api/client"
+	cloud "github.com/calyptia/api/types"
+
+	cfg "github.com/calyptia/cli/config"
+)
+
+func Test_scaleDone(t *testing.T) {
+	start := time.Now()
+	pipeline := func(replicas uint, status cloud.PipelineStatusKind, createdAt time.Time) cloud.Pipeline {
+		return cloud.Pipeline{
+			ReplicasCount: replicas,
+			Status:        cloud.PipelineStatus{Status: status, CreatedAt: createdAt},
+		}
+	}
+
+	tt := []struct {
+		name     string
+		pipeline cloud.Pipeline
+		replicas uint
+		want     bool
+		wantErr  bool
+	}{
+		{name: "stale status", pipeline: pipeline(2, cloud.PipelineStatusStarted, start.Add(-time.Minute)), replicas: 2},
+		{name: "failed", pipeline: pipeline(2, cloud.PipelineStatusFailed, start), replicas: 2, wantErr: true},
+		{name: "scaling up", pipeline: pipeline(2, cloud.PipelineStatusScaling, start), replicas: 2},
+		{name: "scaled up", pipeline: pipeline(2, cloud.PipelineStatusStarted, start), replicas: 2, want: true},
+		{name: "replicas left", pipeline: pipeline(1, cloud.PipelineStatusStarted, start)},
+		{name: "paused", pipeline: pipeline(0, cloud.PipelineStatusStarted, start), want: true},
+		{name: "paused with stale status", pipeline: pipeline(0, cloud.PipelineStatusStarted, start.Add(-time.Minute)), want: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := scaleDone(tc.pipeline, tc.replicas, start)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("want error %v, got %v", tc.wantErr, err)
+			}
+
+			if got != tc.want {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestNewCmdPausePipeline_wait(t *testing.T) {
+	var mu sync.Mutex
+	replicas := 2
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/projects/project-1/pipelines":
+			_, _ = w.Write([]byte(`{"items":[{"id":"pipeline-1","name":"my-pipeline"}]}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/aggregator_pipelines/pipeline-1":
+			_, _ = fmt.Fprintf(w, `{"id":"pipeline-1","name":"my-pipeline","replicasCount":%d,"status":{"status":"NEW","createdAt":%q}}`,
+				replicas, time.Now().UTC().Format(time.RFC3339Nano))
+		case r.Method == http.MethodPatch && r.URL.Path == "/v1/aggregator_pipelines/pipeline-1":
+			replicas = 0
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"not found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	cloudClient := client.New()
+	cloudClient.BaseURL = srv.URL
+	config := &cfg.Config{Ctx: context.Background(), Cloud: cloudClient, ProjectID: "project-1"}
+
+	cmd := NewCmdPausePipeline(config)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"my-pipeline", "--wait", "--timeout", "10s"})
+	if err := cmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "Pipeline \"my-pipeline\" paused, it had 2 replicas\n"; out.String() != want {
+		t.Errorf("want %q, got %q", want, out.String())
+	}
+}
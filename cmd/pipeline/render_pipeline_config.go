@@ -0,0 +1,160 @@
+package pipeline
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	cloud "github.com/calyptia/api/types"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/redact"
+)
+
+var configVariablePattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+func NewCmdRenderPipelineConfig(config *cfg.Config) *cobra.Command {
+	var envFiles, envPairs []string
+	var providedConfigFormat string
+
+	cmd := &cobra.Command{
+		Use:   "pipeline_config FILE",
+		Short: "Print a pipeline config with its ${VARIABLES} resolved",
+		Long: "Print the pipeline config with every ${VARIABLE} replaced as the core instance would see it.\n" +
+			"Variables are resolved, by order of precedence, from the config itself (@SET or env section),\n" +
+			"--env and --env-file in the given order.\n" +
+			"Fails on unresolved variables, which fluent-bit would silently replace with an empty value.\n" +
+			"Secret values are redacted unless --show-secrets is given.",
+		Example: "  calyptia render pipeline_config fluent-bit.conf --env-file prod.env",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rawConfig, err := readFile(args[0])
+			if err != nil {
+				return fmt.Errorf("could not read config file: %w", err)
+			}
+
+			format := cloud.ConfigFormat(providedConfigFormat)
+			if format == "" {
+				format, err = InferConfigFormat(args[0])
+				if err != nil {
+					return err
+				}
+			}
+
+			env := map[string]string{}
+
+			for _, name := range envFiles {
+				b, err := os.ReadFile(name)
+				if err != nil {
+					return fmt.Errorf("could not read env file: %w", err)
+				}
+
+				m, err := godotenv.Parse(bytes.NewReader(b))
+				if err != nil {
+					return fmt.Errorf("could not parse env file %s: %w", name, err)
+				}
+
+				for k, v := range m {
+					env[k] = v
+				}
+			}
+
+			for _, pair := range envPairs {
+				k, v, ok := strings.Cut(pair, "=")
+				if !ok {
+					return fmt.Errorf("invalid env %q, expected KEY=VALUE", pair)
+				}
+				env[k] = v
+			}
+
+			configEnv, err := configDefinedVariables(string(rawConfig), format)
+			if err != nil {
+				return err
+			}
+
+			for k, v := range configEnv {
+				env[k] = v
+			}
+
+			rendered, unresolved := interpolateConfigVariables(string(rawConfig), env)
+			if len(unresolved) != 0 {
+				return fmt.Errorf("unresolved variables: %s", strings.Join(unresolved, ", "))
+			}
+
+			cmd.Print(redact.FromFlags(cmd).Config(rendered))
+			return nil
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.StringArrayVar(&envFiles, "env-file", nil, "Env file with KEY=VALUE lines to resolve variables from. Pass as many as you want, later ones take precedence")
+	fs.StringArrayVar(&envPairs, "env", nil, "Variable in the form of KEY=VALUE. Pass as many as you want")
+	fs.StringVar(&providedConfigFormat, "config-format", "", "Configuration format (yaml, json, ini). Inferred from the file extension by default")
+
+	return cmd
+}
+
+// interpolateConfigVariables replaces the ${VARIABLES} in the given config.
+// It returns the variables with no value, sorted.
+func interpolateConfigVariables(raw string, env map[string]string) (string, []string) {
+	missing := map[string]bool{}
+	out := configVariablePattern.ReplaceAllStringFunc(raw, func(match string) string {
+		name := configVariablePattern.FindStringSubmatch(match)[1]
+		v, ok := env[name]
+		if !ok {
+			missing[name] = true
+			return match
+		}
+		return v
+	})
+
+	unresolved := make([]string, 0, len(missing))
+	for name := range missing {
+		unresolved = append(unresolved, name)
+	}
+	sort.Strings(unresolved)
+
+	return out, unresolved
+}
+
+// configDefinedVariables returns the variables defined within the config:
+// @SET directives on classic configs and the env section on YAML ones.
+func configDefinedVariables(raw string, format cloud.ConfigFormat) (map[string]string, error) {
+	out := map[string]string{}
+	switch format {
+	case cloud.ConfigFormatYAML:
+		var conf struct {
+			Env map[string]string `yaml:"env"`
+		}
+		if err := yaml.Unmarshal([]byte(raw), &conf); err != nil {
+			return nil, fmt.Errorf("could not parse config: %w", err)
+		}
+		for k, v := range conf.Env {
+			out[k] = v
+		}
+	case cloud.ConfigFormatINI:
+		s := bufio.NewScanner(strings.NewReader(raw))
+		for s.Scan() {
+			line := strings.TrimSpace(s.Text())
+			if len(line) < 4 || !strings.EqualFold(line[:4], "@SET") {
+				continue
+			}
+
+			k, v, ok := strings.Cut(strings.TrimSpace(line[4:]), "=")
+			if ok {
+				out[strings.TrimSpace(k)] = strings.TrimSpace(v)
+			}
+		}
+		if err := s.Err(); err != nil {
+			return nil, fmt.Errorf("could not read config: %w", err)
+		}
+	}
+	return out, nil
+}
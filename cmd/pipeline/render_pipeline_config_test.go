@@ -0,0 +1,42 @@
+package pipeline
+
+import (
+	"reflect"
+	"testing"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+func Test_interpolateConfigVariables(t *testing.T) {
+	raw := "[OUTPUT]\n    Name  es\n    Host  ${ES_HOST}\n    Port  ${ES_PORT}\n    Index ${INDEX}-${ENV}\n"
+	got, unresolved := interpolateConfigVariables(raw, map[string]string{"ES_HOST": "es.prod", "ES_PORT": "9200"})
+
+	if want := []string{"ENV", "INDEX"}; !reflect.DeepEqual(unresolved, want) {
+		t.Errorf("want unresolved %v, got %v", want, unresolved)
+	}
+
+	want := "[OUTPUT]\n    Name  es\n    Host  es.prod\n    Port  9200\n    Index ${INDEX}-${ENV}\n"
+	if got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func Test_configDefinedVariables(t *testing.T) {
+	got, err := configDefinedVariables("@SET env=prod\n@set region = eu\n[INPUT]\n    Name dummy\n", cloud.ConfigFormatINI)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := map[string]string{"env": "prod", "region": "eu"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	got, err = configDefinedVariables("env:\n  env: prod\npipeline:\n  inputs:\n    - name: dummy\n", cloud.ConfigFormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := map[string]string{"env": "prod"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
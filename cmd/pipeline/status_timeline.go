@@ -0,0 +1,120 @@
+package pipeline
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	cloud "github.com/calyptia/api/types"
+	"github.com/calyptia/cli/formatters"
+)
+
+// defaultFlappingThreshold is the number of restarts within an hour
+// from which a pipeline is considered to be flapping.
+const defaultFlappingThreshold = 3
+
+// statusTimeline is the pipeline status history with consecutive
+// entries of the same status coalesced into a single period.
+type statusTimeline struct {
+	Periods             []statusPeriod `json:"periods" yaml:"periods"`
+	Restarts            int            `json:"restarts" yaml:"restarts"`
+	PeakRestartsPerHour int            `json:"peakRestartsPerHour" yaml:"peakRestartsPerHour"`
+	Flapping            bool           `json:"flapping" yaml:"flapping"`
+}
+
+// statusPeriod is a span of time the pipeline stayed in the same status.
+// Until is nil while the pipeline is still in that status.
+type statusPeriod struct {
+	Status   cloud.PipelineStatusKind `json:"status" yaml:"status"`
+	Since    time.Time                `json:"since" yaml:"since"`
+	Until    *time.Time               `json:"until" yaml:"until"`
+	Duration time.Duration            `json:"duration" yaml:"duration"`
+	Entries  int                      `json:"entries" yaml:"entries"`
+	Restart  bool                     `json:"restart" yaml:"restart"`
+}
+
+// buildStatusTimeline coalesces the status history, in any order, into
+// periods from the oldest to the newest. A restart is each time the
+// pipeline goes back to STARTED after having left it.
+func buildStatusTimeline(history []cloud.PipelineStatus, flappingThreshold int, now time.Time) statusTimeline {
+	sorted := make([]cloud.PipelineStatus, len(history))
+	copy(sorted, history)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+
+	var out statusTimeline
+	var started bool
+	for _, s := range sorted {
+		if n := len(out.Periods); n != 0 && out.Periods[n-1].Status == s.Status {
+			out.Periods[n-1].Entries++
+			continue
+		}
+
+		p := statusPeriod{Status: s.Status, Since: s.CreatedAt, Entries: 1}
+		if s.Status == cloud.PipelineStatusStarted {
+			p.Restart = started
+			started = true
+		}
+		out.Periods = append(out.Periods, p)
+	}
+
+	var restarts []time.Time
+	for i := range out.Periods {
+		p := &out.Periods[i]
+		until := now
+		if i+1 < len(out.Periods) {
+			until = out.Periods[i+1].Since
+			p.Until = &until
+		}
+		p.Duration = until.Sub(p.Since)
+
+		if p.Restart {
+			restarts = append(restarts, p.Since)
+		}
+	}
+
+	out.Restarts = len(restarts)
+	for i := range restarts {
+		var n int
+		for j := i; j < len(restarts) && restarts[j].Sub(restarts[i]) < time.Hour; j++ {
+			n++
+		}
+		out.PeakRestartsPerHour = max(out.PeakRestartsPerHour, n)
+	}
+	out.Flapping = flappingThreshold > 0 && out.PeakRestartsPerHour >= flappingThreshold
+
+	return out
+}
+
+func renderStatusTimeline(w io.Writer, timeline statusTimeline, flappingThreshold int) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tSINCE\tUNTIL\tDURATION\tENTRIES")
+	for _, p := range timeline.Periods {
+		until := "now"
+		if p.Until != nil {
+			until = formatters.FmtTimestamp(*p.Until)
+		}
+
+		status := string(p.Status)
+		if p.Restart {
+			status += " (restart)"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\n", status, formatters.FmtTimestamp(p.Since), until, formatters.FmtDuration(p.Duration), p.Entries)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if timeline.Flapping {
+		fmt.Fprintf(w, "\nFLAPPING: %d restarts/hour at peak (threshold %d), %d restarts in total.\n",
+			timeline.PeakRestartsPerHour, flappingThreshold, timeline.Restarts)
+	} else if timeline.Restarts != 0 {
+		fmt.Fprintf(w, "\n%d restarts in total, %d/hour at peak.\n", timeline.Restarts, timeline.PeakRestartsPerHour)
+	}
+
+	return nil
+}
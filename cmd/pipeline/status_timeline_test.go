@@ -0,0 +1,65 @@
+package pipeline
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+func Test_buildStatusTimeline(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	at := func(minutes int, status cloud.PipelineStatusKind) cloud.PipelineStatus {
+		return cloud.PipelineStatus{Status: status, CreatedAt: start.Add(time.Duration(minutes) * time.Minute)}
+	}
+
+	// newest first, as returned by the API.
+	history := []cloud.PipelineStatus{
+		at(50, cloud.PipelineStatusStarted),
+		at(45, cloud.PipelineStatusFailed),
+		at(30, cloud.PipelineStatusStarted),
+		at(25, cloud.PipelineStatusFailed),
+		at(20, cloud.PipelineStatusStarted),
+		at(15, cloud.PipelineStatusFailed),
+		at(10, cloud.PipelineStatusStarted),
+		at(5, cloud.PipelineStatusStarting),
+		at(1, cloud.PipelineStatusNew),
+		at(0, cloud.PipelineStatusNew),
+	}
+
+	now := start.Add(2 * time.Hour)
+	got := buildStatusTimeline(history, defaultFlappingThreshold, now)
+
+	if len(got.Periods) != 9 {
+		t.Fatalf("expected 9 periods, got %d", len(got.Periods))
+	}
+
+	first := got.Periods[0]
+	if first.Status != cloud.PipelineStatusNew || first.Entries != 2 || first.Duration != 5*time.Minute {
+		t.Errorf("unexpected first period %+v", first)
+	}
+
+	last := got.Periods[len(got.Periods)-1]
+	if last.Until != nil || last.Duration != 70*time.Minute || !last.Restart {
+		t.Errorf("unexpected last period %+v", last)
+	}
+
+	if got.Restarts != 3 || got.PeakRestartsPerHour != 3 || !got.Flapping {
+		t.Errorf("expected 3 restarts flagged as flapping, got %+v", got)
+	}
+
+	if got := buildStatusTimeline(history, 4, now); got.Flapping {
+		t.Error("expected no flapping under the threshold")
+	}
+
+	var buf bytes.Buffer
+	if err := renderStatusTimeline(&buf, got, defaultFlappingThreshold); err != nil {
+		t.Fatal(err)
+	}
+
+	if out := buf.String(); !strings.Contains(out, "STARTED (restart)") || !strings.Contains(out, "FLAPPING: 3 restarts/hour") {
+		t.Errorf("unexpected timeline output:\n%s", out)
+	}
+}
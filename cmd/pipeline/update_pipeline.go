@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 	"text/tabwriter"
@@ -13,18 +14,23 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 	"gopkg.in/yaml.v2"
 
 	cloud "github.com/calyptia/api/types"
 	"github.com/calyptia/cli/cmd/utils"
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/confirm"
 	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/protection"
+	"github.com/calyptia/cli/redact"
 )
 
 func NewCmdUpdatePipeline(config *cfg.Config) *cobra.Command {
 	var newName string
 	var newConfigFile string
+	var renderTemplate bool
 	var newReplicasCount int
 	var autoCreatePortsFromConfig bool
 	var skipConfigValidation bool
@@ -36,9 +42,13 @@ func NewCmdUpdatePipeline(config *cfg.Config) *cobra.Command {
 	var outputFormat, goTemplate string
 	var metadataPairs []string
 	var metadataFile string
+	var variablePairs []string
 	var providedConfigFormat string
 	var deploymentStrategy string
 	var portsServiceType string
+	var secretsEnvFile, filesDir string
+	var atomic bool
+	var confirmed bool
 
 	completer := completer.Completer{Config: config}
 
@@ -56,6 +66,16 @@ func NewCmdUpdatePipeline(config *cfg.Config) *cobra.Command {
 				}
 
 				rawConfig = string(b)
+				if renderTemplate {
+					rawConfig, err = cfg.RenderTemplate(newConfigFile, rawConfig)
+					if err != nil {
+						return err
+					}
+				}
+
+				if redact.HasRedactedValues(rawConfig) {
+					return fmt.Errorf("config file %q has %s values, get the config with --show-secrets", newConfigFile, redact.Placeholder)
+				}
 			}
 
 			secrets, err := parseUpdatePipelineSecrets(secretsFile, secretsFormat)
@@ -63,6 +83,16 @@ func NewCmdUpdatePipeline(config *cfg.Config) *cobra.Command {
 				return err
 			}
 
+			vars, removedVars, err := parseVariables(variablePairs)
+			if err != nil {
+				return err
+			}
+
+			for _, v := range vars {
+				v := v
+				secrets = append(secrets, cloud.UpdatePipelineSecret{Key: &v.Key, Value: &v.Value})
+			}
+
 			var updatePipelineFiles []cloud.UpdatePipelineFile
 			for _, f := range files {
 				if f == "" {
@@ -84,6 +114,26 @@ func NewCmdUpdatePipeline(config *cfg.Config) *cobra.Command {
 				})
 			}
 
+			assets, err := stagePipelineAssets(secretsEnvFile, filesDir, encryptFiles)
+			if err != nil {
+				return err
+			}
+
+			if atomic {
+				// every secret and file gets applied by the transaction instead.
+				for _, s := range secrets {
+					assets.Secrets = append(assets.Secrets, cloud.CreatePipelineSecret{Key: *s.Key, Value: *s.Value})
+				}
+				for _, f := range updatePipelineFiles {
+					assets.Files = append(assets.Files, cloud.CreatePipelineFile{Name: *f.Name, Contents: *f.Contents, Encrypted: *f.Encrypted})
+				}
+				secrets, updatePipelineFiles = nil, nil
+			} else {
+				moreSecrets, moreFiles := assets.updatePayload()
+				secrets = append(secrets, moreSecrets...)
+				updatePipelineFiles = append(updatePipelineFiles, moreFiles...)
+			}
+
 			var metadata *json.RawMessage
 			if metadataFile != "" {
 				b, err := cfg.ReadFile(metadataFile)
@@ -106,6 +156,26 @@ func NewCmdUpdatePipeline(config *cfg.Config) *cobra.Command {
 				return err
 			}
 
+			protect, err := protection.FromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			if protect != nil {
+				if metadata == nil {
+					pip, err := config.Cloud.Pipeline(config.Ctx, pipelineID, cloud.PipelineParams{})
+					if err != nil {
+						return fmt.Errorf("could not fetch pipeline: %w", err)
+					}
+					metadata = pip.Metadata
+				}
+
+				metadata, err = protection.WithMetadata(metadata, *protect)
+				if err != nil {
+					return err
+				}
+			}
+
 			var format cloud.ConfigFormat
 
 			if providedConfigFormat != "" {
@@ -164,14 +234,39 @@ func NewCmdUpdatePipeline(config *cfg.Config) *cobra.Command {
 				update.Image = &image
 			}
 
+			if rawConfig != "" && !confirmed {
+				ok, err := confirmConfigUpdate(cmd, config, pipelineID, rawConfig, format)
+				if err != nil {
+					return err
+				}
+
+				if !ok {
+					cmd.Println("Aborted")
+					return nil
+				}
+			}
+
+			tx := &assetsTransaction{client: config.Cloud, pipelineID: pipelineID}
+			if atomic && !assets.empty() {
+				if err := tx.apply(config.Ctx, assets); err != nil {
+					return err
+				}
+			}
+
+			if len(removedVars) != 0 {
+				if err := tx.removeSecrets(config.Ctx, removedVars); err != nil {
+					return err
+				}
+			}
+
 			updated, err := config.Cloud.UpdatePipeline(config.Ctx, pipelineID, update)
 			if err != nil {
-				return fmt.Errorf("could not update pipeline: %w", err)
+				return tx.fail(config.Ctx, fmt.Errorf("could not update pipeline: %w", err))
 			}
 
 			if autoCreatePortsFromConfig && len(updated.AddedPorts) != 0 {
-				if strings.HasPrefix(outputFormat, "go-template") {
-					return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, updated)
+				if formatters.IsTemplating(outputFormat) {
+					return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, updated)
 				}
 
 				switch outputFormat {
@@ -195,9 +290,13 @@ func NewCmdUpdatePipeline(config *cfg.Config) *cobra.Command {
 		},
 	}
 
+	isNonInteractive := os.Stdin == nil || !term.IsTerminal(int(os.Stdin.Fd()))
+
 	fs := cmd.Flags()
+	fs.BoolVarP(&confirmed, "yes", "y", isNonInteractive, "Push the new config without showing the diff against the deployed one and asking for confirmation")
 	fs.StringVar(&newName, "new-name", "", "New pipeline name")
 	fs.StringVar(&newConfigFile, "config-file", "", "New Fluent Bit config file used by pipeline")
+	fs.BoolVar(&renderTemplate, "render-template", false, "Render the config file as a go template with sprig functions before sending it, ie: {{ env \"HOST\" | default \"localhost\" }}")
 	fs.StringVar(&providedConfigFormat, "config-format", "", "Default configuration format to use (yaml, ini(deprecated))")
 	fs.IntVar(&newReplicasCount, "replicas", -1, "New pipeline replica size")
 	fs.BoolVar(&autoCreatePortsFromConfig, "auto-create-ports", true, "Automatically create pipeline ports from config if updated")
@@ -208,17 +307,43 @@ func NewCmdUpdatePipeline(config *cfg.Config) *cobra.Command {
 	fs.StringVar(&deploymentStrategy, "deployment-strategy", "", "The deployment strategy to use when deploying this pipeline in cluster (hotReload or recreate (default)).")
 	fs.StringArrayVar(&files, "file", nil, "Optional file. You can reference this file contents from your config like so:\n{{ files.myfile }}\nPass as many as you want; bear in mind the file name can only contain alphanumeric characters.")
 	fs.BoolVar(&encryptFiles, "encrypt-files", false, "Encrypt file contents")
+	fs.StringVar(&secretsEnvFile, "secrets-from-env-file", "", "Create or update a secret for every KEY=VALUE in the given .env file. Secrets not in the file are kept")
+	fs.StringVar(&filesDir, "files-from-dir", "", "Create or update a file for every regular file in the given directory, named after its base name without the extension. Files not in the directory are kept")
+	fs.BoolVar(&atomic, "atomic", false, "Validate every secret and file first, then apply them along with the pipeline update rolling everything back if any of it fails")
 	fs.StringVar(&image, "image", "", "Fluent-bit docker image")
 	fs.StringSliceVar(&metadataPairs, "metadata", nil, "Metadata to attach to the pipeline in the form of key:value. You could instead use a file with the --metadata-file option")
 	fs.StringVar(&metadataFile, "metadata-file", "", "Metadata JSON file to attach to the pipeline intead of passing multiple --metadata flags")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	fs.StringArrayVar(&variablePairs, "var", nil, "Variable to store as a pipeline secret in the form of KEY=VALUE.\nReference it from the config as {{ secrets.KEY }}. Use KEY- to remove an existing one. Pass as many as you want")
+	protection.BindFlags(cmd)
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 
 	_ = cmd.RegisterFlagCompletionFunc("output-format", formatters.CompleteOutputFormat)
 
 	return cmd
 }
 
+// confirmConfigUpdate shows the diff between the deployed config and the new
+// one and asks for confirmation. A config without changes needs none.
+func confirmConfigUpdate(cmd *cobra.Command, config *cfg.Config, pipelineID, rawConfig string, format cloud.ConfigFormat) (bool, error) {
+	// fetch the deployed config in the same format so they compare line by line.
+	pip, err := config.Cloud.Pipeline(config.Ctx, pipelineID, cloud.PipelineParams{ConfigFormat: &format})
+	if err != nil {
+		return false, fmt.Errorf("could not fetch pipeline: %w", err)
+	}
+
+	if pip.Config.RawConfig == rawConfig {
+		cmd.PrintErrf("Pipeline %q config is unchanged\n", pip.Name)
+		return true, nil
+	}
+
+	diff := unifiedDiff(pip.Name+" (deployed)", pip.Name+" (new)", pip.Config.RawConfig, rawConfig)
+	fmt.Fprint(cmd.ErrOrStderr(), colorizeDiff(diff))
+
+	cmd.Printf("Push the new config to %q? (y/N) ", pip.Name)
+	return confirm.Read(cmd.InOrStdin())
+}
+
 func parseUpdatePipelineSecrets(file, format string) ([]cloud.UpdatePipelineSecret, error) {
 	if file == "" {
 		return nil, nil
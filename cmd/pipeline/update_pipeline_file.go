@@ -27,6 +27,12 @@ func NewCmdUpdatePipelineFile(config *cfg.Config) *cobra.Command {
 				return err
 			}
 
+			if isWASMFile(file, "") {
+				if _, err := parseWASM(contents); err != nil {
+					return fmt.Errorf("invalid wasm file %q: %w", file, err)
+				}
+			}
+
 			name := filepath.Base(file)
 			name = strings.TrimSuffix(name, filepath.Ext(name))
 
@@ -0,0 +1,94 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/calyptia/api/client"
+	cloud "github.com/calyptia/api/types"
+
+	cfg "github.com/calyptia/cli/config"
+)
+
+func TestNewCmdUpdatePipeline_vars(t *testing.T) {
+	var mu sync.Mutex
+	var update cloud.UpdatePipeline
+	var created []cloud.CreatePipelineSecret
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/projects/project-1/pipelines":
+			_, _ = w.Write([]byte(`{"items":[{"id":"pipeline-1","name":"my-pipeline"}]}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/aggregator_pipelines/pipeline-1":
+			_, _ = w.Write([]byte(`{"id":"pipeline-1","name":"my-pipeline"}`))
+		case r.Method == http.MethodGet && (r.URL.Path == "/v1/aggregator_pipelines/pipeline-1/secrets" || r.URL.Path == "/v1/aggregator_pipelines/pipeline-1/files"):
+			_, _ = w.Write([]byte(`[]`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/aggregator_pipelines/pipeline-1/secrets":
+			var in cloud.CreatePipelineSecret
+			if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			created = append(created, in)
+			_, _ = w.Write([]byte(`{"id":"secret-` + in.Key + `"}`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/v1/aggregator_pipelines/pipeline-1":
+			update = cloud.UpdatePipeline{}
+			if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"not found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	cloudClient := client.New()
+	cloudClient.BaseURL = srv.URL
+	config := &cfg.Config{Ctx: context.Background(), Cloud: cloudClient, ProjectID: "project-1"}
+
+	run := func(t *testing.T, args ...string) {
+		t.Helper()
+		cmd := NewCmdUpdatePipeline(config)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(append([]string{"my-pipeline", "--var", "A=1", "--var", "B=2"}, args...))
+		if err := cmd.ExecuteContext(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		run(t)
+
+		got := map[string]string{}
+		for _, s := range update.Secrets {
+			got[*s.Key] = string(*s.Value)
+		}
+		if len(got) != 2 || got["A"] != "1" || got["B"] != "2" {
+			t.Errorf("want secrets A=1 and B=2, got %v", got)
+		}
+	})
+
+	t.Run("atomic", func(t *testing.T) {
+		created = nil
+		run(t, "--atomic")
+
+		got := map[string]string{}
+		for _, s := range created {
+			got[s.Key] = string(s.Value)
+		}
+		if len(got) != 2 || got["A"] != "1" || got["B"] != "2" {
+			t.Errorf("want secrets A=1 and B=2, got %v", got)
+		}
+	})
+}
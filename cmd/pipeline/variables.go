@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseVariables parses KEY=VALUE pairs into pipeline secrets, so their
+// values are stored like any other secret instead of in plain text, and
+// the config can reference them as {{ secrets.KEY }} without editing
+// its text per cluster. Pairs in the form of KEY- are returned as removals.
+func parseVariables(pairs []string) (secrets []cloud.CreatePipelineSecret, removed []string, err error) {
+	values := map[string]string{}
+	for _, pair := range pairs {
+		if key, ok := strings.CutSuffix(pair, "-"); ok && !strings.Contains(pair, "=") {
+			if !variableNamePattern.MatchString(key) {
+				return nil, nil, fmt.Errorf("invalid variable name %q", key)
+			}
+			removed = append(removed, key)
+			continue
+		}
+
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid variable %q, expected KEY=VALUE", pair)
+		}
+
+		if !variableNamePattern.MatchString(key) {
+			return nil, nil, fmt.Errorf("invalid variable name %q, it must be a valid secret name", key)
+		}
+
+		values[key] = value
+	}
+
+	for k, v := range values {
+		secrets = append(secrets, cloud.CreatePipelineSecret{Key: k, Value: []byte(v)})
+	}
+
+	sort.Slice(secrets, func(i, j int) bool {
+		return secrets[i].Key < secrets[j].Key
+	})
+
+	return secrets, removed, nil
+}
+
+// removeSecrets deletes the pipeline secrets with the given keys, recording
+// how to create them again. It fails when any of them does not exist.
+func (tx *assetsTransaction) removeSecrets(ctx context.Context, keys []string) error {
+	secrets, err := tx.client.PipelineSecrets(ctx, tx.pipelineID, cloud.PipelineSecretsParams{})
+	if err != nil {
+		return fmt.Errorf("could not fetch pipeline secrets: %w", err)
+	}
+
+	existing := map[string]cloud.PipelineSecret{}
+	for _, s := range secrets.Items {
+		existing[s.Key] = s
+	}
+
+	for _, k := range keys {
+		prev, ok := existing[k]
+		if !ok {
+			return tx.fail(ctx, fmt.Errorf("could not remove variable %q: not found", k))
+		}
+
+		if err := tx.client.DeletePipelineSecret(ctx, prev.ID); err != nil {
+			return tx.fail(ctx, fmt.Errorf("could not remove variable %q: %w", k, err))
+		}
+
+		tx.undo = append(tx.undo, func(ctx context.Context) error {
+			_, err := tx.client.CreatePipelineSecret(ctx, tx.pipelineID, cloud.CreatePipelineSecret{Key: prev.Key, Value: prev.Value})
+			return err
+		})
+	}
+
+	return nil
+}
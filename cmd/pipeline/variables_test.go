@@ -0,0 +1,63 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+func TestParseVariables(t *testing.T) {
+	secrets, removed, err := parseVariables([]string{"REGION=us-east-1", "CLUSTER=prod=1", "REGION=eu-west-1", "OLD-"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(secrets) != 2 ||
+		secrets[0].Key != "CLUSTER" || string(secrets[0].Value) != "prod=1" ||
+		secrets[1].Key != "REGION" || string(secrets[1].Value) != "eu-west-1" {
+		t.Errorf("unexpected secrets %+v", secrets)
+	}
+	if len(removed) != 1 || removed[0] != "OLD" {
+		t.Errorf("unexpected removed %v", removed)
+	}
+
+	for _, pair := range []string{"REGION", "1REGION=x", "MY-VAR=x", "1OLD-"} {
+		if _, _, err := parseVariables([]string{pair}); err == nil {
+			t.Errorf("expected error for %q", pair)
+		}
+	}
+}
+
+func TestAssetsTransaction_removeSecrets(t *testing.T) {
+	newClient := func() *fakeAssetsClient {
+		return &fakeAssetsClient{secrets: map[string]cloud.PipelineSecret{
+			"secret-A": {ID: "secret-A", Key: "A", Value: []byte("a")},
+			"secret-B": {ID: "secret-B", Key: "B", Value: []byte("b")},
+		}}
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		client := newClient()
+		tx := &assetsTransaction{client: client, pipelineID: "pipeline"}
+		if err := tx.removeSecrets(context.Background(), []string{"A"}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := client.secrets["secret-A"]; ok || len(client.secrets) != 1 {
+			t.Errorf("expected only secret A to be removed, got %+v", client.secrets)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		client := newClient()
+		tx := &assetsTransaction{client: client, pipelineID: "pipeline"}
+		if err := tx.removeSecrets(context.Background(), []string{"A", "C"}); err == nil {
+			t.Fatal("expected missing variable to fail")
+		}
+
+		if s, ok := client.secrets["secret-A"]; !ok || string(s.Value) != "a" || len(client.secrets) != 2 {
+			t.Errorf("expected removed secret A to be restored, got %+v", client.secrets)
+		}
+	})
+}
@@ -0,0 +1,236 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	pipelineFileTypeWASM = "wasm"
+
+	// maxWASMSize keeps filter modules within what a pipeline file can hold.
+	maxWASMSize = 10 << 20
+
+	wasmSectionMemory = 5
+	wasmSectionExport = 7
+	wasmExportFunc    = 0
+	// memory64 limits are flagged with bit 0x04. Fluent Bit runs
+	// filters with WAMR on wasm32 only.
+	wasmLimitsMemory64 = 0x04
+)
+
+var wasmHeader = []byte{0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00}
+
+// wasmModule holds what gets checked from a WASM filter module.
+type wasmModule struct {
+	// Functions exported by the module, ie: the filter function_name.
+	Functions []string
+}
+
+// isWASMFile reports whether the pipeline file should be validated
+// as a WASM module, given the --type flag or its extension.
+func isWASMFile(name, fileType string) bool {
+	return fileType == pipelineFileTypeWASM || strings.EqualFold(filepath.Ext(name), ".wasm")
+}
+
+// parseWASM validates the contents are a wasm32 binary module of
+// a size Fluent Bit can load and returns its exported functions.
+func parseWASM(b []byte) (wasmModule, error) {
+	var out wasmModule
+
+	if len(b) > maxWASMSize {
+		return out, fmt.Errorf("wasm module is %d bytes, it must not exceed %d bytes", len(b), maxWASMSize)
+	}
+
+	if !bytes.HasPrefix(b, wasmHeader) {
+		return out, errors.New("not a wasm binary module, expected version 1 header")
+	}
+
+	r := bytes.NewReader(b[len(wasmHeader):])
+	for r.Len() != 0 {
+		id, err := r.ReadByte()
+		if err != nil {
+			return out, err
+		}
+
+		size, err := binary.ReadUvarint(r)
+		if err != nil || size > uint64(r.Len()) {
+			return out, errors.New("invalid wasm module: truncated section")
+		}
+
+		section := make([]byte, size)
+		if _, err := r.Read(section); err != nil {
+			return out, err
+		}
+
+		switch id {
+		case wasmSectionMemory:
+			if err := checkWASMMemory(section); err != nil {
+				return out, err
+			}
+		case wasmSectionExport:
+			out.Functions, err = wasmExportedFunctions(section)
+			if err != nil {
+				return out, err
+			}
+		}
+	}
+
+	return out, nil
+}
+
+func checkWASMMemory(section []byte) error {
+	r := bytes.NewReader(section)
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return errors.New("invalid wasm module: memory section")
+	}
+
+	for i := uint64(0); i < count; i++ {
+		flags, err := r.ReadByte()
+		if err != nil {
+			return errors.New("invalid wasm module: memory section")
+		}
+
+		if flags&wasmLimitsMemory64 != 0 {
+			return errors.New("wasm module uses 64-bit memory, fluent-bit only supports wasm32 modules")
+		}
+
+		limits := 1
+		if flags&0x01 != 0 {
+			limits = 2
+		}
+		for j := 0; j < limits; j++ {
+			if _, err := binary.ReadUvarint(r); err != nil {
+				return errors.New("invalid wasm module: memory section")
+			}
+		}
+	}
+
+	return nil
+}
+
+func wasmExportedFunctions(section []byte) ([]string, error) {
+	r := bytes.NewReader(section)
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, errors.New("invalid wasm module: export section")
+	}
+
+	var out []string
+	for i := uint64(0); i < count; i++ {
+		n, err := binary.ReadUvarint(r)
+		if err != nil || n > uint64(r.Len()) {
+			return nil, errors.New("invalid wasm module: export section")
+		}
+
+		name := make([]byte, n)
+		if _, err := r.Read(name); err != nil {
+			return nil, err
+		}
+
+		kind, err := r.ReadByte()
+		if err != nil {
+			return nil, errors.New("invalid wasm module: export section")
+		}
+
+		if _, err := binary.ReadUvarint(r); err != nil {
+			return nil, errors.New("invalid wasm module: export section")
+		}
+
+		if kind == wasmExportFunc {
+			out = append(out, string(name))
+		}
+	}
+
+	return out, nil
+}
+
+// wasmPathPattern matches a wasm_path property on classic, YAML or JSON
+// configs pointing to a local .wasm file.
+var wasmPathPattern = regexp.MustCompile(`(?im)^(\s*"?wasm_path"?\s*:?\s*"?)([^"\s{}]+\.wasm)("?)`)
+
+// injectWASMPaths replaces the wasm_path properties pointing to a local
+// module uploaded along with the pipeline by a {{files.NAME}} reference,
+// which the core instance resolves to the path where the file is mounted.
+// The given files map the module file base name to the pipeline file name.
+func injectWASMPaths(rawConfig string, files map[string]string) string {
+	return wasmPathPattern.ReplaceAllStringFunc(rawConfig, func(match string) string {
+		m := wasmPathPattern.FindStringSubmatch(match)
+		name, ok := files[filepath.Base(m[2])]
+		if !ok {
+			return match
+		}
+
+		return m[1] + "{{files." + name + "}}" + m[3]
+	})
+}
+
+// checkWASMFunctions verifies the function_name of the wasm filters
+// referencing the given modules is exported by them.
+func checkWASMFunctions(rawConfig string, modules map[string]wasmModule) error {
+	var ref string
+	for _, line := range strings.Split(rawConfig, "\n") {
+		key, value, ok := configProperty(line)
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "wasm_path":
+			ref = ""
+			if m := fileRefPattern.FindStringSubmatch(value); m != nil {
+				ref = m[1]
+			}
+		case "function_name":
+			mod, ok := modules[ref]
+			if ref == "" || !ok {
+				continue
+			}
+
+			if !containsString(mod.Functions, value) {
+				return fmt.Errorf("wasm module %q does not export function %q, exported: %s", ref, value, strings.Join(mod.Functions, ", "))
+			}
+		}
+	}
+
+	return nil
+}
+
+var fileRefPattern = regexp.MustCompile(`^\{\{\s*files\.([A-Za-z0-9_-]+)\s*\}\}$`)
+
+// configProperty parses a single "key value" classic line,
+// "key: value" YAML line or "key": "value" JSON line.
+func configProperty(line string) (string, string, bool) {
+	line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "- "))
+	if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") {
+		return "", "", false
+	}
+
+	key, value, ok := strings.Cut(line, ":")
+	if !ok || strings.ContainsAny(key, " \t") && !strings.HasPrefix(key, `"`) {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return "", "", false
+		}
+		key, value = fields[0], strings.Join(fields[1:], " ")
+	}
+
+	key = strings.ToLower(strings.Trim(strings.TrimSpace(key), `"`))
+	value = strings.Trim(strings.TrimSuffix(strings.TrimSpace(value), ","), `"`)
+	return key, strings.TrimSpace(value), true
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+)
+
+// testWASMModule builds a module with the given memory limits flags
+// exporting a single function.
+func testWASMModule(memoryFlags byte, export string) []byte {
+	b := append([]byte{}, wasmHeader...)
+	b = append(b, wasmSectionMemory, 3, 1, memoryFlags, 1)
+	exports := append([]byte{1, byte(len(export))}, export...)
+	exports = append(exports, wasmExportFunc, 0)
+	b = append(b, wasmSectionExport, byte(len(exports)))
+	return append(b, exports...)
+}
+
+func Test_parseWASM(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		mod, err := parseWASM(testWASMModule(0x00, "go_filter"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(mod.Functions) != 1 || mod.Functions[0] != "go_filter" {
+			t.Errorf("unexpected exported functions %v", mod.Functions)
+		}
+	})
+
+	t.Run("memory64", func(t *testing.T) {
+		_, err := parseWASM(testWASMModule(wasmLimitsMemory64, "go_filter"))
+		if err == nil || !strings.Contains(err.Error(), "64-bit") {
+			t.Errorf("expected memory64 error, got %v", err)
+		}
+	})
+
+	t.Run("not_wasm", func(t *testing.T) {
+		if _, err := parseWASM([]byte("#!/bin/sh\n")); err == nil {
+			t.Error("expected invalid header error")
+		}
+	})
+
+	t.Run("too_big", func(t *testing.T) {
+		if _, err := parseWASM(make([]byte, maxWASMSize+1)); err == nil {
+			t.Error("expected size error")
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		b := testWASMModule(0x00, "go_filter")
+		if _, err := parseWASM(b[:len(b)-3]); err == nil {
+			t.Error("expected truncated section error")
+		}
+	})
+}
+
+func Test_injectWASMPaths(t *testing.T) {
+	files := map[string]string{"my_filter.wasm": "my_filter"}
+
+	tt := []struct {
+		name, in, want string
+	}{
+		{
+			name: "classic",
+			in:   "[FILTER]\n    Name      wasm\n    WASM_Path ./filters/my_filter.wasm\n",
+			want: "[FILTER]\n    Name      wasm\n    WASM_Path {{files.my_filter}}\n",
+		},
+		{
+			name: "yaml",
+			in:   "    - name: wasm\n      wasm_path: /tmp/my_filter.wasm\n",
+			want: "    - name: wasm\n      wasm_path: {{files.my_filter}}\n",
+		},
+		{
+			name: "json",
+			in:   `{"name": "wasm", "wasm_path": "my_filter.wasm"}`,
+			want: `{"name": "wasm", "wasm_path": "my_filter.wasm"}`,
+		},
+		{
+			name: "json_multiline",
+			in:   "  \"wasm_path\": \"my_filter.wasm\",\n",
+			want: "  \"wasm_path\": \"{{files.my_filter}}\",\n",
+		},
+		{
+			name: "unknown",
+			in:   "    WASM_Path ./other.wasm\n",
+			want: "    WASM_Path ./other.wasm\n",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := injectWASMPaths(tc.in, files); got != tc.want {
+				t.Errorf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_checkWASMFunctions(t *testing.T) {
+	modules := map[string]wasmModule{"my_filter": {Functions: []string{"go_filter"}}}
+
+	ok := "[FILTER]\n    Name          wasm\n    WASM_Path     {{ files.my_filter }}\n    Function_Name go_filter\n"
+	if err := checkWASMFunctions(ok, modules); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	missing := "    - name: wasm\n      wasm_path: \"{{files.my_filter}}\"\n      function_name: rust_filter\n"
+	if err := checkWASMFunctions(missing, modules); err == nil {
+		t.Error("expected not exported function error")
+	}
+}
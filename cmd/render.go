@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/calyptia/cli/cmd/fleet"
+	"github.com/calyptia/cli/cmd/pipeline"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/features"
+)
+
+func newCmdRender(config *cfg.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "render",
+		Short: "Render configs with their includes and variables resolved",
+	}
+
+	cmd.AddCommand(pipeline.NewCmdRenderPipelineConfig(config))
+	cmd.AddCommand(features.Require(features.Fleets,
+		fleet.NewCmdRenderFleetConfig(config),
+	)...)
+
+	return cmd
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"os"
@@ -15,14 +16,24 @@ import (
 	cnfg "github.com/calyptia/cli/cmd/config"
 	"github.com/calyptia/cli/cmd/top"
 	"github.com/calyptia/cli/cmd/version"
+	"github.com/calyptia/cli/cmd/workspace"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/features"
+	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/httpcache"
+	"github.com/calyptia/cli/httptransport"
+	"github.com/calyptia/cli/idempotency"
 	"github.com/calyptia/cli/localdata"
+	"github.com/calyptia/cli/redact"
+	"github.com/calyptia/cli/state"
 )
 
 func NewRootCmd(ctx context.Context) *cobra.Command {
-	client := &cloudclient.Client{
-		Client: http.DefaultClient,
+	transport := httptransport.New()
+	httpClient := &http.Client{
+		Transport: idempotency.NewTransport(httpcache.New(transport, httpcache.DefaultMaxEntries)),
 	}
+	client := &cloudclient.Client{Client: httpClient}
 
 	storageDir := os.Getenv("CALYPTIA_STORAGE_DIR")
 	if storageDir == "" {
@@ -34,10 +45,12 @@ func NewRootCmd(ctx context.Context) *cobra.Command {
 	}
 
 	localData := localdata.New(cnfg.ServiceName, storageDir)
+	stateFile := &state.File{LocalData: localData}
 	config := &cfg.Config{
 		Ctx:       ctx,
 		Cloud:     client,
 		LocalData: localData,
+		State:     stateFile,
 	}
 
 	token, err := localData.Get(cnfg.KeyToken)
@@ -54,7 +67,10 @@ func NewRootCmd(ctx context.Context) *cobra.Command {
 		cloudURLStr = version.DefaultCloudURLStr
 	}
 
+	var transportFlags httptransport.Flags
 	cobra.OnInitialize(func() {
+		transportFlags.Apply(httpClient, transport)
+
 		cloudURL, err := url.Parse(cloudURLStr)
 		if err != nil {
 			cobra.CheckErr(fmt.Errorf("invalid cloud url: %w", err))
@@ -81,11 +97,44 @@ func NewRootCmd(ctx context.Context) *cobra.Command {
 		config.ProjectToken = token
 		config.ProjectID = projectID
 	})
+
+	var quiet bool
+	var timeOptions formatters.TimeOptions
+	detector := &features.Detector{Cloud: client, LocalData: localData}
 	cmd := &cobra.Command{
 		Use:           "calyptia",
 		Short:         "Calyptia Cloud CLI",
+		Version:       version.Version,
 		SilenceErrors: true,
 		SilenceUsage:  true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if quiet {
+				// progress, warnings and any other human output goes to stderr,
+				// errors are still reported by main.
+				cmd.Root().SetErr(io.Discard)
+			}
+
+			formatters.SetTimeOptions(timeOptions)
+
+			if err := workspace.ApplyDefaults(config, cmd); err != nil {
+				return err
+			}
+
+			f, ok := features.Required(cmd)
+			if !ok {
+				return nil
+			}
+
+			detector.BaseURL = config.BaseURL
+			support, err := detector.Detect(cmd.Context())
+			if err != nil {
+				// do not block the command if the version cannot be detected,
+				// the request itself will report any error.
+				return nil
+			}
+
+			return support.Check(f)
+		},
 	}
 
 	cmd.SetOut(os.Stdout)
@@ -94,19 +143,63 @@ func NewRootCmd(ctx context.Context) *cobra.Command {
 	fs.StringVar(&cloudURLStr, "cloud-url", cfg.Env("CALYPTIA_CLOUD_URL", cloudURLStr), "Calyptia Cloud URL")
 	fs.StringVar(&token, "token", cfg.Env("CALYPTIA_CLOUD_TOKEN", token), "Calyptia Cloud Project token")
 	fs.Lookup("token").DefValue = "check with the 'calyptia config current_token' command"
+	transportFlags.Bind(fs)
+	fs.BoolVarP(&quiet, "quiet", "q", false, "Suppress progress and informational messages, only the requested data gets printed")
+	formatters.BindTimeFlags(fs, &timeOptions)
+	state.BindFlag(cmd, stateFile)
+	redact.BindFlag(cmd)
 
 	cmd.AddCommand(
 		newCmdConfig(config),
 		newCmdCreate(config),
 		newCmdGet(config),
 		newCmdUpdate(config),
+		newCmdEdit(config),
+		newCmdDiff(config),
 		newCmdRollout(config),
+		newCmdPause(config),
+		newCmdResume(config),
+		newCmdLint(),
+		newCmdCheck(config),
+		newCmdMigrate(config),
+		newCmdDebug(config),
+		newCmdLogs(config),
+		newCmdRender(config),
+		newCmdGenerate(),
 		newCmdInstall(),
 		newCmdUninstall(),
 		newCmdDelete(config),
+		newCmdGC(config),
+		newCmdDestroy(config),
+		newCmdState(config),
+		newCmdStatus(config),
 		top.NewCmdTop(config),
 		version.NewVersionCommand(),
+		newCmdAlias(config),
+		newCmdCI(config),
+		newCmdServe(config),
+		workspace.NewCmdWorkspace(config),
 	)
 
+	defaultHelp := cmd.HelpFunc()
+	cmd.SetHelpFunc(func(c *cobra.Command, args []string) {
+		// only hide commands already known as unsupported,
+		// printing help must not wait on the network.
+		detector.BaseURL = cloudURLStr
+		if support, ok := detector.Cached(); ok {
+			features.Hide(cmd, support)
+		}
+		defaultHelp(c, args)
+	})
+
+	applyAliases(cmd)
+
+	// a broken alias store must not block every command, alias delete included.
+	if aliases, err := loadUserAliases(config); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	} else if args, ok := expandUserAlias(cmd, os.Args[1:], aliases); ok {
+		cmd.SetArgs(args)
+	}
+
 	return cmd
 }
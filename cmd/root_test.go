@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/zalando/go-keyring"
+)
+
+func TestNewRootCmd_quiet(t *testing.T) {
+	keyring.MockInit()
+	t.Setenv("CALYPTIA_STORAGE_DIR", t.TempDir())
+	t.Setenv("CALYPTIA_CLOUD_TOKEN", "")
+
+	for _, quiet := range []bool{false, true} {
+		root := NewRootCmd(context.Background())
+		root.AddCommand(&cobra.Command{
+			Use: "noop",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				cmd.PrintErrln("progress")
+				cmd.Println("data")
+				return nil
+			},
+		})
+
+		var stdout, stderr bytes.Buffer
+		root.SetOut(&stdout)
+		root.SetErr(&stderr)
+		args := []string{"noop"}
+		if quiet {
+			args = append(args, "--quiet")
+		}
+		root.SetArgs(args)
+		if err := root.ExecuteContext(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if stdout.String() != "data\n" {
+			t.Errorf("quiet=%v: want data on stdout, got %q", quiet, stdout.String())
+		}
+
+		wantStderr := "progress\n"
+		if quiet {
+			wantStderr = ""
+		}
+		if stderr.String() != wantStderr {
+			t.Errorf("quiet=%v: want stderr %q, got %q", quiet, wantStderr, stderr.String())
+		}
+	}
+}
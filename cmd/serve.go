@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/calyptia/cli/cmd/serve"
+	cfg "github.com/calyptia/cli/config"
+)
+
+func newCmdServe(config *cfg.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve CLI operations over the network",
+	}
+
+	cmd.AddCommand(
+		serve.NewCmdServeAPI(config),
+	)
+
+	return cmd
+}
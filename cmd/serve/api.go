@@ -0,0 +1,417 @@
+package serve
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	cloud "github.com/calyptia/api/types"
+	"github.com/calyptia/cli/cmd/pipeline"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/exitcode"
+)
+
+const (
+	// EnvAuthToken is the environment variable setting the default --auth-token.
+	EnvAuthToken = "CALYPTIA_SERVE_AUTH_TOKEN"
+
+	maxRequestBodySize = 10 << 20
+	shutdownTimeout    = 10 * time.Second
+)
+
+// commandFactory builds a CLI command, a new one for every request since
+// commands keep their flag values.
+type commandFactory func(config *cfg.Config) *cobra.Command
+
+// apiCommands are the CLI commands the API runs.
+type apiCommands struct {
+	createPipeline commandFactory
+	updatePipeline commandFactory
+	getPipeline    commandFactory
+}
+
+var defaultAPICommands = apiCommands{
+	createPipeline: pipeline.NewCmdCreatePipeline,
+	updatePipeline: pipeline.NewCmdUpdatePipeline,
+	getPipeline:    pipeline.NewCmdGetPipeline,
+}
+
+type createPipelineRequest struct {
+	CoreInstance string `json:"coreInstance"`
+	Environment  string `json:"environment"`
+	Name         string `json:"name"`
+	Config       string `json:"config"`
+	ConfigFormat string `json:"configFormat"`
+	Replicas     *uint  `json:"replicas"`
+}
+
+type updatePipelineConfigRequest struct {
+	Config       string `json:"config"`
+	ConfigFormat string `json:"configFormat"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+func NewCmdServeAPI(config *cfg.Config) *cobra.Command {
+	var listen, authToken, tlsCert, tlsKey string
+
+	cmd := &cobra.Command{
+		Use:   "api",
+		Short: "Serve an HTTP API running CLI operations with the CLI credentials",
+		Long: "Serve a small REST API so internal tools can operate Calyptia Cloud through the CLI\n" +
+			"without each of them holding a project token. Requests authenticate with\n" +
+			"\"Authorization: Bearer <auth-token>\" and run the same code as the matching command:\n\n" +
+			"  POST /v1/pipelines                      calyptia create pipeline\n" +
+			"  PUT  /v1/pipelines/{pipeline}/config    calyptia update pipeline --config-file\n" +
+			"  GET  /v1/pipelines/{pipeline}           calyptia get pipeline, including its status\n" +
+			"  GET  /healthz                           unauthenticated liveness check\n\n" +
+			"Pipelines are referenced by ID or name. Creations honor the Idempotency-Key header.\n" +
+			"Requests run one at a time.\n\n" +
+			"The API listens on localhost by default. Pass --tls-cert and --tls-key before\n" +
+			"listening on other interfaces so the bearer token is not sent in plain text.",
+		Example: "  CALYPTIA_SERVE_AUTH_TOKEN=... calyptia serve api --listen 127.0.0.1:8080\n" +
+			"  curl -H \"Authorization: Bearer $CALYPTIA_SERVE_AUTH_TOKEN\" \\\n" +
+			"    -d '{\"coreInstance\":\"prod\",\"name\":\"logs\",\"config\":\"...\",\"configFormat\":\"yaml\"}' \\\n" +
+			"    http://localhost:8080/v1/pipelines",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if authToken == "" {
+				return fmt.Errorf("no auth token, pass --auth-token or set %s", EnvAuthToken)
+			}
+
+			if config.ProjectToken == "" {
+				return fmt.Errorf("no project token, pass --token or set CALYPTIA_CLOUD_TOKEN")
+			}
+
+			if (tlsCert == "") != (tlsKey == "") {
+				return errors.New("--tls-cert and --tls-key must be passed together")
+			}
+
+			srv := &http.Server{
+				Addr:              listen,
+				Handler:           logRequests(cmd.ErrOrStderr(), newAPIHandler(config, authToken, defaultAPICommands)),
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+
+			ln, err := net.Listen("tcp", listen)
+			if err != nil {
+				return fmt.Errorf("could not listen on %s: %w", listen, err)
+			}
+
+			ctx := cmd.Context()
+			errs := make(chan error, 1)
+			go func() {
+				if tlsCert != "" {
+					errs <- srv.ServeTLS(ln, tlsCert, tlsKey)
+					return
+				}
+				errs <- srv.Serve(ln)
+			}()
+
+			scheme := "http"
+			if tlsCert != "" {
+				scheme = "https"
+			}
+			cmd.PrintErrf("Serving API on %s://%s\n", scheme, ln.Addr())
+
+			select {
+			case err := <-errs:
+				return fmt.Errorf("could not serve api: %w", err)
+			case <-ctx.Done():
+			}
+
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+
+			if err := srv.Shutdown(shutdownCtx); err != nil {
+				return fmt.Errorf("could not shutdown api server: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.StringVar(&listen, "listen", "127.0.0.1:8080", "Address to listen on")
+	fs.StringVar(&tlsCert, "tls-cert", "", "TLS certificate file to serve HTTPS with")
+	fs.StringVar(&tlsKey, "tls-key", "", "TLS private key file matching --tls-cert")
+	fs.StringVar(&authToken, "auth-token", os.Getenv(EnvAuthToken), "Bearer token the API clients must send. Prefer setting "+EnvAuthToken+" so it does not show in the process list")
+
+	return cmd
+}
+
+// apiServer runs CLI commands for API requests.
+type apiServer struct {
+	config    *cfg.Config
+	authToken string
+	commands  apiCommands
+
+	// mu serializes the commands since they share the CLI config and local data.
+	mu sync.Mutex
+}
+
+func newAPIHandler(config *cfg.Config, authToken string, commands apiCommands) http.Handler {
+	s := &apiServer{
+		config:    config,
+		authToken: authToken,
+		commands:  commands,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, "ok\n")
+	})
+	mux.Handle("/v1/", s.authenticate(http.HandlerFunc(s.routePipelines)))
+
+	return mux
+}
+
+func (s *apiServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.authToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// routePipelines dispatches /v1/pipelines, /v1/pipelines/{pipeline}
+// and /v1/pipelines/{pipeline}/config.
+func (s *apiServer) routePipelines(w http.ResponseWriter, r *http.Request) {
+	rest, ok := strings.CutPrefix(r.URL.Path, "/v1/pipelines")
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+
+	parts := strings.Split(strings.Trim(rest, "/"), "/")
+	switch {
+	case rest == "" || rest == "/":
+		s.allow(w, r, http.MethodPost, s.createPipeline)
+	case len(parts) == 1:
+		s.allow(w, r, http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+			s.getPipeline(w, r, parts[0])
+		})
+	case len(parts) == 2 && parts[1] == "config":
+		s.allow(w, r, http.MethodPut, func(w http.ResponseWriter, r *http.Request) {
+			s.updatePipelineConfig(w, r, parts[0])
+		})
+	default:
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+	}
+}
+
+func (s *apiServer) allow(w http.ResponseWriter, r *http.Request, method string, h http.HandlerFunc) {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	h(w, r)
+}
+
+func (s *apiServer) createPipeline(w http.ResponseWriter, r *http.Request) {
+	var in createPipelineRequest
+	if err := decodeBody(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if in.CoreInstance == "" {
+		writeError(w, http.StatusBadRequest, errors.New("coreInstance is required"))
+		return
+	}
+
+	if in.Config == "" {
+		writeError(w, http.StatusBadRequest, errors.New("config is required"))
+		return
+	}
+
+	configFile, cleanup, err := writeConfigFile(in.Config, in.ConfigFormat)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer cleanup()
+
+	args := []string{"--core-instance", in.CoreInstance, "--config-file", configFile, "--output-format", "json"}
+	if in.Environment != "" {
+		args = append(args, "--environment", in.Environment)
+	}
+	if in.Name != "" {
+		args = append(args, "--name", in.Name)
+	}
+	if in.ConfigFormat != "" {
+		args = append(args, "--config-format", in.ConfigFormat)
+	}
+	if in.Replicas != nil {
+		args = append(args, "--replicas", strconv.FormatUint(uint64(*in.Replicas), 10))
+	}
+	if key := r.Header.Get("Idempotency-Key"); key != "" {
+		args = append(args, "--idempotency-key", key)
+	}
+
+	s.run(w, r, http.StatusCreated, s.commands.createPipeline, args)
+}
+
+func (s *apiServer) updatePipelineConfig(w http.ResponseWriter, r *http.Request, pipelineKey string) {
+	var in updatePipelineConfigRequest
+	if err := decodeBody(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if in.Config == "" {
+		writeError(w, http.StatusBadRequest, errors.New("config is required"))
+		return
+	}
+
+	configFile, cleanup, err := writeConfigFile(in.Config, in.ConfigFormat)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer cleanup()
+
+	args := []string{"--config-file", configFile, "--yes", "--output-format", "json"}
+	if in.ConfigFormat != "" {
+		args = append(args, "--config-format", in.ConfigFormat)
+	}
+	// the key comes from the URL, keep it from being parsed as a flag.
+	args = append(args, "--", pipelineKey)
+
+	s.run(w, r, http.StatusOK, s.commands.updatePipeline, args)
+}
+
+func (s *apiServer) getPipeline(w http.ResponseWriter, r *http.Request, pipelineKey string) {
+	s.run(w, r, http.StatusOK, s.commands.getPipeline, []string{"--output-format", "json", "--", pipelineKey})
+}
+
+// run executes the command with the given args and writes its JSON output.
+func (s *apiServer) run(w http.ResponseWriter, r *http.Request, status int, newCmd commandFactory, args []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stdout, stderr bytes.Buffer
+	cmd := newCmd(s.config)
+	cmd.SetArgs(args)
+	cmd.SetIn(strings.NewReader(""))
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+
+	if err := cmd.ExecuteContext(r.Context()); err != nil {
+		writeError(w, statusFromError(err), err)
+		return
+	}
+
+	if !json.Valid(stdout.Bytes()) {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("unexpected command output: %s", strings.TrimSpace(stdout.String())))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(stdout.Bytes())
+}
+
+// writeConfigFile writes the config into a temporary file, named after the
+// config format so the commands can infer it, for the --config-file flag.
+func writeConfigFile(config, format string) (string, func(), error) {
+	ext := ".conf"
+	switch cloud.ConfigFormat(format) {
+	case cloud.ConfigFormatYAML:
+		ext = ".yaml"
+	case cloud.ConfigFormatJSON:
+		ext = ".json"
+	}
+
+	dir, err := os.MkdirTemp("", "calyptia-serve-")
+	if err != nil {
+		return "", nil, fmt.Errorf("could not create temporary directory: %w", err)
+	}
+
+	cleanup := func() { _ = os.RemoveAll(dir) }
+
+	name := filepath.Join(dir, "config"+ext)
+	if err := os.WriteFile(name, []byte(config), 0o600); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("could not write config file: %w", err)
+	}
+
+	return name, cleanup, nil
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("could not decode request body: %w", err)
+	}
+	return nil
+}
+
+// statusFromError maps the CLI exit code of the error to an HTTP status.
+func statusFromError(err error) int {
+	switch exitcode.FromError(err) {
+	case exitcode.NotFound:
+		return http.StatusNotFound
+	case exitcode.Auth:
+		return http.StatusForbidden
+	case exitcode.Conflict:
+		return http.StatusConflict
+	case exitcode.Timeout:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(apiError{Error: err.Error()})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// logRequests logs one line per request with its status and duration.
+func logRequests(w io.Writer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: rw, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		fmt.Fprintf(w, "%s %s %s %d %s\n", time.Now().Format(time.RFC3339), r.Method, r.URL.Path, rec.status, time.Since(start).Round(time.Millisecond))
+	})
+}
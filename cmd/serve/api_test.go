@@ -0,0 +1,178 @@
+package serve
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/exitcode"
+)
+
+// fakeCommand records the args and set flags it was run with and prints the given output.
+func fakeCommand(gotArgs *[]string, gotConfig *string, out string, err error) commandFactory {
+	return func(config *cfg.Config) *cobra.Command {
+		cmd := &cobra.Command{
+			RunE: func(cmd *cobra.Command, args []string) error {
+				*gotArgs = args
+				cmd.Flags().Visit(func(f *pflag.Flag) {
+					*gotArgs = append(*gotArgs, "--"+f.Name, f.Value.String())
+				})
+				if gotConfig != nil {
+					if f, _ := cmd.Flags().GetString("config-file"); f != "" {
+						b, err := os.ReadFile(f)
+						if err != nil {
+							return err
+						}
+						*gotConfig = f + ":" + string(b)
+					}
+				}
+				cmd.Print(out)
+				return err
+			},
+		}
+		fs := cmd.Flags()
+		for _, name := range []string{"core-instance", "config-file", "config-format", "output-format", "name", "environment", "replicas", "idempotency-key"} {
+			fs.String(name, "", "")
+		}
+		fs.Bool("yes", false, "")
+		return cmd
+	}
+}
+
+func doRequest(h http.Handler, method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func Test_apiServer_auth(t *testing.T) {
+	var args []string
+	h := newAPIHandler(&cfg.Config{}, "secret", apiCommands{
+		getPipeline: fakeCommand(&args, nil, `{"id":"pipeline-id"}`, nil),
+	})
+
+	if rec := doRequest(h, http.MethodGet, "/healthz", "", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected healthz to need no auth, got %d", rec.Code)
+	}
+
+	for _, token := range []string{"", "wrong"} {
+		if rec := doRequest(h, http.MethodGet, "/v1/pipelines/foo", token, ""); rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected %d with token %q, got %d", http.StatusUnauthorized, token, rec.Code)
+		}
+	}
+
+	rec := doRequest(h, http.MethodGet, "/v1/pipelines/foo", "secret", "")
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"id":"pipeline-id"}` {
+		t.Errorf("unexpected response %d: %s", rec.Code, rec.Body)
+	}
+
+	if strings.Join(args, " ") != "foo --output-format json" {
+		t.Errorf("unexpected args %q", args)
+	}
+}
+
+func Test_apiServer_createPipeline(t *testing.T) {
+	var args []string
+	var config string
+	h := newAPIHandler(&cfg.Config{}, "secret", apiCommands{
+		createPipeline: fakeCommand(&args, &config, `{"id":"pipeline-id"}`, nil),
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/pipelines", strings.NewReader(`{"coreInstance":"prod","name":"logs","config":"pipeline: {}","configFormat":"yaml","replicas":2}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Idempotency-Key", "deploy-1")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body)
+	}
+
+	if !strings.HasSuffix(config, ".yaml:pipeline: {}") {
+		t.Errorf("unexpected config file %q", config)
+	}
+
+	joined := strings.Join(args, " ")
+	for _, want := range []string{"--core-instance prod", "--name logs", "--config-format yaml", "--replicas 2", "--idempotency-key deploy-1", "--output-format json"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected args to contain %q, got %q", want, joined)
+		}
+	}
+
+	if rec := doRequest(h, http.MethodPost, "/v1/pipelines", "secret", `{"config":"x"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected %d without core instance, got %d", http.StatusBadRequest, rec.Code)
+	}
+
+	if rec := doRequest(h, http.MethodGet, "/v1/pipelines", "secret", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected %d, got %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+}
+
+func Test_apiServer_updatePipelineConfig(t *testing.T) {
+	var args []string
+	var config string
+	h := newAPIHandler(&cfg.Config{}, "secret", apiCommands{
+		updatePipeline: fakeCommand(&args, &config, "", exitcode.WithCode(exitcode.NotFound, errors.New("could not find pipeline"))),
+	})
+
+	rec := doRequest(h, http.MethodPut, "/v1/pipelines/foo/config", "secret", `{"config":"[INPUT]\n  Name dummy"}`)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected %d, got %d: %s", http.StatusNotFound, rec.Code, rec.Body)
+	}
+
+	if !strings.Contains(rec.Body.String(), "could not find pipeline") {
+		t.Errorf("expected the command error, got %s", rec.Body)
+	}
+
+	if !strings.HasSuffix(config, ".conf:[INPUT]\n  Name dummy") {
+		t.Errorf("unexpected config file %q", config)
+	}
+
+	if len(args) == 0 || args[0] != "foo" || !strings.Contains(strings.Join(args, " "), "--yes true") {
+		t.Errorf("unexpected args %q", args)
+	}
+}
+
+func Test_apiServer_getPipeline_flagLikeKey(t *testing.T) {
+	var args []string
+	h := newAPIHandler(&cfg.Config{}, "secret", apiCommands{
+		getPipeline: fakeCommand(&args, nil, `{"id":"x"}`, nil),
+	})
+
+	rec := doRequest(h, http.MethodGet, "/v1/pipelines/--help", "secret", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+
+	if len(args) == 0 || args[0] != "--help" {
+		t.Errorf("expected the pipeline key as an argument, got %q", args)
+	}
+}
+
+func Test_statusFromError(t *testing.T) {
+	tt := []struct {
+		err  error
+		want int
+	}{
+		{exitcode.WithCode(exitcode.NotFound, errors.New("x")), http.StatusNotFound},
+		{exitcode.WithCode(exitcode.Conflict, errors.New("x")), http.StatusConflict},
+		{exitcode.WithCode(exitcode.Auth, errors.New("x")), http.StatusForbidden},
+		{errors.New("invalid config"), http.StatusUnprocessableEntity},
+	}
+	for _, tc := range tt {
+		if got := statusFromError(tc.err); got != tc.want {
+			t.Errorf("statusFromError(%v) = %d, want %d", tc.err, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/state"
+)
+
+func newCmdState(config *cfg.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "state",
+		Short: "Inspect the resources recorded in the local state file",
+		Long: "Resources created while a state file is set, with --state-file or " + state.EnvFile + ",\n" +
+			"are recorded along with the command that created them so they can be\n" +
+			"deleted at once with `calyptia destroy --from-state`.",
+	}
+
+	cmd.AddCommand(newCmdStateList(config))
+
+	return cmd
+}
+
+func newCmdStateList(config *cfg.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the resources recorded in the local state file",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resources, err := config.State.Load()
+			if err != nil {
+				return err
+			}
+
+			fs := cmd.Flags()
+			outputFormat := formatters.OutputFormatFromFlags(fs)
+			if fn, ok := formatters.ShouldApplyTemplating(outputFormat); ok {
+				return fn(cmd.OutOrStdout(), formatters.TemplateFromFlags(fs), resources)
+			}
+
+			switch outputFormat {
+			case formatters.OutputFormatJSON:
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(resources)
+			case formatters.OutputFormatYAML:
+				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(resources)
+			default:
+				return renderStateResources(cmd.OutOrStdout(), resources)
+			}
+		},
+	}
+
+	formatters.BindFormatFlags(cmd)
+
+	return cmd
+}
+
+func renderStateResources(w io.Writer, resources []state.Resource) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tID\tNAME\tPROJECT\tCOMMAND\tAGE")
+	for _, r := range resources {
+		name := r.Name
+		if name == "" {
+			name = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Kind, r.ID, name, r.ProjectID, r.Command, formatters.FmtTime(r.CreatedAt))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,401 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	semver "github.com/hashicorp/go-version"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	cloud "github.com/calyptia/api/types"
+	cnfg "github.com/calyptia/cli/cmd/config"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/k8s"
+)
+
+// staleAgentAfter is how long since its last metrics an agent is considered stale.
+const staleAgentAfter = time.Minute * 5
+
+// projectStatus is a one-screen overview of the current project.
+type projectStatus struct {
+	Cloud         cloudStatus             `json:"cloud" yaml:"cloud"`
+	Token         *tokenStatus            `json:"token,omitempty" yaml:"token,omitempty"`
+	Defaults      []cnfg.EffectiveDefault `json:"defaults" yaml:"defaults"`
+	CoreInstances []coreInstanceStatus    `json:"coreInstances" yaml:"coreInstances"`
+	Pipelines     []statusCount           `json:"pipelines" yaml:"pipelines"`
+	Agents        agentsStatus            `json:"agents" yaml:"agents"`
+	// LatestOperatorVersion is the newest core operator release,
+	// empty if it could not be fetched.
+	LatestOperatorVersion string `json:"latestOperatorVersion,omitempty" yaml:"latestOperatorVersion,omitempty"`
+	// Errors of the sections that could not be fetched.
+	Errors []string `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+type cloudStatus struct {
+	URL       string        `json:"url" yaml:"url"`
+	Project   string        `json:"project,omitempty" yaml:"project,omitempty"`
+	Reachable bool          `json:"reachable" yaml:"reachable"`
+	Latency   time.Duration `json:"latency" yaml:"latency"`
+}
+
+// tokenStatus is the project token in use. Project tokens do not expire,
+// they stay valid until deleted.
+type tokenStatus struct {
+	Name        string    `json:"name" yaml:"name"`
+	Permissions []string  `json:"permissions" yaml:"permissions"`
+	CreatedAt   time.Time `json:"createdAt" yaml:"createdAt"`
+}
+
+type coreInstanceStatus struct {
+	Name        string                   `json:"name" yaml:"name"`
+	Environment string                   `json:"environment" yaml:"environment"`
+	Version     string                   `json:"version" yaml:"version"`
+	Status      cloud.CoreInstanceStatus `json:"status" yaml:"status"`
+	Pipelines   uint                     `json:"pipelines" yaml:"pipelines"`
+	// UpgradeTo is the latest core operator version when newer than the running one.
+	UpgradeTo string `json:"upgradeTo,omitempty" yaml:"upgradeTo,omitempty"`
+}
+
+type statusCount struct {
+	Status string `json:"status" yaml:"status"`
+	Count  int    `json:"count" yaml:"count"`
+}
+
+type agentsStatus struct {
+	Active int `json:"active" yaml:"active"`
+	Stale  int `json:"stale" yaml:"stale"`
+}
+
+func newCmdStatus(config *cfg.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Display an overview of the current project",
+		Long: "Display Cloud connectivity, the project token in use, the configured defaults,\n" +
+			"core instances with their health and pending core operator upgrades,\n" +
+			"pipelines by status and active and stale agents, all in one screen.\n" +
+			"Sections that cannot be fetched are reported without failing the others.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if config.ProjectToken == "" {
+				return errors.New("project token is required to realize this action.\nPlease set it with `calyptia config set_token <token>`")
+			}
+
+			status := fetchProjectStatus(cmd.Context(), config)
+
+			fs := cmd.Flags()
+			outputFormat := formatters.OutputFormatFromFlags(fs)
+			if fn, ok := formatters.ShouldApplyTemplating(outputFormat); ok {
+				if err := fn(cmd.OutOrStdout(), formatters.TemplateFromFlags(fs), status); err != nil {
+					return err
+				}
+			} else {
+				var err error
+				switch outputFormat {
+				case formatters.OutputFormatJSON:
+					err = json.NewEncoder(cmd.OutOrStdout()).Encode(status)
+				case formatters.OutputFormatYAML:
+					err = yaml.NewEncoder(cmd.OutOrStdout()).Encode(status)
+				default:
+					err = renderProjectStatus(cmd.OutOrStdout(), status)
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+			if !status.Cloud.Reachable {
+				return fmt.Errorf("could not reach %s", status.Cloud.URL)
+			}
+
+			return nil
+		},
+	}
+
+	formatters.BindFormatFlags(cmd)
+
+	return cmd
+}
+
+// fetchProjectStatus checks the Cloud is reachable and then fetches
+// every other section concurrently, recording their errors.
+func fetchProjectStatus(ctx context.Context, config *cfg.Config) projectStatus {
+	out := projectStatus{Cloud: cloudStatus{URL: config.BaseURL}}
+
+	start := time.Now()
+	project, err := config.Cloud.Project(ctx, config.ProjectID)
+	if err != nil {
+		out.Errors = append(out.Errors, fmt.Sprintf("could not fetch your project: %v", err))
+		return out
+	}
+
+	out.Cloud.Reachable = true
+	out.Cloud.Latency = time.Since(start).Round(time.Millisecond)
+	out.Cloud.Project = project.Name
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	fetch := func(section string, fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(); err != nil {
+				mu.Lock()
+				out.Errors = append(out.Errors, fmt.Sprintf("could not fetch %s: %v", section, err))
+				mu.Unlock()
+			}
+		}()
+	}
+
+	fetch("your project token", func() error {
+		token, err := fetchCurrentToken(ctx, config)
+		out.Token = token
+		return err
+	})
+	fetch("your project defaults", func() error {
+		var err error
+		out.Defaults, err = cnfg.EffectiveDefaults(config)
+		return err
+	})
+	fetch("your core instances", func() error {
+		var err error
+		out.CoreInstances, err = fetchCoreInstancesStatus(ctx, config)
+		return err
+	})
+	fetch("core operator releases", func() error {
+		releases, err := k8s.ListOperatorReleases(ctx, false)
+		if err != nil {
+			return err
+		}
+		if len(releases) != 0 {
+			out.LatestOperatorVersion = releases[0].Version
+		}
+		return nil
+	})
+	fetch("your pipelines", func() error {
+		var err error
+		out.Pipelines, err = fetchPipelinesByStatus(ctx, config)
+		return err
+	})
+	fetch("your agents", func() error {
+		var err error
+		out.Agents, err = fetchAgentsStatus(ctx, config, time.Now())
+		return err
+	})
+
+	wg.Wait()
+
+	sort.Strings(out.Errors)
+	for i, c := range out.CoreInstances {
+		out.CoreInstances[i].UpgradeTo = operatorUpgrade(c.Version, out.LatestOperatorVersion)
+	}
+
+	return out
+}
+
+// fetchCurrentToken finds the project token in use among those of the project.
+func fetchCurrentToken(ctx context.Context, config *cfg.Config) (*tokenStatus, error) {
+	params := cloud.TokensParams{Last: cfg.Ptr(uint(100))}
+	for {
+		tt, err := config.Cloud.Tokens(ctx, config.ProjectID, params)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range tt.Items {
+			if t.Token == config.ProjectToken {
+				return &tokenStatus{Name: t.Name, Permissions: t.Permissions, CreatedAt: t.CreatedAt}, nil
+			}
+		}
+
+		if tt.EndCursor == nil || len(tt.Items) == 0 {
+			return nil, errors.New("project token not found, it may have been deleted")
+		}
+		params.Before = tt.EndCursor
+	}
+}
+
+func fetchCoreInstancesStatus(ctx context.Context, config *cfg.Config) ([]coreInstanceStatus, error) {
+	var out []coreInstanceStatus
+	params := cloud.CoreInstancesParams{Last: cfg.Ptr(uint(100))}
+	for {
+		cc, err := config.Cloud.CoreInstances(ctx, config.ProjectID, params)
+		if err != nil {
+			return out, err
+		}
+
+		for _, c := range cc.Items {
+			out = append(out, coreInstanceStatus{
+				Name:        c.Name,
+				Environment: c.EnvironmentName,
+				Version:     c.Version,
+				Status:      c.Status,
+				Pipelines:   c.PipelinesCount,
+			})
+		}
+
+		if cc.EndCursor == nil || len(cc.Items) == 0 {
+			return out, nil
+		}
+		params.Before = cc.EndCursor
+	}
+}
+
+func fetchPipelinesByStatus(ctx context.Context, config *cfg.Config) ([]statusCount, error) {
+	counts := map[string]int{}
+	params := cloud.PipelinesParams{ProjectID: &config.ProjectID, Last: cfg.Ptr(uint(100))}
+	for {
+		pp, err := config.Cloud.Pipelines(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range pp.Items {
+			status := string(p.Status.Status)
+			if status == "" {
+				status = "unknown"
+			}
+			counts[status]++
+		}
+
+		if pp.EndCursor == nil || len(pp.Items) == 0 {
+			break
+		}
+		params.Before = pp.EndCursor
+	}
+
+	out := make([]statusCount, 0, len(counts))
+	for status, count := range counts {
+		out = append(out, statusCount{Status: status, Count: count})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Status < out[j].Status
+	})
+	return out, nil
+}
+
+func fetchAgentsStatus(ctx context.Context, config *cfg.Config, now time.Time) (agentsStatus, error) {
+	var out agentsStatus
+	params := cloud.AgentsParams{Last: cfg.Ptr(uint(100))}
+	for {
+		aa, err := config.Cloud.Agents(ctx, config.ProjectID, params)
+		if err != nil {
+			return out, err
+		}
+
+		for _, a := range aa.Items {
+			if a.LastMetricsAddedAt == nil || a.LastMetricsAddedAt.Before(now.Add(-staleAgentAfter)) {
+				out.Stale++
+			} else {
+				out.Active++
+			}
+		}
+
+		if aa.EndCursor == nil || len(aa.Items) == 0 {
+			return out, nil
+		}
+		params.Before = aa.EndCursor
+	}
+}
+
+// operatorUpgrade returns the latest version if newer than the current one.
+// Unparseable versions, ie: dev builds, are never reported as upgradable.
+func operatorUpgrade(current, latest string) string {
+	if current == "" || latest == "" {
+		return ""
+	}
+
+	c, err := semver.NewVersion(strings.TrimPrefix(current, "v"))
+	if err != nil {
+		return ""
+	}
+
+	l, err := semver.NewVersion(strings.TrimPrefix(latest, "v"))
+	if err != nil || !l.GreaterThan(c) {
+		return ""
+	}
+
+	return latest
+}
+
+func renderProjectStatus(w io.Writer, status projectStatus) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+
+	if status.Cloud.Reachable {
+		fmt.Fprintf(tw, "CLOUD:\t%s (reachable in %s)\n", status.Cloud.URL, status.Cloud.Latency)
+		fmt.Fprintf(tw, "PROJECT:\t%s\n", status.Cloud.Project)
+	} else {
+		fmt.Fprintf(tw, "CLOUD:\t%s (unreachable)\n", status.Cloud.URL)
+	}
+
+	if status.Token != nil {
+		permissions := "none"
+		if len(status.Token.Permissions) != 0 {
+			permissions = strings.Join(status.Token.Permissions, ", ")
+		}
+		fmt.Fprintf(tw, "TOKEN:\t%s, age %s, does not expire\n", status.Token.Name, formatters.FmtTime(status.Token.CreatedAt))
+		fmt.Fprintf(tw, "PERMISSIONS:\t%s\n", permissions)
+	}
+
+	if len(status.Defaults) != 0 {
+		var defaults []string
+		for _, d := range status.Defaults {
+			defaults = append(defaults, fmt.Sprintf("%s=%s (%s)", d.Setting, d.Value, d.Source))
+		}
+		fmt.Fprintf(tw, "DEFAULTS:\t%s\n", strings.Join(defaults, ", "))
+	}
+
+	if status.Cloud.Reachable {
+		pipelines := "none"
+		if len(status.Pipelines) != 0 {
+			var counts []string
+			for _, c := range status.Pipelines {
+				counts = append(counts, fmt.Sprintf("%d %s", c.Count, c.Status))
+			}
+			pipelines = strings.Join(counts, ", ")
+		}
+		fmt.Fprintf(tw, "PIPELINES:\t%s\n", pipelines)
+		fmt.Fprintf(tw, "AGENTS:\t%d active, %d stale\n", status.Agents.Active, status.Agents.Stale)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(status.CoreInstances) != 0 {
+		fmt.Fprintln(w)
+		tw = tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+		fmt.Fprintln(tw, "CORE-INSTANCE\tENVIRONMENT\tVERSION\tSTATUS\tPIPELINES\tUPGRADE")
+		for _, c := range status.CoreInstances {
+			upgrade := "-"
+			if c.UpgradeTo != "" {
+				upgrade = c.UpgradeTo
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n", c.Name, c.Environment, c.Version, c.Status, c.Pipelines, upgrade)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if len(status.Errors) != 0 {
+		fmt.Fprintln(w)
+		for _, e := range status.Errors {
+			fmt.Fprintf(w, "error: %s\n", e)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/calyptia/api/client"
+	cloud "github.com/calyptia/api/types"
+
+	cfg "github.com/calyptia/cli/config"
+)
+
+func Test_operatorUpgrade(t *testing.T) {
+	tt := []struct {
+		current string
+		latest  string
+		want    string
+	}{
+		{},
+		{current: "v1.0.0"},
+		{latest: "v1.0.0"},
+		{current: "v1.0.0", latest: "v1.1.0", want: "v1.1.0"},
+		{current: "1.0.0", latest: "v1.0.1", want: "v1.0.1"},
+		{current: "v1.1.0", latest: "v1.1.0"},
+		{current: "v1.2.0", latest: "v1.1.0"},
+		{current: "dev", latest: "v1.1.0"},
+		{current: "v1.0.0", latest: "latest"},
+	}
+	for _, tc := range tt {
+		if got := operatorUpgrade(tc.current, tc.latest); got != tc.want {
+			t.Errorf("operatorUpgrade(%q, %q): want %q, got %q", tc.current, tc.latest, tc.want, got)
+		}
+	}
+}
+
+func Test_fetchAgentsStatus(t *testing.T) {
+	now := time.Now().UTC()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/projects/project-1/agents" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"not found"}`))
+			return
+		}
+
+		_, _ = fmt.Fprintf(w, `[{"id":"agent-1","lastMetricsAddedAt":%q},{"id":"agent-2","lastMetricsAddedAt":%q},{"id":"agent-3"}]`,
+			now.Add(-time.Minute).Format(time.RFC3339), now.Add(-staleAgentAfter*2).Format(time.RFC3339))
+	}))
+	defer srv.Close()
+
+	cloudClient := client.New()
+	cloudClient.BaseURL = srv.URL
+	config := &cfg.Config{Ctx: context.Background(), Cloud: cloudClient, ProjectID: "project-1"}
+
+	got, err := fetchAgentsStatus(context.Background(), config, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := (agentsStatus{Active: 1, Stale: 2}); got != want {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
+
+func Test_renderProjectStatus(t *testing.T) {
+	t.Run("reachable", func(t *testing.T) {
+		var out bytes.Buffer
+		err := renderProjectStatus(&out, projectStatus{
+			Cloud:     cloudStatus{URL: "https://cloud-api.calyptia.com", Project: "my-project", Reachable: true, Latency: time.Millisecond * 20},
+			Pipelines: []statusCount{{Status: "STARTED", Count: 2}, {Status: "FAILED", Count: 1}},
+			Agents:    agentsStatus{Active: 3, Stale: 1},
+			CoreInstances: []coreInstanceStatus{
+				{Name: "core-1", Environment: "default", Version: "v1.0.0", Status: cloud.CoreInstanceStatusRunning, Pipelines: 2, UpgradeTo: "v1.1.0"},
+				{Name: "core-2", Environment: "default", Version: "v1.1.0", Status: cloud.CoreInstanceStatusUnreachable, Pipelines: 1},
+			},
+			Errors: []string{"could not fetch your project token: forbidden"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := "CLOUD:     https://cloud-api.calyptia.com (reachable in 20ms)\n" +
+			"PROJECT:   my-project\n" +
+			"PIPELINES: 2 STARTED, 1 FAILED\n" +
+			"AGENTS:    3 active, 1 stale\n" +
+			"\n" +
+			"CORE-INSTANCE ENVIRONMENT VERSION STATUS      PIPELINES UPGRADE\n" +
+			"core-1        default     v1.0.0  running     2         v1.1.0\n" +
+			"core-2        default     v1.1.0  unreachable 1         -\n" +
+			"\n" +
+			"error: could not fetch your project token: forbidden\n"
+		if out.String() != want {
+			t.Errorf("want\n%s\ngot\n%s", want, out.String())
+		}
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		var out bytes.Buffer
+		err := renderProjectStatus(&out, projectStatus{
+			Cloud:  cloudStatus{URL: "https://cloud-api.calyptia.com"},
+			Errors: []string{"could not fetch your project: connection refused"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := "CLOUD: https://cloud-api.calyptia.com (unreachable)\n" +
+			"\n" +
+			"error: could not fetch your project: connection refused\n"
+		if out.String() != want {
+			t.Errorf("want\n%s\ngot\n%s", want, out.String())
+		}
+	})
+}
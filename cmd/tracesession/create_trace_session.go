@@ -3,7 +3,6 @@ package tracesession
 import (
 	"encoding/json"
 	"fmt"
-	"strings"
 	"text/tabwriter"
 	"time"
 
@@ -14,6 +13,8 @@ import (
 	"github.com/calyptia/cli/completer"
 	cfg "github.com/calyptia/cli/config"
 	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/state"
+	"github.com/calyptia/cli/ttl"
 )
 
 func NewCmdCreateTraceSession(config *cfg.Config) *cobra.Command {
@@ -36,6 +37,11 @@ func NewCmdCreateTraceSession(config *cfg.Config) *cobra.Command {
 				return err
 			}
 
+			expiresAt, err := ttl.FromFlags(cmd, time.Now())
+			if err != nil {
+				return err
+			}
+
 			created, err := config.Cloud.CreateTraceSession(config.Ctx, pipelineID, types.CreateTraceSession{
 				Plugins:  plugins,
 				Lifespan: types.Duration(lifespan),
@@ -44,8 +50,31 @@ func NewCmdCreateTraceSession(config *cfg.Config) *cobra.Command {
 				return err
 			}
 
-			if strings.HasPrefix(outputFormat, "go-template") {
-				return formatters.ApplyGoTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, created)
+			if !expiresAt.IsZero() {
+				err := ttl.Track(config.LocalData, ttl.Resource{
+					Kind:      ttl.KindTraceSession,
+					ID:        created.ID,
+					ProjectID: config.ProjectID,
+					ParentID:  pipelineID,
+					ExpiresAt: expiresAt,
+				})
+				if err != nil {
+					return fmt.Errorf("could not record trace session ttl: %w", err)
+				}
+			}
+
+			err = config.State.Track(cmd, state.Resource{
+				Kind:      state.KindTraceSession,
+				ID:        created.ID,
+				ProjectID: config.ProjectID,
+				ParentID:  pipelineID,
+			})
+			if err != nil {
+				return err
+			}
+
+			if formatters.IsTemplating(outputFormat) {
+				return formatters.ApplyTemplate(cmd.OutOrStdout(), outputFormat, goTemplate, created)
 			}
 
 			switch outputFormat {
@@ -68,8 +97,9 @@ func NewCmdCreateTraceSession(config *cfg.Config) *cobra.Command {
 	fs.StringVar(&pipelineKey, "pipeline", "", "Parent pipeline (name or ID) in which to start the trace session")
 	fs.StringSliceVar(&plugins, "plugins", nil, "Fluent-bit plugins to trace")
 	fs.DurationVar(&lifespan, "lifespan", time.Minute*10, "Trace session lifespan")
-	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file")
-	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	ttl.BindFlag(cmd)
+	fs.StringVarP(&outputFormat, "output-format", "o", "table", "Output format. Allowed: table, json, yaml, go-template, go-template-file, custom-columns, custom-columns-file")
+	fs.StringVar(&goTemplate, "template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 
 	_ = cmd.MarkFlagRequired("pipeline")
 
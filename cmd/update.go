@@ -14,6 +14,7 @@ import (
 	"github.com/calyptia/cli/cmd/pipeline"
 	"github.com/calyptia/cli/cmd/project"
 	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/features"
 )
 
 func newCmdUpdate(config *cfg.Config) *cobra.Command {
@@ -26,8 +27,6 @@ func newCmdUpdate(config *cfg.Config) *cobra.Command {
 		project.NewCmdUpdateProject(config),
 		members.NewCmdUpdateMember(config),
 		agent.NewCmdUpdateAgent(config),
-		fleet.NewCmdUpdateFleet(config),
-		fleet.NewCmdUpdateFleetFile(config),
 		pipeline.NewCmdUpdatePipeline(config),
 		pipeline.NewCmdUpdatePipelineSecret(config),
 		pipeline.NewCmdUpdatePipelineFile(config),
@@ -40,7 +39,13 @@ func newCmdUpdate(config *cfg.Config) *cobra.Command {
 		cnfg.NewCmdUpdateConfigSection(config),
 		cnfg.NewCmdUpdateConfigSectionSet(config),
 		operator.NewCmdUpdate(),
+		operator.NewCmdUpdateCRDs(),
 	)
 
+	cmd.AddCommand(features.Require(features.Fleets,
+		fleet.NewCmdUpdateFleet(config),
+		fleet.NewCmdUpdateFleetFile(config),
+	)...)
+
 	return cmd
 }
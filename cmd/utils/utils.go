@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"code.cloudfoundry.org/bytefmt"
+	"github.com/spf13/pflag"
 
 	metrics "github.com/calyptia/cli/metric"
 
@@ -126,3 +127,10 @@ func ZeroOfPtr[T comparable](v *T) T {
 func PtrBytes(v []byte) *[]byte {
 	return &v
 }
+
+// BindNamespaceCreateFlag binds the --namespace-create flag shared by the
+// commands deploying into a kubernetes namespace. A missing namespace is
+// created by default, disabling it makes those commands fail instead.
+func BindNamespaceCreateFlag(fs *pflag.FlagSet, p *bool) {
+	fs.BoolVar(p, "namespace-create", true, "Create the kubernetes namespace if it does not exist. Set to false to fail instead")
+}
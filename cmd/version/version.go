@@ -1,23 +1,106 @@
 package version
 
 import (
+	"encoding/json"
+	"runtime"
+	"runtime/debug"
+
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/calyptia/cli/cmd/utils"
+	"github.com/calyptia/cli/formatters"
 )
 
 var (
 	DefaultCloudURLStr = "https://cloud-api.calyptia.com"
 	Version            = "dev" // To be injected at build time:  -ldflags="-X 'github.com/calyptia/cli/cmd/version.Version=xxx'"
+	// Commit and BuildDate are injected at build time the same way as Version.
+	// When missing, they are taken from the VCS information embedded by the Go toolchain.
+	Commit    = ""
+	BuildDate = ""
 )
 
+// Info holds the build metadata of the CLI along with the default
+// component images it was built to deploy.
+type Info struct {
+	Version   string `json:"version" yaml:"version"`
+	Commit    string `json:"commit" yaml:"commit"`
+	BuildDate string `json:"buildDate" yaml:"buildDate"`
+	GoVersion string `json:"goVersion" yaml:"goVersion"`
+	Platform  string `json:"platform" yaml:"platform"`
+	Images    Images `json:"images" yaml:"images"`
+}
+
+// Images are the default docker images used by the CLI.
+type Images struct {
+	Core                  string `json:"core" yaml:"core"`
+	CoreOperator          string `json:"coreOperator" yaml:"coreOperator"`
+	CoreOperatorToCloud   string `json:"coreOperatorToCloud" yaml:"coreOperatorToCloud"`
+	CoreOperatorFromCloud string `json:"coreOperatorFromCloud" yaml:"coreOperatorFromCloud"`
+}
+
+// Get returns the build metadata of the running binary.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Images: Images{
+			Core:                  utils.DefaultCoreDockerImage + ":" + utils.DefaultCoreDockerImageTag,
+			CoreOperator:          utils.DefaultCoreOperatorDockerImage + ":" + utils.DefaultCoreOperatorDockerImageTag,
+			CoreOperatorToCloud:   utils.DefaultCoreOperatorToCloudDockerImage + ":" + utils.DefaultCoreOperatorToCloudDockerImageTag,
+			CoreOperatorFromCloud: utils.DefaultCoreOperatorFromCloudDockerImage + ":" + utils.DefaultCoreOperatorFromCloudDockerImageTag,
+		},
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = s.Value
+				}
+			}
+		}
+	}
+
+	return info
+}
+
 func NewVersionCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:          "version",
 		Short:        "Returns currenty Calyptia CLI version.",
 		SilenceUsage: true,
-		Run: func(cmd *cobra.Command, args []string) {
-			cmd.Println(Version)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info := Get()
+
+			fs := cmd.Flags()
+			outputFormat := formatters.OutputFormatFromFlags(fs)
+			if fn, ok := formatters.ShouldApplyTemplating(outputFormat); ok {
+				return fn(cmd.OutOrStdout(), formatters.TemplateFromFlags(fs), info)
+			}
+
+			switch outputFormat {
+			case formatters.OutputFormatJSON:
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(info)
+			case formatters.OutputFormatYAML:
+				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(info)
+			default:
+				cmd.Println(info.Version)
+				return nil
+			}
 		},
 	}
 
+	formatters.BindFormatFlags(cmd)
+
 	return cmd
 }
@@ -0,0 +1,55 @@
+package version
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/calyptia/cli/cmd/utils"
+)
+
+func TestNewVersionCommand(t *testing.T) {
+	defer func(version, commit, buildDate string) {
+		Version, Commit, BuildDate = version, commit, buildDate
+	}(Version, Commit, BuildDate)
+	Version, Commit, BuildDate = "v1.2.3", "abc123", "2023-11-09T13:48:25Z"
+
+	run := func(args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		cmd := NewVersionCommand()
+		cmd.SetOut(&out)
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+
+	if got := run(); got != "v1.2.3\n" {
+		t.Errorf("want plain version, got %q", got)
+	}
+
+	var info Info
+	if err := json.Unmarshal([]byte(run("--output-format", "json")), &info); err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Version != "v1.2.3" || info.Commit != "abc123" || info.BuildDate != "2023-11-09T13:48:25Z" {
+		t.Errorf("unexpected build metadata %+v", info)
+	}
+
+	if info.Platform != runtime.GOOS+"/"+runtime.GOARCH || info.GoVersion != runtime.Version() {
+		t.Errorf("unexpected platform %q and go version %q", info.Platform, info.GoVersion)
+	}
+
+	if !strings.HasPrefix(info.Images.Core, utils.DefaultCoreDockerImage+":") {
+		t.Errorf("unexpected core image %q", info.Images.Core)
+	}
+
+	if got := run("--output-format", "go-template", "--template", "{{.Commit}}"); strings.TrimSpace(got) != "abc123" {
+		t.Errorf("want templated commit, got %q", got)
+	}
+}
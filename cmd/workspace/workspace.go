@@ -0,0 +1,447 @@
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	cnfg "github.com/calyptia/cli/cmd/config"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/confirm"
+	"github.com/calyptia/cli/formatters"
+	"github.com/calyptia/cli/localdata"
+)
+
+// KeyWorkspaces is the local data key where workspaces are stored.
+const KeyWorkspaces = "workspaces"
+
+// annotationNoDefaults marks the commands whose flags must not
+// take their default from the workspace in use.
+const annotationNoDefaults = "calyptia.workspace.no-defaults"
+
+// Workspace groups the settings needed to work against a customer
+// deployment, so switching between them is a single command.
+type Workspace struct {
+	Name         string `json:"name" yaml:"name"`
+	CloudURL     string `json:"cloudURL" yaml:"cloudURL"`
+	ProjectToken string `json:"projectToken,omitempty" yaml:"-"`
+	ProjectID    string `json:"projectID,omitempty" yaml:"projectID,omitempty"`
+	Environment  string `json:"environment,omitempty" yaml:"environment,omitempty"`
+	KubeContext  string `json:"kubeContext,omitempty" yaml:"kubeContext,omitempty"`
+	Namespace    string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+}
+
+// store is the stored list of workspaces along with the one in use.
+type store struct {
+	Current    string      `json:"current,omitempty"`
+	Workspaces []Workspace `json:"workspaces"`
+}
+
+func (s store) lookup(name string) (Workspace, bool) {
+	for _, w := range s.Workspaces {
+		if w.Name == name {
+			return w, true
+		}
+	}
+	return Workspace{}, false
+}
+
+// defaultFlags are the flags that get their default from the workspace
+// in use, on the commands that define them.
+var defaultFlags = map[string]func(Workspace) string{
+	"environment":    func(w Workspace) string { return w.Environment },
+	"kube-context":   func(w Workspace) string { return w.KubeContext },
+	"kube-namespace": func(w Workspace) string { return w.Namespace },
+}
+
+func NewCmdWorkspace(config *cfg.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "workspace",
+		Short: "Manage workspaces",
+		Long: "A workspace groups a cloud URL, project token, environment, kubernetes\n" +
+			"context and namespace under a name, so switching between deployments\n" +
+			"is a single 'calyptia workspace use NAME'.\n" +
+			"The environment, kube context and namespace of the workspace in use are\n" +
+			"the defaults of the --environment, --kube-context and --kube-namespace flags.",
+	}
+
+	cmd.AddCommand(
+		newCmdWorkspaceSave(config),
+		newCmdWorkspaceList(config),
+		newCmdWorkspaceUse(config),
+		newCmdWorkspaceShow(config),
+		newCmdWorkspaceDelete(config),
+	)
+
+	return cmd
+}
+
+func newCmdWorkspaceSave(config *cfg.Config) *cobra.Command {
+	var environment, kubeContext, namespace string
+
+	cmd := &cobra.Command{
+		Use:   "save NAME",
+		Short: "Save the current cloud URL and project token as a workspace",
+		Long: "Save the current cloud URL and project token, as given by --cloud-url\n" +
+			"and --token or the configured ones, along with the given environment,\n" +
+			"kube context and namespace as a workspace. Saving an existing\n" +
+			"workspace replaces it.",
+		Args:        cobra.ExactArgs(1),
+		Annotations: map[string]string{annotationNoDefaults: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := strings.TrimSpace(args[0])
+			if name == "" {
+				return errors.New("workspace name cannot be empty")
+			}
+
+			if config.ProjectToken == "" {
+				return errors.New("no project token configured, use --token or 'calyptia config set_token'")
+			}
+
+			s, err := load(config.LocalData)
+			if err != nil {
+				return err
+			}
+
+			w := Workspace{
+				Name:         name,
+				CloudURL:     config.BaseURL,
+				ProjectToken: config.ProjectToken,
+				ProjectID:    config.ProjectID,
+				Environment:  environment,
+				KubeContext:  kubeContext,
+				Namespace:    namespace,
+			}
+
+			var replaced bool
+			for i, existing := range s.Workspaces {
+				if existing.Name == name {
+					s.Workspaces[i] = w
+					replaced = true
+				}
+			}
+			if !replaced {
+				s.Workspaces = append(s.Workspaces, w)
+			}
+
+			if err := save(config.LocalData, s); err != nil {
+				return err
+			}
+
+			cmd.Printf("Workspace %q saved\n", name)
+			return nil
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.StringVar(&environment, "environment", "", "Calyptia environment name")
+	fs.StringVar(&kubeContext, "kube-context", "", "The name of the kubeconfig context to use")
+	fs.StringVar(&namespace, "kube-namespace", "", "The kubernetes namespace to use")
+
+	return cmd
+}
+
+func newCmdWorkspaceList(config *cfg.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List workspaces",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := load(config.LocalData)
+			if err != nil {
+				return err
+			}
+
+			fs := cmd.Flags()
+			outputFormat := formatters.OutputFormatFromFlags(fs)
+			if fn, ok := formatters.ShouldApplyTemplating(outputFormat); ok {
+				return fn(cmd.OutOrStdout(), formatters.TemplateFromFlags(fs), redacted(s.Workspaces))
+			}
+
+			switch outputFormat {
+			case formatters.OutputFormatJSON:
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(redacted(s.Workspaces))
+			case formatters.OutputFormatYAML:
+				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(s.Workspaces)
+			default:
+				return renderWorkspaces(cmd.OutOrStdout(), s)
+			}
+		},
+	}
+
+	formatters.BindFormatFlags(cmd)
+
+	return cmd
+}
+
+func newCmdWorkspaceUse(config *cfg.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:               "use NAME",
+		Short:             "Switch to a workspace",
+		Long:              "Set the cloud URL and project token of the workspace as the configured ones and use it from now on.",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeWorkspaces(config),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := load(config.LocalData)
+			if err != nil {
+				return err
+			}
+
+			w, ok := s.lookup(args[0])
+			if !ok {
+				return fmt.Errorf("workspace %q not found", args[0])
+			}
+
+			if err := config.LocalData.Save(cnfg.KeyBaseURL, w.CloudURL); err != nil {
+				return fmt.Errorf("could not store cloud url: %w", err)
+			}
+
+			if err := config.LocalData.Save(cnfg.KeyToken, w.ProjectToken); err != nil {
+				return fmt.Errorf("could not store project token: %w", err)
+			}
+
+			s.Current = w.Name
+			if err := save(config.LocalData, s); err != nil {
+				return err
+			}
+
+			cmd.Printf("Switched to workspace %q\n", w.Name)
+			return nil
+		},
+	}
+}
+
+func newCmdWorkspaceShow(config *cfg.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "show [NAME]",
+		Short:             "Show a workspace, the one in use by default",
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeWorkspaces(config),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := load(config.LocalData)
+			if err != nil {
+				return err
+			}
+
+			name := s.Current
+			if len(args) == 1 {
+				name = args[0]
+			}
+
+			if name == "" {
+				return errors.New("no workspace in use, switch to one with 'calyptia workspace use NAME'")
+			}
+
+			w, ok := s.lookup(name)
+			if !ok {
+				return fmt.Errorf("workspace %q not found", name)
+			}
+
+			fs := cmd.Flags()
+			outputFormat := formatters.OutputFormatFromFlags(fs)
+			if fn, ok := formatters.ShouldApplyTemplating(outputFormat); ok {
+				return fn(cmd.OutOrStdout(), formatters.TemplateFromFlags(fs), redacted([]Workspace{w})[0])
+			}
+
+			switch outputFormat {
+			case formatters.OutputFormatJSON:
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(redacted([]Workspace{w})[0])
+			case formatters.OutputFormatYAML:
+				return yaml.NewEncoder(cmd.OutOrStdout()).Encode(w)
+			default:
+				tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 1, ' ', 0)
+				fmt.Fprintf(tw, "NAME\t%s\n", w.Name)
+				fmt.Fprintf(tw, "CLOUD URL\t%s\n", w.CloudURL)
+				fmt.Fprintf(tw, "PROJECT\t%s\n", w.ProjectID)
+				fmt.Fprintf(tw, "ENVIRONMENT\t%s\n", w.Environment)
+				fmt.Fprintf(tw, "KUBE CONTEXT\t%s\n", w.KubeContext)
+				fmt.Fprintf(tw, "NAMESPACE\t%s\n", w.Namespace)
+				fmt.Fprintf(tw, "IN USE\t%t\n", w.Name == s.Current)
+				return tw.Flush()
+			}
+		},
+	}
+
+	formatters.BindFormatFlags(cmd)
+
+	return cmd
+}
+
+func newCmdWorkspaceDelete(config *cfg.Config) *cobra.Command {
+	var confirmed bool
+
+	cmd := &cobra.Command{
+		Use:               "delete NAME",
+		Short:             "Delete a workspace",
+		Long:              "Delete a workspace. The configured cloud URL and project token are kept even if it is the one in use.",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeWorkspaces(config),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			s, err := load(config.LocalData)
+			if err != nil {
+				return err
+			}
+
+			if _, ok := s.lookup(name); !ok {
+				return fmt.Errorf("workspace %q not found", name)
+			}
+
+			if !confirmed {
+				cmd.Printf("Are you sure you want to delete workspace %q? (y/N) ", name)
+				confirmed, err := confirm.Read(cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+
+				if !confirmed {
+					cmd.Println("Aborted")
+					return nil
+				}
+			}
+
+			var kept []Workspace
+			for _, w := range s.Workspaces {
+				if w.Name != name {
+					kept = append(kept, w)
+				}
+			}
+			s.Workspaces = kept
+			if s.Current == name {
+				s.Current = ""
+			}
+
+			return save(config.LocalData, s)
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.BoolVarP(&confirmed, "yes", "y", false, "Confirm deletion")
+
+	return cmd
+}
+
+// ApplyDefaults sets the flags of the command that take their default from
+// the workspace in use, unless they were given explicitly.
+func ApplyDefaults(config *cfg.Config, cmd *cobra.Command) error {
+	if _, ok := cmd.Annotations[annotationNoDefaults]; ok {
+		return nil
+	}
+
+	s, err := load(config.LocalData)
+	if err != nil {
+		return err
+	}
+
+	w, ok := s.lookup(s.Current)
+	if !ok {
+		return nil
+	}
+
+	// the workspace no longer applies once switched to another project
+	// with --token or 'config set_token'.
+	if w.ProjectID != config.ProjectID {
+		return nil
+	}
+
+	fs := cmd.Flags()
+	for name, value := range defaultFlags {
+		f := fs.Lookup(name)
+		if f == nil || f.Changed || value(w) == "" {
+			continue
+		}
+
+		if err := f.Value.Set(value(w)); err != nil {
+			return fmt.Errorf("could not apply workspace %q %s: %w", w.Name, name, err)
+		}
+	}
+
+	return nil
+}
+
+func load(data *localdata.Keyring) (store, error) {
+	var out store
+
+	v, err := data.Get(KeyWorkspaces)
+	if errors.Is(err, localdata.ErrNotFound) {
+		return out, nil
+	}
+
+	if err != nil {
+		return out, fmt.Errorf("could not retrieve your workspaces: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(v), &out); err != nil {
+		return out, fmt.Errorf("could not parse your workspaces: %w", err)
+	}
+
+	return out, nil
+}
+
+func save(data *localdata.Keyring, s store) error {
+	sort.Slice(s.Workspaces, func(i, j int) bool {
+		return s.Workspaces[i].Name < s.Workspaces[j].Name
+	})
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	if err := data.Save(KeyWorkspaces, string(b)); err != nil {
+		return fmt.Errorf("could not store your workspaces: %w", err)
+	}
+
+	return nil
+}
+
+// redacted returns the workspaces without their project token
+// so they can be printed.
+func redacted(ww []Workspace) []Workspace {
+	out := make([]Workspace, len(ww))
+	for i, w := range ww {
+		w.ProjectToken = ""
+		out[i] = w
+	}
+	return out
+}
+
+func renderWorkspaces(w io.Writer, s store) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "CURRENT\tNAME\tCLOUD URL\tPROJECT\tENVIRONMENT\tKUBE CONTEXT\tNAMESPACE")
+	for _, ws := range s.Workspaces {
+		var current string
+		if ws.Name == s.Current {
+			current = "*"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", current, ws.Name, ws.CloudURL, ws.ProjectID, ws.Environment, ws.KubeContext, ws.Namespace)
+	}
+	return tw.Flush()
+}
+
+func completeWorkspaces(config *cfg.Config) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		s, err := load(config.LocalData)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		var out []string
+		for _, w := range s.Workspaces {
+			out = append(out, w.Name)
+		}
+		return out, cobra.ShellCompDirectiveNoFileComp
+	}
+}
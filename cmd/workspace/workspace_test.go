@@ -0,0 +1,94 @@
+package workspace
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/zalando/go-keyring"
+
+	cnfg "github.com/calyptia/cli/cmd/config"
+	cfg "github.com/calyptia/cli/config"
+	"github.com/calyptia/cli/localdata"
+)
+
+func TestWorkspace(t *testing.T) {
+	keyring.MockInit()
+
+	data := localdata.New("workspace-test", t.TempDir())
+	config := &cfg.Config{
+		BaseURL:      "https://cloud.customer-a.example",
+		ProjectToken: "token-a",
+		ProjectID:    "project-a",
+		LocalData:    data,
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+
+		var out bytes.Buffer
+		cmd := NewCmdWorkspace(config)
+		cmd.SetOut(&out)
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+
+	run("save", "customer-a", "--environment", "prod", "--kube-namespace", "calyptia")
+	run("use", "customer-a")
+
+	if got, _ := data.Get(cnfg.KeyToken); got != "token-a" {
+		t.Errorf("expected stored token %q, got %q", "token-a", got)
+	}
+
+	if got, _ := data.Get(cnfg.KeyBaseURL); got != config.BaseURL {
+		t.Errorf("expected stored cloud url %q, got %q", config.BaseURL, got)
+	}
+
+	if out := run("list", "-o", "json"); bytes.Contains([]byte(out), []byte("token-a")) {
+		t.Errorf("expected project token to be redacted, got %s", out)
+	}
+
+	var environment, namespace string
+	cmd := &cobra.Command{Use: "pipeline"}
+	cmd.Flags().StringVar(&environment, "environment", "", "")
+	cmd.Flags().StringVar(&namespace, "kube-namespace", "", "")
+	if err := cmd.Flags().Parse([]string{"--kube-namespace", "other"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ApplyDefaults(config, cmd); err != nil {
+		t.Fatal(err)
+	}
+
+	if environment != "prod" {
+		t.Errorf("expected environment %q from the workspace, got %q", "prod", environment)
+	}
+
+	if namespace != "other" {
+		t.Errorf("expected explicit namespace %q to be kept, got %q", "other", namespace)
+	}
+
+	environment = ""
+	config.ProjectID = "project-b"
+	if err := ApplyDefaults(config, cmd); err != nil {
+		t.Fatal(err)
+	}
+
+	if environment != "" {
+		t.Errorf("expected workspace to not apply to another project, got environment %q", environment)
+	}
+
+	run("delete", "customer-a", "--yes")
+
+	s, err := load(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(s.Workspaces) != 0 || s.Current != "" {
+		t.Errorf("expected no workspaces left, got %+v", s)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/calyptia/api/client"
 	cloud "github.com/calyptia/api/types"
 	"github.com/calyptia/cli/localdata"
+	"github.com/calyptia/cli/state"
 )
 
 type Config struct {
@@ -23,6 +24,7 @@ type Config struct {
 	ProjectToken string
 	ProjectID    string
 	LocalData    *localdata.Keyring
+	State        *state.File
 }
 
 func AgentStatus(lastMetricsAddedAt *time.Time, start time.Duration) string {
@@ -65,7 +67,7 @@ func Env(key, fallback string) string {
 }
 
 func CompleteOutputFormat(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
-	return []string{"table", "json", "yaml", "go-template"}, cobra.ShellCompDirectiveNoFileComp
+	return []string{"table", "json", "yaml", "go-template", "custom-columns"}, cobra.ShellCompDirectiveNoFileComp
 }
 
 func ReadFile(name string) ([]byte, error) {
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+)
+
+// reCloudPlaceholder matches the placeholders resolved by Calyptia Cloud,
+// ie: {{ secrets.foo }} and {{ files.bar }}. These are not valid go
+// templates and must reach the API untouched.
+var reCloudPlaceholder = regexp.MustCompile(`\{\{\s*(?:secrets|files)\.[\w\-]+\s*\}\}`)
+
+// RenderTemplate renders the given text as a go template with the sprig
+// functions available, plus "required" that fails with the given message
+// when its value is empty, ie:
+//
+//	Name {{ env "TAG" | default "app.*" }}
+//	Host {{ env "ES_HOST" | required "ES_HOST is required" }}
+//
+// Placeholders resolved by Calyptia Cloud are kept as is.
+func RenderTemplate(name, text string) (string, error) {
+	text = reCloudPlaceholder.ReplaceAllStringFunc(text, func(s string) string {
+		return "{{" + strconv.Quote(s) + "}}"
+	})
+
+	funcs := sprig.TxtFuncMap()
+	funcs["required"] = required
+
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("could not parse template: %w", err)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, nil); err != nil {
+		return "", fmt.Errorf("could not render template: %w", err)
+	}
+
+	return sb.String(), nil
+}
+
+func required(msg string, v any) (any, error) {
+	if v == nil {
+		return nil, errors.New(msg)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		if rv.Len() == 0 {
+			return nil, errors.New(msg)
+		}
+	}
+
+	return v, nil
+}
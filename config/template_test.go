@@ -0,0 +1,37 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	t.Setenv("CALYPTIA_TEST_HOST", "example.org")
+
+	got, err := RenderTemplate("test", strings.Join([]string{
+		`Host {{ env "CALYPTIA_TEST_HOST" | required "host is required" }}`,
+		`Port {{ env "CALYPTIA_TEST_PORT" | default "9200" }}`,
+		`Auth {{ "user:pass" | b64enc }}`,
+		`Password {{ secrets.password }}`,
+		`File {{files.ca}}`,
+	}, "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"Host example.org",
+		"Port 9200",
+		"Auth dXNlcjpwYXNz",
+		"Password {{ secrets.password }}",
+		"File {{files.ca}}",
+	}, "\n")
+	if got != want {
+		t.Fatalf("unexpected render:\n%s\nwant:\n%s", got, want)
+	}
+
+	_, err = RenderTemplate("test", `Host {{ env "CALYPTIA_TEST_MISSING" | required "CALYPTIA_TEST_MISSING is required" }}`)
+	if err == nil || !strings.Contains(err.Error(), "CALYPTIA_TEST_MISSING is required") {
+		t.Fatalf("expected required error, got %v", err)
+	}
+}
@@ -0,0 +1,274 @@
+// Package download fetches remote files into disk in a resumable way.
+// Partial downloads are kept next to the destination file so an
+// interrupted transfer continues where it left off on the next attempt,
+// and the result can be verified against a SHA-256 checksum.
+package download
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/sethvargo/go-retry"
+)
+
+// PartialSuffix is appended to the destination file name while downloading.
+const PartialSuffix = ".part"
+
+// validatorSuffix is appended to the partial file name to keep the
+// ETag or Last-Modified value of the response it was downloaded from.
+const validatorSuffix = ".validator"
+
+const defaultMaxRetries = 5
+
+// ErrChecksumMismatch is returned when the downloaded contents
+// do not match the expected checksum.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// Options for a download.
+type Options struct {
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+	// SHA256 is the expected hex encoded checksum. Not verified if empty.
+	SHA256 string
+	// MaxRetries on transfer errors. Each retry resumes from the
+	// bytes already written. Defaults to 5.
+	MaxRetries uint64
+
+	backoff func() retry.Backoff
+}
+
+// ToFile downloads the given URL into dest. Bytes are first written to
+// dest+PartialSuffix, resuming from its current size using a Range request
+// if the file exists, and moved to dest once complete and verified.
+// Resumes send If-Range so a file that changed upstream starts over.
+// A partial file that fails verification is removed so the next attempt
+// starts over.
+func ToFile(ctx context.Context, url, dest string, opts Options) error {
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = defaultMaxRetries
+	}
+
+	if opts.backoff == nil {
+		opts.backoff = func() retry.Backoff {
+			return retry.WithMaxRetries(opts.MaxRetries, retry.NewExponential(time.Second))
+		}
+	}
+
+	partial := dest + PartialSuffix
+	err := retry.Do(ctx, opts.backoff(), func(ctx context.Context) error {
+		return fetch(ctx, opts.Client, url, partial)
+	})
+	if err != nil {
+		return fmt.Errorf("could not download %s: %w", url, err)
+	}
+
+	if opts.SHA256 != "" {
+		sum, err := FileSHA256(partial)
+		if err != nil {
+			return err
+		}
+
+		if !strings.EqualFold(sum, opts.SHA256) {
+			_ = os.Remove(partial)
+			_ = os.Remove(partial + validatorSuffix)
+			return fmt.Errorf("%w: %s got sha256 %s, expected %s", ErrChecksumMismatch, url, sum, opts.SHA256)
+		}
+	}
+
+	if err := os.Rename(partial, dest); err != nil {
+		return fmt.Errorf("could not move download into place: %w", err)
+	}
+
+	_ = os.Remove(partial + validatorSuffix)
+
+	return nil
+}
+
+// fetch appends the remaining bytes of the URL into the partial file.
+// Transfer errors are retryable as the next attempt resumes from them.
+func fetch(ctx context.Context, client *http.Client, url, partial string) error {
+	f, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("could not open partial download: %w", err)
+	}
+
+	defer f.Close()
+
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	if offset != 0 {
+		// without a validator there is no telling whether the partial
+		// bytes still match the remote file, so start over.
+		validator, err := os.ReadFile(partial + validatorSuffix)
+		if err == nil && len(validator) != 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			req.Header.Set("If-Range", string(validator))
+		} else if err := restart(f); err != nil {
+			return err
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return retry.RetryableError(err)
+	}
+
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+		// resuming.
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset != 0:
+		// the partial file is already complete.
+		return nil
+	case resp.StatusCode == http.StatusOK:
+		// the server does not support ranges or the file changed
+		// since the partial download, start over.
+		if err := restart(f); err != nil {
+			return err
+		}
+
+		if err := writeValidator(partial+validatorSuffix, resp.Header); err != nil {
+			return err
+		}
+	case resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests:
+		return retry.RetryableError(fmt.Errorf("unexpected status %s", resp.Status))
+	default:
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		return retry.RetryableError(err)
+	}
+
+	return f.Sync()
+}
+
+func restart(f *os.File) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+
+	_, err := f.Seek(0, io.SeekStart)
+	return err
+}
+
+// writeValidator saves the strong ETag or else the Last-Modified header of
+// the response to send as If-Range when resuming. If-Range does not accept
+// weak ETags.
+func writeValidator(name string, h http.Header) error {
+	validator := h.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = h.Get("Last-Modified")
+	}
+
+	if validator == "" {
+		_ = os.Remove(name)
+		return nil
+	}
+
+	if err := os.WriteFile(name, []byte(validator), 0o600); err != nil {
+		return fmt.Errorf("could not save download validator: %w", err)
+	}
+
+	return nil
+}
+
+// FileSHA256 returns the hex encoded SHA-256 checksum of the given file.
+func FileSHA256(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", fmt.Errorf("could not open file: %w", err)
+	}
+
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("could not read contents: %w", err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ChecksumFromFile reads the checksum of the given file name from a
+// checksum file in the format printed by sha256sum, ie:
+//
+//	d2a84f4b8b650937ec8f73cd8be2c74add5a911ba64df27458ed8229da804a26  manifest.yaml
+//
+// A checksum file with a single bare checksum is also accepted.
+func ChecksumFromFile(checksumFile, name string) (string, error) {
+	f, err := os.Open(checksumFile)
+	if err != nil {
+		return "", fmt.Errorf("could not open checksum file: %w", err)
+	}
+
+	defer f.Close()
+
+	return parseChecksums(f, name)
+}
+
+func parseChecksums(r io.Reader, name string) (string, error) {
+	var lines int
+	var bare string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		lines++
+		if len(fields) == 1 {
+			bare = fields[0]
+			continue
+		}
+
+		// sha256sum marks binary mode with a leading "*".
+		file := strings.TrimPrefix(fields[1], "*")
+		if file == name || path.Base(file) == path.Base(name) {
+			return validChecksum(fields[0])
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	if lines == 1 && bare != "" {
+		return validChecksum(bare)
+	}
+
+	return "", fmt.Errorf("no checksum found for %q", name)
+}
+
+func validChecksum(s string) (string, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != sha256.Size {
+		return "", fmt.Errorf("invalid sha256 checksum %q", s)
+	}
+
+	return strings.ToLower(s), nil
+}
@@ -0,0 +1,182 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sethvargo/go-retry"
+)
+
+func TestToFile(t *testing.T) {
+	contents := bytes.Repeat([]byte("calyptia "), 1024)
+	sum := sha256.Sum256(contents)
+	checksum := hex.EncodeToString(sum[:])
+
+	var calls int
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", `"v1"`)
+		if calls == 1 {
+			// interrupt the transfer half way.
+			w.Header().Set("Content-Length", strconv.Itoa(len(contents)))
+			_, _ = w.Write(contents[:len(contents)/2])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "manifest.yaml", time.Time{}, bytes.NewReader(contents))
+	}))
+	defer srv.Close()
+
+	opts := Options{
+		SHA256: checksum,
+		backoff: func() retry.Backoff {
+			return retry.WithMaxRetries(3, retry.NewConstant(time.Millisecond))
+		},
+	}
+
+	dest := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := ToFile(context.Background(), srv.URL, dest, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, contents) {
+		t.Errorf("expected %d bytes, got %d", len(contents), len(got))
+	}
+
+	if len(ranges) != 2 || ranges[0] != "" || ranges[1] != "bytes="+strconv.Itoa(len(contents)/2)+"-" {
+		t.Errorf("expected the second request to resume, got ranges %q", ranges)
+	}
+
+	if _, err := os.Stat(dest + PartialSuffix); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected partial file to be moved, got %v", err)
+	}
+
+	if _, err := os.Stat(dest + PartialSuffix + validatorSuffix); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected validator file to be removed, got %v", err)
+	}
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		opts := opts
+		opts.SHA256 = strings.Repeat("0", 64)
+
+		dest := filepath.Join(t.TempDir(), "manifest.yaml")
+		err := ToFile(context.Background(), srv.URL, dest, opts)
+		if !errors.Is(err, ErrChecksumMismatch) {
+			t.Fatalf("expected %v, got %v", ErrChecksumMismatch, err)
+		}
+
+		if _, err := os.Stat(dest + PartialSuffix); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("expected partial file to be removed, got %v", err)
+		}
+	})
+}
+
+func Test_parseChecksums(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+	other := strings.Repeat("cd", 32)
+
+	tests := []struct {
+		name    string
+		file    string
+		want    string
+		wantErr bool
+	}{
+		{name: "sha256sum", file: other + "  crds.yaml\n" + sum + "  manifest.yaml\n", want: sum},
+		{name: "binary mode", file: sum + " *dist/manifest.yaml\n", want: sum},
+		{name: "bare", file: strings.ToUpper(sum) + "\n", want: sum},
+		{name: "not found", file: other + "  crds.yaml\n", wantErr: true},
+		{name: "invalid", file: "xyz  manifest.yaml\n", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseChecksums(strings.NewReader(tc.file), "manifest.yaml")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if got != tc.want {
+				t.Errorf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestFetch(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(name, []byte("kind: Namespace\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256([]byte("kind: Namespace\n"))
+	got, err := Fetch(context.Background(), name, Options{SHA256: hex.EncodeToString(sum[:])})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(got) != "kind: Namespace\n" {
+		t.Errorf("unexpected contents %q", got)
+	}
+
+	_, err = Fetch(context.Background(), name, Options{SHA256: strings.Repeat("0", 64)})
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("expected %v, got %v", ErrChecksumMismatch, err)
+	}
+}
+
+func TestToFile_changedUpstream(t *testing.T) {
+	stale := bytes.Repeat([]byte("old "), 256)
+	contents := bytes.Repeat([]byte("new "), 1024)
+
+	var ifRange string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ifRange = r.Header.Get("If-Range")
+		w.Header().Set("ETag", `"v2"`)
+		http.ServeContent(w, r, "manifest.yaml", time.Time{}, bytes.NewReader(contents))
+	}))
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(dest+PartialSuffix, stale, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(dest+PartialSuffix+validatorSuffix, []byte(`"v1"`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ToFile(context.Background(), srv.URL, dest, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if ifRange != `"v1"` {
+		t.Errorf("expected If-Range %q, got %q", `"v1"`, ifRange)
+	}
+
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, contents) {
+		t.Errorf("expected the download to start over, got %d bytes", len(got))
+	}
+}
@@ -0,0 +1,118 @@
+package download
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	checksumFlag     = "checksum"
+	checksumFileFlag = "checksum-file"
+)
+
+// BindChecksumFlags adds the --checksum and --checksum-file flags
+// used to verify what the command downloads.
+func BindChecksumFlags(cmd *cobra.Command) {
+	fs := cmd.Flags()
+	fs.String(checksumFlag, "", "Expected SHA-256 checksum of the downloaded file")
+	fs.String(checksumFileFlag, "", "File with the expected SHA-256 checksums in the format printed by sha256sum")
+	cmd.MarkFlagsMutuallyExclusive(checksumFlag, checksumFileFlag)
+}
+
+// ChecksumFromFlags returns the expected checksum of the given file name
+// from the flags bound with BindChecksumFlags, if any.
+func ChecksumFromFlags(fs *pflag.FlagSet, name string) (string, error) {
+	if checksum, err := fs.GetString(checksumFlag); err == nil && checksum != "" {
+		return validChecksum(checksum)
+	}
+
+	checksumFile, err := fs.GetString(checksumFileFlag)
+	if err != nil || checksumFile == "" {
+		return "", nil
+	}
+
+	return ChecksumFromFile(checksumFile, name)
+}
+
+// IsURL reports whether the given location is an http(s) URL
+// as opposed to a local path.
+func IsURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// Fetch returns the contents of a local file or http(s) URL verified
+// against opts.SHA256, if set. URLs are downloaded into the user cache
+// directory so an interrupted transfer resumes on the next run.
+func Fetch(ctx context.Context, location string, opts Options) ([]byte, error) {
+	name := location
+	if IsURL(location) {
+		var err error
+		name, err = cacheFile(location)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := ToFile(ctx, location, name, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not read file: %w", err)
+	}
+
+	if err := Verify(location, b, opts.SHA256); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Verify checks the given contents against the hex encoded SHA-256
+// checksum, if any. The name is only used for the error message.
+func Verify(name string, contents []byte, checksum string) error {
+	if checksum == "" {
+		return nil
+	}
+
+	sum := sha256.Sum256(contents)
+	if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, checksum) {
+		return fmt.Errorf("%w: %s got sha256 %s, expected %s", ErrChecksumMismatch, name, got, checksum)
+	}
+
+	return nil
+}
+
+// cacheFile returns where to download the given URL,
+// unique per URL and keeping its base name.
+func cacheFile(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %w", err)
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find a cache directory: %w", err)
+	}
+
+	dir = filepath.Join(dir, "calyptia", "downloads")
+	if err := os.MkdirAll(dir, 0o700); err != nil && !errors.Is(err, os.ErrExist) {
+		return "", fmt.Errorf("could not create cache directory: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+"-"+path.Base(u.Path)), nil
+}
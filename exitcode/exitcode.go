@@ -0,0 +1,134 @@
+// Package exitcode defines the exit codes of the CLI so scripts can tell
+// failures apart without parsing the output:
+//
+//	0 ok
+//	1 generic error
+//	2 not found, also returned by listings with --fail-on-empty
+//	3 authentication or authorization error
+//	4 conflict, the resource already exists or was modified
+//	5 timeout
+//	130 interrupted
+package exitcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	cloud "github.com/calyptia/api/types"
+	"github.com/calyptia/cli/k8s"
+)
+
+const (
+	OK          = 0
+	Error       = 1
+	NotFound    = 2
+	Auth        = 3
+	Conflict    = 4
+	Timeout     = 5
+	Interrupted = 130
+)
+
+const failOnEmptyFlag = "fail-on-empty"
+
+// codedError is an error with an explicit exit code.
+type codedError struct {
+	code int
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+func (e *codedError) Unwrap() error { return e.err }
+
+// WithCode attaches an exit code to the given error.
+func WithCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{code: code, err: err}
+}
+
+// FromError returns the exit code for the given error.
+// Errors from Calyptia Cloud only carry a message,
+// so they get classified by it.
+func FromError(err error) int {
+	if err == nil {
+		return OK
+	}
+
+	var ce *codedError
+	if errors.As(err, &ce) {
+		return ce.code
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return Interrupted
+	case errors.Is(err, k8s.ErrTimeout):
+		return Timeout
+	case errors.Is(err, k8s.ErrNotFound):
+		return NotFound
+	case errors.Is(err, k8s.ErrPermission):
+		return Auth
+	case errors.Is(err, context.DeadlineExceeded), wait.Interrupted(err), apiErrors.IsTimeout(err), apiErrors.IsServerTimeout(err):
+		return Timeout
+	case apiErrors.IsNotFound(err):
+		return NotFound
+	case apiErrors.IsUnauthorized(err), apiErrors.IsForbidden(err):
+		return Auth
+	case apiErrors.IsConflict(err), apiErrors.IsAlreadyExists(err):
+		return Conflict
+	}
+
+	var apiErr *cloud.Error
+	if errors.As(err, &apiErr) {
+		return fromMessage(apiErr.Msg)
+	}
+
+	// the completer reports missing resources by name as "could not find ...".
+	if strings.Contains(err.Error(), "could not find") {
+		return NotFound
+	}
+
+	return Error
+}
+
+func fromMessage(msg string) int {
+	msg = strings.ToLower(msg)
+	switch {
+	case strings.Contains(msg, "not found"):
+		return NotFound
+	case strings.Contains(msg, "unauthenticated"),
+		strings.Contains(msg, "unauthorized"),
+		strings.Contains(msg, "permission denied"),
+		strings.Contains(msg, "forbidden"),
+		strings.Contains(msg, "invalid token"),
+		strings.Contains(msg, "token expired"):
+		return Auth
+	case strings.Contains(msg, "conflict"), strings.Contains(msg, "already exists"):
+		return Conflict
+	default:
+		return Error
+	}
+}
+
+// BindFailOnEmptyFlag adds the --fail-on-empty flag to a listing command.
+func BindFailOnEmptyFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool(failOnEmptyFlag, false, fmt.Sprintf("Exit with code %d when no %s are found", NotFound, strings.ReplaceAll(cmd.Name(), "_", " ")))
+}
+
+// FailOnEmpty returns a not found error when the listing is empty
+// and the --fail-on-empty flag was set.
+func FailOnEmpty(cmd *cobra.Command, count int) error {
+	failOnEmpty, err := cmd.Flags().GetBool(failOnEmptyFlag)
+	if err != nil || !failOnEmpty || count != 0 {
+		return nil
+	}
+
+	return WithCode(NotFound, fmt.Errorf("no %s found", strings.ReplaceAll(cmd.Name(), "_", " ")))
+}
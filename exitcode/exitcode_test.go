@@ -0,0 +1,72 @@
+package exitcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/cobra"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	cloud "github.com/calyptia/api/types"
+	"github.com/calyptia/cli/k8s"
+)
+
+func TestFromError(t *testing.T) {
+	gr := schema.GroupResource{Resource: "deployments"}
+	tt := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "nil", want: OK},
+		{name: "generic", err: errors.New("boom"), want: Error},
+		{name: "coded", err: fmt.Errorf("wrapped: %w", WithCode(Conflict, errors.New("boom"))), want: Conflict},
+		{name: "canceled", err: fmt.Errorf("wrapped: %w", context.Canceled), want: Interrupted},
+		{name: "deadline", err: context.DeadlineExceeded, want: Timeout},
+		{name: "k8s not found", err: apiErrors.NewNotFound(gr, "x"), want: NotFound},
+		{name: "k8s forbidden", err: apiErrors.NewForbidden(gr, "x", errors.New("nope")), want: Auth},
+		{name: "k8s already exists", err: apiErrors.NewAlreadyExists(gr, "x"), want: Conflict},
+		{name: "k8s wrapper not found", err: fmt.Errorf("wrapped: %w", k8s.ErrCoreOperatorNotFound), want: NotFound},
+		{name: "k8s wrapper timeout", err: fmt.Errorf("%w after 1m", k8s.ErrTimeout), want: Timeout},
+		{name: "k8s wrapper permission", err: &k8s.Error{Op: "list deployments", Kind: k8s.ErrPermission, Err: errors.New("nope")}, want: Auth},
+		{name: "cloud not found", err: fmt.Errorf("could not fetch pipeline: %w", &cloud.Error{Msg: "pipeline not found"}), want: NotFound},
+		{name: "cloud unauthenticated", err: &cloud.Error{Msg: "unauthenticated"}, want: Auth},
+		{name: "cloud conflict", err: &cloud.Error{Msg: "pipeline name already exists"}, want: Conflict},
+		{name: "completer", err: errors.New(`could not find pipeline "x"`), want: NotFound},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := FromError(tc.err); got != tc.want {
+				t.Errorf("want %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestFailOnEmpty(t *testing.T) {
+	cmd := &cobra.Command{Use: "pipelines"}
+	BindFailOnEmptyFlag(cmd)
+
+	if err := FailOnEmpty(cmd, 0); err != nil {
+		t.Fatalf("unexpected error without flag: %v", err)
+	}
+
+	if err := cmd.Flags().Set("fail-on-empty", "true"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := FailOnEmpty(cmd, 1); err != nil {
+		t.Fatalf("unexpected error with items: %v", err)
+	}
+
+	err := FailOnEmpty(cmd, 0)
+	if got := FromError(err); got != NotFound {
+		t.Fatalf("want exit code %d, got %d", NotFound, got)
+	}
+	if err.Error() != "no pipelines found" {
+		t.Fatalf("unexpected message %q", err.Error())
+	}
+}
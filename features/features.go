@@ -0,0 +1,215 @@
+// Package features detects which capabilities the configured Calyptia Cloud
+// supports, so commands that depend on a newer API are hidden and fail with
+// an explanatory message instead of an opaque 404 on older installs.
+//
+// The Cloud version is taken from its health endpoint and cached in local
+// data per Cloud URL, so detection costs at most one request a day.
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	semver "github.com/hashicorp/go-version"
+	"github.com/spf13/cobra"
+
+	"github.com/calyptia/api/client"
+	"github.com/calyptia/cli/localdata"
+)
+
+// Feature is a Cloud capability not available on every API version.
+type Feature string
+
+const (
+	Fleets       Feature = "fleets"
+	IngestChecks Feature = "ingest checks"
+)
+
+const (
+	// Annotation set on the commands that require a feature.
+	Annotation = "calyptia.feature"
+	// KeyVersions is the local data key where detected Cloud versions are cached.
+	KeyVersions = "cloud_versions"
+	// CacheTTL is how long a detected Cloud version is trusted.
+	CacheTTL = 24 * time.Hour
+)
+
+// minVersions holds the first Cloud API version supporting each feature.
+var minVersions = map[Feature]*semver.Version{
+	Fleets:       semver.Must(semver.NewSemver("v1.4.0")),
+	IngestChecks: semver.Must(semver.NewSemver("v1.2.0")),
+}
+
+// Require marks the given commands as depending on the feature
+// and returns them so they can be passed to AddCommand.
+func Require(f Feature, cmds ...*cobra.Command) []*cobra.Command {
+	for _, cmd := range cmds {
+		if cmd.Annotations == nil {
+			cmd.Annotations = map[string]string{}
+		}
+		cmd.Annotations[Annotation] = string(f)
+	}
+	return cmds
+}
+
+// Required returns the feature required by the command
+// or any of its parents.
+func Required(cmd *cobra.Command) (Feature, bool) {
+	for c := cmd; c != nil; c = c.Parent() {
+		if f, ok := c.Annotations[Annotation]; ok {
+			return Feature(f), true
+		}
+	}
+	return "", false
+}
+
+// Support of a Cloud API version.
+type Support struct {
+	Version string `json:"version"`
+}
+
+// Supports reports whether the feature is available. Unknown or
+// unparseable versions, like development builds, support everything.
+func (s Support) Supports(f Feature) bool {
+	min, ok := minVersions[f]
+	if !ok || s.Version == "" {
+		return true
+	}
+
+	v, err := semver.NewSemver(s.Version)
+	if err != nil {
+		return true
+	}
+
+	return !v.Core().LessThan(min)
+}
+
+// Check returns an UnsupportedError if the feature is not available.
+func (s Support) Check(f Feature) error {
+	if s.Supports(f) {
+		return nil
+	}
+
+	return &UnsupportedError{Feature: f, Version: s.Version, MinVersion: minVersions[f].Original()}
+}
+
+// UnsupportedError is returned when running a command that
+// requires a feature the Cloud does not support.
+type UnsupportedError struct {
+	Feature    Feature
+	Version    string
+	MinVersion string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s are not supported by this Calyptia Cloud (version %s), they require version %s or later",
+		e.Feature, e.Version, e.MinVersion)
+}
+
+type cachedVersion struct {
+	Version   string    `json:"version"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// Detector of the features supported by a Cloud.
+type Detector struct {
+	Cloud     *client.Client
+	LocalData *localdata.Keyring
+	BaseURL   string
+
+	now func() time.Time
+}
+
+// Cached returns the support last detected for the Cloud without
+// making any request. It returns false if nothing was cached yet
+// or the cache expired.
+func (d *Detector) Cached() (Support, bool) {
+	versions, err := d.loadVersions()
+	if err != nil {
+		return Support{}, false
+	}
+
+	v, ok := versions[d.key()]
+	if !ok || d.timeNow().Sub(v.CheckedAt) > CacheTTL {
+		return Support{}, false
+	}
+
+	return Support{Version: v.Version}, true
+}
+
+// Detect the features supported by the Cloud, using the cached
+// version if still fresh.
+func (d *Detector) Detect(ctx context.Context) (Support, error) {
+	if s, ok := d.Cached(); ok {
+		return s, nil
+	}
+
+	health, err := d.Cloud.Health(ctx)
+	if err != nil {
+		return Support{}, fmt.Errorf("could not fetch cloud version: %w", err)
+	}
+
+	versions, err := d.loadVersions()
+	if err != nil {
+		versions = map[string]cachedVersion{}
+	}
+
+	versions[d.key()] = cachedVersion{Version: health.Version, CheckedAt: d.timeNow()}
+
+	b, err := json.Marshal(versions)
+	if err != nil {
+		return Support{}, err
+	}
+
+	if err := d.LocalData.Save(KeyVersions, string(b)); err != nil {
+		return Support{}, fmt.Errorf("could not store cloud version: %w", err)
+	}
+
+	return Support{Version: health.Version}, nil
+}
+
+// Hide marks as hidden every command under root that requires
+// a feature the given support lacks.
+func Hide(root *cobra.Command, s Support) {
+	for _, cmd := range root.Commands() {
+		if f, ok := cmd.Annotations[Annotation]; ok && !s.Supports(Feature(f)) {
+			cmd.Hidden = true
+			continue
+		}
+
+		Hide(cmd, s)
+	}
+}
+
+func (d *Detector) loadVersions() (map[string]cachedVersion, error) {
+	s, err := d.LocalData.Get(KeyVersions)
+	if errors.Is(err, localdata.ErrNotFound) {
+		return map[string]cachedVersion{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string]cachedVersion{}
+	if err := json.Unmarshal([]byte(s), &out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (d *Detector) key() string {
+	return strings.TrimSuffix(d.BaseURL, "/")
+}
+
+func (d *Detector) timeNow() time.Time {
+	if d.now != nil {
+		return d.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,111 @@
+package features
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zalando/go-keyring"
+
+	"github.com/calyptia/api/client"
+	"github.com/calyptia/cli/localdata"
+)
+
+func TestSupport_Check(t *testing.T) {
+	tt := []struct {
+		version string
+		want    bool
+	}{
+		{version: "", want: true},
+		{version: "dev", want: true},
+		{version: "v1.3.9", want: false},
+		{version: "v1.4.0-rc1", want: true},
+		{version: "1.4.0", want: true},
+		{version: "v2.0.0", want: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.version, func(t *testing.T) {
+			err := Support{Version: tc.version}.Check(Fleets)
+
+			var unsupported *UnsupportedError
+			if got := !errors.As(err, &unsupported); got != tc.want {
+				t.Errorf("want supported %v, got error %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestRequireAndHide(t *testing.T) {
+	root := &cobra.Command{Use: "calyptia"}
+	get := &cobra.Command{Use: "get"}
+	pipelines := &cobra.Command{Use: "pipelines"}
+	fleets := &cobra.Command{Use: "fleets"}
+	checks := &cobra.Command{Use: "ingest_checks"}
+
+	root.AddCommand(get)
+	get.AddCommand(pipelines)
+	get.AddCommand(Require(Fleets, fleets)...)
+	get.AddCommand(Require(IngestChecks, checks)...)
+
+	if f, ok := Required(fleets); !ok || f != Fleets {
+		t.Errorf("expected fleets to require %q, got %q", Fleets, f)
+	}
+
+	if _, ok := Required(pipelines); ok {
+		t.Error("expected pipelines to not require any feature")
+	}
+
+	Hide(root, Support{Version: "v1.3.0"})
+
+	if !fleets.Hidden || checks.Hidden || pipelines.Hidden {
+		t.Errorf("expected only fleets to be hidden, got fleets=%v ingest_checks=%v pipelines=%v", fleets.Hidden, checks.Hidden, pipelines.Hidden)
+	}
+}
+
+func TestDetector_Detect(t *testing.T) {
+	keyring.MockInit()
+
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(`{"version":"v1.3.0"}`))
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	d := &Detector{
+		Cloud:     &client.Client{Client: srv.Client(), BaseURL: srv.URL},
+		LocalData: localdata.New("features-test", t.TempDir()),
+		BaseURL:   srv.URL,
+		now:       func() time.Time { return now },
+	}
+
+	if _, ok := d.Cached(); ok {
+		t.Fatal("expected nothing cached yet")
+	}
+
+	for i := 0; i < 2; i++ {
+		s, err := d.Detect(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if s.Supports(Fleets) || !s.Supports(IngestChecks) {
+			t.Errorf("unexpected support for version %q", s.Version)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("expected version to be fetched once, got %d requests", requests)
+	}
+
+	now = now.Add(CacheTTL + time.Minute)
+	if _, ok := d.Cached(); ok {
+		t.Error("expected cached version to expire")
+	}
+}
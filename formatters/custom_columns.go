@@ -0,0 +1,242 @@
+package formatters
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+const (
+	OutputFormatCustomColumns     OutputFormat = "custom-columns"
+	OutputFormatCustomColumnsFile OutputFormat = "custom-columns-file"
+)
+
+// noneValue is printed on the cells whose JSONPath matches nothing.
+const noneValue = "<none>"
+
+// IsTemplating reports whether the output format renders the data through
+// a user given template: go-template, go-template-file, custom-columns or
+// custom-columns-file, optionally followed by =TEMPLATE.
+func IsTemplating(outputFormat string) bool {
+	return strings.HasPrefix(outputFormat, "go-template") || strings.HasPrefix(outputFormat, "custom-columns")
+}
+
+// ApplyTemplate renders the data with the custom columns
+// or go template given by the output format.
+func ApplyTemplate(w io.Writer, outputFormat, tmpl string, data any) error {
+	if strings.HasPrefix(outputFormat, "custom-columns") {
+		return ApplyCustomColumns(w, outputFormat, tmpl, data)
+	}
+
+	return ApplyGoTemplate(w, outputFormat, tmpl, data)
+}
+
+// CustomColumn is a column of a custom-columns output.
+type CustomColumn struct {
+	Header string
+	Path   *jsonpath.JSONPath
+}
+
+var jsonPathTemplatePattern = regexp.MustCompile(`^\{.*\}$`)
+
+// ParseCustomColumns parses a kubectl-style custom-columns spec
+// such as NAME:.name,STATUS:.status.
+func ParseCustomColumns(spec string) ([]CustomColumn, error) {
+	if spec == "" {
+		return nil, fmt.Errorf("custom-columns format specified but no custom columns given")
+	}
+
+	var out []CustomColumn
+	for _, part := range strings.Split(spec, ",") {
+		header, path, ok := strings.Cut(part, ":")
+		if !ok || header == "" || path == "" {
+			return nil, fmt.Errorf("unexpected custom-columns spec: %q, expected <header>:<json-path-expr>", part)
+		}
+
+		col, err := newCustomColumn(header, path)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, col)
+	}
+
+	return out, nil
+}
+
+// parseCustomColumnsFile parses a custom-columns template with the headers
+// on the first line and their JSONPaths on the second, separated by spaces.
+func parseCustomColumnsFile(r io.Reader) ([]CustomColumn, error) {
+	scanner := bufio.NewScanner(r)
+	var lines []string
+	for scanner.Scan() && len(lines) < 2 {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(lines) != 2 {
+		return nil, fmt.Errorf("custom-columns file must have a line with the headers and another with their json paths")
+	}
+
+	headers, paths := strings.Fields(lines[0]), strings.Fields(lines[1])
+	if len(headers) != len(paths) {
+		return nil, fmt.Errorf("custom-columns file has %d headers but %d json paths", len(headers), len(paths))
+	}
+
+	out := make([]CustomColumn, 0, len(headers))
+	for i := range headers {
+		col, err := newCustomColumn(headers[i], paths[i])
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, col)
+	}
+
+	return out, nil
+}
+
+func newCustomColumn(header, path string) (CustomColumn, error) {
+	if !jsonPathTemplatePattern.MatchString(path) {
+		path = "{" + path + "}"
+	}
+
+	p := jsonpath.New(header).AllowMissingKeys(true)
+	if err := p.Parse(path); err != nil {
+		return CustomColumn{}, fmt.Errorf("parsing custom-columns %s json path: %w", header, err)
+	}
+
+	return CustomColumn{Header: header, Path: p}, nil
+}
+
+// ApplyCustomColumns renders the data as a table with the columns given
+// inline on the output format, ie: custom-columns=NAME:.name, or by the
+// template. Lists, as well as objects holding a list of "items", are
+// rendered one row per element.
+func ApplyCustomColumns(w io.Writer, outputFormat, tmpl string, data any) error {
+	if tmpl == "" {
+		if _, spec, ok := strings.Cut(outputFormat, "="); ok {
+			tmpl = trimQuotes(spec)
+		}
+	}
+
+	tmpl = strings.TrimSpace(tmpl)
+
+	if !strings.HasPrefix(outputFormat, string(OutputFormatCustomColumnsFile)) {
+		columns, err := ParseCustomColumns(tmpl)
+		if err != nil {
+			return err
+		}
+
+		return RenderCustomColumns(w, columns, data)
+	}
+
+	b, err := os.ReadFile(tmpl)
+	if err != nil {
+		return fmt.Errorf("reading custom-columns-file: %w", err)
+	}
+
+	columns, err := parseCustomColumnsFile(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	return RenderCustomColumns(w, columns, data)
+}
+
+// RenderCustomColumns prints the JSONPath of each column over the
+// JSON representation of the data.
+func RenderCustomColumns(w io.Writer, columns []CustomColumn, data any) error {
+	rows, err := customColumnsRows(data)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	headers := make([]string, len(columns))
+	for i, col := range columns {
+		headers[i] = col.Header
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, col := range columns {
+			cells[i], err = customColumnCell(col, row)
+			if err != nil {
+				return err
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// customColumnsRows converts the data to its JSON representation,
+// so JSONPaths match the JSON field names, and splits it in rows.
+func customColumnsRows(data any) ([]any, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+
+	switch v := v.(type) {
+	case []any:
+		return v, nil
+	case map[string]any:
+		if items, ok := v["items"].([]any); ok {
+			return items, nil
+		}
+	case nil:
+		return nil, nil
+	}
+
+	return []any{v}, nil
+}
+
+func customColumnCell(col CustomColumn, row any) (string, error) {
+	results, err := col.Path.FindResults(row)
+	if err != nil {
+		return "", fmt.Errorf("evaluating custom-columns %s: %w", col.Header, err)
+	}
+
+	var values []string
+	for _, result := range results {
+		for _, r := range result {
+			if !r.IsValid() || (r.CanInterface() && r.Interface() == nil) {
+				continue
+			}
+
+			var buf bytes.Buffer
+			if err := col.Path.PrintResults(&buf, []reflect.Value{r}); err != nil {
+				return "", err
+			}
+			values = append(values, buf.String())
+		}
+	}
+
+	if len(values) == 0 {
+		return noneValue, nil
+	}
+
+	return strings.Join(values, ","), nil
+}
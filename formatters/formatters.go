@@ -13,7 +13,6 @@ import (
 	text_template "text/template"
 
 	"github.com/Masterminds/sprig/v3"
-	"github.com/hako/durafmt"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
@@ -37,8 +36,8 @@ func (o OutputFormat) String() string {
 
 func ShouldApplyTemplating(fmt OutputFormat) (func(w io.Writer, tmpl string, data any) error, bool) {
 	return func(w io.Writer, tmpl string, data any) error {
-		return ApplyGoTemplate(w, fmt.String(), tmpl, data)
-	}, fmt == OutputFormatGoTmpl || fmt == OutputFormatGoTmplFile
+		return ApplyTemplate(w, fmt.String(), tmpl, data)
+	}, IsTemplating(fmt.String())
 }
 
 func RenderWithTemplating(w io.Writer, format OutputFormat, tmpl string, data any) error {
@@ -55,23 +54,35 @@ func OutputFormatFromFlags(fs *pflag.FlagSet) OutputFormat {
 		return OutputFormatTable
 	}
 
-	switch outputFormat {
-	case "json":
+	switch {
+	case outputFormat == "json":
 		return OutputFormatJSON
-	case "yaml", "yml":
+	case outputFormat == "yaml", outputFormat == "yml":
 		return OutputFormatYAML
-	case "go-template":
-		return OutputFormatGoTmpl
-	case "go-template-file":
+	case strings.HasPrefix(outputFormat, "go-template-file"):
 		return OutputFormatGoTmplFile
+	case strings.HasPrefix(outputFormat, "go-template"):
+		return OutputFormatGoTmpl
+	case strings.HasPrefix(outputFormat, "custom-columns-file"):
+		return OutputFormatCustomColumnsFile
+	case strings.HasPrefix(outputFormat, "custom-columns"):
+		return OutputFormatCustomColumns
 	default:
 		return OutputFormatTable
 	}
 }
 
+// TemplateFromFlags returns the --template flag, or the template
+// given inline on the output format, ie: custom-columns=NAME:.name.
 func TemplateFromFlags(fs *pflag.FlagSet) string {
 	if !fs.Changed("template") {
-		return ""
+		outputFormat, err := fs.GetString("output-format")
+		if err != nil {
+			return ""
+		}
+
+		_, tmpl, _ := strings.Cut(outputFormat, "=")
+		return trimQuotes(tmpl)
 	}
 
 	template, err := fs.GetString("template")
@@ -83,7 +94,7 @@ func TemplateFromFlags(fs *pflag.FlagSet) string {
 }
 
 func CompleteOutputFormat(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
-	return []string{"table", "json", "yaml", "go-template", "go-template-file"}, cobra.ShellCompDirectiveNoFileComp
+	return []string{"table", "json", "yaml", "go-template", "go-template-file", "custom-columns", "custom-columns-file"}, cobra.ShellCompDirectiveNoFileComp
 }
 
 func RenderCreated(w io.Writer, created types.Created) error {
@@ -118,8 +129,8 @@ func RenderDeleted(w io.Writer, deleted types.Deleted) error {
 
 func BindFormatFlags(cmd *cobra.Command) {
 	fs := cmd.Flags()
-	fs.StringP("output-format", "o", "table", "Output format. One of: table|json|yaml|go-template|go-template-file")
-	fs.String("template", "", "Template string or path to use when -o=go-template, -o=go-template-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]")
+	fs.StringP("output-format", "o", "table", "Output format. One of: table|json|yaml|go-template|go-template-file|custom-columns|custom-columns-file")
+	fs.String("template", "", "Template string or path to use when -o=go-template, -o=go-template-file, -o=custom-columns or -o=custom-columns-file. The template format is golang templates\n[http://golang.org/pkg/text/template/#pkg-overview]\nor kubectl-style custom columns, ie: NAME:.name,STATUS:.status")
 	_ = cmd.RegisterFlagCompletionFunc("output-format", CompleteOutputFormat)
 }
 
@@ -249,16 +260,3 @@ func FilterOutEmptyMetadata(metadata types.CoreInstanceMetadata) ([]byte, error)
 
 	return json.Marshal(o)
 }
-
-func FmtTime(t time.Time) string {
-	d := time.Since(t)
-	if d < time.Second {
-		return "Just now"
-	}
-
-	return FmtDuration(d)
-}
-
-func FmtDuration(d time.Duration) string {
-	return durafmt.ParseShort(d).LimitFirstN(1).String()
-}
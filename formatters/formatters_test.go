@@ -2,9 +2,12 @@ package formatters
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/alecthomas/assert/v2"
+	"github.com/spf13/cobra"
 )
 
 func Test_applyGoTemplate(t *testing.T) {
@@ -26,3 +29,60 @@ func Test_applyGoTemplate(t *testing.T) {
 		assert.Equal(t, "foobar\n", got)
 	})
 }
+
+func Test_applyCustomColumns(t *testing.T) {
+	type status struct {
+		Name   string            `json:"name"`
+		Status string            `json:"status"`
+		Labels map[string]string `json:"labels,omitempty"`
+	}
+
+	data := []status{
+		{Name: "foo", Status: "STARTED", Labels: map[string]string{"team": "a"}},
+		{Name: "bar", Status: "FAILED"},
+	}
+
+	t.Run("inline", func(t *testing.T) {
+		var buff bytes.Buffer
+		err := ApplyTemplate(&buff, "custom-columns=NAME:.name,STATUS:.status,TEAM:.labels.team", "", data)
+		assert.NoError(t, err)
+		assert.Equal(t, "NAME STATUS  TEAM\nfoo  STARTED a\nbar  FAILED  <none>\n", buff.String())
+	})
+
+	t.Run("items", func(t *testing.T) {
+		var buff bytes.Buffer
+		err := ApplyTemplate(&buff, "custom-columns", "NAME:{.name}", map[string]any{"items": data})
+		assert.NoError(t, err)
+		assert.Equal(t, "NAME\nfoo\nbar\n", buff.String())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "columns.txt")
+		err := os.WriteFile(path, []byte("NAME  STATUS\n.name .status\n"), 0o600)
+		assert.NoError(t, err)
+
+		var buff bytes.Buffer
+		err = ApplyTemplate(&buff, "custom-columns-file", path, data[1])
+		assert.NoError(t, err)
+		assert.Equal(t, "NAME STATUS\nbar  FAILED\n", buff.String())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		var buff bytes.Buffer
+		err := ApplyTemplate(&buff, "custom-columns=NAME", "", data)
+		assert.Error(t, err)
+	})
+}
+
+func TestTemplateFromFlags(t *testing.T) {
+	cmd := &cobra.Command{}
+	BindFormatFlags(cmd)
+
+	fs := cmd.Flags()
+	assert.NoError(t, fs.Set("output-format", "custom-columns=NAME:.name"))
+	assert.Equal(t, OutputFormatCustomColumns, OutputFormatFromFlags(fs))
+	assert.Equal(t, "NAME:.name", TemplateFromFlags(fs))
+
+	assert.NoError(t, fs.Set("template", "ID:.id"))
+	assert.Equal(t, "ID:.id", TemplateFromFlags(fs))
+}
@@ -0,0 +1,133 @@
+package formatters
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hako/durafmt"
+	"github.com/spf13/pflag"
+)
+
+// TimeFormat is how timestamps get rendered in tables and messages.
+type TimeFormat string
+
+const (
+	// TimeFormatRelative renders the time elapsed since, ie: 5 minutes.
+	TimeFormatRelative TimeFormat = "relative"
+	// TimeFormatRFC3339 renders the date and time with the timezone offset.
+	TimeFormatRFC3339 TimeFormat = "rfc3339"
+	// TimeFormatUnix renders the seconds since epoch.
+	TimeFormatUnix TimeFormat = "unix"
+)
+
+var timeFormats = []TimeFormat{TimeFormatRelative, TimeFormatRFC3339, TimeFormatUnix}
+
+// TimeOptions control the rendering of every timestamp so listings
+// and messages stay consistent and parseable.
+type TimeOptions struct {
+	// Format of the timestamps. Empty keeps each column default:
+	// relative for creation times and RFC3339 for points in time.
+	Format TimeFormat
+	// UTC renders the timestamps in UTC instead of the local timezone.
+	UTC bool
+}
+
+var timeOptions TimeOptions
+
+// BindTimeFlags adds the --time-format and --utc flags to the given flag set.
+func BindTimeFlags(fs *pflag.FlagSet, o *TimeOptions) {
+	fs.Var(newTimeFormatValue(&o.Format), "time-format", fmt.Sprintf("How to render timestamps in tables, options: %s", joinTimeFormats()))
+	fs.BoolVar(&o.UTC, "utc", false, "Render timestamps in UTC instead of the local timezone")
+}
+
+// SetTimeOptions sets the options used by FmtTime, FmtTimestamp and FmtDate.
+func SetTimeOptions(o TimeOptions) {
+	timeOptions = o
+}
+
+// FmtTime renders a creation or modification time,
+// relative to now unless another format was set.
+func FmtTime(t time.Time) string {
+	if timeOptions.Format == "" || timeOptions.Format == TimeFormatRelative {
+		return fmtRelative(t)
+	}
+
+	return FmtTimestamp(t)
+}
+
+// FmtTimestamp renders a point in time, as RFC3339 unless another format was set.
+func FmtTimestamp(t time.Time) string {
+	switch timeOptions.Format {
+	case TimeFormatRelative:
+		return fmtRelative(t)
+	case TimeFormatUnix:
+		return strconv.FormatInt(t.Unix(), 10)
+	default:
+		return inTimezone(t).Format(time.RFC3339)
+	}
+}
+
+// FmtDate renders the date part only, as with release dates.
+func FmtDate(t time.Time) string {
+	if timeOptions.Format == TimeFormatUnix {
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+
+	return inTimezone(t).Format(time.DateOnly)
+}
+
+func FmtDuration(d time.Duration) string {
+	return durafmt.ParseShort(d).LimitFirstN(1).String()
+}
+
+func fmtRelative(t time.Time) string {
+	d := time.Since(t)
+	if d < time.Second {
+		return "Just now"
+	}
+
+	return FmtDuration(d)
+}
+
+func inTimezone(t time.Time) time.Time {
+	if timeOptions.UTC {
+		return t.UTC()
+	}
+	return t.Local()
+}
+
+type timeFormatValue struct {
+	format *TimeFormat
+}
+
+func newTimeFormatValue(format *TimeFormat) *timeFormatValue {
+	return &timeFormatValue{format: format}
+}
+
+func (v *timeFormatValue) String() string {
+	return string(*v.format)
+}
+
+func (v *timeFormatValue) Set(s string) error {
+	for _, f := range timeFormats {
+		if TimeFormat(s) == f {
+			*v.format = f
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid time format %q, options: %s", s, joinTimeFormats())
+}
+
+func (v *timeFormatValue) Type() string {
+	return "string"
+}
+
+func joinTimeFormats() string {
+	out := make([]string, len(timeFormats))
+	for i, f := range timeFormats {
+		out[i] = string(f)
+	}
+	return strings.Join(out, ", ")
+}
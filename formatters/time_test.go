@@ -0,0 +1,49 @@
+package formatters
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+func TestTimeOptions(t *testing.T) {
+	t.Cleanup(func() { SetTimeOptions(TimeOptions{}) })
+
+	ts := time.Date(2023, 11, 9, 13, 48, 25, 0, time.FixedZone("CET", 3600))
+
+	var o TimeOptions
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	BindTimeFlags(fs, &o)
+
+	if err := fs.Parse([]string{"--time-format", "ago"}); err == nil {
+		t.Error("expected invalid time format to fail")
+	}
+
+	if err := fs.Parse([]string{"--time-format", "rfc3339", "--utc"}); err != nil {
+		t.Fatal(err)
+	}
+
+	SetTimeOptions(o)
+	if got, want := FmtTime(ts), "2023-11-09T12:48:25Z"; got != want {
+		t.Errorf("FmtTime() = %q, want %q", got, want)
+	}
+
+	if got, want := FmtDate(ts), "2023-11-09"; got != want {
+		t.Errorf("FmtDate() = %q, want %q", got, want)
+	}
+
+	SetTimeOptions(TimeOptions{Format: TimeFormatUnix})
+	if got, want := FmtTimestamp(ts), "1699534105"; got != want {
+		t.Errorf("FmtTimestamp() = %q, want %q", got, want)
+	}
+
+	SetTimeOptions(TimeOptions{UTC: true})
+	if got, want := FmtTimestamp(ts), "2023-11-09T12:48:25Z"; got != want {
+		t.Errorf("FmtTimestamp() = %q, want %q", got, want)
+	}
+
+	if got := FmtTime(time.Now()); got != "Just now" {
+		t.Errorf("expected relative creation time by default, got %q", got)
+	}
+}
@@ -0,0 +1,221 @@
+// Package httpcache provides an http.RoundTripper that caches GET responses
+// in memory and revalidates them with conditional requests using ETags,
+// so unchanged data is not downloaded again.
+package httpcache
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultMaxEntries is the default number of responses kept in memory.
+	DefaultMaxEntries = 256
+	// DefaultMaxBodySize is the default size of the largest response body
+	// kept in memory. Larger responses are passed through without caching.
+	DefaultMaxBodySize = 1 << 20
+)
+
+// varyHeaders are request headers that identify who is asking,
+// so responses are never shared across different credentials.
+var varyHeaders = []string{"Authorization", "X-Project-Token", "X-Agent-Token", "X-Aggregator-Token"}
+
+// Transport is an http.RoundTripper backed by an in-memory LRU cache.
+// Cached responses are always revalidated against the server using
+// If-None-Match, and served from memory when the server replies with
+// 304 Not Modified. Only JSON responses are cached.
+type Transport struct {
+	next        http.RoundTripper
+	maxEntries  int
+	maxBodySize int64
+
+	mu      sync.Mutex
+	ll      *list.List
+	entries map[string]*list.Element
+}
+
+type entry struct {
+	key    string
+	etag   string
+	header http.Header
+	body   []byte
+}
+
+// New transport wrapping next. When next is nil, http.DefaultTransport is used.
+// A maxEntries lower or equal to zero uses DefaultMaxEntries.
+func New(next http.RoundTripper, maxEntries int) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &Transport{
+		next:        next,
+		maxEntries:  maxEntries,
+		maxBodySize: DefaultMaxBodySize,
+		ll:          list.New(),
+		entries:     map[string]*list.Element{},
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.next.RoundTrip(req)
+	}
+
+	key := cacheKey(req)
+	cached, ok := t.get(key)
+	if ok && req.Header.Get("If-None-Match") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return cached.response(req, resp), nil
+	}
+
+	if ok {
+		t.remove(key)
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" || !isJSON(resp.Header.Get("Content-Type")) || resp.ContentLength > t.maxBodySize {
+		return resp, nil
+	}
+
+	// the body is cached while the caller reads it,
+	// and only once it was read completely.
+	resp.Body = &cachingBody{
+		ReadCloser: resp.Body,
+		transport:  t,
+		entry:      &entry{key: key, etag: etag, header: resp.Header.Clone()},
+	}
+	return resp, nil
+}
+
+// response builds a 200 response out of the cached entry, answering req
+// with the 304 response the server replied with.
+func (e *entry) response(req *http.Request, notModified *http.Response) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+// cachingBody copies the response body into its entry as it is read,
+// and adds the entry to the cache on EOF. Bodies larger than the
+// transport max body size are not cached.
+type cachingBody struct {
+	io.ReadCloser
+	transport *Transport
+	entry     *entry
+	buf       bytes.Buffer
+	skip      bool
+}
+
+func (b *cachingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if !b.skip {
+		b.buf.Write(p[:n])
+		if int64(b.buf.Len()) > b.transport.maxBodySize {
+			b.skip = true
+			b.buf = bytes.Buffer{}
+		}
+	}
+
+	if err == io.EOF && !b.skip {
+		b.skip = true
+		b.entry.body = b.buf.Bytes()
+		b.transport.add(b.entry)
+	}
+
+	return n, err
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// Len returns the number of cached responses.
+func (t *Transport) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.ll.Len()
+}
+
+func cacheKey(req *http.Request) string {
+	var b bytes.Buffer
+	b.WriteString(req.URL.String())
+	for _, h := range varyHeaders {
+		b.WriteByte('\n')
+		b.WriteString(req.Header.Get(h))
+	}
+	return b.String()
+}
+
+func (t *Transport) get(key string) (*entry, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	el, ok := t.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	t.ll.MoveToFront(el)
+	return el.Value.(*entry), true
+}
+
+func (t *Transport) add(e *entry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if el, ok := t.entries[e.key]; ok {
+		el.Value = e
+		t.ll.MoveToFront(el)
+		return
+	}
+
+	t.entries[e.key] = t.ll.PushFront(e)
+	for t.ll.Len() > t.maxEntries {
+		oldest := t.ll.Back()
+		t.ll.Remove(oldest)
+		delete(t.entries, oldest.Value.(*entry).key)
+	}
+}
+
+func (t *Transport) remove(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if el, ok := t.entries[key]; ok {
+		t.ll.Remove(el)
+		delete(t.entries, key)
+	}
+}
@@ -0,0 +1,153 @@
+package httpcache
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTransport_RoundTrip(t *testing.T) {
+	var requests, notModified int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		etag := `"v1-` + r.Header.Get("X-Project-Token") + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, "hello "+r.Header.Get("X-Project-Token"))
+	}))
+	defer srv.Close()
+
+	transport := New(nil, 2)
+	client := &http.Client{Transport: transport}
+
+	get := func(token string) string {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/v1/pipelines", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-Project-Token", token)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("want status %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	for i := 0; i < 3; i++ {
+		if got := get("a"); got != "hello a" {
+			t.Fatalf("want %q, got %q", "hello a", got)
+		}
+	}
+
+	if got := atomic.LoadInt32(&notModified); got != 2 {
+		t.Errorf("want 2 not modified responses, got %d", got)
+	}
+
+	// different credentials must not share cached responses.
+	if got := get("b"); got != "hello b" {
+		t.Fatalf("want %q, got %q", "hello b", got)
+	}
+
+	get("c")
+	if got := transport.Len(); got != 2 {
+		t.Errorf("want 2 cached entries, got %d", got)
+	}
+
+	// "a" got evicted, so it is downloaded again.
+	before := atomic.LoadInt32(&notModified)
+	get("a")
+	if got := atomic.LoadInt32(&notModified); got != before {
+		t.Errorf("want evicted entry to be downloaded again")
+	}
+}
+
+func TestTransport_RoundTrip_nonGET(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	transport := New(nil, 0)
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Post(srv.URL, "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got := transport.Len(); got != 0 {
+		t.Errorf("want no cached entries, got %d", got)
+	}
+}
+
+func TestTransport_RoundTrip_notCached(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		switch r.URL.Path {
+		case "/text":
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = io.WriteString(w, "hello")
+		case "/large":
+			w.Header().Set("Content-Type", "application/json")
+			// flush first so the body is chunked, without a content length.
+			w.(http.Flusher).Flush()
+			_, _ = io.WriteString(w, `"`+strings.Repeat("a", 64)+`"`)
+		default:
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			_, _ = io.WriteString(w, `"hello"`)
+		}
+	}))
+	defer srv.Close()
+
+	transport := New(nil, 0)
+	transport.maxBodySize = 32
+	client := &http.Client{Transport: transport}
+
+	get := func(path string, readAll bool) {
+		t.Helper()
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		if readAll {
+			_, _ = io.ReadAll(resp.Body)
+		}
+	}
+
+	get("/text", true)
+	get("/large", true)
+	// partially read bodies are not cached either.
+	get("/json", false)
+
+	if got := transport.Len(); got != 0 {
+		t.Errorf("want no cached entries, got %d", got)
+	}
+
+	get("/json", true)
+	if got := transport.Len(); got != 1 {
+		t.Errorf("want 1 cached entry, got %d", got)
+	}
+}
@@ -0,0 +1,76 @@
+// Package httptransport provides the HTTP transport shared by all the
+// requests to Calyptia Cloud. It negotiates HTTP/2 and keeps idle
+// connections alive so bulk operations reuse them instead of paying
+// a new TCP and TLS handshake per request.
+package httptransport
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	DefaultDialTimeout  = 30 * time.Second
+	DefaultKeepAlive    = 30 * time.Second
+	DefaultMaxIdleConns = 100
+
+	httpTimeoutFlag  = "http-timeout"
+	dialTimeoutFlag  = "dial-timeout"
+	maxIdleConnsFlag = "max-idle-conns"
+)
+
+// New returns an HTTP/2 enabled transport with keep-alives, which keeps
+// up to DefaultMaxIdleConns idle connections to the same host.
+func New() *http.Transport {
+	t := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          DefaultMaxIdleConns,
+		MaxIdleConnsPerHost:   DefaultMaxIdleConns,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	SetDialTimeout(t, DefaultDialTimeout)
+	return t
+}
+
+// SetDialTimeout sets the max time to establish new connections.
+// Zero means no timeout.
+func SetDialTimeout(t *http.Transport, timeout time.Duration) {
+	t.DialContext = (&net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: DefaultKeepAlive,
+	}).DialContext
+}
+
+// SetMaxIdleConns sets the max number of idle connections kept alive,
+// in total and per host, since all requests go to the same host.
+func SetMaxIdleConns(t *http.Transport, n int) {
+	t.MaxIdleConns = n
+	t.MaxIdleConnsPerHost = n
+}
+
+// Flags tune the client and its transport from the command line.
+type Flags struct {
+	HTTPTimeout  time.Duration
+	DialTimeout  time.Duration
+	MaxIdleConns int
+}
+
+// Bind adds the --http-timeout, --dial-timeout and --max-idle-conns flags.
+func (f *Flags) Bind(fs *pflag.FlagSet) {
+	fs.DurationVar(&f.HTTPTimeout, httpTimeoutFlag, 0, "Max time for each request to Calyptia Cloud, including reading the response. 0 means no timeout")
+	fs.DurationVar(&f.DialTimeout, dialTimeoutFlag, DefaultDialTimeout, "Max time to establish a connection to Calyptia Cloud. 0 means no timeout")
+	fs.IntVar(&f.MaxIdleConns, maxIdleConnsFlag, DefaultMaxIdleConns, "Max idle connections to Calyptia Cloud kept alive for reuse")
+}
+
+// Apply the flags to the client and the transport it uses.
+func (f *Flags) Apply(client *http.Client, t *http.Transport) {
+	client.Timeout = f.HTTPTimeout
+	SetDialTimeout(t, f.DialTimeout)
+	SetMaxIdleConns(t, f.MaxIdleConns)
+}
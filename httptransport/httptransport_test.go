@@ -0,0 +1,92 @@
+package httptransport
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+func TestNew_reusesConnections(t *testing.T) {
+	var conns int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Proto)
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	transport := New()
+	transport.TLSClientConfig = srv.Client().Transport.(*http.Transport).TLSClientConfig
+	client := &http.Client{Transport: transport}
+
+	get := func() string {
+		t.Helper()
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Error(err)
+			return ""
+		}
+		defer resp.Body.Close()
+
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		return string(b)
+	}
+
+	if proto := get(); proto != "HTTP/2.0" {
+		t.Fatalf("expected HTTP/2.0, got %q", proto)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			get()
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&conns); n != 1 {
+		t.Errorf("expected a single reused connection, got %d", n)
+	}
+}
+
+func TestFlags_Apply(t *testing.T) {
+	var f Flags
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	f.Bind(fs)
+	if err := fs.Parse([]string{"--http-timeout=5s", "--max-idle-conns=7"}); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{}
+	transport := New()
+	f.Apply(client, transport)
+
+	if client.Timeout != 5*time.Second {
+		t.Errorf("expected 5s client timeout, got %s", client.Timeout)
+	}
+
+	if transport.MaxIdleConns != 7 || transport.MaxIdleConnsPerHost != 7 {
+		t.Errorf("expected 7 max idle conns, got %d/%d", transport.MaxIdleConns, transport.MaxIdleConnsPerHost)
+	}
+
+	if !transport.ForceAttemptHTTP2 {
+		t.Error("expected HTTP/2 to be attempted")
+	}
+}
@@ -0,0 +1,131 @@
+// Package idempotency provides idempotency keys for create commands,
+// so re-running a failed job does not create duplicated resources.
+//
+// The key is sent to Calyptia Cloud as the Idempotency-Key header and also
+// stored as a label on the created resource, so it can be found again.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/spf13/cobra"
+
+	"github.com/calyptia/cli/labels"
+)
+
+const (
+	// Header sent along with mutating requests.
+	Header = "Idempotency-Key"
+	// Label stored on the created resources tags.
+	Label = "idempotency-key"
+
+	keyFlag        = "idempotency-key"
+	idempotentFlag = "idempotent"
+	generatedSize  = 16
+	maxKeySize     = 64
+)
+
+var keyPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+type ctxKey struct{}
+
+// ContextWithKey returns a context whose mutating requests
+// carry the given idempotency key.
+func ContextWithKey(ctx context.Context, key string) context.Context {
+	if key == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxKey{}, key)
+}
+
+// KeyFromContext returns the idempotency key set with ContextWithKey.
+func KeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(ctxKey{}).(string)
+	return key, ok
+}
+
+// Transport is an http.RoundTripper that sets the Idempotency-Key header
+// on mutating requests made with a context holding a key.
+type Transport struct {
+	next http.RoundTripper
+}
+
+// NewTransport wrapping next. When next is nil, http.DefaultTransport is used.
+func NewTransport(next http.RoundTripper) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Transport{next: next}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, ok := KeyFromContext(req.Context())
+	if !ok || req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return t.next.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set(Header, key)
+	return t.next.RoundTrip(req)
+}
+
+// NewKey derives a stable key from the given inputs.
+func NewKey(inputs ...any) (string, error) {
+	b, err := json.Marshal(inputs)
+	if err != nil {
+		return "", fmt.Errorf("could not generate idempotency key: %w", err)
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:generatedSize]), nil
+}
+
+// BindFlags adds the --idempotency-key and --idempotent flags to a create command.
+func BindFlags(cmd *cobra.Command) {
+	fs := cmd.Flags()
+	fs.String(keyFlag, "", "Idempotency key. When a resource was already created with the same key, it is returned instead of creating a new one")
+	fs.Bool(idempotentFlag, false, "Generate the idempotency key from the command inputs")
+	cmd.MarkFlagsMutuallyExclusive(keyFlag, idempotentFlag)
+}
+
+// KeyFromFlags returns the key given with --idempotency-key, or one generated
+// from the given inputs with --idempotent. It returns an empty key otherwise.
+func KeyFromFlags(cmd *cobra.Command, inputs ...any) (string, error) {
+	fs := cmd.Flags()
+	key, err := fs.GetString(keyFlag)
+	if err != nil {
+		return "", err
+	}
+
+	if key != "" {
+		if len(key) > maxKeySize || !keyPattern.MatchString(key) {
+			return "", fmt.Errorf("invalid idempotency key %q, it must be up to %d letters, digits, '.', '_' or '-'", key, maxKeySize)
+		}
+		return key, nil
+	}
+
+	idempotent, err := fs.GetBool(idempotentFlag)
+	if err != nil || !idempotent {
+		return "", err
+	}
+
+	return NewKey(inputs...)
+}
+
+// Tag returns the tag that labels a resource with the given key.
+func Tag(key string) string {
+	return Label + "=" + key
+}
+
+// HasKey reports whether the given resource tags hold the given key.
+func HasKey(tags []string, key string) bool {
+	v, ok := labels.FromTags(tags)[Label]
+	return ok && v == key
+}
@@ -0,0 +1,129 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestTransport_RoundTrip(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Method+" "+r.Header.Get(Header))
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewTransport(nil)}
+	ctx := ContextWithKey(context.Background(), "abc")
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		req, err := http.NewRequestWithContext(ctx, method, srv.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	want := []string{"GET ", "POST abc", "POST "}
+	if len(got) != len(want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("want %q, got %q", want[i], got[i])
+		}
+	}
+}
+
+func TestKeyFromFlags(t *testing.T) {
+	tt := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{name: "none", args: nil, want: ""},
+		{name: "explicit", args: []string{"--idempotency-key", "job-42.run_1"}, want: "job-42.run_1"},
+		{name: "invalid", args: []string{"--idempotency-key", "has space"}, wantErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			BindFlags(cmd)
+			if err := cmd.ParseFlags(tc.args); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := KeyFromFlags(cmd, "input")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if got != tc.want {
+				t.Errorf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+
+	t.Run("generated", func(t *testing.T) {
+		cmd := &cobra.Command{}
+		BindFlags(cmd)
+		if err := cmd.ParseFlags([]string{"--idempotent"}); err != nil {
+			t.Fatal(err)
+		}
+
+		a, err := KeyFromFlags(cmd, "core-instance", map[string]string{"name": "a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := KeyFromFlags(cmd, "core-instance", map[string]string{"name": "a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c, err := KeyFromFlags(cmd, "core-instance", map[string]string{"name": "b"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if a == "" || a != b {
+			t.Errorf("want stable key, got %q and %q", a, b)
+		}
+		if a == c {
+			t.Errorf("want different keys for different inputs, got %q", a)
+		}
+		if len(a) != generatedSize*2 {
+			t.Errorf("unexpected key length %d", len(a))
+		}
+	})
+}
+
+func TestHasKey(t *testing.T) {
+	tags := []string{"prod", Tag("abc")}
+	if !HasKey(tags, "abc") {
+		t.Error("want key to be found")
+	}
+	if HasKey(tags, "other") {
+		t.Error("want key to not be found")
+	}
+}
@@ -0,0 +1,183 @@
+// Package interrupt makes long running commands stop gracefully on Ctrl-C.
+//
+// The first SIGINT or SIGTERM cancels the context returned by NotifyContext
+// so the running command can stop at the next step. A second one runs the
+// registered cleanup handlers, prints a summary of the completed and pending
+// steps and exits right away.
+package interrupt
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// ExitCode used when force quitting, as a shell does for SIGINT.
+const ExitCode = 130
+
+// Handler tracks the cleanup handlers and steps of the running command.
+type Handler struct {
+	Out  io.Writer
+	Exit func(code int)
+
+	mu       sync.Mutex
+	nextID   int
+	cleanups map[int]func()
+	order    []int
+	steps    []*step
+}
+
+type step struct {
+	name string
+	done bool
+}
+
+// New handler writing its messages to the given writer.
+func New(out io.Writer) *Handler {
+	return &Handler{
+		Out:      out,
+		Exit:     os.Exit,
+		cleanups: map[int]func(){},
+	}
+}
+
+// Default handler used by the package level functions.
+var Default = New(os.Stderr)
+
+// NotifyContext returns a context canceled on the first SIGINT or SIGTERM.
+// Calling stop releases the signal handling.
+func (h *Handler) NotifyContext(parent context.Context) (ctx context.Context, stop context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	go func() {
+		h.handle(sigs, cancel, done)
+	}()
+
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			signal.Stop(sigs)
+			close(done)
+			cancel()
+		})
+	}
+}
+
+func (h *Handler) handle(sigs <-chan os.Signal, cancel context.CancelFunc, done <-chan struct{}) {
+	select {
+	case <-sigs:
+	case <-done:
+		return
+	}
+
+	fmt.Fprintln(h.Out, "\nInterrupted, stopping after the current step. Press Ctrl-C again to force quit.")
+	cancel()
+
+	select {
+	case <-sigs:
+	case <-done:
+		return
+	}
+
+	h.RunCleanups()
+	h.PrintSummary()
+	h.Exit(ExitCode)
+}
+
+// OnCleanup registers fn to run on force quit or when RunCleanups gets called.
+// Handlers run in reverse registration order. Calling remove unregisters it.
+func (h *Handler) OnCleanup(fn func()) (remove func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	id := h.nextID
+	h.nextID++
+	h.cleanups[id] = fn
+	h.order = append(h.order, id)
+
+	return func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.cleanups, id)
+	}
+}
+
+// RunCleanups runs and unregisters every cleanup handler.
+func (h *Handler) RunCleanups() {
+	h.mu.Lock()
+	var fns []func()
+	for i := len(h.order) - 1; i >= 0; i-- {
+		if fn, ok := h.cleanups[h.order[i]]; ok {
+			fns = append(fns, fn)
+		}
+	}
+	h.cleanups = map[int]func(){}
+	h.order = nil
+	h.mu.Unlock()
+
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+// Step records the start of a named step to be reported on force quit.
+// Calling done marks the step as completed.
+func (h *Handler) Step(name string) (done func()) {
+	s := &step{name: name}
+
+	h.mu.Lock()
+	h.steps = append(h.steps, s)
+	h.mu.Unlock()
+
+	return func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		s.done = true
+	}
+}
+
+// PrintSummary prints the completed and pending steps, if any.
+func (h *Handler) PrintSummary() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.steps) == 0 {
+		return
+	}
+
+	fmt.Fprintln(h.Out, "Force quit. Summary:")
+	for _, s := range h.steps {
+		status := "not completed"
+		if s.done {
+			status = "completed"
+		}
+		fmt.Fprintf(h.Out, "  %s: %s\n", s.name, status)
+	}
+}
+
+// NotifyContext calls NotifyContext on the default handler.
+func NotifyContext(parent context.Context) (context.Context, context.CancelFunc) {
+	return Default.NotifyContext(parent)
+}
+
+// OnCleanup calls OnCleanup on the default handler.
+func OnCleanup(fn func()) (remove func()) {
+	return Default.OnCleanup(fn)
+}
+
+// RunCleanups calls RunCleanups on the default handler.
+func RunCleanups() {
+	Default.RunCleanups()
+}
+
+// Step calls Step on the default handler.
+func Step(name string) (done func()) {
+	return Default.Step(name)
+}
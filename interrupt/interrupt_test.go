@@ -0,0 +1,51 @@
+package interrupt
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestHandler_handle(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf)
+
+	exited := make(chan int, 1)
+	h.Exit = func(code int) { exited <- code }
+
+	var cleaned []string
+	h.OnCleanup(func() { cleaned = append(cleaned, "first") })
+	remove := h.OnCleanup(func() { cleaned = append(cleaned, "removed") })
+	h.OnCleanup(func() { cleaned = append(cleaned, "last") })
+	remove()
+
+	h.Step("apply manifest")()
+	h.Step("wait ready")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigs := make(chan os.Signal, 2)
+	go h.handle(sigs, cancel, make(chan struct{}))
+
+	sigs <- os.Interrupt
+	<-ctx.Done()
+
+	sigs <- os.Interrupt
+	if code := <-exited; code != ExitCode {
+		t.Fatalf("expected exit code %d, got %d", ExitCode, code)
+	}
+
+	if got := strings.Join(cleaned, ","); got != "last,first" {
+		t.Fatalf("unexpected cleanups: %q", got)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"Press Ctrl-C again", "apply manifest: completed", "wait ready: not completed"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected output to contain %q, got %q", want, out)
+		}
+	}
+}
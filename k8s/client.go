@@ -2,11 +2,10 @@ package k8s
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -20,14 +19,13 @@ import (
 	"k8s.io/client-go/dynamic"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/homedir"
 
 	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
-	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	cloud "github.com/calyptia/api/types"
 	"github.com/calyptia/cli/cmd/utils"
@@ -50,7 +48,11 @@ const (
 
 var (
 	ErrNoContext            = fmt.Errorf("no context is currently set")
-	ErrCoreOperatorNotFound = fmt.Errorf("could not find core operator across all namespaces")
+	ErrCoreOperatorNotFound = &Error{
+		Op:   "could not find core operator",
+		Kind: ErrNotFound,
+		Err:  errors.New("no calyptia-core-controller-manager deployment across all namespaces"),
+	}
 )
 
 var (
@@ -66,6 +68,31 @@ type Client struct {
 	CloudBaseURL string
 	LabelsFunc   func() map[string]string
 	Config       *restclient.Config
+	// ConflictPolicy used when an object to be created already exists.
+	ConflictPolicy ConflictPolicy
+	// WorkloadIdentity to link generated service accounts to.
+	WorkloadIdentity WorkloadIdentity
+	// Scheduling of the core instance and core operator sync pods.
+	Scheduling Scheduling
+	// ImagePullSecrets are the names of the secrets the core instance
+	// and core operator sync pods pull their images with.
+	ImagePullSecrets []string
+	// CoreResources of the core instance container.
+	CoreResources apiv1.ResourceRequirements
+	// SyncResources of each of the core operator sync containers.
+	SyncResources apiv1.ResourceRequirements
+	// CoreEnv are extra environment variables for the core instance container,
+	// overriding the generated ones.
+	CoreEnv map[string]string
+	// OnQuotaIssues, if set, is called with the ResourceQuota and LimitRange
+	// issues found before creating a deployment. Returning an error aborts it.
+	OnQuotaIssues func(deployment string, issues []QuotaIssue) error
+	// OnRolloutProgress, if set, is called with the progress of
+	// the deployment rollout while waiting for it to be ready.
+	OnRolloutProgress func(deployment, status string)
+	// OnPodFailure, if set, is called when waiting verbosely for a deployment
+	// each time the reason one of its rollout pods is not ready changes.
+	OnPodFailure func(deployment, pod, reason string)
 }
 
 func (client *Client) getObjectMeta(agg cloud.CreatedCoreInstance, objectType objectType) metav1.ObjectMeta {
@@ -79,7 +106,7 @@ func (client *Client) getObjectMeta(agg cloud.CreatedCoreInstance, objectType ob
 	}
 }
 
-func (client *Client) EnsureOwnNamespace(ctx context.Context) error {
+func (client *Client) EnsureOwnNamespace(ctx context.Context, create bool) error {
 	exists, err := client.ownNamespaceExists(ctx)
 	if err != nil {
 		return fmt.Errorf("exists: %w", err)
@@ -89,6 +116,10 @@ func (client *Client) EnsureOwnNamespace(ctx context.Context) error {
 		return nil
 	}
 
+	if !create {
+		return fmt.Errorf("namespace %q does not exist", client.Namespace)
+	}
+
 	_, err = client.createOwnNamespace(ctx)
 	if err != nil {
 		return fmt.Errorf("create: %w", err)
@@ -132,11 +163,10 @@ func (client *Client) CreateSecret(ctx context.Context, agg cloud.CreatedCoreIns
 		APIVersion: "v1",
 	}
 
-	options := metav1.CreateOptions{}
 	if dryRun {
 		return req, nil
 	}
-	return client.CoreV1().Secrets(client.Namespace).Create(ctx, req, options)
+	return createWithPolicy(ctx, client.ConflictPolicy, req, client.secretOps())
 }
 
 func (client *Client) CreateSecretOperatorRSAKey(ctx context.Context, agg cloud.CreatedCoreInstance, dryRun bool) (*apiv1.Secret, error) {
@@ -152,18 +182,18 @@ func (client *Client) CreateSecretOperatorRSAKey(ctx context.Context, agg cloud.
 		APIVersion: "v1",
 	}
 
-	options := metav1.CreateOptions{}
 	if dryRun {
 		return req, nil
 	}
-	return client.CoreV1().Secrets(client.Namespace).Create(ctx, req, options)
+	return createWithPolicy(ctx, client.ConflictPolicy, req, client.secretOps())
 }
 
 type ClusterRoleOpt struct {
 	EnableOpenShift bool
 }
 
-func (client *Client) CreateClusterRole(ctx context.Context, agg cloud.CreatedCoreInstance, dryRun bool, opts ...ClusterRoleOpt) (*rbacv1.ClusterRole, error) {
+// ClusterRoleRules returns the rules granted to a core instance cluster role.
+func ClusterRoleRules(opts ...ClusterRoleOpt) []rbacv1.PolicyRule {
 	apiGroups := []string{"", "apps", "batch", "policy", "core.calyptia.com"}
 	resources := []string{
 		"namespaces",
@@ -194,26 +224,30 @@ func (client *Client) CreateClusterRole(ctx context.Context, agg cloud.CreatedCo
 			resources = append(resources, "securitycontextconstraints")
 		}
 	}
-	req := &rbacv1.ClusterRole{
-		ObjectMeta: client.getObjectMeta(agg, clusterRoleObjectType),
-		Rules: []rbacv1.PolicyRule{
-			{
-				APIGroups: apiGroups,
-				Resources: resources,
-				Verbs: []string{
-					"get",
-					"list",
-					"create",
-					"delete",
-					"patch",
-					"update",
-					"watch",
-					"deletecollection",
-					"use",
-				},
+	return []rbacv1.PolicyRule{
+		{
+			APIGroups: apiGroups,
+			Resources: resources,
+			Verbs: []string{
+				"get",
+				"list",
+				"create",
+				"delete",
+				"patch",
+				"update",
+				"watch",
+				"deletecollection",
+				"use",
 			},
 		},
 	}
+}
+
+func (client *Client) CreateClusterRole(ctx context.Context, agg cloud.CreatedCoreInstance, dryRun bool, opts ...ClusterRoleOpt) (*rbacv1.ClusterRole, error) {
+	req := &rbacv1.ClusterRole{
+		ObjectMeta: client.getObjectMeta(agg, clusterRoleObjectType),
+		Rules:      ClusterRoleRules(opts...),
+	}
 
 	req.TypeMeta = metav1.TypeMeta{
 		Kind:       "ClusterRole",
@@ -224,13 +258,14 @@ func (client *Client) CreateClusterRole(ctx context.Context, agg cloud.CreatedCo
 		return req, nil
 	}
 
-	return client.RbacV1().ClusterRoles().Create(ctx, req, metav1.CreateOptions{})
+	return createWithPolicy(ctx, client.ConflictPolicy, req, client.clusterRoleOps())
 }
 
 func (client *Client) CreateServiceAccount(ctx context.Context, agg cloud.CreatedCoreInstance, dryRun bool) (*apiv1.ServiceAccount, error) {
 	req := &apiv1.ServiceAccount{
 		ObjectMeta: client.getObjectMeta(agg, serviceAccountObjectType),
 	}
+	req.Annotations = client.WorkloadIdentity.Annotations()
 
 	req.TypeMeta = metav1.TypeMeta{
 		Kind:       "ServiceAccount",
@@ -241,7 +276,7 @@ func (client *Client) CreateServiceAccount(ctx context.Context, agg cloud.Create
 		return req, nil
 	}
 
-	return client.CoreV1().ServiceAccounts(client.Namespace).Create(ctx, req, metav1.CreateOptions{})
+	return createWithPolicy(ctx, client.ConflictPolicy, req, client.serviceAccountOps())
 }
 
 func (client *Client) CreateClusterRoleBinding(
@@ -271,12 +306,11 @@ func (client *Client) CreateClusterRoleBinding(
 		Kind:       "ClusterRoleBinding",
 		APIVersion: "rbac.authorization.k8s.io/v1",
 	}
-	options := metav1.CreateOptions{}
 	if dryRun {
 		return req, nil
 	}
 
-	return client.RbacV1().ClusterRoleBindings().Create(ctx, req, options)
+	return createWithPolicy(ctx, client.ConflictPolicy, req, client.clusterRoleBindingOps())
 }
 
 func (client *Client) CreateDeployment(
@@ -305,6 +339,7 @@ func (client *Client) CreateDeployment(
 				Spec: apiv1.PodSpec{
 					ServiceAccountName:           serviceAccount.Name,
 					AutomountServiceAccountToken: &automountServiceAccountToken,
+					ImagePullSecrets:             client.imagePullSecrets(),
 					Containers: []apiv1.Container{
 						{
 							Name:            agg.Name,
@@ -357,12 +392,21 @@ func (client *Client) CreateDeployment(
 		APIVersion: "apps/v1",
 	}
 
-	options := metav1.CreateOptions{}
+	client.Scheduling.Apply(&req.Spec.Template.Spec)
+
+	container := &req.Spec.Template.Spec.Containers[0]
+	container.Resources = client.CoreResources
+	container.Env, _ = EnvVarsChange{Set: client.CoreEnv}.Apply(container.Env)
+
 	if dryRun {
 		return req, nil
 	}
 
-	return client.AppsV1().Deployments(client.Namespace).Create(ctx, req, options)
+	if err := client.checkDeploymentQuota(ctx, req); err != nil {
+		return nil, err
+	}
+
+	return createWithPolicy(ctx, client.ConflictPolicy, req, client.deploymentOps())
 }
 
 func (client *Client) DeleteDeploymentByLabel(ctx context.Context, label, ns string) error {
@@ -442,10 +486,10 @@ func (client *Client) FindServicesByLabel(ctx context.Context, label, ns string)
 func (client *Client) UpdateDeploymentByLabel(ctx context.Context, label, newImage, tlsVerify string) error {
 	deploymentList, err := client.FindDeploymentByLabel(ctx, label)
 	if err != nil {
-		return err
+		return wrapErr("find deployment with label "+label, err)
 	}
 	if len(deploymentList.Items) == 0 {
-		return fmt.Errorf("no deployment found with label %s", label)
+		return notFoundErr("find deployment with label "+label, "no deployment found")
 	}
 	deployment := deploymentList.Items[0]
 	if len(deployment.Spec.Template.Spec.Containers) == 0 {
@@ -477,7 +521,7 @@ func (client *Client) UpdateDeploymentByLabel(ctx context.Context, label, newIma
 
 	_, err = client.AppsV1().Deployments(client.Namespace).Update(ctx, &deployment, metav1.UpdateOptions{})
 	if err != nil {
-		return err
+		return wrapErr("update deployment "+deployment.Name, err)
 	}
 
 	return nil
@@ -486,10 +530,10 @@ func (client *Client) UpdateDeploymentByLabel(ctx context.Context, label, newIma
 func (client *Client) UpdateSyncDeploymentByLabel(ctx context.Context, label, newImage, tlsVerify string, verbose bool, waitTimeout time.Duration) error {
 	deploymentList, err := client.FindDeploymentByLabel(ctx, label)
 	if err != nil {
-		return err
+		return wrapErr("find deployment with label "+label, err)
 	}
 	if len(deploymentList.Items) == 0 {
-		return fmt.Errorf("no deployment found with label %s", label)
+		return notFoundErr("find deployment with label "+label, "no deployment found")
 	}
 	deployment := deploymentList.Items[0]
 	if len(deployment.Spec.Template.Spec.Containers) == 0 {
@@ -509,11 +553,11 @@ func (client *Client) UpdateSyncDeploymentByLabel(ctx context.Context, label, ne
 
 	_, err = client.AppsV1().Deployments(client.Namespace).Update(ctx, &deployment, metav1.UpdateOptions{})
 	if err != nil {
-		return err
+		return wrapErr("update deployment "+deployment.Name, err)
 	}
 
 	if err := client.rolloutDeployment(ctx, deployment.Namespace, deployment.Name); err != nil {
-		return err
+		return wrapErr("restart deployment "+deployment.Name, err)
 	}
 
 	if err := client.WaitReady(ctx, deployment.Namespace, deployment.Name, verbose, waitTimeout); err != nil {
@@ -553,10 +597,10 @@ func (client *Client) updateEnvVars(envVars []apiv1.EnvVar, tlsVerify string) []
 func (client *Client) UpdateOperatorDeploymentByLabel(ctx context.Context, label string, newImage string, verbose bool, waitTimeout time.Duration) error {
 	deploymentList, err := client.FindDeploymentByLabel(ctx, label)
 	if err != nil {
-		return err
+		return wrapErr("find deployment with label "+label, err)
 	}
 	if len(deploymentList.Items) == 0 {
-		return fmt.Errorf("no deployment found with label %s", label)
+		return notFoundErr("find deployment with label "+label, "no deployment found")
 	}
 	deployment := deploymentList.Items[0]
 	if len(deployment.Spec.Template.Spec.Containers) == 0 {
@@ -566,11 +610,11 @@ func (client *Client) UpdateOperatorDeploymentByLabel(ctx context.Context, label
 	deployment.Spec.Template.Spec.Containers[0].Image = newImage
 	_, err = client.AppsV1().Deployments(client.Namespace).Update(ctx, &deployment, metav1.UpdateOptions{})
 	if err != nil {
-		return err
+		return wrapErr("update deployment "+deployment.Name, err)
 	}
 
 	if err := client.rolloutDeployment(ctx, deployment.Namespace, deployment.Name); err != nil {
-		return err
+		return wrapErr("restart deployment "+deployment.Name, err)
 	}
 
 	if err := client.WaitReady(ctx, deployment.Namespace, deployment.Name, verbose, waitTimeout); err != nil {
@@ -636,12 +680,14 @@ func (client *Client) DeployCoreOperatorSync(ctx context.Context, coreCloudURL,
 		Image:           toCloudImage,
 		ImagePullPolicy: apiv1.PullAlways,
 		Env:             env,
+		Resources:       *client.SyncResources.DeepCopy(),
 	}
 	fromCloud := apiv1.Container{
 		Name:            coreInstance.Name + "-sync-from-cloud",
 		Image:           fromCloudImage,
 		ImagePullPolicy: apiv1.PullAlways,
 		Env:             env,
+		Resources:       *client.SyncResources.DeepCopy(),
 	}
 
 	req := &appsv1.Deployment{
@@ -665,14 +711,20 @@ func (client *Client) DeployCoreOperatorSync(ctx context.Context, coreCloudURL,
 				},
 				Spec: apiv1.PodSpec{
 					ServiceAccountName: serviceAccount,
+					ImagePullSecrets:   client.imagePullSecrets(),
 					Containers:         []apiv1.Container{fromCloud, toCloud},
 				},
 			},
 		},
 	}
 
-	options := metav1.CreateOptions{}
-	return client.AppsV1().Deployments(client.Namespace).Create(ctx, req, options)
+	client.Scheduling.Apply(&req.Spec.Template.Spec)
+
+	if err := client.checkDeploymentQuota(ctx, req); err != nil {
+		return nil, err
+	}
+
+	return createWithPolicy(ctx, client.ConflictPolicy, req, client.deploymentOps())
 }
 
 type ResourceRollBack struct {
@@ -680,99 +732,87 @@ type ResourceRollBack struct {
 	GVR  schema.GroupVersionResource
 }
 
-func (client *Client) DeleteResources(ctx context.Context, resources []ResourceRollBack) ([]ResourceRollBack, error) {
-	dynamicClient, err := dynamic.NewForConfig(client.Config)
-	if err != nil {
-		return nil, err
-	}
-	var deletedResources []ResourceRollBack
-	for _, r := range resources {
-		resource := dynamicClient.Resource(r.GVR)
-		err = resource.Namespace(client.Namespace).Delete(ctx, r.Name, metav1.DeleteOptions{})
-		if err != nil {
-			return nil, err
-		}
-		deletedResources = append(deletedResources, r)
-	}
-	return deletedResources, nil
-}
-
-var GetOperatorManifest = func(version string) ([]byte, error) {
-	url, err := getOperatorDownloadURL(version)
-	if err != nil {
-		return nil, err
-	}
-	response, err := http.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("error downloading operator manifest: %w", err)
-	}
-	defer func(Body io.ReadCloser) {
-		err := Body.Close()
-		if err != nil {
-			fmt.Println("Error closing response body:", err)
+func (r ResourceRollBack) String() string {
+	return r.GVR.Resource + "/" + r.Name
+}
+
+// rollBackKinds are the kinds, by resource, that DeleteResources deletes
+// with the typed clients, so no dynamic client is needed for them.
+var rollBackKinds = map[schema.GroupVersionResource]string{
+	appsv1.SchemeGroupVersion.WithResource("deployments"):         "Deployment",
+	apiv1.SchemeGroupVersion.WithResource("secrets"):              "Secret",
+	apiv1.SchemeGroupVersion.WithResource("serviceaccounts"):      "ServiceAccount",
+	rbacv1.SchemeGroupVersion.WithResource("clusterroles"):        "ClusterRole",
+	rbacv1.SchemeGroupVersion.WithResource("clusterrolebindings"): "ClusterRoleBinding",
+}
+
+// NewResourceRollBack returns the entry to delete the given object with DeleteResources.
+// Objects returned by the typed clients have no type meta, so the resource
+// is found from the object type.
+func NewResourceRollBack(obj metav1.Object) (ResourceRollBack, error) {
+	var kind string
+	switch obj.(type) {
+	case *appsv1.Deployment:
+		kind = "Deployment"
+	case *apiv1.Secret:
+		kind = "Secret"
+	case *apiv1.ServiceAccount:
+		kind = "ServiceAccount"
+	case *rbacv1.ClusterRole:
+		kind = "ClusterRole"
+	case *rbacv1.ClusterRoleBinding:
+		kind = "ClusterRoleBinding"
+	default:
+		return ResourceRollBack{}, fmt.Errorf("unsupported rollback object %T", obj)
+	}
+
+	for gvr, k := range rollBackKinds {
+		if k == kind {
+			return ResourceRollBack{Name: obj.GetName(), GVR: gvr}, nil
 		}
-	}(response.Body)
-
-	manifestBytes, err := io.ReadAll(response.Body)
-	if err != nil {
-		return nil, err
 	}
 
-	return manifestBytes, nil
+	return ResourceRollBack{}, fmt.Errorf("unsupported rollback kind %q", kind)
 }
 
-func getOperatorDownloadURL(version string) (string, error) {
-	const operatorReleases = "https://api.github.com/repos/calyptia/core-operator-releases/releases"
-	type Release struct {
-		TagName string `json:"tag_name"`
-		Assets  []struct {
-			BrowserDownloadUrl string `json:"browser_download_url"`
-		} `json:"assets"`
-	}
-
-	resp, err := http.Get(operatorReleases)
-	if err != nil {
-		return "", fmt.Errorf("failed to get releases: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected HTTP status: %d", resp.StatusCode)
-	}
-
-	var releases []Release
-	err = json.NewDecoder(resp.Body).Decode(&releases)
-	if err != nil {
-		return "", fmt.Errorf("failed to decode releases: %w", err)
-	}
-
-	if len(releases) == 0 {
-		return "", fmt.Errorf("no releases found")
-	}
-
-	if version == "" {
-		if len(releases[0].Assets) == 0 {
-			return "", fmt.Errorf("no assets found for the latest release")
-		}
-		return releases[0].Assets[0].BrowserDownloadUrl, nil
-	}
+// DeleteResources deletes the given resources, ignoring those already gone.
+// It goes through all of them and returns the deleted ones
+// along with the errors of the others.
+func (client *Client) DeleteResources(ctx context.Context, resources []ResourceRollBack) ([]ResourceRollBack, error) {
+	var dynamicClient dynamic.Interface
+	var deletedResources []ResourceRollBack
+	var errs []error
+	for _, r := range resources {
+		var err error
+		if kind, ok := rollBackKinds[r.GVR]; ok {
+			err = client.DeleteObject(ctx, NamedObject{Kind: kind, Namespace: client.Namespace, Name: r.Name})
+		} else {
+			if dynamicClient == nil {
+				dynamicClient, err = dynamic.NewForConfig(client.Config)
+				if err != nil {
+					return deletedResources, err
+				}
+			}
 
-	for _, release := range releases {
-		if release.TagName == version {
-			if len(release.Assets) == 0 {
-				return "", fmt.Errorf("no assets found for the version: %s", version)
+			err = dynamicClient.Resource(r.GVR).Namespace(client.Namespace).Delete(ctx, r.Name, metav1.DeleteOptions{})
+			if apiErrors.IsNotFound(err) {
+				err = nil
 			}
-			return release.Assets[0].BrowserDownloadUrl, nil
 		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r, err))
+			continue
+		}
+		deletedResources = append(deletedResources, r)
 	}
-
-	return "", fmt.Errorf("version %s not found", version)
+	return deletedResources, errors.Join(errs...)
 }
 
 func GetCurrentContextNamespace() (string, error) {
 	kubeconfig := os.Getenv(clientcmd.RecommendedConfigPathEnvVar)
 	if kubeconfig == "" {
-		kubeconfig = clientcmd.RecommendedHomeFile
+		// clientcmd.RecommendedHomeFile is resolved once at init, so look up the home directory now.
+		kubeconfig = filepath.Join(homedir.HomeDir(), clientcmd.RecommendedHomeDir, clientcmd.RecommendedFileName)
 	}
 	config, err := clientcmd.LoadFromFile(kubeconfig)
 	if err != nil {
@@ -799,67 +839,6 @@ func ExtractGroupVersionResource(obj runtime.Object) (schema.GroupVersionResourc
 	return gvr, nil
 }
 
-func (client *Client) WaitReady(ctx context.Context, namespace, name string, verbose bool, waitTimeout time.Duration) error {
-	if err := wait.PollUntilContextTimeout(ctx, 3*time.Second, waitTimeout, true, client.isDeploymentReady(ctx, namespace, name)); err != nil {
-		if verbose {
-			get, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
-			if err != nil {
-				return err
-			}
-
-			pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: metav1.FormatLabelSelector(get.Spec.Selector)})
-			if err != nil {
-				return err
-			}
-
-			podMessages := map[string]string{}
-			for _, pod := range pods.Items {
-				var containerStatus []string
-				for _, status := range pod.Status.ContainerStatuses {
-					if status.State.Waiting != nil {
-						containerStatus = append(containerStatus, status.State.Waiting.Message)
-					}
-				}
-				if len(containerStatus) != 0 {
-					podMessages[pod.Name] = strings.Join(containerStatus, "\n")
-				}
-			}
-			if len(podMessages) != 0 {
-				var message string
-				for k, v := range podMessages {
-					message += fmt.Sprintf("* pod %s, Message: %s'\n", k, v)
-				}
-				return fmt.Errorf("failed while waiting for deployment to start:\n%s", message)
-			}
-		}
-		return err
-	}
-	return nil
-}
-
-func (client *Client) isDeploymentReady(ctx context.Context, namespace, name string) wait.ConditionWithContextFunc {
-	return func(ctx context.Context) (done bool, err error) {
-		get, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
-		if err != nil {
-			return false, err
-		}
-
-		pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: metav1.FormatLabelSelector(get.Spec.Selector)})
-		if err != nil {
-			return false, err
-		}
-
-		var running bool
-		for _, pod := range pods.Items {
-			running = pod.Status.Phase == apiv1.PodRunning
-			if !running {
-				break
-			}
-		}
-		return running, nil
-	}
-}
-
 // ClusterInfo information that is retrieved from the running cluster.
 type ClusterInfo struct {
 	Namespace, Platform, Version string
@@ -894,7 +873,7 @@ func (client *Client) DeleteCoreInstance(ctx context.Context, name, environment
 
 	namespaceList, err := client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
 	if err != nil {
-		return err
+		return wrapErr("list namespaces", err)
 	}
 	for _, namespace := range namespaceList.Items {
 		namespaceName := namespace.Name
@@ -902,40 +881,43 @@ func (client *Client) DeleteCoreInstance(ctx context.Context, name, environment
 		// Delete Deployment
 		err = client.AppsV1().Deployments(namespaceName).Delete(ctx, core.Deployment, metav1.DeleteOptions{})
 		if err != nil && !apiErrors.IsNotFound(err) {
-			return err
+			return wrapErr(fmt.Sprintf("delete deployment %s/%s", namespaceName, core.Deployment), err)
 		}
 
 		// Delete Secret
 		err = client.CoreV1().Secrets(namespaceName).Delete(ctx, core.Secret, metav1.DeleteOptions{})
 		if err != nil && !apiErrors.IsNotFound(err) {
-			return err
+			return wrapErr(fmt.Sprintf("delete secret %s/%s", namespaceName, core.Secret), err)
 		}
 
 		// Delete ClusterRole
 		err = client.RbacV1().ClusterRoles().Delete(ctx, core.ClusterRole, metav1.DeleteOptions{})
 		if err != nil && !apiErrors.IsNotFound(err) {
-			return err
+			return wrapErr("delete cluster role "+core.ClusterRole, err)
 		}
 
 		// Delete ClusterRoleBinding
 		err = client.RbacV1().ClusterRoleBindings().Delete(ctx, core.ClusterRoleBinding, metav1.DeleteOptions{})
 		if err != nil && !apiErrors.IsNotFound(err) {
-			return err
+			return wrapErr("delete cluster role binding "+core.ClusterRoleBinding, err)
 		}
 
 		// Delete ServiceAccount
 		err = client.CoreV1().ServiceAccounts(namespaceName).Delete(ctx, core.ServiceAccount, metav1.DeleteOptions{})
 		if err != nil && !apiErrors.IsNotFound(err) {
-			return err
+			return wrapErr(fmt.Sprintf("delete service account %s/%s", namespaceName, core.ServiceAccount), err)
 		}
 		if shouldWait {
 			// Wait for the resources to be deleted
-			err = wait.PollImmediate(time.Second, time.Minute, func() (bool, error) {
+			err = wait.PollUntilContextTimeout(ctx, time.Second, time.Minute, true, func(ctx context.Context) (bool, error) {
 				_, err := client.AppsV1().Deployments(namespaceName).Get(ctx, core.Deployment, metav1.GetOptions{})
-				return err != nil, nil
+				if apiErrors.IsNotFound(err) {
+					return true, nil
+				}
+				return false, err
 			})
 			if err != nil {
-				panic(fmt.Errorf("failed to wait for Deployment deletion in namespace %s: %v", namespaceName, err))
+				return wrapErr(fmt.Sprintf("wait for deployment %s/%s deletion", namespaceName, core.Deployment), err)
 			}
 		}
 	}
@@ -959,8 +941,16 @@ func (client *Client) CheckOperatorVersion(ctx context.Context) (string, error)
 	if err != nil {
 		return "", err
 	}
+	if len(manager.Spec.Template.Spec.Containers) == 0 {
+		return "", fmt.Errorf(noContainersErrString, manager.Name)
+	}
+
 	managerImage := manager.Spec.Template.Spec.Containers[0].Image
-	managerImageVersion := strings.Split(managerImage, ":")[1]
+	// the tag comes after the last colon, unless it belongs to a registry port.
+	var managerImageVersion string
+	if i := strings.LastIndex(managerImage, ":"); i > strings.LastIndex(managerImage, "/") {
+		managerImageVersion = managerImage[i+1:]
+	}
 	if managerImageVersion == "" {
 		return "", fmt.Errorf("could not parse version from manager image: %s", managerImage)
 	}
@@ -970,22 +960,21 @@ func (client *Client) CheckOperatorVersion(ctx context.Context) (string, error)
 func (client *Client) SearchManagerAcrossAllNamespaces(ctx context.Context) (*appsv1.Deployment, error) {
 	namespaces, err := client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
 	if err != nil {
-		return nil, err
+		return nil, wrapErr("list namespaces", err)
 	}
-	var manager *appsv1.Deployment
 	for _, namespace := range namespaces.Items {
-		manager, err = client.AppsV1().Deployments(namespace.Name).Get(ctx, "calyptia-core-controller-manager", metav1.GetOptions{})
-		if err != nil && !apiErrors.IsNotFound(err) {
-			return nil, err
+		manager, err := client.AppsV1().Deployments(namespace.Name).Get(ctx, "calyptia-core-controller-manager", metav1.GetOptions{})
+		if apiErrors.IsNotFound(err) {
+			continue
 		}
-		if manager.Name != "" {
-			break
+		if err != nil {
+			return nil, wrapErr("get core operator deployment in namespace "+namespace.Name, err)
+		}
+		if manager != nil && manager.Name != "" {
+			return manager, nil
 		}
 	}
-	if manager.Name == "" {
-		return nil, ErrCoreOperatorNotFound
-	}
-	return manager, err
+	return nil, ErrCoreOperatorNotFound
 }
 
 // GetNamespace returns the namespace if it exists.
@@ -993,100 +982,30 @@ func (client *Client) GetNamespace(ctx context.Context, name string) (*apiv1.Nam
 	return client.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
 }
 
+// IsOperatorInstalled reports whether any of the core operator components are
+// present in the cluster. When it does, the returned error is an
+// *OperatorIncompleteError listing them.
 func (client *Client) IsOperatorInstalled(ctx context.Context) (bool, error) {
-	operatorIncomplete := OperatorIncompleteError{
-		Errors: []error{},
-	}
-
-	dynClient, err := dynamic.NewForConfig(client.Config)
+	status, err := client.OperatorStatus(ctx)
 	if err != nil {
 		return false, err
 	}
 
-	gkv := schema.GroupVersionResource{Group: "core.calyptia.com", Version: "v1", Resource: "pipelines"}
-	_, err = dynClient.Resource(gkv).List(context.TODO(), metav1.ListOptions{})
-	if err == nil {
-		operatorIncomplete.Errors = append(operatorIncomplete.Errors, fmt.Errorf("CustomResourceDefinition Pipeline installed"))
-	}
-
-	scheme := runtime.NewScheme()
-	appsv1.AddToScheme(scheme)
-	rbacv1.AddToScheme(scheme)
-	corev1.AddToScheme(scheme)
-	k8sc, err := k8sclient.New(client.Config, k8sclient.Options{Scheme: scheme})
-	if err != nil {
-		panic(err)
-	}
-	deploymentList := &appsv1.DeploymentList{}
-	if err := k8sc.List(context.Background(), deploymentList, &k8sclient.ListOptions{}); err != nil {
-		panic(err)
-	}
-	for _, i := range deploymentList.Items {
-		if i.Name == operatorDeploymentName {
-			operatorIncomplete.Errors = append(operatorIncomplete.Errors, fmt.Errorf("Operator pod: %s/%s", i.Namespace, i.Name))
-		}
-	}
-
-	clusterRoles := &rbacv1.ClusterRoleList{}
-	if err := k8sc.List(context.Background(), clusterRoles, &k8sclient.ListOptions{}); err != nil {
-		panic(err)
-	}
-	for _, i := range clusterRoles.Items {
-		if i.Name == "calyptia-core-manager-role" {
-			operatorIncomplete.Errors = append(operatorIncomplete.Errors, fmt.Errorf("ClusterRole: %s", i.Name))
-		}
-		if i.Name == "calyptia-core-metrics-reader" {
-			operatorIncomplete.Errors = append(operatorIncomplete.Errors, fmt.Errorf("ClusterRole: %s", i.Name))
-		}
-		if i.Name == "calyptia-core-pod-role" {
-			operatorIncomplete.Errors = append(operatorIncomplete.Errors, fmt.Errorf("ClusterRole: %s", i.Name))
-		}
-		if i.Name == "calyptia-core-proxy-role" {
-			operatorIncomplete.Errors = append(operatorIncomplete.Errors, fmt.Errorf("ClusterRole: %s", i.Name))
-		}
-	}
-
-	crbList := &rbacv1.ClusterRoleBindingList{}
-	if err := k8sc.List(context.Background(), crbList, &k8sclient.ListOptions{}); err != nil {
-		panic(err)
-	}
-
-	for _, i := range crbList.Items {
-		if i.Name == "calyptia-core-manager-rolebinding" {
-			operatorIncomplete.Errors = append(operatorIncomplete.Errors, fmt.Errorf("ClusterRoleBinding: %s", i.Name))
-		}
-		if i.Name == "calyptia-core-proxy-rolebinding" {
-			operatorIncomplete.Errors = append(operatorIncomplete.Errors, fmt.Errorf("ClusterRoleBinding: %s", i.Name))
-		}
-	}
-
-	saList := &corev1.ServiceAccountList{}
-	if err := k8sc.List(context.Background(), saList, &k8sclient.ListOptions{}); err != nil {
-		panic(err)
-	}
-
-	for _, i := range saList.Items {
-		if i.Name == operatorDeploymentName {
-			operatorIncomplete.Errors = append(operatorIncomplete.Errors, fmt.Errorf("ServiceAccount: %s/%s", i.Namespace, i.Name))
-		}
+	if !status.Installed {
+		return false, nil
 	}
 
-	if len(operatorIncomplete.Errors) > 0 {
-		return true, &operatorIncomplete
-	}
-	return false, nil
+	return true, &OperatorIncompleteError{Status: status}
 }
 
-const operatorDeploymentName = "calyptia-core-controller-manager"
-
 type OperatorIncompleteError struct {
-	Errors []error
+	Status OperatorStatus
 }
 
 func (o *OperatorIncompleteError) Error() string {
 	errs := []string{}
-	for _, err := range o.Errors {
-		errs = append(errs, err.Error())
+	for _, c := range o.Status.Components {
+		errs = append(errs, c.String())
 	}
 	return strings.Join(errs, "\n")
 }
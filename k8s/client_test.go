@@ -7,13 +7,18 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 
+	cloud "github.com/calyptia/api/types"
 	"github.com/calyptia/cli/cmd/utils"
 )
 
@@ -134,6 +139,11 @@ func TestUpdateOperatorDeploymentByLabel(t *testing.T) {
 				},
 			},
 		},
+		Status: appsv1.DeploymentStatus{
+			Replicas:          1,
+			UpdatedReplicas:   1,
+			AvailableReplicas: 1,
+		},
 	}
 
 	pod := corev1.Pod{
@@ -184,7 +194,7 @@ func TestUpdateOperatorDeploymentByLabel(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			label := fmt.Sprintf("%s=%s,%s=%s,%s=%s", LabelComponent, tc.manager, LabelCreatedBy, "operator", LabelInstance, "controller-manager")
 
-			if err := tc.client.UpdateOperatorDeploymentByLabel(context.TODO(), label, fmt.Sprintf("%s:%s", utils.DefaultCoreOperatorDockerImage, "1234"), false); err != nil && !tc.expectErr {
+			if err := tc.client.UpdateOperatorDeploymentByLabel(context.TODO(), label, fmt.Sprintf("%s:%s", utils.DefaultCoreOperatorDockerImage, "1234"), false, time.Second); err != nil && !tc.expectErr {
 				t.Errorf("failed to find deployment by label %s", err)
 			}
 		})
@@ -228,6 +238,11 @@ func TestUpdateSyncDeploymentByLabel(t *testing.T) {
 				},
 			},
 		},
+		Status: appsv1.DeploymentStatus{
+			Replicas:          1,
+			UpdatedReplicas:   1,
+			AvailableReplicas: 1,
+		},
 	}
 
 	pod := corev1.Pod{
@@ -287,9 +302,109 @@ func TestUpdateSyncDeploymentByLabel(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			label := fmt.Sprintf("%s=%s,%s=%s,%s=%s", LabelComponent, "operator", LabelCreatedBy, "calyptia-cli", LabelAggregatorID, tc.aggID)
 
-			if err := tc.client.UpdateSyncDeploymentByLabel(context.TODO(), label, fmt.Sprintf("%s:%s", utils.DefaultCoreOperatorDockerImage, "1234"), "true", false); err != nil && !tc.expectErr {
+			if err := tc.client.UpdateSyncDeploymentByLabel(context.TODO(), label, fmt.Sprintf("%s:%s", utils.DefaultCoreOperatorDockerImage, "1234"), "true", false, time.Second); err != nil && !tc.expectErr {
 				t.Errorf("failed to find deployment by label %s", err)
 			}
 		})
 	}
 }
+
+func TestCreateDeploymentCoreContainer(t *testing.T) {
+	client := &Client{
+		Interface:  fake.NewSimpleClientset(),
+		Namespace:  "default",
+		LabelsFunc: func() map[string]string { return nil },
+		CoreResources: apiv1.ResourceRequirements{
+			Requests: apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("100m")},
+		},
+		CoreEnv: map[string]string{"HTTPS_PROXY": "http://proxy:3128", coreTLSVerifyEnvVar: "false"},
+	}
+
+	deploy, err := client.CreateDeployment(context.TODO(), "image", cloud.CreatedCoreInstance{Name: "test", EnvironmentName: "default"},
+		"https://cloud", &apiv1.ServiceAccount{}, true, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	container := deploy.Spec.Template.Spec.Containers[0]
+	if got := container.Resources.Requests.Cpu().String(); got != "100m" {
+		t.Errorf("expected cpu request 100m, got %s", got)
+	}
+
+	env := map[string]string{}
+	for _, e := range container.Env {
+		env[e.Name] = e.Value
+	}
+
+	if env["HTTPS_PROXY"] != "http://proxy:3128" || env[coreTLSVerifyEnvVar] != "false" {
+		t.Errorf("expected extra env vars to be set and override the generated ones, got %v", env)
+	}
+}
+
+func TestResolveNamespace(t *testing.T) {
+	kubeconfig := `
+apiVersion: v1
+kind: Config
+current-context: test-context
+clusters:
+- name: test-cluster
+  cluster:
+    server: https://cluster:6443
+contexts:
+- name: test-context
+  context:
+    cluster: test-cluster
+    namespace: test-namespace
+- name: other-context
+  context:
+    cluster: test-cluster
+`
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(kubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		name      string
+		overrides clientcmd.ConfigOverrides
+		want      string
+	}{
+		{name: "context", want: "test-namespace"},
+		{name: "flag", overrides: clientcmd.ConfigOverrides{Context: clientcmdapi.Context{Namespace: "flag-namespace"}}, want: "flag-namespace"},
+		{name: "default", overrides: clientcmd.ConfigOverrides{CurrentContext: "other-context"}, want: apiv1.NamespaceDefault},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(&clientcmd.ClientConfigLoadingRules{ExplicitPath: path}, &tc.overrides)
+			got, err := ResolveNamespace(kubeConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tc.want {
+				t.Errorf("want namespace %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestClient_EnsureOwnNamespace(t *testing.T) {
+	ctx := context.Background()
+	client := &Client{Interface: fake.NewSimpleClientset(), Namespace: "missing"}
+
+	if err := client.EnsureOwnNamespace(ctx, false); err == nil {
+		t.Error("expected missing namespace to fail without create")
+	}
+
+	if err := client.EnsureOwnNamespace(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.GetNamespace(ctx, "missing"); err != nil {
+		t.Errorf("expected namespace to be created: %v", err)
+	}
+
+	if err := client.EnsureOwnNamespace(ctx, false); err != nil {
+		t.Errorf("expected existing namespace to pass: %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+// ClusterObjectState tells whether a cluster object registered
+// on Calyptia Cloud is present in the live cluster.
+type ClusterObjectState string
+
+const (
+	ClusterObjectStateFound   ClusterObjectState = "found"
+	ClusterObjectStateStale   ClusterObjectState = "stale"
+	ClusterObjectStateUnknown ClusterObjectState = "unknown"
+)
+
+// VerifyClusterObject checks the given cluster object against the live cluster.
+// Objects of a kind that cannot be checked are reported with an unknown state.
+func (client *Client) VerifyClusterObject(ctx context.Context, obj cloud.ClusterObject) (ClusterObjectState, error) {
+	var err error
+	switch obj.Kind {
+	case cloud.ClusterObjectKindNamespace:
+		_, err = client.CoreV1().Namespaces().Get(ctx, obj.Name, metav1.GetOptions{})
+	default:
+		return ClusterObjectStateUnknown, nil
+	}
+
+	if apiErrors.IsNotFound(err) {
+		return ClusterObjectStateStale, nil
+	}
+
+	if err != nil {
+		return ClusterObjectStateUnknown, fmt.Errorf("could not get %s %q: %w", obj.Kind, obj.Name, err)
+	}
+
+	return ClusterObjectStateFound, nil
+}
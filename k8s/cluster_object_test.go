@@ -0,0 +1,38 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+func TestVerifyClusterObject(t *testing.T) {
+	client := &Client{Interface: fake.NewSimpleClientset(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "logging"},
+	})}
+
+	tt := []struct {
+		obj  cloud.ClusterObject
+		want ClusterObjectState
+	}{
+		{obj: cloud.ClusterObject{Name: "logging", Kind: cloud.ClusterObjectKindNamespace}, want: ClusterObjectStateFound},
+		{obj: cloud.ClusterObject{Name: "gone", Kind: cloud.ClusterObjectKindNamespace}, want: ClusterObjectStateStale},
+		{obj: cloud.ClusterObject{Name: "logging", Kind: "configmap"}, want: ClusterObjectStateUnknown},
+	}
+
+	for _, tc := range tt {
+		got, err := client.VerifyClusterObject(context.TODO(), tc.obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != tc.want {
+			t.Errorf("%s %q: want %q, got %q", tc.obj.Kind, tc.obj.Name, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// ConflictPolicy defines what to do when an object about to be created
+// already exists in the cluster, typically left behind by a failed prior run.
+type ConflictPolicy string
+
+const (
+	// ConflictPolicyFail returns the AlreadyExists error as is.
+	ConflictPolicyFail ConflictPolicy = ""
+	// ConflictPolicyRecreate deletes the existing object and creates it again.
+	ConflictPolicyRecreate ConflictPolicy = "recreate"
+	// ConflictPolicyAdopt updates the existing object into the desired state.
+	ConflictPolicyAdopt ConflictPolicy = "adopt"
+)
+
+// ErrNotManaged is returned when a conflicting object was not created by calyptia
+// and thus cannot be recreated nor adopted.
+var ErrNotManaged = fmt.Errorf("object is not managed by calyptia")
+
+const recreateTimeout = time.Minute
+
+// IsManaged reports whether the given labels identify an object created by calyptia.
+func IsManaged(labels map[string]string) bool {
+	return labels[LabelManagedBy] == "calyptia-cli" || labels[LabelPartOf] == "calyptia"
+}
+
+type objectOps[T metav1.Object] struct {
+	create func(context.Context, T) (T, error)
+	get    func(context.Context, string) (T, error)
+	update func(context.Context, T) (T, error)
+	delete func(context.Context, string) error
+}
+
+// createWithPolicy creates the desired object and resolves an AlreadyExists
+// conflict according to the client conflict policy.
+func createWithPolicy[T metav1.Object](ctx context.Context, policy ConflictPolicy, desired T, ops objectOps[T]) (T, error) {
+	created, err := ops.create(ctx, desired)
+	if err == nil || !apiErrors.IsAlreadyExists(err) || policy == ConflictPolicyFail {
+		return created, err
+	}
+
+	existing, err := ops.get(ctx, desired.GetName())
+	if err != nil {
+		return created, fmt.Errorf("get existing %q: %w", desired.GetName(), err)
+	}
+
+	if !IsManaged(existing.GetLabels()) {
+		return created, fmt.Errorf("%q: %w", desired.GetName(), ErrNotManaged)
+	}
+
+	switch policy {
+	case ConflictPolicyAdopt:
+		desired.SetResourceVersion(existing.GetResourceVersion())
+		updated, err := ops.update(ctx, desired)
+		if err != nil {
+			return updated, fmt.Errorf("adopt %q: %w", desired.GetName(), err)
+		}
+		return updated, nil
+	case ConflictPolicyRecreate:
+		if err := ops.delete(ctx, desired.GetName()); err != nil && !apiErrors.IsNotFound(err) {
+			return created, fmt.Errorf("delete existing %q: %w", desired.GetName(), err)
+		}
+
+		err := wait.PollUntilContextTimeout(ctx, time.Second, recreateTimeout, true, func(ctx context.Context) (bool, error) {
+			_, err := ops.get(ctx, desired.GetName())
+			if apiErrors.IsNotFound(err) {
+				return true, nil
+			}
+			return false, err
+		})
+		if err != nil {
+			return created, fmt.Errorf("wait for %q deletion: %w", desired.GetName(), err)
+		}
+
+		created, err = ops.create(ctx, desired)
+		if err != nil {
+			return created, fmt.Errorf("recreate %q: %w", desired.GetName(), err)
+		}
+		return created, nil
+	default:
+		return created, fmt.Errorf("unknown conflict policy %q", policy)
+	}
+}
+
+func (client *Client) secretOps() objectOps[*apiv1.Secret] {
+	secrets := client.CoreV1().Secrets(client.Namespace)
+	return objectOps[*apiv1.Secret]{
+		create: func(ctx context.Context, obj *apiv1.Secret) (*apiv1.Secret, error) {
+			return secrets.Create(ctx, obj, metav1.CreateOptions{})
+		},
+		get: func(ctx context.Context, name string) (*apiv1.Secret, error) {
+			return secrets.Get(ctx, name, metav1.GetOptions{})
+		},
+		update: func(ctx context.Context, obj *apiv1.Secret) (*apiv1.Secret, error) {
+			return secrets.Update(ctx, obj, metav1.UpdateOptions{})
+		},
+		delete: func(ctx context.Context, name string) error {
+			return secrets.Delete(ctx, name, metav1.DeleteOptions{})
+		},
+	}
+}
+
+func (client *Client) serviceAccountOps() objectOps[*apiv1.ServiceAccount] {
+	serviceAccounts := client.CoreV1().ServiceAccounts(client.Namespace)
+	return objectOps[*apiv1.ServiceAccount]{
+		create: func(ctx context.Context, obj *apiv1.ServiceAccount) (*apiv1.ServiceAccount, error) {
+			return serviceAccounts.Create(ctx, obj, metav1.CreateOptions{})
+		},
+		get: func(ctx context.Context, name string) (*apiv1.ServiceAccount, error) {
+			return serviceAccounts.Get(ctx, name, metav1.GetOptions{})
+		},
+		update: func(ctx context.Context, obj *apiv1.ServiceAccount) (*apiv1.ServiceAccount, error) {
+			return serviceAccounts.Update(ctx, obj, metav1.UpdateOptions{})
+		},
+		delete: func(ctx context.Context, name string) error {
+			return serviceAccounts.Delete(ctx, name, metav1.DeleteOptions{})
+		},
+	}
+}
+
+func (client *Client) clusterRoleOps() objectOps[*rbacv1.ClusterRole] {
+	clusterRoles := client.RbacV1().ClusterRoles()
+	return objectOps[*rbacv1.ClusterRole]{
+		create: func(ctx context.Context, obj *rbacv1.ClusterRole) (*rbacv1.ClusterRole, error) {
+			return clusterRoles.Create(ctx, obj, metav1.CreateOptions{})
+		},
+		get: func(ctx context.Context, name string) (*rbacv1.ClusterRole, error) {
+			return clusterRoles.Get(ctx, name, metav1.GetOptions{})
+		},
+		update: func(ctx context.Context, obj *rbacv1.ClusterRole) (*rbacv1.ClusterRole, error) {
+			return clusterRoles.Update(ctx, obj, metav1.UpdateOptions{})
+		},
+		delete: func(ctx context.Context, name string) error {
+			return clusterRoles.Delete(ctx, name, metav1.DeleteOptions{})
+		},
+	}
+}
+
+func (client *Client) clusterRoleBindingOps() objectOps[*rbacv1.ClusterRoleBinding] {
+	bindings := client.RbacV1().ClusterRoleBindings()
+	return objectOps[*rbacv1.ClusterRoleBinding]{
+		create: func(ctx context.Context, obj *rbacv1.ClusterRoleBinding) (*rbacv1.ClusterRoleBinding, error) {
+			return bindings.Create(ctx, obj, metav1.CreateOptions{})
+		},
+		get: func(ctx context.Context, name string) (*rbacv1.ClusterRoleBinding, error) {
+			return bindings.Get(ctx, name, metav1.GetOptions{})
+		},
+		update: func(ctx context.Context, obj *rbacv1.ClusterRoleBinding) (*rbacv1.ClusterRoleBinding, error) {
+			return bindings.Update(ctx, obj, metav1.UpdateOptions{})
+		},
+		delete: func(ctx context.Context, name string) error {
+			return bindings.Delete(ctx, name, metav1.DeleteOptions{})
+		},
+	}
+}
+
+func (client *Client) deploymentOps() objectOps[*appsv1.Deployment] {
+	deployments := client.AppsV1().Deployments(client.Namespace)
+	return objectOps[*appsv1.Deployment]{
+		create: func(ctx context.Context, obj *appsv1.Deployment) (*appsv1.Deployment, error) {
+			return deployments.Create(ctx, obj, metav1.CreateOptions{})
+		},
+		get: func(ctx context.Context, name string) (*appsv1.Deployment, error) {
+			return deployments.Get(ctx, name, metav1.GetOptions{})
+		},
+		update: func(ctx context.Context, obj *appsv1.Deployment) (*appsv1.Deployment, error) {
+			return deployments.Update(ctx, obj, metav1.UpdateOptions{})
+		},
+		delete: func(ctx context.Context, name string) error {
+			return deployments.Delete(ctx, name, metav1.DeleteOptions{})
+		},
+	}
+}
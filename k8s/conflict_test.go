@@ -0,0 +1,101 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+func TestCreateSecretConflictPolicy(t *testing.T) {
+	agg := cloud.CreatedCoreInstance{
+		Name:            "test",
+		EnvironmentName: "default",
+		PrivateRSAKey:   []byte("new-key"),
+	}
+	name := FormatResourceName(agg.Name, agg.EnvironmentName, string(secretObjectType))
+
+	existing := func(labels map[string]string) *apiv1.Secret {
+		return &apiv1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    labels,
+			},
+			Data: map[string][]byte{
+				name: []byte("old-key"),
+			},
+		}
+	}
+	managed := map[string]string{LabelManagedBy: "calyptia-cli"}
+
+	tt := []struct {
+		name      string
+		policy    ConflictPolicy
+		labels    map[string]string
+		expectErr func(error) bool
+		expectKey string
+	}{
+		{
+			name:      "fail",
+			policy:    ConflictPolicyFail,
+			labels:    managed,
+			expectErr: apiErrors.IsAlreadyExists,
+		},
+		{
+			name:      "recreate",
+			policy:    ConflictPolicyRecreate,
+			labels:    managed,
+			expectKey: "new-key",
+		},
+		{
+			name:      "adopt",
+			policy:    ConflictPolicyAdopt,
+			labels:    managed,
+			expectKey: "new-key",
+		},
+		{
+			name:      "not managed",
+			policy:    ConflictPolicyRecreate,
+			expectErr: func(err error) bool { return errors.Is(err, ErrNotManaged) },
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &Client{
+				Interface:      fake.NewSimpleClientset(existing(tc.labels)),
+				Namespace:      "default",
+				ConflictPolicy: tc.policy,
+				LabelsFunc: func() map[string]string {
+					return managed
+				},
+			}
+
+			_, err := client.CreateSecret(context.TODO(), agg, false)
+			if tc.expectErr != nil {
+				if !tc.expectErr(err) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := client.CoreV1().Secrets("default").Get(context.TODO(), name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got.Data[name]) != tc.expectKey {
+				t.Errorf("expected secret data %q, got %q", tc.expectKey, got.Data[name])
+			}
+		})
+	}
+}
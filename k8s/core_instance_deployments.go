@@ -0,0 +1,52 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CoreInstanceDeployment is a deployment of a core instance found in the cluster.
+type CoreInstanceDeployment struct {
+	CoreInstanceID string `json:"coreInstanceID" yaml:"coreInstanceID"`
+	Name           string `json:"name" yaml:"name"`
+	Namespace      string `json:"namespace" yaml:"namespace"`
+	Replicas       int32  `json:"replicas" yaml:"replicas"`
+	ReadyReplicas  int32  `json:"readyReplicas" yaml:"readyReplicas"`
+}
+
+// CoreInstanceDeployments lists the deployments labeled with a core instance ID
+// across all namespaces, leaving out those of its pipelines.
+func (client *Client) CoreInstanceDeployments(ctx context.Context) ([]CoreInstanceDeployment, error) {
+	selector := fmt.Sprintf("%s,!%s", LabelAggregatorID, LabelPipelineID)
+	deployments, err := client.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("could not list core instance deployments: %w", err)
+	}
+
+	out := make([]CoreInstanceDeployment, 0, len(deployments.Items))
+	for _, d := range deployments.Items {
+		replicas := int32(1)
+		if d.Spec.Replicas != nil {
+			replicas = *d.Spec.Replicas
+		}
+		out = append(out, CoreInstanceDeployment{
+			CoreInstanceID: d.Labels[LabelAggregatorID],
+			Name:           d.Name,
+			Namespace:      d.Namespace,
+			Replicas:       replicas,
+			ReadyReplicas:  d.Status.ReadyReplicas,
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Namespace != out[j].Namespace {
+			return out[i].Namespace < out[j].Namespace
+		}
+		return out[i].Name < out[j].Name
+	})
+
+	return out, nil
+}
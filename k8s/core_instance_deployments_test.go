@@ -0,0 +1,40 @@
+package k8s
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestClient_CoreInstanceDeployments(t *testing.T) {
+	deployment := func(namespace, name string, labels map[string]string, ready int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: ready},
+		}
+	}
+
+	client := &Client{Interface: fake.NewSimpleClientset(
+		deployment("b", "core", map[string]string{LabelAggregatorID: "core-2"}, 0),
+		deployment("a", "core", map[string]string{LabelAggregatorID: "core-1"}, 1),
+		deployment("a", "pipeline", map[string]string{LabelAggregatorID: "core-1", LabelPipelineID: "pipeline-1"}, 1),
+		deployment("a", "other", nil, 1),
+	)}
+
+	got, err := client.CoreInstanceDeployments(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []CoreInstanceDeployment{
+		{CoreInstanceID: "core-1", Name: "core", Namespace: "a", Replicas: 1, ReadyReplicas: 1},
+		{CoreInstanceID: "core-2", Name: "core", Namespace: "b", Replicas: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
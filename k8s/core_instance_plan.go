@@ -0,0 +1,126 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const rollBackTimeout = time.Minute
+
+// CoreInstanceStep is a step creating the kubernetes objects of a core instance.
+// Apply returns the object it created, if any, to be deleted when a later step fails.
+type CoreInstanceStep struct {
+	Name   string
+	ErrMsg string
+	Apply  func(ctx context.Context) (metav1.Object, error)
+}
+
+// CoreInstancePlan are the steps creating the kubernetes objects of a core instance.
+type CoreInstancePlan struct {
+	Steps []CoreInstanceStep
+	// DryRun steps create nothing, so there is nothing to roll back.
+	DryRun bool
+	// NoRollback keeps the objects created before a failing step,
+	// ie: to inspect them.
+	NoRollback bool
+	// Run runs each step, ie: to report its progress or retry it.
+	// Steps are called directly when nil.
+	Run func(ctx context.Context, name string, fn func(ctx context.Context) error) error
+}
+
+// Applied are the objects created by a CoreInstancePlan, in creation order.
+type Applied []ResourceRollBack
+
+// ApplyCoreInstanceResources runs the plan steps in order, recording the objects
+// they create. When a step fails, the recorded objects are deleted in reverse
+// order, unless the plan has NoRollback, and the objects left in the cluster
+// are returned along with the error.
+//
+// Objects are not rolled back with ConflictPolicyAdopt, since the adopted ones
+// existed before and cannot be told apart from the created ones.
+func (client *Client) ApplyCoreInstanceResources(ctx context.Context, plan CoreInstancePlan) (Applied, error) {
+	run := plan.Run
+	if run == nil {
+		run = func(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		}
+	}
+
+	var applied Applied
+	for _, step := range plan.Steps {
+		var obj metav1.Object
+		err := run(ctx, step.Name, func(ctx context.Context) (err error) {
+			obj, err = step.Apply(ctx)
+			return err
+		})
+		if err != nil {
+			err = fmt.Errorf("%s: %w", step.ErrMsg, err)
+			if plan.NoRollback || client.ConflictPolicy == ConflictPolicyAdopt || len(applied) == 0 {
+				return applied, err
+			}
+
+			return client.rollBack(ctx, applied, err)
+		}
+
+		if plan.DryRun || isNilObject(obj) {
+			continue
+		}
+
+		r, err := NewResourceRollBack(obj)
+		if err != nil {
+			return applied, err
+		}
+
+		applied = append(applied, r)
+	}
+
+	return applied, nil
+}
+
+// rollBack deletes the applied objects, newest first, after the given step error.
+// It still runs when the command got interrupted.
+func (client *Client) rollBack(ctx context.Context, applied Applied, stepErr error) (Applied, error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollBackTimeout)
+	defer cancel()
+
+	reversed := make([]ResourceRollBack, 0, len(applied))
+	for i := len(applied) - 1; i >= 0; i-- {
+		reversed = append(reversed, applied[i])
+	}
+
+	deleted, err := client.DeleteResources(ctx, reversed)
+	if err != nil {
+		var left Applied
+		for _, r := range applied {
+			if !containsResource(deleted, r) {
+				left = append(left, r)
+			}
+		}
+		return left, fmt.Errorf("%w; could not roll back: %v", stepErr, err)
+	}
+
+	return nil, fmt.Errorf("%w; rolled back %d kubernetes objects", stepErr, len(deleted))
+}
+
+func containsResource(resources []ResourceRollBack, r ResourceRollBack) bool {
+	for _, o := range resources {
+		if o == r {
+			return true
+		}
+	}
+	return false
+}
+
+// isNilObject reports whether the step returned no object,
+// including a nil pointer of a concrete type.
+func isNilObject(obj metav1.Object) bool {
+	if obj == nil {
+		return true
+	}
+	v := reflect.ValueOf(obj)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
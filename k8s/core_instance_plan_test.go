@@ -0,0 +1,149 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testCoreInstancePlan(client *Client, failErr error) CoreInstancePlan {
+	return CoreInstancePlan{Steps: []CoreInstanceStep{
+		{Name: "create secret", ErrMsg: "could not create secret", Apply: func(ctx context.Context) (metav1.Object, error) {
+			return client.CoreV1().Secrets(client.Namespace).Create(ctx, &apiv1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret"}}, metav1.CreateOptions{})
+		}},
+		{Name: "create cluster role", ErrMsg: "could not create cluster role", Apply: func(ctx context.Context) (metav1.Object, error) {
+			return client.RbacV1().ClusterRoles().Create(ctx, &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "role"}}, metav1.CreateOptions{})
+		}},
+		{Name: "validate", ErrMsg: "could not validate", Apply: func(context.Context) (metav1.Object, error) {
+			var sa *apiv1.ServiceAccount
+			return sa, nil
+		}},
+		{Name: "create deployment", ErrMsg: "could not create deployment", Apply: func(context.Context) (metav1.Object, error) {
+			return nil, failErr
+		}},
+	}}
+}
+
+func TestClient_ApplyCoreInstanceResources(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ok", func(t *testing.T) {
+		client := &Client{Interface: fake.NewSimpleClientset(), Namespace: "default"}
+		applied, err := client.ApplyCoreInstanceResources(ctx, testCoreInstancePlan(client, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(applied) != 2 || applied[0].String() != "secrets/secret" || applied[1].String() != "clusterroles/role" {
+			t.Errorf("unexpected applied %v", applied)
+		}
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		client := &Client{Interface: fake.NewSimpleClientset(), Namespace: "default"}
+		applied, err := client.ApplyCoreInstanceResources(ctx, testCoreInstancePlan(client, errors.New("boom")))
+		if err == nil || !strings.Contains(err.Error(), "could not create deployment: boom") || !strings.Contains(err.Error(), "rolled back 2 kubernetes objects") {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		if len(applied) != 0 {
+			t.Errorf("expected nothing left behind, got %v", applied)
+		}
+
+		if _, err := client.CoreV1().Secrets("default").Get(ctx, "secret", metav1.GetOptions{}); !apiErrors.IsNotFound(err) {
+			t.Errorf("expected secret to be deleted, got %v", err)
+		}
+
+		if _, err := client.RbacV1().ClusterRoles().Get(ctx, "role", metav1.GetOptions{}); !apiErrors.IsNotFound(err) {
+			t.Errorf("expected cluster role to be deleted, got %v", err)
+		}
+	})
+
+	t.Run("no rollback", func(t *testing.T) {
+		client := &Client{Interface: fake.NewSimpleClientset(), Namespace: "default"}
+		plan := testCoreInstancePlan(client, errors.New("boom"))
+		plan.NoRollback = true
+		applied, err := client.ApplyCoreInstanceResources(ctx, plan)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+
+		if len(applied) != 2 {
+			t.Errorf("expected 2 objects left behind, got %v", applied)
+		}
+
+		if _, err := client.CoreV1().Secrets("default").Get(ctx, "secret", metav1.GetOptions{}); err != nil {
+			t.Errorf("expected secret to be kept, got %v", err)
+		}
+	})
+
+	t.Run("adopt", func(t *testing.T) {
+		client := &Client{Interface: fake.NewSimpleClientset(), Namespace: "default", ConflictPolicy: ConflictPolicyAdopt}
+		applied, err := client.ApplyCoreInstanceResources(ctx, testCoreInstancePlan(client, errors.New("boom")))
+		if err == nil {
+			t.Fatal("expected error")
+		}
+
+		if len(applied) != 2 {
+			t.Errorf("expected adopted objects to be kept, got %v", applied)
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		client := &Client{Interface: fake.NewSimpleClientset(), Namespace: "default"}
+		plan := testCoreInstancePlan(client, errors.New("boom"))
+		plan.DryRun = true
+		applied, err := client.ApplyCoreInstanceResources(ctx, plan)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+
+		if len(applied) != 0 {
+			t.Errorf("expected nothing recorded on dry run, got %v", applied)
+		}
+	})
+}
+
+func TestClient_DeleteResources(t *testing.T) {
+	ctx := context.Background()
+	client := &Client{
+		Interface: fake.NewSimpleClientset(
+			&apiv1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "default"}},
+			&rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "binding"}},
+		),
+		Namespace: "default",
+	}
+
+	var resources []ResourceRollBack
+	for _, obj := range []metav1.Object{
+		&apiv1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret"}},
+		&rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "binding"}},
+		&apiv1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "already-gone"}},
+	} {
+		r, err := NewResourceRollBack(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resources = append(resources, r)
+	}
+
+	deleted, err := client.DeleteResources(ctx, resources)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deleted) != 3 {
+		t.Errorf("expected 3 deleted resources, got %v", deleted)
+	}
+
+	if _, err := client.RbacV1().ClusterRoleBindings().Get(ctx, "binding", metav1.GetOptions{}); !apiErrors.IsNotFound(err) {
+		t.Errorf("expected cluster role binding to be deleted, got %v", err)
+	}
+}
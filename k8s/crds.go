@@ -0,0 +1,284 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const crdKind = "CustomResourceDefinition"
+
+var crdResource = schema.GroupVersionResource{
+	Group:    "apiextensions.k8s.io",
+	Version:  "v1",
+	Resource: "customresourcedefinitions",
+}
+
+// CRD summarizes a core operator custom resource definition.
+type CRD struct {
+	Name           string    `json:"name" yaml:"name"`
+	Kind           string    `json:"kind" yaml:"kind"`
+	StorageVersion string    `json:"storageVersion" yaml:"storageVersion"`
+	ServedVersions []string  `json:"servedVersions" yaml:"servedVersions"`
+	StoredVersions []string  `json:"storedVersions,omitempty" yaml:"storedVersions,omitempty"`
+	Established    bool      `json:"established" yaml:"established"`
+	CreatedAt      time.Time `json:"createdAt,omitempty" yaml:"createdAt,omitempty"`
+}
+
+// CRDUpgrade is the outcome of checking a custom resource definition
+// from a manifest against the one installed in the cluster.
+type CRDUpgrade struct {
+	Name     string   `json:"name" yaml:"name"`
+	Current  *CRD     `json:"current,omitempty" yaml:"current,omitempty"`
+	Desired  CRD      `json:"desired" yaml:"desired"`
+	Problems []string `json:"problems,omitempty" yaml:"problems,omitempty"`
+	Warnings []string `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+}
+
+// Safe tells whether the upgrade would not break any stored or served version.
+func (u CRDUpgrade) Safe() bool {
+	return len(u.Problems) == 0
+}
+
+// CRDs lists the core operator custom resource definitions installed in the cluster.
+func (client *Client) CRDs(ctx context.Context) ([]CRD, error) {
+	dyn, _, err := client.dynamicClient()
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := listCRDs(ctx, dyn)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]CRD, 0, len(objects))
+	for _, obj := range objects {
+		out = append(out, crdFromObject(obj))
+	}
+	return out, nil
+}
+
+// CheckCRDUpgrades compares the core operator custom resource definitions
+// found in the given manifest against the installed ones. Only the CRDs with
+// the given names are checked, or all of them if none are given.
+func (client *Client) CheckCRDUpgrades(ctx context.Context, manifest string, names ...string) ([]CRDUpgrade, error) {
+	dyn, _, err := client.dynamicClient()
+	if err != nil {
+		return nil, err
+	}
+
+	desired, err := manifestCRDs(manifest, names)
+	if err != nil {
+		return nil, err
+	}
+
+	installed, err := listCRDs(ctx, dyn)
+	if err != nil {
+		return nil, err
+	}
+
+	return checkCRDUpgrades(installed, desired), nil
+}
+
+// UpgradeCRDs applies the custom resource definitions of the given upgrades
+// from the manifest. Unsafe upgrades are refused unless force is set.
+func (client *Client) UpgradeCRDs(ctx context.Context, manifest string, upgrades []CRDUpgrade, force bool) error {
+	var names []string
+	for _, u := range upgrades {
+		if !u.Safe() && !force {
+			return fmt.Errorf("refusing unsafe upgrade of %s: %s", u.Name, u.Problems[0])
+		}
+		names = append(names, u.Name)
+	}
+
+	desired, err := manifestCRDs(manifest, names)
+	if err != nil {
+		return err
+	}
+
+	dyn, mapper, err := client.dynamicClient()
+	if err != nil {
+		return err
+	}
+
+	return applyObjects(ctx, dyn, mapper, desired)
+}
+
+func listCRDs(ctx context.Context, dyn dynamic.Interface) ([]*unstructured.Unstructured, error) {
+	list, err := dyn.Resource(crdResource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("could not list custom resource definitions: %w", err)
+	}
+
+	var out []*unstructured.Unstructured
+	for i := range list.Items {
+		obj := &list.Items[i]
+		group, _, _ := unstructured.NestedString(obj.Object, "spec", "group")
+		if group == operatorAPIGroup {
+			out = append(out, obj)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].GetName() < out[j].GetName()
+	})
+	return out, nil
+}
+
+// manifestCRDs returns the core operator custom resource definitions
+// defined in the manifest, filtered by name if any given.
+func manifestCRDs(manifest string, names []string) ([]*unstructured.Unstructured, error) {
+	objects, err := DecodeManifest(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*unstructured.Unstructured
+	found := map[string]bool{}
+	for _, obj := range objects {
+		if obj.GetKind() != crdKind {
+			continue
+		}
+
+		group, _, _ := unstructured.NestedString(obj.Object, "spec", "group")
+		if group != operatorAPIGroup {
+			continue
+		}
+
+		if len(names) != 0 && !contains(names, obj.GetName()) {
+			continue
+		}
+
+		found[obj.GetName()] = true
+		out = append(out, obj)
+	}
+
+	for _, name := range names {
+		if !found[name] {
+			return nil, fmt.Errorf("custom resource definition %q not found in manifest", name)
+		}
+	}
+
+	if len(out) == 0 {
+		return nil, errors.New("no custom resource definitions found in manifest")
+	}
+
+	return out, nil
+}
+
+func crdFromObject(obj *unstructured.Unstructured) CRD {
+	crd := CRD{
+		Name:      obj.GetName(),
+		CreatedAt: obj.GetCreationTimestamp().Time,
+	}
+	crd.Kind, _, _ = unstructured.NestedString(obj.Object, "spec", "names", "kind")
+	crd.StoredVersions, _, _ = unstructured.NestedStringSlice(obj.Object, "status", "storedVersions")
+
+	versions, _, _ := unstructured.NestedSlice(obj.Object, "spec", "versions")
+	for _, v := range versions {
+		version, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		name, _, _ := unstructured.NestedString(version, "name")
+		if served, _, _ := unstructured.NestedBool(version, "served"); served {
+			crd.ServedVersions = append(crd.ServedVersions, name)
+		}
+		if storage, _, _ := unstructured.NestedBool(version, "storage"); storage {
+			crd.StorageVersion = name
+		}
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		if condition["type"] == "Established" && condition["status"] == "True" {
+			crd.Established = true
+		}
+	}
+
+	return crd
+}
+
+// checkCRDUpgrades runs the conversion-safety checks of each desired
+// custom resource definition against the installed one:
+//   - every version stored in etcd must still be defined,
+//     otherwise existing objects become unreadable.
+//   - every served version must still be served,
+//     otherwise clients using it break.
+//   - exactly one version must be the storage version.
+//
+// Changing the storage version without a conversion webhook is allowed
+// but reported as a warning.
+func checkCRDUpgrades(installed, desired []*unstructured.Unstructured) []CRDUpgrade {
+	current := map[string]CRD{}
+	for _, obj := range installed {
+		current[obj.GetName()] = crdFromObject(obj)
+	}
+
+	var out []CRDUpgrade
+	for _, obj := range desired {
+		d := crdFromObject(obj)
+		u := CRDUpgrade{Name: d.Name, Desired: d}
+
+		var defined []string
+		storageVersions := 0
+		versions, _, _ := unstructured.NestedSlice(obj.Object, "spec", "versions")
+		for _, v := range versions {
+			version, ok := v.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			name, _, _ := unstructured.NestedString(version, "name")
+			defined = append(defined, name)
+			if storage, _, _ := unstructured.NestedBool(version, "storage"); storage {
+				storageVersions++
+			}
+		}
+
+		if storageVersions != 1 {
+			u.Problems = append(u.Problems, fmt.Sprintf("expected exactly one storage version, got %d", storageVersions))
+		}
+
+		c, ok := current[d.Name]
+		if !ok {
+			out = append(out, u)
+			continue
+		}
+
+		u.Current = &c
+		for _, v := range c.StoredVersions {
+			if !contains(defined, v) {
+				u.Problems = append(u.Problems, fmt.Sprintf("version %s is still stored but would be removed", v))
+			}
+		}
+
+		for _, v := range c.ServedVersions {
+			if !contains(d.ServedVersions, v) {
+				u.Problems = append(u.Problems, fmt.Sprintf("version %s is served but would no longer be", v))
+			}
+		}
+
+		strategy, _, _ := unstructured.NestedString(obj.Object, "spec", "conversion", "strategy")
+		if c.StorageVersion != "" && d.StorageVersion != "" && c.StorageVersion != d.StorageVersion && strategy != "Webhook" {
+			u.Warnings = append(u.Warnings, fmt.Sprintf("storage version changes from %s to %s without a conversion webhook", c.StorageVersion, d.StorageVersion))
+		}
+
+		out = append(out, u)
+	}
+	return out
+}
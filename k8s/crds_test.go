@@ -0,0 +1,151 @@
+package k8s
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+const testCRDs = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: pipelines.core.calyptia.com
+spec:
+  group: core.calyptia.com
+  names:
+    kind: Pipeline
+  versions:
+  - name: v1
+    served: true
+    storage: false
+  - name: v2
+    served: true
+    storage: true
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+  versions:
+  - name: v1
+    served: true
+    storage: true
+`
+
+const testInstalledCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: pipelines.core.calyptia.com
+spec:
+  group: core.calyptia.com
+  names:
+    kind: Pipeline
+  versions:
+  - name: v1
+    served: true
+    storage: true
+status:
+  storedVersions:
+  - v1
+  conditions:
+  - type: Established
+    status: "True"
+`
+
+func TestManifestCRDs(t *testing.T) {
+	crds, err := manifestCRDs(testCRDs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(crds) != 1 || crds[0].GetName() != "pipelines.core.calyptia.com" {
+		t.Errorf("expected only the core operator crds, got %v", crds)
+	}
+
+	if _, err := manifestCRDs(testCRDs, []string{"agents.core.calyptia.com"}); err == nil {
+		t.Error("expected unknown crd name to fail")
+	}
+}
+
+func TestCheckCRDUpgrades(t *testing.T) {
+	installed, err := DecodeManifest(testInstalledCRD)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	desired, err := manifestCRDs(testCRDs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("safe", func(t *testing.T) {
+		upgrades := checkCRDUpgrades(installed, desired)
+		if len(upgrades) != 1 {
+			t.Fatalf("expected one upgrade, got %d", len(upgrades))
+		}
+
+		u := upgrades[0]
+		if !u.Safe() {
+			t.Errorf("unexpected problems %v", u.Problems)
+		}
+
+		if u.Current == nil || !u.Current.Established || u.Current.StorageVersion != "v1" {
+			t.Errorf("unexpected current crd %+v", u.Current)
+		}
+
+		if u.Desired.StorageVersion != "v2" || len(u.Desired.ServedVersions) != 2 {
+			t.Errorf("unexpected desired crd %+v", u.Desired)
+		}
+
+		if len(u.Warnings) != 1 || !strings.Contains(u.Warnings[0], "without a conversion webhook") {
+			t.Errorf("unexpected warnings %v", u.Warnings)
+		}
+	})
+
+	t.Run("removes stored version", func(t *testing.T) {
+		removed, err := manifestCRDs(strings.Replace(testCRDs, "  - name: v1\n    served: true\n    storage: false\n", "", 1), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		upgrades := checkCRDUpgrades(installed, removed)
+		if upgrades[0].Safe() || len(upgrades[0].Problems) != 2 {
+			t.Errorf("expected stored and served problems, got %v", upgrades[0].Problems)
+		}
+	})
+
+	t.Run("not installed", func(t *testing.T) {
+		upgrades := checkCRDUpgrades(nil, desired)
+		if upgrades[0].Current != nil || !upgrades[0].Safe() {
+			t.Errorf("unexpected upgrade %+v", upgrades[0])
+		}
+	})
+}
+
+func TestListCRDs(t *testing.T) {
+	objects, err := DecodeManifest(testInstalledCRD + "---\n" + testCRDs[strings.Index(testCRDs, "---\n")+4:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		crdResource: "CustomResourceDefinitionList",
+	}, objects[0], objects[1])
+
+	crds, err := listCRDs(context.TODO(), dyn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(crds) != 1 || crdFromObject(crds[0]).Kind != "Pipeline" {
+		t.Errorf("expected only the core operator crds, got %v", crds)
+	}
+}
@@ -0,0 +1,120 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const syncDeploymentSuffix = "-sync"
+
+// ProxyEnvVars are the environment variables that change how
+// the core instance reaches the Cloud API.
+var ProxyEnvVars = []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY"}
+
+// SyncDeployment summarizes the core instance sync deployment
+// settings used to reach the Cloud API.
+type SyncDeployment struct {
+	Name      string            `json:"name" yaml:"name"`
+	Namespace string            `json:"namespace" yaml:"namespace"`
+	Env       map[string]string `json:"-" yaml:"-"`
+}
+
+// FindSyncDeployment returns the core instance sync deployment
+// matching the given label selector within the client namespace.
+func (client *Client) FindSyncDeployment(ctx context.Context, label string) (SyncDeployment, error) {
+	deployments, err := client.AppsV1().Deployments(client.Namespace).List(ctx, metav1.ListOptions{LabelSelector: label})
+	if err != nil {
+		return SyncDeployment{}, err
+	}
+
+	for _, d := range deployments.Items {
+		if !strings.HasSuffix(d.Name, syncDeploymentSuffix) {
+			continue
+		}
+
+		out := SyncDeployment{Name: d.Name, Namespace: d.Namespace, Env: map[string]string{}}
+		if containers := d.Spec.Template.Spec.Containers; len(containers) != 0 {
+			for _, e := range containers[0].Env {
+				if e.ValueFrom == nil {
+					out.Env[e.Name] = e.Value
+				}
+			}
+		}
+		return out, nil
+	}
+
+	return SyncDeployment{}, fmt.Errorf("could not find sync deployment with labels %q in namespace %q", label, client.Namespace)
+}
+
+// DiagnosticPod is a short-lived pod running a command to completion.
+type DiagnosticPod struct {
+	Name    string
+	Image   string
+	Command []string
+	Env     []apiv1.EnvVar
+}
+
+// RunDiagnosticPod creates the given pod within the client namespace, waits
+// for it to complete and returns its logs. The pod is always deleted afterwards.
+func (client *Client) RunDiagnosticPod(ctx context.Context, pod DiagnosticPod, timeout time.Duration) (string, error) {
+	pods := client.CoreV1().Pods(client.Namespace)
+	created, err := pods.Create(ctx, &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: pod.Name + "-",
+			Namespace:    client.Namespace,
+			Labels: map[string]string{
+				LabelManagedBy: "calyptia-cli",
+				LabelComponent: "diagnostics",
+			},
+		},
+		Spec: apiv1.PodSpec{
+			RestartPolicy: apiv1.RestartPolicyNever,
+			Containers: []apiv1.Container{{
+				Name:    "diagnostics",
+				Image:   pod.Image,
+				Command: pod.Command,
+				Env:     pod.Env,
+			}},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("could not create diagnostic pod: %w", err)
+	}
+
+	defer func() {
+		// the pod is deleted even if the given context got canceled.
+		_ = pods.Delete(context.Background(), created.Name, metav1.DeleteOptions{})
+	}()
+
+	var phase apiv1.PodPhase
+	err = wait.PollUntilContextTimeout(ctx, time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		got, err := pods.Get(ctx, created.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+
+		phase = got.Status.Phase
+		return phase == apiv1.PodSucceeded || phase == apiv1.PodFailed, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("diagnostic pod %q did not complete in phase %q: %w", created.Name, phase, err)
+	}
+
+	logs, err := pods.GetLogs(created.Name, &apiv1.PodLogOptions{}).DoRaw(ctx)
+	if err != nil {
+		return "", fmt.Errorf("could not fetch diagnostic pod logs: %w", err)
+	}
+
+	if phase == apiv1.PodFailed {
+		return string(logs), errors.New("diagnostic pod failed")
+	}
+
+	return string(logs), nil
+}
@@ -0,0 +1,94 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestFindSyncDeployment(t *testing.T) {
+	labels := map[string]string{LabelAggregatorID: "test-id"}
+	deployment := func(name string, env ...apiv1.EnvVar) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+			Spec: appsv1.DeploymentSpec{
+				Template: apiv1.PodTemplateSpec{
+					Spec: apiv1.PodSpec{Containers: []apiv1.Container{{Name: "sync", Env: env}}},
+				},
+			},
+		}
+	}
+
+	client := &Client{
+		Interface: fake.NewSimpleClientset(
+			deployment("test"),
+			deployment("test-default-sync",
+				apiv1.EnvVar{Name: "HTTPS_PROXY", Value: "http://proxy:3128"},
+				apiv1.EnvVar{Name: "TOKEN", ValueFrom: &apiv1.EnvVarSource{SecretKeyRef: &apiv1.SecretKeySelector{Key: "token"}}},
+			),
+		),
+		Namespace: "default",
+	}
+
+	got, err := client.FindSyncDeployment(context.TODO(), LabelAggregatorID+"=test-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Name != "test-default-sync" || got.Env["HTTPS_PROXY"] != "http://proxy:3128" {
+		t.Errorf("unexpected sync deployment %+v", got)
+	}
+
+	if _, ok := got.Env["TOKEN"]; ok {
+		t.Error("expected env vars from references to be skipped")
+	}
+
+	if _, err := client.FindSyncDeployment(context.TODO(), LabelAggregatorID+"=other-id"); err == nil {
+		t.Error("expected missing sync deployment to fail")
+	}
+}
+
+func TestRunDiagnosticPod(t *testing.T) {
+	clientSet := fake.NewSimpleClientset()
+	clientSet.PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		get, ok := action.(k8stesting.GetAction)
+		if !ok || action.GetSubresource() != "" {
+			return false, nil, nil
+		}
+
+		return true, &apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: get.GetName(), Namespace: "default"},
+			Status:     apiv1.PodStatus{Phase: apiv1.PodSucceeded},
+		}, nil
+	})
+
+	client := &Client{Interface: clientSet, Namespace: "default"}
+	logs, err := client.RunDiagnosticPod(context.TODO(), DiagnosticPod{
+		Name:    "test-debug",
+		Image:   "curlimages/curl",
+		Command: []string{"true"},
+	}, time.Second*5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if logs == "" {
+		t.Error("expected pod logs")
+	}
+
+	pods, err := clientSet.CoreV1().Pods("default").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pods.Items) != 0 {
+		t.Errorf("expected diagnostic pod to be deleted, got %d pods", len(pods.Items))
+	}
+}
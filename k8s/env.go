@@ -0,0 +1,151 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KnownCoreInstanceEnvVars are the environment variables read by the
+// core and sync containers, along with a short description of each.
+var KnownCoreInstanceEnvVars = map[string]string{
+	"AGGREGATOR_NAME":                "core instance name (core)",
+	"PROJECT_TOKEN":                  "project token used to authenticate against Calyptia Cloud (core)",
+	"AGGREGATOR_FLUENTBIT_CLOUD_URL": "Calyptia Cloud URL (core)",
+	coreTLSVerifyEnvVar:              "verify Calyptia Cloud TLS certificates (core)",
+	coreSkipServiceCreationEnvVar:    "skip the creation of kubernetes services for pipelines (core)",
+	"POD_NAMESPACE":                  "namespace the pipelines get deployed into (core)",
+	"CORE_INSTANCE":                  "core instance name (sync)",
+	"NAMESPACE":                      "namespace the pipelines get deployed into (sync)",
+	"CLOUD_URL":                      "Calyptia Cloud URL (sync)",
+	"TOKEN":                          "project token used to authenticate against Calyptia Cloud (sync)",
+	"INTERVAL":                       "interval between synchronizations (sync)",
+	syncTLSVerifyEnvVar:              "skip Calyptia Cloud TLS verification (sync)",
+	"METRICS_PORT":                   "port for the metrics endpoint (sync)",
+	"HTTP_PROXY":                     "HTTP proxy",
+	"HTTPS_PROXY":                    "HTTPS proxy",
+	"NO_PROXY":                       "hosts to reach without proxy",
+}
+
+// UnknownEnvVarError is returned when trying to edit an environment
+// variable the core instance does not read.
+type UnknownEnvVarError struct {
+	Name string
+}
+
+func (e *UnknownEnvVarError) Error() string {
+	known := make([]string, 0, len(KnownCoreInstanceEnvVars))
+	for name := range KnownCoreInstanceEnvVars {
+		known = append(known, name)
+	}
+	sort.Strings(known)
+	return fmt.Sprintf("unknown core instance environment variable %q, known variables: %s", e.Name, strings.Join(known, ", "))
+}
+
+// ValidateCoreInstanceEnvVars checks the given names are known core instance environment variables.
+func ValidateCoreInstanceEnvVars(names ...string) error {
+	for _, name := range names {
+		if _, ok := KnownCoreInstanceEnvVars[name]; !ok {
+			return &UnknownEnvVarError{Name: name}
+		}
+	}
+	return nil
+}
+
+// EnvVarsChange holds the environment variables to set and unset.
+type EnvVarsChange struct {
+	Set   map[string]string
+	Unset []string
+}
+
+// Apply returns the given environment variables with the change applied,
+// and whether they were modified. Variables sourced from a reference are
+// replaced by the literal value when set.
+func (c EnvVarsChange) Apply(env []apiv1.EnvVar) ([]apiv1.EnvVar, bool) {
+	var changed bool
+	out := make([]apiv1.EnvVar, 0, len(env)+len(c.Set))
+	for _, e := range env {
+		if contains(c.Unset, e.Name) {
+			changed = true
+			continue
+		}
+
+		if v, ok := c.Set[e.Name]; ok && (e.Value != v || e.ValueFrom != nil) {
+			e = apiv1.EnvVar{Name: e.Name, Value: v}
+			changed = true
+		}
+		out = append(out, e)
+	}
+
+	names := make([]string, 0, len(c.Set))
+	for name := range c.Set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !hasEnvVar(out, name) {
+			out = append(out, apiv1.EnvVar{Name: name, Value: c.Set[name]})
+			changed = true
+		}
+	}
+
+	return out, changed
+}
+
+// UpdateEnvVarsByLabel applies the change to the container with the given
+// name of the deployments matching the given label, leaving any other
+// container, like the sync sidecar, as it is. Updating the pod template
+// already rolls the deployments out, so they are not restarted again.
+// The names of the deployments that got updated are returned.
+func (client *Client) UpdateEnvVarsByLabel(ctx context.Context, label, container string, change EnvVarsChange) ([]string, error) {
+	deploymentList, err := client.FindDeploymentByLabel(ctx, label)
+	if err != nil {
+		return nil, err
+	}
+	if len(deploymentList.Items) == 0 {
+		return nil, notFoundErr("find deployment with label "+label, "no deployment found")
+	}
+
+	var updated []string
+	for _, deployment := range deploymentList.Items {
+		var changed bool
+		for i, c := range deployment.Spec.Template.Spec.Containers {
+			if c.Name != container {
+				continue
+			}
+
+			env, ok := change.Apply(c.Env)
+			if ok {
+				deployment.Spec.Template.Spec.Containers[i].Env = env
+				changed = true
+			}
+		}
+
+		if !changed {
+			continue
+		}
+
+		_, err = client.AppsV1().Deployments(deployment.Namespace).Update(ctx, &deployment, metav1.UpdateOptions{})
+		if err != nil {
+			return updated, fmt.Errorf("could not update deployment %q: %w", deployment.Name, err)
+		}
+
+		updated = append(updated, deployment.Name)
+	}
+
+	return updated, nil
+}
+
+func hasEnvVar(env []apiv1.EnvVar, name string) bool {
+	for _, e := range env {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
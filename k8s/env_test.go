@@ -0,0 +1,109 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestEnvVarsChange_Apply(t *testing.T) {
+	env := []apiv1.EnvVar{
+		{Name: "INTERVAL", Value: "15s"},
+		{Name: "HTTP_PROXY", Value: "http://old"},
+		{Name: "POD_NAMESPACE", ValueFrom: &apiv1.EnvVarSource{FieldRef: &apiv1.ObjectFieldSelector{FieldPath: "metadata.namespace"}}},
+	}
+
+	got, changed := EnvVarsChange{
+		Set:   map[string]string{"INTERVAL": "30s", "POD_NAMESPACE": "pipelines", "NO_PROXY": "localhost"},
+		Unset: []string{"HTTP_PROXY"},
+	}.Apply(env)
+	if !changed {
+		t.Fatal("expected env vars to change")
+	}
+
+	want := []apiv1.EnvVar{
+		{Name: "INTERVAL", Value: "30s"},
+		{Name: "POD_NAMESPACE", Value: "pipelines"},
+		{Name: "NO_PROXY", Value: "localhost"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+
+	_, changed = EnvVarsChange{Set: map[string]string{"INTERVAL": "15s"}, Unset: []string{"TOKEN"}}.Apply(env)
+	if changed {
+		t.Error("expected env vars to not change")
+	}
+}
+
+func TestValidateCoreInstanceEnvVars(t *testing.T) {
+	if err := ValidateCoreInstanceEnvVars("INTERVAL", "HTTPS_PROXY"); err != nil {
+		t.Fatal(err)
+	}
+
+	var unknown *UnknownEnvVarError
+	err := ValidateCoreInstanceEnvVars("INTERVAL", "NOPE")
+	if !errors.As(err, &unknown) || unknown.Name != "NOPE" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestClient_UpdateEnvVarsByLabel(t *testing.T) {
+	proxy := apiv1.EnvVar{Name: "HTTP_PROXY", Value: "http://proxy"}
+	deployment := func(name string, containers ...apiv1.Container) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{LabelAggregatorID: "1"}},
+			Spec: appsv1.DeploymentSpec{
+				Template: apiv1.PodTemplateSpec{
+					Spec: apiv1.PodSpec{Containers: containers},
+				},
+			},
+		}
+	}
+
+	clientSet := fake.NewSimpleClientset(
+		deployment("core",
+			apiv1.Container{Name: "my-core", Env: []apiv1.EnvVar{proxy}},
+			apiv1.Container{Name: "my-core-sync", Env: []apiv1.EnvVar{proxy}},
+		),
+		deployment("sync", apiv1.Container{Name: "my-core-sync-to-cloud", Env: []apiv1.EnvVar{proxy}}),
+	)
+	client := &Client{Namespace: "default", Interface: clientSet}
+
+	ctx := context.TODO()
+	updated, err := client.UpdateEnvVarsByLabel(ctx, LabelAggregatorID+"=1", "my-core", EnvVarsChange{Unset: []string{"HTTP_PROXY"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(updated, []string{"core"}) {
+		t.Errorf("unexpected updated deployments %v", updated)
+	}
+
+	got, err := client.AppsV1().Deployments("default").Get(ctx, "core", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	containers := got.Spec.Template.Spec.Containers
+	if env := containers[0].Env; len(env) != 0 {
+		t.Errorf("expected no env vars on the core container, got %+v", env)
+	}
+
+	if env := containers[1].Env; !reflect.DeepEqual(env, []apiv1.EnvVar{proxy}) {
+		t.Errorf("expected the sidecar env vars to be kept, got %+v", env)
+	}
+
+	// the pod template update rolls the deployment out on its own.
+	for _, action := range clientSet.Actions() {
+		if action.GetVerb() == "patch" {
+			t.Errorf("unexpected %s of %s", action.GetVerb(), action.GetResource().Resource)
+		}
+	}
+}
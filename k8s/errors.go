@@ -0,0 +1,84 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Kinds of failures the Client methods return wrapped,
+// so callers can tell them apart with errors.Is.
+var (
+	// ErrPermission is returned when the kubeconfig user is not allowed
+	// to perform the operation, or its credentials are not valid.
+	ErrPermission = errors.New("permission denied")
+	// ErrNotFound is returned when an object the operation relies on does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrTimeout is returned when the operation did not complete in time.
+	ErrTimeout = errors.New("timed out")
+)
+
+// Error is a failed operation against the kubernetes API.
+// It unwraps to both its Kind, if known, and the underlying error.
+type Error struct {
+	// Op describes the operation, ie: "delete deployment calyptia-core-sync".
+	Op string
+	// Kind is one of ErrPermission, ErrNotFound or ErrTimeout, or nil.
+	Kind error
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Op + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// wrapErr wraps the error of the given operation along with its kind.
+func wrapErr(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Op: op, Kind: errorKind(err), Err: err}
+}
+
+// notFoundErr reports an operation that found nothing to act on.
+func notFoundErr(op, msg string) error {
+	return &Error{Op: op, Kind: ErrNotFound, Err: errors.New(msg)}
+}
+
+func errorKind(err error) error {
+	switch {
+	case errors.Is(err, ErrPermission), apiErrors.IsForbidden(err), apiErrors.IsUnauthorized(err):
+		return ErrPermission
+	case errors.Is(err, ErrNotFound), apiErrors.IsNotFound(err):
+		return ErrNotFound
+	case errors.Is(err, ErrTimeout), errors.Is(err, context.DeadlineExceeded), wait.Interrupted(err) && !errors.Is(err, context.Canceled),
+		apiErrors.IsTimeout(err), apiErrors.IsServerTimeout(err):
+		return ErrTimeout
+	default:
+		return nil
+	}
+}
+
+// Remediation returns a hint on how to solve the given error,
+// or an empty string when it is not of a known kind.
+func Remediation(err error) string {
+	switch {
+	case errors.Is(err, ErrPermission):
+		return "check the kubeconfig user is allowed to perform the operation, ie: kubectl auth can-i --list, or refresh its credentials"
+	case errors.Is(err, ErrNotFound):
+		return "check the kubeconfig context and namespace point to the cluster where the object lives, ie: kubectl config current-context"
+	case errors.Is(err, ErrTimeout):
+		return "retry with a longer timeout, and check the cluster events for what is holding the operation, ie: kubectl get events --sort-by=.lastTimestamp"
+	default:
+		return ""
+	}
+}
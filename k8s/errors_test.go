@@ -0,0 +1,109 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestWrapErr(t *testing.T) {
+	gr := schema.GroupResource{Resource: "deployments"}
+	tt := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"forbidden", apiErrors.NewForbidden(gr, "sync", errors.New("nope")), ErrPermission},
+		{"unauthorized", apiErrors.NewUnauthorized("expired"), ErrPermission},
+		{"not found", apiErrors.NewNotFound(gr, "sync"), ErrNotFound},
+		{"deadline", context.DeadlineExceeded, ErrTimeout},
+		{"server timeout", apiErrors.NewServerTimeout(gr, "get", 1), ErrTimeout},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := wrapErr("get deployment sync", tc.err)
+			if !errors.Is(err, tc.want) {
+				t.Errorf("want %v, got %v", tc.want, err)
+			}
+
+			if !errors.Is(err, tc.err) {
+				t.Errorf("expected the underlying error to be kept, got %v", err)
+			}
+
+			if Remediation(err) == "" {
+				t.Error("expected a remediation hint")
+			}
+		})
+	}
+
+	if err := wrapErr("get deployment sync", errors.New("boom")); Remediation(err) != "" {
+		t.Errorf("expected no remediation hint for unknown errors, got %q", Remediation(err))
+	}
+}
+
+func TestClient_DeleteCoreInstance_permission(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&apiv1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+	clientset.PrependReactor("delete", "deployments", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apiErrors.NewForbidden(schema.GroupResource{Group: "apps", Resource: "deployments"}, "sync", errors.New("nope"))
+	})
+
+	client := &Client{Interface: clientset, Namespace: "default"}
+	err := client.DeleteCoreInstance(context.Background(), "core", "default", true)
+	if !errors.Is(err, ErrPermission) {
+		t.Fatalf("want permission error, got %v", err)
+	}
+}
+
+func TestClient_CheckOperatorVersion(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("not found", func(t *testing.T) {
+		client := &Client{Interface: fake.NewSimpleClientset(&apiv1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}})}
+		_, err := client.CheckOperatorVersion(ctx)
+		if !errors.Is(err, ErrCoreOperatorNotFound) || !errors.Is(err, ErrNotFound) {
+			t.Fatalf("want operator not found error, got %v", err)
+		}
+	})
+
+	t.Run("no containers", func(t *testing.T) {
+		client := &Client{Interface: fake.NewSimpleClientset(
+			&apiv1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "calyptia-core"}},
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "calyptia-core-controller-manager", Namespace: "calyptia-core"}},
+		)}
+		if _, err := client.CheckOperatorVersion(ctx); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("untagged image", func(t *testing.T) {
+		deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "calyptia-core-controller-manager", Namespace: "calyptia-core"}}
+		deploy.Spec.Template.Spec.Containers = []apiv1.Container{{Name: "manager", Image: "registry:5000/calyptia/core-operator"}}
+		client := &Client{Interface: fake.NewSimpleClientset(&apiv1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "calyptia-core"}}, deploy)}
+		if _, err := client.CheckOperatorVersion(ctx); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "calyptia-core-controller-manager", Namespace: "calyptia-core"}}
+		deploy.Spec.Template.Spec.Containers = []apiv1.Container{{Name: "manager", Image: "ghcr.io/calyptia/core-operator:v1.2.3"}}
+		client := &Client{Interface: fake.NewSimpleClientset(&apiv1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "calyptia-core"}}, deploy)}
+		got, err := client.CheckOperatorVersion(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != "v1.2.3" {
+			t.Errorf("want v1.2.3, got %q", got)
+		}
+	})
+}
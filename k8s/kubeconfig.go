@@ -0,0 +1,96 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// MinTokenDuration is the shortest token lifetime the API server accepts.
+const MinTokenDuration = 10 * time.Minute
+
+// ServiceAccountKubeconfig is a kubeconfig authenticating as a service account
+// with a short-lived token.
+type ServiceAccountKubeconfig struct {
+	Config    *clientcmdapi.Config
+	User      string
+	ExpiresAt time.Time
+}
+
+// OperatorServiceAccount returns the namespace and name of the
+// service account the core operator manager runs as.
+func (client *Client) OperatorServiceAccount(ctx context.Context) (string, string, error) {
+	manager, err := client.SearchManagerAcrossAllNamespaces(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	name := manager.Spec.Template.Spec.ServiceAccountName
+	if name == "" {
+		name = "default"
+	}
+
+	return manager.Namespace, name, nil
+}
+
+// GenerateServiceAccountKubeconfig mints a token for the given service account
+// with the TokenRequest API and returns a kubeconfig using it against the
+// same cluster the client is connected to.
+func (client *Client) GenerateServiceAccountKubeconfig(ctx context.Context, namespace, serviceAccount string, duration time.Duration) (ServiceAccountKubeconfig, error) {
+	var out ServiceAccountKubeconfig
+
+	if client.Config == nil {
+		return out, errors.New("kubernetes client config is required to generate a kubeconfig")
+	}
+
+	if duration < MinTokenDuration {
+		return out, fmt.Errorf("token duration must be at least %s", MinTokenDuration)
+	}
+
+	expirationSeconds := int64(duration.Seconds())
+	token, err := client.CoreV1().ServiceAccounts(namespace).CreateToken(ctx, serviceAccount, &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: &expirationSeconds},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return out, fmt.Errorf("could not request token for service account %s/%s: %w", namespace, serviceAccount, err)
+	}
+
+	caData := client.Config.CAData
+	if len(caData) == 0 && client.Config.CAFile != "" {
+		caData, err = os.ReadFile(client.Config.CAFile)
+		if err != nil {
+			return out, fmt.Errorf("could not read cluster certificate authority: %w", err)
+		}
+	}
+
+	cluster := clientcmdapi.NewCluster()
+	cluster.Server = client.Config.Host
+	cluster.CertificateAuthorityData = caData
+	cluster.InsecureSkipTLSVerify = client.Config.Insecure
+	cluster.TLSServerName = client.Config.ServerName
+
+	authInfo := clientcmdapi.NewAuthInfo()
+	authInfo.Token = token.Status.Token
+
+	kubeContext := clientcmdapi.NewContext()
+	kubeContext.Cluster = serviceAccount
+	kubeContext.AuthInfo = serviceAccount
+	kubeContext.Namespace = namespace
+
+	conf := clientcmdapi.NewConfig()
+	conf.Clusters[serviceAccount] = cluster
+	conf.AuthInfos[serviceAccount] = authInfo
+	conf.Contexts[serviceAccount] = kubeContext
+	conf.CurrentContext = serviceAccount
+
+	out.Config = conf
+	out.User = fmt.Sprintf("system:serviceaccount:%s:%s", namespace, serviceAccount)
+	out.ExpiresAt = token.Status.ExpirationTimestamp.Time
+	return out, nil
+}
@@ -0,0 +1,66 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	restclient "k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestClient_GenerateServiceAccountKubeconfig(t *testing.T) {
+	expiresAt := metav1.NewTime(time.Now().Add(time.Hour).Truncate(time.Second))
+
+	clientSet := fake.NewSimpleClientset()
+	clientSet.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		req := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenRequest)
+		if *req.Spec.ExpirationSeconds != 3600 {
+			t.Errorf("expected token to expire in an hour, got %ds", *req.Spec.ExpirationSeconds)
+		}
+
+		return true, &authenticationv1.TokenRequest{Status: authenticationv1.TokenRequestStatus{
+			Token:               "token",
+			ExpirationTimestamp: expiresAt,
+		}}, nil
+	})
+
+	client := &Client{
+		Interface: clientSet,
+		Config: &restclient.Config{
+			Host:            "https://cluster:6443",
+			TLSClientConfig: restclient.TLSClientConfig{CAData: []byte("ca")},
+		},
+	}
+
+	ctx := context.Background()
+	if _, err := client.GenerateServiceAccountKubeconfig(ctx, "calyptia-core", "manager", time.Minute); err == nil {
+		t.Error("expected token duration under the minimum to fail")
+	}
+
+	got, err := client.GenerateServiceAccountKubeconfig(ctx, "calyptia-core", "manager", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.User != "system:serviceaccount:calyptia-core:manager" || !got.ExpiresAt.Equal(expiresAt.Time) {
+		t.Errorf("unexpected kubeconfig %+v", got)
+	}
+
+	kubeContext := got.Config.Contexts[got.Config.CurrentContext]
+	if kubeContext == nil || kubeContext.Namespace != "calyptia-core" {
+		t.Fatalf("expected current context on the service account namespace, got %+v", kubeContext)
+	}
+
+	if cluster := got.Config.Clusters[kubeContext.Cluster]; cluster.Server != "https://cluster:6443" || string(cluster.CertificateAuthorityData) != "ca" {
+		t.Errorf("unexpected cluster %+v", cluster)
+	}
+
+	if authInfo := got.Config.AuthInfos[kubeContext.AuthInfo]; authInfo.Token != "token" {
+		t.Errorf("expected service account token, got %q", authInfo.Token)
+	}
+}
@@ -0,0 +1,84 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LogSource is a single container of a pipeline pod to read logs from.
+type LogSource struct {
+	Namespace string
+	Pod       string
+	Container string
+}
+
+func (s LogSource) String() string {
+	return s.Pod + "/" + s.Container
+}
+
+// LogsOptions for reading the pipeline pods logs.
+type LogsOptions struct {
+	Follow bool
+	// Tail lines to start from. Negative means all of them.
+	Tail int64
+	// Previous reads the logs of the previous terminated container,
+	// useful with crash-looping pipelines.
+	Previous bool
+}
+
+// PipelineLogSources returns the containers of the pods that belong to the
+// given pipeline. Pods are searched within the client namespace, or across
+// all namespaces if none set. Only the given container is returned if set.
+func (client *Client) PipelineLogSources(ctx context.Context, pipelineID, container string) ([]LogSource, error) {
+	pods, err := client.CoreV1().Pods(client.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", LabelPipelineID, pipelineID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not list pipeline pods: %w", err)
+	}
+
+	var out []LogSource
+	for _, pod := range pods.Items {
+		for _, c := range pod.Spec.Containers {
+			if container != "" && c.Name != container {
+				continue
+			}
+			out = append(out, LogSource{Namespace: pod.Namespace, Pod: pod.Name, Container: c.Name})
+		}
+	}
+
+	if len(out) == 0 {
+		if container != "" {
+			return nil, fmt.Errorf("no container %q found for pipeline %s", container, pipelineID)
+		}
+		return nil, fmt.Errorf("no pods found for pipeline %s", pipelineID)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].String() < out[j].String()
+	})
+	return out, nil
+}
+
+// StreamLogs opens the logs stream of the given source.
+func (client *Client) StreamLogs(ctx context.Context, source LogSource, opts LogsOptions) (io.ReadCloser, error) {
+	podOpts := &apiv1.PodLogOptions{
+		Container: source.Container,
+		Follow:    opts.Follow,
+		Previous:  opts.Previous,
+	}
+	if opts.Tail >= 0 {
+		podOpts.TailLines = &opts.Tail
+	}
+
+	stream, err := client.CoreV1().Pods(source.Namespace).GetLogs(source.Pod, podOpts).Stream(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not stream %s logs: %w", source, err)
+	}
+	return stream, nil
+}
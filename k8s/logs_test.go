@@ -0,0 +1,64 @@
+package k8s
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPipelineLogSources(t *testing.T) {
+	pod := func(name, namespace, pipelineID string, containers ...string) *apiv1.Pod {
+		p := &apiv1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{LabelPipelineID: pipelineID},
+		}}
+		for _, c := range containers {
+			p.Spec.Containers = append(p.Spec.Containers, apiv1.Container{Name: c})
+		}
+		return p
+	}
+
+	client := &Client{Interface: fake.NewSimpleClientset(
+		pod("b", "core", "test-id", "fluent-bit"),
+		pod("a", "other", "test-id", "fluent-bit", "reloader"),
+		pod("c", "core", "other-id", "fluent-bit"),
+	)}
+
+	ctx := context.TODO()
+	sources, err := client.PipelineLogSources(ctx, "test-id", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sources) != 3 || sources[0].String() != "a/fluent-bit" || sources[2].Namespace != "core" {
+		t.Errorf("unexpected sources %v", sources)
+	}
+
+	sources, err = client.PipelineLogSources(ctx, "test-id", "reloader")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sources) != 1 || sources[0].Pod != "a" {
+		t.Errorf("unexpected sources %v", sources)
+	}
+
+	if _, err := client.PipelineLogSources(ctx, "missing-id", ""); err == nil {
+		t.Error("expected pipeline without pods to fail")
+	}
+
+	stream, err := client.StreamLogs(ctx, sources[0], LogsOptions{Tail: 10, Previous: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	if b, err := io.ReadAll(stream); err != nil || len(b) == 0 {
+		t.Errorf("expected fake logs, got %q, %v", b, err)
+	}
+}
@@ -0,0 +1,153 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ManagedObject is a kubernetes object created for a Cloud core instance,
+// or for one of its pipelines.
+type ManagedObject struct {
+	NamedObject
+	ProjectID      string
+	CoreInstanceID string
+	// PipelineID is empty for the objects of the core instance itself.
+	PipelineID string
+}
+
+// ListManagedObjects lists the objects labeled with a core instance ID
+// across all namespaces.
+func (client *Client) ListManagedObjects(ctx context.Context) ([]ManagedObject, error) {
+	opts := metav1.ListOptions{LabelSelector: LabelAggregatorID}
+	var objs []metav1.Object
+	var kinds []string
+	add := func(kind string, obj metav1.Object) {
+		kinds = append(kinds, kind)
+		objs = append(objs, obj)
+	}
+
+	deployments, err := client.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not list deployments: %w", err)
+	}
+	for i := range deployments.Items {
+		add("Deployment", &deployments.Items[i])
+	}
+
+	daemonSets, err := client.AppsV1().DaemonSets(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not list daemon sets: %w", err)
+	}
+	for i := range daemonSets.Items {
+		add("DaemonSet", &daemonSets.Items[i])
+	}
+
+	services, err := client.CoreV1().Services(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not list services: %w", err)
+	}
+	for i := range services.Items {
+		add("Service", &services.Items[i])
+	}
+
+	configMaps, err := client.CoreV1().ConfigMaps(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not list config maps: %w", err)
+	}
+	for i := range configMaps.Items {
+		add("ConfigMap", &configMaps.Items[i])
+	}
+
+	secrets, err := client.CoreV1().Secrets(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not list secrets: %w", err)
+	}
+	for i := range secrets.Items {
+		add("Secret", &secrets.Items[i])
+	}
+
+	serviceAccounts, err := client.CoreV1().ServiceAccounts(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not list service accounts: %w", err)
+	}
+	for i := range serviceAccounts.Items {
+		add("ServiceAccount", &serviceAccounts.Items[i])
+	}
+
+	clusterRoles, err := client.RbacV1().ClusterRoles().List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not list cluster roles: %w", err)
+	}
+	for i := range clusterRoles.Items {
+		add("ClusterRole", &clusterRoles.Items[i])
+	}
+
+	bindings, err := client.RbacV1().ClusterRoleBindings().List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not list cluster role bindings: %w", err)
+	}
+	for i := range bindings.Items {
+		add("ClusterRoleBinding", &bindings.Items[i])
+	}
+
+	out := make([]ManagedObject, 0, len(objs))
+	for i, obj := range objs {
+		labels := obj.GetLabels()
+		if labels[LabelAggregatorID] == "" {
+			continue
+		}
+
+		out = append(out, ManagedObject{
+			NamedObject: NamedObject{
+				Kind:      kinds[i],
+				Namespace: obj.GetNamespace(),
+				Name:      obj.GetName(),
+			},
+			ProjectID:      labels[LabelProjectID],
+			CoreInstanceID: labels[LabelAggregatorID],
+			PipelineID:     labels[LabelPipelineID],
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].String() < out[j].String()
+	})
+
+	return out, nil
+}
+
+// DeleteObject deletes the given object. Objects already gone are ignored.
+func (client *Client) DeleteObject(ctx context.Context, obj NamedObject) error {
+	var err error
+	opts := metav1.DeleteOptions{}
+	switch obj.Kind {
+	case "Deployment":
+		err = client.AppsV1().Deployments(obj.Namespace).Delete(ctx, obj.Name, opts)
+	case "DaemonSet":
+		err = client.AppsV1().DaemonSets(obj.Namespace).Delete(ctx, obj.Name, opts)
+	case "Service":
+		err = client.CoreV1().Services(obj.Namespace).Delete(ctx, obj.Name, opts)
+	case "ConfigMap":
+		err = client.CoreV1().ConfigMaps(obj.Namespace).Delete(ctx, obj.Name, opts)
+	case "Secret":
+		err = client.CoreV1().Secrets(obj.Namespace).Delete(ctx, obj.Name, opts)
+	case "ServiceAccount":
+		err = client.CoreV1().ServiceAccounts(obj.Namespace).Delete(ctx, obj.Name, opts)
+	case "ClusterRole":
+		err = client.RbacV1().ClusterRoles().Delete(ctx, obj.Name, opts)
+	case "ClusterRoleBinding":
+		err = client.RbacV1().ClusterRoleBindings().Delete(ctx, obj.Name, opts)
+	default:
+		return fmt.Errorf("unsupported kind %q", obj.Kind)
+	}
+
+	if apiErrors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
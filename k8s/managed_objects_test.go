@@ -0,0 +1,74 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestClient_ListManagedObjects(t *testing.T) {
+	meta := func(namespace, name string, labels map[string]string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels}
+	}
+
+	core := map[string]string{LabelAggregatorID: "core", LabelProjectID: "project"}
+	pipeline := map[string]string{LabelAggregatorID: "core", LabelPipelineID: "pipeline"}
+
+	client := &Client{Interface: fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: meta("default", "calyptia-core-default-deployment", core)},
+		&appsv1.Deployment{ObjectMeta: meta("pipelines", "pipeline", pipeline)},
+		&apiv1.Secret{ObjectMeta: meta("default", "calyptia-core-default-secret", core)},
+		&rbacv1.ClusterRole{ObjectMeta: meta("", "calyptia-core-default-cluster-role", core)},
+		&apiv1.ConfigMap{ObjectMeta: meta("default", "unrelated", map[string]string{"app": "other"})},
+	)}
+
+	ctx := context.Background()
+	got, err := client.ListManagedObjects(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"ClusterRole calyptia-core-default-cluster-role",
+		"Deployment default/calyptia-core-default-deployment",
+		"Deployment pipelines/pipeline",
+		"Secret default/calyptia-core-default-secret",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d objects, got %+v", len(want), got)
+	}
+
+	for i, obj := range got {
+		if obj.String() != want[i] {
+			t.Errorf("expected %s, got %s", want[i], obj)
+		}
+	}
+
+	if got[2].PipelineID != "pipeline" || got[2].CoreInstanceID != "core" || got[3].ProjectID != "project" {
+		t.Errorf("unexpected owners %+v", got)
+	}
+
+	for _, obj := range got {
+		if err := client.DeleteObject(ctx, obj.NamedObject); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := client.DeleteObject(ctx, got[0].NamedObject); err != nil {
+		t.Errorf("expected deleting a missing object to succeed, got %v", err)
+	}
+
+	got, err = client.ListManagedObjects(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 0 {
+		t.Errorf("expected every managed object to be deleted, got %+v", got)
+	}
+}
@@ -0,0 +1,201 @@
+package k8s
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	"sigs.k8s.io/yaml"
+)
+
+// manifestFieldManager is the server-side apply field manager
+// owning the fields set from a manifest.
+const manifestFieldManager = "calyptia-cli"
+
+// DecodeManifest returns the objects defined in the given multi-document YAML manifest.
+func DecodeManifest(manifest string) ([]*unstructured.Unstructured, error) {
+	var out []*unstructured.Unstructured
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(strings.NewReader(manifest)))
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read manifest: %w", err)
+		}
+
+		obj := &unstructured.Unstructured{}
+		if err := utilyaml.Unmarshal(doc, &obj.Object); err != nil {
+			return nil, fmt.Errorf("could not parse manifest: %w", err)
+		}
+
+		if len(obj.Object) == 0 || obj.GetKind() == "" {
+			continue
+		}
+
+		out = append(out, obj)
+	}
+}
+
+// ApplyManifest server-side applies the objects defined in the given manifest,
+// in order, straight from memory.
+func (client *Client) ApplyManifest(ctx context.Context, manifest string) error {
+	objects, err := DecodeManifest(manifest)
+	if err != nil {
+		return err
+	}
+
+	dyn, mapper, err := client.dynamicClient()
+	if err != nil {
+		return err
+	}
+
+	return applyObjects(ctx, dyn, mapper, objects)
+}
+
+// DryRunManifest server-side applies the objects defined in the given manifest
+// in dry-run mode, persisting nothing, and returns them as the API server
+// would store them: with defaults and admission mutations applied.
+func (client *Client) DryRunManifest(ctx context.Context, manifest string) (string, error) {
+	objects, err := DecodeManifest(manifest)
+	if err != nil {
+		return "", err
+	}
+
+	dyn, mapper, err := client.dynamicClient()
+	if err != nil {
+		return "", err
+	}
+
+	return dryRunObjects(ctx, dyn, mapper, objects)
+}
+
+func dryRunObjects(ctx context.Context, dyn dynamic.Interface, mapper meta.RESTMapper, objects []*unstructured.Unstructured) (string, error) {
+	applied, err := applyObjectsWithOptions(ctx, dyn, mapper, objects, metav1.ApplyOptions{
+		FieldManager: manifestFieldManager,
+		Force:        true,
+		DryRun:       []string{metav1.DryRunAll},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, obj := range applied {
+		obj.SetManagedFields(nil)
+		b, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return "", fmt.Errorf("could not encode %s %q: %w", obj.GetKind(), obj.GetName(), err)
+		}
+
+		sb.WriteString("---\n")
+		sb.Write(b)
+	}
+	return sb.String(), nil
+}
+
+// DeleteManifest deletes the objects defined in the given manifest in reverse
+// order. Objects already gone are skipped. It keeps going on failure and
+// returns every error found.
+func (client *Client) DeleteManifest(ctx context.Context, manifest string) error {
+	objects, err := DecodeManifest(manifest)
+	if err != nil {
+		return err
+	}
+
+	return client.DeleteManifestObjects(ctx, objects)
+}
+
+func (client *Client) dynamicClient() (dynamic.Interface, meta.RESTMapper, error) {
+	if client.Config == nil {
+		return nil, nil, errors.New("kubernetes client config is required")
+	}
+
+	dyn, err := dynamic.NewForConfig(client.Config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(client.Discovery()))
+	return dyn, mapper, nil
+}
+
+func applyObjects(ctx context.Context, dyn dynamic.Interface, mapper meta.RESTMapper, objects []*unstructured.Unstructured) error {
+	_, err := applyObjectsWithOptions(ctx, dyn, mapper, objects, metav1.ApplyOptions{FieldManager: manifestFieldManager, Force: true})
+	return err
+}
+
+// applyObjectsWithOptions applies the given objects in order
+// and returns them as persisted by the API server.
+func applyObjectsWithOptions(ctx context.Context, dyn dynamic.Interface, mapper meta.RESTMapper, objects []*unstructured.Unstructured, opts metav1.ApplyOptions) ([]*unstructured.Unstructured, error) {
+	out := make([]*unstructured.Unstructured, 0, len(objects))
+	for _, obj := range objects {
+		res, err := resourceFor(dyn, mapper, obj)
+		if err != nil {
+			// custom resource definitions applied earlier
+			// are not known by the cached mapper yet.
+			if resettable, ok := mapper.(meta.ResettableRESTMapper); ok {
+				resettable.Reset()
+				res, err = resourceFor(dyn, mapper, obj)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		applied, err := res.Apply(ctx, obj.GetName(), obj, opts)
+		if err != nil {
+			return nil, fmt.Errorf("could not apply %s %q: %w", obj.GetKind(), obj.GetName(), err)
+		}
+		out = append(out, applied)
+	}
+	return out, nil
+}
+
+func deleteObjects(ctx context.Context, dyn dynamic.Interface, mapper meta.RESTMapper, objects []*unstructured.Unstructured) error {
+	propagation := metav1.DeletePropagationForeground
+	var errs []error
+	for i := len(objects) - 1; i >= 0; i-- {
+		obj := objects[i]
+		res, err := resourceFor(dyn, mapper, obj)
+		if meta.IsNoMatchError(err) {
+			// the custom resource definition was already deleted.
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		err = res.Delete(ctx, obj.GetName(), metav1.DeleteOptions{PropagationPolicy: &propagation})
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("could not delete %s %q: %w", obj.GetKind(), obj.GetName(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func resourceFor(dyn dynamic.Interface, mapper meta.RESTMapper, obj *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	gvk := obj.GroupVersionKind()
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("could not find resource for %s: %w", gvk, err)
+	}
+
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		return dyn.Resource(mapping.Resource).Namespace(obj.GetNamespace()), nil
+	}
+	return dyn.Resource(mapping.Resource), nil
+}
@@ -0,0 +1,157 @@
+package k8s
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const testManifest = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: calyptia-core
+---
+# comments only
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: calyptia-core
+data:
+  key: value
+`
+
+func TestDecodeManifest(t *testing.T) {
+	objects, err := DecodeManifest(testManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(objects) != 2 {
+		t.Fatalf("want 2 objects, got %d", len(objects))
+	}
+
+	if objects[0].GetKind() != "Namespace" || objects[1].GetName() != "settings" || objects[1].GetNamespace() != "calyptia-core" {
+		t.Errorf("unexpected objects %v, %v", objects[0], objects[1])
+	}
+
+	if _, err := DecodeManifest("kind: [unterminated"); err == nil {
+		t.Error("expected invalid manifest to fail")
+	}
+}
+
+func testMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	return mapper
+}
+
+func TestApplyAndDeleteObjects(t *testing.T) {
+	ctx := context.TODO()
+	objects, err := DecodeManifest(testManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	existing := &unstructured.Unstructured{}
+	existing.SetAPIVersion("v1")
+	existing.SetKind("ConfigMap")
+	existing.SetName("settings")
+	existing.SetNamespace("calyptia-core")
+
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		configMaps:                              "ConfigMapList",
+		{Version: "v1", Resource: "namespaces"}: "NamespaceList",
+	}, existing)
+
+	// the fake client cannot merge unstructured objects, so record the applied ones instead.
+	var applied []string
+	dyn.PrependReactor("patch", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch := action.(k8stesting.PatchAction)
+		if patch.GetPatchType() != types.ApplyPatchType {
+			t.Errorf("unexpected patch type %s", patch.GetPatchType())
+		}
+		applied = append(applied, patch.GetNamespace()+"/"+patch.GetName())
+		return true, existing, nil
+	})
+
+	err = applyObjects(ctx, dyn, testMapper(), objects[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(applied) != 1 || applied[0] != "calyptia-core/settings" {
+		t.Errorf("unexpected applied objects %v", applied)
+	}
+
+	// the namespace does not exist, but the config map must still be deleted.
+	err = deleteObjects(ctx, dyn, testMapper(), objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = dyn.Resource(configMaps).Namespace("calyptia-core").Get(ctx, "settings", metav1.GetOptions{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected config map to be deleted, got %v", err)
+	}
+
+	unknown, err := DecodeManifest("apiVersion: example.com/v1\nkind: Unknown\nmetadata:\n  name: test\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = applyObjects(ctx, dyn, testMapper(), unknown)
+	if err == nil || !strings.Contains(err.Error(), "could not find resource") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestDryRunObjects(t *testing.T) {
+	objects, err := DecodeManifest(testManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		{Version: "v1", Resource: "configmaps"}: "ConfigMapList",
+		{Version: "v1", Resource: "namespaces"}: "NamespaceList",
+	})
+
+	// answer as the API server would: the object with its defaults and managed fields.
+	dyn.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch := action.(k8stesting.PatchAction)
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(patch.GetPatch()); err != nil {
+			return true, nil, err
+		}
+		obj.SetUID("dry-run")
+		obj.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: manifestFieldManager}})
+		return true, obj, nil
+	})
+
+	got, err := dryRunObjects(context.TODO(), dyn, testMapper(), objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Count(got, "---\n") != len(objects) {
+		t.Errorf("expected %d documents, got:\n%s", len(objects), got)
+	}
+
+	if !strings.Contains(got, "uid: dry-run") || strings.Contains(got, "managedFields") {
+		t.Errorf("expected server rendered objects without managed fields, got:\n%s", got)
+	}
+}
@@ -0,0 +1,24 @@
+package k8s
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// ResolveNamespace returns the namespace kubernetes commands operate on,
+// by order of precedence: the --kube-namespace flag, the namespace of the
+// current (or --kube-context) context, and the default namespace.
+func ResolveNamespace(kubeConfig clientcmd.ClientConfig) (string, error) {
+	namespace, _, err := kubeConfig.Namespace()
+	if err != nil && !clientcmd.IsEmptyConfig(err) {
+		return "", fmt.Errorf("could not resolve kubernetes namespace: %w", err)
+	}
+
+	if namespace == "" {
+		namespace = apiv1.NamespaceDefault
+	}
+
+	return namespace, nil
+}
@@ -0,0 +1,134 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// sccVolumes are the volume types the core instance and its pipelines mount.
+// Host paths are needed by the cluster logging pipeline.
+var sccVolumes = []any{"configMap", "downwardAPI", "emptyDir", "hostPath", "persistentVolumeClaim", "projected", "secret"}
+
+// EnableOpenShiftClusterRoles adds the security.openshift.io rules
+// to the cluster roles of the given core instance lacking them.
+// It returns the patched cluster roles along with the added rules.
+func (client *Client) EnableOpenShiftClusterRoles(ctx context.Context, coreInstanceID string) ([]ClusterRoleDrift, error) {
+	roles, err := client.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", LabelAggregatorID, coreInstanceID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not list cluster roles: %w", err)
+	}
+
+	if len(roles.Items) == 0 {
+		return nil, fmt.Errorf("no cluster role found for core instance %q", coreInstanceID)
+	}
+
+	desired := ClusterRoleRules(ClusterRoleOpt{EnableOpenShift: true})
+
+	var out []ClusterRoleDrift
+	for i := range roles.Items {
+		role := &roles.Items[i]
+		missing := MissingPolicyRules(role.Rules, desired)
+		if len(missing) == 0 {
+			continue
+		}
+
+		drift := ClusterRoleDrift{ClusterRole: role, Missing: missing}
+		if err := client.PatchClusterRoleDrift(ctx, drift); err != nil {
+			return out, err
+		}
+		out = append(out, drift)
+	}
+	return out, nil
+}
+
+// CoreInstanceServiceAccounts returns the service accounts, as
+// system:serviceaccount:NAMESPACE:NAME users, the deployments of the
+// given core instance and its pipelines run as.
+func (client *Client) CoreInstanceServiceAccounts(ctx context.Context, coreInstanceID string) ([]string, error) {
+	deployments, err := client.AppsV1().Deployments(client.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", LabelAggregatorID, coreInstanceID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not list deployments: %w", err)
+	}
+
+	seen := map[string]bool{}
+	var out []string
+	for _, d := range deployments.Items {
+		name := d.Spec.Template.Spec.ServiceAccountName
+		if name == "" {
+			name = "default"
+		}
+
+		user := fmt.Sprintf("system:serviceaccount:%s:%s", d.Namespace, name)
+		if !seen[user] {
+			seen[user] = true
+			out = append(out, user)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// OpenShiftSCC returns the SecurityContextConstraints letting the given users
+// run the core instance pods on OpenShift: any user ID as fluent-bit images
+// expect, without privileges but with host paths for cluster logging.
+func OpenShiftSCC(name string, users []string) *unstructured.Unstructured {
+	sccUsers := make([]any, len(users))
+	for i, u := range users {
+		sccUsers[i] = u
+	}
+
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "security.openshift.io/v1",
+		"kind":       "SecurityContextConstraints",
+		"metadata": map[string]any{
+			"name": name,
+			"labels": map[string]any{
+				LabelPartOf:    "calyptia",
+				LabelManagedBy: "calyptia-cli",
+			},
+		},
+		"allowHostDirVolumePlugin": true,
+		"allowHostIPC":             false,
+		"allowHostNetwork":         false,
+		"allowHostPID":             false,
+		"allowHostPorts":           false,
+		"allowPrivilegeEscalation": false,
+		"allowPrivilegedContainer": false,
+		"readOnlyRootFilesystem":   false,
+		"requiredDropCapabilities": []any{"ALL"},
+		"runAsUser":                map[string]any{"type": "RunAsAny"},
+		"seLinuxContext":           map[string]any{"type": "MustRunAs"},
+		"fsGroup":                  map[string]any{"type": "RunAsAny"},
+		"supplementalGroups":       map[string]any{"type": "RunAsAny"},
+		"volumes":                  sccVolumes,
+		"users":                    sccUsers,
+	}}
+}
+
+// ApplyOpenShiftSCC server-side applies the SecurityContextConstraints
+// for the given users.
+func (client *Client) ApplyOpenShiftSCC(ctx context.Context, name string, users []string) error {
+	if len(users) == 0 {
+		return fmt.Errorf("no service account to grant security context constraints %q to", name)
+	}
+
+	dyn, mapper, err := client.dynamicClient()
+	if err != nil {
+		return err
+	}
+
+	err = applyObjects(ctx, dyn, mapper, []*unstructured.Unstructured{OpenShiftSCC(name, users)})
+	if meta.IsNoMatchError(err) {
+		return fmt.Errorf("security context constraints are not supported by this cluster, is it OpenShift?: %w", err)
+	}
+	return err
+}
@@ -0,0 +1,89 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestClient_EnableOpenShiftClusterRoles(t *testing.T) {
+	role := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "calyptia-test-default-cluster-role",
+			Labels: map[string]string{LabelAggregatorID: "id"},
+		},
+		Rules: ClusterRoleRules(),
+	}
+
+	client := &Client{Interface: fake.NewSimpleClientset(role)}
+	ctx := context.Background()
+
+	patched, err := client.EnableOpenShiftClusterRoles(ctx, "id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(patched) != 1 || len(patched[0].Missing) != 1 {
+		t.Fatalf("expected the openshift rule to be added, got %+v", patched)
+	}
+
+	got, err := client.RbacV1().ClusterRoles().Get(ctx, role.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !rulesAllow(got.Rules, "security.openshift.io", "securitycontextconstraints", "use") {
+		t.Errorf("expected cluster role to allow using security context constraints, got %+v", got.Rules)
+	}
+
+	patched, err = client.EnableOpenShiftClusterRoles(ctx, "id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(patched) != 0 {
+		t.Errorf("expected no changes on second run, got %+v", patched)
+	}
+
+	if _, err := client.EnableOpenShiftClusterRoles(ctx, "other"); err == nil {
+		t.Error("expected error with no cluster role")
+	}
+}
+
+func TestClient_CoreInstanceServiceAccounts(t *testing.T) {
+	deployment := func(name, serviceAccount string) *appsv1.Deployment {
+		d := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{LabelAggregatorID: "id"},
+		}}
+		d.Spec.Template.Spec.ServiceAccountName = serviceAccount
+		return d
+	}
+
+	client := &Client{
+		Interface: fake.NewSimpleClientset(
+			deployment("core", "calyptia-test-default-service-account"),
+			deployment("pipeline-a", ""),
+			deployment("pipeline-b", ""),
+		),
+		Namespace: "default",
+	}
+
+	users, err := client.CoreInstanceServiceAccounts(context.Background(), "id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"system:serviceaccount:default:calyptia-test-default-service-account",
+		"system:serviceaccount:default:default",
+	}
+	if len(users) != len(want) || users[0] != want[0] || users[1] != want[1] {
+		t.Errorf("expected %v, got %v", want, users)
+	}
+}
@@ -0,0 +1,153 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// operatorCustomResources are the resources defined by the core operator CRDs.
+var operatorCustomResources = []schema.GroupVersionResource{
+	{Group: operatorAPIGroup, Version: operatorAPIVersion, Resource: "pipelines"},
+	{Group: operatorAPIGroup, Version: operatorAPIVersion, Resource: "ingestchecks"},
+}
+
+// OperatorDependent is an object that relies on the core operator being
+// installed; either a core instance deployed for the operator or a custom
+// resource managed by it.
+type OperatorDependent struct {
+	Kind      string `json:"kind" yaml:"kind"`
+	Name      string `json:"name" yaml:"name"`
+	Namespace string `json:"namespace" yaml:"namespace"`
+	// CoreInstanceID is only set for core instances.
+	CoreInstanceID string `json:"coreInstanceID,omitempty" yaml:"coreInstanceID,omitempty"`
+}
+
+func (d OperatorDependent) String() string {
+	return fmt.Sprintf("%s: %s/%s", d.Kind, d.Namespace, d.Name)
+}
+
+// OperatorDependents lists the core instances and custom resources
+// across all namespaces that would be orphaned by uninstalling the operator.
+// Custom resources are only listed when the client has a rest config.
+func (client *Client) OperatorDependents(ctx context.Context) ([]OperatorDependent, error) {
+	selector := fmt.Sprintf("%s=operator,%s", LabelComponent, LabelAggregatorID)
+	deployments, err := client.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("could not list core instances: %w", err)
+	}
+
+	var out []OperatorDependent
+	for _, d := range deployments.Items {
+		name := d.Labels[LabelInstance]
+		if name == "" {
+			name = d.Name
+		}
+		out = append(out, OperatorDependent{
+			Kind:           "CoreInstance",
+			Name:           name,
+			Namespace:      d.Namespace,
+			CoreInstanceID: d.Labels[LabelAggregatorID],
+		})
+	}
+
+	if client.Config == nil {
+		return out, nil
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(client.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, gvr := range operatorCustomResources {
+		list, err := dynamicClient.Resource(gvr).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+		if apiErrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not list %s: %w", gvr.Resource, err)
+		}
+
+		for _, item := range list.Items {
+			out = append(out, OperatorDependent{
+				Kind:      item.GetKind(),
+				Name:      item.GetName(),
+				Namespace: item.GetNamespace(),
+			})
+		}
+	}
+
+	return out, nil
+}
+
+// DeleteOperatorDependents deletes the given core instances along with
+// all their kubernetes objects, and the given custom resources.
+// Custom resources are deleted while the operator is still running
+// so it gets the chance to clean up after them.
+func (client *Client) DeleteOperatorDependents(ctx context.Context, dependents []OperatorDependent) error {
+	var dynamicClient dynamic.Interface
+	for _, d := range dependents {
+		if d.Kind == "CoreInstance" {
+			if err := client.deleteOperatorCoreInstance(ctx, d); err != nil {
+				return fmt.Errorf("could not delete core instance %q: %w", d.Name, err)
+			}
+			continue
+		}
+
+		if dynamicClient == nil {
+			if client.Config == nil {
+				return fmt.Errorf("could not delete %s: missing kubernetes rest config", d)
+			}
+
+			var err error
+			dynamicClient, err = dynamic.NewForConfig(client.Config)
+			if err != nil {
+				return err
+			}
+		}
+
+		gvr, ok := operatorCustomResourceByKind(d.Kind)
+		if !ok {
+			return fmt.Errorf("unknown operator resource kind %q", d.Kind)
+		}
+
+		err := dynamicClient.Resource(gvr).Namespace(d.Namespace).Delete(ctx, d.Name, metav1.DeleteOptions{})
+		if err != nil && !apiErrors.IsNotFound(err) {
+			return fmt.Errorf("could not delete %s: %w", d, err)
+		}
+	}
+	return nil
+}
+
+func (client *Client) deleteOperatorCoreInstance(ctx context.Context, d OperatorDependent) error {
+	label := fmt.Sprintf("%s=%s", LabelAggregatorID, d.CoreInstanceID)
+	if err := client.DeleteDeploymentByLabel(ctx, label, d.Namespace); err != nil {
+		return err
+	}
+	if err := client.DeleteSecretByLabel(ctx, label, d.Namespace); err != nil {
+		return err
+	}
+	if err := client.DeleteServiceAccountByLabel(ctx, label, d.Namespace); err != nil {
+		return err
+	}
+	if err := client.DeleteRoleBindingByLabel(ctx, label); err != nil {
+		return err
+	}
+	return client.DeleteClusterRoleByLabel(ctx, label)
+}
+
+func operatorCustomResourceByKind(kind string) (schema.GroupVersionResource, bool) {
+	for _, gvr := range operatorCustomResources {
+		// kinds are the singular form of the resource, ie: Pipeline => pipelines.
+		if gvr.Resource == strings.ToLower(kind)+"s" {
+			return gvr, true
+		}
+	}
+	return schema.GroupVersionResource{}, false
+}
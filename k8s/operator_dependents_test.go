@@ -0,0 +1,49 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestOperatorDependents(t *testing.T) {
+	labels := map[string]string{
+		LabelComponent:    "operator",
+		LabelAggregatorID: "core-instance-id",
+		LabelInstance:     "my-core",
+	}
+	sync := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Name:      "calyptia-my-core-default-sync",
+		Namespace: "calyptia-core",
+		Labels:    labels,
+	}}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:      "calyptia-my-core-default-secret",
+		Namespace: "calyptia-core",
+		Labels:    labels,
+	}}
+	unrelated := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Name:      "calyptia-other-default",
+		Namespace: "default",
+		Labels:    map[string]string{LabelAggregatorID: "other-id"},
+	}}
+
+	client := &Client{Interface: fake.NewSimpleClientset(sync, secret, unrelated)}
+	dependents, err := client.OperatorDependents(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dependents) != 1 {
+		t.Fatalf("want 1 dependent, got %v", dependents)
+	}
+
+	want := OperatorDependent{Kind: "CoreInstance", Name: "my-core", Namespace: "calyptia-core", CoreInstanceID: "core-instance-id"}
+	if dependents[0] != want {
+		t.Errorf("want %+v, got %+v", want, dependents[0])
+	}
+}
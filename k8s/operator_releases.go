@@ -0,0 +1,84 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// OperatorReleasesURL is the release index of the core operator.
+var OperatorReleasesURL = "https://api.github.com/repos/calyptia/core-operator-releases/releases"
+
+// OperatorRelease is a published core operator version.
+type OperatorRelease struct {
+	Version         string    `json:"version" yaml:"version"`
+	ReleasedAt      time.Time `json:"releasedAt" yaml:"releasedAt"`
+	ReleaseNotesURL string    `json:"releaseNotesURL" yaml:"releaseNotesURL"`
+	Prerelease      bool      `json:"prerelease" yaml:"prerelease"`
+	// Compatibility is the range of kubernetes versions the release
+	// supports, as stated in its release notes. Empty if not stated.
+	Compatibility string `json:"compatibility,omitempty" yaml:"compatibility,omitempty"`
+}
+
+// compatibilityPattern matches a "Compatibility: ..." or "Kubernetes: ..."
+// line of the release notes, optionally in bold or as a list item.
+var compatibilityPattern = regexp.MustCompile(`(?im)^[\s*-]*(?:compatibility|kubernetes)[\s*]*:[\s*]*(.+?)[\s*]*$`)
+
+// ListOperatorReleases fetches the core operator releases from the newest
+// to the oldest. Drafts are skipped, and so are pre-releases unless prerelease is set.
+func ListOperatorReleases(ctx context.Context, prerelease bool) ([]OperatorRelease, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, OperatorReleasesURL+"?per_page=100", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get releases: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status: %d", resp.StatusCode)
+	}
+
+	var releases []struct {
+		TagName     string    `json:"tag_name"`
+		HTMLURL     string    `json:"html_url"`
+		Body        string    `json:"body"`
+		Draft       bool      `json:"draft"`
+		Prerelease  bool      `json:"prerelease"`
+		PublishedAt time.Time `json:"published_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("failed to decode releases: %w", err)
+	}
+
+	out := make([]OperatorRelease, 0, len(releases))
+	for _, r := range releases {
+		if r.Draft || (r.Prerelease && !prerelease) {
+			continue
+		}
+
+		var compatibility string
+		if m := compatibilityPattern.FindStringSubmatch(r.Body); m != nil {
+			compatibility = strings.TrimSpace(m[1])
+		}
+
+		out = append(out, OperatorRelease{
+			Version:         r.TagName,
+			ReleasedAt:      r.PublishedAt,
+			ReleaseNotesURL: r.HTMLURL,
+			Prerelease:      r.Prerelease,
+			Compatibility:   compatibility,
+		})
+	}
+
+	return out, nil
+}
@@ -0,0 +1,50 @@
+package k8s
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListOperatorReleases(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[
+			{"tag_name": "v2.1.0-rc1", "html_url": "https://example.org/v2.1.0-rc1", "prerelease": true, "published_at": "2024-02-01T00:00:00Z"},
+			{"tag_name": "v2.0.20", "html_url": "https://example.org/v2.0.20", "body": "## Changes\n\n- **Kubernetes**: >= 1.24, <= 1.29\n", "published_at": "2024-01-15T00:00:00Z"},
+			{"tag_name": "v2.0.19", "html_url": "https://example.org/v2.0.19", "body": "Compatibility: 1.23 - 1.28", "published_at": "2024-01-01T00:00:00Z"},
+			{"tag_name": "v2.0.18", "draft": true}
+		]`))
+	}))
+	defer srv.Close()
+
+	defaultURL := OperatorReleasesURL
+	OperatorReleasesURL = srv.URL
+	defer func() { OperatorReleasesURL = defaultURL }()
+
+	releases, err := ListOperatorReleases(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(releases) != 2 {
+		t.Fatalf("expected 2 releases, got %+v", releases)
+	}
+
+	if got, want := releases[0].Compatibility, ">= 1.24, <= 1.29"; got != want {
+		t.Errorf("want compatibility %q, got %q", want, got)
+	}
+
+	if got, want := releases[1].Compatibility, "1.23 - 1.28"; got != want {
+		t.Errorf("want compatibility %q, got %q", want, got)
+	}
+
+	releases, err = ListOperatorReleases(context.Background(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(releases) != 3 || !releases[0].Prerelease || releases[0].Version != "v2.1.0-rc1" {
+		t.Errorf("expected pre-release to be listed first, got %+v", releases)
+	}
+}
@@ -0,0 +1,233 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	operatorDeploymentName = "calyptia-core-controller-manager"
+	operatorAPIGroup       = "core.calyptia.com"
+	operatorAPIVersion     = "v1"
+)
+
+var (
+	operatorClusterRoleNames = []string{
+		"calyptia-core-manager-role",
+		"calyptia-core-metrics-reader",
+		"calyptia-core-pod-role",
+		"calyptia-core-proxy-role",
+	}
+	operatorClusterRoleBindingNames = []string{
+		"calyptia-core-manager-rolebinding",
+		"calyptia-core-proxy-rolebinding",
+	}
+)
+
+// ComponentState tells whether an operator component was found in the cluster
+// or if it could not be determined due to missing permissions.
+type ComponentState string
+
+const (
+	ComponentStateFound   ComponentState = "found"
+	ComponentStateUnknown ComponentState = "unknown"
+)
+
+// OperatorComponent is a single kubernetes object that belongs to the core operator.
+type OperatorComponent struct {
+	Kind      string         `json:"kind" yaml:"kind"`
+	Name      string         `json:"name" yaml:"name"`
+	Namespace string         `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Version   string         `json:"version,omitempty" yaml:"version,omitempty"`
+	State     ComponentState `json:"state" yaml:"state"`
+	Reason    string         `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+func (c OperatorComponent) String() string {
+	name := c.Name
+	if c.Namespace != "" {
+		name = c.Namespace + "/" + c.Name
+	}
+	if c.State == ComponentStateUnknown {
+		return fmt.Sprintf("%s: %s (unknown: %s)", c.Kind, name, c.Reason)
+	}
+	return fmt.Sprintf("%s: %s", c.Kind, name)
+}
+
+// OperatorStatus summarizes the core operator components found in the cluster.
+type OperatorStatus struct {
+	Installed  bool                `json:"installed" yaml:"installed"`
+	Version    string              `json:"version,omitempty" yaml:"version,omitempty"`
+	Namespaces []string            `json:"namespaces" yaml:"namespaces"`
+	Components []OperatorComponent `json:"components" yaml:"components"`
+}
+
+// Found returns only the components that were found in the cluster.
+func (s OperatorStatus) Found() []OperatorComponent {
+	var out []OperatorComponent
+	for _, c := range s.Components {
+		if c.State == ComponentStateFound {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// OperatorStatus lists the core operator components across all namespaces.
+// Permission errors do not fail the whole operation, instead the affected
+// component kind is reported with an unknown state.
+func (client *Client) OperatorStatus(ctx context.Context) (OperatorStatus, error) {
+	var status OperatorStatus
+
+	unknown := func(kind string, err error) error {
+		if apiErrors.IsForbidden(err) || apiErrors.IsUnauthorized(err) {
+			status.Components = append(status.Components, OperatorComponent{
+				Kind:   kind,
+				Name:   "*",
+				State:  ComponentStateUnknown,
+				Reason: string(apiErrors.ReasonForError(err)),
+			})
+			return nil
+		}
+		return wrapErr("list "+kind, err)
+	}
+
+	groupVersion := operatorAPIGroup + "/" + operatorAPIVersion
+	resources, err := client.Discovery().ServerResourcesForGroupVersion(groupVersion)
+	if err != nil && !apiErrors.IsNotFound(err) {
+		if err := unknown("CustomResourceDefinition", err); err != nil {
+			return status, err
+		}
+	}
+	if resources != nil {
+		for _, r := range resources.APIResources {
+			if strings.Contains(r.Name, "/") {
+				continue
+			}
+			status.Components = append(status.Components, OperatorComponent{
+				Kind:    "CustomResourceDefinition",
+				Name:    r.Name + "." + operatorAPIGroup,
+				Version: operatorAPIVersion,
+				State:   ComponentStateFound,
+			})
+		}
+	}
+
+	namespaces := map[string]struct{}{}
+
+	deployments, err := client.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if err := unknown("Deployment", err); err != nil {
+			return status, err
+		}
+	} else {
+		for _, d := range deployments.Items {
+			if d.Name != operatorDeploymentName {
+				continue
+			}
+
+			var version string
+			if containers := d.Spec.Template.Spec.Containers; len(containers) != 0 {
+				version = imageTag(containers[0].Image)
+			}
+			if status.Version == "" {
+				status.Version = version
+			}
+
+			namespaces[d.Namespace] = struct{}{}
+			status.Components = append(status.Components, OperatorComponent{
+				Kind:      "Deployment",
+				Name:      d.Name,
+				Namespace: d.Namespace,
+				Version:   version,
+				State:     ComponentStateFound,
+			})
+		}
+	}
+
+	clusterRoles, err := client.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if err := unknown("ClusterRole", err); err != nil {
+			return status, err
+		}
+	} else {
+		for _, cr := range clusterRoles.Items {
+			if contains(operatorClusterRoleNames, cr.Name) {
+				status.Components = append(status.Components, OperatorComponent{
+					Kind:  "ClusterRole",
+					Name:  cr.Name,
+					State: ComponentStateFound,
+				})
+			}
+		}
+	}
+
+	bindings, err := client.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if err := unknown("ClusterRoleBinding", err); err != nil {
+			return status, err
+		}
+	} else {
+		for _, crb := range bindings.Items {
+			if contains(operatorClusterRoleBindingNames, crb.Name) {
+				status.Components = append(status.Components, OperatorComponent{
+					Kind:  "ClusterRoleBinding",
+					Name:  crb.Name,
+					State: ComponentStateFound,
+				})
+			}
+		}
+	}
+
+	serviceAccounts, err := client.CoreV1().ServiceAccounts(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if err := unknown("ServiceAccount", err); err != nil {
+			return status, err
+		}
+	} else {
+		for _, sa := range serviceAccounts.Items {
+			if sa.Name != operatorDeploymentName {
+				continue
+			}
+
+			namespaces[sa.Namespace] = struct{}{}
+			status.Components = append(status.Components, OperatorComponent{
+				Kind:      "ServiceAccount",
+				Name:      sa.Name,
+				Namespace: sa.Namespace,
+				State:     ComponentStateFound,
+			})
+		}
+	}
+
+	status.Namespaces = make([]string, 0, len(namespaces))
+	for ns := range namespaces {
+		status.Namespaces = append(status.Namespaces, ns)
+	}
+	sort.Strings(status.Namespaces)
+
+	status.Installed = len(status.Found()) != 0
+
+	return status, nil
+}
+
+func imageTag(image string) string {
+	if i := strings.LastIndex(image, ":"); i != -1 && !strings.Contains(image[i:], "/") {
+		return image[i+1:]
+	}
+	return ""
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestOperatorStatus(t *testing.T) {
+	manager := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      operatorDeploymentName,
+			Namespace: "calyptia-core",
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Image: "ghcr.io/calyptia/core-operator:v2.0.20"}},
+				},
+			},
+		},
+	}
+	role := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "calyptia-core-manager-role"}}
+
+	t.Run("not installed", func(t *testing.T) {
+		client := &Client{Interface: fake.NewSimpleClientset()}
+		status, err := client.OperatorStatus(context.TODO())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Installed {
+			t.Error("expected operator not to be installed")
+		}
+	})
+
+	t.Run("installed", func(t *testing.T) {
+		client := &Client{Interface: fake.NewSimpleClientset(manager, role)}
+		status, err := client.OperatorStatus(context.TODO())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !status.Installed {
+			t.Error("expected operator to be installed")
+		}
+		if status.Version != "v2.0.20" {
+			t.Errorf("expected version v2.0.20, got %q", status.Version)
+		}
+		if len(status.Namespaces) != 1 || status.Namespaces[0] != "calyptia-core" {
+			t.Errorf("unexpected namespaces %v", status.Namespaces)
+		}
+		if len(status.Components) != 2 {
+			t.Errorf("expected 2 components, got %d", len(status.Components))
+		}
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		clientSet := fake.NewSimpleClientset(manager)
+		clientSet.PrependReactor("list", "clusterroles", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apiErrors.NewForbidden(schema.GroupResource{Resource: "clusterroles"}, "", nil)
+		})
+
+		client := &Client{Interface: clientSet}
+		status, err := client.OperatorStatus(context.TODO())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var unknown int
+		for _, c := range status.Components {
+			if c.State == ComponentStateUnknown {
+				unknown++
+				if c.Kind != "ClusterRole" {
+					t.Errorf("unexpected unknown component kind %q", c.Kind)
+				}
+			}
+		}
+		if unknown != 1 {
+			t.Errorf("expected 1 unknown component, got %d", unknown)
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// operatorLabel is set by the core operator manifest on its CRDs and cluster roles.
+const operatorLabel = "calyptia.core"
+
+// operatorObjectResources are the kinds of objects the core operator manifest
+// installs, in the order they are applied. Webhook configurations go last so
+// they are deleted first and a broken manager cannot block the other deletions.
+var operatorObjectResources = []struct {
+	schema.GroupVersionResource
+	Kind       string
+	Namespaced bool
+}{
+	{schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}, "CustomResourceDefinition", false},
+	{schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"}, "ClusterRole", false},
+	{schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"}, "ClusterRoleBinding", false},
+	{schema.GroupVersionResource{Version: "v1", Resource: "serviceaccounts"}, "ServiceAccount", true},
+	{schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "roles"}, "Role", true},
+	{schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"}, "RoleBinding", true},
+	{schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, "ConfigMap", true},
+	{schema.GroupVersionResource{Version: "v1", Resource: "secrets"}, "Secret", true},
+	{schema.GroupVersionResource{Version: "v1", Resource: "services"}, "Service", true},
+	{schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, "Deployment", true},
+	{schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "validatingwebhookconfigurations"}, "ValidatingWebhookConfiguration", false},
+	{schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "mutatingwebhookconfigurations"}, "MutatingWebhookConfiguration", false},
+}
+
+// OperatorObjects discovers the objects installed by the core operator manifest
+// across all namespaces, whatever its version and without relying on the
+// manager running. Objects are matched by label and name, and returned
+// in the order they are applied, so DeleteManifestObjects deletes them backwards.
+func (client *Client) OperatorObjects(ctx context.Context) ([]*unstructured.Unstructured, error) {
+	dyn, _, err := client.dynamicClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return discoverOperatorObjects(ctx, dyn)
+}
+
+func discoverOperatorObjects(ctx context.Context, dyn dynamic.Interface) ([]*unstructured.Unstructured, error) {
+	var out []*unstructured.Unstructured
+	for _, r := range operatorObjectResources {
+		var ri dynamic.ResourceInterface = dyn.Resource(r.GroupVersionResource)
+		if r.Namespaced {
+			ri = dyn.Resource(r.GroupVersionResource).Namespace(metav1.NamespaceAll)
+		}
+
+		list, err := ri.List(ctx, metav1.ListOptions{})
+		if apiErrors.IsNotFound(err) {
+			// the resource is not served by this cluster.
+			continue
+		}
+		if err != nil {
+			return nil, wrapErr("list "+r.Resource, err)
+		}
+
+		for i := range list.Items {
+			obj := &list.Items[i]
+			if !isOperatorObject(r.Kind, obj) {
+				continue
+			}
+
+			// list items come without type meta.
+			obj.SetAPIVersion(r.GroupVersion().String())
+			obj.SetKind(r.Kind)
+			out = append(out, obj)
+		}
+	}
+	return out, nil
+}
+
+// isOperatorObject tells whether the object belongs to the core operator:
+// labeled as such, or named after it and labeled as part of the operator
+// by the kubebuilder scaffolding.
+func isOperatorObject(kind string, obj *unstructured.Unstructured) bool {
+	name := obj.GetName()
+	labels := obj.GetLabels()
+
+	switch {
+	case labels[operatorLabel] == "core-operator":
+		return true
+	case kind == "CustomResourceDefinition":
+		return strings.HasSuffix(name, "."+operatorAPIGroup)
+	case kind == "Deployment" && name == operatorDeploymentName:
+		return true
+	}
+
+	return labels[LabelPartOf] == "operator" && strings.HasPrefix(name, "calyptia-core")
+}
+
+// DeleteManifestObjects deletes the given objects in reverse order.
+// Objects already gone are skipped. It keeps going on failure and
+// returns every error found.
+func (client *Client) DeleteManifestObjects(ctx context.Context, objects []*unstructured.Unstructured) error {
+	dyn, mapper, err := client.dynamicClient()
+	if err != nil {
+		return err
+	}
+
+	return deleteObjects(ctx, dyn, mapper, objects)
+}
+
+// RemoveOperatorFinalizers clears the finalizers of the given custom resources,
+// so they can be deleted while the manager is not running to process them.
+func (client *Client) RemoveOperatorFinalizers(ctx context.Context, dependents []OperatorDependent) error {
+	dyn, _, err := client.dynamicClient()
+	if err != nil {
+		return err
+	}
+
+	return removeOperatorFinalizers(ctx, dyn, dependents)
+}
+
+func removeOperatorFinalizers(ctx context.Context, dyn dynamic.Interface, dependents []OperatorDependent) error {
+	patch := []byte(`{"metadata":{"finalizers":null}}`)
+	for _, d := range dependents {
+		gvr, ok := operatorCustomResourceByKind(d.Kind)
+		if !ok {
+			continue
+		}
+
+		_, err := dyn.Resource(gvr).Namespace(d.Namespace).Patch(ctx, d.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+		if err != nil && !apiErrors.IsNotFound(err) {
+			return fmt.Errorf("could not remove finalizers of %s: %w", d, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func testObject(apiVersion, kind, namespace, name string, labels map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetLabels(labels)
+	return obj
+}
+
+func testOperatorDynamicClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	listKinds := map[schema.GroupVersionResource]string{}
+	for _, r := range operatorObjectResources {
+		listKinds[r.GroupVersionResource] = r.Kind + "List"
+	}
+	for _, gvr := range operatorCustomResources {
+		listKinds[gvr] = "List"
+	}
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+}
+
+func TestDiscoverOperatorObjects(t *testing.T) {
+	partOf := map[string]string{LabelPartOf: "operator"}
+	dyn := testOperatorDynamicClient(
+		testObject("apiextensions.k8s.io/v1", "CustomResourceDefinition", "", "pipelines.core.calyptia.com", nil),
+		testObject("apiextensions.k8s.io/v1", "CustomResourceDefinition", "", "certificates.cert-manager.io", nil),
+		testObject("rbac.authorization.k8s.io/v1", "ClusterRole", "", "calyptia-core-manager-role", map[string]string{operatorLabel: "core-operator"}),
+		testObject("rbac.authorization.k8s.io/v1", "ClusterRole", "", "other-operator-role", partOf),
+		testObject("v1", "ServiceAccount", "calyptia-core", "calyptia-core-controller-manager", partOf),
+		testObject("v1", "Secret", "calyptia-core", "calyptia-core-default-secret", map[string]string{LabelPartOf: "calyptia"}),
+		testObject("apps/v1", "Deployment", "calyptia-core", operatorDeploymentName, nil),
+		testObject("apps/v1", "Deployment", "calyptia-core", "calyptia-core-default-sync", nil),
+		testObject("admissionregistration.k8s.io/v1", "ValidatingWebhookConfiguration", "", "calyptia-core-validating-webhook", partOf),
+	)
+
+	objects, err := discoverOperatorObjects(context.TODO(), dyn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"CustomResourceDefinition pipelines.core.calyptia.com",
+		"ClusterRole calyptia-core-manager-role",
+		"ServiceAccount calyptia-core-controller-manager",
+		"Deployment " + operatorDeploymentName,
+		"ValidatingWebhookConfiguration calyptia-core-validating-webhook",
+	}
+
+	var got []string
+	for _, o := range objects {
+		got = append(got, o.GetKind()+" "+o.GetName())
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("want %q at %d, got %q", want[i], i, got[i])
+		}
+	}
+}
+
+func TestRemoveOperatorFinalizers(t *testing.T) {
+	ctx := context.TODO()
+	pipeline := testObject(operatorAPIGroup+"/"+operatorAPIVersion, "Pipeline", "default", "my-pipeline", nil)
+	pipeline.SetFinalizers([]string{"core.calyptia.com/finalizer"})
+
+	dyn := testOperatorDynamicClient(pipeline)
+	err := removeOperatorFinalizers(ctx, dyn, []OperatorDependent{
+		{Kind: "CoreInstance", Name: "my-core", Namespace: "default"},
+		{Kind: "Pipeline", Name: "my-pipeline", Namespace: "default"},
+		{Kind: "Pipeline", Name: "gone", Namespace: "default"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gvr, _ := operatorCustomResourceByKind("Pipeline")
+	got, err := dyn.Resource(gvr).Namespace("default").Get(ctx, "my-pipeline", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.GetFinalizers()) != 0 {
+		t.Errorf("expected finalizers to be removed, got %v", got.GetFinalizers())
+	}
+}
@@ -0,0 +1,267 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// QuotaIssue is a ResourceQuota or LimitRange in the target namespace
+// that would reject or mutate the pods of a deployment about to be created.
+type QuotaIssue struct {
+	Kind     string             `json:"kind" yaml:"kind"`
+	Name     string             `json:"name" yaml:"name"`
+	Resource apiv1.ResourceName `json:"resource" yaml:"resource"`
+	Message  string             `json:"message" yaml:"message"`
+	// Blocking is set when the pods would not be admitted at all,
+	// as opposed to being mutated by LimitRange defaults.
+	Blocking bool `json:"blocking" yaml:"blocking"`
+}
+
+func (i QuotaIssue) String() string {
+	return fmt.Sprintf("%s %q: %s", i.Kind, i.Name, i.Message)
+}
+
+var (
+	quotaRequestResources = map[apiv1.ResourceName]apiv1.ResourceName{
+		apiv1.ResourceRequestsCPU:    apiv1.ResourceCPU,
+		apiv1.ResourceCPU:            apiv1.ResourceCPU,
+		apiv1.ResourceRequestsMemory: apiv1.ResourceMemory,
+		apiv1.ResourceMemory:         apiv1.ResourceMemory,
+	}
+	quotaLimitResources = map[apiv1.ResourceName]apiv1.ResourceName{
+		apiv1.ResourceLimitsCPU:    apiv1.ResourceCPU,
+		apiv1.ResourceLimitsMemory: apiv1.ResourceMemory,
+	}
+)
+
+const quotaDeploymentsResource apiv1.ResourceName = "count/deployments.apps"
+
+// DeploymentQuotaIssues checks the given deployment against the ResourceQuotas
+// and LimitRanges of its namespace. Containers are first defaulted by the
+// LimitRanges the same way the admission controller would, then the total
+// requested by every replica is added to the quota usage.
+func (client *Client) DeploymentQuotaIssues(ctx context.Context, deployment *appsv1.Deployment) ([]QuotaIssue, error) {
+	namespace := deployment.Namespace
+	if namespace == "" {
+		namespace = client.Namespace
+	}
+
+	limitRanges, err := client.CoreV1().LimitRanges(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("could not list limit ranges: %w", err)
+	}
+
+	quotas, err := client.CoreV1().ResourceQuotas(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("could not list resource quotas: %w", err)
+	}
+
+	replicas := int64(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = int64(*deployment.Spec.Replicas)
+	}
+
+	var issues []QuotaIssue
+	containers := make([]apiv1.ResourceRequirements, 0, len(deployment.Spec.Template.Spec.Containers))
+	for _, c := range deployment.Spec.Template.Spec.Containers {
+		resources := *c.Resources.DeepCopy()
+		for _, lr := range limitRanges.Items {
+			issues = append(issues, applyLimitRange(lr, c.Name, &resources)...)
+		}
+		containers = append(containers, resources)
+	}
+
+	for _, q := range quotas.Items {
+		issues = append(issues, checkResourceQuota(q, containers, replicas)...)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Kind != issues[j].Kind {
+			return issues[i].Kind < issues[j].Kind
+		}
+		if issues[i].Name != issues[j].Name {
+			return issues[i].Name < issues[j].Name
+		}
+		return issues[i].Message < issues[j].Message
+	})
+
+	return issues, nil
+}
+
+// checkDeploymentQuota reports the quota issues of the deployment about
+// to be created, if the client was set to do so.
+func (client *Client) checkDeploymentQuota(ctx context.Context, deployment *appsv1.Deployment) error {
+	if client.OnQuotaIssues == nil {
+		return nil
+	}
+
+	issues, err := client.DeploymentQuotaIssues(ctx, deployment)
+	if err != nil {
+		return err
+	}
+
+	if len(issues) == 0 {
+		return nil
+	}
+
+	return client.OnQuotaIssues(deployment.Name, issues)
+}
+
+// applyLimitRange sets the container defaults of the given LimitRange
+// on the resources that were not set and reports the ones out of range.
+func applyLimitRange(lr apiv1.LimitRange, container string, resources *apiv1.ResourceRequirements) []QuotaIssue {
+	var issues []QuotaIssue
+	for _, item := range lr.Spec.Limits {
+		if item.Type != apiv1.LimitTypeContainer {
+			continue
+		}
+
+		for name, def := range item.Default {
+			if _, ok := resources.Limits[name]; ok {
+				continue
+			}
+
+			if resources.Limits == nil {
+				resources.Limits = apiv1.ResourceList{}
+			}
+			resources.Limits[name] = def.DeepCopy()
+			issues = append(issues, QuotaIssue{
+				Kind:     "LimitRange",
+				Name:     lr.Name,
+				Resource: name,
+				Message:  fmt.Sprintf("container %q gets a default %s limit of %s", container, name, def.String()),
+			})
+		}
+
+		for name := range resources.Limits {
+			if _, ok := resources.Requests[name]; ok {
+				continue
+			}
+
+			def, ok := item.DefaultRequest[name]
+			if !ok {
+				// requests default to the limits when not set.
+				def = resources.Limits[name]
+			}
+
+			if resources.Requests == nil {
+				resources.Requests = apiv1.ResourceList{}
+			}
+			resources.Requests[name] = def.DeepCopy()
+			issues = append(issues, QuotaIssue{
+				Kind:     "LimitRange",
+				Name:     lr.Name,
+				Resource: name,
+				Message:  fmt.Sprintf("container %q gets a default %s request of %s", container, name, def.String()),
+			})
+		}
+
+		for name, max := range item.Max {
+			if limit, ok := resources.Limits[name]; ok && limit.Cmp(max) > 0 {
+				issues = append(issues, QuotaIssue{
+					Kind:     "LimitRange",
+					Name:     lr.Name,
+					Resource: name,
+					Message:  fmt.Sprintf("container %q %s limit %s is above the maximum of %s", container, name, limit.String(), max.String()),
+					Blocking: true,
+				})
+			}
+		}
+
+		for name, min := range item.Min {
+			if request, ok := resources.Requests[name]; ok && request.Cmp(min) < 0 {
+				issues = append(issues, QuotaIssue{
+					Kind:     "LimitRange",
+					Name:     lr.Name,
+					Resource: name,
+					Message:  fmt.Sprintf("container %q %s request %s is below the minimum of %s", container, name, request.String(), min.String()),
+					Blocking: true,
+				})
+			}
+		}
+	}
+	return issues
+}
+
+// checkResourceQuota reports the quota resources the deployment would exceed,
+// or the compute resources the quota requires that the containers do not set.
+func checkResourceQuota(q apiv1.ResourceQuota, containers []apiv1.ResourceRequirements, replicas int64) []QuotaIssue {
+	hard := q.Status.Hard
+	if len(hard) == 0 {
+		hard = q.Spec.Hard
+	}
+
+	exceeds := func(name apiv1.ResourceName, requested resource.Quantity) *QuotaIssue {
+		max := hard[name]
+		used := q.Status.Used[name]
+		total := used.DeepCopy()
+		total.Add(requested)
+		if total.Cmp(max) <= 0 {
+			return nil
+		}
+
+		return &QuotaIssue{
+			Kind:     "ResourceQuota",
+			Name:     q.Name,
+			Resource: name,
+			Message:  fmt.Sprintf("requesting %s of %s would exceed the quota, %s used of %s", requested.String(), name, used.String(), max.String()),
+			Blocking: true,
+		}
+	}
+
+	var issues []QuotaIssue
+	for name := range hard {
+		var issue *QuotaIssue
+		switch name {
+		case apiv1.ResourcePods, "count/pods":
+			issue = exceeds(name, *resource.NewQuantity(replicas, resource.DecimalSI))
+		case quotaDeploymentsResource:
+			issue = exceeds(name, *resource.NewQuantity(1, resource.DecimalSI))
+		default:
+			compute, ok := quotaRequestResources[name]
+			lists := func(r apiv1.ResourceRequirements) apiv1.ResourceList { return r.Requests }
+			if !ok {
+				compute, ok = quotaLimitResources[name]
+				lists = func(r apiv1.ResourceRequirements) apiv1.ResourceList { return r.Limits }
+			}
+			if !ok {
+				continue
+			}
+
+			var total resource.Quantity
+			missing := false
+			for _, c := range containers {
+				v, ok := lists(c)[compute]
+				if !ok {
+					missing = true
+					break
+				}
+				total.Add(v)
+			}
+
+			if missing {
+				issue = &QuotaIssue{
+					Kind:     "ResourceQuota",
+					Name:     q.Name,
+					Resource: name,
+					Message:  fmt.Sprintf("the quota tracks %s but the containers do not set it, pods would be rejected", name),
+					Blocking: true,
+				}
+				break
+			}
+
+			issue = exceeds(name, *resource.NewMilliQuantity(total.MilliValue()*replicas, total.Format))
+		}
+
+		if issue != nil {
+			issues = append(issues, *issue)
+		}
+	}
+	return issues
+}
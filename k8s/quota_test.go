@@ -0,0 +1,148 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+func testQuotaDeployment(resources apiv1.ResourceRequirements) *appsv1.Deployment {
+	replicas := int32(2)
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: apiv1.PodTemplateSpec{
+				Spec: apiv1.PodSpec{Containers: []apiv1.Container{{Name: "core", Resources: resources}}},
+			},
+		},
+	}
+}
+
+func TestDeploymentQuotaIssues(t *testing.T) {
+	ctx := context.TODO()
+	quota := &apiv1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "default"},
+		Status: apiv1.ResourceQuotaStatus{
+			Hard: apiv1.ResourceList{
+				apiv1.ResourcePods:           resource.MustParse("3"),
+				apiv1.ResourceRequestsMemory: resource.MustParse("1Gi"),
+			},
+			Used: apiv1.ResourceList{
+				apiv1.ResourcePods:           resource.MustParse("2"),
+				apiv1.ResourceRequestsMemory: resource.MustParse("512Mi"),
+			},
+		},
+	}
+
+	t.Run("no quota", func(t *testing.T) {
+		client := &Client{Interface: fake.NewSimpleClientset(), Namespace: "default"}
+		issues, err := client.DeploymentQuotaIssues(ctx, testQuotaDeployment(apiv1.ResourceRequirements{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(issues) != 0 {
+			t.Errorf("expected no issues, got %v", issues)
+		}
+	})
+
+	t.Run("missing requests", func(t *testing.T) {
+		client := &Client{Interface: fake.NewSimpleClientset(quota), Namespace: "default"}
+		issues, err := client.DeploymentQuotaIssues(ctx, testQuotaDeployment(apiv1.ResourceRequirements{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(issues) != 2 {
+			t.Fatalf("expected 2 issues, got %v", issues)
+		}
+		for _, issue := range issues {
+			if !issue.Blocking {
+				t.Errorf("expected blocking issue, got %v", issue)
+			}
+		}
+		if !strings.Contains(issues[0].Message, "would exceed") || !strings.Contains(issues[1].Message, "do not set") {
+			t.Errorf("expected exceeded pods and missing requests issues, got %v", issues)
+		}
+	})
+
+	t.Run("limit range defaults", func(t *testing.T) {
+		limitRange := &apiv1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{Name: "defaults", Namespace: "default"},
+			Spec: apiv1.LimitRangeSpec{
+				Limits: []apiv1.LimitRangeItem{{
+					Type:           apiv1.LimitTypeContainer,
+					Default:        apiv1.ResourceList{apiv1.ResourceMemory: resource.MustParse("512Mi")},
+					DefaultRequest: apiv1.ResourceList{apiv1.ResourceMemory: resource.MustParse("128Mi")},
+				}},
+			},
+		}
+		withRoom := quota.DeepCopy()
+		withRoom.Status.Hard[apiv1.ResourcePods] = resource.MustParse("10")
+
+		client := &Client{Interface: fake.NewSimpleClientset(withRoom, limitRange), Namespace: "default"}
+		issues, err := client.DeploymentQuotaIssues(ctx, testQuotaDeployment(apiv1.ResourceRequirements{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(issues) != 2 {
+			t.Fatalf("expected 2 issues, got %v", issues)
+		}
+		for _, issue := range issues {
+			if issue.Kind != "LimitRange" || issue.Blocking {
+				t.Errorf("expected non blocking limit range issue, got %v", issue)
+			}
+		}
+	})
+
+	t.Run("exceeds quota", func(t *testing.T) {
+		withRoom := quota.DeepCopy()
+		withRoom.Status.Hard[apiv1.ResourcePods] = resource.MustParse("10")
+
+		client := &Client{Interface: fake.NewSimpleClientset(withRoom), Namespace: "default"}
+		issues, err := client.DeploymentQuotaIssues(ctx, testQuotaDeployment(apiv1.ResourceRequirements{
+			Requests: apiv1.ResourceList{apiv1.ResourceMemory: resource.MustParse("512Mi")},
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(issues) != 1 || issues[0].Resource != apiv1.ResourceRequestsMemory || !issues[0].Blocking {
+			t.Errorf("expected memory quota to be exceeded, got %v", issues)
+		}
+	})
+}
+
+func TestCreateDeploymentQuotaCheck(t *testing.T) {
+	quota := &apiv1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "pods", Namespace: "default"},
+		Spec:       apiv1.ResourceQuotaSpec{Hard: apiv1.ResourceList{apiv1.ResourcePods: resource.MustParse("0")}},
+	}
+
+	wantErr := errors.New("quota exceeded")
+	var got []QuotaIssue
+	client := &Client{
+		Interface:  fake.NewSimpleClientset(quota),
+		Namespace:  "default",
+		LabelsFunc: func() map[string]string { return nil },
+		OnQuotaIssues: func(deployment string, issues []QuotaIssue) error {
+			got = issues
+			return wantErr
+		},
+	}
+
+	_, err := client.DeployCoreOperatorSync(context.TODO(), "https://cloud", "from", "to", "15334", false, "", "", cloud.CreatedCoreInstance{Name: "test", EnvironmentName: "default"}, "default")
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("expected quota error, got %v", err)
+	}
+	if len(got) != 1 || got[0].Resource != apiv1.ResourcePods {
+		t.Errorf("unexpected issues %v", got)
+	}
+}
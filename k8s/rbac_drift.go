@@ -0,0 +1,135 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterRoleDrift holds the rules a core instance cluster role
+// is missing compared to the rules the current CLI would generate.
+type ClusterRoleDrift struct {
+	ClusterRole *rbacv1.ClusterRole
+	Missing     []rbacv1.PolicyRule
+}
+
+// CoreInstanceClusterRoleDrift compares the cluster roles of the given core
+// instance against the rules the current CLI version generates.
+// Only roles with missing rules are returned.
+func (client *Client) CoreInstanceClusterRoleDrift(ctx context.Context, coreInstanceID string) ([]ClusterRoleDrift, error) {
+	roles, err := client.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", LabelAggregatorID, coreInstanceID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not list cluster roles: %w", err)
+	}
+
+	var out []ClusterRoleDrift
+	for i := range roles.Items {
+		role := &roles.Items[i]
+		desired := ClusterRoleRules(ClusterRoleOpt{
+			EnableOpenShift: rulesAllow(role.Rules, "security.openshift.io", "securitycontextconstraints", "use"),
+		})
+
+		missing := MissingPolicyRules(role.Rules, desired)
+		if len(missing) != 0 {
+			out = append(out, ClusterRoleDrift{ClusterRole: role, Missing: missing})
+		}
+	}
+	return out, nil
+}
+
+// PatchClusterRoleDrift adds the missing rules to the drifted cluster role.
+func (client *Client) PatchClusterRoleDrift(ctx context.Context, drift ClusterRoleDrift) error {
+	role := drift.ClusterRole.DeepCopy()
+	role.Rules = append(role.Rules, drift.Missing...)
+
+	_, err := client.RbacV1().ClusterRoles().Update(ctx, role, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("could not patch cluster role %q: %w", role.Name, err)
+	}
+	return nil
+}
+
+// MissingPolicyRules returns the subset of the desired rules
+// not granted by the current ones.
+func MissingPolicyRules(current, desired []rbacv1.PolicyRule) []rbacv1.PolicyRule {
+	var out []rbacv1.PolicyRule
+	for _, rule := range desired {
+		groups, resources, verbs := map[string]bool{}, map[string]bool{}, map[string]bool{}
+		for _, g := range rule.APIGroups {
+			for _, r := range rule.Resources {
+				for _, v := range rule.Verbs {
+					if rulesAllow(current, g, r, v) {
+						continue
+					}
+					groups[g], resources[r], verbs[v] = true, true, true
+				}
+			}
+		}
+
+		if len(verbs) == 0 {
+			continue
+		}
+
+		out = append(out, rbacv1.PolicyRule{
+			APIGroups: filterInOrder(rule.APIGroups, groups),
+			Resources: filterInOrder(rule.Resources, resources),
+			Verbs:     filterInOrder(rule.Verbs, verbs),
+		})
+	}
+	return out
+}
+
+// FormatPolicyRule returns a single line representation of the rule.
+func FormatPolicyRule(rule rbacv1.PolicyRule) string {
+	groups := make([]string, len(rule.APIGroups))
+	for i, g := range rule.APIGroups {
+		if g == "" {
+			g = `""`
+		}
+		groups[i] = g
+	}
+	sort.Strings(groups)
+
+	return fmt.Sprintf("apiGroups=[%s] resources=[%s] verbs=[%s]",
+		strings.Join(groups, ","),
+		strings.Join(rule.Resources, ","),
+		strings.Join(rule.Verbs, ","),
+	)
+}
+
+func rulesAllow(rules []rbacv1.PolicyRule, group, resource, verb string) bool {
+	for _, r := range rules {
+		if len(r.NonResourceURLs) != 0 || len(r.ResourceNames) != 0 {
+			continue
+		}
+		if matchesAny(r.APIGroups, group) && matchesAny(r.Resources, resource) && matchesAny(r.Verbs, verb) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(values []string, v string) bool {
+	for _, s := range values {
+		if s == v || s == rbacv1.ResourceAll {
+			return true
+		}
+	}
+	return false
+}
+
+func filterInOrder(values []string, keep map[string]bool) []string {
+	var out []string
+	for _, v := range values {
+		if keep[v] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,60 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCoreInstanceClusterRoleDrift(t *testing.T) {
+	stale := ClusterRoleRules()
+	stale[0].Resources = stale[0].Resources[:len(stale[0].Resources)-6]
+
+	client := &Client{Interface: fake.NewSimpleClientset(
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "stale", Labels: map[string]string{LabelAggregatorID: "1"}},
+			Rules:      stale,
+		},
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "current", Labels: map[string]string{LabelAggregatorID: "2"}},
+			Rules:      ClusterRoleRules(),
+		},
+	)}
+
+	ctx := context.TODO()
+	drift, err := client.CoreInstanceClusterRoleDrift(ctx, "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drift) != 0 {
+		t.Fatalf("expected no drift, got %+v", drift)
+	}
+
+	drift, err = client.CoreInstanceClusterRoleDrift(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drift) != 1 {
+		t.Fatalf("expected drift on one cluster role, got %d", len(drift))
+	}
+
+	want := "apiGroups=[\"\",apps,batch,core.calyptia.com,policy] resources=[ingestchecks,ingestchecks/finalizers,ingestchecks/status,pipelines,pipelines/finalizers,pipelines/status] verbs=[get,list,create,delete,patch,update,watch,deletecollection,use]"
+	if got := FormatPolicyRule(drift[0].Missing[0]); got != want {
+		t.Fatalf("unexpected missing rule:\n%s\nwant:\n%s", got, want)
+	}
+
+	if err := client.PatchClusterRoleDrift(ctx, drift[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	drift, err = client.CoreInstanceClusterRoleDrift(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drift) != 0 {
+		t.Fatalf("expected no drift after patching, got %+v", drift)
+	}
+}
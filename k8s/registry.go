@@ -0,0 +1,108 @@
+package k8s
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+const registrySecretObjectType objectType = "registry-secret"
+
+// RegistryCredentials to pull images from a private registry.
+type RegistryCredentials struct {
+	Server   string
+	Username string
+	Password string
+}
+
+// ParseRegistryCredentials parses the --registry-creds flag value
+// in the form of user:pass@registry. The password may contain
+// colons and at signs, the user and registry may not.
+func ParseRegistryCredentials(s string) (RegistryCredentials, error) {
+	var out RegistryCredentials
+
+	i := strings.LastIndex(s, "@")
+	if i < 0 || i == len(s)-1 {
+		return out, errors.New("invalid registry credentials, expected user:pass@registry")
+	}
+
+	username, password, ok := strings.Cut(s[:i], ":")
+	if !ok || username == "" || password == "" {
+		return out, errors.New("invalid registry credentials, expected user:pass@registry")
+	}
+
+	out.Server = s[i+1:]
+	out.Username = username
+	out.Password = password
+	return out, nil
+}
+
+// DockerConfigJSON returns the contents of a kubernetes.io/dockerconfigjson
+// secret holding the credentials.
+func (c RegistryCredentials) DockerConfigJSON() ([]byte, error) {
+	type auth struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Auth     string `json:"auth"`
+	}
+
+	return json.Marshal(map[string]map[string]auth{
+		"auths": {
+			c.Server: {
+				Username: c.Username,
+				Password: c.Password,
+				Auth:     base64.StdEncoding.EncodeToString([]byte(c.Username + ":" + c.Password)),
+			},
+		},
+	})
+}
+
+// RegistrySecret returns the docker registry secret with the given name and namespace.
+func (c RegistryCredentials) RegistrySecret(meta metav1.ObjectMeta) (*apiv1.Secret, error) {
+	config, err := c.DockerConfigJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiv1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: meta,
+		Type:       apiv1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			apiv1.DockerConfigJsonKey: config,
+		},
+	}, nil
+}
+
+// CreateRegistrySecret creates the docker registry secret for the core instance
+// deployments to pull images with. Its name should then be added to ImagePullSecrets.
+func (client *Client) CreateRegistrySecret(ctx context.Context, agg cloud.CreatedCoreInstance, creds RegistryCredentials, dryRun bool) (*apiv1.Secret, error) {
+	req, err := creds.RegistrySecret(client.getObjectMeta(agg, registrySecretObjectType))
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return req, nil
+	}
+	return createWithPolicy(ctx, client.ConflictPolicy, req, client.secretOps())
+}
+
+// imagePullSecrets references the ImagePullSecrets from a pod spec.
+func (client *Client) imagePullSecrets() []apiv1.LocalObjectReference {
+	var out []apiv1.LocalObjectReference
+	for _, name := range client.ImagePullSecrets {
+		out = append(out, apiv1.LocalObjectReference{Name: name})
+	}
+	return out
+}
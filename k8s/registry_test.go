@@ -0,0 +1,72 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+func TestParseRegistryCredentials(t *testing.T) {
+	got, err := ParseRegistryCredentials("robot:p@ss:word@registry.example.com:5000")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := RegistryCredentials{Server: "registry.example.com:5000", Username: "robot", Password: "p@ss:word"}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	for _, s := range []string{"", "robot:pass", "robot@registry", ":pass@registry", "robot:pass@"} {
+		if _, err := ParseRegistryCredentials(s); err == nil {
+			t.Errorf("expected ParseRegistryCredentials(%q) to fail", s)
+		}
+	}
+}
+
+func TestClient_CreateRegistrySecret(t *testing.T) {
+	client := &Client{
+		Interface:  fake.NewSimpleClientset(),
+		Namespace:  "default",
+		LabelsFunc: func() map[string]string { return nil },
+	}
+
+	agg := cloud.CreatedCoreInstance{Name: "test", EnvironmentName: "default"}
+	secret, err := client.CreateRegistrySecret(context.TODO(), agg, RegistryCredentials{Server: "registry", Username: "robot", Password: "pass"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if secret.Type != apiv1.SecretTypeDockerConfigJson {
+		t.Errorf("unexpected secret type %q", secret.Type)
+	}
+
+	var config struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(secret.Data[apiv1.DockerConfigJsonKey], &config); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Auths["registry"].Auth != "cm9ib3Q6cGFzcw==" {
+		t.Errorf("unexpected docker config %+v", config)
+	}
+
+	client.ImagePullSecrets = []string{"existing", secret.Name}
+	deploy, err := client.CreateDeployment(context.TODO(), "image", agg, "https://cloud", &apiv1.ServiceAccount{}, true, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refs := deploy.Spec.Template.Spec.ImagePullSecrets
+	if len(refs) != 2 || refs[0].Name != "existing" || refs[1].Name != secret.Name {
+		t.Errorf("unexpected image pull secrets %v", refs)
+	}
+}
@@ -0,0 +1,159 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// NamedObject is a kubernetes object to be created along with a core instance.
+// Namespace is empty for cluster scoped objects.
+type NamedObject struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+func (obj NamedObject) String() string {
+	if obj.Namespace == "" {
+		return obj.Kind + " " + obj.Name
+	}
+	return obj.Kind + " " + obj.Namespace + "/" + obj.Name
+}
+
+// CoreInstanceObjects returns the objects created for a core instance with the
+// given name and environment on the client namespace, named as FormatResourceName.
+// The operator flow names the deployment after its sync container.
+// The cluster role, service account and binding are left out when using
+// an existing service account.
+func (client *Client) CoreInstanceObjects(name, environment string, operator, withRBAC bool) []NamedObject {
+	deployment := string(deploymentObjectType)
+	if operator {
+		deployment = "sync"
+	}
+
+	out := []NamedObject{
+		{Kind: "Secret", Namespace: client.Namespace, Name: FormatResourceName(name, environment, string(secretObjectType))},
+	}
+	if withRBAC {
+		out = append(out,
+			NamedObject{Kind: "ClusterRole", Name: FormatResourceName(name, environment, string(clusterRoleObjectType))},
+			NamedObject{Kind: "ServiceAccount", Namespace: client.Namespace, Name: FormatResourceName(name, environment, string(serviceAccountObjectType))},
+			NamedObject{Kind: "ClusterRoleBinding", Name: FormatResourceName(name, environment, string(clusterRoleBindingObjectType))},
+		)
+	}
+	out = append(out, NamedObject{Kind: "Deployment", Namespace: client.Namespace, Name: FormatResourceName(name, environment, deployment)})
+	return out
+}
+
+// InvalidResourceNameError is returned when a generated object name
+// is not a valid DNS-1123 label.
+type InvalidResourceNameError struct {
+	Object  NamedObject
+	Reasons []string
+}
+
+func (e *InvalidResourceNameError) Error() string {
+	msg := fmt.Sprintf("invalid kubernetes name for %s: %s", e.Object, strings.Join(e.Reasons, "; "))
+	if over := len(e.Object.Name) - validation.DNS1123LabelMaxLength; over > 0 {
+		msg += fmt.Sprintf("; shorten the core instance and environment names by %d characters combined", over)
+	}
+	return msg
+}
+
+// ValidateObjectNames checks the object names are valid DNS-1123 labels,
+// at most 63 lowercase alphanumeric characters or '-', so they are not
+// rejected by the API server once some of them are already created.
+// The longest invalid name is reported, so shortening it fixes them all.
+func ValidateObjectNames(objects []NamedObject) error {
+	var out *InvalidResourceNameError
+	for _, obj := range objects {
+		reasons := validation.IsDNS1123Label(obj.Name)
+		if len(reasons) == 0 {
+			continue
+		}
+
+		if out == nil || len(obj.Name) > len(out.Object.Name) {
+			out = &InvalidResourceNameError{Object: obj, Reasons: reasons}
+		}
+	}
+
+	if out == nil {
+		return nil
+	}
+	return out
+}
+
+// CollisionError is returned when objects about to be created
+// already exist and the conflict policy cannot resolve them.
+type CollisionError struct {
+	Objects []NamedObject
+	// Unmanaged reports the objects were not created by calyptia,
+	// so they can be neither recreated nor adopted.
+	Unmanaged bool
+}
+
+func (e *CollisionError) Error() string {
+	names := make([]string, len(e.Objects))
+	for i, obj := range e.Objects {
+		names[i] = obj.String()
+	}
+
+	if e.Unmanaged {
+		return fmt.Sprintf("objects not managed by calyptia already exist: %s", strings.Join(names, ", "))
+	}
+	return fmt.Sprintf("objects already exist: %s", strings.Join(names, ", "))
+}
+
+// CheckCollisions looks up the objects before creating any of them.
+// Existing ones are reported as collisions with the fail conflict policy,
+// or when they are not managed by calyptia with any other policy.
+func (client *Client) CheckCollisions(ctx context.Context, objects []NamedObject) error {
+	var existing, unmanaged []NamedObject
+	for _, obj := range objects {
+		meta, err := client.getObject(ctx, obj)
+		if apiErrors.IsNotFound(err) {
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("get %s: %w", obj, err)
+		}
+
+		existing = append(existing, obj)
+		if !IsManaged(meta.GetLabels()) {
+			unmanaged = append(unmanaged, obj)
+		}
+	}
+
+	if len(unmanaged) != 0 {
+		return &CollisionError{Objects: unmanaged, Unmanaged: true}
+	}
+
+	if len(existing) != 0 && client.ConflictPolicy == ConflictPolicyFail {
+		return &CollisionError{Objects: existing}
+	}
+
+	return nil
+}
+
+func (client *Client) getObject(ctx context.Context, obj NamedObject) (metav1.Object, error) {
+	switch obj.Kind {
+	case "Secret":
+		return client.CoreV1().Secrets(obj.Namespace).Get(ctx, obj.Name, metav1.GetOptions{})
+	case "ServiceAccount":
+		return client.CoreV1().ServiceAccounts(obj.Namespace).Get(ctx, obj.Name, metav1.GetOptions{})
+	case "ClusterRole":
+		return client.RbacV1().ClusterRoles().Get(ctx, obj.Name, metav1.GetOptions{})
+	case "ClusterRoleBinding":
+		return client.RbacV1().ClusterRoleBindings().Get(ctx, obj.Name, metav1.GetOptions{})
+	case "Deployment":
+		return client.AppsV1().Deployments(obj.Namespace).Get(ctx, obj.Name, metav1.GetOptions{})
+	default:
+		return nil, fmt.Errorf("unsupported kind %q", obj.Kind)
+	}
+}
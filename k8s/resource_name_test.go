@@ -0,0 +1,88 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidateObjectNames(t *testing.T) {
+	client := &Client{Namespace: "default"}
+
+	if err := ValidateObjectNames(client.CoreInstanceObjects("test", "default", false, true)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	long := strings.Repeat("a", 40)
+	err := ValidateObjectNames(client.CoreInstanceObjects("test", long, true, true))
+	var nameErr *InvalidResourceNameError
+	if !errors.As(err, &nameErr) {
+		t.Fatalf("expected InvalidResourceNameError, got %v", err)
+	}
+
+	// calyptia-test-<40>-cluster-role-binding is 75 characters long.
+	if nameErr.Object.Kind != "ClusterRoleBinding" || !strings.Contains(err.Error(), "by 12 characters") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err = ValidateObjectNames(client.CoreInstanceObjects("Test_1", "default", false, false))
+	if !errors.As(err, &nameErr) || strings.Contains(err.Error(), "shorten") {
+		t.Errorf("expected invalid charset error, got %v", err)
+	}
+}
+
+func TestClient_CheckCollisions(t *testing.T) {
+	managed := map[string]string{LabelManagedBy: "calyptia-cli"}
+	secret := &apiv1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:      "calyptia-test-default-secret",
+		Namespace: "default",
+		Labels:    managed,
+	}}
+	clusterRole := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{
+		Name: "calyptia-test-default-cluster-role",
+	}}
+
+	tt := []struct {
+		name          string
+		policy        ConflictPolicy
+		withRBAC      bool
+		wantObjects   int
+		wantUnmanaged bool
+	}{
+		{name: "fail", policy: ConflictPolicyFail, withRBAC: false, wantObjects: 1},
+		{name: "adopt managed", policy: ConflictPolicyAdopt, withRBAC: false},
+		{name: "adopt unmanaged", policy: ConflictPolicyAdopt, withRBAC: true, wantObjects: 1, wantUnmanaged: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &Client{
+				Interface:      fake.NewSimpleClientset(secret, clusterRole),
+				Namespace:      "default",
+				ConflictPolicy: tc.policy,
+			}
+
+			err := client.CheckCollisions(context.Background(), client.CoreInstanceObjects("test", "default", false, tc.withRBAC))
+			if tc.wantObjects == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var collisionErr *CollisionError
+			if !errors.As(err, &collisionErr) {
+				t.Fatalf("expected CollisionError, got %v", err)
+			}
+
+			if len(collisionErr.Objects) != tc.wantObjects || collisionErr.Unmanaged != tc.wantUnmanaged {
+				t.Errorf("unexpected collisions: %+v", collisionErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package k8s
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ResourceOptions are the cpu and memory requests and limits given by the
+// user for a container. Empty values keep the container defaults.
+type ResourceOptions struct {
+	CPURequest    string
+	CPULimit      string
+	MemoryRequest string
+	MemoryLimit   string
+}
+
+// BindResourceFlags binds the --cpu-request, --cpu-limit, --memory-request
+// and --memory-limit flags shared by the commands deploying containers,
+// so they comply with namespaces enforcing a LimitRange.
+// The container describes what the flags apply to, ie: "the core instance container".
+func BindResourceFlags(fs *pflag.FlagSet, p *ResourceOptions, container string) {
+	fs.StringVar(&p.CPURequest, "cpu-request", "", fmt.Sprintf("CPU request of %s, ie: 100m", container))
+	fs.StringVar(&p.CPULimit, "cpu-limit", "", fmt.Sprintf("CPU limit of %s, ie: 500m", container))
+	fs.StringVar(&p.MemoryRequest, "memory-request", "", fmt.Sprintf("Memory request of %s, ie: 64Mi", container))
+	fs.StringVar(&p.MemoryLimit, "memory-limit", "", fmt.Sprintf("Memory limit of %s, ie: 256Mi", container))
+}
+
+// IsZero tells whether no resources were given.
+func (o ResourceOptions) IsZero() bool {
+	return o == ResourceOptions{}
+}
+
+// Requirements returns the resource requirements with only the given values set.
+func (o ResourceOptions) Requirements() (apiv1.ResourceRequirements, error) {
+	var out apiv1.ResourceRequirements
+	return out, o.Apply(&out)
+}
+
+// Apply overrides the given values on top of the existing requirements,
+// and checks that no request ends up exceeding its limit.
+func (o ResourceOptions) Apply(r *apiv1.ResourceRequirements) error {
+	set := func(list *apiv1.ResourceList, name apiv1.ResourceName, flag, value string) error {
+		if value == "" {
+			return nil
+		}
+
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return fmt.Errorf("invalid --%s %q: %w", flag, value, err)
+		}
+
+		if *list == nil {
+			*list = apiv1.ResourceList{}
+		}
+		(*list)[name] = q
+		return nil
+	}
+
+	if err := set(&r.Requests, apiv1.ResourceCPU, "cpu-request", o.CPURequest); err != nil {
+		return err
+	}
+	if err := set(&r.Limits, apiv1.ResourceCPU, "cpu-limit", o.CPULimit); err != nil {
+		return err
+	}
+	if err := set(&r.Requests, apiv1.ResourceMemory, "memory-request", o.MemoryRequest); err != nil {
+		return err
+	}
+	if err := set(&r.Limits, apiv1.ResourceMemory, "memory-limit", o.MemoryLimit); err != nil {
+		return err
+	}
+
+	for _, name := range []apiv1.ResourceName{apiv1.ResourceCPU, apiv1.ResourceMemory} {
+		request, hasRequest := r.Requests[name]
+		limit, hasLimit := r.Limits[name]
+		if hasRequest && hasLimit && request.Cmp(limit) > 0 {
+			return fmt.Errorf("%s request %s exceeds its limit %s", name, request.String(), limit.String())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package k8s
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestResourceOptions_Apply(t *testing.T) {
+	defaults := func() apiv1.ResourceRequirements {
+		return apiv1.ResourceRequirements{
+			Limits:   apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("500m"), apiv1.ResourceMemory: resource.MustParse("128Mi")},
+			Requests: apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("10m"), apiv1.ResourceMemory: resource.MustParse("64Mi")},
+		}
+	}
+
+	t.Run("override", func(t *testing.T) {
+		got := defaults()
+		if err := (ResourceOptions{MemoryLimit: "256Mi", CPURequest: "50m"}).Apply(&got); err != nil {
+			t.Fatal(err)
+		}
+
+		if got.Limits.Memory().String() != "256Mi" || got.Requests.Cpu().String() != "50m" {
+			t.Errorf("expected given values to be overridden, got %v", got)
+		}
+
+		if got.Limits.Cpu().String() != "500m" || got.Requests.Memory().String() != "64Mi" {
+			t.Errorf("expected other values to be kept, got %v", got)
+		}
+	})
+
+	t.Run("request exceeds limit", func(t *testing.T) {
+		got := defaults()
+		if err := (ResourceOptions{CPURequest: "1"}).Apply(&got); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := (ResourceOptions{MemoryLimit: "lots"}).Requirements(); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("requirements", func(t *testing.T) {
+		got, err := ResourceOptions{CPULimit: "1"}.Requirements()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(got.Requests) != 0 || got.Limits.Cpu().String() != "1" {
+			t.Errorf("expected only the cpu limit, got %v", got)
+		}
+	})
+}
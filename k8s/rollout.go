@@ -0,0 +1,326 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// progressDeadlineExceededReason is set on the Progressing condition of a
+// deployment that did not make progress within its progressDeadlineSeconds.
+const progressDeadlineExceededReason = "ProgressDeadlineExceeded"
+
+// revisionAnnotation is set by the deployment controller on a deployment and
+// its replica sets with the revision of the pod template they run.
+const revisionAnnotation = "deployment.kubernetes.io/revision"
+
+// ErrProgressDeadlineExceeded is returned when waiting for a deployment
+// whose rollout stopped making progress.
+var ErrProgressDeadlineExceeded = errors.New("deployment exceeded its progress deadline")
+
+// RolloutStatus evaluates the rollout of the deployment the same way
+// `kubectl rollout status` does. It returns a progress message and whether
+// the rollout completed.
+func RolloutStatus(d *appsv1.Deployment) (string, bool, error) {
+	if d.Generation > d.Status.ObservedGeneration {
+		return "waiting for deployment spec update to be observed", false, nil
+	}
+
+	for _, cond := range d.Status.Conditions {
+		if cond.Type == appsv1.DeploymentProgressing && cond.Reason == progressDeadlineExceededReason {
+			return "", false, fmt.Errorf("%w: %s", ErrProgressDeadlineExceeded, cond.Message)
+		}
+	}
+
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+
+	switch {
+	case d.Status.UpdatedReplicas < replicas:
+		return fmt.Sprintf("%d/%d replicas updated", d.Status.UpdatedReplicas, replicas), false, nil
+	case d.Status.Replicas > d.Status.UpdatedReplicas:
+		return fmt.Sprintf("%d old replicas pending termination", d.Status.Replicas-d.Status.UpdatedReplicas), false, nil
+	case d.Status.AvailableReplicas < d.Status.UpdatedReplicas:
+		return fmt.Sprintf("%d/%d updated replicas available", d.Status.AvailableReplicas, d.Status.UpdatedReplicas), false, nil
+	}
+
+	return fmt.Sprintf("%d/%d replicas available", d.Status.AvailableReplicas, replicas), true, nil
+}
+
+// WaitReady watches the deployment until its rollout completes, fails
+// with ErrProgressDeadlineExceeded or the timeout expires. Progress is
+// reported through OnRolloutProgress when set. When verbose, the reason
+// the pods of the new replica set are not ready is streamed through
+// OnPodFailure as it changes, and included in the failure.
+func (client *Client) WaitReady(ctx context.Context, namespace, name string, verbose bool, waitTimeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, waitTimeout)
+	defer cancel()
+
+	if verbose && client.OnPodFailure != nil {
+		podsCtx, stopPods := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			client.watchPodFailures(podsCtx, namespace, name)
+		}()
+		// no more calls to OnPodFailure once returned.
+		defer func() {
+			stopPods()
+			<-done
+		}()
+	}
+
+	err := client.watchRollout(ctx, namespace, name)
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("%w after %s waiting for deployment %s to be ready", ErrTimeout, waitTimeout, name)
+	}
+
+	if verbose {
+		// the wait context is done at this point.
+		if message := client.waitingPodsMessage(context.WithoutCancel(ctx), namespace, name); message != "" {
+			return fmt.Errorf("%w, pods not ready:\n%s", err, message)
+		}
+	}
+
+	return err
+}
+
+func (client *Client) watchRollout(ctx context.Context, namespace, name string) error {
+	deployments := client.AppsV1().Deployments(namespace)
+
+	d, err := deployments.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return wrapErr("get deployment "+name, err)
+	}
+
+	var last string
+	check := func(d *appsv1.Deployment) (bool, error) {
+		msg, done, err := RolloutStatus(d)
+		if err != nil {
+			return false, err
+		}
+
+		if msg != last && client.OnRolloutProgress != nil {
+			client.OnRolloutProgress(name, msg)
+		}
+		last = msg
+		return done, nil
+	}
+
+	if done, err := check(d); done || err != nil {
+		return err
+	}
+
+	resourceVersion := d.ResourceVersion
+	for {
+		w, err := deployments.Watch(ctx, metav1.ListOptions{
+			FieldSelector:   fields.OneTermEqualSelector("metadata.name", name).String(),
+			ResourceVersion: resourceVersion,
+		})
+		if err != nil {
+			return err
+		}
+
+		done, err := client.consumeRollout(ctx, w, name, &resourceVersion, check)
+		w.Stop()
+		if done || err != nil {
+			return err
+		}
+	}
+}
+
+// consumeRollout handles the watch events until the rollout completes or
+// the watch gets closed by the server, in which case it returns false
+// so the watch is started again from the last seen resource version.
+func (client *Client) consumeRollout(ctx context.Context, w watch.Interface, name string, resourceVersion *string, check func(*appsv1.Deployment) (bool, error)) (bool, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return false, nil
+			}
+
+			switch event.Type {
+			case watch.Deleted:
+				return false, fmt.Errorf("deployment %s was deleted while waiting for it to be ready", name)
+			case watch.Error:
+				// the resource version is too old, start again from the current state.
+				*resourceVersion = ""
+				return false, nil
+			}
+
+			d, ok := event.Object.(*appsv1.Deployment)
+			if !ok || d.Name != name {
+				continue
+			}
+
+			*resourceVersion = d.ResourceVersion
+			if done, err := check(d); done || err != nil {
+				return done, err
+			}
+		}
+	}
+}
+
+// newReplicaSet returns the replica set of the deployment running its
+// current pod template, or nil while the deployment controller
+// has not created it yet.
+func (client *Client) newReplicaSet(ctx context.Context, d *appsv1.Deployment) (*appsv1.ReplicaSet, error) {
+	list, err := client.AppsV1().ReplicaSets(d.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: metav1.FormatLabelSelector(d.Spec.Selector),
+	})
+	if err != nil {
+		return nil, wrapErr("list replica sets of deployment "+d.Name, err)
+	}
+
+	revision := d.Annotations[revisionAnnotation]
+	for i := range list.Items {
+		rs := &list.Items[i]
+		if metav1.IsControlledBy(rs, d) && rs.Annotations[revisionAnnotation] == revision {
+			return rs, nil
+		}
+	}
+	return nil, nil
+}
+
+// rolloutPodSelector selects the pods of the new replica set of the
+// deployment, or all of its pods while the replica set is not known.
+func (client *Client) rolloutPodSelector(ctx context.Context, d *appsv1.Deployment) string {
+	selector := metav1.FormatLabelSelector(d.Spec.Selector)
+
+	rs, err := client.newReplicaSet(ctx, d)
+	if err != nil || rs == nil {
+		return selector
+	}
+
+	if hash := rs.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; hash != "" {
+		selector += "," + appsv1.DefaultDeploymentUniqueLabelKey + "=" + hash
+	}
+	return selector
+}
+
+// watchPodFailures calls OnPodFailure each time the reason a pod of the
+// deployment rollout is not ready changes, until the context is done.
+func (client *Client) watchPodFailures(ctx context.Context, namespace, name string) {
+	d, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return
+	}
+
+	pods := client.CoreV1().Pods(namespace)
+	reported := map[string]string{}
+	for ctx.Err() == nil {
+		// the new replica set may show up while waiting.
+		w, err := pods.Watch(ctx, metav1.ListOptions{LabelSelector: client.rolloutPodSelector(ctx, d)})
+		if err != nil {
+			return
+		}
+
+		client.consumePodFailures(ctx, w, name, reported)
+		w.Stop()
+	}
+}
+
+func (client *Client) consumePodFailures(ctx context.Context, w watch.Interface, name string, reported map[string]string) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return
+			}
+
+			pod, ok := event.Object.(*apiv1.Pod)
+			if !ok {
+				continue
+			}
+
+			if event.Type == watch.Deleted || pod.DeletionTimestamp != nil {
+				delete(reported, pod.Name)
+				continue
+			}
+
+			reason := podFailureReason(*pod)
+			if reason != "" && reason != reported[pod.Name] {
+				client.OnPodFailure(name, pod.Name, reason)
+			}
+			reported[pod.Name] = reason
+		}
+	}
+}
+
+// waitingPodsMessage lists the pods of the deployment rollout not ready,
+// along with the reason why.
+func (client *Client) waitingPodsMessage(ctx context.Context, namespace, name string) string {
+	d, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return ""
+	}
+
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: client.rolloutPodSelector(ctx, d)})
+	if err != nil {
+		return ""
+	}
+
+	var lines []string
+	for _, pod := range pods.Items {
+		if reason := podFailureReason(pod); reason != "" {
+			lines = append(lines, fmt.Sprintf("* pod %s: %s", pod.Name, reason))
+		}
+	}
+	sort.Strings(lines)
+
+	return strings.Join(lines, "\n")
+}
+
+// podFailureReason tells why the pod is not ready: it cannot be scheduled,
+// its containers are waiting, ie: CrashLoopBackOff, along with the
+// last exit, or they are running but failing their readiness probe.
+// It is empty for ready pods.
+func podFailureReason(pod apiv1.Pod) string {
+	var reasons []string
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == apiv1.PodScheduled && cond.Status == apiv1.ConditionFalse {
+			reasons = append(reasons, joinReason(cond.Reason, cond.Message))
+		}
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		switch {
+		case status.State.Waiting != nil:
+			reason := joinReason(status.State.Waiting.Reason, status.State.Waiting.Message)
+			if t := status.LastTerminationState.Terminated; t != nil {
+				reason += fmt.Sprintf(" (last exit code %d: %s, %d restarts)", t.ExitCode, t.Reason, status.RestartCount)
+			}
+			reasons = append(reasons, reason)
+		case status.State.Running != nil && !status.Ready:
+			reasons = append(reasons, fmt.Sprintf("container %s running but not ready, check its readiness probe", status.Name))
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
+
+func joinReason(reason, message string) string {
+	if message == "" {
+		return reason
+	}
+	return reason + ": " + message
+}
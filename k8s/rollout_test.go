@@ -0,0 +1,280 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func rolloutDeployment(replicas, updated, available, total int32, conditions ...appsv1.DeploymentCondition) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "sync", Namespace: "default", Generation: 2},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "sync"}},
+		},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 2,
+			Replicas:           total,
+			UpdatedReplicas:    updated,
+			AvailableReplicas:  available,
+			Conditions:         conditions,
+		},
+	}
+}
+
+func TestRolloutStatus(t *testing.T) {
+	deadline := appsv1.DeploymentCondition{Type: appsv1.DeploymentProgressing, Reason: progressDeadlineExceededReason}
+
+	unobserved := rolloutDeployment(1, 1, 1, 1)
+	unobserved.Status.ObservedGeneration = 1
+
+	tt := []struct {
+		name     string
+		d        *appsv1.Deployment
+		wantMsg  string
+		wantDone bool
+		wantErr  error
+	}{
+		{name: "unobserved", d: unobserved, wantMsg: "waiting for deployment spec update to be observed"},
+		{name: "updating", d: rolloutDeployment(3, 2, 2, 3), wantMsg: "2/3 replicas updated"},
+		{name: "terminating", d: rolloutDeployment(3, 3, 3, 4), wantMsg: "1 old replicas pending termination"},
+		{name: "available", d: rolloutDeployment(3, 3, 1, 3), wantMsg: "1/3 updated replicas available"},
+		{name: "done", d: rolloutDeployment(3, 3, 3, 3), wantMsg: "3/3 replicas available", wantDone: true},
+		{name: "deadline", d: rolloutDeployment(3, 1, 1, 3, deadline), wantErr: ErrProgressDeadlineExceeded},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			msg, done, err := RolloutStatus(tc.d)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("want error %v, got %v", tc.wantErr, err)
+			}
+
+			if msg != tc.wantMsg || done != tc.wantDone {
+				t.Errorf("want (%q, %v), got (%q, %v)", tc.wantMsg, tc.wantDone, msg, done)
+			}
+		})
+	}
+}
+
+func TestClient_WaitReady(t *testing.T) {
+	newClient := func(objects ...runtime.Object) (*Client, *watch.FakeWatcher) {
+		clientSet := fake.NewSimpleClientset(objects...)
+		watcher := watch.NewFakeWithChanSize(10, false)
+		clientSet.PrependWatchReactor("deployments", k8stesting.DefaultWatchReactor(watcher, nil))
+		return &Client{Interface: clientSet}, watcher
+	}
+
+	t.Run("ready", func(t *testing.T) {
+		client, watcher := newClient(rolloutDeployment(3, 1, 1, 3))
+
+		var progress []string
+		client.OnRolloutProgress = func(_, status string) {
+			progress = append(progress, status)
+		}
+
+		watcher.Modify(rolloutDeployment(3, 2, 2, 3))
+		watcher.Modify(rolloutDeployment(3, 3, 3, 3))
+
+		if err := client.WaitReady(context.Background(), "default", "sync", false, time.Second); err != nil {
+			t.Fatal(err)
+		}
+
+		want := "1/3 replicas updated,2/3 replicas updated,3/3 replicas available"
+		if got := strings.Join(progress, ","); got != want {
+			t.Errorf("want progress %q, got %q", want, got)
+		}
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		pod := &apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "sync-1", Namespace: "default", Labels: map[string]string{"app": "sync"}},
+			Status: apiv1.PodStatus{ContainerStatuses: []apiv1.ContainerStatus{{
+				State: apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "image not found"}},
+			}}},
+		}
+		client, watcher := newClient(rolloutDeployment(1, 0, 0, 1), pod)
+
+		watcher.Modify(rolloutDeployment(1, 0, 0, 1, appsv1.DeploymentCondition{
+			Type:   appsv1.DeploymentProgressing,
+			Reason: progressDeadlineExceededReason,
+		}))
+
+		err := client.WaitReady(context.Background(), "default", "sync", true, time.Second)
+		if !errors.Is(err, ErrProgressDeadlineExceeded) {
+			t.Fatalf("want progress deadline error, got %v", err)
+		}
+
+		if !strings.Contains(err.Error(), "sync-1: ImagePullBackOff: image not found") {
+			t.Errorf("expected waiting pod reason in error, got %v", err)
+		}
+	})
+
+	t.Run("new replica set", func(t *testing.T) {
+		d := rolloutDeployment(1, 0, 0, 2, appsv1.DeploymentCondition{
+			Type:   appsv1.DeploymentProgressing,
+			Reason: progressDeadlineExceededReason,
+		})
+		d.UID = types.UID("sync-uid")
+		d.Annotations = map[string]string{revisionAnnotation: "2"}
+
+		replicaSet := func(revision, hash string) *appsv1.ReplicaSet {
+			return &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+				Name:            "sync-" + hash,
+				Namespace:       "default",
+				Labels:          map[string]string{"app": "sync", appsv1.DefaultDeploymentUniqueLabelKey: hash},
+				Annotations:     map[string]string{revisionAnnotation: revision},
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(d, appsv1.SchemeGroupVersion.WithKind("Deployment"))},
+			}}
+		}
+		crashing := func(name, hash string) *apiv1.Pod {
+			return &apiv1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": "sync", appsv1.DefaultDeploymentUniqueLabelKey: hash}},
+				Status: apiv1.PodStatus{ContainerStatuses: []apiv1.ContainerStatus{{
+					State: apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				}}},
+			}
+		}
+
+		client, _ := newClient(d, replicaSet("1", "old"), replicaSet("2", "new"), crashing("sync-old-1", "old"), crashing("sync-new-1", "new"))
+
+		err := client.WaitReady(context.Background(), "default", "sync", true, time.Second)
+		if err == nil || !strings.Contains(err.Error(), "sync-new-1: CrashLoopBackOff") {
+			t.Fatalf("expected new replica set pod reason in error, got %v", err)
+		}
+
+		if strings.Contains(err.Error(), "sync-old-1") {
+			t.Errorf("expected old replica set pods to be skipped, got %v", err)
+		}
+	})
+
+	t.Run("pod failures", func(t *testing.T) {
+		pod := &apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "sync-1", Namespace: "default", Labels: map[string]string{"app": "sync"}},
+		}
+		client, watcher := newClient(rolloutDeployment(1, 0, 0, 1), pod)
+
+		failures := make(chan string, 10)
+		client.OnPodFailure = func(_, pod, reason string) {
+			failures <- pod + ": " + reason
+		}
+
+		errs := make(chan error, 1)
+		go func() {
+			errs <- client.WaitReady(context.Background(), "default", "sync", true, 5*time.Second)
+		}()
+
+		crashing := pod.DeepCopy()
+		crashing.Status.ContainerStatuses = []apiv1.ContainerStatus{{
+			RestartCount:         3,
+			State:                apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			LastTerminationState: apiv1.ContainerState{Terminated: &apiv1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"}},
+		}}
+
+		// the pod watch may not be started yet, keep updating until it gets reported.
+		var got string
+		for got == "" {
+			if _, err := client.CoreV1().Pods("default").Update(context.Background(), crashing, metav1.UpdateOptions{}); err != nil {
+				t.Fatal(err)
+			}
+
+			select {
+			case got = <-failures:
+			case <-time.After(50 * time.Millisecond):
+			}
+		}
+
+		want := "sync-1: CrashLoopBackOff (last exit code 1: Error, 3 restarts)"
+		if got != want {
+			t.Errorf("want failure %q, got %q", want, got)
+		}
+
+		watcher.Modify(rolloutDeployment(1, 1, 1, 1))
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+
+		// the same reason is only reported once.
+		if len(failures) != 0 {
+			t.Errorf("expected no more failures, got %d", len(failures))
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		client, _ := newClient(rolloutDeployment(1, 0, 0, 1))
+
+		err := client.WaitReady(context.Background(), "default", "sync", false, 50*time.Millisecond)
+		if err == nil || !strings.Contains(err.Error(), "timed out") {
+			t.Fatalf("want timeout error, got %v", err)
+		}
+
+		if !errors.Is(err, ErrTimeout) {
+			t.Errorf("want error wrapping ErrTimeout, got %v", err)
+		}
+	})
+}
+
+func TestPodFailureReason(t *testing.T) {
+	tt := []struct {
+		name string
+		pod  apiv1.Pod
+		want string
+	}{
+		{
+			name: "ready",
+			pod: apiv1.Pod{Status: apiv1.PodStatus{ContainerStatuses: []apiv1.ContainerStatus{{
+				Name:  "sync",
+				Ready: true,
+				State: apiv1.ContainerState{Running: &apiv1.ContainerStateRunning{}},
+			}}}},
+		},
+		{
+			name: "unschedulable",
+			pod: apiv1.Pod{Status: apiv1.PodStatus{Conditions: []apiv1.PodCondition{{
+				Type:    apiv1.PodScheduled,
+				Status:  apiv1.ConditionFalse,
+				Reason:  "Unschedulable",
+				Message: "0/3 nodes are available: 3 Insufficient cpu.",
+			}}}},
+			want: "Unschedulable: 0/3 nodes are available: 3 Insufficient cpu.",
+		},
+		{
+			name: "crash loop",
+			pod: apiv1.Pod{Status: apiv1.PodStatus{ContainerStatuses: []apiv1.ContainerStatus{{
+				Name:                 "sync",
+				RestartCount:         5,
+				State:                apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "CrashLoopBackOff", Message: "back-off 5m0s"}},
+				LastTerminationState: apiv1.ContainerState{Terminated: &apiv1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}},
+			}}}},
+			want: "CrashLoopBackOff: back-off 5m0s (last exit code 137: OOMKilled, 5 restarts)",
+		},
+		{
+			name: "readiness probe",
+			pod: apiv1.Pod{Status: apiv1.PodStatus{ContainerStatuses: []apiv1.ContainerStatus{{
+				Name:  "sync",
+				State: apiv1.ContainerState{Running: &apiv1.ContainerStateRunning{}},
+			}}}},
+			want: "container sync running but not ready, check its readiness probe",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := podFailureReason(tc.pod); got != tc.want {
+				t.Errorf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package k8s
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+	apiv1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Scheduling constrains the nodes the core instance pods can run on,
+// ie: to pin them to a dedicated node pool.
+type Scheduling struct {
+	NodeSelector map[string]string
+	Tolerations  []apiv1.Toleration
+	Affinity     *apiv1.Affinity
+}
+
+// Apply sets the scheduling constraints on the given pod spec.
+func (s Scheduling) Apply(spec *apiv1.PodSpec) {
+	if len(s.NodeSelector) != 0 {
+		spec.NodeSelector = make(map[string]string, len(s.NodeSelector))
+		for k, v := range s.NodeSelector {
+			spec.NodeSelector[k] = v
+		}
+	}
+
+	if len(s.Tolerations) != 0 {
+		spec.Tolerations = append([]apiv1.Toleration{}, s.Tolerations...)
+	}
+
+	if s.Affinity != nil {
+		spec.Affinity = s.Affinity.DeepCopy()
+	}
+}
+
+// SchedulingFlags are the raw values of the scheduling flags.
+type SchedulingFlags struct {
+	NodeSelector []string
+	Tolerations  []string
+	AffinityFile string
+}
+
+// BindSchedulingFlags binds the --node-selector, --toleration and --affinity-file
+// flags shared by the commands deploying core instances.
+func BindSchedulingFlags(fs *pflag.FlagSet, p *SchedulingFlags) {
+	fs.StringSliceVar(&p.NodeSelector, "node-selector", nil, "Node labels the core instance pods must be scheduled on, in the form of key=value")
+	fs.StringArrayVar(&p.Tolerations, "toleration", nil, "Taint the core instance pods tolerate, in the form of key[=value][:effect], ie: dedicated=calyptia:NoSchedule. Can be given multiple times")
+	fs.StringVar(&p.AffinityFile, "affinity-file", "", "YAML or JSON file with the kubernetes affinity of the core instance pods")
+}
+
+// Scheduling parses the flag values.
+func (f SchedulingFlags) Scheduling() (Scheduling, error) {
+	var out Scheduling
+
+	for _, pair := range f.NodeSelector {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return out, fmt.Errorf("invalid node selector %q, expected key=value", pair)
+		}
+
+		if out.NodeSelector == nil {
+			out.NodeSelector = map[string]string{}
+		}
+		out.NodeSelector[key] = value
+	}
+
+	for _, s := range f.Tolerations {
+		t, err := ParseToleration(s)
+		if err != nil {
+			return out, err
+		}
+
+		out.Tolerations = append(out.Tolerations, t)
+	}
+
+	if f.AffinityFile != "" {
+		b, err := os.ReadFile(f.AffinityFile)
+		if err != nil {
+			return out, fmt.Errorf("could not read affinity file: %w", err)
+		}
+
+		var affinity apiv1.Affinity
+		if err := yaml.UnmarshalStrict(b, &affinity); err != nil {
+			return out, fmt.Errorf("could not parse affinity file: %w", err)
+		}
+
+		out.Affinity = &affinity
+	}
+
+	return out, nil
+}
+
+// ParseToleration parses a toleration in the kubectl taint form key[=value][:effect].
+// Without value the toleration matches any value of the key,
+// and without effect it matches all the effects.
+func ParseToleration(s string) (apiv1.Toleration, error) {
+	var t apiv1.Toleration
+
+	spec, effect, hasEffect := strings.Cut(s, ":")
+	if hasEffect {
+		switch e := apiv1.TaintEffect(effect); e {
+		case apiv1.TaintEffectNoSchedule, apiv1.TaintEffectPreferNoSchedule, apiv1.TaintEffectNoExecute:
+			t.Effect = e
+		default:
+			return t, fmt.Errorf("invalid toleration %q, effect options: %s, %s, %s", s, apiv1.TaintEffectNoSchedule, apiv1.TaintEffectPreferNoSchedule, apiv1.TaintEffectNoExecute)
+		}
+	}
+
+	key, value, hasValue := strings.Cut(spec, "=")
+	if key == "" {
+		return t, fmt.Errorf("invalid toleration %q, expected key[=value][:effect]", s)
+	}
+
+	t.Key = key
+	if hasValue {
+		t.Operator = apiv1.TolerationOpEqual
+		t.Value = value
+	} else {
+		t.Operator = apiv1.TolerationOpExists
+	}
+
+	return t, nil
+}
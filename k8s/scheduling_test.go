@@ -0,0 +1,99 @@
+package k8s
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+func TestParseToleration(t *testing.T) {
+	tt := []struct {
+		in   string
+		want apiv1.Toleration
+	}{
+		{"dedicated=calyptia:NoSchedule", apiv1.Toleration{Key: "dedicated", Operator: apiv1.TolerationOpEqual, Value: "calyptia", Effect: apiv1.TaintEffectNoSchedule}},
+		{"dedicated:NoExecute", apiv1.Toleration{Key: "dedicated", Operator: apiv1.TolerationOpExists, Effect: apiv1.TaintEffectNoExecute}},
+		{"dedicated", apiv1.Toleration{Key: "dedicated", Operator: apiv1.TolerationOpExists}},
+	}
+	for _, tc := range tt {
+		got, err := ParseToleration(tc.in)
+		if err != nil {
+			t.Errorf("ParseToleration(%q): %v", tc.in, err)
+			continue
+		}
+
+		if got != tc.want {
+			t.Errorf("ParseToleration(%q) = %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+
+	for _, s := range []string{"", "=value", "dedicated:Never"} {
+		if _, err := ParseToleration(s); err == nil {
+			t.Errorf("expected ParseToleration(%q) to fail", s)
+		}
+	}
+}
+
+func TestSchedulingFlags_Scheduling(t *testing.T) {
+	affinityFile := filepath.Join(t.TempDir(), "affinity.yaml")
+	err := os.WriteFile(affinityFile, []byte(`nodeAffinity:
+  requiredDuringSchedulingIgnoredDuringExecution:
+    nodeSelectorTerms:
+    - matchExpressions:
+      - key: pool
+        operator: In
+        values: [calyptia]
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := SchedulingFlags{
+		NodeSelector: []string{"pool=calyptia"},
+		Tolerations:  []string{"dedicated=calyptia:NoSchedule"},
+		AffinityFile: affinityFile,
+	}.Scheduling()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.NodeSelector["pool"] != "calyptia" || len(got.Tolerations) != 1 || got.Affinity == nil || got.Affinity.NodeAffinity == nil {
+		t.Errorf("unexpected scheduling %+v", got)
+	}
+
+	if _, err := (SchedulingFlags{NodeSelector: []string{"pool"}}).Scheduling(); err == nil {
+		t.Error("expected invalid node selector to fail")
+	}
+
+	if _, err := (SchedulingFlags{AffinityFile: filepath.Join(t.TempDir(), "missing.yaml")}).Scheduling(); err == nil {
+		t.Error("expected missing affinity file to fail")
+	}
+}
+
+func TestDeployCoreOperatorSyncScheduling(t *testing.T) {
+	client := &Client{
+		Interface:  fake.NewSimpleClientset(),
+		Namespace:  "default",
+		LabelsFunc: func() map[string]string { return nil },
+		Scheduling: Scheduling{
+			NodeSelector: map[string]string{"pool": "calyptia"},
+			Tolerations:  []apiv1.Toleration{{Key: "dedicated", Operator: apiv1.TolerationOpExists}},
+		},
+	}
+
+	deploy, err := client.DeployCoreOperatorSync(context.TODO(), "https://cloud", "from", "to", "15334", false, "", "", cloud.CreatedCoreInstance{Name: "test", EnvironmentName: "default"}, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec := deploy.Spec.Template.Spec
+	if spec.NodeSelector["pool"] != "calyptia" || len(spec.Tolerations) != 1 || spec.Affinity != nil {
+		t.Errorf("unexpected pod scheduling: node selector %v, tolerations %v, affinity %v", spec.NodeSelector, spec.Tolerations, spec.Affinity)
+	}
+}
@@ -0,0 +1,124 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ResourcePermission is a single verb over a resource that a
+// service account is required to be allowed to perform.
+type ResourcePermission struct {
+	Group    string
+	Resource string
+	Verb     string
+}
+
+func (p ResourcePermission) String() string {
+	if p.Group == "" {
+		return fmt.Sprintf("%s %s", p.Verb, p.Resource)
+	}
+	return fmt.Sprintf("%s %s.%s", p.Verb, p.Resource, p.Group)
+}
+
+func permissions(group string, resources []string, verbs ...string) []ResourcePermission {
+	var out []ResourcePermission
+	for _, resource := range resources {
+		for _, verb := range verbs {
+			out = append(out, ResourcePermission{Group: group, Resource: resource, Verb: verb})
+		}
+	}
+	return out
+}
+
+func concatPermissions(perms ...[]ResourcePermission) []ResourcePermission {
+	var out []ResourcePermission
+	for _, p := range perms {
+		out = append(out, p...)
+	}
+	return out
+}
+
+// CoreInstanceRequiredPermissions are the permissions a pre-provisioned
+// service account needs to run a core instance.
+var CoreInstanceRequiredPermissions = concatPermissions(
+	permissions("", []string{"pods", "services", "configmaps", "secrets"}, "get", "list", "watch", "create", "update", "delete"),
+	permissions("apps", []string{"deployments", "daemonsets"}, "get", "list", "watch", "create", "update", "delete"),
+	permissions("core.calyptia.com", []string{"pipelines"}, "get", "list", "watch", "create", "update", "delete"),
+)
+
+// OperatorRequiredPermissions are the permissions a pre-provisioned
+// service account needs to run the core operator manager.
+var OperatorRequiredPermissions = concatPermissions(
+	CoreInstanceRequiredPermissions,
+	permissions("", []string{"namespaces", "serviceaccounts"}, "get", "list", "watch", "create", "update", "delete"),
+	permissions("batch", []string{"jobs"}, "get", "list", "watch", "create", "update", "delete"),
+	permissions("rbac.authorization.k8s.io", []string{"clusterrolebindings"}, "get", "list", "watch", "create", "update", "delete"),
+	permissions("core.calyptia.com", []string{"ingestchecks"}, "get", "list", "watch", "create", "update", "delete"),
+)
+
+// MissingPermissionsError is returned when a service account
+// is not allowed to perform some of the required actions.
+type MissingPermissionsError struct {
+	ServiceAccount string
+	Missing        []ResourcePermission
+}
+
+func (e *MissingPermissionsError) Error() string {
+	missing := make([]string, len(e.Missing))
+	for i, p := range e.Missing {
+		missing[i] = p.String()
+	}
+	return fmt.Sprintf("service account %q is missing permissions: %s", e.ServiceAccount, strings.Join(missing, ", "))
+}
+
+// ValidateServiceAccount checks that the given service account exists on the client
+// namespace and that it is allowed to perform the required actions.
+// When clusterWide is set, permissions are checked across all namespaces
+// instead of just the client namespace.
+func (client *Client) ValidateServiceAccount(ctx context.Context, name string, clusterWide bool, required []ResourcePermission) (*apiv1.ServiceAccount, error) {
+	sa, err := client.CoreV1().ServiceAccounts(client.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("could not get service account %q: %w", name, err)
+	}
+
+	namespace := client.Namespace
+	if clusterWide {
+		namespace = ""
+	}
+
+	user := fmt.Sprintf("system:serviceaccount:%s:%s", sa.Namespace, sa.Name)
+	groups := []string{"system:serviceaccounts", "system:serviceaccounts:" + sa.Namespace}
+	var missing []ResourcePermission
+	for _, p := range required {
+		review, err := client.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				User:   user,
+				Groups: groups,
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace: namespace,
+					Group:     p.Group,
+					Resource:  p.Resource,
+					Verb:      p.Verb,
+				},
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("could not review %q access: %w", p, err)
+		}
+
+		if !review.Status.Allowed {
+			missing = append(missing, p)
+		}
+	}
+
+	if len(missing) != 0 {
+		return nil, &MissingPermissionsError{ServiceAccount: name, Missing: missing}
+	}
+
+	return sa, nil
+}
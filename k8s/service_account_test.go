@@ -0,0 +1,66 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestValidateServiceAccount(t *testing.T) {
+	sa := &apiv1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "irsa", Namespace: "default"}}
+	required := []ResourcePermission{
+		{Resource: "pods", Verb: "get"},
+		{Group: "apps", Resource: "deployments", Verb: "create"},
+	}
+
+	newClient := func(allowed func(attrs *authorizationv1.ResourceAttributes) bool) *Client {
+		clientSet := fake.NewSimpleClientset(sa)
+		clientSet.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+			if review.Spec.User != "system:serviceaccount:default:irsa" {
+				t.Errorf("unexpected user %q", review.Spec.User)
+			}
+			review.Status.Allowed = allowed(review.Spec.ResourceAttributes)
+			return true, review, nil
+		})
+		return &Client{Interface: clientSet, Namespace: "default"}
+	}
+
+	t.Run("allowed", func(t *testing.T) {
+		client := newClient(func(*authorizationv1.ResourceAttributes) bool { return true })
+		got, err := client.ValidateServiceAccount(context.TODO(), "irsa", false, required)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Name != "irsa" {
+			t.Errorf("unexpected service account %q", got.Name)
+		}
+	})
+
+	t.Run("missing permissions", func(t *testing.T) {
+		client := newClient(func(attrs *authorizationv1.ResourceAttributes) bool { return attrs.Group == "" })
+		_, err := client.ValidateServiceAccount(context.TODO(), "irsa", false, required)
+
+		var e *MissingPermissionsError
+		if !errors.As(err, &e) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(e.Missing) != 1 || e.Missing[0] != required[1] {
+			t.Errorf("unexpected missing permissions %v", e.Missing)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		client := newClient(func(*authorizationv1.ResourceAttributes) bool { return true })
+		if _, err := client.ValidateServiceAccount(context.TODO(), "nope", false, required); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ObjectRef references a namespaced object as NAMESPACE/NAME.
+type ObjectRef struct {
+	Namespace string
+	Name      string
+}
+
+func (ref ObjectRef) String() string {
+	return ref.Namespace + "/" + ref.Name
+}
+
+// ParseObjectRef parses a NAMESPACE/NAME reference. When the namespace
+// is omitted the given default one is used.
+func ParseObjectRef(s, defaultNamespace string) (ObjectRef, error) {
+	ns, name, ok := strings.Cut(s, "/")
+	if !ok {
+		ns, name = defaultNamespace, s
+	}
+
+	if ns == "" || name == "" || strings.Contains(name, "/") {
+		return ObjectRef{}, fmt.Errorf("invalid object reference %q, expected NAMESPACE/NAME", s)
+	}
+
+	return ObjectRef{Namespace: ns, Name: name}, nil
+}
+
+// ConfigMapData returns the entries of the ConfigMap, both data and binary data, by key.
+func (client *Client) ConfigMapData(ctx context.Context, ref ObjectRef) (map[string][]byte, error) {
+	cm, err := client.CoreV1().ConfigMaps(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("could not get configmap %s: %w", ref, err)
+	}
+
+	out := make(map[string][]byte, len(cm.Data)+len(cm.BinaryData))
+	for k, v := range cm.Data {
+		out[k] = []byte(v)
+	}
+	for k, v := range cm.BinaryData {
+		out[k] = v
+	}
+
+	return out, nil
+}
+
+// SecretData returns the decoded entries of the Secret by key.
+func (client *Client) SecretData(ctx context.Context, ref ObjectRef) (map[string][]byte, error) {
+	secret, err := client.CoreV1().Secrets(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("could not get secret %s: %w", ref, err)
+	}
+
+	out := make(map[string][]byte, len(secret.Data)+len(secret.StringData))
+	for k, v := range secret.Data {
+		out[k] = v
+	}
+	for k, v := range secret.StringData {
+		out[k] = []byte(v)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,76 @@
+package k8s
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseObjectRef(t *testing.T) {
+	tt := []struct {
+		in      string
+		want    ObjectRef
+		wantErr bool
+	}{
+		{in: "logging/parsers", want: ObjectRef{Namespace: "logging", Name: "parsers"}},
+		{in: "parsers", want: ObjectRef{Namespace: "default", Name: "parsers"}},
+		{in: "logging/", wantErr: true},
+		{in: "/parsers", wantErr: true},
+		{in: "a/b/c", wantErr: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParseObjectRef(tc.in, "default")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if got != tc.want {
+				t.Errorf("want %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestClient_ConfigMapData(t *testing.T) {
+	client := &Client{Interface: fake.NewSimpleClientset(&apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "parsers", Namespace: "logging"},
+		Data:       map[string]string{"parsers.conf": "[PARSER]"},
+		BinaryData: map[string][]byte{"filter.wasm": {0x00, 'a', 's', 'm'}},
+	})}
+
+	got, err := client.ConfigMapData(context.Background(), ObjectRef{Namespace: "logging", Name: "parsers"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]byte{"parsers.conf": []byte("[PARSER]"), "filter.wasm": {0x00, 'a', 's', 'm'}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	if _, err := client.ConfigMapData(context.Background(), ObjectRef{Namespace: "default", Name: "parsers"}); err == nil {
+		t.Error("expected error on missing configmap")
+	}
+}
+
+func TestClient_SecretData(t *testing.T) {
+	client := &Client{Interface: fake.NewSimpleClientset(&apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "logging"},
+		Data:       map[string][]byte{"token": []byte("s3cr3t")},
+	})}
+
+	got, err := client.SecretData(context.Background(), ObjectRef{Namespace: "logging", Name: "creds"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := map[string][]byte{"token": []byte("s3cr3t")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
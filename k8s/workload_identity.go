@@ -0,0 +1,50 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Annotations used by cloud providers to link a kubernetes service account
+// to a cloud identity.
+const (
+	AnnotationAWSRoleARN          = "eks.amazonaws.com/role-arn"
+	AnnotationGCPServiceAccount   = "iam.gke.io/gcp-service-account"
+	gcpServiceAccountEmailPostfix = ".iam.gserviceaccount.com"
+)
+
+// WorkloadIdentity holds the cloud identities a generated service account
+// is linked to so pipelines can use workload identity instead of static keys.
+type WorkloadIdentity struct {
+	// AWSRoleARN is the IAM role to assume through IRSA.
+	AWSRoleARN string
+	// GCPServiceAccount is the IAM service account email to impersonate
+	// through GKE Workload Identity.
+	GCPServiceAccount string
+}
+
+// Validate the workload identity values format.
+func (wi WorkloadIdentity) Validate() error {
+	if wi.AWSRoleARN != "" && !(strings.HasPrefix(wi.AWSRoleARN, "arn:") && strings.Contains(wi.AWSRoleARN, ":role/")) {
+		return fmt.Errorf("invalid aws role arn %q", wi.AWSRoleARN)
+	}
+	if wi.GCPServiceAccount != "" && !strings.HasSuffix(wi.GCPServiceAccount, gcpServiceAccountEmailPostfix) {
+		return fmt.Errorf("invalid gcp service account %q, expected an email ending with %q", wi.GCPServiceAccount, gcpServiceAccountEmailPostfix)
+	}
+	return nil
+}
+
+// Annotations to set on the service account.
+func (wi WorkloadIdentity) Annotations() map[string]string {
+	out := map[string]string{}
+	if wi.AWSRoleARN != "" {
+		out[AnnotationAWSRoleARN] = wi.AWSRoleARN
+	}
+	if wi.GCPServiceAccount != "" {
+		out[AnnotationGCPServiceAccount] = wi.GCPServiceAccount
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,62 @@
+package k8s
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+func TestWorkloadIdentity_Validate(t *testing.T) {
+	tt := []struct {
+		name    string
+		wi      WorkloadIdentity
+		wantErr bool
+	}{
+		{name: "empty"},
+		{name: "aws", wi: WorkloadIdentity{AWSRoleARN: "arn:aws:iam::123456789012:role/core"}},
+		{name: "gcp", wi: WorkloadIdentity{GCPServiceAccount: "core@project.iam.gserviceaccount.com"}},
+		{name: "aws not a role", wi: WorkloadIdentity{AWSRoleARN: "arn:aws:iam::123456789012:user/core"}, wantErr: true},
+		{name: "aws not an arn", wi: WorkloadIdentity{AWSRoleARN: "core"}, wantErr: true},
+		{name: "gcp not an email", wi: WorkloadIdentity{GCPServiceAccount: "core@example.com"}, wantErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.wi.Validate(); (err != nil) != tc.wantErr {
+				t.Errorf("want error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestClient_CreateServiceAccount_workloadIdentity(t *testing.T) {
+	client := &Client{
+		Interface:  fake.NewSimpleClientset(),
+		Namespace:  "default",
+		LabelsFunc: func() map[string]string { return nil },
+		WorkloadIdentity: WorkloadIdentity{
+			AWSRoleARN:        "arn:aws:iam::123456789012:role/core",
+			GCPServiceAccount: "core@project.iam.gserviceaccount.com",
+		},
+	}
+
+	sa, err := client.CreateServiceAccount(context.TODO(), cloud.CreatedCoreInstance{ID: "core-1", Name: "core"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		AnnotationAWSRoleARN:        "arn:aws:iam::123456789012:role/core",
+		AnnotationGCPServiceAccount: "core@project.iam.gserviceaccount.com",
+	}
+	if !reflect.DeepEqual(sa.Annotations, want) {
+		t.Errorf("want annotations %v, got %v", want, sa.Annotations)
+	}
+
+	if got := (WorkloadIdentity{}).Annotations(); got != nil {
+		t.Errorf("want no annotations without a workload identity, got %v", got)
+	}
+}
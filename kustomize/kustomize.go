@@ -0,0 +1,259 @@
+// Package kustomize generates a kustomize base from kubernetes manifests
+// along with a skeleton overlay per environment, so they can be managed
+// with GitOps tooling standardized on kustomize.
+package kustomize
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	apiVersion        = "kustomize.config.k8s.io/v1beta1"
+	kind              = "Kustomization"
+	kustomizationFile = "kustomization.yaml"
+	resourcesPatch    = "resources-patch.yaml"
+)
+
+// DefaultOverlays are the environments an overlay is generated for by default.
+var DefaultOverlays = []string{"dev", "prod"}
+
+// Resource is a manifest file, holding one or more kubernetes objects,
+// to include in the base.
+type Resource struct {
+	File    string
+	Content string
+}
+
+type kustomization struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Namespace  string   `json:"namespace,omitempty"`
+	Resources  []string `json:"resources"`
+	Images     []image  `json:"images,omitempty"`
+	Patches    []patch  `json:"patches,omitempty"`
+}
+
+type image struct {
+	Name   string `json:"name"`
+	NewTag string `json:"newTag,omitempty"`
+}
+
+type patch struct {
+	Path string `json:"path"`
+}
+
+type object struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace"`
+	} `yaml:"metadata"`
+	Spec struct {
+		Template struct {
+			Spec struct {
+				Containers []struct {
+					Name  string `yaml:"name"`
+					Image string `yaml:"image"`
+				} `yaml:"containers"`
+			} `yaml:"spec"`
+		} `yaml:"template"`
+	} `yaml:"spec"`
+}
+
+// Write generates the base into dir/base and an overlay per given
+// environment into dir/overlays/<env>. Overlays set the namespace, pin the
+// image tags and patch the deployments resources; they are meant to be edited.
+// The written files are returned.
+func Write(dir, namespace string, resources []Resource, overlays []string) ([]string, error) {
+	if len(resources) == 0 {
+		return nil, errors.New("no resources to generate kustomization from")
+	}
+
+	for _, env := range overlays {
+		if env == "" || env != filepath.Base(env) || strings.HasPrefix(env, ".") {
+			return nil, fmt.Errorf("invalid overlay name %q", env)
+		}
+	}
+
+	var objects []object
+	base := kustomization{APIVersion: apiVersion, Kind: kind}
+	files := map[string]string{}
+	for _, r := range resources {
+		oo, err := decodeObjects(r.Content)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %s: %w", r.File, err)
+		}
+
+		objects = append(objects, oo...)
+		base.Resources = append(base.Resources, r.File)
+		files[filepath.Join("base", r.File)] = r.Content
+	}
+
+	b, err := yaml.Marshal(base)
+	if err != nil {
+		return nil, err
+	}
+	files[filepath.Join("base", kustomizationFile)] = string(b)
+
+	images := imagesOf(objects)
+	resourcesPatchContent, err := deploymentsResourcesPatch(objects)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, env := range overlays {
+		overlay := kustomization{
+			APIVersion: apiVersion,
+			Kind:       kind,
+			Namespace:  namespace,
+			Resources:  []string{"../../base"},
+			Images:     images,
+		}
+		if resourcesPatchContent != "" {
+			overlay.Patches = []patch{{Path: resourcesPatch}}
+			files[filepath.Join("overlays", env, resourcesPatch)] = resourcesPatchContent
+		}
+
+		b, err := yaml.Marshal(overlay)
+		if err != nil {
+			return nil, err
+		}
+		files[filepath.Join("overlays", env, kustomizationFile)] = string(b)
+	}
+
+	var written []string
+	for _, name := range sortedKeys(files) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return written, fmt.Errorf("could not create kustomize directory: %w", err)
+		}
+
+		// base manifests can hold secrets, so keep them private to the user.
+		if err := os.WriteFile(path, []byte(files[name]), 0o600); err != nil {
+			return written, fmt.Errorf("could not write %s: %w", name, err)
+		}
+		written = append(written, path)
+	}
+
+	return written, nil
+}
+
+func decodeObjects(content string) ([]object, error) {
+	var out []object
+	dec := yamlv3.NewDecoder(strings.NewReader(content))
+	for {
+		var o object
+		err := dec.Decode(&o)
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if o.Kind != "" {
+			out = append(out, o)
+		}
+	}
+}
+
+// imagesOf returns the images used by the deployments containers
+// with their current tag, in order of appearance.
+func imagesOf(objects []object) []image {
+	var out []image
+	seen := map[string]bool{}
+	for _, o := range objects {
+		if o.Kind != "Deployment" {
+			continue
+		}
+
+		for _, c := range o.Spec.Template.Spec.Containers {
+			name, tag := splitImage(c.Image)
+			if name == "" || seen[name] {
+				continue
+			}
+
+			seen[name] = true
+			out = append(out, image{Name: name, NewTag: tag})
+		}
+	}
+	return out
+}
+
+func splitImage(s string) (name, tag string) {
+	if i := strings.LastIndex(s, ":"); i != -1 && !strings.Contains(s[i:], "/") {
+		return s[:i], s[i+1:]
+	}
+	return s, ""
+}
+
+// deploymentsResourcesPatch returns a strategic merge patch setting
+// placeholder resource requests and limits on every deployment container.
+func deploymentsResourcesPatch(objects []object) (string, error) {
+	var docs []string
+	for _, o := range objects {
+		if o.Kind != "Deployment" || len(o.Spec.Template.Spec.Containers) == 0 {
+			continue
+		}
+
+		containers := make([]any, 0, len(o.Spec.Template.Spec.Containers))
+		for _, c := range o.Spec.Template.Spec.Containers {
+			containers = append(containers, map[string]any{
+				"name": c.Name,
+				"resources": map[string]any{
+					"requests": map[string]string{"cpu": "100m", "memory": "128Mi"},
+					"limits":   map[string]string{"memory": "512Mi"},
+				},
+			})
+		}
+
+		metadata := map[string]string{"name": o.Metadata.Name}
+		if o.Metadata.Namespace != "" {
+			metadata["namespace"] = o.Metadata.Namespace
+		}
+
+		b, err := yaml.Marshal(map[string]any{
+			"apiVersion": o.APIVersion,
+			"kind":       o.Kind,
+			"metadata":   metadata,
+			"spec": map[string]any{
+				"template": map[string]any{
+					"spec": map[string]any{"containers": containers},
+				},
+			},
+		})
+		if err != nil {
+			return "", err
+		}
+		docs = append(docs, string(b))
+	}
+
+	var buf bytes.Buffer
+	for i, doc := range docs {
+		if i != 0 {
+			buf.WriteString("---\n")
+		}
+		buf.WriteString(doc)
+	}
+	return buf.String(), nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,93 @@
+package kustomize
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const deployment = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: calyptia
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: manager
+  namespace: calyptia
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        image: ghcr.io/calyptia/core-operator:v1.2.3
+      - name: proxy
+        image: localhost:5000/proxy
+`
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	files, err := Write(dir, "calyptia", []Resource{{File: "manifest.yaml", Content: deployment}}, []string{"dev", "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"base/kustomization.yaml",
+		"base/manifest.yaml",
+		"overlays/dev/kustomization.yaml",
+		"overlays/dev/resources-patch.yaml",
+		"overlays/prod/kustomization.yaml",
+		"overlays/prod/resources-patch.yaml",
+	}
+	if len(files) != len(want) {
+		t.Fatalf("want %d files, got %v", len(want), files)
+	}
+	for i, f := range want {
+		if files[i] != filepath.Join(dir, f) {
+			t.Errorf("want %s, got %s", f, files[i])
+		}
+	}
+
+	read := func(name string) string {
+		t.Helper()
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	if got := read("base/kustomization.yaml"); !strings.Contains(got, "- manifest.yaml") {
+		t.Errorf("base does not include the manifest:\n%s", got)
+	}
+
+	overlay := read("overlays/dev/kustomization.yaml")
+	for _, s := range []string{
+		"namespace: calyptia",
+		"- ../../base",
+		"name: ghcr.io/calyptia/core-operator\n  newTag: v1.2.3",
+		"name: localhost:5000/proxy\n",
+		"path: resources-patch.yaml",
+	} {
+		if !strings.Contains(overlay, s) {
+			t.Errorf("overlay does not contain %q:\n%s", s, overlay)
+		}
+	}
+
+	patch := read("overlays/prod/resources-patch.yaml")
+	for _, s := range []string{"name: manager", "name: proxy", "namespace: calyptia", "memory: 128Mi"} {
+		if !strings.Contains(patch, s) {
+			t.Errorf("patch does not contain %q:\n%s", s, patch)
+		}
+	}
+}
+
+func TestWrite_invalidOverlay(t *testing.T) {
+	_, err := Write(t.TempDir(), "", []Resource{{File: "manifest.yaml", Content: deployment}}, []string{"../escape"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
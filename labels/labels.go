@@ -0,0 +1,150 @@
+// Package labels stores key/value labels on Calyptia Cloud resources as
+// "key=value" tags and selects resources by them, in a similar fashion to
+// kubernetes label selectors.
+package labels
+
+import (
+	"fmt"
+	"strings"
+)
+
+const separator = "="
+
+// Parse validates the given labels in the form of key=value
+// and returns them as tags.
+func Parse(pairs []string) ([]string, error) {
+	out := make([]string, 0, len(pairs))
+	seen := map[string]bool{}
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, separator)
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid label %q, expected key=value", pair)
+		}
+
+		if seen[key] {
+			return nil, fmt.Errorf("label %q given more than once", key)
+		}
+
+		seen[key] = true
+		out = append(out, key+separator+strings.TrimSpace(value))
+	}
+	return out, nil
+}
+
+// FromTags returns the labels found in the given tags.
+// Tags not in the form of key=value are labels with an empty value.
+func FromTags(tags []string) map[string]string {
+	out := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		key, value, _ := strings.Cut(tag, separator)
+		out[key] = value
+	}
+	return out
+}
+
+// Merge sets the given labels on top of the existing tags.
+// Existing labels with the same key get replaced.
+func Merge(tags, labels []string) []string {
+	keys := FromTags(labels)
+
+	var out []string
+	for _, tag := range tags {
+		key, _, _ := strings.Cut(tag, separator)
+		if _, ok := keys[key]; !ok {
+			out = append(out, tag)
+		}
+	}
+	return append(out, labels...)
+}
+
+type operator string
+
+const (
+	opEquals    operator = "="
+	opNotEquals operator = "!="
+	opExists    operator = "exists"
+	opNotExists operator = "!exists"
+)
+
+type requirement struct {
+	key   string
+	op    operator
+	value string
+}
+
+func (r requirement) matches(labels map[string]string) bool {
+	value, ok := labels[r.key]
+	switch r.op {
+	case opEquals:
+		return ok && value == r.value
+	case opNotEquals:
+		return !ok || value != r.value
+	case opExists:
+		return ok
+	case opNotExists:
+		return !ok
+	}
+	return false
+}
+
+// Selector is a set of requirements that labels must all satisfy.
+// An empty selector matches everything.
+type Selector []requirement
+
+// ParseSelector parses a comma separated list of requirements.
+// Supported requirements are: key=value, key==value, key!=value,
+// key (label exists) and !key (label does not exist).
+func ParseSelector(s string) (Selector, error) {
+	var out Selector
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var r requirement
+		switch {
+		case strings.Contains(part, "!="):
+			r.key, r.value, _ = strings.Cut(part, "!=")
+			r.op = opNotEquals
+		case strings.Contains(part, "=="):
+			r.key, r.value, _ = strings.Cut(part, "==")
+			r.op = opEquals
+		case strings.Contains(part, separator):
+			r.key, r.value, _ = strings.Cut(part, separator)
+			r.op = opEquals
+		case strings.HasPrefix(part, "!"):
+			r.key = strings.TrimPrefix(part, "!")
+			r.op = opNotExists
+		default:
+			r.key = part
+			r.op = opExists
+		}
+
+		r.key = strings.TrimSpace(r.key)
+		r.value = strings.TrimSpace(r.value)
+		if r.key == "" {
+			return nil, fmt.Errorf("invalid selector requirement %q: missing key", part)
+		}
+
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// Matches reports whether the labels found in the given tags satisfy
+// all the selector requirements.
+func (s Selector) Matches(tags []string) bool {
+	if len(s) == 0 {
+		return true
+	}
+
+	labels := FromTags(tags)
+	for _, r := range s {
+		if !r.matches(labels) {
+			return false
+		}
+	}
+	return true
+}
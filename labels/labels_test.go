@@ -0,0 +1,69 @@
+package labels
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	got, err := Parse([]string{"team=infra", " env = prod ", "empty="})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"team=infra", "env=prod", "empty="}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	for _, invalid := range [][]string{{"novalue"}, {"=value"}, {"a=1", "a=2"}} {
+		if _, err := Parse(invalid); err == nil {
+			t.Errorf("expected error for %v", invalid)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	got := Merge([]string{"legacy", "team=infra", "env=dev"}, []string{"env=prod"})
+	want := []string{"legacy", "team=infra", "env=prod"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestSelector_Matches(t *testing.T) {
+	tags := []string{"legacy", "team=infra", "env=prod"}
+
+	tt := []struct {
+		selector string
+		want     bool
+	}{
+		{selector: "", want: true},
+		{selector: "team=infra", want: true},
+		{selector: "team==infra,env=prod", want: true},
+		{selector: "team=infra,env=dev", want: false},
+		{selector: "env!=dev", want: true},
+		{selector: "region!=us", want: true},
+		{selector: "legacy", want: true},
+		{selector: "region", want: false},
+		{selector: "!region", want: true},
+		{selector: "!team", want: false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.selector, func(t *testing.T) {
+			s, err := ParseSelector(tc.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := s.Matches(tags); got != tc.want {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+
+	if _, err := ParseSelector("=value"); err == nil {
+		t.Error("expected error for missing key")
+	}
+}
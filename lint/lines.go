@@ -0,0 +1,167 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	fluentbitconfig "github.com/calyptia/go-fluentbit-config/v2"
+)
+
+var (
+	classicHeaderPattern = regexp.MustCompile(`^\s*\[\s*([A-Za-z]+)\s*\]\s*$`)
+	yamlKeyPattern       = regexp.MustCompile(`^(\s*)(customs|inputs|filters|outputs|parsers|[A-Za-z_][\w.-]*)\s*:\s*$`)
+	yamlItemPattern      = regexp.MustCompile(`^(\s*)-\s`)
+	nameValuePattern     = regexp.MustCompile(`(?i)^\s*(?:-\s*)?name\s*[:\s]\s*["']?([^"'\s]+)`)
+)
+
+// sectionLines maps each namespaced section to the line it starts at.
+// The fluent-bit config parser does not keep track of lines,
+// so they are located on a best-effort basis; unknown sections map to zero.
+func sectionLines(raw string, format fluentbitconfig.Format) map[string]uint {
+	return namespacedLines(sectionStarts(raw, format))
+}
+
+// sectionStarts locates the sections of the raw configuration.
+func sectionStarts(raw string, format fluentbitconfig.Format) []*sectionStart {
+	switch strings.ToLower(string(format)) {
+	case "", "ini", "conf", "classic":
+		return classicSections(raw)
+	case "yml", "yaml":
+		return yamlSections(raw)
+	default:
+		return nil
+	}
+}
+
+type sectionStart struct {
+	kind  fluentbitconfig.SectionKind
+	index int
+	line  uint
+	name  string
+	// end is the last line of the section, and indent the column
+	// its properties start at; zero for the classic format.
+	end    uint
+	indent int
+}
+
+func classicSections(raw string) []*sectionStart {
+	lines := strings.Split(raw, "\n")
+	counts := map[fluentbitconfig.SectionKind]int{}
+
+	var starts []*sectionStart
+	var current *sectionStart
+	for i, line := range lines {
+		if m := classicHeaderPattern.FindStringSubmatch(line); m != nil {
+			if current != nil {
+				current.end = uint(i)
+			}
+			kind := fluentbitconfig.SectionKind(strings.ToLower(m[1]))
+			current = &sectionStart{kind: kind, index: counts[kind], line: uint(i + 1)}
+			counts[kind]++
+			starts = append(starts, current)
+			continue
+		}
+
+		if current != nil && current.name == "" {
+			if m := nameValuePattern.FindStringSubmatch(line); m != nil {
+				current.name = strings.ToLower(m[1])
+			}
+		}
+	}
+	if current != nil {
+		current.end = uint(len(lines))
+	}
+
+	return starts
+}
+
+func yamlSections(raw string) []*sectionStart {
+	lines := strings.Split(raw, "\n")
+	counts := map[fluentbitconfig.SectionKind]int{}
+
+	var starts []*sectionStart
+	var current *sectionStart
+	var kind fluentbitconfig.SectionKind
+	itemIndent := -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		if m := yamlKeyPattern.FindStringSubmatch(line); m != nil {
+			indent := len(m[1])
+			if kind != "" && itemIndent != -1 && indent > itemIndent {
+				// nested key within a section, ie: processors.
+				continue
+			}
+
+			kind = yamlListKind(m[2])
+			itemIndent = -1
+			if current != nil {
+				current.end = uint(i)
+			}
+			current = nil
+			continue
+		}
+
+		if kind == "" {
+			continue
+		}
+
+		if m := yamlItemPattern.FindStringSubmatch(line); m != nil {
+			indent := len(m[1])
+			if itemIndent == -1 {
+				itemIndent = indent
+			}
+			if indent == itemIndent {
+				if current != nil {
+					current.end = uint(i)
+				}
+				rest := line[indent+1:]
+				current = &sectionStart{kind: kind, index: counts[kind], line: uint(i + 1), indent: len(line) - len(strings.TrimLeft(rest, " \t"))}
+				counts[kind]++
+				starts = append(starts, current)
+			}
+		}
+
+		if current != nil && current.name == "" {
+			if m := nameValuePattern.FindStringSubmatch(line); m != nil {
+				current.name = strings.ToLower(m[1])
+			}
+		}
+	}
+	if current != nil {
+		current.end = uint(len(lines))
+	}
+
+	return starts
+}
+
+func yamlListKind(key string) fluentbitconfig.SectionKind {
+	switch strings.ToLower(key) {
+	case "customs":
+		return fluentbitconfig.SectionKindCustom
+	case "inputs":
+		return fluentbitconfig.SectionKindInput
+	case "filters":
+		return fluentbitconfig.SectionKindFilter
+	case "outputs":
+		return fluentbitconfig.SectionKindOutput
+	case "parsers":
+		return fluentbitconfig.SectionKindParser
+	default:
+		return ""
+	}
+}
+
+func namespacedLines(starts []*sectionStart) map[string]uint {
+	out := map[string]uint{}
+	for _, s := range starts {
+		if s.name == "" {
+			continue
+		}
+		out[fmt.Sprintf("%s:%s:%s.%d", s.kind, s.name, s.name, s.index)] = s.line
+	}
+	return out
+}
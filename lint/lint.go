@@ -0,0 +1,293 @@
+// Package lint checks fluent-bit pipeline configurations against
+// best-practice rules and user provided rule packs.
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	fluentbitconfig "github.com/calyptia/go-fluentbit-config/v2"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityNote    Severity = "note"
+)
+
+// Finding is a single rule violation.
+type Finding struct {
+	RuleID   string   `json:"ruleID" yaml:"ruleID"`
+	Severity Severity `json:"severity" yaml:"severity"`
+	Message  string   `json:"message" yaml:"message"`
+	// Section namespaced with the section kind and plugin name.
+	// For example: input:tail:tail.0
+	Section string `json:"section,omitempty" yaml:"section,omitempty"`
+	Line    uint   `json:"line,omitempty" yaml:"line,omitempty"`
+}
+
+// Section of a configuration as seen by the rules.
+type Section struct {
+	Kind   fluentbitconfig.SectionKind
+	Plugin fluentbitconfig.Plugin
+	// Index of the section within those of the same kind.
+	Index int
+}
+
+func (s Section) String() string {
+	return fmt.Sprintf("%s:%s:%s", s.Kind, s.Plugin.Name, s.Plugin.ID)
+}
+
+// Rule checks a whole configuration.
+type Rule struct {
+	ID          string
+	Description string
+	Severity    Severity
+	Check       func(conf fluentbitconfig.Config) []Finding
+}
+
+// BuiltinRules are the best-practice checks always run by the linter.
+var BuiltinRules = []Rule{
+	{
+		ID:          "mem-buf-limit",
+		Description: "Inputs should set mem_buf_limit or use filesystem storage to bound memory usage under backpressure.",
+		Severity:    SeverityWarning,
+		Check:       checkMemBufLimit,
+	},
+	{
+		ID:          "retry-limit",
+		Description: "Outputs should configure retry_limit so failed chunks are not retried indefinitely or dropped silently.",
+		Severity:    SeverityWarning,
+		Check:       checkRetryLimit,
+	},
+	{
+		ID:          "plaintext-credentials",
+		Description: "Credentials should be referenced from pipeline secrets or environment variables instead of written in plain text.",
+		Severity:    SeverityError,
+		Check:       checkPlaintextCredentials,
+	},
+	{
+		ID:          "tag-collision",
+		Description: "Inputs should not share the same tag, otherwise their records cannot be routed separately.",
+		Severity:    SeverityWarning,
+		Check:       checkTagCollisions,
+	},
+	{
+		ID:          "wasm-filter",
+		Description: "WASM filters should set function_name and load their module from a pipeline file referenced as {{files.NAME}}.",
+		Severity:    SeverityError,
+		Check:       checkWASMFilters,
+	},
+}
+
+// Lint parses the raw configuration and runs the built-in rules
+// followed by the given rules against it.
+func Lint(raw string, format fluentbitconfig.Format, rules ...Rule) ([]Finding, error) {
+	conf, err := fluentbitconfig.ParseAs(raw, format)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse config: %w", err)
+	}
+
+	lines := sectionLines(raw, format)
+
+	var out []Finding
+	for _, rule := range append(append([]Rule{}, BuiltinRules...), rules...) {
+		for _, f := range rule.Check(conf) {
+			f.RuleID = rule.ID
+			if f.Severity == "" {
+				f.Severity = rule.Severity
+			}
+			if f.Line == 0 {
+				f.Line = lines[f.Section]
+			}
+			out = append(out, f)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Line < out[j].Line
+	})
+
+	return out, nil
+}
+
+// Sections returns the custom, input, filter and output sections
+// of the configuration in order.
+func Sections(conf fluentbitconfig.Config) []Section {
+	var out []Section
+	add := func(kind fluentbitconfig.SectionKind, plugins fluentbitconfig.Plugins) {
+		for i, p := range plugins {
+			out = append(out, Section{Kind: kind, Plugin: p, Index: i})
+		}
+	}
+
+	add(fluentbitconfig.SectionKindCustom, conf.Customs)
+	add(fluentbitconfig.SectionKindInput, conf.Pipeline.Inputs)
+	add(fluentbitconfig.SectionKindFilter, conf.Pipeline.Filters)
+	add(fluentbitconfig.SectionKindOutput, conf.Pipeline.Outputs)
+
+	return out
+}
+
+// HasErrors reports whether any finding has error severity.
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func checkMemBufLimit(conf fluentbitconfig.Config) []Finding {
+	var out []Finding
+	for i, p := range conf.Pipeline.Inputs {
+		if p.Properties.Has("mem_buf_limit") {
+			continue
+		}
+
+		if v, ok := p.Properties.Get("storage.type"); ok && strings.EqualFold(stringValue(v), "filesystem") {
+			continue
+		}
+
+		sec := Section{Kind: fluentbitconfig.SectionKindInput, Plugin: p, Index: i}
+		out = append(out, Finding{
+			Message: fmt.Sprintf("input %q does not set mem_buf_limit", p.Name),
+			Section: sec.String(),
+		})
+	}
+	return out
+}
+
+func checkRetryLimit(conf fluentbitconfig.Config) []Finding {
+	var out []Finding
+	for i, p := range conf.Pipeline.Outputs {
+		if p.Properties.Has("retry_limit") {
+			continue
+		}
+
+		sec := Section{Kind: fluentbitconfig.SectionKindOutput, Plugin: p, Index: i}
+		out = append(out, Finding{
+			Message: fmt.Sprintf("output %q does not set retry_limit", p.Name),
+			Section: sec.String(),
+		})
+	}
+	return out
+}
+
+var (
+	credentialKeyPattern = regexp.MustCompile(`(?i)(passw(or)?d|passwd|secret|token|api_?key|access_?key|credential|auth_?header)`)
+	// references to cloud secrets or environment variables are fine.
+	credentialRefPattern = regexp.MustCompile(`\{\{\s*secrets\.|\$\{[^}]+\}`)
+)
+
+func checkPlaintextCredentials(conf fluentbitconfig.Config) []Finding {
+	var out []Finding
+	for _, sec := range Sections(conf) {
+		for _, p := range sec.Plugin.Properties {
+			if !credentialKeyPattern.MatchString(p.Key) {
+				continue
+			}
+
+			v := stringValue(p.Value)
+			if v == "" || credentialRefPattern.MatchString(v) {
+				continue
+			}
+
+			// file paths to credentials are not credentials themselves.
+			if strings.HasSuffix(strings.ToLower(p.Key), "_file") || strings.HasSuffix(strings.ToLower(p.Key), ".file") {
+				continue
+			}
+
+			out = append(out, Finding{
+				Message: fmt.Sprintf("%s %q has a plain text value for %q, use a pipeline secret instead", sec.Kind, sec.Plugin.Name, p.Key),
+				Section: sec.String(),
+			})
+		}
+	}
+	return out
+}
+
+func checkTagCollisions(conf fluentbitconfig.Config) []Finding {
+	seen := map[string]string{}
+	var out []Finding
+	for i, p := range conf.Pipeline.Inputs {
+		v, ok := p.Properties.Get("tag")
+		if !ok {
+			continue
+		}
+
+		tag := stringValue(v)
+		sec := Section{Kind: fluentbitconfig.SectionKindInput, Plugin: p, Index: i}
+		if other, ok := seen[tag]; ok {
+			out = append(out, Finding{
+				Message: fmt.Sprintf("input %q uses tag %q already used by %s", p.Name, tag, other),
+				Section: sec.String(),
+			})
+			continue
+		}
+
+		seen[tag] = sec.String()
+	}
+	return out
+}
+
+// fileRefPattern matches a reference to a pipeline file,
+// that gets replaced by its path on the pipeline pods.
+var fileRefPattern = regexp.MustCompile(`^\{\{\s*files\.[A-Za-z0-9_-]+\s*\}\}$`)
+
+func checkWASMFilters(conf fluentbitconfig.Config) []Finding {
+	var out []Finding
+	for i, p := range conf.Pipeline.Filters {
+		if !strings.EqualFold(p.Name, "wasm") {
+			continue
+		}
+
+		sec := Section{Kind: fluentbitconfig.SectionKindFilter, Plugin: p, Index: i}
+		if v, ok := p.Properties.Get("function_name"); !ok || stringValue(v) == "" {
+			out = append(out, Finding{
+				Message: "wasm filter does not set function_name",
+				Section: sec.String(),
+			})
+		}
+
+		v, ok := p.Properties.Get("wasm_path")
+		if !ok || stringValue(v) == "" {
+			out = append(out, Finding{
+				Message: "wasm filter does not set wasm_path",
+				Section: sec.String(),
+			})
+			continue
+		}
+
+		if path := stringValue(v); !fileRefPattern.MatchString(strings.TrimSpace(path)) {
+			out = append(out, Finding{
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("wasm filter loads %q which might not exist on the pipeline pods, upload it with 'create pipeline_file --type wasm' and reference it as {{files.NAME}}", path),
+				Section:  sec.String(),
+			})
+		}
+	}
+	return out
+}
+
+func stringValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []any:
+		var parts []string
+		for _, item := range v {
+			parts = append(parts, stringValue(item))
+		}
+		return strings.Join(parts, " ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,233 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	fluentbitconfig "github.com/calyptia/go-fluentbit-config/v2"
+)
+
+const classicConfig = `[INPUT]
+    Name tail
+    Tag  app
+    Path /var/log/*.log
+
+[INPUT]
+    Name          dummy
+    Tag           app
+    Mem_Buf_Limit 5M
+
+[OUTPUT]
+    Name        http
+    Match       *
+    http_passwd hunter2
+    Retry_Limit 5
+
+[OUTPUT]
+    Name        es
+    Match       *
+    HTTP_Passwd {{ secrets.es_password }}
+    Retry_Limit 5
+`
+
+const yamlConfig = `pipeline:
+  inputs:
+    - name: tail
+      tag: app
+      storage.type: filesystem
+      processors:
+        logs:
+          - name: content_modifier
+  outputs:
+    - name: stdout
+      match: "*"
+`
+
+type findingKey struct {
+	RuleID  string
+	Section string
+	Line    uint
+}
+
+func keys(findings []Finding) []findingKey {
+	var out []findingKey
+	for _, f := range findings {
+		out = append(out, findingKey{RuleID: f.RuleID, Section: f.Section, Line: f.Line})
+	}
+	return out
+}
+
+func assertFindings(t *testing.T, want []findingKey, got []Finding) {
+	t.Helper()
+	gotKeys := keys(got)
+	if len(want) != len(gotKeys) {
+		t.Fatalf("want %+v, got %+v", want, gotKeys)
+	}
+	for i := range want {
+		if want[i] != gotKeys[i] {
+			t.Fatalf("want %+v, got %+v", want, gotKeys)
+		}
+	}
+}
+
+func TestLint_classic(t *testing.T) {
+	got, err := Lint(classicConfig, fluentbitconfig.FormatClassic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertFindings(t, []findingKey{
+		{RuleID: "mem-buf-limit", Section: "input:tail:tail.0", Line: 1},
+		{RuleID: "tag-collision", Section: "input:dummy:dummy.1", Line: 6},
+		{RuleID: "plaintext-credentials", Section: "output:http:http.0", Line: 11},
+	}, got)
+
+	if !HasErrors(got) {
+		t.Fatal("expected plain text credentials to be an error")
+	}
+}
+
+func TestLint_yaml(t *testing.T) {
+	got, err := Lint(yamlConfig, fluentbitconfig.FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertFindings(t, []findingKey{
+		{RuleID: "retry-limit", Section: "output:stdout:stdout.0", Line: 10},
+	}, got)
+}
+
+func TestLint_wasm(t *testing.T) {
+	got, err := Lint(`[INPUT]
+    Name          dummy
+    Mem_Buf_Limit 5M
+
+[FILTER]
+    Name      wasm
+    Match     *
+    WASM_Path ./filters/my_filter.wasm
+
+[FILTER]
+    Name          wasm
+    Match         *
+    WASM_Path     {{ files.my_filter }}
+    Function_Name go_filter
+`, fluentbitconfig.FormatClassic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertFindings(t, []findingKey{
+		{RuleID: "wasm-filter", Section: "filter:wasm:wasm.0", Line: 5},
+		{RuleID: "wasm-filter", Section: "filter:wasm:wasm.0", Line: 5},
+	}, got)
+
+	if got[0].Severity != SeverityError || got[1].Severity != SeverityWarning {
+		t.Errorf("expected missing function_name error and local wasm_path warning, got %+v", got)
+	}
+}
+
+func TestLoadRulePack(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "rules.yaml")
+	err := os.WriteFile(filename, []byte(`rules:
+  - id: tail-db
+    severity: error
+    section: input
+    plugin: tail
+    require: [db]
+  - id: no-stdout
+    section: output
+    plugin: std*
+    message: stdout is not allowed
+  - id: match-all
+    section: output
+    match:
+      match: "^[a-z]+$"
+`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := LoadRulePack(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Lint(yamlConfig, fluentbitconfig.FormatYAML, rules...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertFindings(t, []findingKey{
+		{RuleID: "tail-db", Section: "input:tail:tail.0", Line: 3},
+		{RuleID: "retry-limit", Section: "output:stdout:stdout.0", Line: 10},
+		{RuleID: "no-stdout", Section: "output:stdout:stdout.0", Line: 10},
+		{RuleID: "match-all", Section: "output:stdout:stdout.0", Line: 10},
+	}, got)
+
+	if got[2].Message != "stdout is not allowed" {
+		t.Errorf("unexpected custom message %q", got[2].Message)
+	}
+}
+
+func TestRuleSpec_Rule(t *testing.T) {
+	tt := []struct {
+		name string
+		spec RuleSpec
+	}{
+		{name: "missing id", spec: RuleSpec{}},
+		{name: "severity", spec: RuleSpec{ID: "x", Severity: "fatal"}},
+		{name: "section", spec: RuleSpec{ID: "x", Section: "service"}},
+		{name: "plugin", spec: RuleSpec{ID: "x", Plugin: "["}},
+		{name: "match", spec: RuleSpec{ID: "x", Match: map[string]string{"tls": "("}}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.spec.Rule(); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestRenderSARIF(t *testing.T) {
+	findings, err := Lint(classicConfig, fluentbitconfig.FormatClassic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := RenderSARIF(&buf, "dev", "fluent-bit.conf", nil, findings); err != nil {
+		t.Fatal(err)
+	}
+
+	var got sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Version != sarifVersion || len(got.Runs) != 1 {
+		t.Fatalf("unexpected log %+v", got)
+	}
+
+	run := got.Runs[0]
+	if len(run.Tool.Driver.Rules) != len(BuiltinRules) {
+		t.Fatalf("want %d rules, got %d", len(BuiltinRules), len(run.Tool.Driver.Rules))
+	}
+
+	if len(run.Results) != len(findings) {
+		t.Fatalf("want %d results, got %d", len(findings), len(run.Results))
+	}
+
+	res := run.Results[2]
+	if res.RuleID != "plaintext-credentials" || run.Tool.Driver.Rules[res.RuleIndex].ID != res.RuleID {
+		t.Errorf("unexpected result rule %+v", res)
+	}
+	if res.Level != SeverityError || res.Locations[0].PhysicalLocation.Region.StartLine != 11 {
+		t.Errorf("unexpected result %+v", res)
+	}
+}
@@ -0,0 +1,236 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	fluentbitconfig "github.com/calyptia/go-fluentbit-config/v2"
+	semver "github.com/hashicorp/go-version"
+)
+
+// Deprecation of a plugin option, or of a whole plugin when Key is empty,
+// as of a fluent-bit version.
+type Deprecation struct {
+	Kind   fluentbitconfig.SectionKind `json:"kind" yaml:"kind"`
+	Plugin string                      `json:"plugin" yaml:"plugin"`
+	Key    string                      `json:"key,omitempty" yaml:"key,omitempty"`
+	// ReplacedBy is the option the key got renamed to, with the same semantics.
+	// Empty when the option cannot be safely rewritten.
+	ReplacedBy string `json:"replacedBy,omitempty" yaml:"replacedBy,omitempty"`
+	Since      string `json:"since" yaml:"since"`
+	Note       string `json:"note,omitempty" yaml:"note,omitempty"`
+}
+
+func (d Deprecation) String() string {
+	if d.Key == "" {
+		return fmt.Sprintf("%s %s was removed in fluent-bit %s", d.Kind, d.Plugin, d.Since)
+	}
+	if d.ReplacedBy != "" {
+		return fmt.Sprintf("%s %s option %s was renamed to %s in fluent-bit %s", d.Kind, d.Plugin, d.Key, d.ReplacedBy, d.Since)
+	}
+	return fmt.Sprintf("%s %s option %s is deprecated since fluent-bit %s", d.Kind, d.Plugin, d.Key, d.Since)
+}
+
+// Deprecations known to the migration assistant.
+var Deprecations = []Deprecation{
+	{
+		Kind:       fluentbitconfig.SectionKindFilter,
+		Plugin:     "kubernetes",
+		Key:        "merge_json_log",
+		ReplacedBy: "merge_log",
+		Since:      "1.0.0",
+	},
+	{
+		Kind:       fluentbitconfig.SectionKindFilter,
+		Plugin:     "kubernetes",
+		Key:        "merge_json_key",
+		ReplacedBy: "merge_log_key",
+		Since:      "1.0.0",
+	},
+	{
+		Kind:   fluentbitconfig.SectionKindInput,
+		Plugin: "tail",
+		Key:    "multiline",
+		Since:  "1.8.0",
+		Note:   "use multiline.parser instead",
+	},
+	{
+		Kind:   fluentbitconfig.SectionKindInput,
+		Plugin: "tail",
+		Key:    "parser_firstline",
+		Since:  "1.8.0",
+		Note:   "use multiline.parser instead",
+	},
+	{
+		Kind:   fluentbitconfig.SectionKindInput,
+		Plugin: "tail",
+		Key:    "docker_mode",
+		Since:  "1.8.0",
+		Note:   "use multiline.parser docker, cri instead",
+	},
+	{
+		Kind:   fluentbitconfig.SectionKindOutput,
+		Plugin: "td",
+		Since:  "3.0.0",
+		Note:   "send to the Treasure Data API with the http output instead",
+	},
+}
+
+// Migration is a deprecation found in a configuration section.
+type Migration struct {
+	Deprecation `json:",inline" yaml:",inline"`
+	Section     string `json:"section" yaml:"section"`
+	Line        uint   `json:"line,omitempty" yaml:"line,omitempty"`
+	// Fixed is set when the option got rewritten,
+	// otherwise the migration has to be done by hand.
+	Fixed bool `json:"fixed" yaml:"fixed"`
+}
+
+// DeprecationRule checks the configuration for the deprecations
+// applying to the target fluent-bit version. An empty target
+// version means the latest.
+func DeprecationRule(targetVersion string) (Rule, error) {
+	deprecations, err := applicableDeprecations(targetVersion)
+	if err != nil {
+		return Rule{}, err
+	}
+
+	return Rule{
+		ID:          "deprecated",
+		Description: "Configurations should not use plugins or options removed or renamed in the target fluent-bit version. Run with --fix to rewrite them where safe.",
+		Severity:    SeverityWarning,
+		Check: func(conf fluentbitconfig.Config) []Finding {
+			var out []Finding
+			for _, sec := range Sections(conf) {
+				for _, d := range deprecations {
+					if !d.matches(sec.Kind, sec.Plugin.Name) || (d.Key != "" && !sec.Plugin.Properties.Has(d.Key)) {
+						continue
+					}
+
+					msg := d.String()
+					if d.Note != "" {
+						msg += ": " + d.Note
+					}
+					out = append(out, Finding{Section: sec.String(), Message: msg})
+				}
+			}
+			return out
+		},
+	}, nil
+}
+
+// Migrate rewrites the options of the raw configuration renamed as of the
+// target fluent-bit version, keeping its formatting and comments.
+// Renames are skipped when the new option is already set.
+// It returns the rewritten configuration along with every deprecation found.
+func Migrate(raw string, format fluentbitconfig.Format, targetVersion string) (string, []Migration, error) {
+	if _, err := fluentbitconfig.ParseAs(raw, format); err != nil {
+		return "", nil, fmt.Errorf("could not parse config: %w", err)
+	}
+
+	deprecations, err := applicableDeprecations(targetVersion)
+	if err != nil {
+		return "", nil, err
+	}
+
+	lines := strings.Split(raw, "\n")
+	var out []Migration
+	for _, sec := range sectionStarts(raw, format) {
+		section := fmt.Sprintf("%s:%s:%s.%d", sec.kind, sec.name, sec.name, sec.index)
+		for _, d := range deprecations {
+			if !d.matches(sec.kind, sec.name) {
+				continue
+			}
+
+			if d.Key == "" {
+				out = append(out, Migration{Deprecation: d, Section: section, Line: sec.line})
+				continue
+			}
+
+			line := findOptionLine(lines, sec, d.Key)
+			if line == -1 {
+				continue
+			}
+
+			m := Migration{Deprecation: d, Section: section, Line: uint(line + 1)}
+			if d.ReplacedBy != "" {
+				if findOptionLine(lines, sec, d.ReplacedBy) == -1 {
+					lines[line] = renameOption(lines[line], d.ReplacedBy)
+					m.Fixed = true
+				} else {
+					m.Note = d.ReplacedBy + " is already set, remove " + d.Key
+				}
+			}
+			out = append(out, m)
+		}
+	}
+
+	return strings.Join(lines, "\n"), out, nil
+}
+
+func applicableDeprecations(targetVersion string) ([]Deprecation, error) {
+	if targetVersion == "" {
+		return Deprecations, nil
+	}
+
+	target, err := semver.NewVersion(strings.TrimPrefix(targetVersion, "v"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid target fluent-bit version %q: %w", targetVersion, err)
+	}
+
+	var out []Deprecation
+	for _, d := range Deprecations {
+		if target.GreaterThanOrEqual(semver.Must(semver.NewVersion(d.Since))) {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (d Deprecation) matches(kind fluentbitconfig.SectionKind, plugin string) bool {
+	return d.Kind == kind && strings.EqualFold(d.Plugin, plugin)
+}
+
+var optionKeyPattern = regexp.MustCompile(`^(\s*(?:-\s*)?)([A-Za-z_][\w.-]*)(\s*:|\s)`)
+
+// findOptionLine returns the index of the line setting the option
+// within the section, or -1. Nested yaml keys, ie: processors, are skipped.
+func findOptionLine(lines []string, sec *sectionStart, key string) int {
+	for i := int(sec.line) - 1; i < int(sec.end) && i < len(lines); i++ {
+		m := optionKeyPattern.FindStringSubmatch(lines[i])
+		if m == nil || !strings.EqualFold(m[2], key) {
+			continue
+		}
+
+		if sec.indent != 0 && len(m[1]) != sec.indent {
+			continue
+		}
+
+		return i
+	}
+	return -1
+}
+
+// renameOption replaces the option key of the line, following the
+// capitalization of the old key, ie: Merge_JSON_Log becomes Merge_Log,
+// and keeping aligned values in place.
+func renameOption(line, key string) string {
+	m := optionKeyPattern.FindStringSubmatchIndex(line)
+	old := line[m[4]:m[5]]
+	if old != strings.ToLower(old) {
+		b := []byte(key)
+		for i := range b {
+			if i == 0 || b[i-1] == '_' || b[i-1] == '.' {
+				b[i] = strings.ToUpper(string(b[i]))[0]
+			}
+		}
+		key = string(b)
+	}
+
+	rest := line[m[5]:]
+	if pad := len(old) - len(key); pad > 0 && strings.HasPrefix(rest, " ") {
+		rest = strings.Repeat(" ", pad) + rest
+	}
+	return line[:m[4]] + key + rest
+}
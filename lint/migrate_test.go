@@ -0,0 +1,133 @@
+package lint
+
+import (
+	"testing"
+
+	fluentbitconfig "github.com/calyptia/go-fluentbit-config/v2"
+)
+
+func TestMigrate(t *testing.T) {
+	t.Run("classic", func(t *testing.T) {
+		raw := "[INPUT]\n" +
+			"    Name        tail\n" +
+			"    Path        /var/log/*.log\n" +
+			"    Docker_Mode On\n" +
+			"\n" +
+			"[FILTER]\n" +
+			"    Name           kubernetes\n" +
+			"    Match          *\n" +
+			"    Merge_JSON_Log On\n" +
+			"\n" +
+			"[OUTPUT]\n" +
+			"    Name  td\n" +
+			"    Match *\n"
+
+		got, migrations, err := Migrate(raw, fluentbitconfig.FormatClassic, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := "[INPUT]\n" +
+			"    Name        tail\n" +
+			"    Path        /var/log/*.log\n" +
+			"    Docker_Mode On\n" +
+			"\n" +
+			"[FILTER]\n" +
+			"    Name           kubernetes\n" +
+			"    Match          *\n" +
+			"    Merge_Log      On\n" +
+			"\n" +
+			"[OUTPUT]\n" +
+			"    Name  td\n" +
+			"    Match *\n"
+		if got != want {
+			t.Errorf("want\n%s\ngot\n%s", want, got)
+		}
+
+		if len(migrations) != 3 {
+			t.Fatalf("expected 3 migrations, got %+v", migrations)
+		}
+
+		for i, want := range []struct {
+			key   string
+			line  uint
+			fixed bool
+		}{
+			{key: "docker_mode", line: 4},
+			{key: "merge_json_log", line: 9, fixed: true},
+			{key: "", line: 11},
+		} {
+			if m := migrations[i]; m.Key != want.key || m.Line != want.line || m.Fixed != want.fixed {
+				t.Errorf("migration %d: want %+v, got %+v", i, want, m)
+			}
+		}
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		raw := "pipeline:\n" +
+			"  filters:\n" +
+			"    - name: kubernetes\n" +
+			"      match: \"*\"\n" +
+			"      merge_json_log: on\n" +
+			"      merge_json_key: data\n" +
+			"      merge_log_key: data\n"
+
+		got, migrations, err := Migrate(raw, fluentbitconfig.FormatYAML, "v2.2.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := "pipeline:\n" +
+			"  filters:\n" +
+			"    - name: kubernetes\n" +
+			"      match: \"*\"\n" +
+			"      merge_log: on\n" +
+			"      merge_json_key: data\n" +
+			"      merge_log_key: data\n"
+		if got != want {
+			t.Errorf("want\n%s\ngot\n%s", want, got)
+		}
+
+		if len(migrations) != 2 || !migrations[0].Fixed || migrations[1].Fixed || migrations[1].Note == "" {
+			t.Errorf("unexpected migrations %+v", migrations)
+		}
+	})
+
+	t.Run("target version", func(t *testing.T) {
+		raw := "[OUTPUT]\n    Name  td\n    Match *\n"
+		_, migrations, err := Migrate(raw, fluentbitconfig.FormatClassic, "2.2.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(migrations) != 0 {
+			t.Errorf("expected no migrations before 3.0.0, got %+v", migrations)
+		}
+
+		if _, _, err := Migrate(raw, fluentbitconfig.FormatClassic, "latest"); err == nil {
+			t.Error("expected invalid version error")
+		}
+	})
+}
+
+func TestDeprecationRule(t *testing.T) {
+	rule, err := DeprecationRule("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err := Lint("[FILTER]\n    Name           kubernetes\n    Match          *\n    Merge_JSON_Log On\n", fluentbitconfig.FormatClassic, rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var found bool
+	for _, f := range findings {
+		if f.RuleID == "deprecated" && f.Section == "filter:kubernetes:kubernetes.0" && f.Line == 1 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected deprecated finding, got %+v", findings)
+	}
+}
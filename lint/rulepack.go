@@ -0,0 +1,189 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	fluentbitconfig "github.com/calyptia/go-fluentbit-config/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// RulePack is a set of user provided rules. Example:
+//
+//	rules:
+//	  - id: tail-db
+//	    description: Tail inputs must keep track of offsets.
+//	    severity: error
+//	    section: input
+//	    plugin: tail
+//	    require: [db]
+//	  - id: no-stdout
+//	    section: output
+//	    plugin: stdout
+//	    message: stdout output is not allowed in production.
+//	  - id: https-only
+//	    section: output
+//	    match:
+//	      tls: "^(on|true)$"
+type RulePack struct {
+	Rules []RuleSpec `json:"rules" yaml:"rules"`
+}
+
+// RuleSpec declares a rule applied to every section
+// of the given kind and plugin.
+type RuleSpec struct {
+	ID          string   `json:"id" yaml:"id"`
+	Description string   `json:"description" yaml:"description"`
+	Severity    Severity `json:"severity" yaml:"severity"`
+	// Section kind the rule applies to: input, filter, output, custom.
+	// Empty applies to all of them.
+	Section string `json:"section" yaml:"section"`
+	// Plugin name glob pattern the rule applies to, ie: "es" or "kafka*".
+	// Empty applies to all of them.
+	Plugin string `json:"plugin" yaml:"plugin"`
+	// Require properties to be set.
+	Require []string `json:"require" yaml:"require"`
+	// Forbid properties from being set.
+	Forbid []string `json:"forbid" yaml:"forbid"`
+	// Match property values against regular expressions when set.
+	Match map[string]string `json:"match" yaml:"match"`
+	// Message reported when the rule is violated.
+	// When the rule has no require, forbid or match checks,
+	// the presence of a matching section is a violation itself.
+	Message string `json:"message" yaml:"message"`
+}
+
+// LoadRulePack reads a YAML rule pack file.
+func LoadRulePack(filename string) ([]Rule, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("could not read rule pack: %w", err)
+	}
+
+	var pack RulePack
+	if err := yaml.Unmarshal(b, &pack); err != nil {
+		return nil, fmt.Errorf("could not parse rule pack %q: %w", filename, err)
+	}
+
+	var rules []Rule
+	for i, spec := range pack.Rules {
+		rule, err := spec.Rule()
+		if err != nil {
+			return nil, fmt.Errorf("rule pack %q: rule %d: %w", filename, i, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Rule compiles the spec into a rule.
+func (spec RuleSpec) Rule() (Rule, error) {
+	if spec.ID == "" {
+		return Rule{}, errors.New("id required")
+	}
+
+	severity := spec.Severity
+	switch severity {
+	case "":
+		severity = SeverityWarning
+	case SeverityError, SeverityWarning, SeverityNote:
+	default:
+		return Rule{}, fmt.Errorf("invalid severity %q, expected one of %s, %s, %s", severity, SeverityError, SeverityWarning, SeverityNote)
+	}
+
+	kind := fluentbitconfig.SectionKind(strings.ToLower(spec.Section))
+	switch kind {
+	case "", fluentbitconfig.SectionKindCustom, fluentbitconfig.SectionKindInput, fluentbitconfig.SectionKindFilter, fluentbitconfig.SectionKindOutput:
+	default:
+		return Rule{}, fmt.Errorf("invalid section %q, expected one of custom, input, filter, output", spec.Section)
+	}
+
+	if _, err := path.Match(spec.Plugin, ""); err != nil {
+		return Rule{}, fmt.Errorf("invalid plugin pattern %q: %w", spec.Plugin, err)
+	}
+
+	match := map[string]*regexp.Regexp{}
+	for key, expr := range spec.Match {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return Rule{}, fmt.Errorf("invalid match expression for %q: %w", key, err)
+		}
+		match[key] = re
+	}
+
+	return Rule{
+		ID:          spec.ID,
+		Description: spec.Description,
+		Severity:    severity,
+		Check: func(conf fluentbitconfig.Config) []Finding {
+			var out []Finding
+			for _, sec := range Sections(conf) {
+				if kind != "" && sec.Kind != kind {
+					continue
+				}
+
+				if spec.Plugin != "" {
+					if ok, _ := path.Match(strings.ToLower(spec.Plugin), sec.Plugin.Name); !ok {
+						continue
+					}
+				}
+
+				for _, msg := range spec.violations(sec, match) {
+					out = append(out, Finding{Message: msg, Section: sec.String()})
+				}
+			}
+			return out
+		},
+	}, nil
+}
+
+func (spec RuleSpec) violations(sec Section, match map[string]*regexp.Regexp) []string {
+	report := func(msg string) string {
+		if spec.Message != "" {
+			return spec.Message
+		}
+		return msg
+	}
+
+	if len(spec.Require) == 0 && len(spec.Forbid) == 0 && len(match) == 0 {
+		return []string{report(fmt.Sprintf("%s %q is not allowed", sec.Kind, sec.Plugin.Name))}
+	}
+
+	var out []string
+	for _, key := range spec.Require {
+		if !sec.Plugin.Properties.Has(key) {
+			out = append(out, report(fmt.Sprintf("%s %q does not set %s", sec.Kind, sec.Plugin.Name, key)))
+		}
+	}
+
+	for _, key := range spec.Forbid {
+		if sec.Plugin.Properties.Has(key) {
+			out = append(out, report(fmt.Sprintf("%s %q must not set %s", sec.Kind, sec.Plugin.Name, key)))
+		}
+	}
+
+	keys := make([]string, 0, len(match))
+	for key := range match {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		re := match[key]
+		v, ok := sec.Plugin.Properties.Get(key)
+		if !ok {
+			continue
+		}
+
+		if s := stringValue(v); !re.MatchString(s) {
+			out = append(out, report(fmt.Sprintf("%s %q has %s %q not matching %q", sec.Kind, sec.Plugin.Name, key, s, re)))
+		}
+	}
+
+	return out
+}
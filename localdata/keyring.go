@@ -110,12 +110,7 @@ func readFile(name string) ([]byte, error) {
 		return nil, fmt.Errorf("could not open file: %w", err)
 	}
 
-	defer func(f *os.File) {
-		err := f.Close()
-		if err != nil {
-			fmt.Printf("could not close file: %v", err)
-		}
-	}(f)
+	defer f.Close()
 
 	b, err := io.ReadAll(f)
 	if err != nil {
@@ -2,16 +2,36 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"os"
 
 	"github.com/joho/godotenv"
-	"github.com/spf13/cobra"
 
 	cmd "github.com/calyptia/cli/cmd"
+	"github.com/calyptia/cli/exitcode"
+	"github.com/calyptia/cli/interrupt"
+	"github.com/calyptia/cli/k8s"
+	"github.com/calyptia/cli/operation"
 )
 
 func main() {
 	_ = godotenv.Load()
 
-	cmd := cmd.NewRootCmd(context.Background())
-	cobra.CheckErr(cmd.Execute())
+	ctx, stop := interrupt.NotifyContext(context.Background())
+
+	cmd := cmd.NewRootCmd(ctx)
+	err := cmd.ExecuteContext(ctx)
+
+	stop()
+	interrupt.RunCleanups()
+	if store, storeErr := operation.DefaultStore(); storeErr == nil {
+		_ = store.Finish(err)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		if hint := k8s.Remediation(err); hint != "" {
+			fmt.Fprintln(os.Stderr, "Hint:", hint)
+		}
+		os.Exit(exitcode.FromError(err))
+	}
 }
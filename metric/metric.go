@@ -71,3 +71,39 @@ func MeasurementNames(measurements map[string]types.AgentMeasurement) []string {
 	sort.Stable(sort.StringSlice(names))
 	return names
 }
+
+var sparklineTicks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders the given points as a single line of unicode bars
+// scaled between the min and max values. Missing values render as spaces.
+func Sparkline(points []types.MetricOverTime) string {
+	var lo, hi float64
+	var found bool
+	for _, p := range points {
+		if p.Value == nil {
+			continue
+		}
+		if !found || *p.Value < lo {
+			lo = *p.Value
+		}
+		if !found || *p.Value > hi {
+			hi = *p.Value
+		}
+		found = true
+	}
+
+	out := make([]rune, len(points))
+	for i, p := range points {
+		if p.Value == nil {
+			out[i] = ' '
+			continue
+		}
+
+		var idx int
+		if hi > lo {
+			idx = int((*p.Value - lo) / (hi - lo) * float64(len(sparklineTicks)-1))
+		}
+		out[i] = sparklineTicks[idx]
+	}
+	return string(out)
+}
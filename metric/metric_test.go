@@ -0,0 +1,36 @@
+package metric
+
+import (
+	"testing"
+
+	"github.com/calyptia/api/types"
+)
+
+func TestSparkline(t *testing.T) {
+	points := func(values ...*float64) []types.MetricOverTime {
+		out := make([]types.MetricOverTime, len(values))
+		for i, v := range values {
+			out[i].Value = v
+		}
+		return out
+	}
+	f := func(v float64) *float64 { return &v }
+
+	tt := []struct {
+		name   string
+		points []types.MetricOverTime
+		want   string
+	}{
+		{name: "empty", want: ""},
+		{name: "scaled", points: points(f(0), f(7), f(3.5)), want: "▁█▄"},
+		{name: "missing", points: points(f(1), nil, f(2)), want: "▁ █"},
+		{name: "flat", points: points(f(5), f(5)), want: "▁▁"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Sparkline(tc.points); got != tc.want {
+				t.Errorf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+// Package notify posts a message to external sinks, ie: a Slack incoming
+// webhook, whenever a watched resource transitions to failed or recovers,
+// enabling lightweight alerting from a terminal session or CI job.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// State of a watched resource reported to the sinks.
+type State string
+
+const (
+	StateFailed    State = "FAILED"
+	StateRecovered State = "RECOVERED"
+)
+
+// Event is posted to the sinks on each state transition.
+type Event struct {
+	Time     time.Time `json:"time"`
+	Resource string    `json:"resource"`
+	State    State     `json:"state"`
+	Message  string    `json:"message,omitempty"`
+}
+
+func (e Event) String() string {
+	if e.Message == "" {
+		return fmt.Sprintf("[%s] %s", e.State, e.Resource)
+	}
+	return fmt.Sprintf("[%s] %s: %s", e.State, e.Resource, e.Message)
+}
+
+// Sink receives the events.
+type Sink interface {
+	Notify(ctx context.Context, ev Event) error
+}
+
+const (
+	schemeSlack   = "slack"
+	schemeWebhook = "webhook"
+)
+
+// ValidSchemes lists the sink schemes accepted by ParseSink.
+var ValidSchemes = []string{schemeSlack, schemeWebhook}
+
+// ParseSink parses a sink as given by the user in the form of SCHEME://URL.
+// The URL defaults to https, ie: slack://hooks.slack.com/services/T/B/X,
+// webhook://example.com/alerts or webhook://http://localhost:8080/alerts.
+func ParseSink(s string, client *http.Client) (Sink, error) {
+	scheme, rest, ok := strings.Cut(s, "://")
+	if !ok || rest == "" {
+		return nil, fmt.Errorf("invalid notify sink %q, expected SCHEME://URL with scheme one of %v", s, ValidSchemes)
+	}
+
+	url := rest
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		url = "https://" + url
+	}
+
+	switch scheme {
+	case schemeSlack:
+		return &slackSink{url: url, client: client}, nil
+	case schemeWebhook:
+		return &webhookSink{url: url, client: client}, nil
+	}
+	return nil, fmt.Errorf("invalid notify sink scheme %q, options: %v", scheme, ValidSchemes)
+}
+
+// webhookSink posts the event as json.
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *webhookSink) Notify(ctx context.Context, ev Event) error {
+	return postJSON(ctx, s.client, s.url, ev)
+}
+
+// slackSink posts the event as a Slack incoming webhook message.
+type slackSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *slackSink) Notify(ctx context.Context, ev Event) error {
+	emoji := ":red_circle:"
+	if ev.State == StateRecovered {
+		emoji = ":large_green_circle:"
+	}
+	return postJSON(ctx, s.client, s.url, map[string]string{
+		"text": emoji + " " + ev.String(),
+	})
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not post notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("could not post notification: unexpected HTTP status: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+const flagName = "notify"
+
+// BindFlag adds the --notify flag to a watching or waiting command.
+func BindFlag(cmd *cobra.Command) {
+	cmd.Flags().StringArray(flagName, nil, fmt.Sprintf("Post a message when a watched resource transitions to %s or %s, in the form of SCHEME://URL with scheme one of %v, ie: slack://hooks.slack.com/services/T/B/X. Can be given multiple times", StateFailed, StateRecovered, ValidSchemes))
+}
+
+// FromFlags returns a notifier posting to the sinks given by --notify.
+// It is nil, and does nothing, when none are given.
+func FromFlags(cmd *cobra.Command) (*Notifier, error) {
+	values, err := cmd.Flags().GetStringArray(flagName)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	sinks := make([]Sink, 0, len(values))
+	for _, v := range values {
+		sink, err := ParseSink(v, client)
+		if err != nil {
+			return nil, err
+		}
+
+		sinks = append(sinks, sink)
+	}
+
+	return New(sinks...), nil
+}
+
+// Notifier tracks the state of the watched resources and
+// notifies the sinks whenever it changes.
+type Notifier struct {
+	sinks []Sink
+
+	mu     sync.Mutex
+	failed map[string]bool
+}
+
+// New notifier posting to the given sinks.
+func New(sinks ...Sink) *Notifier {
+	return &Notifier{sinks: sinks, failed: map[string]bool{}}
+}
+
+// Observe records the state of the resource. The sinks are notified when
+// it fails, and when it recovers after having failed, so a resource
+// seen healthy the first time is not reported. It returns the errors
+// of the sinks that could not be notified.
+func (n *Notifier) Observe(ctx context.Context, resource string, failed bool, message string) error {
+	if n == nil {
+		return nil
+	}
+
+	n.mu.Lock()
+	wasFailed, seen := n.failed[resource]
+	n.failed[resource] = failed
+	n.mu.Unlock()
+
+	var state State
+	switch {
+	case failed && !wasFailed:
+		state = StateFailed
+	case !failed && seen && wasFailed:
+		state = StateRecovered
+	default:
+		return nil
+	}
+
+	ev := Event{
+		Time:     time.Now(),
+		Resource: resource,
+		State:    state,
+		Message:  message,
+	}
+
+	var errs []error
+	for _, sink := range n.sinks {
+		if err := sink.Notify(ctx, ev); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,127 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recordSink struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (s *recordSink) Notify(_ context.Context, ev Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, ev)
+	return nil
+}
+
+func TestNotifier_Observe(t *testing.T) {
+	ctx := context.Background()
+	sink := &recordSink{}
+	n := New(sink)
+
+	steps := []struct {
+		resource string
+		failed   bool
+	}{
+		{"check a", false},
+		{"check a", true},
+		{"check a", true},
+		{"check b", true},
+		{"check a", false},
+		{"check a", false},
+	}
+	for _, s := range steps {
+		if err := n.Observe(ctx, s.resource, s.failed, "status changed"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{
+		"[FAILED] check a: status changed",
+		"[FAILED] check b: status changed",
+		"[RECOVERED] check a: status changed",
+	}
+	if len(sink.events) != len(want) {
+		t.Fatalf("want %v, got %v", want, sink.events)
+	}
+	for i := range want {
+		if got := sink.events[i].String(); got != want[i] {
+			t.Errorf("want %q at %d, got %q", want[i], i, got)
+		}
+	}
+
+	var nilNotifier *Notifier
+	if err := nilNotifier.Observe(ctx, "check a", true, ""); err != nil {
+		t.Errorf("expected nil notifier to do nothing, got %v", err)
+	}
+}
+
+func TestParseSink(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies = map[string]map[string]any{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		bodies[r.URL.Path] = body
+		mu.Unlock()
+
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	ev := Event{Resource: "operation abc", State: StateFailed, Message: "exit status 1"}
+
+	slack, err := ParseSink("slack://"+srv.URL+"/slack", srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := slack.Notify(ctx, ev); err != nil {
+		t.Fatal(err)
+	}
+	if text, _ := bodies["/slack"]["text"].(string); !strings.HasSuffix(text, "[FAILED] operation abc: exit status 1") {
+		t.Errorf("unexpected slack message %q", text)
+	}
+
+	webhook, err := ParseSink("webhook://"+srv.URL+"/webhook", srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := webhook.Notify(ctx, ev); err != nil {
+		t.Fatal(err)
+	}
+	if got := bodies["/webhook"]; got["state"] != "FAILED" || got["resource"] != "operation abc" {
+		t.Errorf("unexpected webhook event %v", got)
+	}
+
+	broken, err := ParseSink("webhook://"+srv.URL+"/broken", srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := broken.Notify(ctx, ev); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("expected unexpected status error, got %v", err)
+	}
+
+	for _, invalid := range []string{"hooks.slack.com", "email://ops@example.com", "slack://"} {
+		if _, err := ParseSink(invalid, nil); err == nil {
+			t.Errorf("expected %q to be invalid", invalid)
+		}
+	}
+}
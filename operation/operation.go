@@ -0,0 +1,338 @@
+// Package operation runs long commands detached in the background,
+// so they can be submitted with --async and waited for later with
+// "calyptia get operation ID --wait", letting CI steps run in parallel.
+package operation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// EnvID is set on the detached process to the ID of the operation it runs.
+const EnvID = "CALYPTIA_OPERATION_ID"
+
+const asyncFlag = "async"
+
+// ErrNotFound is returned when there is no operation with the given ID.
+var ErrNotFound = errors.New("operation not found")
+
+// Status of an operation.
+type Status string
+
+const (
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// Operation is a command running, or that ran, detached in the background.
+type Operation struct {
+	ID         string     `json:"id" yaml:"id"`
+	Command    string     `json:"command" yaml:"command"`
+	Status     Status     `json:"status" yaml:"status"`
+	Error      string     `json:"error,omitempty" yaml:"error,omitempty"`
+	PID        int        `json:"pid,omitempty" yaml:"pid,omitempty"`
+	LogFile    string     `json:"logFile" yaml:"logFile"`
+	CreatedAt  time.Time  `json:"createdAt" yaml:"createdAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty" yaml:"finishedAt,omitempty"`
+}
+
+// Done reports whether the operation finished, either way.
+func (op Operation) Done() bool {
+	return op.Status == StatusSucceeded || op.Status == StatusFailed
+}
+
+// Store keeps the operations as JSON files along with their output logs.
+type Store struct {
+	Dir string
+}
+
+// DefaultStore stores the operations under the user cache directory.
+func DefaultStore() (Store, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return Store{}, fmt.Errorf("could not find a directory to store operations: %w", err)
+	}
+
+	return Store{Dir: filepath.Join(dir, "calyptia", "operations")}, nil
+}
+
+// Get returns the operation with the given ID. A running operation whose
+// process is gone, ie: it crashed or got killed before recording its
+// result, is marked as failed.
+func (s Store) Get(id string) (Operation, error) {
+	op, err := s.read(id)
+	if err != nil || op.Status != StatusRunning {
+		return op, err
+	}
+
+	op.PID = s.readPID(id)
+	if op.PID == 0 || processAlive(op.PID) {
+		return op, nil
+	}
+
+	// the process may have recorded its result right before exiting.
+	op, err = s.read(id)
+	if err != nil || op.Status != StatusRunning {
+		return op, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	op.PID = s.readPID(id)
+	op.Status = StatusFailed
+	op.Error = fmt.Sprintf("process %d exited without recording a result, check the logs at %s", op.PID, op.LogFile)
+	op.FinishedAt = &now
+	return op, s.save(op)
+}
+
+func (s Store) read(id string) (Operation, error) {
+	var op Operation
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return op, fmt.Errorf("invalid operation ID %q", id)
+	}
+
+	b, err := os.ReadFile(s.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return op, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	if err != nil {
+		return op, fmt.Errorf("could not read operation %s: %w", id, err)
+	}
+
+	if err := json.Unmarshal(b, &op); err != nil {
+		return op, fmt.Errorf("could not parse operation %s: %w", id, err)
+	}
+
+	return op, nil
+}
+
+// Wait polls the operation every interval until it is done.
+func (s Store) Wait(ctx context.Context, id string, interval time.Duration) (Operation, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		op, err := s.Get(id)
+		if err != nil || op.Done() {
+			return op, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return op, fmt.Errorf("operation %s still %s: %w", id, op.Status, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// Submit starts the calyptia command with the given arguments detached
+// in the background, with its output written to the operation log file.
+func (s Store) Submit(args []string) (Operation, error) {
+	var op Operation
+
+	exe, err := os.Executable()
+	if err != nil {
+		return op, fmt.Errorf("could not find calyptia executable: %w", err)
+	}
+
+	id, err := newID()
+	if err != nil {
+		return op, err
+	}
+
+	if err := os.MkdirAll(s.Dir, 0o700); err != nil {
+		return op, fmt.Errorf("could not create operations directory: %w", err)
+	}
+
+	op = Operation{
+		ID:        id,
+		Command:   commandLine(args),
+		Status:    StatusRunning,
+		LogFile:   filepath.Join(s.Dir, id+".log"),
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+
+	logFile, err := os.OpenFile(op.LogFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return op, fmt.Errorf("could not create operation log file: %w", err)
+	}
+	defer logFile.Close()
+
+	// saved before starting so the detached process always finds it.
+	if err := s.save(op); err != nil {
+		return op, err
+	}
+
+	proc := exec.Command(exe, args...)
+	proc.Env = append(os.Environ(), EnvID+"="+id)
+	proc.Stdout = logFile
+	proc.Stderr = logFile
+	detach(proc)
+
+	if err := proc.Start(); err != nil {
+		_ = os.Remove(s.path(id))
+		return op, fmt.Errorf("could not start operation: %w", err)
+	}
+
+	// kept apart from the operation file, which the
+	// detached process may be writing already.
+	op.PID = proc.Process.Pid
+	if err := os.WriteFile(s.pidPath(id), []byte(strconv.Itoa(op.PID)), 0o600); err != nil {
+		return op, fmt.Errorf("could not record operation process: %w", err)
+	}
+
+	if err := proc.Process.Release(); err != nil {
+		return op, fmt.Errorf("could not detach operation: %w", err)
+	}
+
+	return op, nil
+}
+
+// Finish records the result of the operation the current process runs, if any.
+func (s Store) Finish(runErr error) error {
+	id := os.Getenv(EnvID)
+	if id == "" {
+		return nil
+	}
+
+	return s.update(id, func(op *Operation) {
+		now := time.Now().UTC().Truncate(time.Second)
+		op.FinishedAt = &now
+		op.Status = StatusSucceeded
+		if runErr != nil {
+			op.Status = StatusFailed
+			op.Error = runErr.Error()
+		}
+	})
+}
+
+func (s Store) update(id string, fn func(*Operation)) error {
+	op, err := s.read(id)
+	if err != nil {
+		return err
+	}
+
+	fn(&op)
+	return s.save(op)
+}
+
+// save writes the operation atomically, as it gets polled concurrently.
+func (s Store) save(op Operation) error {
+	b, err := json.Marshal(op)
+	if err != nil {
+		return fmt.Errorf("could not encode operation: %w", err)
+	}
+
+	tmp := s.path(op.ID) + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return fmt.Errorf("could not write operation %s: %w", op.ID, err)
+	}
+
+	if err := os.Rename(tmp, s.path(op.ID)); err != nil {
+		return fmt.Errorf("could not write operation %s: %w", op.ID, err)
+	}
+
+	return nil
+}
+
+func (s Store) path(id string) string {
+	return filepath.Join(s.Dir, id+".json")
+}
+
+func (s Store) pidPath(id string) string {
+	return filepath.Join(s.Dir, id+".pid")
+}
+
+// readPID returns the process ID of the operation, or zero when unknown.
+func (s Store) readPID(id string) int {
+	b, err := os.ReadFile(s.pidPath(id))
+	if err != nil {
+		return 0
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+// commandLine returns the command with the args, the token value redacted.
+func commandLine(args []string) string {
+	out := []string{"calyptia"}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--token" && i+1 < len(args):
+			out = append(out, arg, "REDACTED")
+			i++
+		case strings.HasPrefix(arg, "--token="):
+			out = append(out, "--token=REDACTED")
+		default:
+			out = append(out, arg)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not generate operation ID: %w", err)
+	}
+	return "op-" + hex.EncodeToString(b), nil
+}
+
+// BindFlag adds the --async flag to the given command.
+func BindFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool(asyncFlag, false, "Run in the background and print an operation ID right away. Wait for it with 'calyptia get operation ID --wait'")
+}
+
+// Detach submits the command as an operation when --async is set,
+// printing its ID to stdout. It reports whether the command was detached,
+// in which case the caller must return without doing anything else.
+func Detach(cmd *cobra.Command) (bool, error) {
+	async, err := cmd.Flags().GetBool(asyncFlag)
+	if err != nil || !async {
+		return false, nil
+	}
+
+	store, err := DefaultStore()
+	if err != nil {
+		return false, err
+	}
+
+	op, err := store.Submit(withoutAsyncFlag(os.Args[1:]))
+	if err != nil {
+		return false, err
+	}
+
+	cmd.PrintErrf("Submitted operation %s, wait for it with: calyptia get operation %s --wait\n", op.ID, op.ID)
+	fmt.Fprintln(cmd.OutOrStdout(), op.ID)
+	return true, nil
+}
+
+func withoutAsyncFlag(args []string) []string {
+	out := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "--"+asyncFlag || strings.HasPrefix(arg, "--"+asyncFlag+"=") {
+			continue
+		}
+		out = append(out, arg)
+	}
+	return out
+}
@@ -0,0 +1,116 @@
+package operation
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore_Finish(t *testing.T) {
+	store := Store{Dir: t.TempDir()}
+	op := Operation{ID: "op-1", Status: StatusRunning, CreatedAt: time.Now()}
+	if err := store.save(op); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Finish(nil); err != nil {
+		t.Fatalf("expected no-op outside of an operation, got %v", err)
+	}
+
+	t.Setenv(EnvID, op.ID)
+	if err := store.Finish(errors.New("boom")); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.Wait(context.Background(), op.ID, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Status != StatusFailed || got.Error != "boom" || got.FinishedAt == nil {
+		t.Errorf("unexpected operation %+v", got)
+	}
+}
+
+func TestStore_Wait(t *testing.T) {
+	store := Store{Dir: t.TempDir()}
+	if err := store.save(Operation{ID: "op-1", Status: StatusRunning}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := store.Wait(ctx, "op-1", time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	if _, err := store.Get("op-2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+
+	if _, err := store.Get("../op-1"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("expected invalid ID error, got %v", err)
+	}
+}
+
+func Test_commandLine(t *testing.T) {
+	args := withoutAsyncFlag([]string{"create", "pipeline", "--async", "--token", "secret", "--token=secret", "--async=true", "--name", "a"})
+	if want := []string{"create", "pipeline", "--token", "secret", "--token=secret", "--name", "a"}; !reflect.DeepEqual(args, want) {
+		t.Fatalf("expected %v, got %v", want, args)
+	}
+
+	if got, want := commandLine(args), "calyptia create pipeline --token REDACTED --token=REDACTED --name a"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestStore_Get_processGone(t *testing.T) {
+	store := Store{Dir: t.TempDir()}
+
+	proc := exec.Command(os.Args[0], "-test.run=^$")
+	if err := proc.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	pid := proc.Process.Pid
+	if err := store.save(Operation{ID: "op-1", Status: StatusRunning, LogFile: "op-1.log"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(store.pidPath("op-1"), []byte(strconv.Itoa(pid)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.Wait(context.Background(), "op-1", time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Status != StatusFailed || got.PID != pid || got.FinishedAt == nil || !strings.Contains(got.Error, "without recording a result") {
+		t.Errorf("expected crashed operation to be failed, got %+v", got)
+	}
+
+	// recorded so it is not checked again.
+	if saved, err := store.read("op-1"); err != nil || saved.Status != StatusFailed {
+		t.Errorf("expected failed status to be saved, got %+v, %v", saved, err)
+	}
+
+	if err := store.save(Operation{ID: "op-2", Status: StatusRunning}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(store.pidPath("op-2"), []byte(strconv.Itoa(os.Getpid())), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := store.Get("op-2"); err != nil || got.Status != StatusRunning {
+		t.Errorf("expected live operation to be running, got %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !windows
+
+package operation
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+// detach starts the process in a new session, so it outlives the
+// terminal it was submitted from.
+func detach(proc *exec.Cmd) {
+	proc.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+// processAlive reports whether a process with the given PID exists.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,32 @@
+package operation
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+const (
+	detachedProcess = 0x00000008
+	stillActive     = 259
+)
+
+// detach starts the process without a console in its own process group,
+// so it outlives the terminal it was submitted from.
+func detach(proc *exec.Cmd) {
+	proc.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP | detachedProcess}
+}
+
+// processAlive reports whether a process with the given PID is still running.
+func processAlive(pid int) bool {
+	h, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(h)
+
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return true
+	}
+	return code == stillActive
+}
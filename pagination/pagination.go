@@ -0,0 +1,108 @@
+// Package pagination pages through the Calyptia Cloud listings, so the get
+// commands list large projects in bounded requests instead of asking for
+// every item at once.
+//
+// Listings are limited with the --limit flag and continued from a previous
+// run with the --cursor flag, which gets printed when there are more items.
+package pagination
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	limitFlag  = "limit"
+	cursorFlag = "cursor"
+)
+
+// PageSize is the number of items requested per page.
+var PageSize uint = 100
+
+// Options of a paginated listing.
+type Options struct {
+	// Limit is the maximum number of items to list. 0 means all of them.
+	Limit uint
+	// Cursor to continue listing from, as returned by a previous listing.
+	Cursor string
+}
+
+// BindFlags adds the --limit and --cursor flags to a listing command.
+func BindFlags(cmd *cobra.Command) {
+	fs := cmd.Flags()
+	fs.Uint(limitFlag, 0, "Maximum number of items to list, fetched in pages. 0 means all of them")
+	fs.String(cursorFlag, "", "Continue listing from the cursor printed by a previous listing")
+}
+
+// FromFlags returns the options given with the --limit and --cursor flags.
+// The deprecated per command --last flag, if any, is used when --limit is not given.
+func FromFlags(cmd *cobra.Command) Options {
+	fs := cmd.Flags()
+	limit, _ := fs.GetUint(limitFlag)
+	if !fs.Changed(limitFlag) && fs.Lookup("last") != nil {
+		limit, _ = fs.GetUint("last")
+	}
+
+	cursor, _ := fs.GetString(cursorFlag)
+	return Options{Limit: limit, Cursor: cursor}
+}
+
+// Page of items along with the cursor to the following one.
+type Page[T any] struct {
+	Items     []T
+	EndCursor *string
+}
+
+// FetchFunc requests a page of up to last items before the given cursor,
+// nil for the first page.
+type FetchFunc[T any] func(ctx context.Context, last uint, before *string) (Page[T], error)
+
+// List fetches pages until reaching the limit or the last page.
+// When the limit is reached, the cursor to continue from is returned too.
+func List[T any](ctx context.Context, opts Options, fetch FetchFunc[T]) ([]T, *string, error) {
+	var out []T
+	var before *string
+	if opts.Cursor != "" {
+		before = &opts.Cursor
+	}
+
+	for {
+		size := PageSize
+		if opts.Limit != 0 && opts.Limit-uint(len(out)) < size {
+			size = opts.Limit - uint(len(out))
+		}
+
+		page, err := fetch(ctx, size, before)
+		if err != nil {
+			return out, nil, err
+		}
+
+		out = append(out, page.Items...)
+
+		// a short page is the last one, and so is a page pointing to itself.
+		if page.EndCursor == nil || uint(len(page.Items)) < size || (before != nil && *page.EndCursor == *before) {
+			return out, nil, nil
+		}
+
+		if opts.Limit != 0 && uint(len(out)) >= opts.Limit {
+			return out, page.EndCursor, nil
+		}
+
+		before = page.EndCursor
+	}
+}
+
+// PrintNext tells how to continue a listing that reached its limit.
+// Nothing gets printed on the last page, or with --quiet.
+func PrintNext(cmd *cobra.Command, next *string) {
+	if next == nil {
+		return
+	}
+
+	if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+		return
+	}
+
+	cmd.PrintErrf("More items available, continue with: --%s %s\n", cursorFlag, *next)
+}
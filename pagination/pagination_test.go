@@ -0,0 +1,138 @@
+package pagination
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// fakeListing pages through the numbers from total down to 1,
+// using the last number of a page as its end cursor.
+func fakeListing(total int, requests *[]uint) FetchFunc[int] {
+	return func(_ context.Context, last uint, before *string) (Page[int], error) {
+		*requests = append(*requests, last)
+
+		start := total
+		if before != nil {
+			n, err := strconv.Atoi(*before)
+			if err != nil {
+				return Page[int]{}, err
+			}
+			start = n - 1
+		}
+
+		var page Page[int]
+		for n := start; n > 0 && uint(len(page.Items)) < last; n-- {
+			page.Items = append(page.Items, n)
+		}
+
+		if len(page.Items) != 0 {
+			cursor := strconv.Itoa(page.Items[len(page.Items)-1])
+			page.EndCursor = &cursor
+		}
+		return page, nil
+	}
+}
+
+func TestList(t *testing.T) {
+	defer func(size uint) { PageSize = size }(PageSize)
+	PageSize = 10
+
+	ctx := context.Background()
+
+	t.Run("all", func(t *testing.T) {
+		var requests []uint
+		got, next, err := List(ctx, Options{}, fakeListing(25, &requests))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(got) != 25 || got[0] != 25 || got[24] != 1 {
+			t.Errorf("unexpected items %v", got)
+		}
+
+		if next != nil {
+			t.Errorf("expected no next cursor, got %q", *next)
+		}
+
+		if len(requests) != 3 {
+			t.Errorf("expected 3 pages, got %v", requests)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		var requests []uint
+		got, next, err := List(ctx, Options{Limit: 15}, fakeListing(25, &requests))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(got) != 15 || got[14] != 11 {
+			t.Errorf("unexpected items %v", got)
+		}
+
+		if next == nil || *next != "11" {
+			t.Fatalf("expected next cursor 11, got %v", next)
+		}
+
+		if len(requests) != 2 || requests[1] != 5 {
+			t.Errorf("expected the last page to be limited, got %v", requests)
+		}
+
+		rest, next, err := List(ctx, Options{Cursor: *next}, fakeListing(25, &requests))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(rest) != 10 || rest[0] != 10 || next != nil {
+			t.Errorf("unexpected continuation %v, next %v", rest, next)
+		}
+	})
+
+	t.Run("limit on last item", func(t *testing.T) {
+		var requests []uint
+		got, _, err := List(ctx, Options{Limit: 20}, fakeListing(20, &requests))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(got) != 20 {
+			t.Errorf("unexpected items %v", got)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, _, err := List(ctx, Options{}, func(context.Context, uint, *string) (Page[int], error) {
+			return Page[int]{}, errors.New("boom")
+		})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestFromFlags(t *testing.T) {
+	cmd := &cobra.Command{}
+	var last uint
+	cmd.Flags().UintVar(&last, "last", 0, "")
+	BindFlags(cmd)
+
+	if err := cmd.ParseFlags([]string{"--last", "5", "--cursor", "abc"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := FromFlags(cmd); got.Limit != 5 || got.Cursor != "abc" {
+		t.Errorf("expected --last as limit, got %+v", got)
+	}
+
+	if err := cmd.ParseFlags([]string{"--limit", "7"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := FromFlags(cmd); got.Limit != 7 {
+		t.Errorf("expected --limit to take precedence, got %+v", got)
+	}
+}
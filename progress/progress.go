@@ -0,0 +1,289 @@
+package progress
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sethvargo/go-retry"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// Mode controls how step progress gets rendered.
+type Mode string
+
+const (
+	ModeText  Mode = "text"
+	ModeJSON  Mode = "json"
+	ModeQuiet Mode = "quiet"
+)
+
+// ValidModes lists the modes accepted by ParseMode.
+var ValidModes = []Mode{ModeText, ModeJSON}
+
+// ParseMode parses a progress mode as given by the user.
+func ParseMode(s string) (Mode, error) {
+	for _, m := range ValidModes {
+		if Mode(s) == m {
+			return m, nil
+		}
+	}
+	return "", fmt.Errorf("invalid progress mode %q, options: %v", s, ValidModes)
+}
+
+const flagName = "progress"
+
+// BindFlag adds the --progress flag to a long-running command.
+func BindFlag(cmd *cobra.Command) {
+	cmd.Flags().String(flagName, string(ModeText), fmt.Sprintf("Progress output format, options: %v. With json, newline-delimited events are written to stderr", ValidModes))
+}
+
+// FromFlags returns a tracker writing to stderr in the mode given by
+// --progress. It is quiet when the global --quiet flag is set.
+func FromFlags(cmd *cobra.Command) (*Tracker, error) {
+	if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+		return New(cmd.ErrOrStderr(), ModeQuiet), nil
+	}
+
+	s, err := cmd.Flags().GetString(flagName)
+	if err != nil {
+		return nil, err
+	}
+
+	mode, err := ParseMode(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(cmd.ErrOrStderr(), mode), nil
+}
+
+// Status of a step.
+type Status string
+
+const (
+	StatusStarted  Status = "started"
+	StatusRetrying Status = "retrying"
+	StatusProgress Status = "progress"
+	StatusDone     Status = "done"
+	StatusFailed   Status = "failed"
+)
+
+// Event is emitted on each step status change when using ModeJSON.
+// Completed and Total count the items processed by bulk steps.
+type Event struct {
+	Time      time.Time `json:"time"`
+	Step      string    `json:"step"`
+	Status    Status    `json:"status"`
+	Attempt   int       `json:"attempt"`
+	Message   string    `json:"message,omitempty"`
+	Completed int       `json:"completed,omitempty"`
+	Total     int       `json:"total,omitempty"`
+	Duration  string    `json:"duration,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Step holds the outcome of a single tracked step.
+type Step struct {
+	Name     string
+	Status   Status
+	Attempts int
+	Duration time.Duration
+}
+
+const maxRetries = 3
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Tracker runs steps in sequence and reports their progress.
+type Tracker struct {
+	w       io.Writer
+	mode    Mode
+	spinner bool
+	backoff func() retry.Backoff
+
+	mu    sync.Mutex
+	steps []Step
+}
+
+// New tracker writing to w. Spinners are only rendered when w is a terminal.
+func New(w io.Writer, mode Mode) *Tracker {
+	var spinner bool
+	if f, ok := w.(*os.File); ok && mode == ModeText {
+		spinner = term.IsTerminal(int(f.Fd()))
+	}
+	return &Tracker{
+		w:       w,
+		mode:    mode,
+		spinner: spinner,
+		backoff: func() retry.Backoff {
+			return retry.WithMaxRetries(maxRetries, retry.NewExponential(time.Second))
+		},
+	}
+}
+
+// Run the given step. The step is retried while fn returns an error wrapped
+// with retry.RetryableError.
+func (t *Tracker) Run(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	start := time.Now()
+	var attempts int
+
+	t.emit(Event{Step: name, Status: StatusStarted, Attempt: 1})
+	stop := t.spin(name, start)
+
+	err := retry.Do(ctx, t.backoff(), func(ctx context.Context) error {
+		attempts++
+		if attempts > 1 {
+			t.emit(Event{Step: name, Status: StatusRetrying, Attempt: attempts})
+		}
+		return fn(ctx)
+	})
+	stop()
+
+	step := Step{
+		Name:     name,
+		Status:   StatusDone,
+		Attempts: attempts,
+		Duration: time.Since(start),
+	}
+	ev := Event{
+		Step:     name,
+		Status:   StatusDone,
+		Attempt:  attempts,
+		Duration: step.Duration.Round(time.Millisecond).String(),
+	}
+	if err != nil {
+		step.Status = StatusFailed
+		ev.Status = StatusFailed
+		ev.Error = err.Error()
+	}
+
+	t.mu.Lock()
+	t.steps = append(t.steps, step)
+	t.mu.Unlock()
+
+	t.emit(ev)
+	return err
+}
+
+// Report emits an update of a running step. Completed and total count
+// the items processed so far by bulk steps, they are zero otherwise.
+func (t *Tracker) Report(step, message string, completed, total int) {
+	t.emit(Event{
+		Step:      step,
+		Status:    StatusProgress,
+		Message:   message,
+		Completed: completed,
+		Total:     total,
+	})
+}
+
+// RolloutReporter reports the progress of a deployment rollout
+// as updates of the given step while waiting for it to be ready.
+func (t *Tracker) RolloutReporter(step string) func(deployment, status string) {
+	return func(deployment, status string) {
+		t.Report(step, fmt.Sprintf("deployment %q: %s", deployment, status), 0, 0)
+	}
+}
+
+// PodFailureReporter reports the reason the pods of a deployment
+// rollout are not ready as updates of the given step.
+func (t *Tracker) PodFailureReporter(step string) func(deployment, pod, reason string) {
+	return func(deployment, pod, reason string) {
+		t.Report(step, fmt.Sprintf("deployment %q: pod %s: %s", deployment, pod, reason), 0, 0)
+	}
+}
+
+// Steps returns the steps ran so far.
+func (t *Tracker) Steps() []Step {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Step(nil), t.steps...)
+}
+
+// Summary renders a table with all the steps ran so far.
+// It only renders on ModeText.
+func (t *Tracker) Summary() error {
+	if t.mode != ModeText {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(t.w, 0, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tSTATUS\tATTEMPTS\tDURATION")
+	for _, s := range t.Steps() {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", s.Name, s.Status, s.Attempts, s.Duration.Round(time.Millisecond))
+	}
+	return tw.Flush()
+}
+
+func (t *Tracker) emit(ev Event) {
+	ev.Time = time.Now()
+
+	switch t.mode {
+	case ModeJSON:
+		_ = json.NewEncoder(t.w).Encode(ev)
+	case ModeText:
+		if t.spinner && ev.Status != StatusDone && ev.Status != StatusFailed && ev.Status != StatusProgress {
+			return
+		}
+
+		switch ev.Status {
+		case StatusStarted:
+			fmt.Fprintf(t.w, "  %s...\n", ev.Step)
+		case StatusRetrying:
+			fmt.Fprintf(t.w, "  %s (attempt %d)...\n", ev.Step, ev.Attempt)
+		case StatusProgress:
+			if t.spinner {
+				// clear the spinner line, it gets redrawn on the next frame.
+				fmt.Fprint(t.w, "\r\033[K")
+			}
+			msg := ev.Message
+			if ev.Total != 0 {
+				msg = strings.TrimSpace(fmt.Sprintf("%d/%d %s", ev.Completed, ev.Total, msg))
+			}
+			fmt.Fprintf(t.w, "  %s: %s\n", ev.Step, msg)
+		case StatusDone:
+			fmt.Fprintf(t.w, "✓ %s (%s)\n", ev.Step, ev.Duration)
+		case StatusFailed:
+			fmt.Fprintf(t.w, "✗ %s (%s): %s\n", ev.Step, ev.Duration, ev.Error)
+		}
+	}
+}
+
+// spin renders a spinner for the given step until the returned func is called.
+func (t *Tracker) spin(name string, start time.Time) func() {
+	if !t.spinner {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for i := 0; ; i++ {
+			fmt.Fprintf(t.w, "\r%s %s (%s)", spinnerFrames[i%len(spinnerFrames)], name, time.Since(start).Round(time.Second))
+			select {
+			case <-done:
+				// clear the spinner line.
+				fmt.Fprint(t.w, "\r\033[K")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
@@ -0,0 +1,161 @@
+package progress
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sethvargo/go-retry"
+	"github.com/spf13/cobra"
+)
+
+func TestTracker_Run(t *testing.T) {
+	var buf bytes.Buffer
+	tracker := New(&buf, ModeJSON)
+	tracker.backoff = func() retry.Backoff {
+		return retry.WithMaxRetries(maxRetries, retry.NewConstant(time.Millisecond))
+	}
+
+	var calls int
+	err := tracker.Run(context.Background(), "flaky", func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return retry.RetryableError(errors.New("transient"))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantErr := errors.New("permanent")
+	err = tracker.Run(context.Background(), "broken", func(ctx context.Context) error {
+		return wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("expected %v, got %v", wantErr, err)
+	}
+
+	var statuses []Status
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var ev Event
+		if err := dec.Decode(&ev); err != nil {
+			t.Fatal(err)
+		}
+		statuses = append(statuses, ev.Status)
+	}
+
+	want := []Status{StatusStarted, StatusRetrying, StatusRetrying, StatusDone, StatusStarted, StatusFailed}
+	if len(statuses) != len(want) {
+		t.Fatalf("expected events %v, got %v", want, statuses)
+	}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Fatalf("expected events %v, got %v", want, statuses)
+		}
+	}
+
+	steps := tracker.Steps()
+	if len(steps) != 2 || steps[0].Attempts != 3 || steps[1].Status != StatusFailed {
+		t.Errorf("unexpected steps %+v", steps)
+	}
+}
+
+func TestTracker_Summary(t *testing.T) {
+	var buf bytes.Buffer
+	tracker := New(&buf, ModeText)
+	_ = tracker.Run(context.Background(), "step", func(ctx context.Context) error { return nil })
+	buf.Reset()
+
+	if err := tracker.Summary(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "STEP") || !strings.Contains(buf.String(), "done") {
+		t.Errorf("unexpected summary %q", buf.String())
+	}
+
+	buf.Reset()
+	quiet := New(&buf, ModeQuiet)
+	_ = quiet.Run(context.Background(), "step", func(ctx context.Context) error { return nil })
+	_ = quiet.Summary()
+	if buf.Len() != 0 {
+		t.Errorf("expected no output on quiet mode, got %q", buf.String())
+	}
+}
+
+func TestTracker_Report(t *testing.T) {
+	var buf bytes.Buffer
+	tracker := New(&buf, ModeJSON)
+	tracker.Report("enroll", "enrolled agent \"a\"", 1, 4)
+
+	var ev Event
+	if err := json.NewDecoder(&buf).Decode(&ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev.Status != StatusProgress || ev.Completed != 1 || ev.Total != 4 || ev.Time.IsZero() {
+		t.Errorf("unexpected event %+v", ev)
+	}
+
+	buf.Reset()
+	tracker = New(&buf, ModeText)
+	tracker.Report("enroll", "enrolled agent \"a\"", 1, 4)
+	if want := "  enroll: 1/4 enrolled agent \"a\"\n"; buf.String() != want {
+		t.Errorf("want %q, got %q", want, buf.String())
+	}
+}
+
+func TestTracker_rolloutReporters(t *testing.T) {
+	var buf bytes.Buffer
+	tracker := New(&buf, ModeText)
+	tracker.RolloutReporter("wait")("core", "1 of 2 replicas ready")
+	tracker.PodFailureReporter("wait")("core", "core-abc", "ImagePullBackOff")
+
+	want := "  wait: deployment \"core\": 1 of 2 replicas ready\n" +
+		"  wait: deployment \"core\": pod core-abc: ImagePullBackOff\n"
+	if buf.String() != want {
+		t.Errorf("want %q, got %q", want, buf.String())
+	}
+}
+
+func TestFromFlags(t *testing.T) {
+	var buf bytes.Buffer
+	fromFlags := func(args ...string) (*Tracker, error) {
+		t.Helper()
+		cmd := &cobra.Command{}
+		cmd.Flags().Bool("quiet", false, "")
+		BindFlag(cmd)
+		cmd.SetErr(&buf)
+		if err := cmd.ParseFlags(args); err != nil {
+			t.Fatal(err)
+		}
+		return FromFlags(cmd)
+	}
+
+	tracker, err := fromFlags("--progress", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker.Report("step", "message", 0, 0)
+	if !strings.HasPrefix(buf.String(), "{") {
+		t.Errorf("expected a json event on stderr, got %q", buf.String())
+	}
+
+	buf.Reset()
+	tracker, err = fromFlags("--progress", "json", "--quiet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker.Report("step", "message", 0, 0)
+	if buf.Len() != 0 {
+		t.Errorf("expected no output with --quiet, got %q", buf.String())
+	}
+
+	if _, err := fromFlags("--progress", "xml"); err == nil {
+		t.Error("expected invalid progress mode error")
+	}
+}
@@ -0,0 +1,148 @@
+// Package protection guards business-critical resources from accidental
+// deletion. Protected resources refuse to be deleted, alone or in bulk,
+// unless --override-protection is given.
+//
+// Core instances are protected with a label on their tags. Pipelines, whose
+// tags cannot be updated, are protected with a key on their metadata; the
+// label is honored on pipelines too so they can be protected on creation.
+package protection
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	cloud "github.com/calyptia/api/types"
+	"github.com/calyptia/cli/labels"
+)
+
+const (
+	// Label stored on the resource tags, and key stored on the pipeline
+	// metadata, marking it as protected.
+	Label = "protected"
+
+	protectFlag   = "protect"
+	unprotectFlag = "unprotect"
+	overrideFlag  = "override-protection"
+)
+
+// BindFlags adds the --protect and --unprotect flags to an update command.
+func BindFlags(cmd *cobra.Command) {
+	fs := cmd.Flags()
+	fs.Bool(protectFlag, false, "Protect from deletion. Deleting it will require --override-protection")
+	fs.Bool(unprotectFlag, false, "Remove the protection from deletion")
+	cmd.MarkFlagsMutuallyExclusive(protectFlag, unprotectFlag)
+}
+
+// FromFlags returns whether the resource should be protected or
+// unprotected, or nil if neither --protect nor --unprotect were given.
+func FromFlags(cmd *cobra.Command) (*bool, error) {
+	fs := cmd.Flags()
+	protect, err := fs.GetBool(protectFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	unprotect, err := fs.GetBool(unprotectFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case protect:
+		return &protect, nil
+	case unprotect:
+		protect = false
+		return &protect, nil
+	}
+
+	return nil, nil
+}
+
+// Tag returns the tag that labels a resource as protected or not.
+func Tag(protected bool) string {
+	return fmt.Sprintf("%s=%t", Label, protected)
+}
+
+// WithMetadata returns the pipeline metadata with the protection set,
+// or removed if not protected.
+func WithMetadata(metadata *json.RawMessage, protected bool) (*json.RawMessage, error) {
+	m := map[string]any{}
+	if metadata != nil {
+		if err := json.Unmarshal(*metadata, &m); err != nil {
+			return nil, fmt.Errorf("could not set protection on metadata: %w", err)
+		}
+	}
+
+	if protected {
+		m[Label] = true
+	} else {
+		delete(m, Label)
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	out := json.RawMessage(b)
+	return &out, nil
+}
+
+// IsProtected reports whether the given resource tags carry the protection label.
+func IsProtected(tags []string) bool {
+	return labels.FromTags(tags)[Label] == "true"
+}
+
+// IsPipelineProtected reports whether the pipeline is protected
+// either on its tags or on its metadata.
+func IsPipelineProtected(p cloud.Pipeline) bool {
+	if IsProtected(p.Tags) {
+		return true
+	}
+
+	if p.Metadata == nil {
+		return false
+	}
+
+	var metadata map[string]any
+	if err := json.Unmarshal(*p.Metadata, &metadata); err != nil {
+		return false
+	}
+
+	protected, _ := metadata[Label].(bool)
+	return protected
+}
+
+// BindOverrideFlag adds the --override-protection flag to a delete command.
+func BindOverrideFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool(overrideFlag, false, "Delete even if protected")
+}
+
+// Overridden reports whether --override-protection was given.
+func Overridden(cmd *cobra.Command) bool {
+	ok, _ := cmd.Flags().GetBool(overrideFlag)
+	return ok
+}
+
+// ProtectedError is returned when trying to delete a protected resource.
+type ProtectedError struct {
+	Kind string
+	Name string
+}
+
+func (e *ProtectedError) Error() string {
+	return fmt.Sprintf("%s %q is protected from deletion, pass --%s to delete it anyway or update it with --%s first",
+		e.Kind, e.Name, overrideFlag, unprotectFlag)
+}
+
+// Check returns a ProtectedError if the resource is protected
+// and --override-protection was not given.
+func Check(cmd *cobra.Command, kind, name string, protected bool) error {
+	if !protected || Overridden(cmd) {
+		return nil
+	}
+
+	return &ProtectedError{Kind: kind, Name: name}
+}
@@ -0,0 +1,97 @@
+package protection
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	cloud "github.com/calyptia/api/types"
+)
+
+func TestFromFlags(t *testing.T) {
+	tt := []struct {
+		args []string
+		want *bool
+	}{
+		{args: nil, want: nil},
+		{args: []string{"--protect"}, want: ptr(true)},
+		{args: []string{"--unprotect"}, want: ptr(false)},
+	}
+
+	for _, tc := range tt {
+		cmd := &cobra.Command{}
+		BindFlags(cmd)
+		if err := cmd.ParseFlags(tc.args); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := FromFlags(cmd)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if (got == nil) != (tc.want == nil) || (got != nil && *got != *tc.want) {
+			t.Errorf("args %v: want %v, got %v", tc.args, tc.want, got)
+		}
+	}
+}
+
+func TestWithMetadata(t *testing.T) {
+	metadata := json.RawMessage(`{"team":"a"}`)
+
+	got, err := WithMetadata(&metadata, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `{"protected":true,"team":"a"}`; string(*got) != want {
+		t.Errorf("want %s, got %s", want, *got)
+	}
+
+	if !IsPipelineProtected(cloud.Pipeline{Metadata: got}) {
+		t.Error("expected pipeline to be protected")
+	}
+
+	got, err = WithMetadata(got, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `{"team":"a"}`; string(*got) != want {
+		t.Errorf("want %s, got %s", want, *got)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	newCmd := func(args ...string) *cobra.Command {
+		cmd := &cobra.Command{}
+		BindOverrideFlag(cmd)
+		if err := cmd.ParseFlags(args); err != nil {
+			t.Fatal(err)
+		}
+		return cmd
+	}
+
+	if !IsProtected([]string{"team=a", Tag(true)}) || IsProtected([]string{Tag(false)}) {
+		t.Fatal("unexpected protection from tags")
+	}
+
+	var protectedErr *ProtectedError
+	if err := Check(newCmd(), "pipeline", "ingest", true); !errors.As(err, &protectedErr) {
+		t.Errorf("expected protected error, got %v", err)
+	}
+
+	if err := Check(newCmd("--override-protection"), "pipeline", "ingest", true); err != nil {
+		t.Errorf("expected protection to be overridden, got %v", err)
+	}
+
+	if err := Check(newCmd(), "pipeline", "ingest", false); err != nil {
+		t.Errorf("expected no error on unprotected resource, got %v", err)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
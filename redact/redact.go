@@ -0,0 +1,223 @@
+// Package redact hides the values of secret looking keys, like tokens,
+// passwords and keys, from the CLI output so they do not end up in CI logs.
+// Redaction is on by default and gets disabled with the global --show-secrets flag.
+package redact
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Placeholder replaces the redacted values.
+const Placeholder = "REDACTED"
+
+const flagName = "show-secrets"
+
+var (
+	secretKeyPattern = regexp.MustCompile(`(?i)(token|passw(or)?d|secret|credential|(api|access|private|shared|signing|license|auth|client|encryption|rsa)[_.-]?key)`)
+	// references to secrets, like paths to files holding them, are not secret.
+	notSecretKeyPattern = regexp.MustCompile(`(?i)(file|path|name|ref|_id|type)$`)
+	// values referencing a secret, ie: {{ secrets.token }} or ${TOKEN}.
+	referencePattern = regexp.MustCompile(`^("?)(\{\{.*\}\}|\$\{[^}]*\})("?)$`)
+
+	setLinePattern  = regexp.MustCompile(`^(\s*@SET\s+([\w.\-]+)\s*=\s*)(.*?)(\s*)$`)
+	yamlLinePattern = regexp.MustCompile(`^(\s*(?:-\s+)?"?([\w.\-]+)"?\s*:\s+)(.*?)(\s*,?\s*)$`)
+	iniLinePattern  = regexp.MustCompile(`^(\s*([\w.\-]+)\s+)(.*?)(\s*)$`)
+)
+
+// nonSecretFields are the fields describing the objects within a secret key,
+// ie: the ID and key of a pipeline secret, or a page of them,
+// kept along with their value redacted.
+var nonSecretFields = map[string]bool{
+	"id":        true,
+	"key":       true,
+	"name":      true,
+	"kind":      true,
+	"createdAt": true,
+	"updatedAt": true,
+	"endCursor": true,
+	"count":     true,
+}
+
+// BindFlag adds the global --show-secrets flag.
+func BindFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().Bool(flagName, false, "Print tokens, passwords, keys and other secret looking values instead of redacting them")
+}
+
+// Redactor redacts the output of a command.
+// The zero value redacts.
+type Redactor struct {
+	ShowSecrets bool
+}
+
+// FromFlags returns the redactor for the --show-secrets flag.
+// Commands without the flag redact.
+func FromFlags(cmd *cobra.Command) Redactor {
+	show, _ := cmd.Flags().GetBool(flagName)
+	return Redactor{ShowSecrets: show}
+}
+
+// IsSecretKey reports whether the value of the given key should be redacted.
+func IsSecretKey(key string) bool {
+	return secretKeyPattern.MatchString(key) && !notSecretKeyPattern.MatchString(key)
+}
+
+// Config redacts the values of secret keys from a fluent-bit config,
+// classic, YAML or JSON. References like {{ secrets.name }} are kept.
+func (r Redactor) Config(raw string) string {
+	if r.ShowSecrets {
+		return raw
+	}
+
+	lines := strings.Split(raw, "\n")
+	for i, line := range lines {
+		if prefix, value, suffix, ok := splitConfigLine(line); ok && isRedactable(value) {
+			lines[i] = prefix + redactedLike(value) + suffix
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// HasRedactedValues reports whether a config has secret keys with the
+// placeholder as value, ie: got with redaction and edited back.
+func HasRedactedValues(raw string) bool {
+	for _, line := range strings.Split(raw, "\n") {
+		if _, value, _, ok := splitConfigLine(line); ok && strings.Trim(value, `"`) == Placeholder {
+			return true
+		}
+	}
+	return false
+}
+
+// splitConfigLine returns the value of a config line setting a secret key,
+// along with what comes before and after it.
+func splitConfigLine(line string) (prefix, value, suffix string, ok bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "[") {
+		return "", "", "", false
+	}
+
+	for _, pattern := range []*regexp.Regexp{setLinePattern, yamlLinePattern, iniLinePattern} {
+		m := pattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		if !IsSecretKey(m[2]) {
+			return "", "", "", false
+		}
+
+		return m[1], m[3], m[4], true
+	}
+
+	return "", "", "", false
+}
+
+func isRedactable(value string) bool {
+	if value == "" || value == `""` || strings.Trim(value, `"`) == Placeholder {
+		return false
+	}
+	return !referencePattern.MatchString(value)
+}
+
+// redactedLike returns the placeholder, quoted if the value was.
+func redactedLike(value string) string {
+	if len(value) > 1 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		return `"` + Placeholder + `"`
+	}
+	return Placeholder
+}
+
+// Value redacts the secrets of a value about to be printed as JSON or YAML:
+// values of secret keys, values of name/value pairs with a secret name,
+// like kubernetes env vars, and the secret values within raw configs.
+// The value is returned as is when it has nothing to redact, or when it
+// cannot be converted, so the encoder reports the error.
+func (r Redactor) Value(v any) any {
+	return r.value(v, false)
+}
+
+// Secrets redacts a value holding secrets, like a list of pipeline secrets,
+// keeping only the fields that describe them, like their ID and key.
+func (r Redactor) Secrets(v any) any {
+	return r.value(v, true)
+}
+
+func (r Redactor) value(v any, secret bool) any {
+	if r.ShowSecrets {
+		return v
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+
+	var generic any
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return v
+	}
+
+	out, changed := r.walk(generic, secret)
+	if !changed {
+		return v
+	}
+	return out
+}
+
+// walk redacts the given decoded JSON value.
+// Scalars within a secret key get redacted, and so do the fields of the
+// objects within it but nonSecretFields, ie: the values of the pipeline secrets.
+func (r Redactor) walk(v any, secret bool) (any, bool) {
+	switch v := v.(type) {
+	case map[string]any:
+		pairName, _ := v["name"].(string)
+		if pairName == "" {
+			pairName, _ = v["key"].(string)
+		}
+		kind, _ := v["kind"].(string)
+
+		var changed bool
+		for k, item := range v {
+			var c bool
+			switch {
+			case k == "rawConfig" && !secret:
+				if s, ok := item.(string); ok {
+					v[k] = r.Config(s)
+					c = v[k] != s
+				}
+			case k == "value":
+				v[k], c = r.walk(item, secret || (pairName != "" && IsSecretKey(pairName)))
+			case kind == "Secret" && (k == "data" || k == "stringData"):
+				v[k], c = r.walk(item, true)
+			default:
+				v[k], c = r.walk(item, IsSecretKey(k) || (secret && !nonSecretFields[k]))
+			}
+			changed = changed || c
+		}
+		return v, changed
+	case []any:
+		var changed bool
+		for i, item := range v {
+			var c bool
+			v[i], c = r.walk(item, secret)
+			changed = changed || c
+		}
+		return v, changed
+	case string:
+		if secret && isRedactable(v) {
+			return Placeholder, true
+		}
+		return v, false
+	case float64, bool:
+		if secret {
+			return Placeholder, true
+		}
+		return v, false
+	default:
+		return v, false
+	}
+}
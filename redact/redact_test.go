@@ -0,0 +1,166 @@
+package redact
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestIsSecretKey(t *testing.T) {
+	tt := []struct {
+		key  string
+		want bool
+	}{
+		{"http_passwd", true},
+		{"Password", true},
+		{"api_key", true},
+		{"AWS_SECRET_ACCESS_KEY", true},
+		{"PROJECT_TOKEN", true},
+		{"privateRSAKey", true},
+		{"tls.key_file", false},
+		{"token_file", false},
+		{"Key_Name", false},
+		{"Time_Key", false},
+		{"secretName", false},
+		{"host", false},
+	}
+	for _, tc := range tt {
+		if got := IsSecretKey(tc.key); got != tc.want {
+			t.Errorf("IsSecretKey(%q) = %v, want %v", tc.key, got, tc.want)
+		}
+	}
+}
+
+func TestRedactor_Config(t *testing.T) {
+	tt := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{
+			name: "classic",
+			raw:  "@SET token=abc\n[OUTPUT]\n    Name        http\n    http_Passwd s3cr3t\n    Passwd_File /etc/passwd\n    api_key     {{ secrets.api_key }}\n",
+			want: "@SET token=REDACTED\n[OUTPUT]\n    Name        http\n    http_Passwd REDACTED\n    Passwd_File /etc/passwd\n    api_key     {{ secrets.api_key }}\n",
+		},
+		{
+			name: "yaml",
+			raw:  "env:\n  API_TOKEN: abc\npipeline:\n  outputs:\n    - name: http\n      http_passwd: \"s3cr3t\"\n      host: example.org\n",
+			want: "env:\n  API_TOKEN: REDACTED\npipeline:\n  outputs:\n    - name: http\n      http_passwd: \"REDACTED\"\n      host: example.org\n",
+		},
+		{
+			name: "json",
+			raw:  "{\n  \"http_passwd\": \"s3cr3t\",\n  \"host\": \"example.org\"\n}",
+			want: "{\n  \"http_passwd\": \"REDACTED\",\n  \"host\": \"example.org\"\n}",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Redactor{}.Config(tc.raw)
+			if got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+
+			if !HasRedactedValues(got) {
+				t.Error("expected redacted values to be detected")
+			}
+
+			if got := (Redactor{ShowSecrets: true}).Config(tc.raw); got != tc.raw {
+				t.Errorf("expected config as is with show secrets, got:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestRedactor_Value(t *testing.T) {
+	type secret struct {
+		ID    string `json:"id"`
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+	type pipeline struct {
+		Name   string `json:"name"`
+		Token  string `json:"token"`
+		Config struct {
+			RawConfig string `json:"rawConfig"`
+		} `json:"config"`
+		Secrets []secret `json:"secrets"`
+		Env     []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"env"`
+	}
+
+	var in pipeline
+	in.Name = "logs"
+	in.Token = "abc"
+	in.Config.RawConfig = "[OUTPUT]\n    http_passwd s3cr3t"
+	in.Secrets = []secret{{ID: "1", Key: "FOO", Value: "bar"}}
+	in.Env = append(in.Env, struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}{Name: "PROJECT_TOKEN", Value: "abc"}, struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}{Name: "NAMESPACE", Value: "default"})
+
+	b, err := json.Marshal(Redactor{}.Value(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"config":{"rawConfig":"[OUTPUT]\n    http_passwd REDACTED"},"env":[{"name":"PROJECT_TOKEN","value":"REDACTED"},{"name":"NAMESPACE","value":"default"}],"name":"logs","secrets":[{"id":"1","key":"FOO","value":"REDACTED"}],"token":"REDACTED"}`
+	if string(b) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b, want)
+	}
+
+	plain := struct {
+		Name string `json:"name"`
+	}{Name: "logs"}
+	if got := (Redactor{}).Value(plain); got != any(plain) {
+		t.Errorf("expected value without secrets as is, got %#v", got)
+	}
+
+	if got := (Redactor{ShowSecrets: true}).Value(in); got.(pipeline).Token != "abc" {
+		t.Errorf("expected value as is with show secrets, got %#v", got)
+	}
+}
+
+func TestRedactor_Secrets(t *testing.T) {
+	type secret struct {
+		ID    string `json:"id"`
+		Key   string `json:"key"`
+		Value []byte `json:"value"`
+	}
+	page := struct {
+		Items     []secret `json:"items"`
+		EndCursor *string  `json:"endCursor"`
+		Count     int      `json:"count"`
+	}{Items: []secret{{ID: "1", Key: "FOO", Value: []byte("bar")}}, Count: 1}
+
+	b, err := json.Marshal(Redactor{}.Secrets(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"count":1,"endCursor":null,"items":[{"id":"1","key":"FOO","value":"REDACTED"}]}`
+	if string(b) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b, want)
+	}
+}
+
+func TestFromFlags(t *testing.T) {
+	if FromFlags(&cobra.Command{}).ShowSecrets {
+		t.Error("expected commands without the flag to redact")
+	}
+
+	cmd := &cobra.Command{}
+	BindFlag(cmd)
+	if err := cmd.ParseFlags([]string{"--show-secrets"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !FromFlags(cmd).ShowSecrets {
+		t.Error("expected --show-secrets to disable redaction")
+	}
+}
@@ -0,0 +1,263 @@
+// Package state records the resources created by the CLI, along with the
+// command that created them, so everything a demo or test run created can be
+// listed with `calyptia state list` and deleted with `calyptia destroy --from-state`.
+//
+// Recording is optional: it only happens when a state file is set with
+// --state-file or CALYPTIA_STATE_FILE. The file is encrypted with AES-GCM
+// using a key kept in local data, so it can be shared between runs on the
+// same machine but is unreadable anywhere else.
+package state
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/calyptia/cli/localdata"
+)
+
+const (
+	// KeyEncryption is the local data key where the state encryption key is stored.
+	KeyEncryption = "state_encryption_key"
+	// EnvFile is the environment variable setting the default --state-file.
+	EnvFile = "CALYPTIA_STATE_FILE"
+
+	KindCoreInstance  = "core_instance"
+	KindEnvironment   = "environment"
+	KindIngestCheck   = "ingest_check"
+	KindPipeline      = "pipeline"
+	KindTraceSession  = "trace_session"
+	KindConfigSection = "config_section"
+
+	flagName = "state-file"
+)
+
+// Resource is a resource created by the CLI.
+type Resource struct {
+	Kind      string `json:"kind" yaml:"kind"`
+	ID        string `json:"id" yaml:"id"`
+	Name      string `json:"name,omitempty" yaml:"name,omitempty"`
+	ProjectID string `json:"projectID" yaml:"projectID"`
+	// ParentID is the core instance of a pipeline or ingest check,
+	// or the pipeline of a trace session.
+	ParentID string `json:"parentID,omitempty" yaml:"parentID,omitempty"`
+	// Command is the command path that created the resource, ie: "calyptia create pipeline".
+	Command   string    `json:"command" yaml:"command"`
+	CreatedAt time.Time `json:"createdAt" yaml:"createdAt"`
+}
+
+// File is the encrypted local state file.
+// The zero value, with no path, is disabled: nothing gets recorded.
+type File struct {
+	Path      string
+	LocalData *localdata.Keyring
+}
+
+// BindFlag adds the global --state-file flag.
+func BindFlag(cmd *cobra.Command, f *File) {
+	cmd.PersistentFlags().StringVar(&f.Path, flagName, os.Getenv(EnvFile), "Encrypted file to record the created resources into, so they can be deleted with `calyptia destroy --from-state`")
+}
+
+// Enabled reports whether a state file is set.
+func (f *File) Enabled() bool {
+	return f != nil && f.Path != ""
+}
+
+// Load the resources recorded in the state file.
+// A missing file has no resources.
+func (f *File) Load() ([]Resource, error) {
+	if !f.Enabled() {
+		return nil, errors.New("no state file set, use --state-file or " + EnvFile)
+	}
+
+	b, err := os.ReadFile(f.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read state file: %w", err)
+	}
+
+	aead, err := f.cipher(false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) < aead.NonceSize() {
+		return nil, errors.New("could not decrypt state file: too short")
+	}
+
+	nonce, ciphertext := b[:aead.NonceSize()], b[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt state file: %w", err)
+	}
+
+	var out []Resource
+	if err := json.Unmarshal(plaintext, &out); err != nil {
+		return nil, fmt.Errorf("could not parse state file: %w", err)
+	}
+
+	return out, nil
+}
+
+// Save replaces the resources recorded in the state file.
+// The file is removed when there are no resources left.
+func (f *File) Save(resources []Resource) error {
+	if !f.Enabled() {
+		return errors.New("no state file set, use --state-file or " + EnvFile)
+	}
+
+	if len(resources) == 0 {
+		err := os.Remove(f.Path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	sort.SliceStable(resources, func(i, j int) bool {
+		return resources[i].CreatedAt.Before(resources[j].CreatedAt)
+	})
+
+	plaintext, err := json.Marshal(resources)
+	if err != nil {
+		return err
+	}
+
+	aead, err := f.cipher(true)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("could not generate nonce: %w", err)
+	}
+
+	if dir := filepath.Dir(f.Path); dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return fmt.Errorf("could not create state directory %q: %w", dir, err)
+		}
+	}
+
+	// write and rename so an interrupted command does not corrupt the state.
+	tmp := f.Path + ".tmp"
+	if err := os.WriteFile(tmp, aead.Seal(nonce, nonce, plaintext, nil), 0o600); err != nil {
+		return fmt.Errorf("could not write state file: %w", err)
+	}
+
+	if err := os.Rename(tmp, f.Path); err != nil {
+		return fmt.Errorf("could not write state file: %w", err)
+	}
+
+	return nil
+}
+
+// Track records the given resource, created by cmd, in the state file.
+// It does nothing when no state file is set.
+func (f *File) Track(cmd *cobra.Command, r Resource) error {
+	if !f.Enabled() {
+		return nil
+	}
+
+	if r.Command == "" {
+		r.Command = cmd.CommandPath()
+	}
+
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now().Truncate(time.Second)
+	}
+
+	resources, err := f.Load()
+	if err != nil {
+		return err
+	}
+
+	if err := f.Save(append(Forget(resources, r), r)); err != nil {
+		return fmt.Errorf("could not record %s in state: %w", r.Kind, err)
+	}
+
+	return nil
+}
+
+// cipher returns the AEAD sealing the state file,
+// generating and storing its key on first use when create is true.
+func (f *File) cipher(create bool) (cipher.AEAD, error) {
+	if f.LocalData == nil {
+		return nil, errors.New("no local data to store the state encryption key")
+	}
+
+	encoded, err := f.LocalData.Get(KeyEncryption)
+	if errors.Is(err, localdata.ErrNotFound) && create {
+		key := make([]byte, 32)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return nil, fmt.Errorf("could not generate state encryption key: %w", err)
+		}
+
+		encoded = base64.StdEncoding.EncodeToString(key)
+		err = f.LocalData.Save(KeyEncryption, encoded)
+	}
+
+	if errors.Is(err, localdata.ErrNotFound) {
+		return nil, errors.New("state encryption key not found, the state file was created on another machine")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve state encryption key: %w", err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state encryption key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state encryption key: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// Forget returns the resources without the given ones.
+func Forget(resources []Resource, forget ...Resource) []Resource {
+	var out []Resource
+	for _, r := range resources {
+		var found bool
+		for _, f := range forget {
+			if r.Kind == f.Kind && r.ID == f.ID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// OfProject returns the resources created on the given project.
+func OfProject(resources []Resource, projectID string) []Resource {
+	var out []Resource
+	for _, r := range resources {
+		if r.ProjectID == projectID {
+			out = append(out, r)
+		}
+	}
+	return out
+}
@@ -0,0 +1,87 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zalando/go-keyring"
+
+	"github.com/calyptia/cli/localdata"
+)
+
+func TestFile_Track(t *testing.T) {
+	keyring.MockInit()
+
+	path := filepath.Join(t.TempDir(), "demo.state")
+	f := &File{Path: path, LocalData: localdata.New("state-test", t.TempDir())}
+	cmd := &cobra.Command{Use: "pipeline"}
+
+	now := time.Now().Truncate(time.Second)
+	instance := Resource{Kind: KindCoreInstance, ID: "instance-1", Name: "demo", ProjectID: "project-a", CreatedAt: now.Add(-time.Minute)}
+	pipeline := Resource{Kind: KindPipeline, ID: "pipeline-1", Name: "demo", ProjectID: "project-a", ParentID: "instance-1", CreatedAt: now}
+	other := Resource{Kind: KindPipeline, ID: "pipeline-2", ProjectID: "project-b", CreatedAt: now}
+
+	for _, r := range []Resource{pipeline, instance, other, pipeline} {
+		if err := f.Track(cmd, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(b), "pipeline-1") {
+		t.Error("expected state file to be encrypted")
+	}
+
+	got, err := f.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 3 || got[0].ID != "instance-1" {
+		t.Fatalf("expected 3 resources sorted by creation, got %+v", got)
+	}
+
+	if got[1].Command != "pipeline" {
+		t.Errorf("expected the creating command to be recorded, got %q", got[1].Command)
+	}
+
+	if project := OfProject(got, "project-a"); len(project) != 2 {
+		t.Errorf("expected 2 resources of project-a, got %+v", project)
+	}
+
+	if err := f.Save(Forget(got, got...)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected empty state file to be removed, got %v", err)
+	}
+
+	foreign := &File{Path: path, LocalData: localdata.New("state-test-other", t.TempDir())}
+	if err := f.Track(cmd, pipeline); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := foreign.Load(); err == nil {
+		t.Error("expected state file to be unreadable without its key")
+	}
+}
+
+func TestFile_Disabled(t *testing.T) {
+	var f File
+	if err := f.Track(&cobra.Command{}, Resource{Kind: KindPipeline, ID: "pipeline-1"}); err != nil {
+		t.Errorf("expected tracking without state file to be a no-op, got %v", err)
+	}
+
+	if _, err := f.Load(); err == nil {
+		t.Error("expected loading without state file to fail")
+	}
+}
@@ -0,0 +1,168 @@
+// Package ttl tracks temporary resources created with --ttl so they get
+// deleted once expired by `calyptia gc`.
+//
+// Every temporary resource is recorded in local data. Resources that
+// support tags, like pipelines, also carry their expiry as a label so they
+// can be collected from any machine with access to the project.
+package ttl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/calyptia/cli/labels"
+	"github.com/calyptia/cli/localdata"
+)
+
+const (
+	// Label stored on the resource tags holding its expiry as unix seconds.
+	Label = "expires-at"
+	// KeyResources is the local data key where temporary resources are recorded.
+	KeyResources = "temporary_resources"
+
+	KindPipeline     = "pipeline"
+	KindTraceSession = "trace_session"
+
+	flagName = "ttl"
+)
+
+// Resource is a temporary resource to delete once expired.
+type Resource struct {
+	Kind      string `json:"kind" yaml:"kind"`
+	ID        string `json:"id" yaml:"id"`
+	Name      string `json:"name,omitempty" yaml:"name,omitempty"`
+	ProjectID string `json:"projectID" yaml:"projectID"`
+	// ParentID is the pipeline of a trace session.
+	ParentID  string    `json:"parentID,omitempty" yaml:"parentID,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt" yaml:"expiresAt"`
+}
+
+// BindFlag adds the --ttl flag to a create command.
+func BindFlag(cmd *cobra.Command) {
+	cmd.Flags().Duration(flagName, 0, "Time to live. Once expired the resource gets deleted by `calyptia gc`. 0 means it never expires")
+}
+
+// FromFlags returns the expiry set with --ttl relative to now,
+// or a zero time if not set.
+func FromFlags(cmd *cobra.Command, now time.Time) (time.Time, error) {
+	d, err := cmd.Flags().GetDuration(flagName)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if d < 0 {
+		return time.Time{}, fmt.Errorf("invalid ttl %s, it must be positive", d)
+	}
+
+	if d == 0 {
+		return time.Time{}, nil
+	}
+
+	return now.Add(d).Truncate(time.Second), nil
+}
+
+// Tag returns the tag that labels a resource with the given expiry.
+func Tag(expiresAt time.Time) string {
+	return Label + "=" + strconv.FormatInt(expiresAt.Unix(), 10)
+}
+
+// ExpiresAt returns the expiry stored on the given resource tags.
+func ExpiresAt(tags []string) (time.Time, bool) {
+	v, ok := labels.FromTags(tags)[Label]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	sec, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(sec, 0), true
+}
+
+// Load the temporary resources recorded in local data.
+func Load(data *localdata.Keyring) ([]Resource, error) {
+	s, err := data.Get(KeyResources)
+	if errors.Is(err, localdata.ErrNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve your temporary resources: %w", err)
+	}
+
+	var out []Resource
+	if err := json.Unmarshal([]byte(s), &out); err != nil {
+		return nil, fmt.Errorf("could not parse your temporary resources: %w", err)
+	}
+
+	return out, nil
+}
+
+// Save replaces the temporary resources recorded in local data.
+func Save(data *localdata.Keyring, resources []Resource) error {
+	if len(resources) == 0 {
+		err := data.Delete(KeyResources)
+		if errors.Is(err, localdata.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	sort.SliceStable(resources, func(i, j int) bool {
+		return resources[i].ExpiresAt.Before(resources[j].ExpiresAt)
+	})
+
+	b, err := json.Marshal(resources)
+	if err != nil {
+		return err
+	}
+
+	return data.Save(KeyResources, string(b))
+}
+
+// Track records the given temporary resource in local data.
+func Track(data *localdata.Keyring, r Resource) error {
+	resources, err := Load(data)
+	if err != nil {
+		return err
+	}
+
+	return Save(data, append(Forget(resources, r), r))
+}
+
+// Forget returns the resources without the given ones.
+func Forget(resources []Resource, forget ...Resource) []Resource {
+	var out []Resource
+	for _, r := range resources {
+		var found bool
+		for _, f := range forget {
+			if r.Kind == f.Kind && r.ID == f.ID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Expired returns the resources of the given project expired by now.
+func Expired(resources []Resource, projectID string, now time.Time) []Resource {
+	var out []Resource
+	for _, r := range resources {
+		if r.ProjectID == projectID && !r.ExpiresAt.After(now) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
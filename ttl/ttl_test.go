@@ -0,0 +1,85 @@
+package ttl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zalando/go-keyring"
+
+	"github.com/calyptia/cli/localdata"
+)
+
+func TestFromFlags(t *testing.T) {
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	cmd := &cobra.Command{}
+	BindFlag(cmd)
+
+	got, err := FromFlags(cmd, now)
+	if err != nil || !got.IsZero() {
+		t.Fatalf("expected no expiry, got %v, %v", got, err)
+	}
+
+	if err := cmd.Flags().Set("ttl", "2h"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err = FromFlags(cmd, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := now.Add(2 * time.Hour); !got.Equal(want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	expiresAt, ok := ExpiresAt([]string{"team=core", Tag(got)})
+	if !ok || !expiresAt.Equal(got) {
+		t.Errorf("expected expiry %v from tags, got %v", got, expiresAt)
+	}
+
+	if _, ok := ExpiresAt([]string{Label + "=tomorrow"}); ok {
+		t.Error("expected invalid expiry label to be ignored")
+	}
+}
+
+func TestTrack(t *testing.T) {
+	keyring.MockInit()
+
+	now := time.Now().Truncate(time.Second)
+	data := localdata.New("ttl-test", t.TempDir())
+
+	pipeline := Resource{Kind: KindPipeline, ID: "pipeline-1", ProjectID: "project-a", ExpiresAt: now.Add(-time.Minute)}
+	session := Resource{Kind: KindTraceSession, ID: "session-1", ProjectID: "project-a", ParentID: "pipeline-1", ExpiresAt: now.Add(time.Hour)}
+	other := Resource{Kind: KindPipeline, ID: "pipeline-2", ProjectID: "project-b", ExpiresAt: now.Add(-time.Hour)}
+
+	for _, r := range []Resource{session, pipeline, other, pipeline} {
+		if err := Track(data, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := Load(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("expected 3 resources recorded once each, got %+v", got)
+	}
+
+	expired := Expired(got, "project-a", now)
+	if len(expired) != 1 || expired[0].ID != pipeline.ID {
+		t.Errorf("expected only %q to be expired, got %+v", pipeline.ID, expired)
+	}
+
+	if err := Save(data, Forget(got, got...)); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err = Load(data)
+	if err != nil || len(got) != 0 {
+		t.Errorf("expected no resources left, got %+v, %v", got, err)
+	}
+}